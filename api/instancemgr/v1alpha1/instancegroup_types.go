@@ -16,9 +16,15 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/url"
+	"path"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/keikoproj/instance-manager/controllers/common"
@@ -27,7 +33,9 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -63,11 +71,56 @@ const (
 	CRDStrategyName           = "crd"
 	RollingUpdateStrategyName = "rollingupdate"
 	ManagedStrategyName       = "managed"
+	BlueGreenStrategyName     = "bluegreen"
 	EKSProvisionerName        = "eks"
 	EKSManagedProvisionerName = "eks-managed"
 	EKSFargateProvisionerName = "eks-fargate"
 
-	NodesReady InstanceGroupConditionType = "NodesReady"
+	NodesReady                  InstanceGroupConditionType = "NodesReady"
+	NodesStartupTaintTimeout    InstanceGroupConditionType = "NodesStartupTaintTimeout"
+	NodesFailedToJoin           InstanceGroupConditionType = "NodesFailedToJoin"
+	UserDataValidationFailed    InstanceGroupConditionType = "UserDataValidationFailed"
+	WaitingForMaintenanceWindow InstanceGroupConditionType = "WaitingForMaintenanceWindow"
+	RotationUnschedulable       InstanceGroupConditionType = "RotationUnschedulable"
+	WaitingForDependencies      InstanceGroupConditionType = "WaitingForDependencies"
+	UnhealthyInstancesProtected InstanceGroupConditionType = "UnhealthyInstancesProtected"
+	RotationDenied              InstanceGroupConditionType = "RotationDenied"
+	BlueGreenRolledBack         InstanceGroupConditionType = "BlueGreenRolledBack"
+	ImageNotAllowed             InstanceGroupConditionType = "ImageNotAllowed"
+
+	// BlueGreenPhaseProvisioning is the phase of a blue/green rotation while it waits for the green nodes to be ready
+	BlueGreenPhaseProvisioning = "ProvisioningGreen"
+	// BlueGreenPhaseRetiring is the phase of a blue/green rotation while the cordoned blue nodes are drained and removed
+	BlueGreenPhaseRetiring = "RetiringBlue"
+	// BlueGreenPhaseRolledBack is the phase of a blue/green rotation whose green nodes did not become ready
+	BlueGreenPhaseRolledBack = "RolledBack"
+
+	DefaultBlueGreenReadyTimeout = 15 * time.Minute
+
+	MaintenanceWindowTimeFormat = "15:04"
+
+	DefaultStartupTaintTimeout = 10 * time.Minute
+	DefaultInitialGracePeriod  = 5 * time.Minute
+
+	// DefaultInstanceStorageMountPath is where the instance store RAID0 array is mounted by default
+	DefaultInstanceStorageMountPath = "/var/lib/containerd"
+
+	// DefaultCABundleKey is the ConfigMap key a CA bundle is read from by default
+	DefaultCABundleKey = "ca-bundle.crt"
+
+	// the kubelet's default image garbage collection thresholds in percent of disk usage
+	DefaultImageGCHighThreshold = int64(85)
+	DefaultImageGCLowThreshold  = int64(80)
+	// MaxParallelImagePullsLimit bounds how many images the kubelet of a node can pull at once
+	MaxParallelImagePullsLimit = int64(100)
+
+	// DefaultBootstrapRetryBackoff is the delay before the first retry of a failed bootstrap operation
+	DefaultBootstrapRetryBackoff = 5 * time.Second
+	// MaxBootstrapRetryAttempts bounds how many times a bootstrap operation is attempted
+	MaxBootstrapRetryAttempts = int64(10)
+
+	// DefaultScalingGroupCooldown is the default cooldown of auto scaling groups in seconds
+	DefaultScalingGroupCooldown = int64(300)
 
 	ForbidConcurrencyPolicy  = "forbid"
 	AllowConcurrencyPolicy   = "allow"
@@ -76,12 +129,54 @@ const (
 	FileSystemTypeXFS  = "xfs"
 	FileSystemTypeEXT4 = "ext4"
 
+	InstanceStoragePolicyPreferInstanceStore = "prefer-instance-store"
+	InstanceStoragePolicyEBSOnly             = "ebs-only"
+
+	// unhealthy instances protected from scale in are either reported for an operator to act on, or their protection is
+	// cleared so the scaling group can replace them
+	UnhealthyProtectedPolicyReport          = "report"
+	UnhealthyProtectedPolicyClearProtection = "clear-protection"
+
+	// nodes which report an unhealthy node condition are cordoned until they are rotated
+	UnhealthyNodeActionCordon = "Cordon"
+
+	// capacity type enforcement rejects configurations which would launch a disallowed capacity type
+	CapacityTypeEnforcementSpotOnly     = "spot-only"
+	CapacityTypeEnforcementOnDemandOnly = "on-demand-only"
+	CapacityTypeEnforcementMixed        = "mixed"
+
+	NetworkInterfaceTypeInterface = "interface"
+	NetworkInterfaceTypeEFA       = "efa"
+
 	HostPlacementTenancyType      = "host"
 	DefaultPlacementTenancyType   = "default"
 	DedicatedPlacementTenancyType = "dedicated"
 
+	MetadataHttpEndpointEnabled  = "enabled"
+	MetadataHttpEndpointDisabled = "disabled"
+	MetadataHttpTokensOptional   = "optional"
+	MetadataHttpTokensRequired   = "required"
+	// MetadataMaxHopLimit is the largest PUT response hop limit accepted by EC2
+	MetadataMaxHopLimit = 64
+
 	ImageLatestValue = "latest"
 	ImageSSMPrefix   = "ssm://"
+
+	// CNI plugins which assign pod IPs on the nodes, only the VPC CNI requires the CNI managed policy on the node role
+	CNIPluginAwsVpcCNI = "aws-vpc-cni"
+	CNIPluginOther     = "other"
+
+	// node roles are authorized to join clusters through the aws-auth configmap or an EKS access entry
+	NodeAuthenticationAwsAuth     = "awsAuth"
+	NodeAuthenticationAccessEntry = "accessEntry"
+
+	TerminationPolicyDefault                   = "Default"
+	TerminationPolicyOldestInstance            = "OldestInstance"
+	TerminationPolicyNewestInstance            = "NewestInstance"
+	TerminationPolicyOldestLaunchConfiguration = "OldestLaunchConfiguration"
+	TerminationPolicyOldestLaunchTemplate      = "OldestLaunchTemplate"
+	TerminationPolicyAllocationStrategy        = "AllocationStrategy"
+	TerminationPolicyClosestToNextInstanceHour = "ClosestToNextInstanceHour"
 )
 
 type ContainerRuntime string
@@ -95,10 +190,17 @@ const (
 	ContainerDRuntime ContainerRuntime = "containerd"
 
 	UpgradeLockedAnnotationKey = "instancemgr.keikoproj.io/lock-upgrades"
+
+	DefaultDataVolumeName = "/dev/xvdb"
+
+	MaxPodsFormulaENIs         = "ENIs"
+	MaxPodsFormulaIPsPerENI    = "IPsPerENI"
+	MaxPodsFormulaIPsPerPrefix = "IPsPerPrefix"
+	MaxPodsFormulaHostPods     = "HostPods"
 )
 
 var (
-	Strategies   = []string{CRDStrategyName, RollingUpdateStrategyName, ManagedStrategyName}
+	Strategies   = []string{CRDStrategyName, RollingUpdateStrategyName, ManagedStrategyName, BlueGreenStrategyName}
 	Provisioners = []string{
 		EKSProvisionerName,
 		EKSManagedProvisionerName,
@@ -118,13 +220,84 @@ var (
 	DefaultCRDStrategyMaxRetries = 3
 
 	AllowedContainerRuntimes            = []ContainerRuntime{ContainerDRuntime, DockerRuntime}
+	AllowedMaxPodsFormulaVariables      = []string{MaxPodsFormulaENIs, MaxPodsFormulaIPsPerENI, MaxPodsFormulaIPsPerPrefix, MaxPodsFormulaHostPods}
 	AllowedFileSystemTypes              = []string{FileSystemTypeXFS, FileSystemTypeEXT4}
+	AllowedInstanceStoragePolicies      = []string{InstanceStoragePolicyPreferInstanceStore, InstanceStoragePolicyEBSOnly}
+	AllowedNodeAuthentications          = []string{NodeAuthenticationAwsAuth, NodeAuthenticationAccessEntry}
+	AllowedCNIPlugins                   = []string{CNIPluginAwsVpcCNI, CNIPluginOther}
+	AllowedUnhealthyProtectedPolicies   = []string{UnhealthyProtectedPolicyReport, UnhealthyProtectedPolicyClearProtection}
+	AllowedUnhealthyNodeActions         = []string{UnhealthyNodeActionCordon}
+	AllowedCapacityTypeEnforcements     = []string{CapacityTypeEnforcementSpotOnly, CapacityTypeEnforcementOnDemandOnly, CapacityTypeEnforcementMixed}
+	AllowedNetworkInterfaceTypes        = []string{NetworkInterfaceTypeInterface, NetworkInterfaceTypeEFA}
 	AllowedMixedPolicyStrategies        = []string{LaunchTemplateStrategyCapacityOptimized, LaunchTemplateStrategyLowestPrice}
 	AllowedInstancePools                = []string{SubFamilyFlexibleInstancePool}
 	LifecycleHookAllowedTransitions     = []string{LifecycleHookTransitionLaunch, LifecycleHookTransitionTerminate}
 	LifecycleHookAllowedDefaultResult   = []string{LifecycleHookResultAbandon, LifecycleHookResultContinue}
 	LaunchTemplatePlacementTenancyTypes = []string{HostPlacementTenancyType, DefaultPlacementTenancyType, DedicatedPlacementTenancyType}
+	AllowedMetadataHttpEndpoints        = []string{MetadataHttpEndpointEnabled, MetadataHttpEndpointDisabled}
+	AllowedMetadataHttpTokens           = []string{MetadataHttpTokensOptional, MetadataHttpTokensRequired}
 	log                                 = ctrl.Log.WithName("v1alpha1")
+	VpcCNIVersionRegex                  = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+(-eksbuild\.[0-9]+)?$`)
+	KubeletVersionRegex                 = regexp.MustCompile(`^v?[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+	WindowsPathRegex                    = regexp.MustCompile(`^[a-zA-Z]:\\[^'"\r\n]*$`)
+	ImageReferenceRegex                 = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._\-/:@]*$`)
+	UserDataVariableNameRegex           = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	AWSRegionRegex                      = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+	LinuxPathRegex                      = regexp.MustCompile(`^(/[a-zA-Z0-9._\-]+)+$`)
+	BootstrapFlagNameRegex              = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+	BootstrapFlagValueRegex             = regexp.MustCompile(`^[a-zA-Z0-9._:/,@+=-]+$`)
+	SysctlKeyRegex                      = regexp.MustCompile(`^(kernel|net|vm|fs|user)(\.[a-zA-Z0-9_-]+)+$`)
+	SysctlValueRegex                    = regexp.MustCompile(`^[a-zA-Z0-9._:/,+-]+( [a-zA-Z0-9._:/,+-]+)*$`)
+	SysctlPatternRegex                  = regexp.MustCompile(`^([a-z0-9]([-_a-z0-9]*[a-z0-9])?\.)*([a-z0-9][-_a-z0-9]*)?[a-z0-9*]$`)
+	AllowedTerminationPolicies          = []string{
+		TerminationPolicyDefault,
+		TerminationPolicyOldestInstance,
+		TerminationPolicyNewestInstance,
+		TerminationPolicyOldestLaunchConfiguration,
+		TerminationPolicyOldestLaunchTemplate,
+		TerminationPolicyAllocationStrategy,
+		TerminationPolicyClosestToNextInstanceHour,
+	}
+	// ReservedUserDataVariables are the names of the fields userdata templates are rendered with
+	ReservedUserDataVariables = []string{
+		"OsFamily", "ApiEndpoint", "ClusterCA", "ClusterName", "NodeLabels", "NodeTaints", "KubeletExtraArgs",
+		"Arguments", "PreBootstrap", "PostBootstrap", "MountOptions", "MaxPods", "ClusterIP", "NodeConfigYaml",
+		"RegistryCredentialsParameter", "WindowsContainerd", "PrePullImages", "ImageGCHighThreshold", "ImageGCLowThreshold",
+		"InstanceStorage", "Variables", "SerializeImagePulls", "MaxParallelImagePulls",
+		"ShutdownGracePeriod", "ShutdownGracePeriodCriticalPods", "KubeletConfig", "Sysctls",
+		"AllowedUnsafeSysctls", "BootstrapRetries", "Proxy", "CABundle",
+	}
+	// NamespacedSysctlPrefixes are the prefixes of the namespaced sysctls, the kubelet only allows pods to set unsafe
+	// sysctls which are namespaced
+	NamespacedSysctlPrefixes = []string{"kernel.shm", "kernel.msg", "kernel.sem", "fs.mqueue.", "net."}
+	// KubeletRestrictedLabelNamespaces are the label namespaces, and their subdomains, in which the kubelet cannot
+	// register nodes with labels through --node-labels
+	KubeletRestrictedLabelNamespaces = []string{"kubernetes.io", "k8s.io"}
+	// KubeletAllowedLabelNamespaces are the namespaces within the restricted namespaces the kubelet can set labels in
+	KubeletAllowedLabelNamespaces = []string{"kubelet.kubernetes.io", "node.kubernetes.io"}
+	// KubeletAllowedLabels are the labels within the restricted namespaces the kubelet can set
+	KubeletAllowedLabels = []string{
+		"kubernetes.io/hostname",
+		"kubernetes.io/arch",
+		"kubernetes.io/os",
+		"beta.kubernetes.io/arch",
+		"beta.kubernetes.io/os",
+		"beta.kubernetes.io/instance-type",
+		"node.kubernetes.io/instance-type",
+		"failure-domain.beta.kubernetes.io/region",
+		"failure-domain.beta.kubernetes.io/zone",
+		"topology.kubernetes.io/region",
+		"topology.kubernetes.io/zone",
+	}
+	MaintenanceWindowDays = []string{
+		time.Sunday.String(),
+		time.Monday.String(),
+		time.Tuesday.String(),
+		time.Wednesday.String(),
+		time.Thursday.String(),
+		time.Friday.String(),
+		time.Saturday.String(),
+	}
 )
 
 // InstanceGroup is the Schema for the instancegroups API
@@ -161,10 +334,90 @@ type AwsUpgradeStrategy struct {
 	Type              string                 `json:"type,omitempty"`
 	CRDType           *CRDUpdateStrategy     `json:"crd,omitempty"`
 	RollingUpdateType *RollingUpdateStrategy `json:"rollingUpdate,omitempty"`
+	BlueGreenType     *BlueGreenStrategy     `json:"blueGreen,omitempty"`
+	// MaintenanceWindows restrict node rotations to the windows, rotations are allowed at any time when empty
+	MaintenanceWindows []MaintenanceWindowSpec `json:"maintenanceWindows,omitempty"`
+}
+
+// MaintenanceWindowSpec is a recurring time window during which nodes may be rotated
+type MaintenanceWindowSpec struct {
+	// Days are the days of the week the window starts on, e.g. Saturday, the window starts every day when empty
+	Days []string `json:"days,omitempty"`
+	// StartTime is the time of day the window opens in the format HH:MM
+	StartTime string `json:"startTime"`
+	// EndTime is the time of day the window closes in the format HH:MM, windows ending before they start close on the next day
+	EndTime string `json:"endTime"`
+	// TimeZone is the IANA time zone of the window, e.g. America/Los_Angeles, defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 type RollingUpdateStrategy struct {
 	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	Drain          *DrainSpec          `json:"drain,omitempty"`
+	// FailureDomainLabel is a node label, such as topology.kubernetes.io/zone, whose values are failure domains, at most
+	// one node of each failure domain is rotated at a time
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
+	// SchedulingCheck defers the rotation of nodes whose evicted pods cannot be scheduled on the remaining nodes, given
+	// their taints and free capacity
+	SchedulingCheck bool `json:"schedulingCheck,omitempty"`
+}
+
+// BlueGreenStrategy rotates nodes by launching a parallel set of green instances with the new scaling configuration,
+// once the green nodes are ready the blue instances running the previous configuration are cordoned, drained and removed
+type BlueGreenStrategy struct {
+	// ReadyTimeout is how long the green nodes have to become ready before they are removed and the rotation is rolled
+	// back, defaults to 15m
+	ReadyTimeout string `json:"readyTimeout,omitempty"`
+	// Drain configures the drain of the blue nodes before they are removed
+	Drain *DrainSpec `json:"drain,omitempty"`
+}
+
+// GetReadyTimeout returns how long the green nodes have to become ready
+func (s *BlueGreenStrategy) GetReadyTimeout() time.Duration {
+	if s == nil {
+		return DefaultBlueGreenReadyTimeout
+	}
+	timeout, err := time.ParseDuration(s.ReadyTimeout)
+	if err != nil || timeout <= 0 {
+		return DefaultBlueGreenReadyTimeout
+	}
+	return timeout
+}
+
+func (s *BlueGreenStrategy) GetDrain() *DrainSpec {
+	if s == nil {
+		return nil
+	}
+	return s.Drain
+}
+
+func (s *BlueGreenStrategy) Validate() error {
+	if !common.StringEmpty(s.ReadyTimeout) {
+		timeout, err := time.ParseDuration(s.ReadyTimeout)
+		if err != nil || timeout <= 0 {
+			return errors.Errorf("validation failed, 'blueGreen.readyTimeout' must be a positive duration e.g. 15m")
+		}
+	}
+	if s.Drain != nil {
+		if err := s.Drain.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DrainSpec enables draining the nodes of instances before the rolling update terminates them
+type DrainSpec struct {
+	// EvictDaemonSets lists DaemonSets, by name or namespace/name, whose pods are evicted, the pods of all other DaemonSets are ignored
+	EvictDaemonSets []string `json:"evictDaemonSets,omitempty"`
+	// Timeout is how long a drain waits on blocking pods, which cannot be evicted due to a disruption budget or the
+	// cluster-autoscaler safe-to-evict annotation, before it is aborted or forced
+	Timeout string `json:"timeout,omitempty"`
+	// Force deletes blocking pods once the timeout is exceeded instead of aborting the rolling update
+	Force bool `json:"force,omitempty"`
+	// PriorityOrdering evicts pods in order of their priority class, lowest first, so that the pods of higher priority
+	// are only evicted once all pods of lower priority left the node
+	PriorityOrdering bool `json:"priorityOrdering,omitempty"`
 }
 
 func (s *RollingUpdateStrategy) GetMaxUnavailable() *intstr.IntOrString {
@@ -175,6 +428,64 @@ func (s *RollingUpdateStrategy) SetMaxUnavailable(value *intstr.IntOrString) {
 	s.MaxUnavailable = value
 }
 
+func (s *RollingUpdateStrategy) GetDrain() *DrainSpec {
+	return s.Drain
+}
+
+func (s *RollingUpdateStrategy) SetDrain(drain *DrainSpec) {
+	s.Drain = drain
+}
+
+func (s *RollingUpdateStrategy) GetFailureDomainLabel() string {
+	return s.FailureDomainLabel
+}
+
+func (s *RollingUpdateStrategy) GetSchedulingCheck() bool {
+	return s.SchedulingCheck
+}
+
+func (d *DrainSpec) GetEvictDaemonSets() []string {
+	return d.EvictDaemonSets
+}
+
+// GetTimeout returns how long a drain waits on blocking pods, or zero if it waits indefinitely
+func (d *DrainSpec) GetTimeout() time.Duration {
+	timeout, err := time.ParseDuration(d.Timeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+func (d *DrainSpec) GetForce() bool {
+	return d.Force
+}
+
+func (d *DrainSpec) GetPriorityOrdering() bool {
+	return d.PriorityOrdering
+}
+
+func (d *DrainSpec) Validate() error {
+	for _, name := range d.EvictDaemonSets {
+		parts := strings.Split(name, "/")
+		if len(parts) > 2 || common.ContainsString(parts, "") {
+			return errors.Errorf("validation failed, drain.evictDaemonSets entry '%v' must be a DaemonSet name or namespace/name", name)
+		}
+	}
+
+	if !common.StringEmpty(d.Timeout) {
+		timeout, err := time.ParseDuration(d.Timeout)
+		if err != nil || timeout <= 0 {
+			return errors.Errorf("validation failed, 'drain.timeout' must be a positive duration e.g. 15m")
+		}
+	}
+
+	if d.Force && common.StringEmpty(d.Timeout) {
+		return errors.Errorf("validation failed, 'drain.force' requires 'drain.timeout'")
+	}
+	return nil
+}
+
 type CRDUpdateStrategy struct {
 	Spec                string `json:"spec,omitempty"`
 	CRDName             string `json:"crdName,omitempty"`
@@ -192,6 +503,8 @@ type InstanceGroupSpec struct {
 	EKSFargateSpec     *EKSFargateSpec    `json:"eks-fargate,omitempty"`
 	EKSSpec            *EKSSpec           `json:"eks,omitempty"`
 	AwsUpgradeStrategy AwsUpgradeStrategy `json:"strategy,omitempty"`
+	// DependsOn lists instance groups, by name or namespace/name, which must be Ready before this instance group is reconciled
+	DependsOn []string `json:"dependsOn,omitempty"`
 }
 
 type EKSManagedSpec struct {
@@ -203,6 +516,157 @@ type EKSManagedSpec struct {
 type BootstrapOptions struct {
 	MaxPods          int64            `json:"maxPods,omitempty"`
 	ContainerRuntime ContainerRuntime `json:"containerRuntime,omitempty"`
+	// MaxPodsFormula computes max pods from the network limits of the instance type, e.g. ENIs * (IPsPerENI - 1) + HostPods
+	MaxPodsFormula string `json:"maxPodsFormula,omitempty"`
+	// ImageGCHighThreshold is the percent of disk usage after which the kubelet garbage collects images
+	ImageGCHighThreshold *int64 `json:"imageGCHighThreshold,omitempty"`
+	// ImageGCLowThreshold is the percent of disk usage the kubelet garbage collects images down to
+	ImageGCLowThreshold *int64 `json:"imageGCLowThreshold,omitempty"`
+	// SerializeImagePulls pulls one image at a time when true, the kubelet default, it must be false for parallel pulls
+	SerializeImagePulls *bool `json:"serializeImagePulls,omitempty"`
+	// MaxParallelImagePulls is the number of images the kubelet pulls at once when image pulls are not serialized
+	MaxParallelImagePulls *int64 `json:"maxParallelImagePulls,omitempty"`
+	// ShutdownGracePeriod is the duration the kubelet delays node shutdown by to terminate pods, e.g. 60s
+	ShutdownGracePeriod string `json:"shutdownGracePeriod,omitempty"`
+	// ShutdownGracePeriodCriticalPods is the part of the shutdown grace period reserved for terminating critical pods
+	ShutdownGracePeriodCriticalPods string `json:"shutdownGracePeriodCriticalPods,omitempty"`
+	// AllowedUnsafeSysctls are the unsafe sysctls, or sysctl patterns ending with *, pods are allowed to set
+	AllowedUnsafeSysctls []string `json:"allowedUnsafeSysctls,omitempty"`
+	// Flags are additional flags of the bootstrap script by name without leading dashes, they are validated against the
+	// flags supported by the instance group's OS family and appended to the bootstrap arguments
+	Flags map[string]string `json:"flags,omitempty"`
+	// KubeletConfiguration is a partial KubeletConfiguration merged into the kubelet config of AL2 and AL2023 nodes,
+	// fields managed by instance-manager or the node bootstrap cannot be set
+	// +kubebuilder:pruning:PreserveUnknownFields
+	KubeletConfiguration *runtime.RawExtension `json:"kubeletConfiguration,omitempty"`
+}
+
+// IsKubeletAllowedLabel returns true if the kubelet can register a node with the label key through --node-labels, the
+// NodeRestriction admission plugin rejects nodes registering with other labels in the restricted namespaces
+func IsKubeletAllowedLabel(key string) bool {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return true
+	}
+	if common.ContainsString(KubeletAllowedLabels, key) {
+		return true
+	}
+
+	inNamespace := func(namespace string, namespaces []string) bool {
+		for _, n := range namespaces {
+			if namespace == n || strings.HasSuffix(namespace, "."+n) {
+				return true
+			}
+		}
+		return false
+	}
+
+	namespace := strings.ToLower(key[:i])
+	if inNamespace(namespace, KubeletAllowedLabelNamespaces) {
+		return true
+	}
+	return !inNamespace(namespace, KubeletRestrictedLabelNamespaces)
+}
+
+func (b *BootstrapOptions) validateFlags() error {
+	for name, value := range b.Flags {
+		if !BootstrapFlagNameRegex.MatchString(name) {
+			return errors.Errorf("validation failed, 'bootstrapOptions.flags' name '%v' must be a flag name without leading dashes e.g. use-max-pods", name)
+		}
+		if !BootstrapFlagValueRegex.MatchString(value) {
+			return errors.Errorf("validation failed, 'bootstrapOptions.flags' value of %v must be non-empty and not contain whitespace, quotes or shell characters, got '%v'", name, value)
+		}
+	}
+	return nil
+}
+
+// validateImageGCThresholds validates the image garbage collection thresholds, a threshold which is not set is validated
+// against the kubelet's default for it
+func (b *BootstrapOptions) validateImageGCThresholds() error {
+	if b.ImageGCHighThreshold == nil && b.ImageGCLowThreshold == nil {
+		return nil
+	}
+	if b.ImageGCHighThreshold != nil && (*b.ImageGCHighThreshold < 0 || *b.ImageGCHighThreshold > 100) {
+		return errors.Errorf("validation failed, 'bootstrapOptions.imageGCHighThreshold' must be between 0 and 100, got %v", *b.ImageGCHighThreshold)
+	}
+	if b.ImageGCLowThreshold != nil && (*b.ImageGCLowThreshold < 0 || *b.ImageGCLowThreshold > 100) {
+		return errors.Errorf("validation failed, 'bootstrapOptions.imageGCLowThreshold' must be between 0 and 100, got %v", *b.ImageGCLowThreshold)
+	}
+
+	high, low := DefaultImageGCHighThreshold, DefaultImageGCLowThreshold
+	if b.ImageGCHighThreshold != nil {
+		high = *b.ImageGCHighThreshold
+	}
+	if b.ImageGCLowThreshold != nil {
+		low = *b.ImageGCLowThreshold
+	}
+	if high <= low {
+		return errors.Errorf("validation failed, 'bootstrapOptions.imageGCHighThreshold' %v must be greater than 'bootstrapOptions.imageGCLowThreshold' %v, unset thresholds default to %v and %v", high, low, DefaultImageGCHighThreshold, DefaultImageGCLowThreshold)
+	}
+	return nil
+}
+
+// validateImagePulls validates the image pull concurrency, parallel pulls require image pulls not to be serialized
+func (b *BootstrapOptions) validateImagePulls() error {
+	if b.MaxParallelImagePulls == nil {
+		return nil
+	}
+	if *b.MaxParallelImagePulls < 1 || *b.MaxParallelImagePulls > MaxParallelImagePullsLimit {
+		return errors.Errorf("validation failed, 'bootstrapOptions.maxParallelImagePulls' must be between 1 and %v, got %v", MaxParallelImagePullsLimit, *b.MaxParallelImagePulls)
+	}
+	if *b.MaxParallelImagePulls > 1 && (b.SerializeImagePulls == nil || *b.SerializeImagePulls) {
+		return errors.Errorf("validation failed, 'bootstrapOptions.maxParallelImagePulls' %v requires 'bootstrapOptions.serializeImagePulls' to be false", *b.MaxParallelImagePulls)
+	}
+	return nil
+}
+
+// validateShutdownGracePeriod validates the graceful node shutdown periods, the period reserved for critical pods is
+// part of the shutdown grace period and cannot exceed it
+func (b *BootstrapOptions) validateShutdownGracePeriod() error {
+	if common.StringEmpty(b.ShutdownGracePeriod) {
+		if !common.StringEmpty(b.ShutdownGracePeriodCriticalPods) {
+			return errors.Errorf("validation failed, 'bootstrapOptions.shutdownGracePeriodCriticalPods' requires 'bootstrapOptions.shutdownGracePeriod' to be set")
+		}
+		return nil
+	}
+	total, err := time.ParseDuration(b.ShutdownGracePeriod)
+	if err != nil || total <= 0 {
+		return errors.Errorf("validation failed, 'bootstrapOptions.shutdownGracePeriod' must be a positive duration e.g. 60s")
+	}
+	if common.StringEmpty(b.ShutdownGracePeriodCriticalPods) {
+		return nil
+	}
+	critical, err := time.ParseDuration(b.ShutdownGracePeriodCriticalPods)
+	if err != nil || critical < 0 {
+		return errors.Errorf("validation failed, 'bootstrapOptions.shutdownGracePeriodCriticalPods' must be a duration e.g. 20s")
+	}
+	if critical > total {
+		return errors.Errorf("validation failed, 'bootstrapOptions.shutdownGracePeriodCriticalPods' %v must not exceed 'bootstrapOptions.shutdownGracePeriod' %v", b.ShutdownGracePeriodCriticalPods, b.ShutdownGracePeriod)
+	}
+	return nil
+}
+
+// validateAllowedUnsafeSysctls validates the allowed unsafe sysctls are sysctl names or patterns of namespaced sysctls
+func (b *BootstrapOptions) validateAllowedUnsafeSysctls() error {
+	for i, sysctl := range b.AllowedUnsafeSysctls {
+		if !SysctlPatternRegex.MatchString(sysctl) {
+			return errors.Errorf("validation failed, 'bootstrapOptions.allowedUnsafeSysctls[%d]' must be a dot separated sysctl name, optionally ending with *, got '%v'", i, sysctl)
+		}
+		if common.ContainsString(b.AllowedUnsafeSysctls[:i], sysctl) {
+			return errors.Errorf("validation failed, 'bootstrapOptions.allowedUnsafeSysctls' contains duplicate sysctl '%v'", sysctl)
+		}
+		var namespaced bool
+		for _, prefix := range NamespacedSysctlPrefixes {
+			if strings.HasPrefix(sysctl, prefix) {
+				namespaced = true
+				break
+			}
+		}
+		if !namespaced {
+			return errors.Errorf("validation failed, 'bootstrapOptions.allowedUnsafeSysctls[%d]' must be a namespaced sysctl starting with one of %v, got '%v'", i, NamespacedSysctlPrefixes, sysctl)
+		}
+	}
+	return nil
 }
 
 type WarmPoolSpec struct {
@@ -222,6 +686,7 @@ type EKSConfiguration struct {
 	EksClusterName              string                    `json:"clusterName,omitempty"`
 	KeyPairName                 string                    `json:"keyPairName,omitempty"`
 	Image                       string                    `json:"image,omitempty"`
+	KubeletVersion              string                    `json:"kubeletVersion,omitempty"`
 	InstanceType                string                    `json:"instanceType,omitempty"`
 	NodeSecurityGroups          []string                  `json:"securityGroups,omitempty"`
 	Volumes                     []NodeVolume              `json:"volumes,omitempty"`
@@ -230,8 +695,10 @@ type EKSConfiguration struct {
 	BootstrapArguments          string                    `json:"bootstrapArguments,omitempty"`
 	BootstrapOptions            *BootstrapOptions         `json:"bootstrapOptions,omitempty"`
 	SpotPrice                   string                    `json:"spotPrice,omitempty"`
+	CapacityTypeEnforcement     string                    `json:"capacityTypeEnforcement,omitempty"`
 	Tags                        []map[string]string       `json:"tags,omitempty"`
 	Labels                      map[string]string         `json:"labels,omitempty"`
+	InstanceTagLabels           map[string]string         `json:"instanceTagLabels,omitempty"`
 	Taints                      []corev1.Taint            `json:"taints,omitempty"`
 	UserData                    []UserDataStage           `json:"userData,omitempty"`
 	ExistingRoleName            string                    `json:"roleName,omitempty"`
@@ -243,6 +710,190 @@ type EKSConfiguration struct {
 	LicenseSpecifications       []string                  `json:"licenseSpecifications,omitempty"`
 	Placement                   *PlacementSpec            `json:"placement,omitempty"`
 	MetadataOptions             *MetadataOptions          `json:"metadataOptions,omitempty"`
+	ZoneSharding                bool                      `json:"zoneSharding,omitempty"`
+	ClusterNameSource           *ClusterNameSourceSpec    `json:"clusterNameSource,omitempty"`
+	ApiEndpoint                 string                    `json:"apiEndpoint,omitempty"`
+	ClusterCA                   string                    `json:"clusterCA,omitempty"`
+	DataVolume                  *NodeVolume               `json:"dataVolume,omitempty"`
+	NodeTTL                     string                    `json:"nodeTTL,omitempty"`
+	ImageUpdateCheckInterval    string                    `json:"imageUpdateCheckInterval,omitempty"`
+	DetectSecurityGroupDrift    bool                      `json:"detectSecurityGroupDrift,omitempty"`
+	IgnoreCosmeticChanges       bool                      `json:"ignoreCosmeticChanges,omitempty"`
+	DisableSourceDestCheck      bool                      `json:"disableSourceDestCheck,omitempty"`
+	NetworkInterfaces           []NetworkInterfaceSpec    `json:"networkInterfaces,omitempty"`
+	AssociatePublicIpAddress    *bool                     `json:"associatePublicIpAddress,omitempty"`
+	StartupTaint                *StartupTaintSpec         `json:"startupTaint,omitempty"`
+	TerminationPolicies         []string                  `json:"terminationPolicies,omitempty"`
+	CapacityRebalance           bool                      `json:"capacityRebalance,omitempty"`
+	DefaultCooldown             *int64                    `json:"defaultCooldown,omitempty"`
+	InstanceMaintenancePolicy   *InstanceMaintenanceSpec  `json:"instanceMaintenancePolicy,omitempty"`
+	ClusterAutoscalerPriority   *int64                    `json:"clusterAutoscalerPriority,omitempty"`
+	ScaleInProtection           bool                      `json:"scaleInProtection,omitempty"`
+	UnhealthyProtectedPolicy    string                    `json:"unhealthyProtectedPolicy,omitempty"`
+	RegistryCredentials         *RegistryCredentialsSpec  `json:"registryCredentials,omitempty"`
+	CNI                         *CNISpec                  `json:"cni,omitempty"`
+	VpcCNIVersion               string                    `json:"vpcCNIVersion,omitempty"`
+	VpcCNIWarmTargets           *VpcCNIWarmTargetsSpec    `json:"vpcCNIWarmTargets,omitempty"`
+	WindowsContainerd           *WindowsContainerdSpec    `json:"windowsContainerd,omitempty"`
+	PrePullImages               []string                  `json:"prePullImages,omitempty"`
+	BootstrapRetries            *BootstrapRetriesSpec     `json:"bootstrapRetries,omitempty"`
+	UserDataVariables           map[string]string         `json:"userDataVariables,omitempty"`
+	AssumeRoleArn               string                    `json:"assumeRoleArn,omitempty"`
+	AwsCallTimeout              string                    `json:"awsCallTimeout,omitempty"`
+	Region                      string                    `json:"region,omitempty"`
+	ReadinessChecks             *ReadinessChecksSpec      `json:"readinessChecks,omitempty"`
+	NodeAuthentication          string                    `json:"nodeAuthentication,omitempty"`
+	AuthMappings                *AuthMappingsSpec         `json:"authMappings,omitempty"`
+	NodeJoinDeadline            *NodeJoinDeadlineSpec     `json:"nodeJoinDeadline,omitempty"`
+	NotReadyReplacement         *NotReadyReplacementSpec  `json:"notReadyReplacement,omitempty"`
+	UnhealthyNodeConditions     map[string]string         `json:"unhealthyNodeConditions,omitempty"`
+	InstanceStorage             *InstanceStorageSpec      `json:"instanceStorage,omitempty"`
+	InitialGracePeriod          string                    `json:"initialGracePeriod,omitempty"`
+	ReadinessSLA                string                    `json:"readinessSLA,omitempty"`
+	ImageAllowlist              *ImageAllowlistSpec       `json:"imageAllowlist,omitempty"`
+	Sysctls                     map[string]string         `json:"sysctls,omitempty"`
+	Proxy                       *ProxySpec                `json:"proxy,omitempty"`
+	CABundle                    *CABundleSpec             `json:"caBundle,omitempty"`
+}
+
+// ProxySpec are the proxies nodes reach the internet through, configured for the bootstrap, containerd and the kubelet.
+// The metadata endpoint, the cluster's VPC and service CIDRs and its API endpoint are always reached directly, noProxy
+// lists additional hosts, domains and CIDRs which are.
+type ProxySpec struct {
+	HTTPProxy  string   `json:"httpProxy,omitempty"`
+	HTTPSProxy string   `json:"httpsProxy,omitempty"`
+	NoProxy    []string `json:"noProxy,omitempty"`
+}
+
+// CABundleSpec are PEM encoded certificates of certificate authorities nodes trust, such as the private CA of internal
+// registries. The bundle is set inline with certificates, or referenced with configMapRef from a ConfigMap in the
+// instance group's namespace, and installed into the system trust store which containerd uses.
+type CABundleSpec struct {
+	Certificates string                      `json:"certificates,omitempty"`
+	ConfigMapRef *CABundleConfigMapReference `json:"configMapRef,omitempty"`
+}
+
+// CABundleConfigMapReference is a key of a ConfigMap which holds a CA bundle, the key defaults to ca-bundle.crt
+type CABundleConfigMapReference struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+}
+
+// InstanceStorageSpec is where nodes mount their NVMe instance store volumes, which are assembled into a RAID0 array
+// and formatted at bootstrap. Nodes without instance store volumes bootstrap without it. The policy selects whether
+// container storage lives on instance store, when the instance types have it, or always on EBS.
+type InstanceStorageSpec struct {
+	MountPath  string `json:"mountPath,omitempty"`
+	FileSystem string `json:"fileSystem,omitempty"`
+	Policy     string `json:"policy,omitempty"`
+}
+
+// NodeJoinDeadlineSpec is the time instances have to register as nodes after launching, instances which have not joined
+// the cluster by then are reported as failed to join, and terminated for replacement if replace is set. The tail of the
+// console output of failed instances is published in an event if collectConsoleOutput is set.
+type NodeJoinDeadlineSpec struct {
+	Timeout              string `json:"timeout"`
+	Replace              bool   `json:"replace,omitempty"`
+	CollectConsoleOutput bool   `json:"collectConsoleOutput,omitempty"`
+}
+
+// NotReadyReplacementSpec drains and replaces instances whose nodes stay NotReady for longer than the
+// threshold. Instances which are not in service and NotReady nodes count as unavailable, replacements are deferred while
+// more than maxUnavailable instances, a number or a percentage of the instances, are unavailable.
+type NotReadyReplacementSpec struct {
+	Threshold      string              `json:"threshold"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// ImageAllowlistSpec restricts the images instances are launched from, the resolved image must match an id, be owned
+// by one of the owners, which are account ids or owner aliases such as amazon, or have a name matching a pattern
+type ImageAllowlistSpec struct {
+	IDs          []string `json:"ids,omitempty"`
+	Owners       []string `json:"owners,omitempty"`
+	NamePatterns []string `json:"namePatterns,omitempty"`
+}
+
+// ReadinessChecksSpec are additional checks nodes must pass before they are counted as ready
+type ReadinessChecksSpec struct {
+	// DaemonSets lists DaemonSets, by name or namespace/name, which must have a running pod on a node before it is counted as ready
+	DaemonSets []string `json:"daemonSets,omitempty"`
+}
+
+// WindowsContainerdSpec is the containerd configuration of Windows nodes, rendered into the containerd config.toml
+// before nodes bootstrap.
+type WindowsContainerdSpec struct {
+	Root         string `json:"root,omitempty"`
+	State        string `json:"state,omitempty"`
+	SandboxImage string `json:"sandboxImage,omitempty"`
+}
+
+// CNISpec is the CNI configuration of the instance group's nodes. The AmazonEKS_CNI_Policy is attached to the node role
+// for the VPC CNI unless its pods use IRSA, and detached from it for other plugins.
+type CNISpec struct {
+	Plugin string `json:"plugin,omitempty"`
+}
+
+// VpcCNIWarmTargetsSpec are the warm pool targets of the VPC CNI for the instance group's nodes, matching the
+// WARM_IP_TARGET, MINIMUM_IP_TARGET and WARM_ENI_TARGET settings of the aws-node daemonset.
+type VpcCNIWarmTargetsSpec struct {
+	WarmIPTarget    *int64 `json:"warmIPTarget,omitempty"`
+	MinimumIPTarget *int64 `json:"minimumIPTarget,omitempty"`
+	WarmENITarget   *int64 `json:"warmENITarget,omitempty"`
+}
+
+// RegistryCredentialsSpec references a secret of type kubernetes.io/dockerconfigjson in the instance group's namespace,
+// nodes retrieve its contents at bootstrap from an encrypted SSM parameter rather than from userdata.
+type RegistryCredentialsSpec struct {
+	SecretName string `json:"secretName"`
+}
+
+// StartupTaintSpec is a taint nodes register with, which is removed by a startup process once the node is ready
+// for workloads. Nodes are not counted as ready until the taint has been removed.
+type StartupTaintSpec struct {
+	Key     string `json:"key"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// BootstrapRetriesSpec retries the network operations of the bootstrap, such as retrieving registry credentials and
+// pulling images, so that transient failures do not permanently fail a node join
+type BootstrapRetriesSpec struct {
+	Attempts int64  `json:"attempts"`
+	Backoff  string `json:"backoff,omitempty"`
+}
+
+// InstanceMaintenanceSpec is the instance maintenance policy of the scaling group, which bounds its healthy
+// capacity while instances are replaced by instance refreshes and health check replacements
+type InstanceMaintenanceSpec struct {
+	// MinHealthyPercentage is the percentage of the desired capacity kept in service while instances are replaced,
+	// between 0 and 100
+	MinHealthyPercentage int64 `json:"minHealthyPercentage"`
+	// MaxHealthyPercentage is the percentage of the desired capacity which can be in service or pending while instances
+	// are replaced, between 100 and 200 and at most 100 above the minimum
+	MaxHealthyPercentage int64 `json:"maxHealthyPercentage"`
+}
+
+// AuthMappingsSpec are additional entries of the aws-auth configmap which are managed for the instance group, beyond
+// the entry of its node role
+type AuthMappingsSpec struct {
+	Roles []AuthMapping `json:"roles,omitempty"`
+	Users []AuthMapping `json:"users,omitempty"`
+}
+
+// AuthMappingSystemGroupPrefix is the prefix of the Kubernetes system groups, which authMappings cannot map to
+const AuthMappingSystemGroupPrefix = "system:"
+
+// AuthMapping maps an IAM role or user to a Kubernetes username and groups
+type AuthMapping struct {
+	ARN      string   `json:"arn"`
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// AuthMappingsStatus are the ARNs of the aws-auth entries owned by the instance group, entries which are not owned
+// are never modified or removed
+type AuthMappingsStatus struct {
+	Roles []string `json:"roles,omitempty"`
+	Users []string `json:"users,omitempty"`
 }
 
 const (
@@ -266,10 +917,51 @@ type PlacementSpec struct {
 	Tenancy              string `json:"tenancy,omitempty"`
 }
 
+type ClusterNameSourceSpec struct {
+	Arn      string `json:"arn,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// NetworkInterfaceSpec is an additional network interface attached to instances at launch, the primary
+// interface (device index 0) is always created from the instance group's subnets and security groups
+type NetworkInterfaceSpec struct {
+	DeviceIndex              int64    `json:"deviceIndex"`
+	Subnet                   string   `json:"subnet"`
+	SecurityGroups           []string `json:"securityGroups,omitempty"`
+	AssociatePublicIpAddress *bool    `json:"associatePublicIpAddress,omitempty"`
+	// InterfaceType is either interface or efa, efa interfaces require an instance type with EFA support and a
+	// security group which allows all traffic from and to itself
+	InterfaceType string `json:"interfaceType,omitempty"`
+}
+
+func (n *NetworkInterfaceSpec) IsEFA() bool {
+	return n.InterfaceType == NetworkInterfaceTypeEFA
+}
+
+// MetadataOptions configures the instance metadata service of instances, each option can be set independently and
+// options which are not set use their defaults
 type MetadataOptions struct {
-	HttpEndpoint    string `json:"httpEndpoint,omitempty"`
-	HttpTokens      string `json:"httpTokens,omitempty"`
-	HttpPutHopLimit int64  `json:"httpPutHopLimit,omitempty"`
+	HttpEndpoint string `json:"httpEndpoint,omitempty"`
+	HttpTokens   string `json:"httpTokens,omitempty"`
+	// HttpPutHopLimit is the number of network hops a metadata PUT response may travel, when not set it defaults to
+	// a limit depending on the OS family and whether pods use IRSA
+	HttpPutHopLimit int64 `json:"httpPutHopLimit,omitempty"`
+}
+
+func (m *MetadataOptions) Validate() error {
+	if m == nil {
+		return nil
+	}
+	if !common.StringEmpty(m.HttpEndpoint) && !common.ContainsEqualFold(AllowedMetadataHttpEndpoints, m.HttpEndpoint) {
+		return errors.Errorf("validation failed, 'metadataOptions.httpEndpoint' must be one of %v", AllowedMetadataHttpEndpoints)
+	}
+	if !common.StringEmpty(m.HttpTokens) && !common.ContainsEqualFold(AllowedMetadataHttpTokens, m.HttpTokens) {
+		return errors.Errorf("validation failed, 'metadataOptions.httpTokens' must be one of %v", AllowedMetadataHttpTokens)
+	}
+	if m.HttpPutHopLimit < 0 || m.HttpPutHopLimit > MetadataMaxHopLimit {
+		return errors.Errorf("validation failed, 'metadataOptions.httpPutHopLimit' must be between 1 and %v", MetadataMaxHopLimit)
+	}
+	return nil
 }
 
 type InstanceTypeSpec struct {
@@ -299,6 +991,16 @@ type UserDataStage struct {
 	Name  string `json:"name,omitempty"`
 	Stage string `json:"stage"`
 	Data  string `json:"data"`
+	// Order controls the position of the stage, ordered stages render before unordered stages of the same kind
+	Order *int `json:"order,omitempty"`
+}
+
+// GetOrder returns the order of the stage and whether one is set
+func (s UserDataStage) GetOrder() (int, bool) {
+	if s.Order == nil {
+		return 0, false
+	}
+	return *s.Order, true
 }
 
 type NodeVolume struct {
@@ -355,6 +1057,10 @@ type InstanceGroupStatus struct {
 	ActiveLaunchConfigurationName string                   `json:"activeLaunchConfigurationName,omitempty"`
 	ActiveLaunchTemplateName      string                   `json:"activeLaunchTemplateName,omitempty"`
 	LatestTemplateVersion         string                   `json:"latestTemplateVersion,omitempty"`
+	ActiveLaunchTemplateID        string                   `json:"activeLaunchTemplateId,omitempty"`
+	ActiveLaunchTemplateVersion   string                   `json:"activeLaunchTemplateVersion,omitempty"`
+	CleanedLaunchTemplateVersions int                      `json:"cleanedLaunchTemplateVersions,omitempty"`
+	ActiveImageID                 string                   `json:"activeImageId,omitempty"`
 	ActiveScalingGroupName        string                   `json:"activeScalingGroupName,omitempty"`
 	NodesArn                      string                   `json:"nodesInstanceRoleArn,omitempty"`
 	StrategyResourceName          string                   `json:"strategyResourceName,omitempty"`
@@ -366,6 +1072,116 @@ type InstanceGroupStatus struct {
 	Conditions                    []InstanceGroupCondition `json:"conditions,omitempty"`
 	Provisioner                   string                   `json:"provisioner,omitempty"`
 	Strategy                      string                   `json:"strategy,omitempty"`
+	ZoneCapacity                  []ZoneCapacityStatus     `json:"zoneCapacity,omitempty"`
+	ProtectedInstances            []string                 `json:"protectedInstances,omitempty"`
+	FailedJoinInstances           []string                 `json:"failedJoinInstances,omitempty"`
+	NotReadyInstances             []string                 `json:"notReadyInstances,omitempty"`
+	UnhealthyProtectedInstances   []string                 `json:"unhealthyProtectedInstances,omitempty"`
+	RotationStarted               bool                     `json:"rotationStarted,omitempty"`
+	PrefixDensity                 *PrefixDensityStatus     `json:"prefixDensity,omitempty"`
+	CostEstimate                  *CostEstimateStatus      `json:"costEstimate,omitempty"`
+	LifecycleCapacity             *LifecycleCapacityStatus `json:"lifecycleCapacity,omitempty"`
+	UserDataHash                  string                   `json:"userDataHash,omitempty"`
+	BlueGreen                     *BlueGreenStatus         `json:"blueGreen,omitempty"`
+	ImageUpdate                   *ImageUpdateStatus       `json:"imageUpdate,omitempty"`
+	// ClusterAutoscalerPriority is the priority of the scaling groups in the cluster-autoscaler priority expander
+	// configmap, set while the instance group's scaling groups are listed in it
+	ClusterAutoscalerPriority *int64 `json:"clusterAutoscalerPriority,omitempty"`
+	// ClusterAutoscalerPriorityScalingGroups are the names of the scaling groups listed in the priority expander
+	// configmap, scaling groups which no longer exist are removed from it
+	ClusterAutoscalerPriorityScalingGroups []string `json:"clusterAutoscalerPriorityScalingGroups,omitempty"`
+	// AuthMappings are the additional aws-auth entries owned by the instance group
+	AuthMappings *AuthMappingsStatus `json:"authMappings,omitempty"`
+	// ManagedRoleTags are the keys of the custom tags applied to the managed role and instance profile, only these
+	// keys are removed once they are no longer desired
+	ManagedRoleTags []string `json:"managedRoleTags,omitempty"`
+	// RegistryCredentialsParameter is the SSM parameter the registry credentials were copied into, it is deleted once
+	// registry credentials are no longer referenced
+	RegistryCredentialsParameter string `json:"registryCredentialsParameter,omitempty"`
+	// SourceDestCheckDisabled is true while the source/dest check of the instances may be disabled, it is enabled again
+	// once disableSourceDestCheck is removed
+	SourceDestCheckDisabled bool `json:"sourceDestCheckDisabled,omitempty"`
+	// BootstrapDiagnostics are the digests of the console output last published for instances which have not joined
+	// the cluster, by instance id, console output is only published again once it changed
+	BootstrapDiagnostics map[string]string `json:"bootstrapDiagnostics,omitempty"`
+	// Readiness records the time taken by the instance group to become fully ready after it was created or scaled up
+	Readiness *ReadinessStatus `json:"readiness,omitempty"`
+}
+
+// ReadinessStatus tracks the provisioning of the instance group, from the start of the reconcile which created or
+// scaled up its scaling group until all of its desired nodes are ready
+type ReadinessStatus struct {
+	// ProvisioningStartTime is the start of the reconcile which created or scaled up the instance group, it is unset
+	// once all desired nodes are ready
+	ProvisioningStartTime *metav1.Time `json:"provisioningStartTime,omitempty"`
+	// ReadyCapacity is the desired capacity at which all nodes were last ready, a greater desired capacity starts a
+	// new provisioning
+	ReadyCapacity int64        `json:"readyCapacity,omitempty"`
+	LastReadyTime *metav1.Time `json:"lastReadyTime,omitempty"`
+	// LastProvisioningDuration is the time the last provisioning took to become fully ready, e.g. 4m30s
+	LastProvisioningDuration string `json:"lastProvisioningDuration,omitempty"`
+	// SLAExceeded is true while the current provisioning, or the last one if none is in progress, takes longer than
+	// the readiness SLA
+	SLAExceeded bool `json:"slaExceeded,omitempty"`
+}
+
+// ImageUpdateStatus compares the image currently published for the instance group's image reference with the images
+// its instances were launched from
+type ImageUpdateStatus struct {
+	AvailableImageID string   `json:"availableImageId"`
+	RunningImageIDs  []string `json:"runningImageIds,omitempty"`
+	// UpdateAvailable is true while instances run an image other than the available image
+	UpdateAvailable bool        `json:"updateAvailable"`
+	LastCheckTime   metav1.Time `json:"lastCheckTime"`
+}
+
+// BlueGreenStatus tracks the phase of a blue/green rotation
+type BlueGreenStatus struct {
+	Phase string `json:"phase"`
+	// ScalingConfiguration is the scaling configuration the green instances are launched with, launch templates are
+	// suffixed with their version
+	ScalingConfiguration string `json:"scalingConfiguration"`
+	// BlueInstances are the instances which are retired once the green nodes are ready
+	BlueInstances []string `json:"blueInstances,omitempty"`
+	// PreviousInstances are the instances of the scaling group when the rotation started, only instances launched
+	// since are green
+	PreviousInstances []string `json:"previousInstances,omitempty"`
+	// GreenCapacity is the number of green instances launched in addition to the blue instances
+	GreenCapacity int `json:"greenCapacity"`
+	// StartTime is when the green instances were launched
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// LifecycleCapacityStatus is the number of on-demand and spot instances in the instance group's scaling groups
+type LifecycleCapacityStatus struct {
+	OnDemand int `json:"onDemand"`
+	Spot     int `json:"spot"`
+}
+
+// CostEstimateStatus is a rough, informational estimate of the monthly cost of the instance group's desired capacity,
+// computed from a static pricing table. It excludes volumes, data transfer and discounts and does not reflect billing.
+type CostEstimateStatus struct {
+	EstimatedMonthlyCost  string   `json:"estimatedMonthlyCost"`
+	Currency              string   `json:"currency"`
+	OnDemandCapacity      int64    `json:"onDemandCapacity"`
+	SpotCapacity          int64    `json:"spotCapacity"`
+	UnpricedInstanceTypes []string `json:"unpricedInstanceTypes,omitempty"`
+}
+
+// PrefixDensityStatus records the max pods the instance group's nodes bootstrap with for the pod density of VPC CNI
+// prefix assignment, prefix delegation itself is configured on the aws-node daemonset and is not verified
+type PrefixDensityStatus struct {
+	CustomNetworkingEnabled bool  `json:"customNetworkingEnabled,omitempty"`
+	MaxPods                 int64 `json:"maxPods,omitempty"`
+}
+
+type ZoneCapacityStatus struct {
+	Zone             string `json:"zone"`
+	ScalingGroupName string `json:"scalingGroupName,omitempty"`
+	CurrentMin       int    `json:"currentMin,omitempty"`
+	CurrentMax       int    `json:"currentMax,omitempty"`
+	DesiredCapacity  int    `json:"desiredCapacity,omitempty"`
+	InService        int    `json:"inService,omitempty"`
 }
 
 type InstanceGroupConditionType string
@@ -401,6 +1217,39 @@ func (ig *InstanceGroup) GetEKSSpec() *EKSSpec {
 func (ig *InstanceGroup) NamespacedName() string {
 	return fmt.Sprintf("%v/%v", ig.GetNamespace(), ig.GetName())
 }
+
+// GetDependencies returns the namespace/name of the instance groups this instance group depends on, dependencies
+// referenced by name are in the instance group's namespace
+func (ig *InstanceGroup) GetDependencies() []string {
+	dependencies := make([]string, 0, len(ig.Spec.DependsOn))
+	for _, dependency := range ig.Spec.DependsOn {
+		if !strings.Contains(dependency, "/") {
+			dependency = fmt.Sprintf("%v/%v", ig.GetNamespace(), dependency)
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies
+}
+
+func (ig *InstanceGroup) validateDependsOn() error {
+	for _, dependency := range ig.Spec.DependsOn {
+		parts := strings.Split(dependency, "/")
+		if len(parts) > 2 {
+			return errors.Errorf("validation failed, 'dependsOn' entry '%v' must be a name or namespace/name", dependency)
+		}
+		for _, part := range parts {
+			if errs := validation.IsDNS1123Subdomain(part); len(errs) > 0 {
+				return errors.Errorf("validation failed, 'dependsOn' entry '%v' must be a name or namespace/name: %v", dependency, strings.Join(errs, ", "))
+			}
+		}
+	}
+	for _, dependency := range ig.GetDependencies() {
+		if dependency == ig.NamespacedName() {
+			return errors.Errorf("validation failed, 'dependsOn' cannot reference the instance group itself")
+		}
+	}
+	return nil
+}
 func (ig *InstanceGroup) GetStatus() *InstanceGroupStatus {
 	return &ig.Status
 }
@@ -448,6 +1297,12 @@ func (s *EKSSpec) Validate(overrides *ValidationOverrides) error {
 		if !common.SliceEmpty(s.EKSConfiguration.LicenseSpecifications) {
 			return errors.Errorf("validation failed, field 'licenseSpecifications' is only valid for LaunchTemplates")
 		}
+		if len(s.EKSConfiguration.NetworkInterfaces) > 0 {
+			return errors.Errorf("validation failed, field 'networkInterfaces' is only valid for LaunchTemplates")
+		}
+		if s.EKSConfiguration.AssociatePublicIpAddress != nil {
+			return errors.Errorf("validation failed, field 'associatePublicIpAddress' is only valid for LaunchTemplates")
+		}
 		if s.EKSConfiguration.GetPlacement() != nil {
 			if s.EKSConfiguration.GetPlacement().HostResourceGroupArn != "" {
 				return errors.Errorf("validation failed, field 'hostResourceGroupArn' is only valid for LaunchTemplates")
@@ -458,7 +1313,12 @@ func (s *EKSSpec) Validate(overrides *ValidationOverrides) error {
 		}
 	}
 
-	for _, v := range configuration.Volumes {
+	volumes := configuration.GetVolumes()
+	if dataVolume := configuration.GetDataVolume(); dataVolume != nil {
+		volumes = append(append([]NodeVolume{}, volumes...), *dataVolume)
+	}
+
+	for _, v := range volumes {
 		if configType == LaunchConfiguration {
 			if !common.ContainsEqualFold(awsprovider.ConfigurationAllowedVolumeTypes, v.Type) {
 				return errors.Errorf("validation failed, volume type '%v' is unsupported", v.Type)
@@ -478,6 +1338,31 @@ func (s *EKSSpec) Validate(overrides *ValidationOverrides) error {
 		if v.Throughput != 0 && !common.ContainsEqualFold(awsprovider.AllowedVolumeTypesWithProvisionedThroughput, v.Type) {
 			return errors.Errorf("validation failed, volume type '%v' does not support provisioned throughput", v.Type)
 		}
+
+		if r, ok := awsprovider.ProvisionedIOPSRange[strings.ToLower(v.Type)]; ok && v.Iops != 0 && !common.Int64InRange(v.Iops, r[0], r[1]) {
+			return errors.Errorf("validation failed, volume type '%v' iops must be between %v and %v", v.Type, r[0], r[1])
+		}
+
+		if r, ok := awsprovider.ProvisionedThroughputRange[strings.ToLower(v.Type)]; ok && v.Throughput != 0 && !common.Int64InRange(v.Throughput, r[0], r[1]) {
+			return errors.Errorf("validation failed, volume type '%v' throughput must be between %v and %v", v.Type, r[0], r[1])
+		}
+	}
+
+	if policy := configuration.GetMixedInstancesPolicy(); policy != nil && s.MaxSize > 0 {
+		for _, t := range policy.InstanceTypes {
+			if t.Weight > s.MaxSize {
+				return errors.Errorf("validation failed, mixedInstancesPolicy.instanceTypes weight for '%v' cannot exceed maxSize %v, got %v", t.Type, s.MaxSize, t.Weight)
+			}
+		}
+	}
+
+	if configuration.ZoneSharding {
+		if s.HasWarmPool() {
+			return errors.Errorf("validation failed, cannot use warmPool with zoneSharding")
+		}
+		if configuration.GetPlacement() != nil && configuration.GetPlacement().AvailabilityZone != "" {
+			return errors.Errorf("validation failed, cannot use placement.availabilityZone with zoneSharding")
+		}
 	}
 
 	if s.HasWarmPool() {
@@ -523,24 +1408,36 @@ func (c *EKSConfiguration) Validate() error {
 	if common.SliceEmpty(c.NodeSecurityGroups) {
 		return errors.Errorf("validation failed, 'securityGroups' is a required parameter")
 	}
-	for _, m := range c.MetricsCollection {
-		metrics := make([]string, 0)
-		if strings.EqualFold(m, "all") {
-			continue
-		}
-		if common.ContainsString(awsprovider.DefaultAutoscalingMetrics, m) {
-			metrics = append(metrics, m)
+	// metric names are normalized, since scaling groups only accept the exact names of group metrics. Names which are
+	// not group metrics were accepted before, they are kept and skipped with a warning by the provisioner
+	if len(c.MetricsCollection) > 0 {
+		metrics := make([]string, 0, len(c.MetricsCollection))
+		for _, metric := range c.MetricsCollection {
+			name := metric
+			if normalized, ok := scalingMetricName(metric); ok {
+				name = normalized
+			}
+			if !common.ContainsString(metrics, name) {
+				metrics = append(metrics, name)
+			}
 		}
 		c.MetricsCollection = metrics
 	}
 
-	for _, m := range c.SuspendedProcesses {
-		processes := make([]string, 0)
-		if strings.EqualFold(m, "all") {
-			continue
-		}
-		if common.ContainsString(awsprovider.DefaultSuspendProcesses, m) {
-			processes = append(processes, m)
+	// process names are normalized, since scaling groups only accept the exact names of scaling processes
+	if len(c.SuspendedProcesses) > 0 {
+		processes := make([]string, 0, len(c.SuspendedProcesses))
+		for _, process := range c.SuspendedProcesses {
+			name := process
+			if !strings.EqualFold(process, "all") {
+				var ok bool
+				if name, ok = scalingProcessName(process); !ok {
+					return errors.Errorf("validation failed, 'suspendProcesses' entry '%v' must be one of %v or All", process, awsprovider.DefaultSuspendProcesses)
+				}
+			}
+			if !common.ContainsString(processes, name) {
+				processes = append(processes, name)
+			}
 		}
 		c.SuspendedProcesses = processes
 	}
@@ -549,6 +1446,32 @@ func (c *EKSConfiguration) Validate() error {
 		if c.BootstrapOptions.ContainerRuntime != "" && !contains(AllowedContainerRuntimes, c.BootstrapOptions.ContainerRuntime) {
 			return errors.Errorf("validation failed, 'bootstrapOptions.containerRuntime' must be one of %+v", AllowedContainerRuntimes)
 		}
+		if !common.StringEmpty(c.BootstrapOptions.MaxPodsFormula) {
+			if c.BootstrapOptions.MaxPods != 0 {
+				return errors.Errorf("validation failed, 'bootstrapOptions.maxPodsFormula' cannot be combined with 'bootstrapOptions.maxPods'")
+			}
+			if _, err := common.ParseFormula(c.BootstrapOptions.MaxPodsFormula, AllowedMaxPodsFormulaVariables); err != nil {
+				return errors.Errorf("validation failed, 'bootstrapOptions.maxPodsFormula' %v", err)
+			}
+		}
+		if err := c.BootstrapOptions.validateImageGCThresholds(); err != nil {
+			return err
+		}
+		if err := c.BootstrapOptions.validateImagePulls(); err != nil {
+			return err
+		}
+		if err := c.BootstrapOptions.validateShutdownGracePeriod(); err != nil {
+			return err
+		}
+		if err := c.BootstrapOptions.validateAllowedUnsafeSysctls(); err != nil {
+			return err
+		}
+		if err := c.BootstrapOptions.validateFlags(); err != nil {
+			return err
+		}
+		if err := c.BootstrapOptions.validateKubeletConfiguration(); err != nil {
+			return err
+		}
 	}
 
 	hooks := []LifecycleHookSpec{}
@@ -611,6 +1534,23 @@ func (c *EKSConfiguration) Validate() error {
 		}
 	}
 
+	if c.DataVolume != nil {
+		if common.StringEmpty(c.DataVolume.Name) {
+			c.DataVolume.Name = DefaultDataVolumeName
+		}
+		if c.DataVolume.Size <= 0 {
+			return errors.Errorf("validation failed, 'dataVolume.size' must be greater than 0")
+		}
+		if c.DataVolume.MountOptions != nil {
+			return errors.Errorf("validation failed, 'dataVolume.mountOptions' is not supported, the data volume is mounted by bottlerocket")
+		}
+		for _, v := range c.Volumes {
+			if strings.EqualFold(v.Name, c.DataVolume.Name) {
+				return errors.Errorf("validation failed, 'dataVolume' device '%v' is already defined in 'volumes'", v.Name)
+			}
+		}
+	}
+
 	if len(c.Volumes) == 0 {
 		c.Volumes = []NodeVolume{
 			{
@@ -627,41 +1567,759 @@ func (c *EKSConfiguration) Validate() error {
 		}
 	}
 
+	if err := c.validateCapacityTypeEnforcement(); err != nil {
+		return err
+	}
+
 	for i, v := range c.LicenseSpecifications {
 		if !arn.IsARN(v) {
 			return errors.Errorf("validation failed, 'LicenseSpecifications[%d]' must be a valid IAM role ARN", i)
 		}
 	}
 
+	if !common.StringEmpty(c.UnhealthyProtectedPolicy) && !common.ContainsString(AllowedUnhealthyProtectedPolicies, c.UnhealthyProtectedPolicy) {
+		return errors.Errorf("validation failed, 'unhealthyProtectedPolicy' must be one of %v, got %v", AllowedUnhealthyProtectedPolicies, c.UnhealthyProtectedPolicy)
+	}
+	if !common.StringEmpty(c.NodeAuthentication) && !common.ContainsString(AllowedNodeAuthentications, c.NodeAuthentication) {
+		return errors.Errorf("validation failed, 'nodeAuthentication' must be one of %v, got %v", AllowedNodeAuthentications, c.NodeAuthentication)
+	}
+
+	if !common.StringEmpty(c.Region) && !AWSRegionRegex.MatchString(c.Region) {
+		return errors.Errorf("validation failed, 'region' must be a valid AWS region such as us-west-2, got %v", c.Region)
+	}
+
+	if !common.StringEmpty(c.AssumeRoleArn) {
+		roleArn, err := arn.Parse(c.AssumeRoleArn)
+		if err != nil || roleArn.Service != "iam" || !strings.HasPrefix(roleArn.Resource, "role/") {
+			return errors.Errorf("validation failed, 'assumeRoleArn' must be a valid IAM role ARN, got %v", c.AssumeRoleArn)
+		}
+	}
+
+	if !common.StringEmpty(c.AwsCallTimeout) {
+		timeout, err := time.ParseDuration(c.AwsCallTimeout)
+		if err != nil || timeout <= 0 {
+			return errors.Errorf("validation failed, 'awsCallTimeout' must be a positive duration e.g. 30s")
+		}
+	}
+
 	if c.Placement != nil {
 		if err := c.Placement.Validate(); err != nil {
 			return err
 		}
 	}
 
-	return nil
-}
-
-func (p *PlacementSpec) Validate() error {
-
-	if p == nil {
-		return nil
+	if c.AuthMappings != nil {
+		if err := c.AuthMappings.Validate(); err != nil {
+			return err
+		}
 	}
 
-	if !common.ContainsEqualFold(LaunchTemplatePlacementTenancyTypes, p.Tenancy) {
-		return errors.Errorf("validation failed, Tenancy must be one of default, dedicated, host")
+	if err := c.MetadataOptions.Validate(); err != nil {
+		return err
 	}
 
-	if !common.StringEmpty(p.HostResourceGroupArn) && !arn.IsARN(p.HostResourceGroupArn) {
-		return errors.Errorf("validation failed, HostResourceGroupArn must be a valid dedicated HostResourceGroup ARN")
+	if c.IsPublicIpAddressAssociated() && len(c.NetworkInterfaces) > 0 {
+		return errors.Errorf("validation failed, 'associatePublicIpAddress' cannot be true with 'networkInterfaces', public IPs are not assigned to instances with multiple network interfaces")
 	}
 
-	if !common.StringEmpty(p.HostResourceGroupArn) && p.Tenancy != HostPlacementTenancyType {
-		return errors.Errorf("validation failed, Tenancy must be \"host\" when HostResourceGroupArn is set")
+	deviceIndexes := make(map[int64]bool)
+	for i, n := range c.NetworkInterfaces {
+		if n.DeviceIndex < 1 {
+			return errors.Errorf("validation failed, 'networkInterfaces[%d].deviceIndex' must be greater than 0, device index 0 is the primary interface", i)
+		}
+		if deviceIndexes[n.DeviceIndex] {
+			return errors.Errorf("validation failed, 'networkInterfaces[%d].deviceIndex' %v is already in use", i, n.DeviceIndex)
+		}
+		deviceIndexes[n.DeviceIndex] = true
+		if common.StringEmpty(n.Subnet) {
+			return errors.Errorf("validation failed, 'networkInterfaces[%d].subnet' is a required parameter", i)
+		}
+		if !common.StringEmpty(n.InterfaceType) && !common.ContainsString(AllowedNetworkInterfaceTypes, n.InterfaceType) {
+			return errors.Errorf("validation failed, 'networkInterfaces[%d].interfaceType' must be one of %v, got %v", i, AllowedNetworkInterfaceTypes, n.InterfaceType)
+		}
+		// EC2 only auto-assigns public IPs to instances launched with a single network interface
+		if n.AssociatePublicIpAddress != nil && *n.AssociatePublicIpAddress {
+			return errors.Errorf("validation failed, 'networkInterfaces[%d].associatePublicIpAddress' cannot be true, public IPs are not assigned to instances with multiple network interfaces", i)
+		}
 	}
 
-	return nil
-}
+	for i, p := range c.TerminationPolicies {
+		if common.ContainsString(c.TerminationPolicies[:i], p) {
+			return errors.Errorf("validation failed, 'terminationPolicies' contains duplicate policy '%v'", p)
+		}
+		if common.ContainsString(AllowedTerminationPolicies, p) {
+			continue
+		}
+		// custom termination policies are lambda functions
+		if a, err := arn.Parse(p); err == nil && a.Service == "lambda" {
+			continue
+		}
+		return errors.Errorf("validation failed, 'terminationPolicies[%d]' must be one of %+v or a lambda function ARN, got '%v'", i, AllowedTerminationPolicies, p)
+	}
+
+	labelKeys := make([]string, 0, len(c.Labels))
+	for key := range c.Labels {
+		labelKeys = append(labelKeys, key)
+	}
+	sort.Strings(labelKeys)
+	for _, key := range labelKeys {
+		if !IsKubeletAllowedLabel(key) {
+			return errors.Errorf("validation failed, 'labels' key '%v' cannot be set by the kubelet, labels in the %v namespaces must be one of %v or in the %v namespaces", key, KubeletRestrictedLabelNamespaces, KubeletAllowedLabels, KubeletAllowedLabelNamespaces)
+		}
+	}
+
+	if c.DefaultCooldown != nil && *c.DefaultCooldown < 0 {
+		return errors.Errorf("validation failed, 'defaultCooldown' must be a non-negative number of seconds, got %v", *c.DefaultCooldown)
+	}
+
+	// tag values are only checked when nodes are labeled, since instances may be tagged after launch
+	labelTags := make(map[string]string)
+	for tag, label := range c.InstanceTagLabels {
+		if common.StringEmpty(tag) {
+			return errors.Errorf("validation failed, 'instanceTagLabels' keys must be non-empty instance tag keys")
+		}
+		if errs := validation.IsQualifiedName(label); len(errs) > 0 {
+			return errors.Errorf("validation failed, 'instanceTagLabels' label '%v' of tag '%v' is not a valid label key: %v", label, tag, strings.Join(errs, ", "))
+		}
+		if other, ok := labelTags[label]; ok {
+			return errors.Errorf("validation failed, 'instanceTagLabels' tags '%v' and '%v' map to the same label '%v'", other, tag, label)
+		}
+		labelTags[label] = tag
+	}
+
+	for conditionType, action := range c.UnhealthyNodeConditions {
+		if common.StringEmpty(conditionType) || strings.EqualFold(conditionType, string(corev1.NodeReady)) {
+			return errors.Errorf("validation failed, 'unhealthyNodeConditions' keys must be node condition types other than %v", corev1.NodeReady)
+		}
+		if !common.ContainsString(AllowedUnhealthyNodeActions, action) {
+			return errors.Errorf("validation failed, 'unhealthyNodeConditions' action '%v' of condition '%v' must be one of %v", action, conditionType, AllowedUnhealthyNodeActions)
+		}
+	}
+
+	if c.ClusterAutoscalerPriority != nil && *c.ClusterAutoscalerPriority < 0 {
+		return errors.Errorf("validation failed, 'clusterAutoscalerPriority' must be non-negative, got %v", *c.ClusterAutoscalerPriority)
+	}
+
+	if c.StartupTaint != nil {
+		if err := c.StartupTaint.Validate(c.Taints); err != nil {
+			return err
+		}
+	}
+
+	if c.InstanceMaintenancePolicy != nil {
+		if err := c.InstanceMaintenancePolicy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.ReadinessChecks != nil {
+		if err := c.ReadinessChecks.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.NodeJoinDeadline != nil {
+		if err := c.NodeJoinDeadline.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.NotReadyReplacement != nil {
+		if err := c.NotReadyReplacement.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.InstanceStorage != nil {
+		if err := c.InstanceStorage.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.RegistryCredentials != nil && common.StringEmpty(c.RegistryCredentials.SecretName) {
+		return errors.Errorf("validation failed, 'registryCredentials.secretName' is a required parameter")
+	}
+
+	if c.CNI != nil && !common.StringEmpty(c.CNI.Plugin) && !common.ContainsString(AllowedCNIPlugins, c.CNI.Plugin) {
+		return errors.Errorf("validation failed, 'cni.plugin' must be one of %v, got %v", AllowedCNIPlugins, c.CNI.Plugin)
+	}
+
+	if !strings.EqualFold(c.GetCNIPlugin(), CNIPluginAwsVpcCNI) && (!common.StringEmpty(c.VpcCNIVersion) || c.VpcCNIWarmTargets != nil) {
+		return errors.Errorf("validation failed, 'vpcCNIVersion' and 'vpcCNIWarmTargets' are only supported with cni plugin '%v'", CNIPluginAwsVpcCNI)
+	}
+
+	if !common.StringEmpty(c.VpcCNIVersion) && !VpcCNIVersionRegex.MatchString(c.VpcCNIVersion) {
+		return errors.Errorf("validation failed, 'vpcCNIVersion' must be a VPC CNI release version e.g. v1.18.3 or v1.18.3-eksbuild.1, got '%v'", c.VpcCNIVersion)
+	}
+
+	if c.VpcCNIWarmTargets != nil {
+		if err := c.VpcCNIWarmTargets.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.WindowsContainerd != nil {
+		if err := c.WindowsContainerd.Validate(c.BootstrapOptions); err != nil {
+			return err
+		}
+	}
+
+	for _, image := range c.PrePullImages {
+		if !ImageReferenceRegex.MatchString(image) {
+			return errors.Errorf("validation failed, 'prePullImages' entries must be image references, got '%v'", image)
+		}
+	}
+
+	if c.BootstrapRetries != nil {
+		if err := c.BootstrapRetries.Validate(); err != nil {
+			return err
+		}
+	}
+
+	sysctlKeys := make([]string, 0, len(c.Sysctls))
+	for key := range c.Sysctls {
+		sysctlKeys = append(sysctlKeys, key)
+	}
+	sort.Strings(sysctlKeys)
+	for _, key := range sysctlKeys {
+		if !SysctlKeyRegex.MatchString(key) {
+			return errors.Errorf("validation failed, 'sysctls' key '%v' must be a dot separated kernel, net, vm, fs or user parameter", key)
+		}
+		if !SysctlValueRegex.MatchString(c.Sysctls[key]) {
+			return errors.Errorf("validation failed, 'sysctls' value of '%v' must be a single line of space separated values, got '%v'", key, c.Sysctls[key])
+		}
+	}
+
+	if err := validateUserDataOrder(c.UserData); err != nil {
+		return err
+	}
+
+	variableNames := make([]string, 0, len(c.UserDataVariables))
+	for name := range c.UserDataVariables {
+		variableNames = append(variableNames, name)
+	}
+	sort.Strings(variableNames)
+	for _, name := range variableNames {
+		if !UserDataVariableNameRegex.MatchString(name) {
+			return errors.Errorf("validation failed, 'userDataVariables' name '%v' must start with a letter or underscore and contain only letters, digits and underscores", name)
+		}
+		if common.ContainsEqualFold(ReservedUserDataVariables, name) {
+			return errors.Errorf("validation failed, 'userDataVariables' name '%v' is reserved by a userdata field", name)
+		}
+	}
+
+	if c.ClusterNameSource != nil {
+		if err := c.ClusterNameSource.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if !common.StringEmpty(c.ApiEndpoint) && !strings.HasPrefix(c.ApiEndpoint, "https://") {
+		return errors.Errorf("validation failed, 'apiEndpoint' must be a valid https endpoint")
+	}
+
+	if !common.StringEmpty(c.ClusterCA) {
+		if _, err := base64.StdEncoding.DecodeString(c.ClusterCA); err != nil {
+			return errors.Errorf("validation failed, 'clusterCA' must be base64 encoded")
+		}
+	}
+
+	if !common.StringEmpty(c.NodeTTL) {
+		ttl, err := time.ParseDuration(c.NodeTTL)
+		if err != nil || ttl <= 0 {
+			return errors.Errorf("validation failed, 'nodeTTL' must be a positive duration e.g. 720h")
+		}
+	}
+
+	if !common.StringEmpty(c.KubeletVersion) && !KubeletVersionRegex.MatchString(c.KubeletVersion) {
+		return errors.Errorf("validation failed, 'kubeletVersion' must be a Kubernetes version e.g. 1.30 or v1.30.2, got '%v'", c.KubeletVersion)
+	}
+
+	if !common.StringEmpty(c.ImageUpdateCheckInterval) {
+		interval, err := time.ParseDuration(c.ImageUpdateCheckInterval)
+		if err != nil || interval <= 0 {
+			return errors.Errorf("validation failed, 'imageUpdateCheckInterval' must be a positive duration e.g. 6h")
+		}
+		if !c.IsImageReference() {
+			return errors.Errorf("validation failed, 'imageUpdateCheckInterval' is only supported with image '%v' or an image prefixed with '%v'", ImageLatestValue, ImageSSMPrefix)
+		}
+	}
+
+	if !common.StringEmpty(c.InitialGracePeriod) {
+		grace, err := time.ParseDuration(c.InitialGracePeriod)
+		if err != nil || grace < 0 {
+			return errors.Errorf("validation failed, 'initialGracePeriod' must be a duration e.g. 10m, or 0s to disable it")
+		}
+	}
+
+	if c.ImageAllowlist != nil {
+		if err := c.ImageAllowlist.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if !common.StringEmpty(c.ReadinessSLA) {
+		sla, err := time.ParseDuration(c.ReadinessSLA)
+		if err != nil || sla <= 0 {
+			return errors.Errorf("validation failed, 'readinessSLA' must be a positive duration e.g. 10m")
+		}
+	}
+
+	if c.Proxy != nil {
+		if err := c.Proxy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.CABundle != nil {
+		if err := c.CABundle.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *StartupTaintSpec) Validate(taints []corev1.Taint) error {
+	if common.StringEmpty(s.Key) {
+		return errors.Errorf("validation failed, 'startupTaint.key' is a required parameter")
+	}
+
+	var registered bool
+	for _, t := range taints {
+		if t.Key == s.Key {
+			registered = true
+		}
+	}
+	if !registered {
+		return errors.Errorf("validation failed, 'startupTaint.key' %v must match the key of a configured taint", s.Key)
+	}
+
+	if !common.StringEmpty(s.Timeout) {
+		timeout, err := time.ParseDuration(s.Timeout)
+		if err != nil || timeout <= 0 {
+			return errors.Errorf("validation failed, 'startupTaint.timeout' must be a positive duration e.g. 10m")
+		}
+	}
+	return nil
+}
+
+// GetTimeout returns the duration a node may keep its startup taint before it is considered stuck
+func (s *StartupTaintSpec) GetTimeout() time.Duration {
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil || timeout <= 0 {
+		return DefaultStartupTaintTimeout
+	}
+	return timeout
+}
+
+func (s *BootstrapRetriesSpec) Validate() error {
+	if s.Attempts < 1 || s.Attempts > MaxBootstrapRetryAttempts {
+		return errors.Errorf("validation failed, 'bootstrapRetries.attempts' must be between 1 and %v, got %v", MaxBootstrapRetryAttempts, s.Attempts)
+	}
+	if !common.StringEmpty(s.Backoff) {
+		backoff, err := time.ParseDuration(s.Backoff)
+		if err != nil || backoff < time.Second || backoff > time.Minute {
+			return errors.Errorf("validation failed, 'bootstrapRetries.backoff' must be a duration between 1s and 1m e.g. 5s")
+		}
+	}
+	return nil
+}
+
+// GetBackoff returns the delay before the first retry, later retries wait a multiple of it
+func (s *BootstrapRetriesSpec) GetBackoff() time.Duration {
+	backoff, err := time.ParseDuration(s.Backoff)
+	if err != nil || backoff <= 0 {
+		return DefaultBootstrapRetryBackoff
+	}
+	return backoff
+}
+
+func (s *InstanceMaintenanceSpec) Validate() error {
+	if s.MinHealthyPercentage < 0 || s.MinHealthyPercentage > 100 {
+		return errors.Errorf("validation failed, 'instanceMaintenancePolicy.minHealthyPercentage' must be between 0 and 100, got %v", s.MinHealthyPercentage)
+	}
+	if s.MaxHealthyPercentage < 100 || s.MaxHealthyPercentage > 200 {
+		return errors.Errorf("validation failed, 'instanceMaintenancePolicy.maxHealthyPercentage' must be between 100 and 200, got %v", s.MaxHealthyPercentage)
+	}
+	if s.MaxHealthyPercentage-s.MinHealthyPercentage > 100 {
+		return errors.Errorf("validation failed, 'instanceMaintenancePolicy.maxHealthyPercentage' can be at most 100 above 'minHealthyPercentage', got %v and %v", s.MaxHealthyPercentage, s.MinHealthyPercentage)
+	}
+	return nil
+}
+
+func (s *ImageAllowlistSpec) Validate() error {
+	if len(s.IDs) == 0 && len(s.Owners) == 0 && len(s.NamePatterns) == 0 {
+		return errors.Errorf("validation failed, 'imageAllowlist' must have at least one of 'ids', 'owners' or 'namePatterns'")
+	}
+	for _, id := range s.IDs {
+		if !strings.HasPrefix(id, "ami-") {
+			return errors.Errorf("validation failed, 'imageAllowlist.ids' entry '%v' must be an image id e.g. ami-12345", id)
+		}
+	}
+	for _, owner := range s.Owners {
+		if common.StringEmpty(owner) {
+			return errors.Errorf("validation failed, 'imageAllowlist.owners' entries must not be empty")
+		}
+	}
+	for _, pattern := range s.NamePatterns {
+		if _, err := path.Match(pattern, ""); err != nil || common.StringEmpty(pattern) {
+			return errors.Errorf("validation failed, 'imageAllowlist.namePatterns' entry '%v' must be a valid pattern e.g. amazon-eks-node-1.29-*", pattern)
+		}
+	}
+	return nil
+}
+
+// IsAllowed returns true if the image matches an id, owner or name pattern of the allowlist
+func (s *ImageAllowlistSpec) IsAllowed(id, ownerId, ownerAlias, name string) bool {
+	if common.ContainsEqualFold(s.IDs, id) {
+		return true
+	}
+	for _, owner := range s.Owners {
+		if owner == ownerId || (!common.StringEmpty(ownerAlias) && strings.EqualFold(owner, ownerAlias)) {
+			return true
+		}
+	}
+	for _, pattern := range s.NamePatterns {
+		if ok, _ := path.Match(pattern, name); ok && !common.StringEmpty(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ProxySpec) Validate() error {
+	if common.StringEmpty(s.HTTPProxy) && common.StringEmpty(s.HTTPSProxy) {
+		return errors.Errorf("validation failed, 'proxy' must have at least one of 'httpProxy' or 'httpsProxy'")
+	}
+	validate := func(field, proxy string) error {
+		if common.StringEmpty(proxy) {
+			return nil
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || common.StringEmpty(u.Host) || strings.ContainsAny(proxy, " \t\"'") {
+			return errors.Errorf("validation failed, 'proxy.%v' must be an http or https URL e.g. http://proxy.example.com:3128, got '%v'", field, proxy)
+		}
+		return nil
+	}
+	if err := validate("httpProxy", s.HTTPProxy); err != nil {
+		return err
+	}
+	if err := validate("httpsProxy", s.HTTPSProxy); err != nil {
+		return err
+	}
+	for _, host := range s.NoProxy {
+		if common.StringEmpty(host) || strings.ContainsAny(host, ", \t\"'") {
+			return errors.Errorf("validation failed, 'proxy.noProxy' entry '%v' must be a single host, domain or CIDR", host)
+		}
+	}
+	return nil
+}
+
+func (s *CABundleSpec) Validate() error {
+	if common.StringEmpty(s.Certificates) == (s.ConfigMapRef == nil) {
+		return errors.Errorf("validation failed, 'caBundle' must have exactly one of 'certificates' or 'configMapRef'")
+	}
+	if s.ConfigMapRef != nil && common.StringEmpty(s.ConfigMapRef.Name) {
+		return errors.Errorf("validation failed, 'caBundle.configMapRef.name' is a required parameter")
+	}
+	if !common.StringEmpty(s.Certificates) {
+		if _, err := common.ParseCertificates(s.Certificates); err != nil {
+			return errors.Wrap(err, "validation failed, 'caBundle.certificates' must be PEM encoded certificates")
+		}
+	}
+	return nil
+}
+
+func (r *CABundleConfigMapReference) GetKey() string {
+	if common.StringEmpty(r.Key) {
+		return DefaultCABundleKey
+	}
+	return r.Key
+}
+
+func (s *AuthMappingsSpec) Validate() error {
+	validate := func(field, resource string, mappings []AuthMapping) error {
+		arns := make(map[string]bool)
+		for _, mapping := range mappings {
+			parsed, err := arn.Parse(mapping.ARN)
+			if err != nil || parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, resource+"/") {
+				return errors.Errorf("validation failed, 'authMappings.%v' entry '%v' must be a valid IAM %v ARN", field, mapping.ARN, resource)
+			}
+			if arns[mapping.ARN] {
+				return errors.Errorf("validation failed, 'authMappings.%v' entry '%v' is mapped more than once", field, mapping.ARN)
+			}
+			arns[mapping.ARN] = true
+			if common.StringEmpty(mapping.Username) {
+				return errors.Errorf("validation failed, 'authMappings.%v' entry '%v' must have a username", field, mapping.ARN)
+			}
+			// system groups such as system:masters grant privileges the nodes of an instance group must not hand out
+			for _, group := range mapping.Groups {
+				if strings.HasPrefix(group, AuthMappingSystemGroupPrefix) {
+					return errors.Errorf("validation failed, 'authMappings.%v' entry '%v' cannot map to the system group '%v'", field, mapping.ARN, group)
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := validate("roles", "role", s.Roles); err != nil {
+		return err
+	}
+	return validate("users", "user", s.Users)
+}
+
+func (s *NodeJoinDeadlineSpec) Validate() error {
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil || timeout <= 0 {
+		return errors.Errorf("validation failed, 'nodeJoinDeadline.timeout' must be a positive duration e.g. 15m")
+	}
+	return nil
+}
+
+// GetTimeout returns the duration an instance may run without registering as a node before it has failed to join
+func (s *NodeJoinDeadlineSpec) GetTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(s.Timeout)
+	return timeout
+}
+
+func (s *NotReadyReplacementSpec) Validate() error {
+	threshold, err := time.ParseDuration(s.Threshold)
+	if err != nil || threshold <= 0 {
+		return errors.Errorf("validation failed, 'notReadyReplacement.threshold' must be a positive duration e.g. 10m")
+	}
+	if s.MaxUnavailable != nil {
+		if s.MaxUnavailable.Type == intstr.String {
+			if err := common.IsValidPercent(s.MaxUnavailable.StrVal); err != nil {
+				return errors.Errorf("validation failed, 'notReadyReplacement.maxUnavailable' must be a number or a percentage e.g. 25%%")
+			}
+		}
+		if common.IntOrStrValue(s.MaxUnavailable) <= 0 {
+			return errors.Errorf("validation failed, 'notReadyReplacement.maxUnavailable' must be positive")
+		}
+	}
+	return nil
+}
+
+// GetThreshold returns the duration a node may be NotReady before its instance is replaced
+func (s *NotReadyReplacementSpec) GetThreshold() time.Duration {
+	threshold, _ := time.ParseDuration(s.Threshold)
+	return threshold
+}
+
+// GetMaxUnavailable returns the number of instances which may be unavailable while NotReady instances are replaced,
+// percentages are of the given instance count and rounded up, at least one instance may be unavailable
+func (s *NotReadyReplacementSpec) GetMaxUnavailable(instanceCount int) int {
+	if s.MaxUnavailable == nil {
+		return 1
+	}
+	maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(s.MaxUnavailable, instanceCount, true)
+	if err != nil || maxUnavailable < 1 {
+		return 1
+	}
+	return maxUnavailable
+}
+
+func (r *ReadinessChecksSpec) Validate() error {
+	for _, name := range r.DaemonSets {
+		parts := strings.Split(name, "/")
+		if len(parts) > 2 || common.ContainsString(parts, "") {
+			return errors.Errorf("validation failed, readinessChecks.daemonSets entry '%v' must be a DaemonSet name or namespace/name", name)
+		}
+	}
+	return nil
+}
+
+func (r *ReadinessChecksSpec) GetDaemonSets() []string {
+	return r.DaemonSets
+}
+
+// validateUserDataOrder rejects duplicate stage orders, PreBootstrap stages always render before bootstrap and
+// PostBootstrap stages after it regardless of their order
+func validateUserDataOrder(stages []UserDataStage) error {
+	orders := make(map[int]int)
+	for i, stage := range stages {
+		order, ok := stage.GetOrder()
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(stage.Stage, NodeConfigYamlStage) {
+			return errors.Errorf("validation failed, 'userData[%d].order' is not supported for stage %v", i, NodeConfigYamlStage)
+		}
+		if j, exists := orders[order]; exists {
+			return errors.Errorf("validation failed, 'userData[%d].order' %v is already used by 'userData[%d]'", i, order, j)
+		}
+		orders[order] = i
+	}
+	return nil
+}
+
+func (w *WindowsContainerdSpec) Validate(bootstrapOptions *BootstrapOptions) error {
+	if common.StringEmpty(w.Root) && common.StringEmpty(w.State) && common.StringEmpty(w.SandboxImage) {
+		return errors.Errorf("validation failed, 'windowsContainerd' must set at least one of 'root', 'state' or 'sandboxImage'")
+	}
+	if bootstrapOptions != nil && bootstrapOptions.ContainerRuntime != "" && bootstrapOptions.ContainerRuntime != ContainerDRuntime {
+		return errors.Errorf("validation failed, 'windowsContainerd' requires 'bootstrapOptions.containerRuntime' %v, got %v", ContainerDRuntime, bootstrapOptions.ContainerRuntime)
+	}
+	if !common.StringEmpty(w.Root) && !WindowsPathRegex.MatchString(w.Root) {
+		return errors.Errorf("validation failed, 'windowsContainerd.root' must be an absolute Windows path e.g. D:\\containerd\\root, got '%v'", w.Root)
+	}
+	if !common.StringEmpty(w.State) && !WindowsPathRegex.MatchString(w.State) {
+		return errors.Errorf("validation failed, 'windowsContainerd.state' must be an absolute Windows path e.g. D:\\containerd\\state, got '%v'", w.State)
+	}
+	if !common.StringEmpty(w.SandboxImage) && !ImageReferenceRegex.MatchString(w.SandboxImage) {
+		return errors.Errorf("validation failed, 'windowsContainerd.sandboxImage' must be an image reference, got '%v'", w.SandboxImage)
+	}
+	return nil
+}
+
+func (s *InstanceStorageSpec) Validate() error {
+	if !common.StringEmpty(s.MountPath) && !LinuxPathRegex.MatchString(s.MountPath) {
+		return errors.Errorf("validation failed, 'instanceStorage.mountPath' must be an absolute path e.g. %v, got '%v'", DefaultInstanceStorageMountPath, s.MountPath)
+	}
+	if !common.StringEmpty(s.FileSystem) && !common.ContainsString(AllowedFileSystemTypes, s.FileSystem) {
+		return errors.Errorf("validation failed, 'instanceStorage.fileSystem' must be one of %v, got '%v'", AllowedFileSystemTypes, s.FileSystem)
+	}
+	if !common.StringEmpty(s.Policy) && !common.ContainsString(AllowedInstanceStoragePolicies, s.Policy) {
+		return errors.Errorf("validation failed, 'instanceStorage.policy' must be one of %v, got '%v'", AllowedInstanceStoragePolicies, s.Policy)
+	}
+	return nil
+}
+
+// GetMountPath returns where the instance store RAID0 array is mounted, defaults to the containerd root
+func (s *InstanceStorageSpec) GetMountPath() string {
+	if common.StringEmpty(s.MountPath) {
+		return DefaultInstanceStorageMountPath
+	}
+	return s.MountPath
+}
+
+// GetFileSystem returns the file system the instance store RAID0 array is formatted with, defaults to xfs
+func (s *InstanceStorageSpec) GetFileSystem() string {
+	if common.StringEmpty(s.FileSystem) {
+		return FileSystemTypeXFS
+	}
+	return s.FileSystem
+}
+
+// GetPolicy returns where container storage lives, defaults to prefer-instance-store
+func (s *InstanceStorageSpec) GetPolicy() string {
+	if common.StringEmpty(s.Policy) {
+		return InstanceStoragePolicyPreferInstanceStore
+	}
+	return s.Policy
+}
+
+func (t *VpcCNIWarmTargetsSpec) Validate() error {
+	if t.WarmIPTarget != nil && *t.WarmIPTarget < 0 {
+		return errors.Errorf("validation failed, 'vpcCNIWarmTargets.warmIPTarget' must be non-negative, got %v", *t.WarmIPTarget)
+	}
+	if t.MinimumIPTarget != nil && *t.MinimumIPTarget < 0 {
+		return errors.Errorf("validation failed, 'vpcCNIWarmTargets.minimumIPTarget' must be non-negative, got %v", *t.MinimumIPTarget)
+	}
+	if t.WarmENITarget != nil && *t.WarmENITarget < 0 {
+		return errors.Errorf("validation failed, 'vpcCNIWarmTargets.warmENITarget' must be non-negative, got %v", *t.WarmENITarget)
+	}
+
+	// the CNI ignores the warm ENI target when any IP target is set
+	if t.WarmENITarget != nil && (t.WarmIPTarget != nil || t.MinimumIPTarget != nil) {
+		return errors.Errorf("validation failed, 'vpcCNIWarmTargets.warmENITarget' cannot be combined with 'warmIPTarget' or 'minimumIPTarget'")
+	}
+
+	if t.WarmIPTarget != nil && t.MinimumIPTarget != nil && *t.WarmIPTarget == 0 && *t.MinimumIPTarget == 0 {
+		return errors.Errorf("validation failed, 'vpcCNIWarmTargets.warmIPTarget' and 'minimumIPTarget' cannot both be 0")
+	}
+	return nil
+}
+
+func (p *PlacementSpec) Validate() error {
+
+	if p == nil {
+		return nil
+	}
+
+	if !common.ContainsEqualFold(LaunchTemplatePlacementTenancyTypes, p.Tenancy) {
+		return errors.Errorf("validation failed, Tenancy must be one of default, dedicated, host")
+	}
+
+	if !common.StringEmpty(p.HostResourceGroupArn) && !arn.IsARN(p.HostResourceGroupArn) {
+		return errors.Errorf("validation failed, HostResourceGroupArn must be a valid dedicated HostResourceGroup ARN")
+	}
+
+	if !common.StringEmpty(p.HostResourceGroupArn) && p.Tenancy != HostPlacementTenancyType {
+		return errors.Errorf("validation failed, Tenancy must be \"host\" when HostResourceGroupArn is set")
+	}
+
+	return nil
+}
+
+func (c *ClusterNameSourceSpec) Validate() error {
+	if common.StringEmpty(c.Arn) == common.StringEmpty(c.Endpoint) {
+		return errors.Errorf("validation failed, exactly one of 'clusterNameSource.arn' or 'clusterNameSource.endpoint' must be provided")
+	}
+
+	if !common.StringEmpty(c.Arn) {
+		clusterArn, err := arn.Parse(c.Arn)
+		if err != nil || clusterArn.Service != "eks" || !strings.HasPrefix(clusterArn.Resource, "cluster/") {
+			return errors.Errorf("validation failed, 'clusterNameSource.arn' must be a valid EKS cluster ARN")
+		}
+	}
+
+	if !common.StringEmpty(c.Endpoint) && !strings.HasPrefix(c.Endpoint, "https://") {
+		return errors.Errorf("validation failed, 'clusterNameSource.endpoint' must be a valid https endpoint")
+	}
+
+	return nil
+}
+
+// ClusterName returns the name of the cluster referenced by the ARN source
+func (c *ClusterNameSourceSpec) ClusterName() string {
+	clusterArn, err := arn.Parse(c.Arn)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(clusterArn.Resource, "cluster/")
+}
+
+// validateCapacityTypeEnforcement rejects a spot price or a mixed instances policy which would launch a capacity type
+// the enforcement does not allow, it runs after the mixed instances policy is defaulted
+func (c *EKSConfiguration) validateCapacityTypeEnforcement() error {
+	var (
+		enforcement = c.CapacityTypeEnforcement
+		mixedPolicy = c.MixedInstancesPolicy
+	)
+
+	if common.StringEmpty(enforcement) {
+		return nil
+	}
+	if !common.ContainsString(AllowedCapacityTypeEnforcements, enforcement) {
+		return errors.Errorf("validation failed, 'capacityTypeEnforcement' must be one of %v, got %v", AllowedCapacityTypeEnforcements, enforcement)
+	}
+
+	switch enforcement {
+	case CapacityTypeEnforcementOnDemandOnly:
+		if !common.StringEmpty(c.SpotPrice) {
+			return errors.Errorf("validation failed, 'spotPrice' cannot be used with capacityTypeEnforcement '%v'", enforcement)
+		}
+		if mixedPolicy != nil && common.IntOrStrValue(mixedPolicy.SpotRatio) > 0 {
+			return errors.Errorf("validation failed, 'mixedInstancesPolicy.spotRatio' must be 0 with capacityTypeEnforcement '%v'", enforcement)
+		}
+	case CapacityTypeEnforcementSpotOnly:
+		if mixedPolicy == nil {
+			if common.StringEmpty(c.SpotPrice) {
+				return errors.Errorf("validation failed, 'spotPrice' or a 'mixedInstancesPolicy' with a spotRatio of 100%% is required with capacityTypeEnforcement '%v'", enforcement)
+			}
+			return nil
+		}
+		if common.IntOrStrValue(mixedPolicy.SpotRatio) != 100 {
+			return errors.Errorf("validation failed, 'mixedInstancesPolicy.spotRatio' must be 100%% with capacityTypeEnforcement '%v'", enforcement)
+		}
+		if common.Int64Value(mixedPolicy.BaseCapacity) > 0 {
+			return errors.Errorf("validation failed, 'mixedInstancesPolicy.baseCapacity' must be 0 with capacityTypeEnforcement '%v'", enforcement)
+		}
+	}
+	return nil
+}
 
 func (m *MixedInstancesPolicySpec) Validate() error {
 	if m.Strategy == nil {
@@ -686,10 +2344,18 @@ func (m *MixedInstancesPolicySpec) Validate() error {
 		}
 	}
 	if m.InstanceTypes != nil {
+		types := make([]string, 0)
 		for _, t := range m.InstanceTypes {
 			if t.Weight == 0 {
 				t.Weight = 1
 			}
+			if !common.Int64InRange(t.Weight, awsprovider.MinWeightedCapacity, awsprovider.MaxWeightedCapacity) {
+				return errors.Errorf("validation failed, mixedInstancesPolicy.instanceTypes weight for '%v' must be between %v and %v, got %v", t.Type, awsprovider.MinWeightedCapacity, awsprovider.MaxWeightedCapacity, t.Weight)
+			}
+			if common.ContainsEqualFold(types, t.Type) {
+				return errors.Errorf("validation failed, mixedInstancesPolicy.instanceTypes contains duplicate type '%v'", t.Type)
+			}
+			types = append(types, t.Type)
 		}
 	} else if m.InstancePool == nil {
 		return errors.Errorf("validation failed, must provide either instancePool or instanceTypes when using mixedInstancesPolicy")
@@ -752,6 +2418,10 @@ func (ig *InstanceGroup) Validate(overrides *ValidationOverrides) error {
 		}
 	}
 
+	if err := ig.validateDependsOn(); err != nil {
+		return err
+	}
+
 	if s.AwsUpgradeStrategy.Type == "" {
 		s.AwsUpgradeStrategy.Type = RollingUpdateStrategyName
 	}
@@ -774,6 +2444,80 @@ func (ig *InstanceGroup) Validate(overrides *ValidationOverrides) error {
 		s.AwsUpgradeStrategy.RollingUpdateType = DefaultRollingUpdateStrategy
 	}
 
+	if rollingUpdate := s.AwsUpgradeStrategy.GetRollingUpdateType(); rollingUpdate != nil && rollingUpdate.GetDrain() != nil {
+		if !strings.EqualFold(s.AwsUpgradeStrategy.Type, RollingUpdateStrategyName) {
+			return errors.Errorf("validation failed, 'drain' is only supported with strategy '%v'", RollingUpdateStrategyName)
+		}
+		if err := rollingUpdate.GetDrain().Validate(); err != nil {
+			return err
+		}
+	}
+
+	if rollingUpdate := s.AwsUpgradeStrategy.GetRollingUpdateType(); rollingUpdate != nil && !common.StringEmpty(rollingUpdate.GetFailureDomainLabel()) {
+		if !strings.EqualFold(s.AwsUpgradeStrategy.Type, RollingUpdateStrategyName) {
+			return errors.Errorf("validation failed, 'failureDomainLabel' is only supported with strategy '%v'", RollingUpdateStrategyName)
+		}
+		if errs := validation.IsQualifiedName(rollingUpdate.GetFailureDomainLabel()); len(errs) > 0 {
+			return errors.Errorf("validation failed, 'failureDomainLabel' must be a valid label key: %v", strings.Join(errs, ", "))
+		}
+	}
+
+	if rollingUpdate := s.AwsUpgradeStrategy.GetRollingUpdateType(); rollingUpdate != nil && rollingUpdate.GetSchedulingCheck() {
+		if !strings.EqualFold(s.AwsUpgradeStrategy.Type, RollingUpdateStrategyName) {
+			return errors.Errorf("validation failed, 'schedulingCheck' is only supported with strategy '%v'", RollingUpdateStrategyName)
+		}
+	}
+
+	if strings.EqualFold(s.AwsUpgradeStrategy.Type, BlueGreenStrategyName) {
+		if !strings.EqualFold(s.Provisioner, EKSProvisionerName) {
+			return errors.Errorf("validation failed, strategy '%v' is only supported with provisioner '%v'", BlueGreenStrategyName, EKSProvisionerName)
+		}
+		if ig.GetEKSConfiguration().IsZoneSharded() {
+			return errors.Errorf("validation failed, strategy '%v' is not supported with 'zoneSharding'", BlueGreenStrategyName)
+		}
+		if ig.GetEKSSpec().HasWarmPool() {
+			return errors.Errorf("validation failed, strategy '%v' is not supported with 'warmPool'", BlueGreenStrategyName)
+		}
+	}
+
+	if blueGreen := s.AwsUpgradeStrategy.GetBlueGreenType(); blueGreen != nil {
+		if !strings.EqualFold(s.AwsUpgradeStrategy.Type, BlueGreenStrategyName) {
+			return errors.Errorf("validation failed, 'blueGreen' is only supported with strategy '%v'", BlueGreenStrategyName)
+		}
+		if err := blueGreen.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if len(s.AwsUpgradeStrategy.MaintenanceWindows) > 0 {
+		if !strings.EqualFold(s.Provisioner, EKSProvisionerName) {
+			return errors.Errorf("validation failed, 'maintenanceWindows' is only supported with provisioner '%v'", EKSProvisionerName)
+		}
+		for _, window := range s.AwsUpgradeStrategy.MaintenanceWindows {
+			if err := window.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if strings.EqualFold(s.Provisioner, EKSProvisionerName) && ig.GetEKSConfiguration().GetNodeTTL() > 0 {
+		if !strings.EqualFold(s.AwsUpgradeStrategy.Type, RollingUpdateStrategyName) {
+			return errors.Errorf("validation failed, 'nodeTTL' is only supported with strategy '%v'", RollingUpdateStrategyName)
+		}
+	}
+
+	if strings.EqualFold(s.Provisioner, EKSProvisionerName) && ig.GetEKSConfiguration().IsSecurityGroupDriftDetectionEnabled() {
+		if !strings.EqualFold(s.AwsUpgradeStrategy.Type, RollingUpdateStrategyName) && !strings.EqualFold(s.AwsUpgradeStrategy.Type, BlueGreenStrategyName) {
+			return errors.Errorf("validation failed, 'detectSecurityGroupDrift' is only supported with strategies '%v' and '%v'", RollingUpdateStrategyName, BlueGreenStrategyName)
+		}
+	}
+
+	if strings.EqualFold(s.Provisioner, EKSProvisionerName) && ig.GetEKSConfiguration().IsCosmeticChangeRotationSkipped() {
+		if !ig.GetEKSSpec().IsLaunchTemplate() {
+			return errors.Errorf("validation failed, 'ignoreCosmeticChanges' is only supported with type '%v'", LaunchTemplate)
+		}
+	}
+
 	return nil
 }
 func (c *EKSConfiguration) GetRoleName() string {
@@ -785,9 +2529,146 @@ func (c *EKSConfiguration) GetMixedInstancesPolicy() *MixedInstancesPolicySpec {
 func (c *EKSConfiguration) GetMetadataOptions() *MetadataOptions {
 	return c.MetadataOptions
 }
+func (c *EKSConfiguration) GetNetworkInterfaces() []NetworkInterfaceSpec {
+	return c.NetworkInterfaces
+}
+func (c *EKSConfiguration) IsCapacityRebalanceEnabled() bool {
+	return c.CapacityRebalance
+}
+func (c *EKSConfiguration) GetDefaultCooldown() int64 {
+	if c.DefaultCooldown == nil {
+		return DefaultScalingGroupCooldown
+	}
+	return *c.DefaultCooldown
+}
+func (c *EKSConfiguration) IsScaleInProtectionEnabled() bool {
+	return c.ScaleInProtection
+}
+
+// GetUnhealthyProtectedPolicy returns how unhealthy instances protected from scale in are handled, defaults to report
+func (c *EKSConfiguration) GetUnhealthyProtectedPolicy() string {
+	if common.StringEmpty(c.UnhealthyProtectedPolicy) {
+		return UnhealthyProtectedPolicyReport
+	}
+	return c.UnhealthyProtectedPolicy
+}
+func (c *EKSConfiguration) GetTerminationPolicies() []string {
+	return c.TerminationPolicies
+}
+func (c *EKSConfiguration) GetStartupTaint() *StartupTaintSpec {
+	return c.StartupTaint
+}
+func (c *EKSConfiguration) GetInstanceMaintenancePolicy() *InstanceMaintenanceSpec {
+	return c.InstanceMaintenancePolicy
+}
+func (c *EKSConfiguration) GetClusterAutoscalerPriority() *int64 {
+	return c.ClusterAutoscalerPriority
+}
+func (c *EKSConfiguration) GetRegistryCredentials() *RegistryCredentialsSpec {
+	return c.RegistryCredentials
+}
+
+// GetCNIPlugin returns the CNI plugin of the instance group's nodes, which defaults to the VPC CNI
+func (c *EKSConfiguration) GetCNIPlugin() string {
+	if c.CNI == nil || common.StringEmpty(c.CNI.Plugin) {
+		return CNIPluginAwsVpcCNI
+	}
+	return c.CNI.Plugin
+}
+
+func (c *EKSConfiguration) GetVpcCNIVersion() string {
+	return c.VpcCNIVersion
+}
+func (c *EKSConfiguration) GetVpcCNIWarmTargets() *VpcCNIWarmTargetsSpec {
+	return c.VpcCNIWarmTargets
+}
+func (c *EKSConfiguration) GetWindowsContainerd() *WindowsContainerdSpec {
+	return c.WindowsContainerd
+}
+func (c *EKSConfiguration) GetInstanceStorage() *InstanceStorageSpec {
+	return c.InstanceStorage
+}
+func (c *EKSConfiguration) GetPrePullImages() []string {
+	return c.PrePullImages
+}
+func (c *EKSConfiguration) GetBootstrapRetries() *BootstrapRetriesSpec {
+	return c.BootstrapRetries
+}
+func (c *EKSConfiguration) GetUserDataVariables() map[string]string {
+	return c.UserDataVariables
+}
+func (c *EKSConfiguration) GetSysctls() map[string]string {
+	return c.Sysctls
+}
+func (c *EKSConfiguration) GetAssumeRoleArn() string {
+	return c.AssumeRoleArn
+}
+
+// GetAwsCallTimeout returns the deadline of the instance group's AWS API calls, or zero if the controller's default applies
+func (c *EKSConfiguration) GetAwsCallTimeout() time.Duration {
+	timeout, err := time.ParseDuration(c.AwsCallTimeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+func (c *EKSConfiguration) GetProxy() *ProxySpec {
+	return c.Proxy
+}
+
+func (c *EKSConfiguration) GetCABundle() *CABundleSpec {
+	return c.CABundle
+}
+
+func (c *EKSConfiguration) GetImageAllowlist() *ImageAllowlistSpec {
+	return c.ImageAllowlist
+}
+
+func (c *EKSConfiguration) GetAuthMappings() *AuthMappingsSpec {
+	return c.AuthMappings
+}
+
+// GetNodeAuthentication returns how the node role is authorized to join the cluster, defaults to the aws-auth configmap
+func (c *EKSConfiguration) GetNodeAuthentication() string {
+	if common.StringEmpty(c.NodeAuthentication) {
+		return NodeAuthenticationAwsAuth
+	}
+	return c.NodeAuthentication
+}
+func (c *EKSConfiguration) GetReadinessChecks() *ReadinessChecksSpec {
+	return c.ReadinessChecks
+}
+func (c *EKSConfiguration) GetNodeJoinDeadline() *NodeJoinDeadlineSpec {
+	return c.NodeJoinDeadline
+}
+func (c *EKSConfiguration) GetNotReadyReplacement() *NotReadyReplacementSpec {
+	return c.NotReadyReplacement
+}
+func (c *EKSConfiguration) GetRegion() string {
+	return c.Region
+}
+func (c *EKSConfiguration) GetAssociatePublicIpAddress() *bool {
+	return c.AssociatePublicIpAddress
+}
+func (c *EKSConfiguration) IsPublicIpAddressAssociated() bool {
+	return c.AssociatePublicIpAddress != nil && *c.AssociatePublicIpAddress
+}
 func (c *EKSConfiguration) GetPlacement() *PlacementSpec {
 	return c.Placement
 }
+func (c *EKSConfiguration) IsZoneSharded() bool {
+	return c.ZoneSharding
+}
+func (c *EKSConfiguration) GetClusterNameSource() *ClusterNameSourceSpec {
+	return c.ClusterNameSource
+}
+func (c *EKSConfiguration) GetApiEndpoint() string {
+	return c.ApiEndpoint
+}
+func (c *EKSConfiguration) GetClusterCA() string {
+	return c.ClusterCA
+}
 func (c *EKSConfiguration) GetLifecycleHooks() []LifecycleHookSpec {
 	return c.LifecycleHooks
 }
@@ -829,6 +2710,12 @@ func (c *EKSConfiguration) GetLabels() map[string]string {
 func (c *EKSConfiguration) SetLabels(labels map[string]string) {
 	c.Labels = labels
 }
+func (c *EKSConfiguration) GetUnhealthyNodeConditions() map[string]string {
+	return c.UnhealthyNodeConditions
+}
+func (c *EKSConfiguration) GetInstanceTagLabels() map[string]string {
+	return c.InstanceTagLabels
+}
 func (c *EKSConfiguration) GetTaints() []corev1.Taint {
 	return c.Taints
 }
@@ -854,6 +2741,74 @@ func (c *EKSConfiguration) SetMetricsCollection(metrics []string) {
 func (c *EKSConfiguration) GetVolumes() []NodeVolume {
 	return c.Volumes
 }
+func (c *EKSConfiguration) GetDataVolume() *NodeVolume {
+	return c.DataVolume
+}
+
+func (c *EKSConfiguration) IsSourceDestCheckDisabled() bool {
+	return c.DisableSourceDestCheck
+}
+
+// IsSecurityGroupDriftDetectionEnabled returns true if running instances are checked for security group drift
+func (c *EKSConfiguration) IsSecurityGroupDriftDetectionEnabled() bool {
+	return c.DetectSecurityGroupDrift
+}
+
+// IsCosmeticChangeRotationSkipped returns true if instances running a launch template version which only differs from
+// the active version in data which does not affect nodes are not rotated
+func (c *EKSConfiguration) IsCosmeticChangeRotationSkipped() bool {
+	return c.IgnoreCosmeticChanges
+}
+
+// IsImageReference returns true if the image is resolved to the image currently published in SSM
+func (c *EKSConfiguration) IsImageReference() bool {
+	return strings.EqualFold(c.Image, ImageLatestValue) || strings.HasPrefix(c.Image, ImageSSMPrefix)
+}
+
+// GetKubeletVersion returns the Kubernetes version of the instance group's image, or an empty string if it is determined
+// from the image
+func (c *EKSConfiguration) GetKubeletVersion() string {
+	return c.KubeletVersion
+}
+
+// GetImageUpdateCheckInterval returns the interval at which the image reference is resolved again, or zero if scheduled
+// image update checks are not enabled
+func (c *EKSConfiguration) GetImageUpdateCheckInterval() time.Duration {
+	interval, err := time.ParseDuration(c.ImageUpdateCheckInterval)
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+// GetNodeTTL returns the maximum age of an instance, or zero if node TTL is not enabled
+func (c *EKSConfiguration) GetNodeTTL() time.Duration {
+	ttl, err := time.ParseDuration(c.NodeTTL)
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
+
+// GetReadinessSLA returns the time the instance group is expected to take to become fully ready after it was created or
+// scaled up, or zero if no SLA is configured
+func (c *EKSConfiguration) GetReadinessSLA() time.Duration {
+	sla, err := time.ParseDuration(c.ReadinessSLA)
+	if err != nil || sla < 0 {
+		return 0
+	}
+	return sla
+}
+
+// GetInitialGracePeriod returns how long after its creation the scaling group's instances are expected to be launching
+func (c *EKSConfiguration) GetInitialGracePeriod() time.Duration {
+	grace, err := time.ParseDuration(c.InitialGracePeriod)
+	if err != nil || grace < 0 {
+		return DefaultInitialGracePeriod
+	}
+	return grace
+}
+
 func (c *EKSConfiguration) GetBootstrapArguments() string {
 	return c.BootstrapArguments
 }
@@ -883,12 +2838,34 @@ func (c *EKSConfiguration) GetSubnets() []string {
 	}
 	return c.Subnets
 }
+
+func scalingProcessName(process string) (string, bool) {
+	for _, name := range awsprovider.DefaultSuspendProcesses {
+		if strings.EqualFold(name, process) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func scalingMetricName(metric string) (string, bool) {
+	for _, name := range awsprovider.DefaultAutoscalingMetrics {
+		if strings.EqualFold(name, metric) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 func (c *EKSConfiguration) GetSuspendProcesses() []string {
 	if c.SuspendedProcesses == nil {
 		return []string{}
 	}
 	return c.SuspendedProcesses
 }
+func (c *EKSConfiguration) GetCapacityTypeEnforcement() string {
+	return c.CapacityTypeEnforcement
+}
 func (c *EKSConfiguration) GetSpotPrice() string {
 	return c.SpotPrice
 }
@@ -953,6 +2930,14 @@ func (s *AwsUpgradeStrategy) SetRollingUpdateType(ru *RollingUpdateStrategy) {
 	s.RollingUpdateType = ru
 }
 
+func (s *AwsUpgradeStrategy) GetBlueGreenType() *BlueGreenStrategy {
+	return s.BlueGreenType
+}
+
+func (s *AwsUpgradeStrategy) SetBlueGreenType(blueGreen *BlueGreenStrategy) {
+	s.BlueGreenType = blueGreen
+}
+
 func (s *AwsUpgradeStrategy) GetCRDType() *CRDUpdateStrategy {
 	return s.CRDType
 }
@@ -961,6 +2946,84 @@ func (s *AwsUpgradeStrategy) SetCRDType(crd *CRDUpdateStrategy) {
 	s.CRDType = crd
 }
 
+func (s *AwsUpgradeStrategy) GetMaintenanceWindows() []MaintenanceWindowSpec {
+	return s.MaintenanceWindows
+}
+
+// InMaintenanceWindow returns true if nodes may be rotated at the given time
+func (s *AwsUpgradeStrategy) InMaintenanceWindow(t time.Time) bool {
+	if len(s.MaintenanceWindows) == 0 {
+		return true
+	}
+	for _, window := range s.MaintenanceWindows {
+		if window.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *MaintenanceWindowSpec) Validate() error {
+	for _, day := range w.Days {
+		if !common.ContainsEqualFold(MaintenanceWindowDays, day) {
+			return errors.Errorf("validation failed, 'maintenanceWindows.days' entry '%v' must be one of %v", day, MaintenanceWindowDays)
+		}
+	}
+
+	start, err := time.Parse(MaintenanceWindowTimeFormat, w.StartTime)
+	if err != nil {
+		return errors.Errorf("validation failed, 'maintenanceWindows.startTime' must be a time of day e.g. 22:00, got '%v'", w.StartTime)
+	}
+	end, err := time.Parse(MaintenanceWindowTimeFormat, w.EndTime)
+	if err != nil {
+		return errors.Errorf("validation failed, 'maintenanceWindows.endTime' must be a time of day e.g. 06:00, got '%v'", w.EndTime)
+	}
+	if start.Equal(end) {
+		return errors.Errorf("validation failed, 'maintenanceWindows.startTime' and 'maintenanceWindows.endTime' cannot be equal")
+	}
+
+	if _, err := time.LoadLocation(w.TimeZone); err != nil {
+		return errors.Errorf("validation failed, 'maintenanceWindows.timeZone' must be an IANA time zone e.g. America/Los_Angeles, got '%v'", w.TimeZone)
+	}
+	return nil
+}
+
+// Contains returns true if the time falls within the window, windows which close on the next day are matched by the
+// day they open on
+func (w *MaintenanceWindowSpec) Contains(t time.Time) bool {
+	location, err := time.LoadLocation(w.TimeZone)
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse(MaintenanceWindowTimeFormat, w.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(MaintenanceWindowTimeFormat, w.EndTime)
+	if err != nil {
+		return false
+	}
+
+	var (
+		local       = t.In(location)
+		minute      = local.Hour()*60 + local.Minute()
+		startMinute = start.Hour()*60 + start.Minute()
+		endMinute   = end.Hour()*60 + end.Minute()
+		startsOnDay = func(day time.Weekday) bool {
+			return len(w.Days) == 0 || common.ContainsEqualFold(w.Days, day.String())
+		}
+	)
+
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute && startsOnDay(local.Weekday())
+	}
+	// the window wraps past midnight, times before the end belong to the window opened on the previous day
+	if minute >= startMinute {
+		return startsOnDay(local.Weekday())
+	}
+	return minute < endMinute && startsOnDay(local.AddDate(0, 0, -1).Weekday())
+}
+
 func (c *CRDUpdateStrategy) Validate() error {
 	if c.GetSpec() == "" {
 		return errors.New("spec is empty")
@@ -1062,6 +3125,8 @@ func (status *InstanceGroupStatus) SetActiveLaunchConfigurationName(name string)
 	status.ActiveLaunchConfigurationName = name
 	status.ActiveLaunchTemplateName = ""
 	status.LatestTemplateVersion = ""
+	status.ActiveLaunchTemplateID = ""
+	status.ActiveLaunchTemplateVersion = ""
 }
 
 func (status *InstanceGroupStatus) SetActiveLaunchTemplateName(name string) {
@@ -1077,6 +3142,39 @@ func (status *InstanceGroupStatus) GetLatestTemplateVersion() string {
 	return status.LatestTemplateVersion
 }
 
+func (status *InstanceGroupStatus) SetActiveLaunchTemplateID(id string) {
+	status.ActiveLaunchTemplateID = id
+}
+
+func (status *InstanceGroupStatus) GetActiveLaunchTemplateID() string {
+	return status.ActiveLaunchTemplateID
+}
+
+func (status *InstanceGroupStatus) SetActiveLaunchTemplateVersion(version string) {
+	status.ActiveLaunchTemplateVersion = version
+}
+
+func (status *InstanceGroupStatus) GetActiveLaunchTemplateVersion() string {
+	return status.ActiveLaunchTemplateVersion
+}
+
+func (status *InstanceGroupStatus) GetCleanedLaunchTemplateVersions() int {
+	return status.CleanedLaunchTemplateVersions
+}
+
+// AddCleanedLaunchTemplateVersions adds to the total number of launch template versions deleted by the controller
+func (status *InstanceGroupStatus) AddCleanedLaunchTemplateVersions(count int) {
+	status.CleanedLaunchTemplateVersions += count
+}
+
+func (status *InstanceGroupStatus) SetActiveImageID(id string) {
+	status.ActiveImageID = id
+}
+
+func (status *InstanceGroupStatus) GetActiveImageID() string {
+	return status.ActiveImageID
+}
+
 func (status *InstanceGroupStatus) GetConfigHash() string {
 	return status.ConfigHash
 }
@@ -1085,6 +3183,70 @@ func (status *InstanceGroupStatus) SetConfigHash(hash string) {
 	status.ConfigHash = hash
 }
 
+func (status *InstanceGroupStatus) GetReadiness() *ReadinessStatus {
+	return status.Readiness
+}
+
+func (status *InstanceGroupStatus) SetReadiness(readiness *ReadinessStatus) {
+	status.Readiness = readiness
+}
+
+func (status *InstanceGroupStatus) GetAuthMappings() *AuthMappingsStatus {
+	return status.AuthMappings
+}
+
+func (status *InstanceGroupStatus) SetAuthMappings(mappings *AuthMappingsStatus) {
+	status.AuthMappings = mappings
+}
+
+func (status *InstanceGroupStatus) GetManagedRoleTags() []string {
+	return status.ManagedRoleTags
+}
+
+func (status *InstanceGroupStatus) SetManagedRoleTags(keys []string) {
+	status.ManagedRoleTags = keys
+}
+
+func (status *InstanceGroupStatus) GetRegistryCredentialsParameter() string {
+	return status.RegistryCredentialsParameter
+}
+
+func (status *InstanceGroupStatus) SetRegistryCredentialsParameter(name string) {
+	status.RegistryCredentialsParameter = name
+}
+
+func (status *InstanceGroupStatus) GetSourceDestCheckDisabled() bool {
+	return status.SourceDestCheckDisabled
+}
+
+func (status *InstanceGroupStatus) SetSourceDestCheckDisabled(disabled bool) {
+	status.SourceDestCheckDisabled = disabled
+}
+
+func (status *InstanceGroupStatus) GetClusterAutoscalerPriority() *int64 {
+	return status.ClusterAutoscalerPriority
+}
+
+func (status *InstanceGroupStatus) SetClusterAutoscalerPriority(priority *int64) {
+	status.ClusterAutoscalerPriority = priority
+}
+
+func (status *InstanceGroupStatus) GetClusterAutoscalerPriorityScalingGroups() []string {
+	return status.ClusterAutoscalerPriorityScalingGroups
+}
+
+func (status *InstanceGroupStatus) SetClusterAutoscalerPriorityScalingGroups(names []string) {
+	status.ClusterAutoscalerPriorityScalingGroups = names
+}
+
+func (status *InstanceGroupStatus) GetUserDataHash() string {
+	return status.UserDataHash
+}
+
+func (status *InstanceGroupStatus) SetUserDataHash(hash string) {
+	status.UserDataHash = hash
+}
+
 func (status *InstanceGroupStatus) GetNodesReadyCondition() corev1.ConditionStatus {
 	for _, c := range status.Conditions {
 		if c.Type == NodesReady {
@@ -1094,6 +3256,33 @@ func (status *InstanceGroupStatus) GetNodesReadyCondition() corev1.ConditionStat
 	return corev1.ConditionFalse
 }
 
+func (status *InstanceGroupStatus) GetStartupTaintTimeoutCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == NodesStartupTaintTimeout {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
+func (status *InstanceGroupStatus) GetFailedJoinCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == NodesFailedToJoin {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
+func (status *InstanceGroupStatus) GetUnhealthyInstancesProtectedCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == UnhealthyInstancesProtected {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
 func (status *InstanceGroupStatus) GetActiveScalingGroupName() string {
 	return status.ActiveScalingGroupName
 }
@@ -1154,6 +3343,117 @@ func (status *InstanceGroupStatus) SetCurrentMax(max int) {
 	status.CurrentMax = max
 }
 
+func (status *InstanceGroupStatus) GetZoneCapacity() []ZoneCapacityStatus {
+	return status.ZoneCapacity
+}
+
+func (status *InstanceGroupStatus) SetZoneCapacity(capacity []ZoneCapacityStatus) {
+	status.ZoneCapacity = capacity
+}
+
+func (status *InstanceGroupStatus) GetPrefixDensity() *PrefixDensityStatus {
+	return status.PrefixDensity
+}
+
+func (status *InstanceGroupStatus) SetPrefixDensity(prefixDensity *PrefixDensityStatus) {
+	status.PrefixDensity = prefixDensity
+}
+
+func (status *InstanceGroupStatus) GetCostEstimate() *CostEstimateStatus {
+	return status.CostEstimate
+}
+
+func (status *InstanceGroupStatus) SetCostEstimate(costEstimate *CostEstimateStatus) {
+	status.CostEstimate = costEstimate
+}
+
+func (status *InstanceGroupStatus) GetBlueGreen() *BlueGreenStatus {
+	return status.BlueGreen
+}
+
+func (status *InstanceGroupStatus) SetBlueGreen(blueGreen *BlueGreenStatus) {
+	status.BlueGreen = blueGreen
+}
+
+// IsBlueGreenRolledBack returns true if the blue/green rotation was rolled back, and is not retried until the scaling
+// configuration changes
+func (status *InstanceGroupStatus) IsBlueGreenRolledBack() bool {
+	return status.BlueGreen != nil && status.BlueGreen.Phase == BlueGreenPhaseRolledBack
+}
+
+// GetBlueGreenSurge returns the number of green instances a blue/green rotation launched in addition to the desired
+// capacity, or zero when no rotation is in progress
+func (status *InstanceGroupStatus) GetBlueGreenSurge() int64 {
+	if status.BlueGreen == nil || status.BlueGreen.Phase == BlueGreenPhaseRolledBack {
+		return 0
+	}
+	return int64(status.BlueGreen.GreenCapacity)
+}
+
+func (status *InstanceGroupStatus) GetImageUpdate() *ImageUpdateStatus {
+	return status.ImageUpdate
+}
+
+func (status *InstanceGroupStatus) SetImageUpdate(update *ImageUpdateStatus) {
+	status.ImageUpdate = update
+}
+
+func (status *InstanceGroupStatus) GetLifecycleCapacity() *LifecycleCapacityStatus {
+	return status.LifecycleCapacity
+}
+
+func (status *InstanceGroupStatus) SetLifecycleCapacity(capacity *LifecycleCapacityStatus) {
+	status.LifecycleCapacity = capacity
+}
+
+func (status *InstanceGroupStatus) GetProtectedInstances() []string {
+	return status.ProtectedInstances
+}
+
+func (status *InstanceGroupStatus) SetProtectedInstances(instances []string) {
+	status.ProtectedInstances = instances
+}
+
+func (status *InstanceGroupStatus) GetFailedJoinInstances() []string {
+	return status.FailedJoinInstances
+}
+
+func (status *InstanceGroupStatus) SetFailedJoinInstances(instances []string) {
+	status.FailedJoinInstances = instances
+}
+
+func (status *InstanceGroupStatus) GetBootstrapDiagnostics() map[string]string {
+	return status.BootstrapDiagnostics
+}
+
+func (status *InstanceGroupStatus) SetBootstrapDiagnostics(diagnostics map[string]string) {
+	status.BootstrapDiagnostics = diagnostics
+}
+
+func (status *InstanceGroupStatus) GetNotReadyInstances() []string {
+	return status.NotReadyInstances
+}
+
+func (status *InstanceGroupStatus) SetNotReadyInstances(instances []string) {
+	status.NotReadyInstances = instances
+}
+
+func (status *InstanceGroupStatus) GetUnhealthyProtectedInstances() []string {
+	return status.UnhealthyProtectedInstances
+}
+
+func (status *InstanceGroupStatus) SetUnhealthyProtectedInstances(instances []string) {
+	status.UnhealthyProtectedInstances = instances
+}
+
+func (status *InstanceGroupStatus) GetRotationStarted() bool {
+	return status.RotationStarted
+}
+
+func (status *InstanceGroupStatus) SetRotationStarted(started bool) {
+	status.RotationStarted = started
+}
+
 func (status *InstanceGroupStatus) GetUsingSpotRecommendation() bool {
 	return status.UsingSpotRecommendation
 }
@@ -1178,6 +3478,90 @@ func (status *InstanceGroupStatus) SetConditions(conditions []InstanceGroupCondi
 	status.Conditions = conditions
 }
 
+// SetCondition adds the condition or replaces an existing condition of the same type
+func (status *InstanceGroupStatus) SetCondition(condition InstanceGroupCondition) {
+	for i, c := range status.Conditions {
+		if c.Type == condition.Type {
+			status.Conditions[i] = condition
+			return
+		}
+	}
+	status.Conditions = append(status.Conditions, condition)
+}
+
+// RemoveCondition removes the condition of the given type if it exists
+func (status *InstanceGroupStatus) RemoveCondition(conditionType InstanceGroupConditionType) {
+	for i, c := range status.Conditions {
+		if c.Type == conditionType {
+			status.Conditions = append(status.Conditions[:i], status.Conditions[i+1:]...)
+			return
+		}
+	}
+}
+
+func (status *InstanceGroupStatus) GetWaitingForMaintenanceWindowCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == WaitingForMaintenanceWindow {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
+func (status *InstanceGroupStatus) GetRotationUnschedulableCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == RotationUnschedulable {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
+func (status *InstanceGroupStatus) GetBlueGreenRolledBackCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == BlueGreenRolledBack {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
+func (status *InstanceGroupStatus) GetRotationDeniedCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == RotationDenied {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
+func (status *InstanceGroupStatus) GetWaitingForDependenciesCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == WaitingForDependencies {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
+func (status *InstanceGroupStatus) GetImageNotAllowedCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == ImageNotAllowed {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
+func (status *InstanceGroupStatus) GetUserDataValidationFailedCondition() corev1.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == UserDataValidationFailed {
+			return c.Status
+		}
+	}
+	return corev1.ConditionFalse
+}
+
 func (strategy *AwsUpgradeStrategy) GetType() string {
 	return strategy.Type
 }
@@ -14,10 +14,15 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type EksUnitTest struct {
@@ -36,6 +41,9 @@ func (u *EksUnitTest) Run(t *testing.T) string {
 
 func TestInstanceGroupSpecValidate(t *testing.T) {
 	launchconfiguration := LaunchConfiguration
+	associatePublicIp := true
+	warmTarget, zeroTarget, negativeTarget := int64(5), int64(0), int64(-1)
+	firstStage, secondStage, thirdStage := 1, 2, 3
 	type args struct {
 		instancegroup *InstanceGroup
 		overrides     *ValidationOverrides
@@ -247,7 +255,7 @@ func TestInstanceGroupSpecValidate(t *testing.T) {
 						Volumes: []NodeVolume{
 							{
 								Type:       "gp3",
-								Iops:       230,
+								Iops:       3000,
 								Throughput: 1000,
 							},
 						},
@@ -316,6 +324,3119 @@ func TestInstanceGroupSpecValidate(t *testing.T) {
 			},
 			want: "validation failed, volume type 'gp2' does not support provisioned iops",
 		},
+		{
+			name: "eks with gp3 volume with iops out of range fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Volumes: []NodeVolume{
+							{
+								Type:       "gp3",
+								Iops:       20000,
+								Throughput: 500,
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, volume type 'gp3' iops must be between 3000 and 16000",
+		},
+		{
+			name: "eks with gp3 volume with throughput out of range fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Volumes: []NodeVolume{
+							{
+								Type:       "gp3",
+								Iops:       6000,
+								Throughput: 100,
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, volume type 'gp3' throughput must be between 125 and 1000",
+		},
+		{
+			name: "eks with io2 volume with provisioned iops validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Volumes: []NodeVolume{
+							{
+								Type: "io2",
+								Iops: 100000,
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with mixed instances weights validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 10,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						MixedInstancesPolicy: &MixedInstancesPolicySpec{
+							InstanceTypes: []*InstanceTypeSpec{
+								{Type: "m5a.large", Weight: 1},
+								{Type: "m5.xlarge", Weight: 2},
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with mixed instances negative weight fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 10,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						MixedInstancesPolicy: &MixedInstancesPolicySpec{
+							InstanceTypes: []*InstanceTypeSpec{
+								{Type: "m5a.large", Weight: -1},
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, mixedInstancesPolicy.instanceTypes weight for 'm5a.large' must be between 1 and 999, got -1",
+		},
+		{
+			name: "eks with mixed instances out of range weight fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 2000,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						MixedInstancesPolicy: &MixedInstancesPolicySpec{
+							InstanceTypes: []*InstanceTypeSpec{
+								{Type: "m5a.large", Weight: 1000},
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, mixedInstancesPolicy.instanceTypes weight for 'm5a.large' must be between 1 and 999, got 1000",
+		},
+		{
+			name: "eks with mixed instances weight exceeding maxSize fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 3,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						MixedInstancesPolicy: &MixedInstancesPolicySpec{
+							InstanceTypes: []*InstanceTypeSpec{
+								{Type: "m5.xlarge", Weight: 4},
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, mixedInstancesPolicy.instanceTypes weight for 'm5.xlarge' cannot exceed maxSize 3, got 4",
+		},
+		{
+			name: "eks with mixed instances duplicate types fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 10,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						MixedInstancesPolicy: &MixedInstancesPolicySpec{
+							InstanceTypes: []*InstanceTypeSpec{
+								{Type: "m5a.large", Weight: 1},
+								{Type: "m5a.large", Weight: 2},
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, mixedInstancesPolicy.instanceTypes contains duplicate type 'm5a.large'",
+		},
+		{
+			name: "eks with zone sharding validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 10,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ZoneSharding:       true,
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with zone sharding and warm pool fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 10,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					WarmPool: &WarmPoolSpec{
+						MaxSize: -1,
+						MinSize: 0,
+					},
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ZoneSharding:       true,
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, cannot use warmPool with zoneSharding",
+		},
+		{
+			name: "eks with zone sharding and placement availability zone fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 10,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ZoneSharding:       true,
+						Placement: &PlacementSpec{
+							AvailabilityZone: "us-west-2a",
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, cannot use placement.availabilityZone with zoneSharding",
+		},
+		{
+			name: "eks with cluster name source arn validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ClusterNameSource:  &ClusterNameSourceSpec{Arn: "arn:aws:eks:us-west-2:123456789012:cluster/control-plane"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with cluster name source endpoint validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ClusterNameSource:  &ClusterNameSourceSpec{Endpoint: "https://ABCDEF.gr7.us-west-2.eks.amazonaws.com"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with empty cluster name source fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ClusterNameSource:  &ClusterNameSourceSpec{},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, exactly one of 'clusterNameSource.arn' or 'clusterNameSource.endpoint' must be provided",
+		},
+		{
+			name: "eks with cluster name source arn and endpoint fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ClusterNameSource:  &ClusterNameSourceSpec{Arn: "arn:aws:eks:us-west-2:123456789012:cluster/control-plane", Endpoint: "https://ABCDEF.gr7.us-west-2.eks.amazonaws.com"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, exactly one of 'clusterNameSource.arn' or 'clusterNameSource.endpoint' must be provided",
+		},
+		{
+			name: "eks with cluster name source non-cluster arn fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ClusterNameSource:  &ClusterNameSourceSpec{Arn: "arn:aws:iam::123456789012:role/some-role"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'clusterNameSource.arn' must be a valid EKS cluster ARN",
+		},
+		{
+			name: "eks with cluster name source http endpoint fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ClusterNameSource:  &ClusterNameSourceSpec{Endpoint: "http://ABCDEF.gr7.us-west-2.eks.amazonaws.com"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'clusterNameSource.endpoint' must be a valid https endpoint",
+		},
+		{
+			name: "eks with explicit apiEndpoint and clusterCA validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ApiEndpoint:        "https://ABCDEF.gr7.us-west-2.eks.amazonaws.com",
+						ClusterCA:          "dGVzdA==",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with non-https apiEndpoint fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ApiEndpoint:        "ABCDEF.gr7.us-west-2.eks.amazonaws.com",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'apiEndpoint' must be a valid https endpoint",
+		},
+		{
+			name: "eks with non-base64 clusterCA fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ClusterCA:          "-----BEGIN CERTIFICATE-----",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'clusterCA' must be base64 encoded",
+		},
+		{
+			name: "eks with data volume validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						DataVolume: &NodeVolume{
+							Type: "gp3",
+							Size: 50,
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with data volume without size fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						DataVolume: &NodeVolume{
+							Type: "gp3",
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'dataVolume.size' must be greater than 0",
+		},
+		{
+			name: "eks with data volume of unsupported type fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						DataVolume: &NodeVolume{
+							Type: "magnetic",
+							Size: 50,
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, volume type 'magnetic' is unsupported",
+		},
+		{
+			name: "eks with data volume with mount options fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						DataVolume: &NodeVolume{
+							Type:         "gp3",
+							Size:         50,
+							MountOptions: &NodeVolumeMountOptions{FileSystem: "xfs", Mount: "/data"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'dataVolume.mountOptions' is not supported, the data volume is mounted by bottlerocket",
+		},
+		{
+			name: "eks with data volume conflicting with volumes fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Volumes: []NodeVolume{
+							{Name: "/dev/xvdb", Type: "gp3", Size: 20},
+						},
+						DataVolume: &NodeVolume{
+							Type: "gp3",
+							Size: 50,
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'dataVolume' device '/dev/xvdb' is already defined in 'volumes'",
+		},
+		{
+			name: "eks with node ttl validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NodeTTL:            "720h",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid node ttl fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NodeTTL:            "30d",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'nodeTTL' must be a positive duration e.g. 720h",
+		},
+		{
+			name: "eks with node ttl and managed strategy fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "managed", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NodeTTL:            "720h",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'nodeTTL' is only supported with strategy 'rollingupdate'",
+		},
+		{
+			name: "eks with iam compatible tags validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Tags: []map[string]string{
+							{"key": "cost-center", "value": "team@example.com"},
+							{"key": "kubernetes.io/cluster/my-eks-cluster", "value": "owned"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with tags which do not meet iam constraints validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Tags: []map[string]string{
+							{"key": "cost-center", "value": "#1234"},
+							{"key": "Cost-Center", "value": "5678"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with networkInterfaces validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 1, Subnet: "subnet-3333333"},
+							{DeviceIndex: 2, Subnet: "subnet-4444444"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with duplicate networkInterfaces deviceIndex fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 1, Subnet: "subnet-3333333"},
+							{DeviceIndex: 1, Subnet: "subnet-4444444"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'networkInterfaces[1].deviceIndex' 1 is already in use",
+		},
+		{
+			name: "eks with primary networkInterfaces deviceIndex fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 0, Subnet: "subnet-3333333"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'networkInterfaces[0].deviceIndex' must be greater than 0, device index 0 is the primary interface",
+		},
+		{
+			name: "eks with networkInterfaces without subnet fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 1},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'networkInterfaces[0].subnet' is a required parameter",
+		},
+		{
+			name: "eks with networkInterfaces public ip fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 1, Subnet: "subnet-3333333", AssociatePublicIpAddress: &associatePublicIp},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'networkInterfaces[0].associatePublicIpAddress' cannot be true, public IPs are not assigned to instances with multiple network interfaces",
+		},
+		{
+			name: "eks with efa networkInterfaces validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "p4d.24xlarge",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 1, Subnet: "subnet-3333333", InterfaceType: "efa"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with networkInterfaces of invalid interface type fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "p4d.24xlarge",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 1, Subnet: "subnet-3333333", InterfaceType: "sriov"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'networkInterfaces[0].interfaceType' must be one of [interface efa], got sriov",
+		},
+		{
+			name: "eks with networkInterfaces and launchconfiguration fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchConfiguration",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 1, Subnet: "subnet-3333333"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, field 'networkInterfaces' is only valid for LaunchTemplates",
+		},
+		{
+			name: "eks with associatePublicIpAddress validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:           "my-eks-cluster",
+						NodeSecurityGroups:       []string{"sg-123456789"},
+						Image:                    "ami-12345",
+						InstanceType:             "m5.large",
+						KeyPairName:              "thisShouldBeOptional",
+						Subnets:                  []string{"subnet-1111111", "subnet-222222"},
+						AssociatePublicIpAddress: &associatePublicIp,
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with associatePublicIpAddress and launchconfiguration fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchConfiguration",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:           "my-eks-cluster",
+						NodeSecurityGroups:       []string{"sg-123456789"},
+						Image:                    "ami-12345",
+						InstanceType:             "m5.large",
+						KeyPairName:              "thisShouldBeOptional",
+						Subnets:                  []string{"subnet-1111111", "subnet-222222"},
+						AssociatePublicIpAddress: &associatePublicIp,
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, field 'associatePublicIpAddress' is only valid for LaunchTemplates",
+		},
+		{
+			name: "eks with associatePublicIpAddress and networkInterfaces fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:           "my-eks-cluster",
+						NodeSecurityGroups:       []string{"sg-123456789"},
+						Image:                    "ami-12345",
+						InstanceType:             "m5.large",
+						KeyPairName:              "thisShouldBeOptional",
+						Subnets:                  []string{"subnet-1111111", "subnet-222222"},
+						AssociatePublicIpAddress: &associatePublicIp,
+						NetworkInterfaces: []NetworkInterfaceSpec{
+							{DeviceIndex: 1, Subnet: "subnet-3333333"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'associatePublicIpAddress' cannot be true with 'networkInterfaces', public IPs are not assigned to instances with multiple network interfaces",
+		},
+		{
+			name: "eks with startupTaint validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Taints:             []corev1.Taint{{Key: "node.example.com/startup", Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+						StartupTaint:       &StartupTaintSpec{Key: "node.example.com/startup", Timeout: "15m"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with startupTaint without key fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Taints:             []corev1.Taint{{Key: "node.example.com/startup", Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+						StartupTaint:       &StartupTaintSpec{},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'startupTaint.key' is a required parameter",
+		},
+		{
+			name: "eks with unregistered startupTaint fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Taints:             []corev1.Taint{{Key: "node.example.com/startup", Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+						StartupTaint:       &StartupTaintSpec{Key: "node.example.com/other"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'startupTaint.key' node.example.com/other must match the key of a configured taint",
+		},
+		{
+			name: "eks with invalid startupTaint timeout fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Taints:             []corev1.Taint{{Key: "node.example.com/startup", Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+						StartupTaint:       &StartupTaintSpec{Key: "node.example.com/startup", Timeout: "-5m"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'startupTaint.timeout' must be a positive duration e.g. 10m",
+		},
+		{
+			name: "eks with terminationPolicies validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						TerminationPolicies: []string{"OldestInstance", "ClosestToNextInstanceHour", "Default"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with lambda terminationPolicies validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						TerminationPolicies: []string{"arn:aws:lambda:us-west-2:123456789012:function:my-termination-policy", "Default"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid terminationPolicies fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						TerminationPolicies: []string{"OldestInstance", "CheapestInstance"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'terminationPolicies[1]' must be one of [Default OldestInstance NewestInstance OldestLaunchConfiguration OldestLaunchTemplate AllocationStrategy ClosestToNextInstanceHour] or a lambda function ARN, got 'CheapestInstance'",
+		},
+		{
+			name: "eks with non-lambda arn terminationPolicies fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						TerminationPolicies: []string{"arn:aws:iam::123456789012:role/my-role"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'terminationPolicies[0]' must be one of [Default OldestInstance NewestInstance OldestLaunchConfiguration OldestLaunchTemplate AllocationStrategy ClosestToNextInstanceHour] or a lambda function ARN, got 'arn:aws:iam::123456789012:role/my-role'",
+		},
+		{
+			name: "eks with duplicate terminationPolicies fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						TerminationPolicies: []string{"OldestInstance", "OldestInstance"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'terminationPolicies' contains duplicate policy 'OldestInstance'",
+		},
+		{
+			name: "eks with registryCredentials validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						RegistryCredentials: &RegistryCredentialsSpec{SecretName: "registry-credentials"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with registryCredentials without secretName fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						RegistryCredentials: &RegistryCredentialsSpec{},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'registryCredentials.secretName' is a required parameter",
+		},
+		{
+			name: "eks with vpcCNIVersion validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIVersion:      "v1.18.3",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with eksbuild vpcCNIVersion validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIVersion:      "v1.18.3-eksbuild.1",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks without v prefix vpcCNIVersion fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIVersion:      "1.18.3",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'vpcCNIVersion' must be a VPC CNI release version e.g. v1.18.3 or v1.18.3-eksbuild.1, got '1.18.3'",
+		},
+		{
+			name: "eks with partial vpcCNIVersion fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIVersion:      "v1.18",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'vpcCNIVersion' must be a VPC CNI release version e.g. v1.18.3 or v1.18.3-eksbuild.1, got 'v1.18'",
+		},
+		{
+			name: "eks with vpcCNIWarmTargets ip targets validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIWarmTargets:  &VpcCNIWarmTargetsSpec{WarmIPTarget: &warmTarget, MinimumIPTarget: &zeroTarget},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with vpcCNIWarmTargets eni target validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIWarmTargets:  &VpcCNIWarmTargetsSpec{WarmENITarget: &zeroTarget},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with negative vpcCNIWarmTargets fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIWarmTargets:  &VpcCNIWarmTargetsSpec{MinimumIPTarget: &negativeTarget},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'vpcCNIWarmTargets.minimumIPTarget' must be non-negative, got -1",
+		},
+		{
+			name: "eks with vpcCNIWarmTargets eni and ip targets fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIWarmTargets:  &VpcCNIWarmTargetsSpec{WarmIPTarget: &warmTarget, WarmENITarget: &warmTarget},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'vpcCNIWarmTargets.warmENITarget' cannot be combined with 'warmIPTarget' or 'minimumIPTarget'",
+		},
+		{
+			name: "eks with zero vpcCNIWarmTargets ip targets fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						VpcCNIWarmTargets:  &VpcCNIWarmTargetsSpec{WarmIPTarget: &zeroTarget, MinimumIPTarget: &zeroTarget},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'vpcCNIWarmTargets.warmIPTarget' and 'minimumIPTarget' cannot both be 0",
+		},
+		{
+			name: "eks with ordered userdata validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						UserData: []UserDataStage{
+							{Name: "pre-2", Stage: PreBootstrapStage, Data: "pre-2", Order: &secondStage},
+							{Name: "pre-1", Stage: PreBootstrapStage, Data: "pre-1", Order: &firstStage},
+							{Name: "post-3", Stage: PostBootstrapStage, Data: "post-3", Order: &thirdStage},
+							{Name: "post", Stage: PostBootstrapStage, Data: "post"},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with duplicate userdata order fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						UserData: []UserDataStage{
+							{Name: "pre-1", Stage: PreBootstrapStage, Data: "pre-1", Order: &firstStage},
+							{Name: "pre-2", Stage: PreBootstrapStage, Data: "pre-2", Order: &firstStage},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'userData[1].order' 1 is already used by 'userData[0]'",
+		},
+		{
+			name: "eks with postbootstrap ordered before prebootstrap validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						UserData: []UserDataStage{
+							{Name: "pre-2", Stage: PreBootstrapStage, Data: "pre-2", Order: &secondStage},
+							{Name: "post-1", Stage: PostBootstrapStage, Data: "post-1", Order: &firstStage},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with ordered nodeconfig userdata fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						UserData: []UserDataStage{
+							{Name: "nodeconfig", Stage: NodeConfigYamlStage, Data: "nodeconfig", Order: &firstStage},
+						},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'userData[0].order' is not supported for stage NodeConfigYaml",
+		},
+		{
+			name: "eks with windowsContainerd validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ContainerRuntime: ContainerDRuntime},
+						WindowsContainerd:  &WindowsContainerdSpec{Root: `D:\containerd\root`, State: `D:\containerd\state`, SandboxImage: "mcr.microsoft.com/oss/kubernetes/pause:3.9"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with empty windowsContainerd fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						WindowsContainerd:  &WindowsContainerdSpec{},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'windowsContainerd' must set at least one of 'root', 'state' or 'sandboxImage'",
+		},
+		{
+			name: "eks with windowsContainerd and dockerd fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ContainerRuntime: DockerRuntime},
+						WindowsContainerd:  &WindowsContainerdSpec{SandboxImage: "mcr.microsoft.com/oss/kubernetes/pause:3.9"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'windowsContainerd' requires 'bootstrapOptions.containerRuntime' containerd, got dockerd",
+		},
+		{
+			name: "eks with relative windowsContainerd root fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						WindowsContainerd:  &WindowsContainerdSpec{Root: "containerd/root"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'windowsContainerd.root' must be an absolute Windows path e.g. D:\\containerd\\root, got 'containerd/root'",
+		},
+		{
+			name: "eks with invalid windowsContainerd sandboxImage fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						WindowsContainerd:  &WindowsContainerdSpec{SandboxImage: "pause:3.9\" && evil"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'windowsContainerd.sandboxImage' must be an image reference, got 'pause:3.9\" && evil'",
+		},
+		{
+			name: "eks with maxPodsFormula validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{MaxPodsFormula: "min(ENIs * (IPsPerENI - 1) * IPsPerPrefix + HostPods, 110)"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with maxPodsFormula and maxPods fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{MaxPods: 29, MaxPodsFormula: "ENIs * (IPsPerENI - 1) + HostPods"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.maxPodsFormula' cannot be combined with 'bootstrapOptions.maxPods'",
+		},
+		{
+			name: "eks with maxPodsFormula unknown variable fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{MaxPodsFormula: "ENIs * Prefixes"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.maxPodsFormula' invalid formula 'ENIs * Prefixes': unknown variable Prefixes, allowed variables are [ENIs IPsPerENI IPsPerPrefix HostPods]",
+		},
+		{
+			name: "eks with maxPodsFormula function call fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{MaxPodsFormula: "pow(ENIs, 2)"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.maxPodsFormula' invalid formula 'pow(ENIs, 2)': unsupported function, only min and max are allowed",
+		},
+		{
+			name: "eks with prePullImages validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						PrePullImages:      []string{"123456789012.dkr.ecr.us-west-2.amazonaws.com/app:v1", "public.ecr.aws/eks-distro/kubernetes/pause:3.9"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid prePullImages fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						PrePullImages:      []string{"busybox:latest", "busybox; reboot"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'prePullImages' entries must be image references, got 'busybox; reboot'",
+		},
+		{
+			name: "eks with suspendProcesses validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						SuspendedProcesses: []string{"AZRebalance", "scheduledactions", "All"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid suspendProcesses fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						SuspendedProcesses: []string{"AZRebalance", "Rebalance"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'suspendProcesses' entry 'Rebalance' must be one of [Launch Terminate AddToLoadBalancer AlarmNotification AZRebalance HealthCheck InstanceRefresh ReplaceUnhealthy ScheduledActions] or All",
+		},
+		{
+			name: "eks with unknown metricsCollection names validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						MetricsCollection:  []string{"GroupMinSize", "GroupSize"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with defaultCooldown validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						DefaultCooldown:    aws.Int64(0),
+						ScaleInProtection:  true,
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with negative defaultCooldown fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						DefaultCooldown:    aws.Int64(-1),
+						ScaleInProtection:  true,
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'defaultCooldown' must be a non-negative number of seconds, got -1",
+		},
+		{
+			name: "eks with userDataVariables validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						UserDataVariables:  map[string]string{"ProxyURL": "http://proxy:3128", "internal_registry": "registry.internal"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid userDataVariables name fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						UserDataVariables:  map[string]string{"proxy-url": "http://proxy:3128"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'userDataVariables' name 'proxy-url' must start with a letter or underscore and contain only letters, digits and underscores",
+		},
+		{
+			name: "eks with reserved userDataVariables name fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						UserDataVariables:  map[string]string{"clusterName": "other-cluster"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'userDataVariables' name 'clusterName' is reserved by a userdata field",
+		},
+		{
+			name: "eks with image gc thresholds validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ImageGCHighThreshold: aws.Int64(90), ImageGCLowThreshold: aws.Int64(60)},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with image gc high threshold validates against the default low threshold",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ImageGCHighThreshold: aws.Int64(95)},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with image gc high threshold out of range fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ImageGCHighThreshold: aws.Int64(101)},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.imageGCHighThreshold' must be between 0 and 100, got 101",
+		},
+		{
+			name: "eks with image gc low threshold out of range fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ImageGCLowThreshold: aws.Int64(-1)},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.imageGCLowThreshold' must be between 0 and 100, got -1",
+		},
+		{
+			name: "eks with image gc high threshold below low threshold fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ImageGCHighThreshold: aws.Int64(60), ImageGCLowThreshold: aws.Int64(60)},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.imageGCHighThreshold' 60 must be greater than 'bootstrapOptions.imageGCLowThreshold' 60, unset thresholds default to 85 and 80",
+		},
+		{
+			name: "eks with image gc high threshold below the default low threshold fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ImageGCHighThreshold: aws.Int64(70)},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.imageGCHighThreshold' 70 must be greater than 'bootstrapOptions.imageGCLowThreshold' 80, unset thresholds default to 85 and 80",
+		},
+		{
+			name: "eks with parallel image pulls validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{SerializeImagePulls: aws.Bool(false), MaxParallelImagePulls: aws.Int64(5)},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with serialized image pulls validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{SerializeImagePulls: aws.Bool(true), MaxParallelImagePulls: aws.Int64(1)},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with maxParallelImagePulls out of bounds fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{SerializeImagePulls: aws.Bool(false), MaxParallelImagePulls: aws.Int64(0)},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.maxParallelImagePulls' must be between 1 and 100, got 0",
+		},
+		{
+			name: "eks with maxParallelImagePulls above limit fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{SerializeImagePulls: aws.Bool(false), MaxParallelImagePulls: aws.Int64(101)},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.maxParallelImagePulls' must be between 1 and 100, got 101",
+		},
+		{
+			name: "eks with parallel image pulls and serialized image pulls fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{MaxParallelImagePulls: aws.Int64(5)},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.maxParallelImagePulls' 5 requires 'bootstrapOptions.serializeImagePulls' to be false",
+		},
+		{
+			name: "eks with shutdown grace period validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ShutdownGracePeriod: "60s", ShutdownGracePeriodCriticalPods: "20s"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with shutdown grace period equal to critical period validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ShutdownGracePeriod: "30s", ShutdownGracePeriodCriticalPods: "30s"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid shutdown grace period fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ShutdownGracePeriod: "a minute"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.shutdownGracePeriod' must be a positive duration e.g. 60s",
+		},
+		{
+			name: "eks with invalid shutdown grace period for critical pods fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ShutdownGracePeriod: "60s", ShutdownGracePeriodCriticalPods: "-10s"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.shutdownGracePeriodCriticalPods' must be a duration e.g. 20s",
+		},
+		{
+			name: "eks with shutdown grace period for critical pods exceeding shutdown grace period fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ShutdownGracePeriod: "30s", ShutdownGracePeriodCriticalPods: "1m"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.shutdownGracePeriodCriticalPods' 1m must not exceed 'bootstrapOptions.shutdownGracePeriod' 30s",
+		},
+		{
+			name: "eks with shutdown grace period for critical pods only fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{ShutdownGracePeriodCriticalPods: "10s"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.shutdownGracePeriodCriticalPods' requires 'bootstrapOptions.shutdownGracePeriod' to be set",
+		},
+		{
+			name: "eks with allowed unsafe sysctls validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{AllowedUnsafeSysctls: []string{"net.core.somaxconn", "net.ipv4.*", "kernel.shm_rmid_forced", "kernel.msg*", "fs.mqueue.msg_max"}},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with malformed allowed unsafe sysctl fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{AllowedUnsafeSysctls: []string{"net.core.*.rp_filter"}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.allowedUnsafeSysctls[0]' must be a dot separated sysctl name, optionally ending with *, got 'net.core.*.rp_filter'",
+		},
+		{
+			name: "eks with duplicate allowed unsafe sysctl fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{AllowedUnsafeSysctls: []string{"net.core.somaxconn", "net.core.somaxconn"}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.allowedUnsafeSysctls' contains duplicate sysctl 'net.core.somaxconn'",
+		},
+		{
+			name: "eks with allowed unsafe sysctl which is not namespaced fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{AllowedUnsafeSysctls: []string{"vm.max_map_count"}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.allowedUnsafeSysctls[0]' must be a namespaced sysctl starting with one of [kernel.shm kernel.msg kernel.sem fs.mqueue. net.], got 'vm.max_map_count'",
+		},
+		{
+			name: "eks with sysctls validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Sysctls:            map[string]string{"net.core.somaxconn": "4096", "net.ipv4.tcp_rmem": "4096 87380 16777216", "kernel.pid_max": "4194304", "net.ipv4.conf.eth0.rp_filter": "2"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with sysctl of disallowed namespace fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Sysctls:            map[string]string{"dev.raid.speed_limit_min": "10000"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'sysctls' key 'dev.raid.speed_limit_min' must be a dot separated kernel, net, vm, fs or user parameter",
+		},
+		{
+			name: "eks with sysctl key of slashes fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Sysctls:            map[string]string{"net/core/somaxconn": "4096"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'sysctls' key 'net/core/somaxconn' must be a dot separated kernel, net, vm, fs or user parameter",
+		},
+		{
+			name: "eks with multiline sysctl value fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Sysctls:            map[string]string{"net.core.somaxconn": "4096\nkernel.panic = 1"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'sysctls' value of 'net.core.somaxconn' must be a single line of space separated values, got '4096\nkernel.panic = 1'",
+		},
+		{
+			name: "eks with kubelet configuration validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`{"apiVersion": "kubelet.config.k8s.io/v1beta1", "kind": "KubeletConfiguration", "cpuManagerPolicy": "static", "cpuManagerReconcilePeriod": "10s", "podPidsLimit": 4096, "featureGates": {"InPlacePodVerticalScaling": true}}`)}},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with kubelet configuration of unknown field fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`{"cpuManagerPolicy": "static", "maxPod": 10}`)}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.kubeletConfiguration.maxPod' is not a KubeletConfiguration field",
+		},
+		{
+			name: "eks with kubelet configuration of invalid type fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`{"podPidsLimit": "4096"}`)}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.kubeletConfiguration.podPidsLimit' must be of type integer",
+		},
+		{
+			name: "eks with kubelet configuration of invalid duration fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`{"cpuManagerReconcilePeriod": "a minute"}`)}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.kubeletConfiguration.cpuManagerReconcilePeriod' must be of type duration",
+		},
+		{
+			name: "eks with kubelet configuration of invalid apiVersion fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`{"apiVersion": "kubelet.config.k8s.io/v1alpha1", "kind": "KubeletConfiguration"}`)}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.kubeletConfiguration.apiVersion' must be kubelet.config.k8s.io/v1beta1, got kubelet.config.k8s.io/v1alpha1",
+		},
+		{
+			name: "eks with kubelet configuration which is not an object fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`["cpuManagerPolicy"]`)}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.kubeletConfiguration' must be a KubeletConfiguration object",
+		},
+		{
+			name: "eks with kubelet configuration of field managed by instance-manager fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`{"maxPods": 110}`)}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.kubeletConfiguration.maxPods' is managed by instance-manager, use 'bootstrapOptions.maxPods' instead",
+		},
+		{
+			name: "eks with kubelet configuration of field managed by the node bootstrap fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`{"clusterDNS": ["10.100.0.10"]}`)}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.kubeletConfiguration.clusterDNS' is managed by the node bootstrap",
+		},
+		{
+			name: "eks with unhealthy protected policy clear-protection validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:           "my-eks-cluster",
+						NodeSecurityGroups:       []string{"sg-123456789"},
+						Image:                    "ami-12345",
+						InstanceType:             "m5.large",
+						KeyPairName:              "thisShouldBeOptional",
+						Subnets:                  []string{"subnet-1111111", "subnet-222222"},
+						UnhealthyProtectedPolicy: "clear-protection",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid unhealthy protected policy fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:           "my-eks-cluster",
+						NodeSecurityGroups:       []string{"sg-123456789"},
+						Image:                    "ami-12345",
+						InstanceType:             "m5.large",
+						KeyPairName:              "thisShouldBeOptional",
+						Subnets:                  []string{"subnet-1111111", "subnet-222222"},
+						UnhealthyProtectedPolicy: "terminate",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'unhealthyProtectedPolicy' must be one of [report clear-protection], got terminate",
+		},
+		{
+			name: "eks with kubelet allowed labels validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Labels:             map[string]string{"node.kubernetes.io/role": "worker", "example.com/team": "a"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with kubelet restricted labels fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Labels:             map[string]string{"node-role.kubernetes.io/worker": "", "example.com/team": "a"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'labels' key 'node-role.kubernetes.io/worker' cannot be set by the kubelet, labels in the [kubernetes.io k8s.io] namespaces must be one of [kubernetes.io/hostname kubernetes.io/arch kubernetes.io/os beta.kubernetes.io/arch beta.kubernetes.io/os beta.kubernetes.io/instance-type node.kubernetes.io/instance-type failure-domain.beta.kubernetes.io/region failure-domain.beta.kubernetes.io/zone topology.kubernetes.io/region topology.kubernetes.io/zone] or in the [kubelet.kubernetes.io node.kubernetes.io] namespaces",
+		},
+		{
+			name: "eks with assumeRoleArn validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						AssumeRoleArn:      "arn:aws:iam::123456789012:role/instance-manager",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with non-role assumeRoleArn fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						AssumeRoleArn:      "arn:aws:iam::123456789012:user/instance-manager",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'assumeRoleArn' must be a valid IAM role ARN, got arn:aws:iam::123456789012:user/instance-manager",
+		},
+		{
+			name: "eks with invalid assumeRoleArn fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						AssumeRoleArn:      "instance-manager",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'assumeRoleArn' must be a valid IAM role ARN, got instance-manager",
+		},
+		{
+			name: "eks with region validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Region:             "us-gov-west-1",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid region fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						Region:             "US West 2",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'region' must be a valid AWS region such as us-west-2, got US West 2",
+		},
+		{
+			name: "eks with readinessChecks validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ReadinessChecks:    &ReadinessChecksSpec{DaemonSets: []string{"aws-node", "logging/log-agent"}},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid readinessChecks daemonset fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						ReadinessChecks:    &ReadinessChecksSpec{DaemonSets: []string{"kube-system/aws-node/extra"}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, readinessChecks.daemonSets entry 'kube-system/aws-node/extra' must be a DaemonSet name or namespace/name",
+		},
+		{
+			name: "eks with accessEntry nodeAuthentication validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NodeAuthentication: "accessEntry",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with invalid nodeAuthentication fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NodeAuthentication: "API",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'nodeAuthentication' must be one of [awsAuth accessEntry], got API",
+		},
+		{
+			name: "eks with nodeJoinDeadline validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NodeJoinDeadline:   &NodeJoinDeadlineSpec{Timeout: "15m", Replace: true},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with missing nodeJoinDeadline timeout fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NodeJoinDeadline:   &NodeJoinDeadlineSpec{Replace: true},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'nodeJoinDeadline.timeout' must be a positive duration e.g. 15m",
+		},
+		{
+			name: "eks with negative nodeJoinDeadline timeout fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						NodeJoinDeadline:   &NodeJoinDeadlineSpec{Timeout: "-5m"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'nodeJoinDeadline.timeout' must be a positive duration e.g. 15m",
+		},
+		{
+			name: "eks with notReadyReplacement validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						NotReadyReplacement: &NotReadyReplacementSpec{Threshold: "10m", MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "25%"}},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with missing notReadyReplacement threshold fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						NotReadyReplacement: &NotReadyReplacementSpec{},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'notReadyReplacement.threshold' must be a positive duration e.g. 10m",
+		},
+		{
+			name: "eks with invalid notReadyReplacement maxUnavailable fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						NotReadyReplacement: &NotReadyReplacementSpec{Threshold: "10m", MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "some"}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'notReadyReplacement.maxUnavailable' must be a number or a percentage e.g. 25%",
+		},
+		{
+			name: "eks with zero notReadyReplacement maxUnavailable fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:      "my-eks-cluster",
+						NodeSecurityGroups:  []string{"sg-123456789"},
+						Image:               "ami-12345",
+						InstanceType:        "m5.large",
+						KeyPairName:         "thisShouldBeOptional",
+						Subnets:             []string{"subnet-1111111", "subnet-222222"},
+						NotReadyReplacement: &NotReadyReplacementSpec{Threshold: "10m", MaxUnavailable: &intstr.IntOrString{Type: intstr.Int, IntVal: 0}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'notReadyReplacement.maxUnavailable' must be positive",
+		},
+		{
+			name: "eks with instanceStorage validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InstanceStorage:    &InstanceStorageSpec{MountPath: "/var/lib/containerd", FileSystem: "ext4", Policy: "ebs-only"},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with relative instanceStorage mountPath fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InstanceStorage:    &InstanceStorageSpec{MountPath: "var/lib/containerd"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'instanceStorage.mountPath' must be an absolute path e.g. /var/lib/containerd, got 'var/lib/containerd'",
+		},
+		{
+			name: "eks with unsafe instanceStorage mountPath fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InstanceStorage:    &InstanceStorageSpec{MountPath: "/mnt/data; reboot"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'instanceStorage.mountPath' must be an absolute path e.g. /var/lib/containerd, got '/mnt/data; reboot'",
+		},
+		{
+			name: "eks with unsupported instanceStorage policy fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InstanceStorage:    &InstanceStorageSpec{Policy: "instance-store-only"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'instanceStorage.policy' must be one of [prefer-instance-store ebs-only], got 'instance-store-only'",
+		},
+		{
+			name: "eks with unsupported instanceStorage fileSystem fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InstanceStorage:    &InstanceStorageSpec{FileSystem: "btrfs"},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'instanceStorage.fileSystem' must be one of [xfs ext4], got 'btrfs'",
+		},
+		{
+			name: "eks with bootstrap flags validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{Flags: map[string]string{"use-max-pods": "false", "enable-docker-bridge": "true"}},
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with dashed bootstrap flag name fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{Flags: map[string]string{"--use-max-pods": "false"}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.flags' name '--use-max-pods' must be a flag name without leading dashes e.g. use-max-pods",
+		},
+		{
+			name: "eks with unsafe bootstrap flag value fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{Flags: map[string]string{"use-max-pods": "false; reboot"}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.flags' value of use-max-pods must be non-empty and not contain whitespace, quotes or shell characters, got 'false; reboot'",
+		},
+		{
+			name: "eks with empty bootstrap flag value fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						BootstrapOptions:   &BootstrapOptions{Flags: map[string]string{"use-max-pods": ""}},
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'bootstrapOptions.flags' value of use-max-pods must be non-empty and not contain whitespace, quotes or shell characters, got ''",
+		},
+		{
+			name: "eks with initialGracePeriod validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InitialGracePeriod: "10m",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with disabled initialGracePeriod validates",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InitialGracePeriod: "0s",
+					},
+				}, nil, nil),
+			},
+			want: "",
+		},
+		{
+			name: "eks with negative initialGracePeriod fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InitialGracePeriod: "-5m",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'initialGracePeriod' must be a duration e.g. 10m, or 0s to disable it",
+		},
+		{
+			name: "eks with invalid initialGracePeriod fails",
+			args: args{
+				instancegroup: MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{
+					MaxSize: 1,
+					MinSize: 1,
+					Type:    "LaunchTemplate",
+					EKSConfiguration: &EKSConfiguration{
+						EksClusterName:     "my-eks-cluster",
+						NodeSecurityGroups: []string{"sg-123456789"},
+						Image:              "ami-12345",
+						InstanceType:       "m5.large",
+						KeyPairName:        "thisShouldBeOptional",
+						Subnets:            []string{"subnet-1111111", "subnet-222222"},
+						InitialGracePeriod: "ten minutes",
+					},
+				}, nil, nil),
+			},
+			want: "validation failed, 'initialGracePeriod' must be a duration e.g. 10m, or 0s to disable it",
+		},
 		{
 			name: "eks with metadataoptions validates",
 			args: args{
@@ -447,14 +3568,681 @@ func TestInstanceGroupSpecValidate(t *testing.T) {
 			want: "",
 		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := testFunction(t, tt.args)
-			if got != tt.want {
-				t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testFunction(t, tt.args)
+			if got != tt.want {
+				t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+			}
+		})
+
+	}
+}
+
+func TestMixedInstancesPolicyDefaultWeight(t *testing.T) {
+	policy := &MixedInstancesPolicySpec{
+		InstanceTypes: []*InstanceTypeSpec{
+			{Type: "m5a.large"},
+			{Type: "m5.xlarge", Weight: 2},
+		},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := policy.InstanceTypes[0].Weight; got != 1 {
+		t.Errorf("got weight %v, want 1", got)
+	}
+	if got := policy.InstanceTypes[1].Weight; got != 2 {
+		t.Errorf("got weight %v, want 2", got)
+	}
+}
+
+func TestDrainSpecValidate(t *testing.T) {
+	tests := []struct {
+		name            string
+		evictDaemonSets []string
+		timeout         string
+		force           bool
+		wantErr         bool
+	}{
+		{name: "ignore all daemonsets", evictDaemonSets: nil},
+		{name: "daemonset names", evictDaemonSets: []string{"log-agent", "kube-system/aws-node"}},
+		{name: "empty name", evictDaemonSets: []string{""}, wantErr: true},
+		{name: "empty namespace", evictDaemonSets: []string{"/aws-node"}, wantErr: true},
+		{name: "too many parts", evictDaemonSets: []string{"kube-system/aws-node/extra"}, wantErr: true},
+		{name: "timeout", timeout: "15m"},
+		{name: "forced timeout", timeout: "15m", force: true},
+		{name: "invalid timeout", timeout: "15", wantErr: true},
+		{name: "negative timeout", timeout: "-15m", wantErr: true},
+		{name: "force without timeout", force: true, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drain := &DrainSpec{EvictDaemonSets: test.evictDaemonSets, Timeout: test.timeout, Force: test.force}
+			if err := drain.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestFailureDomainLabelValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		label    string
+		wantErr  bool
+	}{
+		{name: "zone label", strategy: "rollingUpdate", label: "topology.kubernetes.io/zone"},
+		{name: "unprefixed label", strategy: "rollingUpdate", label: "zone"},
+		{name: "invalid label", strategy: "rollingUpdate", label: "topology.kubernetes.io/zone/extra", wantErr: true},
+		{name: "crd strategy", strategy: "crd", label: "topology.kubernetes.io/zone", wantErr: true},
+		{name: "crd strategy without label", strategy: "crd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+			}
+			ig := MockInstanceGroup("eks", test.strategy, &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			ig.Spec.AwsUpgradeStrategy.RollingUpdateType = &RollingUpdateStrategy{FailureDomainLabel: test.label}
+			ig.Spec.AwsUpgradeStrategy.CRDType = &CRDUpdateStrategy{Spec: "spec", CRDName: "crd", StatusJSONPath: ".status", StatusSuccessString: "ok", StatusFailureString: "failed"}
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedulingCheckValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		check    bool
+		wantErr  bool
+	}{
+		{name: "rolling update strategy", strategy: "rollingUpdate", check: true},
+		{name: "crd strategy", strategy: "crd", check: true, wantErr: true},
+		{name: "crd strategy without check", strategy: "crd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+			}
+			ig := MockInstanceGroup("eks", test.strategy, &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			ig.Spec.AwsUpgradeStrategy.RollingUpdateType = &RollingUpdateStrategy{SchedulingCheck: test.check}
+			ig.Spec.AwsUpgradeStrategy.CRDType = &CRDUpdateStrategy{Spec: "spec", CRDName: "crd", StatusJSONPath: ".status", StatusSuccessString: "ok", StatusFailureString: "failed"}
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBlueGreenStrategyValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategy     string
+		blueGreen    *BlueGreenStrategy
+		zoneSharding bool
+		warmPool     bool
+		wantErr      bool
+	}{
+		{name: "defaults", strategy: "bluegreen"},
+		{name: "ready timeout and drain", strategy: "bluegreen", blueGreen: &BlueGreenStrategy{ReadyTimeout: "10m", Drain: &DrainSpec{Timeout: "5m", Force: true}}},
+		{name: "invalid ready timeout", strategy: "bluegreen", blueGreen: &BlueGreenStrategy{ReadyTimeout: "10"}, wantErr: true},
+		{name: "negative ready timeout", strategy: "bluegreen", blueGreen: &BlueGreenStrategy{ReadyTimeout: "-10m"}, wantErr: true},
+		{name: "invalid drain", strategy: "bluegreen", blueGreen: &BlueGreenStrategy{Drain: &DrainSpec{Force: true}}, wantErr: true},
+		{name: "rolling update strategy", strategy: "rollingUpdate", blueGreen: &BlueGreenStrategy{ReadyTimeout: "10m"}, wantErr: true},
+		{name: "zone sharding", strategy: "bluegreen", zoneSharding: true, wantErr: true},
+		{name: "warm pool", strategy: "bluegreen", warmPool: true, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				ZoneSharding:       test.zoneSharding,
+			}
+			spec := &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}
+			if test.warmPool {
+				spec.WarmPool = &WarmPoolSpec{MaxSize: -1, MinSize: 0}
+			}
+			ig := MockInstanceGroup("eks", test.strategy, spec, nil, nil)
+			ig.Spec.AwsUpgradeStrategy.BlueGreenType = test.blueGreen
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetadataOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *MetadataOptions
+		wantErr bool
+	}{
+		{name: "not set"},
+		{name: "defaulted options", options: &MetadataOptions{}},
+		{name: "hop limit only", options: &MetadataOptions{HttpPutHopLimit: 2}},
+		{name: "all options", options: &MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "required", HttpPutHopLimit: 1}},
+		{name: "invalid endpoint", options: &MetadataOptions{HttpEndpoint: "on"}, wantErr: true},
+		{name: "invalid tokens", options: &MetadataOptions{HttpTokens: "always"}, wantErr: true},
+		{name: "negative hop limit", options: &MetadataOptions{HttpPutHopLimit: -1}, wantErr: true},
+		{name: "hop limit too large", options: &MetadataOptions{HttpPutHopLimit: 65}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.options.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecurityGroupDriftValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		detect   bool
+		wantErr  bool
+	}{
+		{name: "rolling update strategy", strategy: "rollingUpdate", detect: true},
+		{name: "blue/green strategy", strategy: "bluegreen", detect: true},
+		{name: "crd strategy", strategy: "crd", detect: true, wantErr: true},
+		{name: "crd strategy without detection", strategy: "crd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:           "my-eks-cluster",
+				NodeSecurityGroups:       []string{"sg-123456789"},
+				Image:                    "ami-12345",
+				InstanceType:             "m5.large",
+				KeyPairName:              "thisShouldBeOptional",
+				Subnets:                  []string{"subnet-1111111"},
+				DetectSecurityGroupDrift: test.detect,
+			}
+			ig := MockInstanceGroup("eks", test.strategy, &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			ig.Spec.AwsUpgradeStrategy.CRDType = &CRDUpdateStrategy{Spec: "spec", CRDName: "crd", StatusJSONPath: ".status", StatusSuccessString: "ok", StatusFailureString: "failed"}
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCNIValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		cni           *CNISpec
+		vpcCNIVersion string
+		wantErr       bool
+	}{
+		{name: "default plugin", vpcCNIVersion: "v1.18.3"},
+		{name: "vpc cni", cni: &CNISpec{Plugin: CNIPluginAwsVpcCNI}, vpcCNIVersion: "v1.18.3"},
+		{name: "other plugin", cni: &CNISpec{Plugin: CNIPluginOther}},
+		{name: "unknown plugin", cni: &CNISpec{Plugin: "flannel"}, wantErr: true},
+		{name: "vpc cni version with other plugin", cni: &CNISpec{Plugin: CNIPluginOther}, vpcCNIVersion: "v1.18.3", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				CNI:                test.cni,
+				VpcCNIVersion:      test.vpcCNIVersion,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageUpdateCheckIntervalValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    string
+		interval string
+		wantErr  bool
+	}{
+		{name: "latest image", image: "latest", interval: "6h"},
+		{name: "ssm image", image: "ssm://amazon-eks-node-1.30-v20240807", interval: "30m"},
+		{name: "image id", image: "ami-12345", interval: "6h", wantErr: true},
+		{name: "image id without interval", image: "ami-12345"},
+		{name: "invalid interval", image: "latest", interval: "daily", wantErr: true},
+		{name: "non-positive interval", image: "latest", interval: "0s", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:           "my-eks-cluster",
+				NodeSecurityGroups:       []string{"sg-123456789"},
+				Image:                    test.image,
+				InstanceType:             "m5.large",
+				KeyPairName:              "thisShouldBeOptional",
+				Subnets:                  []string{"subnet-1111111"},
+				ImageUpdateCheckInterval: test.interval,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestKubeletVersionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		wantErr bool
+	}{
+		{name: "version of the image"},
+		{name: "minor version", version: "1.30"},
+		{name: "patch version", version: "v1.30.2"},
+		{name: "invalid version", version: "latest", wantErr: true},
+		{name: "major version", version: "1", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				KubeletVersion:     test.version,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestAwsCallTimeoutValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+		wantErr bool
+	}{
+		{name: "default timeout"},
+		{name: "timeout", timeout: "30s"},
+		{name: "invalid timeout", timeout: "slow", wantErr: true},
+		{name: "non-positive timeout", timeout: "0s", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				AwsCallTimeout:     test.timeout,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestInstanceMaintenancePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *InstanceMaintenanceSpec
+		wantErr bool
+	}{
+		{name: "no policy"},
+		{name: "policy", policy: &InstanceMaintenanceSpec{MinHealthyPercentage: 90, MaxHealthyPercentage: 120}},
+		{name: "replace before terminating", policy: &InstanceMaintenanceSpec{MinHealthyPercentage: 100, MaxHealthyPercentage: 200}},
+		{name: "minimum out of range", policy: &InstanceMaintenanceSpec{MinHealthyPercentage: 110, MaxHealthyPercentage: 120}, wantErr: true},
+		{name: "maximum out of range", policy: &InstanceMaintenanceSpec{MinHealthyPercentage: 90, MaxHealthyPercentage: 210}, wantErr: true},
+		{name: "maximum below 100", policy: &InstanceMaintenanceSpec{MinHealthyPercentage: 50, MaxHealthyPercentage: 90}, wantErr: true},
+		{name: "range too wide", policy: &InstanceMaintenanceSpec{MinHealthyPercentage: 50, MaxHealthyPercentage: 160}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:            "my-eks-cluster",
+				NodeSecurityGroups:        []string{"sg-123456789"},
+				Image:                     "ami-12345",
+				InstanceType:              "m5.large",
+				KeyPairName:               "thisShouldBeOptional",
+				Subnets:                   []string{"subnet-1111111"},
+				InstanceMaintenancePolicy: test.policy,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBootstrapRetriesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries *BootstrapRetriesSpec
+		wantErr bool
+	}{
+		{name: "no retries"},
+		{name: "retries", retries: &BootstrapRetriesSpec{Attempts: 5}},
+		{name: "retries with backoff", retries: &BootstrapRetriesSpec{Attempts: 5, Backoff: "10s"}},
+		{name: "no attempts", retries: &BootstrapRetriesSpec{}, wantErr: true},
+		{name: "too many attempts", retries: &BootstrapRetriesSpec{Attempts: 11}, wantErr: true},
+		{name: "invalid backoff", retries: &BootstrapRetriesSpec{Attempts: 5, Backoff: "soon"}, wantErr: true},
+		{name: "backoff too short", retries: &BootstrapRetriesSpec{Attempts: 5, Backoff: "500ms"}, wantErr: true},
+		{name: "backoff too long", retries: &BootstrapRetriesSpec{Attempts: 5, Backoff: "5m"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				BootstrapRetries:   test.retries,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestIgnoreCosmeticChangesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		igType  string
+		ignore  bool
+		wantErr bool
+	}{
+		{name: "launch template", igType: "LaunchTemplate", ignore: true},
+		{name: "launch configuration", igType: "LaunchConfiguration", ignore: true, wantErr: true},
+		{name: "launch configuration without ignoring cosmetic changes", igType: "LaunchConfiguration"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:        "my-eks-cluster",
+				NodeSecurityGroups:    []string{"sg-123456789"},
+				Image:                 "ami-12345",
+				InstanceType:          "m5.large",
+				KeyPairName:           "thisShouldBeOptional",
+				Subnets:               []string{"subnet-1111111"},
+				IgnoreCosmeticChanges: test.ignore,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: ScalingConfigurationType(test.igType), EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCapacityTypeEnforcementValidate(t *testing.T) {
+	mixedPolicy := func(spotRatio intstr.IntOrString, baseCapacity int64) *MixedInstancesPolicySpec {
+		return &MixedInstancesPolicySpec{
+			InstanceTypes: []*InstanceTypeSpec{{Type: "m5.large"}, {Type: "m5a.large"}},
+			SpotRatio:     &spotRatio,
+			BaseCapacity:  &baseCapacity,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		enforcement string
+		spotPrice   string
+		mixedPolicy *MixedInstancesPolicySpec
+		wantErr     bool
+	}{
+		{name: "no enforcement", spotPrice: "0.67", mixedPolicy: mixedPolicy(intstr.FromInt(50), 1)},
+		{name: "invalid enforcement", enforcement: "spot-preferred", wantErr: true},
+		{name: "mixed", enforcement: "mixed", mixedPolicy: mixedPolicy(intstr.FromInt(50), 1)},
+		{name: "on-demand-only", enforcement: "on-demand-only"},
+		{name: "on-demand-only with on-demand mixed policy", enforcement: "on-demand-only", mixedPolicy: mixedPolicy(intstr.FromInt(0), 2)},
+		{name: "on-demand-only with spot price", enforcement: "on-demand-only", spotPrice: "0.67", wantErr: true},
+		{name: "on-demand-only with spot ratio", enforcement: "on-demand-only", mixedPolicy: mixedPolicy(intstr.FromString("25%"), 0), wantErr: true},
+		{name: "spot-only with spot price", enforcement: "spot-only", spotPrice: "0.67"},
+		{name: "spot-only with spot mixed policy", enforcement: "spot-only", mixedPolicy: mixedPolicy(intstr.FromString("100%"), 0)},
+		{name: "spot-only without spot", enforcement: "spot-only", wantErr: true},
+		{name: "spot-only with partial spot ratio", enforcement: "spot-only", mixedPolicy: mixedPolicy(intstr.FromInt(80), 0), wantErr: true},
+		{name: "spot-only with default spot ratio", enforcement: "spot-only", mixedPolicy: &MixedInstancesPolicySpec{InstanceTypes: []*InstanceTypeSpec{{Type: "m5.large"}}}, wantErr: true},
+		{name: "spot-only with on-demand base capacity", enforcement: "spot-only", mixedPolicy: mixedPolicy(intstr.FromInt(100), 1), wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:          "my-eks-cluster",
+				NodeSecurityGroups:      []string{"sg-123456789"},
+				Image:                   "ami-12345",
+				InstanceType:            "m5.large",
+				KeyPairName:             "thisShouldBeOptional",
+				Subnets:                 []string{"subnet-1111111"},
+				SpotPrice:               test.spotPrice,
+				MixedInstancesPolicy:    test.mixedPolicy,
+				CapacityTypeEnforcement: test.enforcement,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowSpecValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  MaintenanceWindowSpec
+		wantErr bool
+	}{
+		{name: "daily window", window: MaintenanceWindowSpec{StartTime: "09:00", EndTime: "17:00"}},
+		{name: "overnight window", window: MaintenanceWindowSpec{Days: []string{"Saturday", "sunday"}, StartTime: "22:00", EndTime: "04:00", TimeZone: "America/Los_Angeles"}},
+		{name: "invalid day", window: MaintenanceWindowSpec{Days: []string{"Funday"}, StartTime: "09:00", EndTime: "17:00"}, wantErr: true},
+		{name: "invalid start time", window: MaintenanceWindowSpec{StartTime: "25:00", EndTime: "17:00"}, wantErr: true},
+		{name: "missing end time", window: MaintenanceWindowSpec{StartTime: "09:00"}, wantErr: true},
+		{name: "empty window", window: MaintenanceWindowSpec{StartTime: "09:00", EndTime: "09:00"}, wantErr: true},
+		{name: "invalid time zone", window: MaintenanceWindowSpec{StartTime: "09:00", EndTime: "17:00", TimeZone: "Mars/Olympus_Mons"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.window.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestDependsOnValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		dependsOn []string
+		expected  []string
+		wantErr   bool
+	}{
+		{name: "no dependencies", expected: []string{}},
+		{name: "name and namespace/name", dependsOn: []string{"system", "other/monitoring"}, expected: []string{"default/system", "other/monitoring"}},
+		{name: "invalid name", dependsOn: []string{"System_Nodes"}, wantErr: true},
+		{name: "too many separators", dependsOn: []string{"a/b/c"}, wantErr: true},
+		{name: "self reference", dependsOn: []string{"workers"}, wantErr: true},
+		{name: "self reference with namespace", dependsOn: []string{"default/workers"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ig := MockInstanceGroup("eks", "rollingUpdate", MockEKSSpec(), nil, nil)
+			ig.SetName("workers")
+			ig.SetNamespace("default")
+			ig.Spec.DependsOn = test.dependsOn
+			if err := ig.validateDependsOn(); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+			if !test.wantErr && !reflect.DeepEqual(ig.GetDependencies(), test.expected) {
+				t.Errorf("%v: got dependencies %v, want %v", test.name, ig.GetDependencies(), test.expected)
+			}
+		})
+	}
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+
+	var (
+		daily     = MaintenanceWindowSpec{StartTime: "09:00", EndTime: "17:00"}
+		overnight = MaintenanceWindowSpec{Days: []string{"Saturday"}, StartTime: "22:00", EndTime: "04:00", TimeZone: "America/Los_Angeles"}
+	)
+
+	tests := []struct {
+		name    string
+		windows []MaintenanceWindowSpec
+		time    time.Time
+		want    bool
+	}{
+		{name: "no windows", time: time.Date(2026, 10, 14, 3, 0, 0, 0, time.UTC), want: true},
+		{name: "inside daily window", windows: []MaintenanceWindowSpec{daily}, time: time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "daily window end is exclusive", windows: []MaintenanceWindowSpec{daily}, time: time.Date(2026, 10, 14, 17, 0, 0, 0, time.UTC), want: false},
+		{name: "opened on saturday", windows: []MaintenanceWindowSpec{overnight}, time: time.Date(2026, 10, 17, 23, 0, 0, 0, losAngeles), want: true},
+		{name: "opened on saturday, utc", windows: []MaintenanceWindowSpec{overnight}, time: time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC), want: true},
+		{name: "past midnight of saturday window", windows: []MaintenanceWindowSpec{overnight}, time: time.Date(2026, 10, 18, 3, 0, 0, 0, losAngeles), want: true},
+		{name: "before saturday window", windows: []MaintenanceWindowSpec{overnight}, time: time.Date(2026, 10, 17, 21, 0, 0, 0, losAngeles), want: false},
+		{name: "after saturday window", windows: []MaintenanceWindowSpec{overnight}, time: time.Date(2026, 10, 18, 4, 0, 0, 0, losAngeles), want: false},
+		{name: "sunday night", windows: []MaintenanceWindowSpec{overnight}, time: time.Date(2026, 10, 18, 23, 0, 0, 0, losAngeles), want: false},
+		{name: "any window", windows: []MaintenanceWindowSpec{overnight, daily}, time: time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC), want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strategy := &AwsUpgradeStrategy{MaintenanceWindows: test.windows}
+			if got := strategy.InMaintenanceWindow(test.time); got != test.want {
+				t.Errorf("%v: got %v, want %v", test.name, got, test.want)
 			}
 		})
+	}
+}
+
+func TestSuspendProcessesNormalized(t *testing.T) {
+	configuration := &EKSConfiguration{
+		EksClusterName:     "my-eks-cluster",
+		NodeSecurityGroups: []string{"sg-123456789"},
+		Image:              "ami-12345",
+		InstanceType:       "m5.large",
+		KeyPairName:        "thisShouldBeOptional",
+		Subnets:            []string{"subnet-1111111"},
+		SuspendedProcesses: []string{"azrebalance", "AZRebalance", "ScheduledActions", "all"},
+	}
+	ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+	if err := ig.Validate(NewValidationOverrides(nil)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []string{"AZRebalance", "ScheduledActions", "all"}
+	if !reflect.DeepEqual(configuration.GetSuspendProcesses(), expected) {
+		t.Errorf("got suspendProcesses %v, want %v", configuration.GetSuspendProcesses(), expected)
+	}
+}
+
+func TestMetricsCollectionNormalized(t *testing.T) {
+	configuration := &EKSConfiguration{
+		EksClusterName:     "my-eks-cluster",
+		NodeSecurityGroups: []string{"sg-123456789"},
+		Image:              "ami-12345",
+		InstanceType:       "m5.large",
+		KeyPairName:        "thisShouldBeOptional",
+		Subnets:            []string{"subnet-1111111"},
+		MetricsCollection:  []string{"groupminsize", "GroupMaxSize", "GroupMinSize", "GroupInServiceInstances", "GroupSize"},
+	}
+	ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+	if err := ig.Validate(NewValidationOverrides(nil)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// every selected metric is kept, not only the last one, and names which are not group metrics are left as they are
+	expected := []string{"GroupMinSize", "GroupMaxSize", "GroupInServiceInstances", "GroupSize"}
+	if !reflect.DeepEqual(configuration.GetMetricsCollection(), expected) {
+		t.Errorf("got metricsCollection %v, want %v", configuration.GetMetricsCollection(), expected)
+	}
+}
+
+func TestIsKubeletAllowedLabel(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{key: "team", expected: true},
+		{key: "example.com/team", expected: true},
+		{key: "kubernetes.io.example.com/team", expected: true},
+		{key: "node.kubernetes.io/role", expected: true},
+		{key: "node.kubernetes.io/lifecycle", expected: true},
+		{key: "pool.node.kubernetes.io/name", expected: true},
+		{key: "kubelet.kubernetes.io/team", expected: true},
+		{key: "topology.kubernetes.io/zone", expected: true},
+		{key: "kubernetes.io/os", expected: true},
+		{key: "beta.kubernetes.io/instance-type", expected: true},
+		{key: "node-role.kubernetes.io/worker", expected: false},
+		{key: "kubernetes.io/role", expected: false},
+		{key: "topology.kubernetes.io/rack", expected: false},
+		{key: "k8s.io/team", expected: false},
+		{key: "team.k8s.io/name", expected: false},
+		{key: "Node-Role.Kubernetes.io/worker", expected: false},
+	}
 
+	for _, tc := range tests {
+		t.Run(tc.key, func(t *testing.T) {
+			if got := IsKubeletAllowedLabel(tc.key); got != tc.expected {
+				t.Errorf("IsKubeletAllowedLabel(%v) = %v, want %v", tc.key, got, tc.expected)
+			}
+		})
 	}
 }
 
@@ -616,3 +4404,300 @@ func MockEKSSpec() *EKSSpec {
 		},
 	}
 }
+
+func TestGetKubeletConfiguration(t *testing.T) {
+	var b *BootstrapOptions
+	fragment, err := b.GetKubeletConfiguration()
+	if err != nil || len(fragment) != 0 {
+		t.Errorf("expected empty kubelet configuration, got %v, %v", fragment, err)
+	}
+
+	b = &BootstrapOptions{
+		MaxPods:              10,
+		KubeletConfiguration: &runtime.RawExtension{Raw: []byte(`{"apiVersion": "kubelet.config.k8s.io/v1beta1", "kind": "KubeletConfiguration", "maxPods": 110, "providerID": "aws:///us-west-2a/i-1234", "cpuManagerPolicy": "static"}`)},
+	}
+	fragment, err = b.GetKubeletConfiguration()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := map[string]interface{}{"cpuManagerPolicy": "static"}; !reflect.DeepEqual(fragment, expected) {
+		t.Errorf("expected kubelet configuration %v, got %v", expected, fragment)
+	}
+}
+
+func TestInstanceTagLabelsValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		tagLabels map[string]string
+		wantErr   bool
+	}{
+		{name: "no tag labels"},
+		{name: "tag labels", tagLabels: map[string]string{"team": "example.com/team", "aws:autoscaling:groupName": "scaling-group"}},
+		{name: "empty tag key", tagLabels: map[string]string{"": "example.com/team"}, wantErr: true},
+		{name: "invalid label key", tagLabels: map[string]string{"team": "example.com/my team"}, wantErr: true},
+		{name: "duplicate label key", tagLabels: map[string]string{"team": "example.com/team", "Team": "example.com/team"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				InstanceTagLabels:  test.tagLabels,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthMappingsValidate(t *testing.T) {
+	var (
+		roleARN = "arn:aws:iam::123456789012:role/debug-role"
+		userARN = "arn:aws:iam::123456789012:user/debug-user"
+	)
+	tests := []struct {
+		name     string
+		mappings *AuthMappingsSpec
+		wantErr  bool
+	}{
+		{name: "no mappings"},
+		{name: "role and user mappings", mappings: &AuthMappingsSpec{Roles: []AuthMapping{{ARN: roleARN, Username: "debug", Groups: []string{"debuggers"}}}, Users: []AuthMapping{{ARN: userARN, Username: "debug-user"}}}},
+		{name: "invalid arn", mappings: &AuthMappingsSpec{Roles: []AuthMapping{{ARN: "debug-role", Username: "debug"}}}, wantErr: true},
+		{name: "user arn in roles", mappings: &AuthMappingsSpec{Roles: []AuthMapping{{ARN: userARN, Username: "debug"}}}, wantErr: true},
+		{name: "role arn in users", mappings: &AuthMappingsSpec{Users: []AuthMapping{{ARN: roleARN, Username: "debug"}}}, wantErr: true},
+		{name: "duplicate arn", mappings: &AuthMappingsSpec{Roles: []AuthMapping{{ARN: roleARN, Username: "debug"}, {ARN: roleARN, Username: "other"}}}, wantErr: true},
+		{name: "missing username", mappings: &AuthMappingsSpec{Users: []AuthMapping{{ARN: userARN}}}, wantErr: true},
+		{name: "system masters group", mappings: &AuthMappingsSpec{Roles: []AuthMapping{{ARN: roleARN, Username: "debug", Groups: []string{"system:masters"}}}}, wantErr: true},
+		{name: "system group", mappings: &AuthMappingsSpec{Users: []AuthMapping{{ARN: userARN, Username: "debug-user", Groups: []string{"debuggers", "system:nodes"}}}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				AuthMappings:       test.mappings,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadinessSLAValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sla     string
+		wantErr bool
+	}{
+		{name: "no sla"},
+		{name: "sla", sla: "10m"},
+		{name: "invalid sla", sla: "fast", wantErr: true},
+		{name: "non-positive sla", sla: "0s", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				ReadinessSLA:       test.sla,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestProxyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   *ProxySpec
+		wantErr bool
+	}{
+		{name: "no proxy"},
+		{name: "http and https proxy", proxy: &ProxySpec{HTTPProxy: "http://proxy.example.com:3128", HTTPSProxy: "https://proxy.example.com:3129", NoProxy: []string{".example.com", "10.0.0.0/8"}}},
+		{name: "https proxy", proxy: &ProxySpec{HTTPSProxy: "http://proxy.example.com:3128"}},
+		{name: "missing proxy", proxy: &ProxySpec{NoProxy: []string{".example.com"}}, wantErr: true},
+		{name: "missing scheme", proxy: &ProxySpec{HTTPProxy: "proxy.example.com:3128"}, wantErr: true},
+		{name: "unsupported scheme", proxy: &ProxySpec{HTTPSProxy: "socks5://proxy.example.com:1080"}, wantErr: true},
+		{name: "quoted proxy", proxy: &ProxySpec{HTTPProxy: "http://proxy.example.com:3128\""}, wantErr: true},
+		{name: "empty no proxy entry", proxy: &ProxySpec{HTTPProxy: "http://proxy.example.com:3128", NoProxy: []string{""}}, wantErr: true},
+		{name: "joined no proxy entries", proxy: &ProxySpec{HTTPProxy: "http://proxy.example.com:3128", NoProxy: []string{".example.com,10.0.0.0/8"}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				Proxy:              test.proxy,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCABundleValidate(t *testing.T) {
+	certificate := `-----BEGIN CERTIFICATE-----
+MIIBlDCCATmgAwIBAgIUIF/BL+oJz5pj6sGiKoNpe9uhgaIwCgYIKoZIzj0EAwIw
+HjEcMBoGA1UEAwwTRXhhbXBsZSBJbnRlcm5hbCBDQTAgFw0yNjEwMTUwMDA2NDBa
+GA8yMTI2MDkyMTAwMDY0MFowHjEcMBoGA1UEAwwTRXhhbXBsZSBJbnRlcm5hbCBD
+QTBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABOQn1G1y4huNLo0ELuB/lgjv7MNQ
+WO+wncfm3PWp0DY1a84FRgTrJ5m8NjxBZCixqPyWcXVeSyRlULAtMr7rat2jUzBR
+MB0GA1UdDgQWBBRFDUHk6nsdLecCdmEqmAcNxWcYMDAfBgNVHSMEGDAWgBRFDUHk
+6nsdLecCdmEqmAcNxWcYMDAPBgNVHRMBAf8EBTADAQH/MAoGCCqGSM49BAMCA0kA
+MEYCIQDhTMfxDBHW2TZie2J3Jb/iVgPDcXEpZRxyaGSmjJaU9AIhAI+txVvcJfWk
+M2LKzua6qSyf7rjBKHpJYwYfqrj1WC3v
+-----END CERTIFICATE-----
+`
+	tests := []struct {
+		name     string
+		caBundle *CABundleSpec
+		wantErr  bool
+	}{
+		{name: "no ca bundle"},
+		{name: "inline certificates", caBundle: &CABundleSpec{Certificates: certificate + certificate}},
+		{name: "configmap reference", caBundle: &CABundleSpec{ConfigMapRef: &CABundleConfigMapReference{Name: "internal-ca", Key: "ca.crt"}}},
+		{name: "no certificates or reference", caBundle: &CABundleSpec{}, wantErr: true},
+		{name: "certificates and reference", caBundle: &CABundleSpec{Certificates: certificate, ConfigMapRef: &CABundleConfigMapReference{Name: "internal-ca"}}, wantErr: true},
+		{name: "missing configmap name", caBundle: &CABundleSpec{ConfigMapRef: &CABundleConfigMapReference{Key: "ca.crt"}}, wantErr: true},
+		{name: "invalid certificates", caBundle: &CABundleSpec{Certificates: "not a certificate"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				CABundle:           test.caBundle,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageAllowlistValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist *ImageAllowlistSpec
+		wantErr   bool
+	}{
+		{name: "no allowlist"},
+		{name: "allowlist", allowlist: &ImageAllowlistSpec{IDs: []string{"ami-12345"}, Owners: []string{"amazon", "602401143452"}, NamePatterns: []string{"amazon-eks-node-1.29-*"}}},
+		{name: "empty allowlist", allowlist: &ImageAllowlistSpec{}, wantErr: true},
+		{name: "invalid id", allowlist: &ImageAllowlistSpec{IDs: []string{"12345"}}, wantErr: true},
+		{name: "empty owner", allowlist: &ImageAllowlistSpec{Owners: []string{""}}, wantErr: true},
+		{name: "invalid pattern", allowlist: &ImageAllowlistSpec{NamePatterns: []string{"amazon-eks-node-[1"}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:     "my-eks-cluster",
+				NodeSecurityGroups: []string{"sg-123456789"},
+				Image:              "ami-12345",
+				InstanceType:       "m5.large",
+				KeyPairName:        "thisShouldBeOptional",
+				Subnets:            []string{"subnet-1111111"},
+				ImageAllowlist:     test.allowlist,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageAllowlistIsAllowed(t *testing.T) {
+	allowlist := &ImageAllowlistSpec{
+		IDs:          []string{"ami-12345"},
+		Owners:       []string{"amazon", "602401143452"},
+		NamePatterns: []string{"bottlerocket-aws-k8s-1.29-*"},
+	}
+	tests := []struct {
+		name                         string
+		id, ownerId, ownerAlias, ami string
+		expected                     bool
+	}{
+		{name: "allowed id", id: "ami-12345", expected: true},
+		{name: "allowed owner id", id: "ami-23456", ownerId: "602401143452", expected: true},
+		{name: "allowed owner alias", id: "ami-23456", ownerId: "137112412989", ownerAlias: "amazon", expected: true},
+		{name: "allowed name", id: "ami-23456", ownerId: "123456789012", ami: "bottlerocket-aws-k8s-1.29-x86_64-v1.20.0", expected: true},
+		{name: "disallowed image", id: "ami-23456", ownerId: "123456789012", ami: "custom-eks-node-1.29"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := allowlist.IsAllowed(test.id, test.ownerId, test.ownerAlias, test.ami); got != test.expected {
+				t.Errorf("%v: got %v, expected %v", test.name, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestUnhealthyNodeConditionsValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions map[string]string
+		wantErr    bool
+	}{
+		{name: "no conditions"},
+		{name: "cordon conditions", conditions: map[string]string{"KernelDeadlock": "Cordon", "ReadonlyFilesystem": "Cordon"}},
+		{name: "empty condition type", conditions: map[string]string{"": "Cordon"}, wantErr: true},
+		{name: "ready condition", conditions: map[string]string{"Ready": "Cordon"}, wantErr: true},
+		{name: "invalid action", conditions: map[string]string{"KernelDeadlock": "Drain"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := &EKSConfiguration{
+				EksClusterName:          "my-eks-cluster",
+				NodeSecurityGroups:      []string{"sg-123456789"},
+				Image:                   "ami-12345",
+				InstanceType:            "m5.large",
+				KeyPairName:             "thisShouldBeOptional",
+				Subnets:                 []string{"subnet-1111111"},
+				UnhealthyNodeConditions: test.conditions,
+			}
+			ig := MockInstanceGroup("eks", "rollingUpdate", &EKSSpec{MaxSize: 1, MinSize: 1, Type: "LaunchTemplate", EKSConfiguration: configuration}, nil, nil)
+			if err := ig.Validate(NewValidationOverrides(nil)); (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AutoscalerTolerance is the relative difference between the metric value and its target within which the desired
+	// capacity is not changed, to avoid scaling on small fluctuations of the metric
+	AutoscalerTolerance = 0.1
+	// DefaultAutoscalerMaxValueAge is the age after which a reported metric value is stale, unless the metric sets
+	// maxValueAge
+	DefaultAutoscalerMaxValueAge = 5 * time.Minute
+)
+
+// InstanceGroupAutoscaler is the Schema for the instancegroupautoscalers API, it scales the desired capacity of an
+// instance group in the same namespace so that the value of a metric per instance is close to its target
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=instancegroupautoscalers,scope=Namespaced,shortName=iga
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.scaleTargetRef.name",description="instance group scaled by the autoscaler"
+// +kubebuilder:printcolumn:name="Min",type="integer",JSONPath=".spec.minCapacity",description="minimum desired capacity"
+// +kubebuilder:printcolumn:name="Max",type="integer",JSONPath=".spec.maxCapacity",description="maximum desired capacity"
+// +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.desiredCapacity",description="desired capacity last set by the autoscaler"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="time passed since autoscaler creation"
+type InstanceGroupAutoscaler struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   InstanceGroupAutoscalerSpec   `json:"spec"`
+	Status InstanceGroupAutoscalerStatus `json:"status,omitempty"`
+}
+
+// InstanceGroupAutoscalerList contains a list of InstanceGroupAutoscaler
+// +kubebuilder:object:root=true
+type InstanceGroupAutoscalerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []InstanceGroupAutoscaler `json:"items"`
+}
+
+// InstanceGroupAutoscalerSpec defines the instance group an autoscaler scales, the bounds of its desired capacity and
+// the metric it is scaled on
+type InstanceGroupAutoscalerSpec struct {
+	ScaleTargetRef ScaleTargetReference `json:"scaleTargetRef"`
+	MinCapacity    int64                `json:"minCapacity"`
+	MaxCapacity    int64                `json:"maxCapacity"`
+	Metric         AutoscalerMetricSpec `json:"metric"`
+}
+
+// ScaleTargetReference is the name of an instance group in the namespace of the autoscaler
+type ScaleTargetReference struct {
+	Name string `json:"name"`
+}
+
+// AutoscalerMetricSpec is the metric an instance group is scaled on
+type AutoscalerMetricSpec struct {
+	Name string `json:"name"`
+	// TargetAverageValue is the value of the metric per instance, the desired capacity is the current value of the
+	// metric divided by it
+	TargetAverageValue resource.Quantity `json:"targetAverageValue"`
+	// MaxValueAge is the duration after which a reported value is stale and no longer scaled on, e.g. 10m, defaults to 5m
+	MaxValueAge string `json:"maxValueAge,omitempty"`
+}
+
+// InstanceGroupAutoscalerStatus defines the observed state of an InstanceGroupAutoscaler
+type InstanceGroupAutoscalerStatus struct {
+	// CurrentValue is the value of the metric across the instance group, reported by a metrics adapter
+	CurrentValue *resource.Quantity `json:"currentValue,omitempty"`
+	// CurrentValueTime is when the metrics adapter last reported the current value, values without a time or older than
+	// the metric's maxValueAge are not scaled on
+	CurrentValueTime *metav1.Time `json:"currentValueTime,omitempty"`
+	// DesiredCapacity is the desired capacity the instance group was last scaled to
+	DesiredCapacity int64 `json:"desiredCapacity,omitempty"`
+	// LastScaleTime is when the instance group was last scaled
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&InstanceGroupAutoscaler{}, &InstanceGroupAutoscalerList{})
+}
+
+func (a *InstanceGroupAutoscaler) NamespacedName() string {
+	return fmt.Sprintf("%v/%v", a.GetNamespace(), a.GetName())
+}
+
+func (a *InstanceGroupAutoscaler) Validate() error {
+	s := a.Spec
+
+	if s.ScaleTargetRef.Name == "" {
+		return errors.Errorf("validation failed, 'scaleTargetRef.name' is a required parameter")
+	}
+	if s.MinCapacity < 0 {
+		return errors.Errorf("validation failed, 'minCapacity' must not be negative, got %v", s.MinCapacity)
+	}
+	if s.MaxCapacity < 1 || s.MaxCapacity < s.MinCapacity {
+		return errors.Errorf("validation failed, 'maxCapacity' must be positive and not less than 'minCapacity', got %v", s.MaxCapacity)
+	}
+	if s.Metric.Name == "" {
+		return errors.Errorf("validation failed, 'metric.name' is a required parameter")
+	}
+	if s.Metric.TargetAverageValue.Sign() <= 0 {
+		return errors.Errorf("validation failed, 'metric.targetAverageValue' must be positive, got %v", s.Metric.TargetAverageValue.String())
+	}
+	if s.Metric.MaxValueAge != "" {
+		age, err := time.ParseDuration(s.Metric.MaxValueAge)
+		if err != nil || age <= 0 {
+			return errors.Errorf("validation failed, 'metric.maxValueAge' must be a positive duration e.g. 10m")
+		}
+	}
+	return nil
+}
+
+func (a *InstanceGroupAutoscaler) GetScaleTargetName() string {
+	return a.Spec.ScaleTargetRef.Name
+}
+
+// GetMaxValueAge returns the age after which a reported metric value is stale
+func (a *InstanceGroupAutoscaler) GetMaxValueAge() time.Duration {
+	if age, err := time.ParseDuration(a.Spec.Metric.MaxValueAge); err == nil && age > 0 {
+		return age
+	}
+	return DefaultAutoscalerMaxValueAge
+}
+
+// IsValueStale returns true if the current value was reported without a time, or longer than the max value age ago
+func (a *InstanceGroupAutoscaler) IsValueStale() bool {
+	reported := a.Status.CurrentValueTime
+	return reported == nil || time.Since(reported.Time) > a.GetMaxValueAge()
+}
+
+// GetDesiredCapacity returns the capacity needed for the value of the metric per instance to meet its target, bounded
+// by min/max capacity. The current capacity is kept while no value is reported, the value is stale or the metric is
+// within the tolerance of its target, false is returned if no value was reported or the value is stale.
+func (a *InstanceGroupAutoscaler) GetDesiredCapacity(current int64) (int64, bool) {
+	value := a.Status.CurrentValue
+	target := a.Spec.Metric.TargetAverageValue
+	if value == nil || target.Sign() <= 0 || a.IsValueStale() {
+		return current, false
+	}
+
+	ratio := float64(value.MilliValue()) / float64(target.MilliValue())
+	desired := int64(math.Ceil(ratio))
+	if current > 0 && math.Abs(ratio/float64(current)-1) <= AutoscalerTolerance {
+		desired = current
+	}
+
+	switch {
+	case desired < a.Spec.MinCapacity:
+		desired = a.Spec.MinCapacity
+	case desired > a.Spec.MaxCapacity:
+		desired = a.Spec.MaxCapacity
+	}
+	return desired, true
+}
+
+func (s *InstanceGroupAutoscalerStatus) SetDesiredCapacity(capacity int64) {
+	s.DesiredCapacity = capacity
+}
+
+func (s *InstanceGroupAutoscalerStatus) SetLastScaleTime(t metav1.Time) {
+	s.LastScaleTime = &t
+}
@@ -0,0 +1,118 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func mockAutoscaler(min, max int64, target string) *InstanceGroupAutoscaler {
+	return &InstanceGroupAutoscaler{
+		Spec: InstanceGroupAutoscalerSpec{
+			ScaleTargetRef: ScaleTargetReference{Name: "instance-group-1"},
+			MinCapacity:    min,
+			MaxCapacity:    max,
+			Metric: AutoscalerMetricSpec{
+				Name:               "queue-depth",
+				TargetAverageValue: resource.MustParse(target),
+			},
+		},
+	}
+}
+
+func TestInstanceGroupAutoscalerValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoscaler *InstanceGroupAutoscaler
+		wantErr    bool
+	}{
+		{name: "valid autoscaler", autoscaler: mockAutoscaler(1, 10, "100")},
+		{name: "fractional target", autoscaler: mockAutoscaler(0, 10, "500m")},
+		{name: "missing target", autoscaler: func() *InstanceGroupAutoscaler {
+			a := mockAutoscaler(1, 10, "100")
+			a.Spec.ScaleTargetRef.Name = ""
+			return a
+		}(), wantErr: true},
+		{name: "missing metric", autoscaler: func() *InstanceGroupAutoscaler {
+			a := mockAutoscaler(1, 10, "100")
+			a.Spec.Metric.Name = ""
+			return a
+		}(), wantErr: true},
+		{name: "negative min", autoscaler: mockAutoscaler(-1, 10, "100"), wantErr: true},
+		{name: "max less than min", autoscaler: mockAutoscaler(5, 2, "100"), wantErr: true},
+		{name: "zero max", autoscaler: mockAutoscaler(0, 0, "100"), wantErr: true},
+		{name: "zero target", autoscaler: mockAutoscaler(1, 10, "0"), wantErr: true},
+		{name: "max value age", autoscaler: func() *InstanceGroupAutoscaler {
+			a := mockAutoscaler(1, 10, "100")
+			a.Spec.Metric.MaxValueAge = "10m"
+			return a
+		}()},
+		{name: "invalid max value age", autoscaler: func() *InstanceGroupAutoscaler {
+			a := mockAutoscaler(1, 10, "100")
+			a.Spec.Metric.MaxValueAge = "-10m"
+			return a
+		}(), wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.autoscaler.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("%v: got error %v, wantErr %v", test.name, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestInstanceGroupAutoscalerGetDesiredCapacity(t *testing.T) {
+	tests := []struct {
+		name         string
+		currentValue string
+		valueAge     time.Duration
+		maxValueAge  string
+		current      int64
+		expected     int64
+		expectedOk   bool
+	}{
+		{name: "metric not reported", current: 3, expected: 3},
+		{name: "stale value", currentValue: "450", valueAge: 10 * time.Minute, current: 3, expected: 3},
+		{name: "value within max value age", currentValue: "450", valueAge: 10 * time.Minute, maxValueAge: "15m", current: 3, expected: 5, expectedOk: true},
+		{name: "scale up", currentValue: "450", current: 3, expected: 5, expectedOk: true},
+		{name: "scale down", currentValue: "150", current: 3, expected: 2, expectedOk: true},
+		{name: "within tolerance", currentValue: "320", current: 3, expected: 3, expectedOk: true},
+		{name: "bounded by max", currentValue: "5k", current: 3, expected: 10, expectedOk: true},
+		{name: "bounded by min", currentValue: "0", current: 3, expected: 1, expectedOk: true},
+		{name: "from zero", currentValue: "250", current: 0, expected: 3, expectedOk: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			autoscaler := mockAutoscaler(1, 10, "100")
+			autoscaler.Spec.Metric.MaxValueAge = test.maxValueAge
+			if test.currentValue != "" {
+				value := resource.MustParse(test.currentValue)
+				autoscaler.Status.CurrentValue = &value
+				autoscaler.Status.CurrentValueTime = &metav1.Time{Time: time.Now().Add(-test.valueAge)}
+			}
+			got, ok := autoscaler.GetDesiredCapacity(test.current)
+			if got != test.expected || ok != test.expectedOk {
+				t.Errorf("%v: got %v/%v, expected %v/%v", test.name, got, ok, test.expected, test.expectedOk)
+			}
+		})
+	}
+}
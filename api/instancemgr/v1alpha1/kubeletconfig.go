@@ -0,0 +1,288 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	KubeletConfigurationAPIVersion = "kubelet.config.k8s.io/v1beta1"
+	KubeletConfigurationKind       = "KubeletConfiguration"
+)
+
+// kubeletConfigFieldType is the JSON type of a KubeletConfiguration field
+type kubeletConfigFieldType string
+
+const (
+	kubeletConfigString   kubeletConfigFieldType = "string"
+	kubeletConfigDuration kubeletConfigFieldType = "duration"
+	kubeletConfigBool     kubeletConfigFieldType = "boolean"
+	kubeletConfigInteger  kubeletConfigFieldType = "integer"
+	kubeletConfigNumber   kubeletConfigFieldType = "number"
+	kubeletConfigObject   kubeletConfigFieldType = "object"
+	kubeletConfigArray    kubeletConfigFieldType = "array"
+)
+
+var (
+	// ManagedKubeletConfigKeys are the KubeletConfiguration fields which are managed by instance-manager or by the node
+	// bootstrap, mapped to the spec field they are configured with, they cannot be set in a kubelet configuration fragment
+	ManagedKubeletConfigKeys = map[string]string{
+		"authentication":                  "",
+		"authorization":                   "",
+		"clusterDNS":                      "",
+		"clusterDomain":                   "",
+		"providerID":                      "",
+		"maxPods":                         "bootstrapOptions.maxPods",
+		"registerWithTaints":              "taints",
+		"imageGCHighThresholdPercent":     "bootstrapOptions.imageGCHighThreshold",
+		"imageGCLowThresholdPercent":      "bootstrapOptions.imageGCLowThreshold",
+		"serializeImagePulls":             "bootstrapOptions.serializeImagePulls",
+		"maxParallelImagePulls":           "bootstrapOptions.maxParallelImagePulls",
+		"shutdownGracePeriod":             "bootstrapOptions.shutdownGracePeriod",
+		"shutdownGracePeriodCriticalPods": "bootstrapOptions.shutdownGracePeriodCriticalPods",
+		"allowedUnsafeSysctls":            "bootstrapOptions.allowedUnsafeSysctls",
+	}
+
+	// kubeletConfigSchema are the fields of the kubelet.config.k8s.io/v1beta1 KubeletConfiguration and their types
+	kubeletConfigSchema = map[string]kubeletConfigFieldType{
+		"apiVersion":                       kubeletConfigString,
+		"kind":                             kubeletConfigString,
+		"enableServer":                     kubeletConfigBool,
+		"staticPodPath":                    kubeletConfigString,
+		"podLogsDir":                       kubeletConfigString,
+		"syncFrequency":                    kubeletConfigDuration,
+		"fileCheckFrequency":               kubeletConfigDuration,
+		"httpCheckFrequency":               kubeletConfigDuration,
+		"staticPodURL":                     kubeletConfigString,
+		"staticPodURLHeader":               kubeletConfigObject,
+		"address":                          kubeletConfigString,
+		"port":                             kubeletConfigInteger,
+		"readOnlyPort":                     kubeletConfigInteger,
+		"tlsCertFile":                      kubeletConfigString,
+		"tlsPrivateKeyFile":                kubeletConfigString,
+		"tlsCipherSuites":                  kubeletConfigArray,
+		"tlsMinVersion":                    kubeletConfigString,
+		"rotateCertificates":               kubeletConfigBool,
+		"serverTLSBootstrap":               kubeletConfigBool,
+		"authentication":                   kubeletConfigObject,
+		"authorization":                    kubeletConfigObject,
+		"registryPullQPS":                  kubeletConfigInteger,
+		"registryBurst":                    kubeletConfigInteger,
+		"eventRecordQPS":                   kubeletConfigInteger,
+		"eventBurst":                       kubeletConfigInteger,
+		"enableDebuggingHandlers":          kubeletConfigBool,
+		"enableContentionProfiling":        kubeletConfigBool,
+		"healthzPort":                      kubeletConfigInteger,
+		"healthzBindAddress":               kubeletConfigString,
+		"oomScoreAdj":                      kubeletConfigInteger,
+		"clusterDomain":                    kubeletConfigString,
+		"clusterDNS":                       kubeletConfigArray,
+		"streamingConnectionIdleTimeout":   kubeletConfigDuration,
+		"nodeStatusUpdateFrequency":        kubeletConfigDuration,
+		"nodeStatusReportFrequency":        kubeletConfigDuration,
+		"nodeLeaseDurationSeconds":         kubeletConfigInteger,
+		"imageMinimumGCAge":                kubeletConfigDuration,
+		"imageMaximumGCAge":                kubeletConfigDuration,
+		"imageGCHighThresholdPercent":      kubeletConfigInteger,
+		"imageGCLowThresholdPercent":       kubeletConfigInteger,
+		"volumeStatsAggPeriod":             kubeletConfigDuration,
+		"kubeletCgroups":                   kubeletConfigString,
+		"systemCgroups":                    kubeletConfigString,
+		"cgroupRoot":                       kubeletConfigString,
+		"cgroupsPerQOS":                    kubeletConfigBool,
+		"cgroupDriver":                     kubeletConfigString,
+		"cpuManagerPolicy":                 kubeletConfigString,
+		"cpuManagerPolicyOptions":          kubeletConfigObject,
+		"cpuManagerReconcilePeriod":        kubeletConfigDuration,
+		"singleProcessOOMKill":             kubeletConfigBool,
+		"memoryManagerPolicy":              kubeletConfigString,
+		"topologyManagerPolicy":            kubeletConfigString,
+		"topologyManagerScope":             kubeletConfigString,
+		"topologyManagerPolicyOptions":     kubeletConfigObject,
+		"qosReserved":                      kubeletConfigObject,
+		"runtimeRequestTimeout":            kubeletConfigDuration,
+		"hairpinMode":                      kubeletConfigString,
+		"maxPods":                          kubeletConfigInteger,
+		"podCIDR":                          kubeletConfigString,
+		"podPidsLimit":                     kubeletConfigInteger,
+		"resolvConf":                       kubeletConfigString,
+		"runOnce":                          kubeletConfigBool,
+		"cpuCFSQuota":                      kubeletConfigBool,
+		"cpuCFSQuotaPeriod":                kubeletConfigDuration,
+		"nodeStatusMaxImages":              kubeletConfigInteger,
+		"maxOpenFiles":                     kubeletConfigInteger,
+		"contentType":                      kubeletConfigString,
+		"kubeAPIQPS":                       kubeletConfigInteger,
+		"kubeAPIBurst":                     kubeletConfigInteger,
+		"serializeImagePulls":              kubeletConfigBool,
+		"maxParallelImagePulls":            kubeletConfigInteger,
+		"evictionHard":                     kubeletConfigObject,
+		"evictionSoft":                     kubeletConfigObject,
+		"evictionSoftGracePeriod":          kubeletConfigObject,
+		"evictionPressureTransitionPeriod": kubeletConfigDuration,
+		"evictionMaxPodGracePeriod":        kubeletConfigInteger,
+		"evictionMinimumReclaim":           kubeletConfigObject,
+		"podsPerCore":                      kubeletConfigInteger,
+		"enableControllerAttachDetach":     kubeletConfigBool,
+		"protectKernelDefaults":            kubeletConfigBool,
+		"makeIPTablesUtilChains":           kubeletConfigBool,
+		"iptablesMasqueradeBit":            kubeletConfigInteger,
+		"iptablesDropBit":                  kubeletConfigInteger,
+		"featureGates":                     kubeletConfigObject,
+		"failSwapOn":                       kubeletConfigBool,
+		"memorySwap":                       kubeletConfigObject,
+		"containerLogMaxSize":              kubeletConfigString,
+		"containerLogMaxFiles":             kubeletConfigInteger,
+		"containerLogMaxWorkers":           kubeletConfigInteger,
+		"containerLogMonitorInterval":      kubeletConfigDuration,
+		"configMapAndSecretChangeDetectionStrategy": kubeletConfigString,
+		"systemReserved":                   kubeletConfigObject,
+		"kubeReserved":                     kubeletConfigObject,
+		"reservedSystemCPUs":               kubeletConfigString,
+		"showHiddenMetricsForVersion":      kubeletConfigString,
+		"systemReservedCgroup":             kubeletConfigString,
+		"kubeReservedCgroup":               kubeletConfigString,
+		"enforceNodeAllocatable":           kubeletConfigArray,
+		"allowedUnsafeSysctls":             kubeletConfigArray,
+		"volumePluginDir":                  kubeletConfigString,
+		"providerID":                       kubeletConfigString,
+		"kernelMemcgNotification":          kubeletConfigBool,
+		"logging":                          kubeletConfigObject,
+		"enableSystemLogHandler":           kubeletConfigBool,
+		"enableSystemLogQuery":             kubeletConfigBool,
+		"shutdownGracePeriod":              kubeletConfigDuration,
+		"shutdownGracePeriodCriticalPods":  kubeletConfigDuration,
+		"shutdownGracePeriodByPodPriority": kubeletConfigArray,
+		"crashLoopBackOff":                 kubeletConfigObject,
+		"reservedMemory":                   kubeletConfigArray,
+		"enableProfilingHandler":           kubeletConfigBool,
+		"enableDebugFlagsHandler":          kubeletConfigBool,
+		"seccompDefault":                   kubeletConfigBool,
+		"memoryThrottlingFactor":           kubeletConfigNumber,
+		"registerWithTaints":               kubeletConfigArray,
+		"registerNode":                     kubeletConfigBool,
+		"tracing":                          kubeletConfigObject,
+		"localStorageCapacityIsolation":    kubeletConfigBool,
+		"containerRuntimeEndpoint":         kubeletConfigString,
+		"imageServiceEndpoint":             kubeletConfigString,
+		"failCgroupV1":                     kubeletConfigBool,
+		"userNamespaces":                   kubeletConfigObject,
+	}
+)
+
+// GetKubeletConfiguration returns the fields of the kubelet configuration fragment without its apiVersion and kind,
+// fields managed by instance-manager or the node bootstrap are left out so that they cannot be overridden by the fragment
+func (b *BootstrapOptions) GetKubeletConfiguration() (map[string]interface{}, error) {
+	fragment, err := b.parseKubeletConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	delete(fragment, "apiVersion")
+	delete(fragment, "kind")
+	for key := range fragment {
+		if _, ok := ManagedKubeletConfigKeys[key]; ok {
+			delete(fragment, key)
+		}
+	}
+	return fragment, nil
+}
+
+func (b *BootstrapOptions) parseKubeletConfiguration() (map[string]interface{}, error) {
+	fragment := make(map[string]interface{})
+	if b == nil || b.KubeletConfiguration == nil || len(b.KubeletConfiguration.Raw) == 0 {
+		return fragment, nil
+	}
+	if err := json.Unmarshal(b.KubeletConfiguration.Raw, &fragment); err != nil {
+		return nil, errors.Errorf("validation failed, 'bootstrapOptions.kubeletConfiguration' must be a KubeletConfiguration object")
+	}
+	return fragment, nil
+}
+
+// validateKubeletConfiguration validates the kubelet configuration fragment against the KubeletConfiguration schema,
+// managed fields must be configured with their spec fields instead
+func (b *BootstrapOptions) validateKubeletConfiguration() error {
+	fragment, err := b.parseKubeletConfiguration()
+	if err != nil {
+		return err
+	}
+
+	if apiVersion, ok := fragment["apiVersion"]; ok && apiVersion != KubeletConfigurationAPIVersion {
+		return errors.Errorf("validation failed, 'bootstrapOptions.kubeletConfiguration.apiVersion' must be %v, got %v", KubeletConfigurationAPIVersion, apiVersion)
+	}
+	if kind, ok := fragment["kind"]; ok && kind != KubeletConfigurationKind {
+		return errors.Errorf("validation failed, 'bootstrapOptions.kubeletConfiguration.kind' must be %v, got %v", KubeletConfigurationKind, kind)
+	}
+
+	keys := make([]string, 0, len(fragment))
+	for key := range fragment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fieldType, ok := kubeletConfigSchema[key]
+		if !ok {
+			return errors.Errorf("validation failed, 'bootstrapOptions.kubeletConfiguration.%v' is not a KubeletConfiguration field", key)
+		}
+		if !isKubeletConfigFieldType(fragment[key], fieldType) {
+			return errors.Errorf("validation failed, 'bootstrapOptions.kubeletConfiguration.%v' must be of type %v", key, fieldType)
+		}
+		if field, managed := ManagedKubeletConfigKeys[key]; managed {
+			if field == "" {
+				return errors.Errorf("validation failed, 'bootstrapOptions.kubeletConfiguration.%v' is managed by the node bootstrap", key)
+			}
+			return errors.Errorf("validation failed, 'bootstrapOptions.kubeletConfiguration.%v' is managed by instance-manager, use '%v' instead", key, field)
+		}
+	}
+	return nil
+}
+
+func isKubeletConfigFieldType(value interface{}, fieldType kubeletConfigFieldType) bool {
+	switch fieldType {
+	case kubeletConfigString:
+		_, ok := value.(string)
+		return ok
+	case kubeletConfigDuration:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.ParseDuration(s)
+		return err == nil
+	case kubeletConfigBool:
+		_, ok := value.(bool)
+		return ok
+	case kubeletConfigInteger:
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case kubeletConfigNumber:
+		_, ok := value.(float64)
+		return ok
+	case kubeletConfigObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case kubeletConfigArray:
+		_, ok := value.([]interface{})
+		return ok
+	}
+	return false
+}
@@ -25,6 +25,96 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthMapping) DeepCopyInto(out *AuthMapping) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthMapping.
+func (in *AuthMapping) DeepCopy() *AuthMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthMappingsSpec) DeepCopyInto(out *AuthMappingsSpec) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]AuthMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]AuthMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthMappingsSpec.
+func (in *AuthMappingsSpec) DeepCopy() *AuthMappingsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthMappingsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthMappingsStatus) DeepCopyInto(out *AuthMappingsStatus) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthMappingsStatus.
+func (in *AuthMappingsStatus) DeepCopy() *AuthMappingsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthMappingsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerMetricSpec) DeepCopyInto(out *AutoscalerMetricSpec) {
+	*out = *in
+	out.TargetAverageValue = in.TargetAverageValue.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerMetricSpec.
+func (in *AutoscalerMetricSpec) DeepCopy() *AutoscalerMetricSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerMetricSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AwsUpgradeStrategy) DeepCopyInto(out *AwsUpgradeStrategy) {
 	*out = *in
@@ -38,6 +128,18 @@ func (in *AwsUpgradeStrategy) DeepCopyInto(out *AwsUpgradeStrategy) {
 		*out = new(RollingUpdateStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BlueGreenType != nil {
+		in, out := &in.BlueGreenType, &out.BlueGreenType
+		*out = new(BlueGreenStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindowSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AwsUpgradeStrategy.
@@ -50,9 +152,92 @@ func (in *AwsUpgradeStrategy) DeepCopy() *AwsUpgradeStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenStatus) DeepCopyInto(out *BlueGreenStatus) {
+	*out = *in
+	if in.BlueInstances != nil {
+		in, out := &in.BlueInstances, &out.BlueInstances
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreviousInstances != nil {
+		in, out := &in.PreviousInstances, &out.PreviousInstances
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenStatus.
+func (in *BlueGreenStatus) DeepCopy() *BlueGreenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenStrategy) DeepCopyInto(out *BlueGreenStrategy) {
+	*out = *in
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(DrainSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenStrategy.
+func (in *BlueGreenStrategy) DeepCopy() *BlueGreenStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BootstrapOptions) DeepCopyInto(out *BootstrapOptions) {
 	*out = *in
+	if in.ImageGCHighThreshold != nil {
+		in, out := &in.ImageGCHighThreshold, &out.ImageGCHighThreshold
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ImageGCLowThreshold != nil {
+		in, out := &in.ImageGCLowThreshold, &out.ImageGCLowThreshold
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SerializeImagePulls != nil {
+		in, out := &in.SerializeImagePulls, &out.SerializeImagePulls
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MaxParallelImagePulls != nil {
+		in, out := &in.MaxParallelImagePulls, &out.MaxParallelImagePulls
+		*out = new(int64)
+		**out = **in
+	}
+	if in.AllowedUnsafeSysctls != nil {
+		in, out := &in.AllowedUnsafeSysctls, &out.AllowedUnsafeSysctls
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KubeletConfiguration != nil {
+		in, out := &in.KubeletConfiguration, &out.KubeletConfiguration
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapOptions.
@@ -65,6 +250,71 @@ func (in *BootstrapOptions) DeepCopy() *BootstrapOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapRetriesSpec) DeepCopyInto(out *BootstrapRetriesSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapRetriesSpec.
+func (in *BootstrapRetriesSpec) DeepCopy() *BootstrapRetriesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapRetriesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundleConfigMapReference) DeepCopyInto(out *CABundleConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundleConfigMapReference.
+func (in *CABundleConfigMapReference) DeepCopy() *CABundleConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundleConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundleSpec) DeepCopyInto(out *CABundleSpec) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(CABundleConfigMapReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundleSpec.
+func (in *CABundleSpec) DeepCopy() *CABundleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CNISpec) DeepCopyInto(out *CNISpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNISpec.
+func (in *CNISpec) DeepCopy() *CNISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CNISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CRDUpdateStrategy) DeepCopyInto(out *CRDUpdateStrategy) {
 	*out = *in
@@ -85,6 +335,61 @@ func (in *CRDUpdateStrategy) DeepCopy() *CRDUpdateStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNameSourceSpec) DeepCopyInto(out *ClusterNameSourceSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNameSourceSpec.
+func (in *ClusterNameSourceSpec) DeepCopy() *ClusterNameSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNameSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CostEstimateStatus) DeepCopyInto(out *CostEstimateStatus) {
+	*out = *in
+	if in.UnpricedInstanceTypes != nil {
+		in, out := &in.UnpricedInstanceTypes, &out.UnpricedInstanceTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CostEstimateStatus.
+func (in *CostEstimateStatus) DeepCopy() *CostEstimateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CostEstimateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrainSpec) DeepCopyInto(out *DrainSpec) {
+	*out = *in
+	if in.EvictDaemonSets != nil {
+		in, out := &in.EvictDaemonSets, &out.EvictDaemonSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrainSpec.
+func (in *DrainSpec) DeepCopy() *DrainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DrainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EKSConfiguration) DeepCopyInto(out *EKSConfiguration) {
 	*out = *in
@@ -113,7 +418,7 @@ func (in *EKSConfiguration) DeepCopyInto(out *EKSConfiguration) {
 	if in.BootstrapOptions != nil {
 		in, out := &in.BootstrapOptions, &out.BootstrapOptions
 		*out = new(BootstrapOptions)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Tags != nil {
 		in, out := &in.Tags, &out.Tags
@@ -135,6 +440,13 @@ func (in *EKSConfiguration) DeepCopyInto(out *EKSConfiguration) {
 			(*out)[key] = val
 		}
 	}
+	if in.InstanceTagLabels != nil {
+		in, out := &in.InstanceTagLabels, &out.InstanceTagLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Taints != nil {
 		in, out := &in.Taints, &out.Taints
 		*out = make([]v1.Taint, len(*in))
@@ -145,7 +457,9 @@ func (in *EKSConfiguration) DeepCopyInto(out *EKSConfiguration) {
 	if in.UserData != nil {
 		in, out := &in.UserData, &out.UserData
 		*out = make([]UserDataStage, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.ManagedPolicies != nil {
 		in, out := &in.ManagedPolicies, &out.ManagedPolicies
@@ -172,15 +486,153 @@ func (in *EKSConfiguration) DeepCopyInto(out *EKSConfiguration) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.Placement != nil {
-		in, out := &in.Placement, &out.Placement
-		*out = new(PlacementSpec)
-		**out = **in
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(PlacementSpec)
+		**out = **in
+	}
+	if in.MetadataOptions != nil {
+		in, out := &in.MetadataOptions, &out.MetadataOptions
+		*out = new(MetadataOptions)
+		**out = **in
+	}
+	if in.ClusterNameSource != nil {
+		in, out := &in.ClusterNameSource, &out.ClusterNameSource
+		*out = new(ClusterNameSourceSpec)
+		**out = **in
+	}
+	if in.DataVolume != nil {
+		in, out := &in.DataVolume, &out.DataVolume
+		*out = new(NodeVolume)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkInterfaces != nil {
+		in, out := &in.NetworkInterfaces, &out.NetworkInterfaces
+		*out = make([]NetworkInterfaceSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AssociatePublicIpAddress != nil {
+		in, out := &in.AssociatePublicIpAddress, &out.AssociatePublicIpAddress
+		*out = new(bool)
+		**out = **in
+	}
+	if in.StartupTaint != nil {
+		in, out := &in.StartupTaint, &out.StartupTaint
+		*out = new(StartupTaintSpec)
+		**out = **in
+	}
+	if in.TerminationPolicies != nil {
+		in, out := &in.TerminationPolicies, &out.TerminationPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultCooldown != nil {
+		in, out := &in.DefaultCooldown, &out.DefaultCooldown
+		*out = new(int64)
+		**out = **in
+	}
+	if in.InstanceMaintenancePolicy != nil {
+		in, out := &in.InstanceMaintenancePolicy, &out.InstanceMaintenancePolicy
+		*out = new(InstanceMaintenanceSpec)
+		**out = **in
+	}
+	if in.ClusterAutoscalerPriority != nil {
+		in, out := &in.ClusterAutoscalerPriority, &out.ClusterAutoscalerPriority
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RegistryCredentials != nil {
+		in, out := &in.RegistryCredentials, &out.RegistryCredentials
+		*out = new(RegistryCredentialsSpec)
+		**out = **in
+	}
+	if in.CNI != nil {
+		in, out := &in.CNI, &out.CNI
+		*out = new(CNISpec)
+		**out = **in
+	}
+	if in.VpcCNIWarmTargets != nil {
+		in, out := &in.VpcCNIWarmTargets, &out.VpcCNIWarmTargets
+		*out = new(VpcCNIWarmTargetsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WindowsContainerd != nil {
+		in, out := &in.WindowsContainerd, &out.WindowsContainerd
+		*out = new(WindowsContainerdSpec)
+		**out = **in
+	}
+	if in.PrePullImages != nil {
+		in, out := &in.PrePullImages, &out.PrePullImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BootstrapRetries != nil {
+		in, out := &in.BootstrapRetries, &out.BootstrapRetries
+		*out = new(BootstrapRetriesSpec)
+		**out = **in
+	}
+	if in.UserDataVariables != nil {
+		in, out := &in.UserDataVariables, &out.UserDataVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ReadinessChecks != nil {
+		in, out := &in.ReadinessChecks, &out.ReadinessChecks
+		*out = new(ReadinessChecksSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuthMappings != nil {
+		in, out := &in.AuthMappings, &out.AuthMappings
+		*out = new(AuthMappingsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeJoinDeadline != nil {
+		in, out := &in.NodeJoinDeadline, &out.NodeJoinDeadline
+		*out = new(NodeJoinDeadlineSpec)
+		**out = **in
+	}
+	if in.NotReadyReplacement != nil {
+		in, out := &in.NotReadyReplacement, &out.NotReadyReplacement
+		*out = new(NotReadyReplacementSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UnhealthyNodeConditions != nil {
+		in, out := &in.UnhealthyNodeConditions, &out.UnhealthyNodeConditions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.InstanceStorage != nil {
+		in, out := &in.InstanceStorage, &out.InstanceStorage
+		*out = new(InstanceStorageSpec)
+		**out = **in
+	}
+	if in.ImageAllowlist != nil {
+		in, out := &in.ImageAllowlist, &out.ImageAllowlist
+		*out = new(ImageAllowlistSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sysctls != nil {
+		in, out := &in.Sysctls, &out.Sysctls
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxySpec)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.MetadataOptions != nil {
-		in, out := &in.MetadataOptions, &out.MetadataOptions
-		*out = new(MetadataOptions)
-		**out = **in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(CABundleSpec)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -346,6 +798,57 @@ func (in *EKSSpec) DeepCopy() *EKSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageAllowlistSpec) DeepCopyInto(out *ImageAllowlistSpec) {
+	*out = *in
+	if in.IDs != nil {
+		in, out := &in.IDs, &out.IDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Owners != nil {
+		in, out := &in.Owners, &out.Owners
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamePatterns != nil {
+		in, out := &in.NamePatterns, &out.NamePatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageAllowlistSpec.
+func (in *ImageAllowlistSpec) DeepCopy() *ImageAllowlistSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageAllowlistSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageUpdateStatus) DeepCopyInto(out *ImageUpdateStatus) {
+	*out = *in
+	if in.RunningImageIDs != nil {
+		in, out := &in.RunningImageIDs, &out.RunningImageIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastCheckTime.DeepCopyInto(&out.LastCheckTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageUpdateStatus.
+func (in *ImageUpdateStatus) DeepCopy() *ImageUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InstanceGroup) DeepCopyInto(out *InstanceGroup) {
 	*out = *in
@@ -370,133 +873,389 @@ func (in *InstanceGroup) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
-	return nil
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupAutoscaler) DeepCopyInto(out *InstanceGroupAutoscaler) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupAutoscaler.
+func (in *InstanceGroupAutoscaler) DeepCopy() *InstanceGroupAutoscaler {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupAutoscaler)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InstanceGroupAutoscaler) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupAutoscalerList) DeepCopyInto(out *InstanceGroupAutoscalerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]InstanceGroupAutoscaler, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupAutoscalerList.
+func (in *InstanceGroupAutoscalerList) DeepCopy() *InstanceGroupAutoscalerList {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupAutoscalerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InstanceGroupAutoscalerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupAutoscalerSpec) DeepCopyInto(out *InstanceGroupAutoscalerSpec) {
+	*out = *in
+	out.ScaleTargetRef = in.ScaleTargetRef
+	in.Metric.DeepCopyInto(&out.Metric)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupAutoscalerSpec.
+func (in *InstanceGroupAutoscalerSpec) DeepCopy() *InstanceGroupAutoscalerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupAutoscalerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupAutoscalerStatus) DeepCopyInto(out *InstanceGroupAutoscalerStatus) {
+	*out = *in
+	if in.CurrentValue != nil {
+		in, out := &in.CurrentValue, &out.CurrentValue
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.CurrentValueTime != nil {
+		in, out := &in.CurrentValueTime, &out.CurrentValueTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastScaleTime != nil {
+		in, out := &in.LastScaleTime, &out.LastScaleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupAutoscalerStatus.
+func (in *InstanceGroupAutoscalerStatus) DeepCopy() *InstanceGroupAutoscalerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupAutoscalerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupCondition) DeepCopyInto(out *InstanceGroupCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupCondition.
+func (in *InstanceGroupCondition) DeepCopy() *InstanceGroupCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupList) DeepCopyInto(out *InstanceGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]InstanceGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupList.
+func (in *InstanceGroupList) DeepCopy() *InstanceGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InstanceGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupSpec) DeepCopyInto(out *InstanceGroupSpec) {
+	*out = *in
+	if in.EKSManagedSpec != nil {
+		in, out := &in.EKSManagedSpec, &out.EKSManagedSpec
+		*out = new(EKSManagedSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EKSFargateSpec != nil {
+		in, out := &in.EKSFargateSpec, &out.EKSFargateSpec
+		*out = new(EKSFargateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EKSSpec != nil {
+		in, out := &in.EKSSpec, &out.EKSSpec
+		*out = new(EKSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.AwsUpgradeStrategy.DeepCopyInto(&out.AwsUpgradeStrategy)
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupSpec.
+func (in *InstanceGroupSpec) DeepCopy() *InstanceGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupStatus) DeepCopyInto(out *InstanceGroupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]InstanceGroupCondition, len(*in))
+		copy(*out, *in)
+	}
+	if in.ZoneCapacity != nil {
+		in, out := &in.ZoneCapacity, &out.ZoneCapacity
+		*out = make([]ZoneCapacityStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProtectedInstances != nil {
+		in, out := &in.ProtectedInstances, &out.ProtectedInstances
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedJoinInstances != nil {
+		in, out := &in.FailedJoinInstances, &out.FailedJoinInstances
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotReadyInstances != nil {
+		in, out := &in.NotReadyInstances, &out.NotReadyInstances
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnhealthyProtectedInstances != nil {
+		in, out := &in.UnhealthyProtectedInstances, &out.UnhealthyProtectedInstances
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PrefixDensity != nil {
+		in, out := &in.PrefixDensity, &out.PrefixDensity
+		*out = new(PrefixDensityStatus)
+		**out = **in
+	}
+	if in.CostEstimate != nil {
+		in, out := &in.CostEstimate, &out.CostEstimate
+		*out = new(CostEstimateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LifecycleCapacity != nil {
+		in, out := &in.LifecycleCapacity, &out.LifecycleCapacity
+		*out = new(LifecycleCapacityStatus)
+		**out = **in
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageUpdate != nil {
+		in, out := &in.ImageUpdate, &out.ImageUpdate
+		*out = new(ImageUpdateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterAutoscalerPriority != nil {
+		in, out := &in.ClusterAutoscalerPriority, &out.ClusterAutoscalerPriority
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ClusterAutoscalerPriorityScalingGroups != nil {
+		in, out := &in.ClusterAutoscalerPriorityScalingGroups, &out.ClusterAutoscalerPriorityScalingGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AuthMappings != nil {
+		in, out := &in.AuthMappings, &out.AuthMappings
+		*out = new(AuthMappingsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ManagedRoleTags != nil {
+		in, out := &in.ManagedRoleTags, &out.ManagedRoleTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BootstrapDiagnostics != nil {
+		in, out := &in.BootstrapDiagnostics, &out.BootstrapDiagnostics
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(ReadinessStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupStatus.
+func (in *InstanceGroupStatus) DeepCopy() *InstanceGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *InstanceGroupCondition) DeepCopyInto(out *InstanceGroupCondition) {
+func (in *InstanceMaintenanceSpec) DeepCopyInto(out *InstanceMaintenanceSpec) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupCondition.
-func (in *InstanceGroupCondition) DeepCopy() *InstanceGroupCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceMaintenanceSpec.
+func (in *InstanceMaintenanceSpec) DeepCopy() *InstanceMaintenanceSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(InstanceGroupCondition)
+	out := new(InstanceMaintenanceSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *InstanceGroupList) DeepCopyInto(out *InstanceGroupList) {
+func (in *InstanceStorageSpec) DeepCopyInto(out *InstanceStorageSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]InstanceGroup, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupList.
-func (in *InstanceGroupList) DeepCopy() *InstanceGroupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceStorageSpec.
+func (in *InstanceStorageSpec) DeepCopy() *InstanceStorageSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(InstanceGroupList)
+	out := new(InstanceStorageSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *InstanceGroupList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *InstanceGroupSpec) DeepCopyInto(out *InstanceGroupSpec) {
+func (in *InstanceTypeSpec) DeepCopyInto(out *InstanceTypeSpec) {
 	*out = *in
-	if in.EKSManagedSpec != nil {
-		in, out := &in.EKSManagedSpec, &out.EKSManagedSpec
-		*out = new(EKSManagedSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.EKSFargateSpec != nil {
-		in, out := &in.EKSFargateSpec, &out.EKSFargateSpec
-		*out = new(EKSFargateSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.EKSSpec != nil {
-		in, out := &in.EKSSpec, &out.EKSSpec
-		*out = new(EKSSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	in.AwsUpgradeStrategy.DeepCopyInto(&out.AwsUpgradeStrategy)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupSpec.
-func (in *InstanceGroupSpec) DeepCopy() *InstanceGroupSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceTypeSpec.
+func (in *InstanceTypeSpec) DeepCopy() *InstanceTypeSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(InstanceGroupSpec)
+	out := new(InstanceTypeSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *InstanceGroupStatus) DeepCopyInto(out *InstanceGroupStatus) {
+func (in *LifecycleCapacityStatus) DeepCopyInto(out *LifecycleCapacityStatus) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]InstanceGroupCondition, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupStatus.
-func (in *InstanceGroupStatus) DeepCopy() *InstanceGroupStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LifecycleCapacityStatus.
+func (in *LifecycleCapacityStatus) DeepCopy() *LifecycleCapacityStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(InstanceGroupStatus)
+	out := new(LifecycleCapacityStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *InstanceTypeSpec) DeepCopyInto(out *InstanceTypeSpec) {
+func (in *LifecycleHookSpec) DeepCopyInto(out *LifecycleHookSpec) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceTypeSpec.
-func (in *InstanceTypeSpec) DeepCopy() *InstanceTypeSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LifecycleHookSpec.
+func (in *LifecycleHookSpec) DeepCopy() *LifecycleHookSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(InstanceTypeSpec)
+	out := new(LifecycleHookSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LifecycleHookSpec) DeepCopyInto(out *LifecycleHookSpec) {
+func (in *MaintenanceWindowSpec) DeepCopyInto(out *MaintenanceWindowSpec) {
 	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LifecycleHookSpec.
-func (in *LifecycleHookSpec) DeepCopy() *LifecycleHookSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindowSpec.
+func (in *MaintenanceWindowSpec) DeepCopy() *MaintenanceWindowSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(LifecycleHookSpec)
+	out := new(MaintenanceWindowSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -567,6 +1326,46 @@ func (in *MixedInstancesPolicySpec) DeepCopy() *MixedInstancesPolicySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterfaceSpec) DeepCopyInto(out *NetworkInterfaceSpec) {
+	*out = *in
+	if in.SecurityGroups != nil {
+		in, out := &in.SecurityGroups, &out.SecurityGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AssociatePublicIpAddress != nil {
+		in, out := &in.AssociatePublicIpAddress, &out.AssociatePublicIpAddress
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterfaceSpec.
+func (in *NetworkInterfaceSpec) DeepCopy() *NetworkInterfaceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterfaceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeJoinDeadlineSpec) DeepCopyInto(out *NodeJoinDeadlineSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeJoinDeadlineSpec.
+func (in *NodeJoinDeadlineSpec) DeepCopy() *NodeJoinDeadlineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeJoinDeadlineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeVolume) DeepCopyInto(out *NodeVolume) {
 	*out = *in
@@ -617,6 +1416,26 @@ func (in *NodeVolumeMountOptions) DeepCopy() *NodeVolumeMountOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotReadyReplacementSpec) DeepCopyInto(out *NotReadyReplacementSpec) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotReadyReplacementSpec.
+func (in *NotReadyReplacementSpec) DeepCopy() *NotReadyReplacementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NotReadyReplacementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
 	*out = *in
@@ -632,6 +1451,99 @@ func (in *PlacementSpec) DeepCopy() *PlacementSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrefixDensityStatus) DeepCopyInto(out *PrefixDensityStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrefixDensityStatus.
+func (in *PrefixDensityStatus) DeepCopy() *PrefixDensityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PrefixDensityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxySpec) DeepCopyInto(out *ProxySpec) {
+	*out = *in
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxySpec.
+func (in *ProxySpec) DeepCopy() *ProxySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessChecksSpec) DeepCopyInto(out *ReadinessChecksSpec) {
+	*out = *in
+	if in.DaemonSets != nil {
+		in, out := &in.DaemonSets, &out.DaemonSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessChecksSpec.
+func (in *ReadinessChecksSpec) DeepCopy() *ReadinessChecksSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessChecksSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessStatus) DeepCopyInto(out *ReadinessStatus) {
+	*out = *in
+	if in.ProvisioningStartTime != nil {
+		in, out := &in.ProvisioningStartTime, &out.ProvisioningStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastReadyTime != nil {
+		in, out := &in.LastReadyTime, &out.LastReadyTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessStatus.
+func (in *ReadinessStatus) DeepCopy() *ReadinessStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryCredentialsSpec) DeepCopyInto(out *RegistryCredentialsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryCredentialsSpec.
+func (in *RegistryCredentialsSpec) DeepCopy() *RegistryCredentialsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryCredentialsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RollingUpdateStrategy) DeepCopyInto(out *RollingUpdateStrategy) {
 	*out = *in
@@ -640,6 +1552,11 @@ func (in *RollingUpdateStrategy) DeepCopyInto(out *RollingUpdateStrategy) {
 		*out = new(intstr.IntOrString)
 		**out = **in
 	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(DrainSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStrategy.
@@ -652,9 +1569,44 @@ func (in *RollingUpdateStrategy) DeepCopy() *RollingUpdateStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleTargetReference) DeepCopyInto(out *ScaleTargetReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleTargetReference.
+func (in *ScaleTargetReference) DeepCopy() *ScaleTargetReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleTargetReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StartupTaintSpec) DeepCopyInto(out *StartupTaintSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StartupTaintSpec.
+func (in *StartupTaintSpec) DeepCopy() *StartupTaintSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StartupTaintSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UserDataStage) DeepCopyInto(out *UserDataStage) {
 	*out = *in
+	if in.Order != nil {
+		in, out := &in.Order, &out.Order
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserDataStage.
@@ -687,6 +1639,36 @@ func (in *ValidationOverrides) DeepCopy() *ValidationOverrides {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VpcCNIWarmTargetsSpec) DeepCopyInto(out *VpcCNIWarmTargetsSpec) {
+	*out = *in
+	if in.WarmIPTarget != nil {
+		in, out := &in.WarmIPTarget, &out.WarmIPTarget
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MinimumIPTarget != nil {
+		in, out := &in.MinimumIPTarget, &out.MinimumIPTarget
+		*out = new(int64)
+		**out = **in
+	}
+	if in.WarmENITarget != nil {
+		in, out := &in.WarmENITarget, &out.WarmENITarget
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VpcCNIWarmTargetsSpec.
+func (in *VpcCNIWarmTargetsSpec) DeepCopy() *VpcCNIWarmTargetsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VpcCNIWarmTargetsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WarmPoolSpec) DeepCopyInto(out *WarmPoolSpec) {
 	*out = *in
@@ -701,3 +1683,33 @@ func (in *WarmPoolSpec) DeepCopy() *WarmPoolSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WindowsContainerdSpec) DeepCopyInto(out *WindowsContainerdSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WindowsContainerdSpec.
+func (in *WindowsContainerdSpec) DeepCopy() *WindowsContainerdSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WindowsContainerdSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneCapacityStatus) DeepCopyInto(out *ZoneCapacityStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneCapacityStatus.
+func (in *ZoneCapacityStatus) DeepCopy() *ZoneCapacityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneCapacityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,87 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	v1alpha1 "github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetAutoscaler returns the InstanceGroupAutoscaler which targets the instance group, or nil if autoscalers are not
+// enabled or none targets it, an instance group can only be targeted by a single autoscaler
+func (r *InstanceGroupReconciler) GetAutoscaler(instanceGroup *v1alpha1.InstanceGroup) (*v1alpha1.InstanceGroupAutoscaler, error) {
+	if !r.AutoscalersEnabled {
+		return nil, nil
+	}
+
+	autoscalers := &v1alpha1.InstanceGroupAutoscalerList{}
+	if err := r.List(context.Background(), autoscalers, client.InNamespace(instanceGroup.GetNamespace())); err != nil {
+		return nil, errors.Wrap(err, "failed to list instance group autoscalers")
+	}
+
+	var autoscaler *v1alpha1.InstanceGroupAutoscaler
+	for i, a := range autoscalers.Items {
+		if a.GetScaleTargetName() != instanceGroup.GetName() {
+			continue
+		}
+		if autoscaler != nil {
+			return nil, errors.Errorf("instance group is targeted by multiple autoscalers, %v and %v", autoscaler.NamespacedName(), a.NamespacedName())
+		}
+		autoscaler = &autoscalers.Items[i]
+	}
+
+	if autoscaler == nil {
+		return nil, nil
+	}
+	if err := autoscaler.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid autoscaler %v", autoscaler.NamespacedName())
+	}
+	return autoscaler, nil
+}
+
+// PatchAutoscalerStatus patches the status of an autoscaler with the capacity it last scaled its instance group to
+func (r *InstanceGroupReconciler) PatchAutoscalerStatus(autoscaler *v1alpha1.InstanceGroupAutoscaler, patch client.Patch) {
+	if err := r.Status().Patch(context.Background(), autoscaler, patch); err != nil {
+		// avoid error if object already deleted
+		if kubeprovider.IsStorageError(err) {
+			return
+		}
+		r.Log.Info("failed to patch autoscaler status", "error", err, "autoscaler", autoscaler.NamespacedName())
+	}
+}
+
+func (r *InstanceGroupReconciler) autoscalerReconciler(obj client.Object) []ctrl.Request {
+	autoscaler, ok := obj.(*v1alpha1.InstanceGroupAutoscaler)
+	if !ok {
+		return nil
+	}
+
+	ctrl.Log.Info("autoscaler watch event", "autoscaler", autoscaler.NamespacedName(), "instancegroup", autoscaler.GetScaleTargetName())
+	return []ctrl.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Namespace: autoscaler.GetNamespace(),
+				Name:      autoscaler.GetScaleTargetName(),
+			},
+		},
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1alpha1 "github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func mockAutoscaler(name, namespace, target string) *v1alpha1.InstanceGroupAutoscaler {
+	return &v1alpha1.InstanceGroupAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.InstanceGroupAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetReference{Name: target},
+			MinCapacity:    1,
+			MaxCapacity:    10,
+			Metric: v1alpha1.AutoscalerMetricSpec{
+				Name:               "queue-depth",
+				TargetAverageValue: resource.MustParse("100"),
+			},
+		},
+	}
+}
+
+func TestGetAutoscaler(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	invalid := mockAutoscaler("invalid", "default", "workers")
+	invalid.Spec.MaxCapacity = 0
+
+	tests := []struct {
+		name        string
+		disabled    bool
+		objects     []runtime.Object
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:    "no autoscaler",
+			objects: []runtime.Object{mockAutoscaler("other", "default", "system")},
+		},
+		{
+			name:     "autoscaler targets instance group",
+			objects:  []runtime.Object{mockAutoscaler("workers-autoscaler", "default", "workers"), mockAutoscaler("other", "default", "system")},
+			expected: "default/workers-autoscaler",
+		},
+		{
+			name:    "autoscaler in other namespace",
+			objects: []runtime.Object{mockAutoscaler("workers-autoscaler", "other", "workers")},
+		},
+		{
+			name:     "autoscalers disabled",
+			disabled: true,
+			objects:  []runtime.Object{mockAutoscaler("workers-autoscaler", "default", "workers")},
+		},
+		{
+			name:        "multiple autoscalers",
+			objects:     []runtime.Object{mockAutoscaler("a", "default", "workers"), mockAutoscaler("b", "default", "workers")},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid autoscaler",
+			objects:     []runtime.Object{invalid},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test %v", tc.name)
+		instanceGroup := mockDependentInstanceGroup("workers", v1alpha1.ReconcileInit)
+		reconciler := createTestReconciler(append(tc.objects, instanceGroup)...)
+		reconciler.AutoscalersEnabled = !tc.disabled
+
+		autoscaler, err := reconciler.GetAutoscaler(instanceGroup)
+		if tc.expectedErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		if tc.expected == "" {
+			g.Expect(autoscaler).To(gomega.BeNil())
+			continue
+		}
+		g.Expect(autoscaler).NotTo(gomega.BeNil())
+		g.Expect(autoscaler.NamespacedName()).To(gomega.Equal(tc.expected))
+	}
+}
+
+func TestAutoscalerReconciler(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reconciler := createTestReconciler()
+	requests := reconciler.autoscalerReconciler(mockAutoscaler("workers-autoscaler", "default", "workers"))
+	g.Expect(requests).To(gomega.HaveLen(1))
+	g.Expect(requests[0].NamespacedName.String()).To(gomega.Equal("default/workers"))
+}
@@ -16,13 +16,47 @@ limitations under the License.
 package common
 
 import (
+	"context"
+	"reflect"
 	"strings"
-	"time"
 
-	awsauth "github.com/keikoproj/aws-auth/pkg/mapper"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 )
 
+const (
+	AuthConfigMapName      = "aws-auth"
+	AuthConfigMapNamespace = "kube-system"
+	AuthMapRolesKey        = "mapRoles"
+	AuthMapUsersKey        = "mapUsers"
+	NodeBootstrapUsername  = "system:node:{{EC2PrivateDNSName}}"
+)
+
+// RolesAuthMap is a mapRoles entry of the aws-auth configmap
+type RolesAuthMap struct {
+	RoleARN  string   `json:"rolearn"`
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// UsersAuthMap is a mapUsers entry of the aws-auth configmap
+type UsersAuthMap struct {
+	UserARN  string   `json:"userarn"`
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// OwnedAuthMappings are the ARNs of the additional aws-auth entries owned by an instance group
+type OwnedAuthMappings struct {
+	Roles []string
+	Users []string
+}
+
 func GetGroupsForOsFamily(osFamily string) []string {
 	if strings.EqualFold(osFamily, "windows") {
 		return []string{
@@ -38,57 +72,365 @@ func GetGroupsForOsFamily(osFamily string) []string {
 	}
 }
 
-func GetNodeBootstrapUpsert(arn string, osFamily string) *awsauth.MapperArguments {
-	return &awsauth.MapperArguments{
-		MapRoles:      true,
-		RoleARN:       arn,
-		Username:      "system:node:{{EC2PrivateDNSName}}",
-		Groups:        GetGroupsForOsFamily(osFamily),
-		WithRetries:   true,
-		MinRetryTime:  time.Millisecond * 100,
-		MaxRetryTime:  time.Second * 30,
-		MaxRetryCount: 12,
+func GetNodeBootstrapRole(arn string, osFamily string) RolesAuthMap {
+	return RolesAuthMap{
+		RoleARN:  arn,
+		Username: NodeBootstrapUsername,
+		Groups:   GetGroupsForOsFamily(osFamily),
 	}
 }
 
-func GetNodeBootstrapRemove(arn string, osFamily string) *awsauth.MapperArguments {
-	return &awsauth.MapperArguments{
-		MapRoles:      true,
-		RoleARN:       arn,
-		Force:         true,
-		Username:      "system:node:{{EC2PrivateDNSName}}",
-		Groups:        GetGroupsForOsFamily(osFamily),
-		WithRetries:   true,
-		MinRetryTime:  time.Millisecond * 100,
-		MaxRetryTime:  time.Second * 30,
-		MaxRetryCount: 12,
+// ReadAuthRoles returns the mapRoles entries of the aws-auth configmap, the configmap is nil if it does not exist
+func ReadAuthRoles(kube kubernetes.Interface) (*corev1.ConfigMap, []RolesAuthMap, error) {
+	cm, err := kube.CoreV1().ConfigMaps(AuthConfigMapNamespace).Get(context.Background(), AuthConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.Wrap(err, "failed to get aws-auth configmap")
+	}
+
+	roles := make([]RolesAuthMap, 0)
+	if err := yaml.Unmarshal([]byte(cm.Data[AuthMapRolesKey]), &roles); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse aws-auth mapRoles")
 	}
+	return cm, roles, nil
+}
+
+// ReadAuthUsers returns the mapUsers entries of the aws-auth configmap, the configmap is nil if it does not exist
+func ReadAuthUsers(kube kubernetes.Interface) (*corev1.ConfigMap, []UsersAuthMap, error) {
+	cm, _, err := ReadAuthRoles(kube)
+	if err != nil || cm == nil {
+		return nil, nil, err
+	}
+
+	users, err := parseAuthUsers(cm)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cm, users, nil
+}
+
+func parseAuthUsers(cm *corev1.ConfigMap) ([]UsersAuthMap, error) {
+	users := make([]UsersAuthMap, 0)
+	if err := yaml.Unmarshal([]byte(cm.Data[AuthMapUsersKey]), &users); err != nil {
+		return nil, errors.Wrap(err, "failed to parse aws-auth mapUsers")
+	}
+	return users, nil
+}
+
+// updateAuthRoles applies a mutation to the mapRoles entries of the aws-auth configmap, other keys of the configmap are
+// left untouched.
+func updateAuthRoles(kube kubernetes.Interface, mutate func([]RolesAuthMap) []RolesAuthMap) error {
+	return updateAuthConfigMap(kube, mutate, nil)
+}
+
+// updateAuthConfigMap applies mutations to the mapRoles and mapUsers entries of the aws-auth configmap, mapUsers is
+// only parsed if its mutation is not nil and other keys of the configmap are left untouched. The configmap is only
+// written if the mutations changed the entries, and the read-modify-write is retried if the configmap was modified
+// concurrently.
+func updateAuthConfigMap(kube kubernetes.Interface, mutateRoles func([]RolesAuthMap) []RolesAuthMap, mutateUsers func([]UsersAuthMap) []UsersAuthMap) error {
+	isConcurrentEdit := func(err error) bool {
+		return kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err)
+	}
+
+	return retry.OnError(retry.DefaultBackoff, isConcurrentEdit, func() error {
+		cm, roles, err := ReadAuthRoles(kube)
+		if err != nil {
+			return err
+		}
+
+		data := make(map[string]string)
+		updatedRoles := mutateRoles(append([]RolesAuthMap{}, roles...))
+		if !reflect.DeepEqual(roles, updatedRoles) {
+			marshaled, err := yaml.Marshal(updatedRoles)
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal aws-auth mapRoles")
+			}
+			data[AuthMapRolesKey] = string(marshaled)
+		}
+
+		if mutateUsers != nil {
+			users := make([]UsersAuthMap, 0)
+			if cm != nil {
+				if users, err = parseAuthUsers(cm); err != nil {
+					return err
+				}
+			}
+			updatedUsers := mutateUsers(append([]UsersAuthMap{}, users...))
+			if !reflect.DeepEqual(users, updatedUsers) {
+				marshaled, err := yaml.Marshal(updatedUsers)
+				if err != nil {
+					return errors.Wrap(err, "failed to marshal aws-auth mapUsers")
+				}
+				data[AuthMapUsersKey] = string(marshaled)
+			}
+		}
+
+		if len(data) == 0 {
+			return nil
+		}
+
+		if cm == nil {
+			if len(updatedRoles) == 0 && data[AuthMapUsersKey] == "" {
+				return nil
+			}
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AuthConfigMapName,
+					Namespace: AuthConfigMapNamespace,
+				},
+				Data: data,
+			}
+			_, err = kube.CoreV1().ConfigMaps(AuthConfigMapNamespace).Create(context.Background(), cm, metav1.CreateOptions{})
+			return err
+		}
+
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		for key, value := range data {
+			cm.Data[key] = value
+		}
+		_, err = kube.CoreV1().ConfigMaps(AuthConfigMapNamespace).Update(context.Background(), cm, metav1.UpdateOptions{})
+		return err
+	})
 }
 
 func RemoveAuthConfigMap(kube kubernetes.Interface, arns []string, osFamilies []string) error {
-	authMap := awsauth.New(kube, false)
-	for index, arn := range arns {
+	remove := make(map[string]bool)
+	for _, arn := range arns {
 		if arn == "" {
 			continue
 		}
-		err := authMap.Remove(GetNodeBootstrapRemove(arn, osFamilies[index]))
-		if err != nil {
-			return err
-		}
+		remove[arn] = true
 	}
-	return nil
+	if len(remove) == 0 {
+		return nil
+	}
+
+	err := updateAuthRoles(kube, func(roles []RolesAuthMap) []RolesAuthMap {
+		updated := make([]RolesAuthMap, 0, len(roles))
+		for _, role := range roles {
+			if remove[role.RoleARN] {
+				continue
+			}
+			updated = append(updated, role)
+		}
+		return updated
+	})
+	return errors.Wrap(err, "failed to remove node roles from aws-auth")
 }
 
 func UpsertAuthConfigMap(kube kubernetes.Interface, arns []string, osFamilies []string) error {
-	authMap := awsauth.New(kube, false)
+	upsert := make([]RolesAuthMap, 0)
 	for index, arn := range arns {
 		if arn == "" {
 			continue
 		}
-		err := authMap.Upsert(GetNodeBootstrapUpsert(arn, osFamilies[index]))
-		if err != nil {
-			return err
+		upsert = append(upsert, GetNodeBootstrapRole(arn, osFamilies[index]))
+	}
+	if len(upsert) == 0 {
+		return nil
+	}
+
+	err := updateAuthRoles(kube, func(roles []RolesAuthMap) []RolesAuthMap {
+		for _, expected := range upsert {
+			var found bool
+			for i, role := range roles {
+				if role.RoleARN != expected.RoleARN {
+					continue
+				}
+				found = true
+				roles[i] = expected
+			}
+			if !found {
+				roles = append(roles, expected)
+			}
+		}
+		return roles
+	})
+	return errors.Wrap(err, "failed to upsert node roles in aws-auth")
+}
+
+// authEntry is a mapRoles or mapUsers entry of the aws-auth configmap
+type authEntry struct {
+	ARN      string
+	Username string
+	Groups   []string
+}
+
+// matches returns true if the entry maps the same ARN to the same username and groups
+func (e authEntry) matches(other authEntry) bool {
+	if e.ARN != other.ARN || e.Username != other.Username || len(e.Groups) != len(other.Groups) {
+		return false
+	}
+	for i := range e.Groups {
+		if e.Groups[i] != other.Groups[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// adoptAuthEntries returns the owned ARNs, extended by the ARNs of the entries which exactly match an adoptable entry
+func adoptAuthEntries(entries, adoptable []authEntry, owned []string) []string {
+	var (
+		isOwned = make(map[string]bool)
+		adopted = append(make([]string, 0, len(owned)), owned...)
+	)
+	for _, arn := range owned {
+		isOwned[arn] = true
+	}
+	for _, expected := range adoptable {
+		for _, entry := range entries {
+			if isOwned[entry.ARN] || !entry.matches(expected) {
+				continue
+			}
+			isOwned[entry.ARN] = true
+			adopted = append(adopted, entry.ARN)
+		}
+	}
+	return adopted
+}
+
+// AdoptAuthMappings returns the owned ARNs, extended by the ARNs of the aws-auth entries which exactly match one of the
+// given mappings. Ownership is only recorded in the instance group's status after the aws-auth configmap is written,
+// entries which exactly match are therefore adopted so that they are not orphaned if recording their ownership failed.
+func AdoptAuthMappings(kube kubernetes.Interface, roles []RolesAuthMap, users []UsersAuthMap, owned OwnedAuthMappings) (OwnedAuthMappings, error) {
+	cm, existingRoles, err := ReadAuthRoles(kube)
+	if err != nil || cm == nil {
+		return owned, err
+	}
+	existingUsers, err := parseAuthUsers(cm)
+	if err != nil {
+		return owned, err
+	}
+
+	var (
+		entries   = make([]authEntry, 0, len(existingRoles))
+		adoptable = make([]authEntry, 0, len(roles))
+	)
+	for _, role := range existingRoles {
+		entries = append(entries, authEntry{ARN: role.RoleARN, Username: role.Username, Groups: role.Groups})
+	}
+	for _, role := range roles {
+		adoptable = append(adoptable, authEntry{ARN: role.RoleARN, Username: role.Username, Groups: role.Groups})
+	}
+	owned.Roles = adoptAuthEntries(entries, adoptable, owned.Roles)
+
+	entries = make([]authEntry, 0, len(existingUsers))
+	adoptable = make([]authEntry, 0, len(users))
+	for _, user := range existingUsers {
+		entries = append(entries, authEntry{ARN: user.UserARN, Username: user.Username, Groups: user.Groups})
+	}
+	for _, user := range users {
+		adoptable = append(adoptable, authEntry{ARN: user.UserARN, Username: user.Username, Groups: user.Groups})
+	}
+	owned.Users = adoptAuthEntries(entries, adoptable, owned.Users)
+	return owned, nil
+}
+
+// syncAuthEntries removes the owned entries which are no longer desired, and adds or updates the desired entries
+// unless an entry of their ARN exists which is not owned. Entries which are not owned but exactly match their desired
+// entry are adopted. It returns the updated entries, the ARNs of the desired
+// entries which are now owned and the ARNs of those which were skipped.
+func syncAuthEntries(entries, desired []authEntry, owned []string) ([]authEntry, []string, []string) {
+	var (
+		isOwned   = make(map[string]bool)
+		isDesired = make(map[string]bool)
+		updated   = make([]authEntry, 0, len(entries))
+		nowOwned  = make([]string, 0)
+		skipped   = make([]string, 0)
+	)
+	for _, entry := range desired {
+		isDesired[entry.ARN] = true
+	}
+	for _, arn := range adoptAuthEntries(entries, desired, owned) {
+		isOwned[arn] = true
+	}
+
+	for _, entry := range entries {
+		if isOwned[entry.ARN] && !isDesired[entry.ARN] {
+			continue
+		}
+		updated = append(updated, entry)
+	}
+
+	for _, expected := range desired {
+		var found, conflict bool
+		for i, entry := range updated {
+			if entry.ARN != expected.ARN {
+				continue
+			}
+			found = true
+			if !isOwned[entry.ARN] {
+				conflict = true
+				continue
+			}
+			updated[i] = expected
+		}
+		if conflict {
+			skipped = append(skipped, expected.ARN)
+			continue
+		}
+		if !found {
+			updated = append(updated, expected)
+		}
+		nowOwned = append(nowOwned, expected.ARN)
+	}
+	return updated, nowOwned, skipped
+}
+
+// SyncAuthMappings reconciles the additional aws-auth entries of an instance group. Entries of owned ARNs which are no
+// longer desired are removed, and desired entries are added or updated unless an entry of their ARN exists which is not
+// owned, so that the entries of node roles, other instance groups and cluster operators are never modified. It returns
+// the ARNs of the entries which are owned afterwards, and the ARNs of the desired entries which were skipped.
+func SyncAuthMappings(kube kubernetes.Interface, roles []RolesAuthMap, users []UsersAuthMap, owned OwnedAuthMappings) (OwnedAuthMappings, []string, error) {
+	var (
+		result       OwnedAuthMappings
+		skipped      []string
+		desiredRoles = make([]authEntry, 0, len(roles))
+		desiredUsers = make([]authEntry, 0, len(users))
+	)
+	for _, role := range roles {
+		desiredRoles = append(desiredRoles, authEntry{ARN: role.RoleARN, Username: role.Username, Groups: role.Groups})
+	}
+	for _, user := range users {
+		desiredUsers = append(desiredUsers, authEntry{ARN: user.UserARN, Username: user.Username, Groups: user.Groups})
+	}
+
+	err := updateAuthConfigMap(kube, func(existing []RolesAuthMap) []RolesAuthMap {
+		entries := make([]authEntry, 0, len(existing))
+		for _, role := range existing {
+			entries = append(entries, authEntry{ARN: role.RoleARN, Username: role.Username, Groups: role.Groups})
+		}
+		var skippedRoles []string
+		entries, result.Roles, skippedRoles = syncAuthEntries(entries, desiredRoles, owned.Roles)
+		skipped = skippedRoles
+
+		updated := make([]RolesAuthMap, 0, len(entries))
+		for _, entry := range entries {
+			updated = append(updated, RolesAuthMap{RoleARN: entry.ARN, Username: entry.Username, Groups: entry.Groups})
+		}
+		return updated
+	}, func(existing []UsersAuthMap) []UsersAuthMap {
+		entries := make([]authEntry, 0, len(existing))
+		for _, user := range existing {
+			entries = append(entries, authEntry{ARN: user.UserARN, Username: user.Username, Groups: user.Groups})
+		}
+		var skippedUsers []string
+		entries, result.Users, skippedUsers = syncAuthEntries(entries, desiredUsers, owned.Users)
+		skipped = append(skipped, skippedUsers...)
+
+		updated := make([]UsersAuthMap, 0, len(entries))
+		for _, entry := range entries {
+			updated = append(updated, UsersAuthMap{UserARN: entry.ARN, Username: entry.Username, Groups: entry.Groups})
 		}
+		return updated
+	})
+	if err != nil {
+		return owned, nil, errors.Wrap(err, "failed to sync additional mappings in aws-auth")
 	}
-	return nil
+	return result, skipped, nil
 }
@@ -0,0 +1,314 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	testNodeRoleARN  = "arn:aws:iam::123456789012:role/my-node-role"
+	testOtherRoleARN = "arn:aws:iam::123456789012:role/other-role"
+)
+
+func mockAuthConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AuthConfigMapName,
+			Namespace: AuthConfigMapNamespace,
+		},
+		Data: map[string]string{
+			AuthMapRolesKey: "- rolearn: " + testOtherRoleARN + "\n  username: admin\n  groups:\n  - system:masters\n",
+			"mapUsers":      "- userarn: arn:aws:iam::123456789012:user/admin\n  username: admin\n",
+			"mapAccounts":   "- \"123456789012\"\n",
+		},
+	}
+}
+
+func TestUpsertAuthConfigMap(t *testing.T) {
+	other := RolesAuthMap{RoleARN: testOtherRoleARN, Username: "admin", Groups: []string{"system:masters"}}
+
+	tests := []struct {
+		name     string
+		existing *corev1.ConfigMap
+		osFamily string
+		expected []RolesAuthMap
+	}{
+		{
+			name:     "configmap is created",
+			osFamily: "amazonlinux2",
+			expected: []RolesAuthMap{GetNodeBootstrapRole(testNodeRoleARN, "amazonlinux2")},
+		},
+		{
+			name:     "entry is added",
+			existing: mockAuthConfigMap(),
+			osFamily: "amazonlinux2",
+			expected: []RolesAuthMap{other, GetNodeBootstrapRole(testNodeRoleARN, "amazonlinux2")},
+		},
+		{
+			name:     "windows entry is added",
+			existing: mockAuthConfigMap(),
+			osFamily: "windows",
+			expected: []RolesAuthMap{other, {RoleARN: testNodeRoleARN, Username: NodeBootstrapUsername, Groups: []string{"system:bootstrappers", "system:nodes", "eks:kube-proxy-windows"}}},
+		},
+		{
+			name: "entry is corrected",
+			existing: func() *corev1.ConfigMap {
+				cm := mockAuthConfigMap()
+				cm.Data[AuthMapRolesKey] += "- rolearn: " + testNodeRoleARN + "\n  username: someone\n  groups:\n  - system:nodes\n"
+				return cm
+			}(),
+			osFamily: "amazonlinux2",
+			expected: []RolesAuthMap{other, GetNodeBootstrapRole(testNodeRoleARN, "amazonlinux2")},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset()
+			if tc.existing != nil {
+				kube = fake.NewSimpleClientset(tc.existing)
+			}
+
+			if err := UpsertAuthConfigMap(kube, []string{testNodeRoleARN, ""}, []string{tc.osFamily, ""}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cm, roles, err := ReadAuthRoles(kube)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(roles, tc.expected) {
+				t.Errorf("expected roles %+v, got %+v", tc.expected, roles)
+			}
+			if tc.existing != nil {
+				for _, key := range []string{"mapUsers", "mapAccounts"} {
+					if cm.Data[key] != tc.existing.Data[key] {
+						t.Errorf("expected %v to be preserved, got %q", key, cm.Data[key])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUpsertAuthConfigMapUnchanged(t *testing.T) {
+	kube := fake.NewSimpleClientset(mockAuthConfigMap())
+	if err := UpsertAuthConfigMap(kube, []string{testNodeRoleARN}, []string{"amazonlinux2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var updates int
+	kube.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		updates++
+		return false, nil, nil
+	})
+	if err := UpsertAuthConfigMap(kube, []string{testNodeRoleARN}, []string{"amazonlinux2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updates != 0 {
+		t.Errorf("expected existing entry not to be rewritten, got %v updates", updates)
+	}
+}
+
+func TestUpsertAuthConfigMapConflict(t *testing.T) {
+	kube := fake.NewSimpleClientset(mockAuthConfigMap())
+
+	// another writer adds an entry before our first update lands
+	var conflicts int
+	kube.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts > 0 {
+			return false, nil, nil
+		}
+		conflicts++
+		cm := mockAuthConfigMap()
+		cm.Data[AuthMapRolesKey] += "- rolearn: arn:aws:iam::123456789012:role/concurrent-role\n  username: concurrent\n"
+		if err := kube.Tracker().Update(corev1.SchemeGroupVersion.WithResource("configmaps"), cm, AuthConfigMapNamespace); err != nil {
+			return true, nil, err
+		}
+		return true, nil, kerrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, AuthConfigMapName, nil)
+	})
+
+	if err := UpsertAuthConfigMap(kube, []string{testNodeRoleARN}, []string{"amazonlinux2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, roles, err := ReadAuthRoles(kube)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	arns := make([]string, 0)
+	for _, role := range roles {
+		arns = append(arns, role.RoleARN)
+	}
+	expected := []string{testOtherRoleARN, "arn:aws:iam::123456789012:role/concurrent-role", testNodeRoleARN}
+	if !reflect.DeepEqual(arns, expected) {
+		t.Errorf("expected roles %v, got %v", expected, arns)
+	}
+}
+
+func TestRemoveAuthConfigMap(t *testing.T) {
+	existing := mockAuthConfigMap()
+	kube := fake.NewSimpleClientset(existing)
+	if err := UpsertAuthConfigMap(kube, []string{testNodeRoleARN}, []string{"windows"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := RemoveAuthConfigMap(kube, []string{testNodeRoleARN, ""}, []string{"windows", ""}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm, roles, err := ReadAuthRoles(kube)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []RolesAuthMap{{RoleARN: testOtherRoleARN, Username: "admin", Groups: []string{"system:masters"}}}
+	if !reflect.DeepEqual(roles, expected) {
+		t.Errorf("expected roles %+v, got %+v", expected, roles)
+	}
+	for _, key := range []string{"mapUsers", "mapAccounts"} {
+		if cm.Data[key] != existing.Data[key] {
+			t.Errorf("expected %v to be preserved, got %q", key, cm.Data[key])
+		}
+	}
+
+	// removing from a missing configmap does not create it
+	kube = fake.NewSimpleClientset()
+	if err := RemoveAuthConfigMap(kube, []string{testNodeRoleARN}, []string{"windows"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := kube.CoreV1().ConfigMaps(AuthConfigMapNamespace).Get(context.Background(), AuthConfigMapName, metav1.GetOptions{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected aws-auth configmap not to be created, got %v", err)
+	}
+}
+
+func TestSyncAuthMappings(t *testing.T) {
+	var (
+		debugRoleARN = "arn:aws:iam::123456789012:role/debug-role"
+		debugUserARN = "arn:aws:iam::123456789012:user/debug-user"
+		adminUserARN = "arn:aws:iam::123456789012:user/admin"
+		other        = RolesAuthMap{RoleARN: testOtherRoleARN, Username: "admin", Groups: []string{"system:masters"}}
+		admin        = UsersAuthMap{UserARN: adminUserARN, Username: "admin"}
+		debugRole    = RolesAuthMap{RoleARN: debugRoleARN, Username: "debug", Groups: []string{"debuggers"}}
+		debugUser    = UsersAuthMap{UserARN: debugUserARN, Username: "debug-user", Groups: []string{"debuggers"}}
+	)
+
+	existing := mockAuthConfigMap()
+	kube := fake.NewSimpleClientset(existing)
+	if err := UpsertAuthConfigMap(kube, []string{testNodeRoleARN}, []string{"amazonlinux2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nodeRole := GetNodeBootstrapRole(testNodeRoleARN, "amazonlinux2")
+
+	assertEntries := func(expectedRoles []RolesAuthMap, expectedUsers []UsersAuthMap) {
+		t.Helper()
+		_, roles, err := ReadAuthRoles(kube)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(roles, expectedRoles) {
+			t.Errorf("expected roles %+v, got %+v", expectedRoles, roles)
+		}
+		cm, users, err := ReadAuthUsers(kube)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(users, expectedUsers) {
+			t.Errorf("expected users %+v, got %+v", expectedUsers, users)
+		}
+		if cm.Data["mapAccounts"] != existing.Data["mapAccounts"] {
+			t.Errorf("expected mapAccounts to be preserved, got %q", cm.Data["mapAccounts"])
+		}
+	}
+
+	// additional mappings are added next to the existing entries
+	owned, skipped, err := SyncAuthMappings(kube, []RolesAuthMap{debugRole}, []UsersAuthMap{debugUser}, OwnedAuthMappings{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(owned, OwnedAuthMappings{Roles: []string{debugRoleARN}, Users: []string{debugUserARN}}) || len(skipped) != 0 {
+		t.Errorf("expected debug role and user to be owned, got %+v, skipped %v", owned, skipped)
+	}
+	assertEntries([]RolesAuthMap{other, nodeRole, debugRole}, []UsersAuthMap{admin, debugUser})
+
+	// entries which exactly match their desired mapping are adopted if their ownership was not recorded
+	owned, skipped, err = SyncAuthMappings(kube, []RolesAuthMap{debugRole}, []UsersAuthMap{debugUser}, OwnedAuthMappings{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(owned, OwnedAuthMappings{Roles: []string{debugRoleARN}, Users: []string{debugUserARN}}) || len(skipped) != 0 {
+		t.Errorf("expected matching debug role and user to be adopted, got %+v, skipped %v", owned, skipped)
+	}
+	assertEntries([]RolesAuthMap{other, nodeRole, debugRole}, []UsersAuthMap{admin, debugUser})
+
+	// owned mappings are updated, while mappings of entries which are not owned are skipped
+	debugRole.Groups = []string{"viewers"}
+	conflicting := RolesAuthMap{RoleARN: testNodeRoleARN, Username: "debug"}
+	owned, skipped, err = SyncAuthMappings(kube, []RolesAuthMap{debugRole, conflicting}, []UsersAuthMap{debugUser, {UserARN: adminUserARN, Username: "debug"}}, owned)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(owned, OwnedAuthMappings{Roles: []string{debugRoleARN}, Users: []string{debugUserARN}}) {
+		t.Errorf("expected debug role and user to be owned, got %+v", owned)
+	}
+	if !reflect.DeepEqual(skipped, []string{testNodeRoleARN, adminUserARN}) {
+		t.Errorf("expected entries which are not owned to be skipped, got %v", skipped)
+	}
+	assertEntries([]RolesAuthMap{other, nodeRole, debugRole}, []UsersAuthMap{admin, debugUser})
+
+	// owned mappings which are no longer desired are removed
+	owned, _, err = SyncAuthMappings(kube, nil, []UsersAuthMap{debugUser}, owned)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEntries([]RolesAuthMap{other, nodeRole}, []UsersAuthMap{admin, debugUser})
+
+	// entries which differ from the mappings are not adopted
+	adopted, err := AdoptAuthMappings(kube, []RolesAuthMap{{RoleARN: testOtherRoleARN, Username: "admin"}}, []UsersAuthMap{debugUser}, OwnedAuthMappings{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(adopted, OwnedAuthMappings{Roles: []string{}, Users: []string{debugUserARN}}) {
+		t.Errorf("expected only the matching debug user to be adopted, got %+v", adopted)
+	}
+
+	owned, _, err = SyncAuthMappings(kube, nil, nil, owned)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(owned.Roles) != 0 || len(owned.Users) != 0 {
+		t.Errorf("expected no owned mappings, got %+v", owned)
+	}
+	assertEntries([]RolesAuthMap{other, nodeRole}, []UsersAuthMap{admin})
+
+	// removing from a missing configmap does not create it
+	kube = fake.NewSimpleClientset()
+	if _, _, err := SyncAuthMappings(kube, nil, nil, OwnedAuthMappings{Roles: []string{debugRoleARN}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := kube.CoreV1().ConfigMaps(AuthConfigMapNamespace).Get(context.Background(), AuthConfigMapName, metav1.GetOptions{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected aws-auth configmap not to be created, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Formula is a parsed integer arithmetic expression over a set of named variables, supporting +, -, *, /, parentheses
+// and the min and max functions
+type Formula struct {
+	expr ast.Expr
+}
+
+// ParseFormula parses the expression, returning an error if it uses anything other than integer literals, the allowed
+// variables, arithmetic operators, min or max
+func ParseFormula(expression string, variables []string) (*Formula, error) {
+	expr, err := parser.ParseExpr(expression)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse formula '%v'", expression)
+	}
+	if err := validateFormulaExpr(expr, variables); err != nil {
+		return nil, errors.Wrapf(err, "invalid formula '%v'", expression)
+	}
+	return &Formula{expr: expr}, nil
+}
+
+// Evaluate evaluates the formula with the given variable values
+func (f *Formula) Evaluate(values map[string]int64) (int64, error) {
+	return evaluateFormulaExpr(f.expr, values)
+}
+
+func validateFormulaExpr(expr ast.Expr, variables []string) error {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.INT {
+			return errors.Errorf("unsupported literal %v, only integers are allowed", e.Value)
+		}
+		if _, err := strconv.ParseInt(e.Value, 10, 64); err != nil {
+			return errors.Errorf("unsupported literal %v, only decimal integers are allowed", e.Value)
+		}
+	case *ast.Ident:
+		if !ContainsString(variables, e.Name) {
+			return errors.Errorf("unknown variable %v, allowed variables are %v", e.Name, variables)
+		}
+	case *ast.ParenExpr:
+		return validateFormulaExpr(e.X, variables)
+	case *ast.UnaryExpr:
+		if e.Op != token.SUB && e.Op != token.ADD {
+			return errors.Errorf("unsupported operator %v", e.Op)
+		}
+		return validateFormulaExpr(e.X, variables)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO:
+		default:
+			return errors.Errorf("unsupported operator %v", e.Op)
+		}
+		if err := validateFormulaExpr(e.X, variables); err != nil {
+			return err
+		}
+		return validateFormulaExpr(e.Y, variables)
+	case *ast.CallExpr:
+		fn, ok := e.Fun.(*ast.Ident)
+		if !ok || (fn.Name != "min" && fn.Name != "max") {
+			return errors.New("unsupported function, only min and max are allowed")
+		}
+		if len(e.Args) < 2 || e.Ellipsis.IsValid() {
+			return errors.Errorf("%v requires at least two arguments", fn.Name)
+		}
+		for _, arg := range e.Args {
+			if err := validateFormulaExpr(arg, variables); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("unsupported expression %T", expr)
+	}
+	return nil
+}
+
+func evaluateFormulaExpr(expr ast.Expr, values map[string]int64) (int64, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return strconv.ParseInt(e.Value, 10, 64)
+	case *ast.Ident:
+		v, ok := values[e.Name]
+		if !ok {
+			return 0, errors.Errorf("no value for variable %v", e.Name)
+		}
+		return v, nil
+	case *ast.ParenExpr:
+		return evaluateFormulaExpr(e.X, values)
+	case *ast.UnaryExpr:
+		x, err := evaluateFormulaExpr(e.X, values)
+		if err != nil {
+			return 0, err
+		}
+		if e.Op == token.SUB {
+			return -x, nil
+		}
+		return x, nil
+	case *ast.BinaryExpr:
+		x, err := evaluateFormulaExpr(e.X, values)
+		if err != nil {
+			return 0, err
+		}
+		y, err := evaluateFormulaExpr(e.Y, values)
+		if err != nil {
+			return 0, err
+		}
+		switch e.Op {
+		case token.ADD:
+			return x + y, nil
+		case token.SUB:
+			return x - y, nil
+		case token.MUL:
+			return x * y, nil
+		case token.QUO:
+			if y == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return x / y, nil
+		}
+	case *ast.CallExpr:
+		var result int64
+		for i, arg := range e.Args {
+			v, err := evaluateFormulaExpr(arg, values)
+			if err != nil {
+				return 0, err
+			}
+			if i == 0 || (e.Fun.(*ast.Ident).Name == "min" && v < result) || (e.Fun.(*ast.Ident).Name == "max" && v > result) {
+				result = v
+			}
+		}
+		return result, nil
+	}
+	return 0, errors.Errorf("unsupported expression %T", expr)
+}
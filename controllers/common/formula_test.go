@@ -0,0 +1,80 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+)
+
+func TestFormula(t *testing.T) {
+	variables := []string{"ENIs", "IPsPerENI"}
+	values := map[string]int64{"ENIs": 3, "IPsPerENI": 10}
+
+	tests := []struct {
+		name     string
+		formula  string
+		expected int64
+		parseErr bool
+		evalErr  bool
+	}{
+		{name: "literal", formula: "58", expected: 58},
+		{name: "variables", formula: "ENIs * (IPsPerENI - 1) + 2", expected: 29},
+		{name: "precedence", formula: "2 + ENIs * IPsPerENI", expected: 32},
+		{name: "unary minus", formula: "-ENIs + 10", expected: 7},
+		{name: "integer division", formula: "IPsPerENI / ENIs", expected: 3},
+		{name: "min", formula: "min(ENIs * IPsPerENI * 16, 110)", expected: 110},
+		{name: "max", formula: "max(ENIs, IPsPerENI, 4)", expected: 10},
+		{name: "division by zero", formula: "IPsPerENI / (ENIs - 3)", evalErr: true},
+		{name: "unknown variable", formula: "ENIs * Prefixes", parseErr: true},
+		{name: "unknown function", formula: "pow(ENIs, 2)", parseErr: true},
+		{name: "single argument min", formula: "min(ENIs)", parseErr: true},
+		{name: "float literal", formula: "ENIs * 1.5", parseErr: true},
+		{name: "string literal", formula: `"ENIs"`, parseErr: true},
+		{name: "hex literal", formula: "0x10", parseErr: true},
+		{name: "bitwise operator", formula: "ENIs << 2", parseErr: true},
+		{name: "selector", formula: "os.Exit(1)", parseErr: true},
+		{name: "syntax error", formula: "ENIs *", parseErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			formula, err := ParseFormula(tc.formula, variables)
+			if tc.parseErr {
+				if err == nil {
+					t.Fatalf("expected formula '%v' to fail parsing", tc.formula)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error parsing formula '%v': %v", tc.formula, err)
+			}
+
+			result, err := formula.Evaluate(values)
+			if tc.evalErr {
+				if err == nil {
+					t.Fatalf("expected formula '%v' to fail evaluation", tc.formula)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error evaluating formula '%v': %v", tc.formula, err)
+			}
+			if result != tc.expected {
+				t.Errorf("formula '%v' = %v, expected %v", tc.formula, result, tc.expected)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package common
 
 import (
+	"sync"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -8,6 +11,17 @@ const (
 	namespace = "instance_manager"
 )
 
+// MetricsSink receives the events recorded by a MetricsCollector, it can be registered to export metrics to systems
+// other than prometheus
+type MetricsSink interface {
+	SetInstanceGroupState(instanceGroup, state string)
+	ObserveStateDuration(instanceGroup, state string, duration time.Duration)
+	ObserveReadinessDuration(instanceGroup string, duration time.Duration)
+	IncSuccess(instanceGroup string)
+	IncFail(instanceGroup, reason string)
+	IncThrottle(serviceName, operationName string)
+}
+
 type MetricsCollector struct {
 	prometheus.Collector
 
@@ -15,6 +29,18 @@ type MetricsCollector struct {
 	failureCounter  *prometheus.CounterVec
 	throttleCounter *prometheus.CounterVec
 	statusGauge     *prometheus.GaugeVec
+	reconcileGauge  *prometheus.GaugeVec
+	stateDuration   *prometheus.HistogramVec
+	readiness       *prometheus.HistogramVec
+	reconcileAge    *prometheus.Desc
+
+	// lastReconcile tracks the last successful reconcile per instance group
+	lastReconcile map[string]time.Time
+	lock          *sync.RWMutex
+	now           func() time.Time
+
+	sinks     []MetricsSink
+	sinksLock *sync.RWMutex
 }
 
 func NewMetricsCollector() *MetricsCollector {
@@ -51,6 +77,57 @@ func NewMetricsCollector() *MetricsCollector {
 			},
 			[]string{"instancegroup", "status"},
 		),
+		reconcileGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "instance_group_last_reconcile_timestamp_seconds",
+				Help:      "unix timestamp of the last successful reconcile of an instance group",
+			},
+			[]string{"instancegroup"},
+		),
+		stateDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "instance_group_state_duration_seconds",
+				Help:      "seconds spent by an instance group in a state during a reconcile",
+				Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300},
+			},
+			[]string{"instancegroup", "state"},
+		),
+		readiness: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "instance_group_readiness_duration_seconds",
+				Help:      "seconds taken by an instance group to become fully ready after it was created or scaled up",
+				Buckets:   []float64{60, 120, 180, 300, 600, 900, 1200, 1800, 3600},
+			},
+			[]string{"instancegroup"},
+		),
+		reconcileAge: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "instance_group_reconcile_age_seconds"),
+			"seconds since the last successful reconcile of an instance group",
+			[]string{"instancegroup"},
+			nil,
+		),
+		lastReconcile: make(map[string]time.Time),
+		lock:          &sync.RWMutex{},
+		now:           time.Now,
+		sinksLock:     &sync.RWMutex{},
+	}
+}
+
+// RegisterSink registers a sink which receives the same events as the collector
+func (c *MetricsCollector) RegisterSink(sink MetricsSink) {
+	c.sinksLock.Lock()
+	defer c.sinksLock.Unlock()
+	c.sinks = append(c.sinks, sink)
+}
+
+func (c *MetricsCollector) emit(f func(sink MetricsSink)) {
+	c.sinksLock.RLock()
+	defer c.sinksLock.RUnlock()
+	for _, sink := range c.sinks {
+		f(sink)
 	}
 }
 
@@ -59,6 +136,15 @@ func (c MetricsCollector) Collect(ch chan<- prometheus.Metric) {
 	c.failureCounter.Collect(ch)
 	c.throttleCounter.Collect(ch)
 	c.statusGauge.Collect(ch)
+	c.reconcileGauge.Collect(ch)
+	c.stateDuration.Collect(ch)
+	c.readiness.Collect(ch)
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for instanceGroup, t := range c.lastReconcile {
+		ch <- prometheus.MustNewConstMetric(c.reconcileAge, prometheus.GaugeValue, c.now().Sub(t).Seconds(), instanceGroup)
+	}
 }
 
 func (c MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -66,6 +152,10 @@ func (c MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
 	c.failureCounter.Describe(ch)
 	c.throttleCounter.Describe(ch)
 	c.statusGauge.Describe(ch)
+	c.reconcileGauge.Describe(ch)
+	c.stateDuration.Describe(ch)
+	c.readiness.Describe(ch)
+	ch <- c.reconcileAge
 }
 
 func (c *MetricsCollector) SetInstanceGroup(instanceGroup, state string) {
@@ -77,6 +167,27 @@ func (c *MetricsCollector) SetInstanceGroup(instanceGroup, state string) {
 		c.statusGauge.With(prometheus.Labels{"instancegroup": instanceGroup, "status": s}).Set(0)
 	}
 	c.statusGauge.With(prometheus.Labels{"instancegroup": instanceGroup, "status": state}).Set(1)
+	c.emit(func(sink MetricsSink) { sink.SetInstanceGroupState(instanceGroup, state) })
+}
+
+// ObserveStateDuration records the time an instance group spent in a state before transitioning to another state
+func (c *MetricsCollector) ObserveStateDuration(instanceGroup, state string, duration time.Duration) {
+	c.stateDuration.With(prometheus.Labels{"instancegroup": instanceGroup, "state": state}).Observe(duration.Seconds())
+	c.emit(func(sink MetricsSink) { sink.ObserveStateDuration(instanceGroup, state, duration) })
+}
+
+// ObserveReadinessDuration records the time an instance group took to become fully ready after it was created or scaled up
+func (c *MetricsCollector) ObserveReadinessDuration(instanceGroup string, duration time.Duration) {
+	c.readiness.With(prometheus.Labels{"instancegroup": instanceGroup}).Observe(duration.Seconds())
+	c.emit(func(sink MetricsSink) { sink.ObserveReadinessDuration(instanceGroup, duration) })
+}
+
+func (c *MetricsCollector) setLastReconcile(instanceGroup string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	c.lastReconcile[instanceGroup] = now
+	c.reconcileGauge.With(prometheus.Labels{"instancegroup": instanceGroup}).Set(float64(now.Unix()))
 }
 
 func (c *MetricsCollector) UnsetInstanceGroup() {
@@ -84,16 +195,28 @@ func (c *MetricsCollector) UnsetInstanceGroup() {
 	c.failureCounter.Reset()
 	c.throttleCounter.Reset()
 	c.statusGauge.Reset()
+	c.reconcileGauge.Reset()
+	c.stateDuration.Reset()
+	c.readiness.Reset()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lastReconcile = make(map[string]time.Time)
 }
 
+// IncSuccess counts a successful reconcile of an instance group, and records it as its last successful reconcile
 func (c *MetricsCollector) IncSuccess(instanceGroup string) {
 	c.successCounter.With(prometheus.Labels{"instancegroup": instanceGroup}).Inc()
+	c.setLastReconcile(instanceGroup)
+	c.emit(func(sink MetricsSink) { sink.IncSuccess(instanceGroup) })
 }
 
 func (c *MetricsCollector) IncFail(instanceGroup, reason string) {
 	c.failureCounter.With(prometheus.Labels{"instancegroup": instanceGroup, "reason": reason}).Inc()
+	c.emit(func(sink MetricsSink) { sink.IncFail(instanceGroup, reason) })
 }
 
 func (c *MetricsCollector) IncThrottle(serviceName, operationName string) {
 	c.throttleCounter.With(prometheus.Labels{"service": serviceName, "operation": operationName}).Inc()
+	c.emit(func(sink MetricsSink) { sink.IncThrottle(serviceName, operationName) })
 }
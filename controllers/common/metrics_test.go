@@ -0,0 +1,142 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestReconcileFreshness(t *testing.T) {
+	var (
+		c           = NewMetricsCollector()
+		current     = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		name        = "instance-manager/test-ig"
+		collectSize = func() int {
+			ch := make(chan prometheus.Metric, 100)
+			c.Collect(ch)
+			close(ch)
+			return len(ch)
+		}
+		lastReconcile = func() (time.Time, bool) {
+			c.lock.RLock()
+			defer c.lock.RUnlock()
+			t, ok := c.lastReconcile[name]
+			return t, ok
+		}
+	)
+	c.now = func() time.Time { return current }
+
+	// states, including the ready state of a reconcile which failed afterwards, are not successful reconciles
+	withoutAge := collectSize()
+	c.SetInstanceGroup(name, "Ready")
+	if _, ok := lastReconcile(); ok {
+		t.Errorf("expected state change not to record a successful reconcile")
+	}
+
+	c.IncSuccess(name)
+	if last, ok := lastReconcile(); !ok || !last.Equal(current) {
+		t.Errorf("got last reconcile %v, ok %v, expected %v, true", last, ok, current)
+	}
+	if collectSize() <= withoutAge {
+		t.Errorf("expected reconcile age metric to be collected")
+	}
+
+	// failures do not count as a successful reconcile
+	reconciled := current
+	current = current.Add(10 * time.Minute)
+	c.IncFail(name, "ReconcileFailed")
+	if last, _ := lastReconcile(); !last.Equal(reconciled) {
+		t.Errorf("got last reconcile %v, expected %v", last, reconciled)
+	}
+
+	c.IncSuccess(name)
+	if last, _ := lastReconcile(); !last.Equal(current) {
+		t.Errorf("got last reconcile %v, expected %v", last, current)
+	}
+
+	withAge := collectSize()
+	c.UnsetInstanceGroup()
+	if _, ok := lastReconcile(); ok {
+		t.Errorf("expected reconcile age to be unset")
+	}
+	if collectSize() >= withAge {
+		t.Errorf("expected reconcile age metric to be removed")
+	}
+}
+
+type fakeMetricsSink struct {
+	events []string
+}
+
+func (s *fakeMetricsSink) SetInstanceGroupState(instanceGroup, state string) {
+	s.events = append(s.events, fmt.Sprintf("state %v %v", instanceGroup, state))
+}
+
+func (s *fakeMetricsSink) ObserveStateDuration(instanceGroup, state string, duration time.Duration) {
+	s.events = append(s.events, fmt.Sprintf("duration %v %v %v", instanceGroup, state, duration))
+}
+
+func (s *fakeMetricsSink) ObserveReadinessDuration(instanceGroup string, duration time.Duration) {
+	s.events = append(s.events, fmt.Sprintf("readiness %v %v", instanceGroup, duration))
+}
+
+func (s *fakeMetricsSink) IncSuccess(instanceGroup string) {
+	s.events = append(s.events, fmt.Sprintf("success %v", instanceGroup))
+}
+
+func (s *fakeMetricsSink) IncFail(instanceGroup, reason string) {
+	s.events = append(s.events, fmt.Sprintf("fail %v %v", instanceGroup, reason))
+}
+
+func (s *fakeMetricsSink) IncThrottle(serviceName, operationName string) {
+	s.events = append(s.events, fmt.Sprintf("throttle %v %v", serviceName, operationName))
+}
+
+func TestMetricsSink(t *testing.T) {
+	var (
+		c    = NewMetricsCollector()
+		sink = &fakeMetricsSink{}
+		name = "instance-manager/test-ig"
+	)
+
+	// events recorded before a sink is registered are not emitted
+	c.IncSuccess(name)
+	c.RegisterSink(sink)
+
+	c.SetInstanceGroup(name, "InitUpdate")
+	c.SetInstanceGroup(name, "Ready")
+	c.ObserveStateDuration(name, "InitUpdate", 2*time.Second)
+	c.ObserveReadinessDuration(name, 5*time.Minute)
+	c.IncSuccess(name)
+	c.IncFail(name, "ReconcileFailed")
+	c.IncThrottle("autoscaling", "DescribeAutoScalingGroups")
+
+	expected := []string{
+		"state instance-manager/test-ig Ready",
+		"duration instance-manager/test-ig InitUpdate 2s",
+		"readiness instance-manager/test-ig 5m0s",
+		"success instance-manager/test-ig",
+		"fail instance-manager/test-ig ReconcileFailed",
+		"throttle autoscaling DescribeAutoScalingGroups",
+	}
+	if !reflect.DeepEqual(sink.events, expected) {
+		t.Errorf("got events %v, expected %v", sink.events, expected)
+	}
+}
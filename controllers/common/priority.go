@@ -0,0 +1,205 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	PriorityExpanderConfigMapName      = "cluster-autoscaler-priority-expander"
+	PriorityExpanderConfigMapNamespace = "kube-system"
+	PriorityExpanderPrioritiesKey      = "priorities"
+)
+
+// ScalingGroupPriorityPattern returns the priority expander pattern which matches only the named scaling group
+func ScalingGroupPriorityPattern(name string) string {
+	return "^" + regexp.QuoteMeta(name) + "$"
+}
+
+// ReadPriorities returns the scaling group patterns of each priority in the cluster-autoscaler priority expander
+// configmap, the configmap is nil if it does not exist
+func ReadPriorities(kube kubernetes.Interface) (*corev1.ConfigMap, map[int64][]string, error) {
+	cm, err := kube.CoreV1().ConfigMaps(PriorityExpanderConfigMapNamespace).Get(context.Background(), PriorityExpanderConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.Wrap(err, "failed to get priority expander configmap")
+	}
+
+	raw := make(map[string][]string)
+	if err := yaml.Unmarshal([]byte(cm.Data[PriorityExpanderPrioritiesKey]), &raw); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse priority expander priorities")
+	}
+
+	priorities := make(map[int64][]string, len(raw))
+	for key, patterns := range raw {
+		priority, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to parse priority expander priority %v", key)
+		}
+		priorities[priority] = patterns
+	}
+	return cm, priorities, nil
+}
+
+// MarshalPriorities returns the priorities in the format of the priority expander configmap, with the highest priority
+// first. Priorities are written as plain integer keys since cluster-autoscaler does not parse quoted keys.
+func MarshalPriorities(priorities map[int64][]string) string {
+	keys := make([]int64, 0, len(priorities))
+	for priority := range priorities {
+		keys = append(keys, priority)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] > keys[j] })
+
+	var b strings.Builder
+	for _, priority := range keys {
+		fmt.Fprintf(&b, "%d:\n", priority)
+		for _, pattern := range priorities[priority] {
+			quoted, _ := json.Marshal(pattern)
+			fmt.Fprintf(&b, "  - %s\n", quoted)
+		}
+	}
+	return b.String()
+}
+
+// updatePriorities applies a mutation to the priorities of the priority expander configmap, other keys of the
+// configmap are left untouched. The configmap is only written if the mutation changed the priorities, and the
+// read-modify-write is retried if the configmap was modified concurrently.
+func updatePriorities(kube kubernetes.Interface, mutate func(map[int64][]string) map[int64][]string) error {
+	isConcurrentEdit := func(err error) bool {
+		return kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err)
+	}
+
+	return retry.OnError(retry.DefaultBackoff, isConcurrentEdit, func() error {
+		cm, priorities, err := ReadPriorities(kube)
+		if err != nil {
+			return err
+		}
+
+		existing := make(map[int64][]string, len(priorities))
+		for priority, patterns := range priorities {
+			existing[priority] = append([]string{}, patterns...)
+		}
+		updated := mutate(existing)
+		if reflect.DeepEqual(priorities, updated) || (len(priorities) == 0 && len(updated) == 0) {
+			return nil
+		}
+
+		if cm == nil {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      PriorityExpanderConfigMapName,
+					Namespace: PriorityExpanderConfigMapNamespace,
+				},
+				Data: map[string]string{PriorityExpanderPrioritiesKey: MarshalPriorities(updated)},
+			}
+			_, err = kube.CoreV1().ConfigMaps(PriorityExpanderConfigMapNamespace).Create(context.Background(), cm, metav1.CreateOptions{})
+			return err
+		}
+
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[PriorityExpanderPrioritiesKey] = MarshalPriorities(updated)
+		_, err = kube.CoreV1().ConfigMaps(PriorityExpanderConfigMapNamespace).Update(context.Background(), cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// removePriorityPatterns drops the patterns from every priority, and the priorities left without patterns
+func removePriorityPatterns(priorities map[int64][]string, remove map[string]bool) map[int64][]string {
+	for priority, patterns := range priorities {
+		kept := make([]string, 0, len(patterns))
+		for _, pattern := range patterns {
+			if remove[pattern] {
+				continue
+			}
+			kept = append(kept, pattern)
+		}
+		if len(kept) == 0 {
+			delete(priorities, priority)
+			continue
+		}
+		priorities[priority] = kept
+	}
+	return priorities
+}
+
+func priorityPatterns(names []string) ([]string, map[string]bool) {
+	patterns := make([]string, 0)
+	set := make(map[string]bool)
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		pattern := ScalingGroupPriorityPattern(name)
+		if set[pattern] {
+			continue
+		}
+		patterns = append(patterns, pattern)
+		set[pattern] = true
+	}
+	sort.Strings(patterns)
+	return patterns, set
+}
+
+// UpsertPriorityExpander lists the scaling groups under the priority in the priority expander configmap, moving them
+// from any other priority they were listed under
+func UpsertPriorityExpander(kube kubernetes.Interface, names []string, priority int64) error {
+	patterns, set := priorityPatterns(names)
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	err := updatePriorities(kube, func(priorities map[int64][]string) map[int64][]string {
+		priorities = removePriorityPatterns(priorities, set)
+		priorities[priority] = append(priorities[priority], patterns...)
+		// sorted, so that the reconciles of groups sharing a priority do not reorder each other's patterns
+		sort.Strings(priorities[priority])
+		return priorities
+	})
+	return errors.Wrap(err, "failed to upsert scaling groups in priority expander")
+}
+
+// RemovePriorityExpander removes the scaling groups from the priority expander configmap
+func RemovePriorityExpander(kube kubernetes.Interface, names []string) error {
+	_, set := priorityPatterns(names)
+	if len(set) == 0 {
+		return nil
+	}
+
+	err := updatePriorities(kube, func(priorities map[int64][]string) map[int64][]string {
+		return removePriorityPatterns(priorities, set)
+	})
+	return errors.Wrap(err, "failed to remove scaling groups from priority expander")
+}
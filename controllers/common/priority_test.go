@@ -0,0 +1,153 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func mockPriorityExpanderConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PriorityExpanderConfigMapName,
+			Namespace: PriorityExpanderConfigMapNamespace,
+		},
+		Data: map[string]string{
+			PriorityExpanderPrioritiesKey: "10:\n  - .*-spot-.*\n1:\n  - .*\n",
+			"owner":                       "platform-team",
+		},
+	}
+}
+
+func TestUpsertPriorityExpander(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *corev1.ConfigMap
+		priority int64
+		expected map[int64][]string
+	}{
+		{
+			name:     "configmap is created",
+			priority: 50,
+			expected: map[int64][]string{50: {"^my-scaling-group$", "^my-scaling-group-us-west-2a$"}},
+		},
+		{
+			name:     "groups are added to an existing priority",
+			existing: mockPriorityExpanderConfigMap(),
+			priority: 10,
+			expected: map[int64][]string{10: {".*-spot-.*", "^my-scaling-group$", "^my-scaling-group-us-west-2a$"}, 1: {".*"}},
+		},
+		{
+			name: "groups are moved to the new priority",
+			existing: func() *corev1.ConfigMap {
+				cm := mockPriorityExpanderConfigMap()
+				cm.Data[PriorityExpanderPrioritiesKey] += "20:\n  - ^my-scaling-group$\n  - ^my-scaling-group-us-west-2a$\n"
+				return cm
+			}(),
+			priority: 0,
+			expected: map[int64][]string{10: {".*-spot-.*"}, 1: {".*"}, 0: {"^my-scaling-group$", "^my-scaling-group-us-west-2a$"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset()
+			if tc.existing != nil {
+				kube = fake.NewSimpleClientset(tc.existing)
+			}
+
+			if err := UpsertPriorityExpander(kube, []string{"my-scaling-group-us-west-2a", "my-scaling-group", ""}, tc.priority); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cm, priorities, err := ReadPriorities(kube)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(priorities, tc.expected) {
+				t.Errorf("expected priorities %+v, got %+v", tc.expected, priorities)
+			}
+			if tc.existing != nil && cm.Data["owner"] != tc.existing.Data["owner"] {
+				t.Errorf("expected owner to be preserved, got %q", cm.Data["owner"])
+			}
+		})
+	}
+}
+
+func TestUpsertPriorityExpanderUnchanged(t *testing.T) {
+	kube := fake.NewSimpleClientset(mockPriorityExpanderConfigMap())
+	if err := UpsertPriorityExpander(kube, []string{"my-scaling-group"}, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := UpsertPriorityExpander(kube, []string{"other-scaling-group"}, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var updates int
+	kube.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		updates++
+		return false, nil, nil
+	})
+	for _, name := range []string{"my-scaling-group", "other-scaling-group"} {
+		if err := UpsertPriorityExpander(kube, []string{name}, 10); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if updates != 0 {
+		t.Errorf("expected existing priorities not to be rewritten, got %v updates", updates)
+	}
+}
+
+func TestRemovePriorityExpander(t *testing.T) {
+	existing := mockPriorityExpanderConfigMap()
+	kube := fake.NewSimpleClientset(existing)
+	if err := UpsertPriorityExpander(kube, []string{"my-scaling-group"}, 50); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := RemovePriorityExpander(kube, []string{"my-scaling-group", ""}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm, _, err := ReadPriorities(kube)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "10:\n  - \".*-spot-.*\"\n1:\n  - \".*\"\n"
+	if cm.Data[PriorityExpanderPrioritiesKey] != expected {
+		t.Errorf("expected priorities %q, got %q", expected, cm.Data[PriorityExpanderPrioritiesKey])
+	}
+	if cm.Data["owner"] != existing.Data["owner"] {
+		t.Errorf("expected owner to be preserved, got %q", cm.Data["owner"])
+	}
+
+	// removing from a missing configmap does not create it
+	kube = fake.NewSimpleClientset()
+	if err := RemovePriorityExpander(kube, []string{"my-scaling-group"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := kube.CoreV1().ConfigMaps(PriorityExpanderConfigMapNamespace).Get(context.Background(), PriorityExpanderConfigMapName, metav1.GetOptions{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected priority expander configmap not to be created, got %v", err)
+	}
+}
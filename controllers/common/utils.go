@@ -17,8 +17,10 @@ package common
 
 import (
 	"crypto/md5"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"os"
 	"reflect"
@@ -322,3 +324,33 @@ func IntOrStrValue(x *intstr.IntOrString) int {
 func Int64ToStr(x int64) string {
 	return strconv.FormatInt(x, 10)
 }
+
+// ParseCertificates parses a bundle of PEM encoded certificates, any other content of the bundle is rejected
+func ParseCertificates(bundle string) ([]*x509.Certificate, error) {
+	var (
+		certificates = make([]*x509.Certificate, 0)
+		rest         = []byte(bundle)
+	)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, errors.Errorf("unexpected %v block in certificate bundle", block.Type)
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse certificate")
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(strings.TrimSpace(string(rest))) > 0 {
+		return nil, errors.New("certificate bundle has content which is not PEM encoded")
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("certificate bundle has no certificates")
+	}
+	return certificates, nil
+}
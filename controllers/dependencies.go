@@ -0,0 +1,116 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	v1alpha1 "github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// DependencyRequeueInterval is the interval after which an instance group waiting for its dependencies is requeued
+	DependencyRequeueInterval = 30 * time.Second
+)
+
+// DependencyCycleError is returned when the dependencies of an instance group depend on the instance group
+type DependencyCycleError struct {
+	Cycle []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return "instance group dependency cycle " + strings.Join(e.Cycle, " -> ")
+}
+
+// GetPendingDependencies returns the dependencies of the instance group which are not Ready, or which do not exist, and
+// fails when the instance group is part of a dependency cycle. Dependencies only defer the creation of the instance
+// group, once its scaling group exists it is reconciled regardless of the state of its dependencies
+func (r *InstanceGroupReconciler) GetPendingDependencies(instanceGroup *v1alpha1.InstanceGroup) ([]string, error) {
+	var (
+		pending = make([]string, 0)
+		graph   = map[string][]string{instanceGroup.NamespacedName(): instanceGroup.GetDependencies()}
+	)
+
+	if instanceGroup.GetStatus().GetActiveScalingGroupName() != "" {
+		return pending, nil
+	}
+
+	for _, dependency := range instanceGroup.GetDependencies() {
+		dependencyGroup, err := r.getDependency(dependency)
+		if err != nil {
+			return nil, err
+		}
+		if dependencyGroup == nil || dependencyGroup.GetState() != v1alpha1.ReconcileReady {
+			pending = append(pending, dependency)
+		}
+	}
+
+	if cycle, err := r.findDependencyCycle(instanceGroup.NamespacedName(), graph, []string{}); err != nil {
+		return nil, err
+	} else if cycle != nil {
+		return nil, &DependencyCycleError{Cycle: cycle}
+	}
+	return pending, nil
+}
+
+// findDependencyCycle walks the dependencies of an instance group depth first and returns the first path which leads
+// back to an instance group on it, dependencies are looked up once and added to the graph
+func (r *InstanceGroupReconciler) findDependencyCycle(name string, graph map[string][]string, path []string) ([]string, error) {
+	for i, visited := range path {
+		if visited == name {
+			return append(append([]string{}, path[i:]...), name), nil
+		}
+	}
+
+	dependencies, ok := graph[name]
+	if !ok {
+		dependencyGroup, err := r.getDependency(name)
+		if err != nil {
+			return nil, err
+		}
+		if dependencyGroup != nil {
+			dependencies = dependencyGroup.GetDependencies()
+		}
+		graph[name] = dependencies
+	}
+
+	path = append(path, name)
+	for _, dependency := range dependencies {
+		cycle, err := r.findDependencyCycle(dependency, graph, path)
+		if err != nil || cycle != nil {
+			return cycle, err
+		}
+	}
+	return nil, nil
+}
+
+// getDependency returns the instance group with the namespace/name, or nil if it does not exist
+func (r *InstanceGroupReconciler) getDependency(name string) (*v1alpha1.InstanceGroup, error) {
+	parts := strings.SplitN(name, "/", 2)
+	dependency := &v1alpha1.InstanceGroup{}
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, dependency); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get dependency %v", name)
+	}
+	return dependency, nil
+}
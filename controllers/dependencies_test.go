@@ -0,0 +1,154 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1alpha1 "github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func mockDependentInstanceGroup(name string, state v1alpha1.ReconcileState, dependsOn ...string) *v1alpha1.InstanceGroup {
+	return &v1alpha1.InstanceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1alpha1.InstanceGroupSpec{DependsOn: dependsOn},
+		Status:     v1alpha1.InstanceGroupStatus{CurrentState: string(state)},
+	}
+}
+
+func TestGetPendingDependencies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		name          string
+		instanceGroup *v1alpha1.InstanceGroup
+		objects       []runtime.Object
+		expected      []string
+	}{
+		{
+			name:          "no dependencies",
+			instanceGroup: mockDependentInstanceGroup("workers", v1alpha1.ReconcileInit),
+			expected:      []string{},
+		},
+		{
+			name:          "dependency is ready",
+			instanceGroup: mockDependentInstanceGroup("workers", v1alpha1.ReconcileInit, "system"),
+			objects:       []runtime.Object{mockDependentInstanceGroup("system", v1alpha1.ReconcileReady)},
+			expected:      []string{},
+		},
+		{
+			name:          "dependency is not ready",
+			instanceGroup: mockDependentInstanceGroup("workers", v1alpha1.ReconcileInit, "system"),
+			objects:       []runtime.Object{mockDependentInstanceGroup("system", v1alpha1.ReconcileModifying)},
+			expected:      []string{"default/system"},
+		},
+		{
+			name:          "dependency does not exist",
+			instanceGroup: mockDependentInstanceGroup("workers", v1alpha1.ReconcileInit, "other/system"),
+			expected:      []string{"other/system"},
+		},
+		{
+			name:          "only direct dependencies must be ready",
+			instanceGroup: mockDependentInstanceGroup("workers", v1alpha1.ReconcileInit, "system", "monitoring"),
+			objects: []runtime.Object{
+				mockDependentInstanceGroup("system", v1alpha1.ReconcileReady, "base"),
+				mockDependentInstanceGroup("monitoring", v1alpha1.ReconcileInitUpgrade, "base"),
+				mockDependentInstanceGroup("base", v1alpha1.ReconcileModifying),
+			},
+			expected: []string{"default/monitoring"},
+		},
+		{
+			name: "dependencies do not defer instance groups which were created",
+			instanceGroup: func() *v1alpha1.InstanceGroup {
+				instanceGroup := mockDependentInstanceGroup("workers", v1alpha1.ReconcileInit, "system")
+				instanceGroup.GetStatus().SetActiveScalingGroupName("my-cluster-default-workers")
+				return instanceGroup
+			}(),
+			objects:  []runtime.Object{mockDependentInstanceGroup("system", v1alpha1.ReconcileModifying)},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test %v", tc.name)
+		reconciler := createTestReconciler(append(tc.objects, tc.instanceGroup)...)
+		pending, err := reconciler.GetPendingDependencies(tc.instanceGroup)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(pending).To(gomega.Equal(tc.expected))
+	}
+}
+
+func TestGetPendingDependenciesCycle(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		name          string
+		instanceGroup *v1alpha1.InstanceGroup
+		objects       []runtime.Object
+		expected      []string
+	}{
+		{
+			name:          "direct cycle",
+			instanceGroup: mockDependentInstanceGroup("a", v1alpha1.ReconcileInit, "b"),
+			objects:       []runtime.Object{mockDependentInstanceGroup("b", v1alpha1.ReconcileReady, "a")},
+			expected:      []string{"default/a", "default/b", "default/a"},
+		},
+		{
+			name:          "transitive cycle",
+			instanceGroup: mockDependentInstanceGroup("a", v1alpha1.ReconcileInit, "b"),
+			objects: []runtime.Object{
+				mockDependentInstanceGroup("b", v1alpha1.ReconcileReady, "c"),
+				mockDependentInstanceGroup("c", v1alpha1.ReconcileReady, "a"),
+			},
+			expected: []string{"default/a", "default/b", "default/c", "default/a"},
+		},
+		{
+			name:          "cycle between dependencies",
+			instanceGroup: mockDependentInstanceGroup("a", v1alpha1.ReconcileInit, "b"),
+			objects: []runtime.Object{
+				mockDependentInstanceGroup("b", v1alpha1.ReconcileReady, "c"),
+				mockDependentInstanceGroup("c", v1alpha1.ReconcileReady, "b"),
+			},
+			expected: []string{"default/b", "default/c", "default/b"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test %v", tc.name)
+		reconciler := createTestReconciler(append(tc.objects, tc.instanceGroup)...)
+		_, err := reconciler.GetPendingDependencies(tc.instanceGroup)
+		g.Expect(err).To(gomega.HaveOccurred())
+		cycleErr, ok := err.(*DependencyCycleError)
+		g.Expect(ok).To(gomega.BeTrue())
+		g.Expect(cycleErr.Cycle).To(gomega.Equal(tc.expected))
+	}
+
+	// shared dependencies are not cycles
+	var (
+		instanceGroup = mockDependentInstanceGroup("a", v1alpha1.ReconcileInit, "b", "c")
+		reconciler    = createTestReconciler(
+			instanceGroup,
+			mockDependentInstanceGroup("b", v1alpha1.ReconcileReady, "d"),
+			mockDependentInstanceGroup("c", v1alpha1.ReconcileReady, "d"),
+			mockDependentInstanceGroup("d", v1alpha1.ReconcileReady),
+		)
+	)
+	pending, err := reconciler.GetPendingDependencies(instanceGroup)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(pending).To(gomega.BeEmpty())
+}
@@ -43,26 +43,63 @@ import (
 // InstanceGroupReconciler reconciles an InstanceGroup object
 type InstanceGroupReconciler struct {
 	client.Client
-	SpotRecommendationTime      float64
-	ConfigNamespace             string
-	NodeRelabel                 bool
-	Log                         logr.Logger
-	MaxParallel                 int
-	Auth                        *InstanceGroupAuthenticator
-	ConfigMap                   *corev1.ConfigMap
-	Namespaces                  map[string]corev1.Namespace
-	NamespacesLock              *sync.RWMutex
-	ConfigRetention             int
-	Metrics                     *common.MetricsCollector
-	DisableWinClusterInjection  bool
-	DefaultScalingConfiguration *v1alpha1.ScalingConfigurationType
+	SpotRecommendationTime            float64
+	ConfigNamespace                   string
+	NodeRelabel                       bool
+	WatchNodeConditions               bool
+	AutoscalersEnabled                bool
+	Log                               logr.Logger
+	MaxParallel                       int
+	Auth                              *InstanceGroupAuthenticator
+	ConfigMap                         *corev1.ConfigMap
+	Namespaces                        map[string]corev1.Namespace
+	NamespacesLock                    *sync.RWMutex
+	ConfigRetention                   int
+	Metrics                           *common.MetricsCollector
+	DisableWinClusterInjection        bool
+	DefaultScalingConfiguration       *v1alpha1.ScalingConfigurationType
+	RequeueIntervals                  provisioners.RequeueIntervals
+	UserDataValidator                 *provisioners.UserDataValidator
+	UserDataExporter                  *provisioners.UserDataExporter
+	StateExporter                     *provisioners.StateExporter
+	InstanceProfilePropagationTimeout time.Duration
+	PricingTable                      awsprovider.PricingTable
+	ResourcePrefixTemplate            *provisioners.ResourcePrefixTemplate
+	RotationNotifier                  *provisioners.RotationNotifier
+	AwsCallTimeout                    time.Duration
+	OsFamilyManagedPolicies           provisioners.OsFamilyManagedPolicies
+	CrossAccountAuthMappings          bool
 }
 
 type InstanceGroupAuthenticator struct {
 	Aws        awsprovider.AwsWorker
+	AwsWorkers *awsprovider.AwsWorkers
 	Kubernetes kubeprovider.KubernetesClientSet
 }
 
+// GetAwsWorker returns the AwsWorker of an instance group, instance groups with a region or an assumeRoleArn call AWS
+// APIs in that region or as the assumed role
+func (a *InstanceGroupAuthenticator) GetAwsWorker(instanceGroup *v1alpha1.InstanceGroup) (awsprovider.AwsWorker, error) {
+	spec := instanceGroup.GetEKSSpec()
+	if spec == nil || spec.EKSConfiguration == nil {
+		return a.Aws, nil
+	}
+	var (
+		region  = spec.EKSConfiguration.GetRegion()
+		roleArn = spec.EKSConfiguration.GetAssumeRoleArn()
+	)
+	if common.StringEmpty(region) && common.StringEmpty(roleArn) {
+		return a.Aws, nil
+	}
+	if a.AwsWorkers == nil {
+		return awsprovider.AwsWorker{}, errors.New("regional and assumed role workers are not configured")
+	}
+	if strings.EqualFold(region, a.AwsWorkers.Region) && common.StringEmpty(roleArn) {
+		return a.Aws, nil
+	}
+	return a.AwsWorkers.Get(region, roleArn)
+}
+
 const (
 	FinalizerStr = "finalizer.instancegroups.keikoproj.io"
 
@@ -71,6 +108,13 @@ const (
 	ErrorReasonDefaultsApplyFailed     = "ApplyDefaults"
 	ErrorReasonValidationFailed        = "ResourceValidation"
 	ErrorReasonReconcileFailed         = "HandleReconcile"
+	ErrorReasonAssumeRoleFailed        = "AssumeRole"
+	ErrorReasonDependenciesFailed      = "Dependencies"
+	ErrorReasonAwsCallTimeout          = "AwsCallTimeout"
+
+	// AwsCallTimeoutRequeueInterval is the interval after which an instance group whose reconcile was abandoned at the
+	// deadline of an AWS API call is requeued
+	AwsCallTimeoutRequeueInterval = 30 * time.Second
 )
 
 func (r *InstanceGroupReconciler) Finalize(instanceGroup *v1alpha1.InstanceGroup) {
@@ -107,12 +151,17 @@ func (r *InstanceGroupReconciler) SetFinalizer(instanceGroup *v1alpha1.InstanceG
 }
 
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=list;get;watch
-// +kubebuilder:rbac:groups=core,resources=nodes,verbs=list;patch;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;patch;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=list;delete
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;create;update;patch;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=instancemgr.keikoproj.io,resources=instancegroups,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=instancemgr.keikoproj.io,resources=instancegroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=instancemgr.keikoproj.io,resources=instancegroupautoscalers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=instancemgr.keikoproj.io,resources=instancegroupautoscalers/status,verbs=get;update;patch
 
 func (r *InstanceGroupReconciler) Reconcile(ctxt context.Context, req ctrl.Request) (ctrl.Result, error) {
 	_ = r.Log.WithValues("instancegroup", req.NamespacedName)
@@ -135,14 +184,25 @@ func (r *InstanceGroupReconciler) Reconcile(ctxt context.Context, req ctrl.Reque
 	r.SetFinalizer(instanceGroup)
 
 	input := provisioners.ProvisionerInput{
-		AwsWorker:                  r.Auth.Aws,
-		Kubernetes:                 r.Auth.Kubernetes,
-		Configuration:              r.ConfigMap,
-		InstanceGroup:              instanceGroup,
-		Log:                        r.Log,
-		ConfigRetention:            r.ConfigRetention,
-		Metrics:                    r.Metrics,
-		DisableWinClusterInjection: r.DisableWinClusterInjection,
+		AwsWorker:                         r.Auth.Aws,
+		Kubernetes:                        r.Auth.Kubernetes,
+		Configuration:                     r.ConfigMap,
+		InstanceGroup:                     instanceGroup,
+		Log:                               provisioners.GetInstanceGroupLogger(r.Log, instanceGroup),
+		ConfigRetention:                   r.ConfigRetention,
+		Metrics:                           r.Metrics,
+		DisableWinClusterInjection:        r.DisableWinClusterInjection,
+		RequeueIntervals:                  r.RequeueIntervals,
+		UserDataValidator:                 r.UserDataValidator,
+		UserDataExporter:                  r.UserDataExporter,
+		StateExporter:                     r.StateExporter,
+		InstanceProfilePropagationTimeout: r.InstanceProfilePropagationTimeout,
+		PricingTable:                      r.PricingTable,
+		ResourcePrefixTemplate:            r.ResourcePrefixTemplate,
+		RotationNotifier:                  r.RotationNotifier,
+		AwsCallTimeout:                    r.AwsCallTimeout,
+		OsFamilyManagedPolicies:           r.OsFamilyManagedPolicies,
+		CrossAccountAuthMappings:          r.CrossAccountAuthMappings,
 	}
 
 	var (
@@ -183,6 +243,26 @@ func (r *InstanceGroupReconciler) Reconcile(ctxt context.Context, req ctrl.Reque
 		return ctrl.Result{}, errors.Errorf("provisioner '%v' does not exist", provisionerKind)
 	}
 
+	if strings.EqualFold(provisionerKind, eks.ProvisionerName) {
+		if input.AwsWorker, err = r.Auth.GetAwsWorker(input.InstanceGroup); err != nil {
+			r.Metrics.IncFail(instanceGroup.NamespacedName(), ErrorReasonAssumeRoleFailed)
+			return ctrl.Result{}, errors.Wrapf(err, "provisioner %v reconcile failed", provisionerKind)
+		}
+	}
+	var autoscalerPatch client.Patch
+	if strings.EqualFold(provisionerKind, eks.ProvisionerName) {
+		if input.Autoscaler, err = r.GetAutoscaler(input.InstanceGroup); err != nil {
+			input.InstanceGroup.SetState(v1alpha1.ReconcileErr)
+			r.PatchStatus(input.InstanceGroup, statusPatch)
+			r.Metrics.IncFail(instanceGroup.NamespacedName(), ErrorReasonValidationFailed)
+			return ctrl.Result{}, errors.Wrapf(err, "provisioner %v reconcile failed", provisionerKind)
+		}
+		if input.Autoscaler != nil {
+			autoscalerPatch = client.MergeFrom(input.Autoscaler.DeepCopy())
+		}
+	}
+	input.AwsWorker = input.AwsWorker.WithCallTimeout(provisioners.GetAwsCallTimeout(input.InstanceGroup, input.AwsCallTimeout))
+
 	r.Log.Info("reconcile event started", "instancegroup", req.NamespacedName, "provisioner", provisionerKind)
 	var ctx CloudDeployer
 	switch {
@@ -204,24 +284,65 @@ func (r *InstanceGroupReconciler) Reconcile(ctxt context.Context, req ctrl.Reque
 		return ctrl.Result{}, errors.Wrapf(err, "provisioner %v reconcile failed", provisionerKind)
 	}
 
+	// defer creating the instance group until the instance groups it depends on are ready, deletes are not deferred
+	if instanceGroup.DeletionTimestamp.IsZero() {
+		pending, err := r.GetPendingDependencies(input.InstanceGroup)
+		if err != nil {
+			ctx.SetState(v1alpha1.ReconcileErr)
+			r.PatchStatus(input.InstanceGroup, statusPatch)
+			r.Metrics.IncFail(instanceGroup.NamespacedName(), ErrorReasonDependenciesFailed)
+			return ctrl.Result{}, errors.Wrapf(err, "provisioner %v reconcile failed", provisionerKind)
+		}
+
+		if len(pending) > 0 {
+			status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.WaitingForDependencies, corev1.ConditionTrue))
+			r.Log.Info("reconcile event deferred, waiting for dependencies", "instancegroup", req.NamespacedName, "dependencies", pending, "requeueAfter", DependencyRequeueInterval.String())
+			r.PatchStatus(input.InstanceGroup, statusPatch)
+			r.Metrics.IncSuccess(instanceGroup.NamespacedName())
+			return ctrl.Result{RequeueAfter: DependencyRequeueInterval}, nil
+		}
+
+		if status.GetWaitingForDependenciesCondition() == corev1.ConditionTrue {
+			status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.WaitingForDependencies, corev1.ConditionFalse))
+		}
+	}
+
 	if err = HandleReconcileRequest(ctx); err != nil {
+		// slow AWS APIs requeue the reconcile instead of failing the instance group
+		if requeueAfter := awsCallTimeoutRequeueInterval(err); requeueAfter > 0 {
+			r.Log.Info("reconcile event ended with requeue, aws api call timed out", "instancegroup", req.NamespacedName, "provisioner", provisionerKind, "error", err.Error(), "requeueAfter", requeueAfter.String())
+			r.PatchStatus(input.InstanceGroup, statusPatch)
+			r.Metrics.IncFail(instanceGroup.NamespacedName(), ErrorReasonAwsCallTimeout)
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
 		ctx.SetState(v1alpha1.ReconcileErr)
 		r.PatchStatus(input.InstanceGroup, statusPatch)
 		r.Metrics.IncFail(instanceGroup.NamespacedName(), ErrorReasonReconcileFailed)
 		return ctrl.Result{}, errors.Wrapf(err, "provisioner %v reconcile failed", provisionerKind)
 	}
 
+	if input.Autoscaler != nil {
+		r.PatchAutoscalerStatus(input.Autoscaler, autoscalerPatch)
+	}
+
 	if provisioners.IsRetryable(input.InstanceGroup) {
-		r.Log.Info("reconcile event ended with requeue", "instancegroup", req.NamespacedName, "provisioner", provisionerKind)
+		requeueAfter := shortestInterval(provisioners.GetRequeueInterval(input.InstanceGroup, input.RequeueIntervals), notReadyRequeueInterval(ctx))
+		r.Log.Info("reconcile event ended with requeue", "instancegroup", req.NamespacedName, "provisioner", provisionerKind, "requeueAfter", requeueAfter.String())
 		r.PatchStatus(input.InstanceGroup, statusPatch)
 		r.Metrics.IncSuccess(instanceGroup.NamespacedName())
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
 	}
 
 	r.Log.Info("reconcile event ended", "instancegroup", req.NamespacedName, "provisioner", provisionerKind)
 	r.PatchStatus(input.InstanceGroup, statusPatch)
 	r.Finalize(instanceGroup)
 	r.Metrics.IncSuccess(instanceGroup.NamespacedName())
+
+	// images resolved from SSM are checked again at the interval, so newly published images are rolled out without an
+	// update to the instance group, NotReady nodes are replaced once they exceed the threshold
+	if interval := shortestInterval(imageUpdateCheckInterval(input.InstanceGroup, provisionerKind), notReadyRequeueInterval(ctx)); interval > 0 {
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -257,3 +378,42 @@ func (r *InstanceGroupReconciler) IsNamespaceAnnotated(namespace, key, value str
 	}
 	return false
 }
+
+// awsCallTimeoutRequeueInterval returns the interval after which a reconcile which failed with the error is requeued if
+// an AWS API call was abandoned at its deadline, or zero if the reconcile failed otherwise
+func awsCallTimeoutRequeueInterval(err error) time.Duration {
+	if !awsprovider.IsCallTimeout(err) {
+		return 0
+	}
+	return AwsCallTimeoutRequeueInterval
+}
+
+// imageUpdateCheckInterval returns the interval at which an eks instance group's image reference is resolved again, or
+// zero if it is not checked on a schedule
+func imageUpdateCheckInterval(instanceGroup *v1alpha1.InstanceGroup, provisionerKind string) time.Duration {
+	if !strings.EqualFold(provisionerKind, eks.ProvisionerName) || !instanceGroup.GetDeletionTimestamp().IsZero() {
+		return 0
+	}
+	return instanceGroup.GetEKSConfiguration().GetImageUpdateCheckInterval()
+}
+
+// notReadyRequeueInterval returns the interval after which the next NotReady node of an eks instance group exceeds the
+// not ready replacement threshold, node events are only received when nodes become NotReady, or zero if there is none
+func notReadyRequeueInterval(ctx CloudDeployer) time.Duration {
+	eksCtx, ok := ctx.(*eks.EksInstanceGroupContext)
+	if !ok {
+		return 0
+	}
+	return eksCtx.GetDiscoveredState().GetNotReadyInterval()
+}
+
+// shortestInterval returns the shortest of the intervals which are not zero, or zero if all are
+func shortestInterval(intervals ...time.Duration) time.Duration {
+	var shortest time.Duration
+	for _, interval := range intervals {
+		if interval > 0 && (shortest == 0 || interval < shortest) {
+			shortest = interval
+		}
+	}
+	return shortest
+}
@@ -0,0 +1,102 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	v1alpha1 "github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
+	"github.com/keikoproj/instance-manager/controllers/provisioners/eks"
+	"github.com/keikoproj/instance-manager/controllers/provisioners/eksmanaged"
+	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestImageUpdateCheckInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	mockInstanceGroup := func(interval string, deleted bool) *v1alpha1.InstanceGroup {
+		ig := &v1alpha1.InstanceGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "instance-group-1", Namespace: "default"},
+			Spec: v1alpha1.InstanceGroupSpec{
+				EKSSpec: &v1alpha1.EKSSpec{
+					EKSConfiguration: &v1alpha1.EKSConfiguration{Image: v1alpha1.ImageLatestValue, ImageUpdateCheckInterval: interval},
+				},
+			},
+		}
+		if deleted {
+			ig.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		}
+		return ig
+	}
+
+	// instance groups checking for image updates are requeued at the interval
+	g.Expect(imageUpdateCheckInterval(mockInstanceGroup("6h", false), eks.ProvisionerName)).To(gomega.Equal(6 * time.Hour))
+	g.Expect(imageUpdateCheckInterval(mockInstanceGroup("", false), eks.ProvisionerName)).To(gomega.BeZero())
+	g.Expect(imageUpdateCheckInterval(mockInstanceGroup("6h", true), eks.ProvisionerName)).To(gomega.BeZero())
+	g.Expect(imageUpdateCheckInterval(mockInstanceGroup("6h", false), eksmanaged.ProvisionerName)).To(gomega.BeZero())
+}
+
+func TestAwsCallTimeoutRequeueInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// simulate an AWS API which responds slower than the call timeout
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	config := aws.NewConfig().WithRegion("us-west-2").WithEndpoint(server.URL).WithCredentials(credentials.NewStaticCredentials("id", "secret", ""))
+	worker := awsprovider.AwsWorker{Ec2Client: ec2.New(session.Must(session.NewSession(config)))}.WithCallTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	_, err := worker.Ec2Client.DescribeInstances(&ec2.DescribeInstancesInput{})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second))
+
+	// reconciles abandoned at the deadline of a call are requeued, other failures are not
+	g.Expect(awsCallTimeoutRequeueInterval(errors.Wrap(err, "failed to describe instances"))).To(gomega.Equal(AwsCallTimeoutRequeueInterval))
+	g.Expect(awsCallTimeoutRequeueInterval(errors.New("some-error"))).To(gomega.BeZero())
+}
+
+func TestNotReadyRequeueInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// eks instance groups are requeued when their next NotReady node exceeds the threshold
+	ctx := &eks.EksInstanceGroupContext{}
+	g.Expect(notReadyRequeueInterval(ctx)).To(gomega.BeZero())
+	ctx.GetDiscoveredState().SetNotReadyInterval(5 * time.Minute)
+	g.Expect(notReadyRequeueInterval(ctx)).To(gomega.Equal(5 * time.Minute))
+	g.Expect(notReadyRequeueInterval(&eksmanaged.EksManagedInstanceGroupContext{})).To(gomega.BeZero())
+
+	// the shortest interval which is set is used
+	g.Expect(shortestInterval(6*time.Hour, 5*time.Minute)).To(gomega.Equal(5 * time.Minute))
+	g.Expect(shortestInterval(0, 5*time.Minute)).To(gomega.Equal(5 * time.Minute))
+	g.Expect(shortestInterval(0, 0)).To(gomega.BeZero())
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -28,8 +29,11 @@ import (
 )
 
 // GetAwsAsgClient returns an ASG client
-func GetAwsAsgClient(region string, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) autoscalingiface.AutoScalingAPI {
+func GetAwsAsgClient(region string, creds *credentials.Credentials, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) autoscalingiface.AutoScalingAPI {
 	config := aws.NewConfig().WithRegion(region).WithCredentialsChainVerboseErrors(true)
+	if creds != nil {
+		config = config.WithCredentials(creds)
+	}
 	config = request.WithRetryer(config, NewRetryLogger(maxRetries, collector))
 	sess, err := session.NewSession(config)
 	if err != nil {
@@ -219,16 +223,88 @@ func (w *AwsWorker) TerminateScalingInstances(instanceIds []string) error {
 	return nil
 }
 
+// RemoveScalingInstances terminates instances of a scaling group and decrements its desired capacity, so that the
+// instances are not replaced
+func (w *AwsWorker) RemoveScalingInstances(instanceIds []string) error {
+	for _, instance := range instanceIds {
+		_, err := w.AsgClient.TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     aws.String(instance),
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetInstancesProtection sets or clears the scale in protection of instances of a scaling group
+func (w *AwsWorker) SetInstancesProtection(asgName string, instanceIds []string, protected bool) error {
+	_, err := w.AsgClient.SetInstanceProtection(&autoscaling.SetInstanceProtectionInput{
+		AutoScalingGroupName: aws.String(asgName),
+		InstanceIds:          aws.StringSlice(instanceIds),
+		ProtectedFromScaleIn: aws.Bool(protected),
+	})
+	return err
+}
+
 func (w *AwsWorker) DescribeAutoscalingGroups() ([]*autoscaling.Group, error) {
+	return w.describeAutoscalingGroupsByName(nil)
+}
+
+// DescribeAutoscalingGroupsByName describes the scaling groups with the given names, names of scaling groups which do
+// not exist are ignored
+func (w *AwsWorker) DescribeAutoscalingGroupsByName(names []string) ([]*autoscaling.Group, error) {
+	if len(names) == 0 {
+		return []*autoscaling.Group{}, nil
+	}
+	return w.describeAutoscalingGroupsByName(names)
+}
+
+func (w *AwsWorker) describeAutoscalingGroupsByName(names []string) ([]*autoscaling.Group, error) {
+	var (
+		resources []interface{}
+		err       error
+	)
+	if w.ScalingGroupBatcher != nil {
+		resources, err = w.ScalingGroupBatcher.Describe(names, w.callTimeout)
+	} else {
+		resources, err = describeAutoscalingGroups(w.AsgClient)(names)
+	}
+
 	scalingGroups := []*autoscaling.Group{}
-	err := w.AsgClient.DescribeAutoScalingGroupsPages(&autoscaling.DescribeAutoScalingGroupsInput{}, func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
-		scalingGroups = append(scalingGroups, page.AutoScalingGroups...)
-		return page.NextToken != nil
-	})
-	if err != nil {
-		return scalingGroups, err
+	for _, resource := range resources {
+		scalingGroups = append(scalingGroups, resource.(*autoscaling.Group))
+	}
+	return scalingGroups, err
+}
+
+// describeAutoscalingGroups returns a DescribeFunc which describes scaling groups by name, in calls of at most
+// DescribeAutoScalingGroupsMaxNames names
+func describeAutoscalingGroups(client autoscalingiface.AutoScalingAPI) DescribeFunc {
+	return func(names []string) ([]interface{}, error) {
+		resources := []interface{}{}
+		for start := 0; start == 0 || start < len(names); start += DescribeAutoScalingGroupsMaxNames {
+			input := &autoscaling.DescribeAutoScalingGroupsInput{}
+			if names != nil {
+				end := start + DescribeAutoScalingGroupsMaxNames
+				if end > len(names) {
+					end = len(names)
+				}
+				input.AutoScalingGroupNames = aws.StringSlice(names[start:end])
+			}
+			err := client.DescribeAutoScalingGroupsPages(input, func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+				for _, group := range page.AutoScalingGroups {
+					resources = append(resources, group)
+				}
+				return page.NextToken != nil
+			})
+			if err != nil {
+				return resources, err
+			}
+		}
+		return resources, nil
 	}
-	return scalingGroups, nil
 }
 
 func (w *AwsWorker) DescribeAutoscalingLaunchConfigs() ([]*autoscaling.LaunchConfiguration, error) {
@@ -272,14 +348,50 @@ func (w *AwsWorker) DisableMetrics(asgName string, metrics []string) error {
 	return nil
 }
 
-func GetScalingGroupTagsByName(name string, client autoscalingiface.AutoScalingAPI) ([]*autoscaling.TagDescription, error) {
+// DescribeActiveInstanceRefreshes returns the instance refreshes of a scaling group which have not yet completed
+func (w *AwsWorker) DescribeActiveInstanceRefreshes(asgName string) ([]*autoscaling.InstanceRefresh, error) {
+	out, err := w.AsgClient.DescribeInstanceRefreshes(&autoscaling.DescribeInstanceRefreshesInput{
+		AutoScalingGroupName: aws.String(asgName),
+	})
+	if err != nil {
+		return []*autoscaling.InstanceRefresh{}, err
+	}
+
+	refreshes := []*autoscaling.InstanceRefresh{}
+	for _, refresh := range out.InstanceRefreshes {
+		if common.ContainsEqualFold(ActiveInstanceRefreshStatuses, aws.StringValue(refresh.Status)) {
+			refreshes = append(refreshes, refresh)
+		}
+	}
+	return refreshes, nil
+}
+
+// GetScalingGroupNameByInstanceId returns the name of the scaling group an instance belongs to, or an empty string if the
+// instance does not belong to a scaling group
+func GetScalingGroupNameByInstanceId(instanceId string, client autoscalingiface.AutoScalingAPI) (string, error) {
+	out, err := client.DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: aws.StringSlice([]string{instanceId}),
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, instance := range out.AutoScalingInstances {
+		if strings.EqualFold(aws.StringValue(instance.InstanceId), instanceId) {
+			return aws.StringValue(instance.AutoScalingGroupName), nil
+		}
+	}
+	return "", nil
+}
+
+// GetScalingGroupTagsByName returns the tags of a scaling group, the scaling group is described by name so that lookups
+// of concurrent events are batched
+func (w *AwsWorker) GetScalingGroupTagsByName(name string) ([]*autoscaling.TagDescription, error) {
 	tags := []*autoscaling.TagDescription{}
-	input := &autoscaling.DescribeAutoScalingGroupsInput{}
-	out, err := client.DescribeAutoScalingGroups(input)
+	groups, err := w.DescribeAutoscalingGroupsByName([]string{name})
 	if err != nil {
 		return tags, err
 	}
-	for _, asg := range out.AutoScalingGroups {
+	for _, asg := range groups {
 		n := aws.StringValue(asg.AutoScalingGroupName)
 		if strings.EqualFold(name, n) {
 			tags = asg.Tags
@@ -48,13 +48,19 @@ const (
 	GetRoleTTL                        time.Duration = 60 * time.Second
 	GetInstanceProfileTTL             time.Duration = 60 * time.Second
 	DescribeNodegroupTTL              time.Duration = 60 * time.Second
+	DescribeAccessEntryTTL            time.Duration = 60 * time.Second
 	DescribeLifecycleHooksTTL         time.Duration = 180 * time.Second
 	DescribeClusterTTL                time.Duration = 180 * time.Second
+	ListClustersTTL                   time.Duration = 180 * time.Second
 	DescribeSecurityGroupsTTL         time.Duration = 180 * time.Second
 	DescribeSubnetsTTL                time.Duration = 180 * time.Second
+	DescribeRouteTablesTTL            time.Duration = 180 * time.Second
+	DescribeVpcsTTL                   time.Duration = 1 * time.Hour
 	DescribeLaunchTemplatesTTL        time.Duration = 60 * time.Second
 	DescribeLaunchTemplateVersionsTTL time.Duration = 60 * time.Second
 	DescribeInstanceTypesTTL          time.Duration = 24 * time.Hour
+	DescribeImagesTTL                 time.Duration = 1 * time.Hour
+	DescribeTagsTTL                   time.Duration = 5 * time.Minute
 	DescribeInstanceTypeOfferingTTL   time.Duration = 1 * time.Hour
 	GetParameterTTL                   time.Duration = 1 * time.Hour
 
@@ -66,15 +72,29 @@ const (
 	LaunchTemplateStrategyLowestPrice       = "lowest-price"
 	LaunchTemplateAllocationStrategy        = "prioritized"
 	LaunchTemplateLatestVersionKey          = "$Latest"
+	MinWeightedCapacity                     = 1
+	MaxWeightedCapacity                     = 999
 	IAMPolicyPrefix                         = "arn:aws:iam::aws:policy"
 	LaunchConfigurationNotFoundErrorMessage = "Launch configuration name not found"
 	defaultPolicyArn                        = "arn:aws:iam::aws:policy/AmazonEKSFargatePodExecutionRolePolicy"
+	IAMMaxTags                              = 50
+	IAMTagKeyMaxLength                      = 128
+	IAMTagValueMaxLength                    = 256
+	ReservedTagPrefix                       = "aws:"
+	IAMReservedTagPrefix                    = ReservedTagPrefix
+	InstanceHealthStatusUnhealthy           = "Unhealthy"
 )
 
 var (
+	// IAMTagPattern matches the characters allowed in IAM tag keys and values
+	IAMTagPattern = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
 	DefaultInstanceProfilePropagationDelay = time.Second * 35
-	DefaultWaiterDuration                  = time.Second * 5
-	DefaultWaiterRetries                   = 12
+	// DefaultInstanceProfilePropagationTimeout is how long after its creation an instance profile which cannot be
+	// launched with is assumed to still be propagating
+	DefaultInstanceProfilePropagationTimeout = time.Minute * 5
+	DefaultWaiterDuration                    = time.Second * 5
+	DefaultWaiterRetries                     = 12
 
 	DefaultSuspendProcesses = []string{
 		"Launch",
@@ -104,10 +124,19 @@ var (
 		"GroupTotalCapacity",
 	}
 
+	ActiveInstanceRefreshStatuses = []string{
+		autoscaling.InstanceRefreshStatusPending,
+		autoscaling.InstanceRefreshStatusInProgress,
+		autoscaling.InstanceRefreshStatusCancelling,
+		autoscaling.InstanceRefreshStatusRollbackInProgress,
+	}
+
 	ConfigurationAllowedVolumeTypes             = []string{"gp2", "io1", "sc1", "st1"}
 	TemplateAllowedVolumeTypes                  = []string{"gp2", "gp3", "io1", "io2", "sc1", "st1"}
 	AllowedVolumeTypesWithProvisionedIOPS       = []string{"io1", "io2", "gp3"}
 	AllowedVolumeTypesWithProvisionedThroughput = []string{"gp3"}
+	ProvisionedIOPSRange                        = map[string][]int64{"gp3": {3000, 16000}, "io1": {100, 64000}, "io2": {100, 256000}}
+	ProvisionedThroughputRange                  = map[string][]int64{"gp3": {125, 1000}}
 	LifecycleHookTransitionLaunch               = "autoscaling:EC2_INSTANCE_LAUNCHING"
 	LifecycleHookTransitionTerminate            = "autoscaling:EC2_INSTANCE_TERMINATING"
 )
@@ -120,6 +149,13 @@ type AwsWorker struct {
 	SsmClient   ssmiface.SSMAPI
 	Ec2Metadata *ec2metadata.EC2Metadata
 	Parameters  map[string]interface{}
+	// ScalingGroupBatcher and LaunchTemplateBatcher coalesce the describes of concurrent reconciles, describes are not
+	// batched when they are nil
+	ScalingGroupBatcher   *DescribeBatcher
+	LaunchTemplateBatcher *DescribeBatcher
+
+	// callTimeout is the deadline WithCallTimeout set on the calls of the worker, batched describes are described with it
+	callTimeout time.Duration
 }
 
 func (w *AwsWorker) WithRetries(f func() bool) error {
@@ -134,6 +170,29 @@ func (w *AwsWorker) WithRetries(f func() bool) error {
 	return errors.New("waiter timed out")
 }
 
+// IsReservedTagKey returns true if the tag key uses the aws: prefix, which is reserved for tags created by AWS and
+// rejected by the tagging APIs of all resources
+func IsReservedTagKey(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), ReservedTagPrefix)
+}
+
+// ValidateIAMTag returns an error if the tag does not meet the constraints of IAM tags
+func ValidateIAMTag(key, value string) error {
+	if len(key) == 0 || len(key) > IAMTagKeyMaxLength {
+		return errors.Errorf("tag key '%v' must be between 1 and %v characters", key, IAMTagKeyMaxLength)
+	}
+	if len(value) > IAMTagValueMaxLength {
+		return errors.Errorf("value of tag '%v' must not exceed %v characters", key, IAMTagValueMaxLength)
+	}
+	if IsReservedTagKey(key) {
+		return errors.Errorf("tag key '%v' must not use the reserved prefix '%v'", key, IAMReservedTagPrefix)
+	}
+	if !IAMTagPattern.MatchString(key) || !IAMTagPattern.MatchString(value) {
+		return errors.Errorf("tag '%v' may only contain letters, numbers, spaces and the characters _.:/=+-@", key)
+	}
+	return nil
+}
+
 func (w *AwsWorker) compactTags(tags []map[string]string) map[string]string {
 	compacted := make(map[string]string)
 	for _, tagSet := range tags {
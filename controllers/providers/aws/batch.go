@@ -0,0 +1,184 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+const (
+	// DefaultDescribeBatchWindow is how long describe requests are collected before they are described together
+	DefaultDescribeBatchWindow = 100 * time.Millisecond
+	// DescribeAutoScalingGroupsMaxNames is the number of scaling group names AWS accepts in a single describe call
+	DescribeAutoScalingGroupsMaxNames = 100
+	// DescribeLaunchTemplatesMaxNames is the number of launch template names AWS accepts in a single filter
+	DescribeLaunchTemplatesMaxNames = 200
+)
+
+// DescribeFunc describes the resources with the given ids, or all resources when ids is nil
+type DescribeFunc func(ids []string) ([]interface{}, error)
+
+// DescribeBatcher coalesces the describe requests of concurrent reconciles for a resource type, requests received within
+// the window with the same call timeout are described together. Requests of all resources share a single describe call
+// and serve the requests of specific resources from its result, otherwise the requested ids are described in calls of
+// at most MaxBatchSize ids
+type DescribeBatcher struct {
+	Window       time.Duration
+	MaxBatchSize int
+
+	describe func(timeout time.Duration) DescribeFunc
+	key      func(interface{}) string
+	pending  map[time.Duration]*describeBatch
+	lock     sync.Mutex
+}
+
+type describeBatch struct {
+	timeout   time.Duration
+	all       bool
+	ids       map[string]bool
+	done      chan struct{}
+	resources []interface{}
+	err       error
+}
+
+// NewDescribeBatcher returns a batcher which describes resources with the DescribeFunc describe returns for the call
+// timeout of a batch and identifies them by key, a batch size larger than limit, or which is not positive, is replaced
+// by limit
+func NewDescribeBatcher(window time.Duration, maxBatchSize, limit int, describe func(timeout time.Duration) DescribeFunc, key func(interface{}) string) *DescribeBatcher {
+	if maxBatchSize <= 0 || maxBatchSize > limit {
+		maxBatchSize = limit
+	}
+	return &DescribeBatcher{
+		Window:       window,
+		MaxBatchSize: maxBatchSize,
+		describe:     describe,
+		key:          key,
+		pending:      make(map[time.Duration]*describeBatch),
+	}
+}
+
+// Describe returns the resources with the given ids, or all resources when ids is nil, once the batch the request
+// joined is described. The describe calls of the batch are abandoned once they did not complete within the timeout, a
+// timeout which is not positive removes the deadline
+func (b *DescribeBatcher) Describe(ids []string, timeout time.Duration) ([]interface{}, error) {
+	if ids != nil && len(ids) == 0 {
+		return []interface{}{}, nil
+	}
+	if timeout < 0 {
+		timeout = 0
+	}
+
+	b.lock.Lock()
+	batch := b.pending[timeout]
+	if batch == nil {
+		batch = &describeBatch{
+			timeout: timeout,
+			ids:     make(map[string]bool),
+			done:    make(chan struct{}),
+		}
+		b.pending[timeout] = batch
+		time.AfterFunc(b.Window, func() { b.flush(batch) })
+	}
+	if ids == nil {
+		batch.all = true
+	}
+	for _, id := range ids {
+		batch.ids[id] = true
+	}
+	b.lock.Unlock()
+
+	<-batch.done
+	if batch.err != nil {
+		return nil, batch.err
+	}
+	if ids == nil {
+		return batch.resources, nil
+	}
+
+	requested := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		requested[id] = true
+	}
+	resources := make([]interface{}, 0)
+	for _, resource := range batch.resources {
+		if requested[b.key(resource)] {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+func (b *DescribeBatcher) flush(batch *describeBatch) {
+	b.lock.Lock()
+	if b.pending[batch.timeout] == batch {
+		delete(b.pending, batch.timeout)
+	}
+	b.lock.Unlock()
+	defer close(batch.done)
+
+	describe := b.describe(batch.timeout)
+	if batch.all {
+		batch.resources, batch.err = describe(nil)
+		return
+	}
+
+	ids := make([]string, 0, len(batch.ids))
+	for id := range batch.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for start := 0; start < len(ids); start += b.MaxBatchSize {
+		end := start + b.MaxBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		resources, err := describe(ids[start:end])
+		if err != nil {
+			batch.err = err
+			return
+		}
+		batch.resources = append(batch.resources, resources...)
+	}
+}
+
+// EnableDescribeBatching coalesces the scaling group and launch template describes of concurrent reconciles which call
+// AWS APIs with this worker, describes are not batched when the window is not positive. Batches are described with the
+// call timeout of the workers which requested them rather than the timeout of this worker
+func (w *AwsWorker) EnableDescribeBatching(window time.Duration, maxBatchSize int) {
+	if window <= 0 {
+		w.ScalingGroupBatcher = nil
+		w.LaunchTemplateBatcher = nil
+		return
+	}
+	untimed := w.WithCallTimeout(0)
+	w.ScalingGroupBatcher = NewDescribeBatcher(window, maxBatchSize, DescribeAutoScalingGroupsMaxNames, func(timeout time.Duration) DescribeFunc {
+		return describeAutoscalingGroups(untimed.WithCallTimeout(timeout).AsgClient)
+	}, func(resource interface{}) string {
+		return aws.StringValue(resource.(*autoscaling.Group).AutoScalingGroupName)
+	})
+	w.LaunchTemplateBatcher = NewDescribeBatcher(window, maxBatchSize, DescribeLaunchTemplatesMaxNames, func(timeout time.Duration) DescribeFunc {
+		return describeLaunchTemplates(untimed.WithCallTimeout(timeout).Ec2Client)
+	}, func(resource interface{}) string {
+		return aws.StringValue(resource.(*ec2.LaunchTemplate).LaunchTemplateName)
+	})
+}
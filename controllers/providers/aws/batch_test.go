@@ -0,0 +1,270 @@
+package aws
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+type batchAsgClient struct {
+	autoscalingiface.AutoScalingAPI
+	groups []*autoscaling.Group
+	calls  [][]string
+	err    error
+	lock   sync.Mutex
+}
+
+func (c *batchAsgClient) DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAutoScalingGroupsInput, callback func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	names := aws.StringValueSlice(input.AutoScalingGroupNames)
+	c.calls = append(c.calls, names)
+	if c.err != nil {
+		return c.err
+	}
+
+	out := &autoscaling.DescribeAutoScalingGroupsOutput{}
+	for _, group := range c.groups {
+		if len(names) == 0 || containsString(names, aws.StringValue(group.AutoScalingGroupName)) {
+			out.AutoScalingGroups = append(out.AutoScalingGroups, group)
+		}
+	}
+	callback(out, true)
+	return nil
+}
+
+type batchEc2Client struct {
+	ec2iface.EC2API
+	templates []*ec2.LaunchTemplate
+	calls     [][]string
+	lock      sync.Mutex
+}
+
+func (c *batchEc2Client) DescribeLaunchTemplatesPages(input *ec2.DescribeLaunchTemplatesInput, callback func(*ec2.DescribeLaunchTemplatesOutput, bool) bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var names []string
+	for _, filter := range input.Filters {
+		if aws.StringValue(filter.Name) == "launch-template-name" {
+			names = aws.StringValueSlice(filter.Values)
+		}
+	}
+	c.calls = append(c.calls, names)
+
+	out := &ec2.DescribeLaunchTemplatesOutput{}
+	for _, template := range c.templates {
+		if len(names) == 0 || containsString(names, aws.StringValue(template.LaunchTemplateName)) {
+			out.LaunchTemplates = append(out.LaunchTemplates, template)
+		}
+	}
+	callback(out, true)
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func mockBatchWorker(groups, templates int) (*AwsWorker, *batchAsgClient, *batchEc2Client) {
+	asgClient := &batchAsgClient{}
+	for i := 0; i < groups; i++ {
+		asgClient.groups = append(asgClient.groups, &autoscaling.Group{AutoScalingGroupName: aws.String(fmt.Sprintf("group-%03d", i))})
+	}
+	ec2Client := &batchEc2Client{}
+	for i := 0; i < templates; i++ {
+		ec2Client.templates = append(ec2Client.templates, &ec2.LaunchTemplate{LaunchTemplateName: aws.String(fmt.Sprintf("template-%03d", i))})
+	}
+	return &AwsWorker{AsgClient: asgClient, Ec2Client: ec2Client}, asgClient, ec2Client
+}
+
+// concurrently runs f for n instance groups
+func concurrently(n int, f func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestDescribeBatchingScalingGroups(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// describes of all scaling groups by concurrent reconciles share a single call
+	w, asgClient, _ := mockBatchWorker(10, 0)
+	w.EnableDescribeBatching(100*time.Millisecond, 0)
+	concurrently(20, func(i int) {
+		groups, err := w.DescribeAutoscalingGroups()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(groups).To(gomega.HaveLen(10))
+	})
+	g.Expect(asgClient.calls).To(gomega.HaveLen(1))
+	g.Expect(asgClient.calls[0]).To(gomega.BeEmpty())
+
+	// describes by name are batched in calls of at most the AWS limit of names
+	w, asgClient, _ = mockBatchWorker(250, 0)
+	w.EnableDescribeBatching(100*time.Millisecond, 0)
+	concurrently(250, func(i int) {
+		name := fmt.Sprintf("group-%03d", i)
+		groups, err := w.DescribeAutoscalingGroupsByName([]string{name, "missing-group"})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(groups).To(gomega.HaveLen(1))
+		g.Expect(aws.StringValue(groups[0].AutoScalingGroupName)).To(gomega.Equal(name))
+	})
+	g.Expect(asgClient.calls).To(gomega.HaveLen(3))
+	for _, names := range asgClient.calls {
+		g.Expect(len(names)).To(gomega.BeNumerically("<=", DescribeAutoScalingGroupsMaxNames))
+	}
+
+	// the batch size can be lowered below the AWS limit
+	w, asgClient, _ = mockBatchWorker(100, 0)
+	w.EnableDescribeBatching(100*time.Millisecond, 25)
+	g.Expect(w.ScalingGroupBatcher.MaxBatchSize).To(gomega.Equal(25))
+	concurrently(100, func(i int) {
+		_, err := w.DescribeAutoscalingGroupsByName([]string{fmt.Sprintf("group-%03d", i)})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+	g.Expect(asgClient.calls).To(gomega.HaveLen(4))
+
+	// describes by name are served from a describe of all scaling groups in the same batch
+	w, asgClient, _ = mockBatchWorker(10, 0)
+	w.EnableDescribeBatching(100*time.Millisecond, 0)
+	concurrently(10, func(i int) {
+		if i == 0 {
+			groups, err := w.DescribeAutoscalingGroups()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(groups).To(gomega.HaveLen(10))
+			return
+		}
+		groups, err := w.DescribeAutoscalingGroupsByName([]string{fmt.Sprintf("group-%03d", i)})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(groups).To(gomega.HaveLen(1))
+	})
+	g.Expect(asgClient.calls).To(gomega.HaveLen(1))
+
+	// errors are returned to every request of the batch
+	w, asgClient, _ = mockBatchWorker(10, 0)
+	w.EnableDescribeBatching(100*time.Millisecond, 0)
+	asgClient.err = errors.New("some-error")
+	concurrently(5, func(i int) {
+		_, err := w.DescribeAutoscalingGroups()
+		g.Expect(err).To(gomega.HaveOccurred())
+	})
+	g.Expect(asgClient.calls).To(gomega.HaveLen(1))
+
+	// every reconcile calls AWS when describes are not batched
+	w, asgClient, _ = mockBatchWorker(10, 0)
+	w.EnableDescribeBatching(0, 0)
+	g.Expect(w.ScalingGroupBatcher).To(gomega.BeNil())
+	concurrently(5, func(i int) {
+		_, err := w.DescribeAutoscalingGroups()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+	g.Expect(asgClient.calls).To(gomega.HaveLen(5))
+}
+
+func TestDescribeBatchingLaunchTemplates(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	w, _, ec2Client := mockBatchWorker(0, 10)
+	w.EnableDescribeBatching(100*time.Millisecond, 0)
+	concurrently(20, func(i int) {
+		templates, err := w.DescribeLaunchTemplates()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(templates).To(gomega.HaveLen(10))
+	})
+	g.Expect(ec2Client.calls).To(gomega.HaveLen(1))
+
+	w, _, ec2Client = mockBatchWorker(0, 300)
+	w.EnableDescribeBatching(100*time.Millisecond, 0)
+	concurrently(300, func(i int) {
+		name := fmt.Sprintf("template-%03d", i)
+		templates, err := w.DescribeLaunchTemplatesByName([]string{name})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(templates).To(gomega.HaveLen(1))
+		g.Expect(aws.StringValue(templates[0].LaunchTemplateName)).To(gomega.Equal(name))
+	})
+	g.Expect(ec2Client.calls).To(gomega.HaveLen(2))
+	for _, names := range ec2Client.calls {
+		g.Expect(len(names)).To(gomega.BeNumerically("<=", DescribeLaunchTemplatesMaxNames))
+	}
+
+	// unbatched describes by name still respect the AWS limit of names
+	w, _, ec2Client = mockBatchWorker(0, 300)
+	names := []string{}
+	for _, template := range ec2Client.templates {
+		names = append(names, aws.StringValue(template.LaunchTemplateName))
+	}
+	templates, err := w.DescribeLaunchTemplatesByName(names)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(templates).To(gomega.HaveLen(300))
+	g.Expect(ec2Client.calls).To(gomega.HaveLen(2))
+
+	templates, err = w.DescribeLaunchTemplatesByName(nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(templates).To(gomega.BeEmpty())
+	g.Expect(ec2Client.calls).To(gomega.HaveLen(2))
+}
+
+func TestDescribeBatchingCallTimeout(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// batched describes are abandoned at the deadline of the worker which requested them
+	server := MockSlowAwsServer(t, 2*time.Second)
+	worker := mockSlowAwsWorker(server.URL).WithCallTimeout(time.Minute)
+	worker.EnableDescribeBatching(100*time.Millisecond, 0)
+	timed := worker.WithCallTimeout(100 * time.Millisecond)
+	start := time.Now()
+	_, err := timed.DescribeAutoscalingGroups()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(IsCallTimeout(err)).To(gomega.BeTrue())
+	_, err = timed.DescribeLaunchTemplatesByName([]string{"template-000"})
+	g.Expect(IsCallTimeout(err)).To(gomega.BeTrue())
+	g.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second))
+
+	// only describes with the same deadline share a batch
+	w, asgClient, _ := mockBatchWorker(10, 0)
+	w.EnableDescribeBatching(100*time.Millisecond, 0)
+	concurrently(10, func(i int) {
+		timed := w.WithCallTimeout(time.Duration(i%2+1) * time.Minute)
+		groups, err := timed.DescribeAutoscalingGroups()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(groups).To(gomega.HaveLen(10))
+	})
+	g.Expect(asgClient.calls).To(gomega.HaveLen(2))
+}
+
+func TestAwsWorkersDescribeBatching(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	workers := NewAwsWorkers("us-west-2", 3, nil, nil)
+	worker, err := workers.Get("", "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(worker.ScalingGroupBatcher).To(gomega.BeNil())
+	g.Expect(worker.LaunchTemplateBatcher).To(gomega.BeNil())
+
+	workers = NewAwsWorkers("us-west-2", 3, nil, nil)
+	workers.DescribeBatchWindow = DefaultDescribeBatchWindow
+	workers.DescribeBatchSize = 50
+	worker, err = workers.Get("", "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(worker.ScalingGroupBatcher.MaxBatchSize).To(gomega.Equal(50))
+	g.Expect(worker.LaunchTemplateBatcher.Window).To(gomega.Equal(DefaultDescribeBatchWindow))
+}
@@ -16,9 +16,13 @@ limitations under the License.
 package aws
 
 import (
+	"encoding/base64"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -26,11 +30,15 @@ import (
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/keikoproj/aws-sdk-go-cache/cache"
 	"github.com/keikoproj/instance-manager/controllers/common"
+	"github.com/pkg/errors"
 )
 
 // GetAwsEc2Client returns an EC2 client
-func GetAwsEc2Client(region string, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) ec2iface.EC2API {
+func GetAwsEc2Client(region string, creds *credentials.Credentials, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) ec2iface.EC2API {
 	config := aws.NewConfig().WithRegion(region).WithCredentialsChainVerboseErrors(true)
+	if creds != nil {
+		config = config.WithCredentials(creds)
+	}
 	config = request.WithRetryer(config, NewRetryLogger(maxRetries, collector))
 	sess, err := session.NewSession(config)
 	if err != nil {
@@ -38,14 +46,22 @@ func GetAwsEc2Client(region string, cacheCfg *cache.Config, maxRetries int, coll
 	}
 
 	cache.AddCaching(sess, cacheCfg)
+	// the controller never modifies security groups, launch template and instance attribute changes do not flush them
 	cacheCfg.SetCacheTTL("ec2", "DescribeSecurityGroups", DescribeSecurityGroupsTTL)
+	cacheCfg.SetExcludeFlushing("ec2", "DescribeSecurityGroups", true)
 	cacheCfg.SetCacheTTL("ec2", "DescribeSubnets", DescribeSubnetsTTL)
+	// route tables are only read to warn about public IPs in private subnets, and never modified by the controller
+	cacheCfg.SetCacheTTL("ec2", "DescribeRouteTables", DescribeRouteTablesTTL)
+	cacheCfg.SetExcludeFlushing("ec2", "DescribeRouteTables", true)
+	cacheCfg.SetCacheTTL("ec2", "DescribeVpcs", DescribeVpcsTTL)
 	cacheCfg.SetCacheTTL("ec2", "DescribeInstanceTypes", DescribeInstanceTypesTTL)
 	cacheCfg.SetExcludeFlushing("ec2", "DescribeInstanceTypes", true)
 	cacheCfg.SetCacheTTL("ec2", "DescribeInstanceTypeOfferings", DescribeInstanceTypeOfferingTTL)
 	cacheCfg.SetExcludeFlushing("ec2", "DescribeInstanceTypeOfferings", true)
 	cacheCfg.SetCacheTTL("ec2", "DescribeLaunchTemplates", DescribeLaunchTemplatesTTL)
 	cacheCfg.SetCacheTTL("ec2", "DescribeLaunchTemplateVersions", DescribeLaunchTemplateVersionsTTL)
+	cacheCfg.SetCacheTTL("ec2", "DescribeImages", DescribeImagesTTL)
+	cacheCfg.SetCacheTTL("ec2", "DescribeTags", DescribeTagsTTL)
 	sess.Handlers.Complete.PushFront(func(r *request.Request) {
 		ctx := r.HTTPRequest.Context()
 		log.V(1).Info("AWS API call",
@@ -91,15 +107,68 @@ func (w *AwsWorker) DescribeInstanceTypes() ([]*ec2.InstanceTypeInfo, error) {
 }
 
 func (w *AwsWorker) DescribeLaunchTemplates() ([]*ec2.LaunchTemplate, error) {
+	return w.describeLaunchTemplatesByName(nil)
+}
+
+// DescribeLaunchTemplatesByName describes the launch templates with the given names, names of launch templates which
+// do not exist are ignored
+func (w *AwsWorker) DescribeLaunchTemplatesByName(names []string) ([]*ec2.LaunchTemplate, error) {
+	if len(names) == 0 {
+		return []*ec2.LaunchTemplate{}, nil
+	}
+	return w.describeLaunchTemplatesByName(names)
+}
+
+func (w *AwsWorker) describeLaunchTemplatesByName(names []string) ([]*ec2.LaunchTemplate, error) {
+	var (
+		resources []interface{}
+		err       error
+	)
+	if w.LaunchTemplateBatcher != nil {
+		resources, err = w.LaunchTemplateBatcher.Describe(names, w.callTimeout)
+	} else {
+		resources, err = describeLaunchTemplates(w.Ec2Client)(names)
+	}
+
 	launchTemplates := []*ec2.LaunchTemplate{}
-	err := w.Ec2Client.DescribeLaunchTemplatesPages(&ec2.DescribeLaunchTemplatesInput{}, func(page *ec2.DescribeLaunchTemplatesOutput, lastPage bool) bool {
-		launchTemplates = append(launchTemplates, page.LaunchTemplates...)
-		return page.NextToken != nil
-	})
-	if err != nil {
-		return launchTemplates, err
+	for _, resource := range resources {
+		launchTemplates = append(launchTemplates, resource.(*ec2.LaunchTemplate))
+	}
+	return launchTemplates, err
+}
+
+// describeLaunchTemplates returns a DescribeFunc which describes launch templates by name, in calls of at most
+// DescribeLaunchTemplatesMaxNames names. Names are filtered on since describing a launch template name which does not
+// exist fails the entire call
+func describeLaunchTemplates(client ec2iface.EC2API) DescribeFunc {
+	return func(names []string) ([]interface{}, error) {
+		resources := []interface{}{}
+		for start := 0; start == 0 || start < len(names); start += DescribeLaunchTemplatesMaxNames {
+			input := &ec2.DescribeLaunchTemplatesInput{}
+			if names != nil {
+				end := start + DescribeLaunchTemplatesMaxNames
+				if end > len(names) {
+					end = len(names)
+				}
+				input.Filters = []*ec2.Filter{
+					{
+						Name:   aws.String("launch-template-name"),
+						Values: aws.StringSlice(names[start:end]),
+					},
+				}
+			}
+			err := client.DescribeLaunchTemplatesPages(input, func(page *ec2.DescribeLaunchTemplatesOutput, lastPage bool) bool {
+				for _, template := range page.LaunchTemplates {
+					resources = append(resources, template)
+				}
+				return page.NextToken != nil
+			})
+			if err != nil {
+				return resources, err
+			}
+		}
+		return resources, nil
 	}
-	return launchTemplates, nil
 }
 
 func (w *AwsWorker) DescribeLaunchTemplateVersions(templateName string) ([]*ec2.LaunchTemplateVersion, error) {
@@ -199,6 +268,313 @@ func (w *AwsWorker) SubnetByName(name, vpc string) (*ec2.Subnet, error) {
 	return filteredSubnets[0], nil
 }
 
+func (w *AwsWorker) DescribeSubnets(subnetIds []string) ([]*ec2.Subnet, error) {
+	subnets := []*ec2.Subnet{}
+	err := w.Ec2Client.DescribeSubnetsPages(
+		&ec2.DescribeSubnetsInput{
+			SubnetIds: aws.StringSlice(subnetIds),
+		},
+		func(page *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+			subnets = append(subnets, page.Subnets...)
+			return page.NextToken != nil
+		},
+	)
+	if err != nil {
+		return subnets, err
+	}
+	return subnets, nil
+}
+
+// PrivateSubnets returns the subnets which have no route to an internet gateway, subnets without an explicit
+// route table association use the main route table of the VPC
+func (w *AwsWorker) PrivateSubnets(subnetIds []string, vpcId string) ([]string, error) {
+	routeTables := []*ec2.RouteTable{}
+	err := w.Ec2Client.DescribeRouteTablesPages(
+		&ec2.DescribeRouteTablesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("vpc-id"),
+					Values: []*string{aws.String(vpcId)},
+				},
+			},
+		},
+		func(page *ec2.DescribeRouteTablesOutput, lastPage bool) bool {
+			routeTables = append(routeTables, page.RouteTables...)
+			return page.NextToken != nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mainIsPublic bool
+		associated   = make(map[string]bool)
+	)
+	for _, rt := range routeTables {
+		var isPublic bool
+		for _, route := range rt.Routes {
+			if strings.HasPrefix(aws.StringValue(route.GatewayId), "igw-") {
+				isPublic = true
+			}
+		}
+		for _, association := range rt.Associations {
+			if aws.BoolValue(association.Main) {
+				mainIsPublic = isPublic
+				continue
+			}
+			if association.SubnetId != nil {
+				associated[aws.StringValue(association.SubnetId)] = isPublic
+			}
+		}
+	}
+
+	private := make([]string, 0)
+	for _, id := range subnetIds {
+		isPublic, ok := associated[id]
+		if !ok {
+			isPublic = mainIsPublic
+		}
+		if !isPublic {
+			private = append(private, id)
+		}
+	}
+	return private, nil
+}
+
+// SubnetZones returns a map of subnet IDs to their availability zone
+func (w *AwsWorker) SubnetZones(subnetIds []string) (map[string]string, error) {
+	zones := make(map[string]string)
+	err := w.Ec2Client.DescribeSubnetsPages(
+		&ec2.DescribeSubnetsInput{
+			SubnetIds: aws.StringSlice(subnetIds),
+		},
+		func(page *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+			for _, s := range page.Subnets {
+				zones[aws.StringValue(s.SubnetId)] = aws.StringValue(s.AvailabilityZone)
+			}
+			return page.NextToken != nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// VpcCidrs returns the IPv4 and IPv6 CIDR blocks associated with a VPC
+func (w *AwsWorker) VpcCidrs(vpcId string) ([]string, error) {
+	out, err := w.Ec2Client.DescribeVpcs(&ec2.DescribeVpcsInput{
+		VpcIds: aws.StringSlice([]string{vpcId}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cidrs := make([]string, 0)
+	for _, vpc := range out.Vpcs {
+		for _, association := range vpc.CidrBlockAssociationSet {
+			if association.CidrBlockState != nil && aws.StringValue(association.CidrBlockState.State) != ec2.VpcCidrBlockStateCodeAssociated {
+				continue
+			}
+			cidrs = append(cidrs, aws.StringValue(association.CidrBlock))
+		}
+		for _, association := range vpc.Ipv6CidrBlockAssociationSet {
+			if association.Ipv6CidrBlockState != nil && aws.StringValue(association.Ipv6CidrBlockState.State) != ec2.VpcCidrBlockStateCodeAssociated {
+				continue
+			}
+			cidrs = append(cidrs, aws.StringValue(association.Ipv6CidrBlock))
+		}
+		if len(vpc.CidrBlockAssociationSet) == 0 && vpc.CidrBlock != nil {
+			cidrs = append(cidrs, aws.StringValue(vpc.CidrBlock))
+		}
+	}
+	return cidrs, nil
+}
+
+func (w *AwsWorker) DescribeInstances(instanceIds []string) ([]*ec2.Instance, error) {
+	instances := []*ec2.Instance{}
+	if len(instanceIds) == 0 {
+		return instances, nil
+	}
+	err := w.Ec2Client.DescribeInstancesPages(
+		&ec2.DescribeInstancesInput{
+			InstanceIds: aws.StringSlice(instanceIds),
+		},
+		func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range page.Reservations {
+				instances = append(instances, reservation.Instances...)
+			}
+			return page.NextToken != nil
+		},
+	)
+	if err != nil {
+		return instances, err
+	}
+	return instances, nil
+}
+
+// DescribeInstanceLaunchTimes returns a map of instance IDs to the time they were launched
+func (w *AwsWorker) DescribeInstanceLaunchTimes(instanceIds []string) (map[string]time.Time, error) {
+	launchTimes := make(map[string]time.Time)
+	instances, err := w.DescribeInstances(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		if instance.LaunchTime == nil {
+			continue
+		}
+		launchTimes[aws.StringValue(instance.InstanceId)] = aws.TimeValue(instance.LaunchTime)
+	}
+	return launchTimes, nil
+}
+
+// GetConsoleOutput returns the decoded console output of an instance, which is empty until the instance has written to
+// its console
+func (w *AwsWorker) GetConsoleOutput(instanceId string) (string, error) {
+	out, err := w.Ec2Client.GetConsoleOutput(&ec2.GetConsoleOutputInput{
+		InstanceId: aws.String(instanceId),
+	})
+	if err != nil {
+		return "", err
+	}
+	output, err := base64.StdEncoding.DecodeString(aws.StringValue(out.Output))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode console output")
+	}
+	return string(output), nil
+}
+
+// DescribeInstanceLifecycles returns a map of instance IDs to their lifecycle, on-demand instances have an empty lifecycle
+func (w *AwsWorker) DescribeInstanceLifecycles(instanceIds []string) (map[string]string, error) {
+	lifecycles := make(map[string]string)
+	instances, err := w.DescribeInstances(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		lifecycles[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.InstanceLifecycle)
+	}
+	return lifecycles, nil
+}
+
+// DescribeInstanceImages returns a map of instance IDs to the ID of the image they were launched from
+func (w *AwsWorker) DescribeInstanceImages(instanceIds []string) (map[string]string, error) {
+	images := make(map[string]string)
+	instances, err := w.DescribeInstances(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		images[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.ImageId)
+	}
+	return images, nil
+}
+
+// DescribeInstanceTags returns a map of instance IDs to the tags of the instance. Tags are described per instance, so
+// that instances which are described again because they lack a tag are served from the cache
+func (w *AwsWorker) DescribeInstanceTags(instanceIds []string) (map[string]map[string]string, error) {
+	tags := make(map[string]map[string]string)
+	for _, instanceId := range instanceIds {
+		instanceTags := make(map[string]string)
+		err := w.Ec2Client.DescribeTagsPages(&ec2.DescribeTagsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("resource-id"),
+					Values: aws.StringSlice([]string{instanceId}),
+				},
+			},
+		}, func(page *ec2.DescribeTagsOutput, lastPage bool) bool {
+			for _, tag := range page.Tags {
+				instanceTags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			return page.NextToken != nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		tags[instanceId] = instanceTags
+	}
+	return tags, nil
+}
+
+// DescribeImage returns the image with the given id, or nil if it does not exist
+func (w *AwsWorker) DescribeImage(imageId string) (*ec2.Image, error) {
+	out, err := w.Ec2Client.DescribeImages(&ec2.DescribeImagesInput{
+		ImageIds: aws.StringSlice([]string{imageId}),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "InvalidAMIID.NotFound" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(out.Images) == 0 {
+		return nil, nil
+	}
+	return out.Images[0], nil
+}
+
+// DisableSourceDestCheck disables the source/destination check of an instance's primary network interface
+func (w *AwsWorker) DisableSourceDestCheck(instanceId string) error {
+	return w.setSourceDestCheck(instanceId, false)
+}
+
+// EnableSourceDestCheck enables the source/destination check of an instance's primary network interface
+func (w *AwsWorker) EnableSourceDestCheck(instanceId string) error {
+	return w.setSourceDestCheck(instanceId, true)
+}
+
+func (w *AwsWorker) setSourceDestCheck(instanceId string, enabled bool) error {
+	_, err := w.Ec2Client.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+		InstanceId: aws.String(instanceId),
+		SourceDestCheck: &ec2.AttributeBooleanValue{
+			Value: aws.Bool(enabled),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *AwsWorker) DescribeSecurityGroups(groupIds []string) ([]*ec2.SecurityGroup, error) {
+	groups := []*ec2.SecurityGroup{}
+	err := w.Ec2Client.DescribeSecurityGroupsPages(
+		&ec2.DescribeSecurityGroupsInput{
+			GroupIds: aws.StringSlice(groupIds),
+		},
+		func(page *ec2.DescribeSecurityGroupsOutput, lastPage bool) bool {
+			groups = append(groups, page.SecurityGroups...)
+			return page.NextToken != nil
+		},
+	)
+	if err != nil {
+		return groups, err
+	}
+	return groups, nil
+}
+
+// IsSelfReferencingSecurityGroup returns true if the security group allows all inbound and all outbound traffic from and
+// to itself
+func IsSelfReferencingSecurityGroup(group *ec2.SecurityGroup) bool {
+	allowsSelf := func(permissions []*ec2.IpPermission) bool {
+		for _, p := range permissions {
+			if aws.StringValue(p.IpProtocol) != "-1" {
+				continue
+			}
+			for _, pair := range p.UserIdGroupPairs {
+				if aws.StringValue(pair.GroupId) == aws.StringValue(group.GroupId) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return allowsSelf(group.IpPermissions) && allowsSelf(group.IpPermissionsEgress)
+}
+
 func (w *AwsWorker) SecurityGroupByName(name, vpc string) (*ec2.SecurityGroup, error) {
 	groups := []*ec2.SecurityGroup{}
 	err := w.Ec2Client.DescribeSecurityGroupsPages(
@@ -20,6 +20,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/eks"
@@ -29,8 +30,11 @@ import (
 )
 
 // GetAwsEksClient returns an EKS client
-func GetAwsEksClient(region string, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) eksiface.EKSAPI {
+func GetAwsEksClient(region string, creds *credentials.Credentials, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) eksiface.EKSAPI {
 	config := aws.NewConfig().WithRegion(region).WithCredentialsChainVerboseErrors(true)
+	if creds != nil {
+		config = config.WithCredentials(creds)
+	}
 	config = request.WithRetryer(config, NewRetryLogger(maxRetries, collector))
 	sess, err := session.NewSession(config)
 	if err != nil {
@@ -38,7 +42,9 @@ func GetAwsEksClient(region string, cacheCfg *cache.Config, maxRetries int, coll
 	}
 	cache.AddCaching(sess, cacheCfg)
 	cacheCfg.SetCacheTTL("eks", "DescribeCluster", DescribeClusterTTL)
+	cacheCfg.SetCacheTTL("eks", "ListClusters", ListClustersTTL)
 	cacheCfg.SetCacheTTL("eks", "DescribeNodegroup", DescribeNodegroupTTL)
+	cacheCfg.SetCacheTTL("eks", "DescribeAccessEntry", DescribeAccessEntryTTL)
 	sess.Handlers.Complete.PushFront(func(r *request.Request) {
 		ctx := r.HTTPRequest.Context()
 		log.V(1).Info("AWS API call",
@@ -83,6 +89,63 @@ func (w *AwsWorker) DescribeEKSCluster(clusterName string) (*eks.Cluster, error)
 	return output.Cluster, nil
 }
 
+// ListEKSClusters returns the names of all EKS clusters in the region
+func (w *AwsWorker) ListEKSClusters() ([]string, error) {
+	clusters := []string{}
+	err := w.EksClient.ListClustersPages(&eks.ListClustersInput{}, func(page *eks.ListClustersOutput, lastPage bool) bool {
+		clusters = append(clusters, aws.StringValueSlice(page.Clusters)...)
+		return page.NextToken != nil
+	})
+	if err != nil {
+		return clusters, err
+	}
+	return clusters, nil
+}
+
+const (
+	// AccessEntryTypeLinux is the access entry type of node roles of Linux nodes
+	AccessEntryTypeLinux = "EC2_LINUX"
+	// AccessEntryTypeWindows is the access entry type of node roles of Windows nodes
+	AccessEntryTypeWindows = "EC2_WINDOWS"
+)
+
+// DescribeAccessEntry returns the access entry of a principal in a cluster, or nil if the principal has no access entry
+func (w *AwsWorker) DescribeAccessEntry(clusterName, principalArn string) (*eks.AccessEntry, error) {
+	output, err := w.EksClient.DescribeAccessEntry(&eks.DescribeAccessEntryInput{
+		ClusterName:  aws.String(clusterName),
+		PrincipalArn: aws.String(principalArn),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == eks.ErrCodeResourceNotFoundException {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return output.AccessEntry, nil
+}
+
+// CreateAccessEntry creates an access entry of a type for a principal in a cluster
+func (w *AwsWorker) CreateAccessEntry(clusterName, principalArn, entryType string) error {
+	_, err := w.EksClient.CreateAccessEntry(&eks.CreateAccessEntryInput{
+		ClusterName:  aws.String(clusterName),
+		PrincipalArn: aws.String(principalArn),
+		Type:         aws.String(entryType),
+	})
+	return err
+}
+
+// DeleteAccessEntry deletes the access entry of a principal in a cluster, access entries which do not exist are ignored
+func (w *AwsWorker) DeleteAccessEntry(clusterName, principalArn string) error {
+	_, err := w.EksClient.DeleteAccessEntry(&eks.DeleteAccessEntryInput{
+		ClusterName:  aws.String(clusterName),
+		PrincipalArn: aws.String(principalArn),
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == eks.ErrCodeResourceNotFoundException {
+		return nil
+	}
+	return err
+}
+
 // TODO: Rename - GetNodeGroup
 func (w *AwsWorker) GetSelfNodeGroup() (*eks.Nodegroup, error) {
 	input := &eks.DescribeNodegroupInput{
@@ -16,10 +16,12 @@ limitations under the License.
 package aws
 
 import (
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -30,8 +32,11 @@ import (
 )
 
 // GetAwsIAMClient returns an IAM client
-func GetAwsIamClient(region string, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) iamiface.IAMAPI {
+func GetAwsIamClient(region string, creds *credentials.Credentials, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) iamiface.IAMAPI {
 	config := aws.NewConfig().WithRegion(region).WithCredentialsChainVerboseErrors(true)
+	if creds != nil {
+		config = config.WithCredentials(creds)
+	}
 	config = request.WithRetryer(config, NewRetryLogger(maxRetries, collector))
 	sess, err := session.NewSession(config)
 	if err != nil {
@@ -174,6 +179,125 @@ func (w *AwsWorker) ListRolePolicies(name string) ([]*iam.AttachedPolicy, error)
 	return policies, nil
 }
 
+// ListRoleInlinePolicies returns the names of the inline policies of a role
+func (w *AwsWorker) ListRoleInlinePolicies(name string) ([]string, error) {
+	policies := []string{}
+	err := w.IamClient.ListRolePoliciesPages(
+		&iam.ListRolePoliciesInput{
+			RoleName: aws.String(name),
+		},
+		func(page *iam.ListRolePoliciesOutput, lastPage bool) bool {
+			policies = append(policies, aws.StringValueSlice(page.PolicyNames)...)
+			return page.Marker != nil
+		})
+	if err != nil {
+		return policies, err
+	}
+	return policies, nil
+}
+
+// PutRoleInlinePolicy creates or replaces an inline policy of a role
+func (w *AwsWorker) PutRoleInlinePolicy(name, policyName, document string) error {
+	_, err := w.IamClient.PutRolePolicy(&iam.PutRolePolicyInput{
+		RoleName:       aws.String(name),
+		PolicyName:     aws.String(policyName),
+		PolicyDocument: aws.String(document),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to put role policy")
+	}
+	return nil
+}
+
+// DeleteRoleInlinePolicy deletes an inline policy of a role, it does not fail if the policy does not exist
+func (w *AwsWorker) DeleteRoleInlinePolicy(name, policyName string) error {
+	_, err := w.IamClient.DeleteRolePolicy(&iam.DeleteRolePolicyInput{
+		RoleName:   aws.String(name),
+		PolicyName: aws.String(policyName),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+			return nil
+		}
+		return errors.Wrap(err, "failed to delete role policy")
+	}
+	return nil
+}
+
+func (w *AwsWorker) TagRole(name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := w.IamClient.TagRole(&iam.TagRoleInput{
+		RoleName: aws.String(name),
+		Tags:     NewIAMTags(tags),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to tag role")
+	}
+	return nil
+}
+
+func (w *AwsWorker) UntagRole(name string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := w.IamClient.UntagRole(&iam.UntagRoleInput{
+		RoleName: aws.String(name),
+		TagKeys:  aws.StringSlice(keys),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to untag role")
+	}
+	return nil
+}
+
+func (w *AwsWorker) TagInstanceProfile(name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := w.IamClient.TagInstanceProfile(&iam.TagInstanceProfileInput{
+		InstanceProfileName: aws.String(name),
+		Tags:                NewIAMTags(tags),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to tag instance-profile")
+	}
+	return nil
+}
+
+func (w *AwsWorker) UntagInstanceProfile(name string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := w.IamClient.UntagInstanceProfile(&iam.UntagInstanceProfileInput{
+		InstanceProfileName: aws.String(name),
+		TagKeys:             aws.StringSlice(keys),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to untag instance-profile")
+	}
+	return nil
+}
+
+// NewIAMTags converts a map of tags to IAM tags sorted by key
+func NewIAMTags(tags map[string]string) []*iam.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	iamTags := make([]*iam.Tag, 0, len(keys))
+	for _, k := range keys {
+		iamTags = append(iamTags, &iam.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(tags[k]),
+		})
+	}
+	return iamTags
+}
+
 func (w *AwsWorker) CreateScalingGroupRole(name string) (*iam.Role, *iam.InstanceProfile, error) {
 	var (
 		assumeRolePolicyDocument = `{
@@ -16,9 +16,13 @@ limitations under the License.
 package aws
 
 import (
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/pkg/errors"
 )
 
 func IsUsingLaunchConfiguration(group *autoscaling.Group) bool {
@@ -29,6 +33,22 @@ func IsUsingLaunchTemplate(group *autoscaling.Group) bool {
 	return group.LaunchTemplate != nil && group.LaunchTemplate.LaunchTemplateName != nil
 }
 
+// IsInvalidInstanceProfileErr returns true if a launch configuration or scaling group was rejected because its instance
+// profile is invalid, which is the case for missing instance profiles as well as ones which have not yet propagated
+func IsInvalidInstanceProfileErr(err error) bool {
+	aerr, ok := errors.Cause(err).(awserr.Error)
+	if !ok {
+		return false
+	}
+
+	switch aerr.Code() {
+	case "ValidationError", "InvalidParameterValue":
+		message := strings.ToLower(aerr.Message())
+		return strings.Contains(message, "invalid iaminstanceprofile") || strings.Contains(message, "invalid iam instance profile")
+	}
+	return false
+}
+
 type ManagedNodeGroupReconcileState struct {
 	OngoingState             bool
 	FiniteState              bool
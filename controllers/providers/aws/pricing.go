@@ -0,0 +1,138 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// HoursPerMonth is the average number of hours in a month used for cost estimates
+	HoursPerMonth = 730
+	// DefaultSpotPriceRatio is the fraction of the on-demand price spot instances are estimated at when the pricing
+	// table has no spot price for an instance type
+	DefaultSpotPriceRatio = 0.4
+	// PricingCurrency is the currency of pricing table prices
+	PricingCurrency = "USD"
+)
+
+// InstancePrice is the hourly price of an instance type
+type InstancePrice struct {
+	OnDemand float64 `json:"onDemand"`
+	Spot     float64 `json:"spot,omitempty"`
+}
+
+// GetSpot returns the spot price, or an estimate derived from the on-demand price if no spot price is known
+func (p InstancePrice) GetSpot() float64 {
+	if p.Spot > 0 {
+		return p.Spot
+	}
+	return p.OnDemand * DefaultSpotPriceRatio
+}
+
+// PricingTable maps instance types to their hourly prices
+type PricingTable map[string]InstancePrice
+
+// DefaultPricingTable are the bundled on-demand Linux prices of common instance types in us-east-1
+var DefaultPricingTable = PricingTable{
+	"t3.small":    {OnDemand: 0.0208},
+	"t3.medium":   {OnDemand: 0.0416},
+	"t3.large":    {OnDemand: 0.0832},
+	"t3.xlarge":   {OnDemand: 0.1664},
+	"t3.2xlarge":  {OnDemand: 0.3328},
+	"m5.large":    {OnDemand: 0.096},
+	"m5.xlarge":   {OnDemand: 0.192},
+	"m5.2xlarge":  {OnDemand: 0.384},
+	"m5.4xlarge":  {OnDemand: 0.768},
+	"m5.8xlarge":  {OnDemand: 1.536},
+	"m5.12xlarge": {OnDemand: 2.304},
+	"m5.16xlarge": {OnDemand: 3.072},
+	"m5.24xlarge": {OnDemand: 4.608},
+	"m6i.large":   {OnDemand: 0.096},
+	"m6i.xlarge":  {OnDemand: 0.192},
+	"m6i.2xlarge": {OnDemand: 0.384},
+	"m6i.4xlarge": {OnDemand: 0.768},
+	"m6i.8xlarge": {OnDemand: 1.536},
+	"m6g.large":   {OnDemand: 0.077},
+	"m6g.xlarge":  {OnDemand: 0.154},
+	"m6g.2xlarge": {OnDemand: 0.308},
+	"m6g.4xlarge": {OnDemand: 0.616},
+	"m7i.large":   {OnDemand: 0.1008},
+	"m7i.xlarge":  {OnDemand: 0.2016},
+	"m7i.2xlarge": {OnDemand: 0.4032},
+	"m7i.4xlarge": {OnDemand: 0.8064},
+	"c5.large":    {OnDemand: 0.085},
+	"c5.xlarge":   {OnDemand: 0.17},
+	"c5.2xlarge":  {OnDemand: 0.34},
+	"c5.4xlarge":  {OnDemand: 0.68},
+	"c5.9xlarge":  {OnDemand: 1.53},
+	"c6i.large":   {OnDemand: 0.085},
+	"c6i.xlarge":  {OnDemand: 0.17},
+	"c6i.2xlarge": {OnDemand: 0.34},
+	"c6i.4xlarge": {OnDemand: 0.68},
+	"c6g.large":   {OnDemand: 0.068},
+	"c6g.xlarge":  {OnDemand: 0.136},
+	"c6g.2xlarge": {OnDemand: 0.272},
+	"c6g.4xlarge": {OnDemand: 0.544},
+	"r5.large":    {OnDemand: 0.126},
+	"r5.xlarge":   {OnDemand: 0.252},
+	"r5.2xlarge":  {OnDemand: 0.504},
+	"r5.4xlarge":  {OnDemand: 1.008},
+	"r5.8xlarge":  {OnDemand: 2.016},
+	"r6i.large":   {OnDemand: 0.126},
+	"r6i.xlarge":  {OnDemand: 0.252},
+	"r6i.2xlarge": {OnDemand: 0.504},
+	"r6i.4xlarge": {OnDemand: 1.008},
+	"r6g.large":   {OnDemand: 0.1008},
+	"r6g.xlarge":  {OnDemand: 0.2016},
+	"r6g.2xlarge": {OnDemand: 0.4032},
+	"r6g.4xlarge": {OnDemand: 0.8064},
+}
+
+// LoadPricingTable reads a JSON pricing table from a file, its prices override the bundled prices of the same
+// instance types
+func LoadPricingTable(path string) (PricingTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read pricing table %v", path)
+	}
+
+	custom := make(PricingTable)
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse pricing table %v", path)
+	}
+
+	table := make(PricingTable)
+	for instanceType, price := range DefaultPricingTable {
+		table[instanceType] = price
+	}
+	for instanceType, price := range custom {
+		if price.OnDemand <= 0 || price.Spot < 0 {
+			return nil, errors.Errorf("invalid price of instance type %v in pricing table %v, prices must be positive", instanceType, path)
+		}
+		table[instanceType] = price
+	}
+	return table, nil
+}
+
+// Get returns the price of an instance type
+func (t PricingTable) Get(instanceType string) (InstancePrice, bool) {
+	price, ok := t[instanceType]
+	return price, ok
+}
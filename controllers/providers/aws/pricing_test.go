@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestLoadPricingTable(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		content   string
+		shouldErr bool
+		expected  map[string]InstancePrice
+	}{
+		{
+			content: `{"m5.large": {"onDemand": 0.107, "spot": 0.04}, "x2idn.16xlarge": {"onDemand": 6.669}}`,
+			expected: map[string]InstancePrice{
+				"m5.large":       {OnDemand: 0.107, Spot: 0.04},
+				"x2idn.16xlarge": {OnDemand: 6.669},
+				"c5.large":       DefaultPricingTable["c5.large"],
+			},
+		},
+		{content: `{"m5.large": 0.107}`, shouldErr: true},
+		{content: `{"m5.large": {"spot": 0.04}}`, shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %v", i, tc.content)
+		path := filepath.Join(dir, "pricing.json")
+		g.Expect(os.WriteFile(path, []byte(tc.content), 0600)).To(gomega.Succeed())
+
+		table, err := LoadPricingTable(path)
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		for instanceType, expected := range tc.expected {
+			price, ok := table.Get(instanceType)
+			g.Expect(ok).To(gomega.BeTrue())
+			g.Expect(price).To(gomega.Equal(expected))
+		}
+	}
+
+	_, err := LoadPricingTable(filepath.Join(dir, "missing.json"))
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// spot prices are estimated from on-demand prices when unknown
+	g.Expect(InstancePrice{OnDemand: 0.1}.GetSpot()).To(gomega.BeNumerically("~", 0.04, 0.0001))
+	g.Expect(InstancePrice{OnDemand: 0.1, Spot: 0.03}.GetSpot()).To(gomega.Equal(0.03))
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ssm"
@@ -44,8 +46,11 @@ var (
 	}
 )
 
-func GetAwsSsmClient(region string, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) ssmiface.SSMAPI {
+func GetAwsSsmClient(region string, creds *credentials.Credentials, cacheCfg *cache.Config, maxRetries int, collector *common.MetricsCollector) ssmiface.SSMAPI {
 	config := aws.NewConfig().WithRegion(region).WithCredentialsChainVerboseErrors(true)
+	if creds != nil {
+		config = config.WithCredentials(creds)
+	}
 	config = request.WithRetryer(config, NewRetryLogger(maxRetries, collector))
 	sess, err := session.NewSession(config)
 	if err != nil {
@@ -83,3 +88,42 @@ func (w *AwsWorker) GetEksSsmAmi(OSFamily string, arch string, kubernetesVersion
 	}
 	return aws.StringValue(output.Parameter.Value), nil
 }
+
+// GetSecureParameter returns the decrypted value of a parameter, or an empty string if the parameter does not exist
+func (w *AwsWorker) GetSecureParameter(name string) (string, error) {
+	output, err := w.SsmClient.GetParameter(&ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return aws.StringValue(output.Parameter.Value), nil
+}
+
+// PutSecureParameter creates or overwrites a parameter encrypted with the account's default SSM key
+func (w *AwsWorker) PutSecureParameter(name, value string) error {
+	_, err := w.SsmClient.PutParameter(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      aws.String(ssm.ParameterTypeSecureString),
+		Overwrite: aws.Bool(true),
+	})
+	return err
+}
+
+func (w *AwsWorker) DeleteParameter(name string) error {
+	_, err := w.SsmClient.DeleteParameter(&ssm.DeleteParameterInput{
+		Name: aws.String(name),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultCallTimeout is the deadline of an AWS API call, including its retries
+	DefaultCallTimeout = 5 * time.Minute
+
+	callTimeoutHandlerName = "instancemgr.CallTimeoutHandler"
+)
+
+// WithCallTimeout returns a copy of the worker whose AWS API calls are abandoned once they did not complete within the
+// timeout, a timeout which is not positive removes the deadline. Calls which are retried share a single deadline, and
+// clients which are not AWS SDK clients are not copied
+func (w AwsWorker) WithCallTimeout(timeout time.Duration) AwsWorker {
+	if c, ok := w.AsgClient.(*autoscaling.AutoScaling); ok {
+		w.AsgClient = &autoscaling.AutoScaling{Client: withCallTimeout(c.Client, timeout)}
+	}
+	if c, ok := w.EksClient.(*eks.EKS); ok {
+		w.EksClient = &eks.EKS{Client: withCallTimeout(c.Client, timeout)}
+	}
+	if c, ok := w.IamClient.(*iam.IAM); ok {
+		w.IamClient = &iam.IAM{Client: withCallTimeout(c.Client, timeout)}
+	}
+	if c, ok := w.Ec2Client.(*ec2.EC2); ok {
+		w.Ec2Client = &ec2.EC2{Client: withCallTimeout(c.Client, timeout)}
+	}
+	if c, ok := w.SsmClient.(*ssm.SSM); ok {
+		w.SsmClient = &ssm.SSM{Client: withCallTimeout(c.Client, timeout)}
+	}
+	w.callTimeout = timeout
+	return w
+}
+
+func withCallTimeout(c *client.Client, timeout time.Duration) *client.Client {
+	timed := *c
+	timed.Handlers = c.Handlers.Copy()
+	timed.Handlers.Validate.RemoveByName(callTimeoutHandlerName)
+	if timeout <= 0 {
+		return &timed
+	}
+
+	timed.Handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: callTimeoutHandlerName,
+		Fn: func(r *request.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			r.SetContext(ctx)
+			r.Handlers.Complete.PushBack(func(*request.Request) { cancel() })
+		},
+	})
+	return &timed
+}
+
+// IsCallTimeout returns true if the error is caused by an AWS API call which was abandoned at its deadline
+func IsCallTimeout(err error) bool {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		return aerr.Code() == request.CanceledErrorCode && aerr.OrigErr() == context.DeadlineExceeded
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+// MockSlowAwsServer returns an AWS API endpoint which responds after the delay
+func MockSlowAwsServer(t *testing.T, delay time.Duration) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the request is canceled once the client abandons it only after its body was read
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet/></DescribeInstancesResponse>`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func mockSlowAwsWorker(endpoint string) AwsWorker {
+	config := aws.NewConfig().WithRegion("us-west-2").WithEndpoint(endpoint).WithCredentials(credentials.NewStaticCredentials("id", "secret", "")).WithMaxRetries(1)
+	sess := session.Must(session.NewSession(config))
+	return AwsWorker{
+		Ec2Client: ec2.New(sess),
+		AsgClient: autoscaling.New(sess),
+	}
+}
+
+func TestWithCallTimeout(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := MockSlowAwsServer(t, 2*time.Second)
+	worker := mockSlowAwsWorker(server.URL)
+
+	// slow calls are abandoned at the deadline
+	timed := worker.WithCallTimeout(100 * time.Millisecond)
+	start := time.Now()
+	_, err := timed.Ec2Client.DescribeInstances(&ec2.DescribeInstancesInput{})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second))
+	g.Expect(IsCallTimeout(err)).To(gomega.BeTrue())
+	g.Expect(IsCallTimeout(errors.Wrap(err, "failed to describe instances"))).To(gomega.BeTrue())
+
+	// the worker the timed worker was copied from is not modified
+	g.Expect(timed.Ec2Client).NotTo(gomega.BeIdenticalTo(worker.Ec2Client))
+	g.Expect(timed.AsgClient).NotTo(gomega.BeIdenticalTo(worker.AsgClient))
+	g.Expect(worker.Ec2Client.(*ec2.EC2).Handlers.Validate.Len()).To(gomega.Equal(timed.Ec2Client.(*ec2.EC2).Handlers.Validate.Len() - 1))
+
+	// calls which complete within the deadline succeed
+	server = MockSlowAwsServer(t, 0)
+	timed = mockSlowAwsWorker(server.URL).WithCallTimeout(time.Second)
+	_, err = timed.Ec2Client.DescribeInstances(&ec2.DescribeInstancesInput{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// a later timeout replaces the deadline, and a timeout which is not positive removes it
+	timed = worker.WithCallTimeout(time.Minute).WithCallTimeout(100 * time.Millisecond)
+	g.Expect(timed.Ec2Client.(*ec2.EC2).Handlers.Validate.Len()).To(gomega.Equal(worker.Ec2Client.(*ec2.EC2).Handlers.Validate.Len() + 1))
+	g.Expect(worker.WithCallTimeout(time.Minute).WithCallTimeout(0).Ec2Client.(*ec2.EC2).Handlers.Validate.Len()).To(gomega.Equal(worker.Ec2Client.(*ec2.EC2).Handlers.Validate.Len()))
+
+	// clients which are not AWS SDK clients are not copied
+	mockClient := &batchEc2Client{}
+	g.Expect(AwsWorker{Ec2Client: mockClient}.WithCallTimeout(time.Second).Ec2Client).To(gomega.BeIdenticalTo(mockClient))
+}
+
+func TestIsCallTimeout(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(IsCallTimeout(nil)).To(gomega.BeFalse())
+	g.Expect(IsCallTimeout(errors.New("some-error"))).To(gomega.BeFalse())
+
+	// calls canceled for other reasons than their deadline are not timeouts
+	server := MockSlowAwsServer(t, 2*time.Second)
+	worker := mockSlowAwsWorker(server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := worker.Ec2Client.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(IsCallTimeout(err)).To(gomega.BeFalse())
+}
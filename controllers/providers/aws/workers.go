@@ -0,0 +1,123 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/keikoproj/aws-sdk-go-cache/cache"
+	"github.com/keikoproj/instance-manager/controllers/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AssumeRoleSessionName is the session name of roles assumed by the controller
+	AssumeRoleSessionName = "instance-manager"
+	// AssumeRoleDuration is how long assumed role credentials are valid for
+	AssumeRoleDuration = 1 * time.Hour
+	// AssumeRoleExpiryWindow is how long before expiry assumed role credentials are refreshed
+	AssumeRoleExpiryWindow = 5 * time.Minute
+)
+
+// AwsWorkers creates AwsWorkers which call AWS APIs in another region or as an assumed role, workers are cached per
+// region and role so that assumed role credentials are only refreshed when they are about to expire
+type AwsWorkers struct {
+	// Region is the controller's region, used when no region is requested
+	Region      string
+	MaxRetries  int
+	Collector   *common.MetricsCollector
+	Ec2Metadata *ec2metadata.EC2Metadata
+	// NewCredentials returns the credentials of an assumed role, defaults to assuming the role with STS
+	NewCredentials func(roleArn string) *credentials.Credentials
+	// DescribeBatchWindow and DescribeBatchSize configure the describe batching of created workers
+	DescribeBatchWindow time.Duration
+	DescribeBatchSize   int
+	// CallTimeout is the default deadline of the AWS API calls of created workers, zero disables it
+	CallTimeout time.Duration
+
+	workers map[string]AwsWorker
+	lock    sync.Mutex
+}
+
+// NewAwsWorkers returns AwsWorkers which assume roles with the controller's credentials
+func NewAwsWorkers(region string, maxRetries int, collector *common.MetricsCollector, metadata *ec2metadata.EC2Metadata) *AwsWorkers {
+	return &AwsWorkers{
+		Region:         region,
+		MaxRetries:     maxRetries,
+		Collector:      collector,
+		Ec2Metadata:    metadata,
+		NewCredentials: newAssumeRoleCredentials(region),
+	}
+}
+
+func newAssumeRoleCredentials(region string) func(roleArn string) *credentials.Credentials {
+	return func(roleArn string) *credentials.Credentials {
+		config := aws.NewConfig().WithRegion(region).WithCredentialsChainVerboseErrors(true)
+		sess := session.Must(session.NewSession(config))
+		return stscreds.NewCredentials(sess, roleArn, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = AssumeRoleSessionName
+			p.Duration = AssumeRoleDuration
+			p.ExpiryWindow = AssumeRoleExpiryWindow
+		})
+	}
+}
+
+// Get returns the AwsWorker of a region and role, an empty region is the controller's region and an empty role uses
+// the controller's credentials. Roles are assumed when their worker is created to validate they can be assumed
+func (a *AwsWorkers) Get(region, roleArn string) (AwsWorker, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if common.StringEmpty(region) {
+		region = a.Region
+	}
+	key := region + "/" + roleArn
+	if worker, ok := a.workers[key]; ok {
+		return worker, nil
+	}
+
+	var creds *credentials.Credentials
+	if !common.StringEmpty(roleArn) {
+		creds = a.NewCredentials(roleArn)
+		if _, err := creds.Get(); err != nil {
+			return AwsWorker{}, errors.Wrapf(err, "failed to assume role %v", roleArn)
+		}
+	}
+
+	// responses are cached per worker so that they are not shared across regions and accounts
+	cacheCfg := cache.NewConfig(CacheDefaultTTL, CacheBackgroundPruningInterval, CacheMaxItems, CacheItemsToPrune)
+	worker := AwsWorker{
+		Ec2Client:   GetAwsEc2Client(region, creds, cacheCfg, a.MaxRetries, a.Collector),
+		IamClient:   GetAwsIamClient(region, creds, cacheCfg, a.MaxRetries, a.Collector),
+		AsgClient:   GetAwsAsgClient(region, creds, cacheCfg, a.MaxRetries, a.Collector),
+		EksClient:   GetAwsEksClient(region, creds, cacheCfg, a.MaxRetries, a.Collector),
+		SsmClient:   GetAwsSsmClient(region, creds, cacheCfg, a.MaxRetries, a.Collector),
+		Ec2Metadata: a.Ec2Metadata,
+	}.WithCallTimeout(a.CallTimeout)
+	worker.EnableDescribeBatching(a.DescribeBatchWindow, a.DescribeBatchSize)
+
+	if a.workers == nil {
+		a.workers = make(map[string]AwsWorker)
+	}
+	a.workers[key] = worker
+	return worker, nil
+}
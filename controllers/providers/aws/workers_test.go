@@ -0,0 +1,98 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+type failingProvider struct{}
+
+func (p *failingProvider) Retrieve() (credentials.Value, error) {
+	return credentials.Value{}, errors.New("AccessDenied: not authorized to perform sts:AssumeRole")
+}
+
+func (p *failingProvider) IsExpired() bool {
+	return true
+}
+
+func TestAwsWorkers(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		roleArn      = "arn:aws:iam::123456789012:role/instance-manager"
+		otherRoleArn = "arn:aws:iam::210987654321:role/instance-manager"
+		deniedArn    = "arn:aws:iam::123456789012:role/denied"
+		assumed      = make(map[string]int)
+	)
+
+	workers := NewAwsWorkers("us-west-2", 3, nil, nil)
+	workers.NewCredentials = func(arn string) *credentials.Credentials {
+		assumed[arn]++
+		if arn == deniedArn {
+			return credentials.NewCredentials(&failingProvider{})
+		}
+		return credentials.NewStaticCredentials(arn, "secret", "")
+	}
+
+	worker, err := workers.Get("", roleArn)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// every client of the worker calls AWS APIs with the assumed role credentials, in the controller's region
+	for _, config := range workerConfigs(worker) {
+		value, err := config.Credentials.Get()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(value.AccessKeyID).To(gomega.Equal(roleArn))
+		g.Expect(aws.StringValue(config.Region)).To(gomega.Equal("us-west-2"))
+	}
+
+	// workers are cached per role
+	cached, err := workers.Get("", roleArn)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cached.AsgClient).To(gomega.BeIdenticalTo(worker.AsgClient))
+	g.Expect(assumed[roleArn]).To(gomega.Equal(1))
+
+	other, err := workers.Get("", otherRoleArn)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	value, err := other.AsgClient.(*autoscaling.AutoScaling).Config.Credentials.Get()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(value.AccessKeyID).To(gomega.Equal(otherRoleArn))
+
+	// workers of other regions call AWS APIs in that region
+	regional, err := workers.Get("eu-west-1", "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	for _, config := range workerConfigs(regional) {
+		g.Expect(aws.StringValue(config.Region)).To(gomega.Equal("eu-west-1"))
+	}
+	g.Expect(assumed).NotTo(gomega.HaveKey(""))
+
+	regionalRole, err := workers.Get("eu-west-1", roleArn)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(regionalRole.AsgClient).NotTo(gomega.BeIdenticalTo(worker.AsgClient))
+	g.Expect(aws.StringValue(regionalRole.AsgClient.(*autoscaling.AutoScaling).Config.Region)).To(gomega.Equal("eu-west-1"))
+
+	// roles which cannot be assumed fail and are retried on the next call
+	_, err = workers.Get("", deniedArn)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("failed to assume role " + deniedArn)))
+	_, err = workers.Get("", deniedArn)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(assumed[deniedArn]).To(gomega.Equal(2))
+}
+
+func workerConfigs(w AwsWorker) []aws.Config {
+	return []aws.Config{
+		w.AsgClient.(*autoscaling.AutoScaling).Config,
+		w.Ec2Client.(*ec2.EC2).Config,
+		w.EksClient.(*eks.EKS).Config,
+		w.IamClient.(*iam.IAM).Config,
+		w.SsmClient.(*ssm.SSM).Config,
+	}
+}
@@ -79,7 +79,11 @@ func ProcessCRDStrategy(kube dynamic.Interface, instanceGroup *v1alpha1.Instance
 		launchID = common.GetLastElementBy(configName, "-")
 	} else if spec.IsLaunchTemplate() {
 		templateID := common.GetLastElementBy(configName, "-")
-		version := status.GetLatestTemplateVersion()
+		// the active version differs from the latest version when rolled back to a pinned version
+		version := status.GetActiveLaunchTemplateVersion()
+		if common.StringEmpty(version) {
+			version = status.GetLatestTemplateVersion()
+		}
 		if common.StringEmpty(version) {
 			version = "0"
 		}
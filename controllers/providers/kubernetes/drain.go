@@ -0,0 +1,269 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// DrainStartedAnnotation records when the controller cordoned a node to drain it
+	DrainStartedAnnotation = "instancemgr.keikoproj.io/drain-started"
+	// SafeToEvictAnnotation is the cluster-autoscaler annotation pods use to opt out of eviction
+	SafeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+)
+
+// DrainOptions control which pods are evicted when a node is drained
+type DrainOptions struct {
+	// EvictDaemonSets are DaemonSets, by name or namespace/name, whose pods are evicted, pods of other DaemonSets are ignored
+	EvictDaemonSets []string
+	// Timeout is how long blocking pods are waited on, zero waits indefinitely
+	Timeout time.Duration
+	// Force deletes blocking pods once the timeout is exceeded instead of failing the drain
+	Force bool
+	// PriorityOrdering evicts pods in order of their priority, lowest first, pods are only evicted once no pods of a
+	// lower priority remain on the node
+	PriorityOrdering bool
+	// IgnoreTerminating does not wait on pods which are terminating, the kubelet of a NotReady node cannot confirm that
+	// its pods terminated
+	IgnoreTerminating bool
+}
+
+// ShouldEvictPod returns true if the pod must be evicted before its node is considered drained, mirror pods,
+// completed pods and pods of DaemonSets which are not listed in the drain options are ignored
+func ShouldEvictPod(pod corev1.Pod, opts *DrainOptions) bool {
+	if _, ok := pod.GetAnnotations()[corev1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+
+	if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+		if opts == nil {
+			return false
+		}
+		for _, daemonSet := range opts.EvictDaemonSets {
+			if IsDaemonSetPodOf(pod, daemonSet) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// IsSafeToEvictDisabled returns true if the pod opted out of eviction with the cluster-autoscaler safe-to-evict annotation
+func IsSafeToEvictDisabled(pod corev1.Pod) bool {
+	return HasAnnotationWithValue(pod.GetAnnotations(), SafeToEvictAnnotation, "false")
+}
+
+// PodPriority returns the priority the pod was admitted with, pods without a priority have the default priority of zero
+func PodPriority(pod corev1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+// LowestPriorityPods returns the pods of the lowest priority, sorted by name, these are evicted first by drains which
+// are ordered by priority
+func LowestPriorityPods(pods []corev1.Pod) []corev1.Pod {
+	lowest := make([]corev1.Pod, 0)
+	for _, pod := range pods {
+		switch {
+		case len(lowest) == 0 || PodPriority(pod) < PodPriority(lowest[0]):
+			lowest = []corev1.Pod{pod}
+		case PodPriority(pod) == PodPriority(lowest[0]):
+			lowest = append(lowest, pod)
+		}
+	}
+	sort.SliceStable(lowest, func(i, j int) bool {
+		return podName(lowest[i]) < podName(lowest[j])
+	})
+	return lowest
+}
+
+// uncordonDrainedNode uncordons a node whose drain was aborted and removes its drain started annotation
+func (k KubernetesClientSet) uncordonDrainedNode(nodeName string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{DrainStartedAnnotation: nil},
+		},
+		"spec": map[string]interface{}{
+			"unschedulable": false,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := k.Kubernetes.CoreV1().Nodes().Patch(context.Background(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to uncordon node %v", nodeName)
+	}
+	return nil
+}
+
+// DrainTimeoutError is returned when pods blocked the drain of a node for longer than the drain timeout
+type DrainTimeoutError struct {
+	Node    string
+	Timeout time.Duration
+	Pods    []string
+}
+
+func (e *DrainTimeoutError) Error() string {
+	return fmt.Sprintf("drain of node %v exceeded timeout %v, blocked by pods %v", e.Node, e.Timeout, e.Pods)
+}
+
+// DrainNode cordons a node and evicts its pods, it returns true once no pods which should be evicted remain on the node.
+// Evictions are not waited on, pods which are terminating or blocking are retried on the next call. Pods are blocking
+// when their eviction is rejected by a disruption budget or they are annotated as not safe to evict, once blocking pods
+// exceed the drain timeout they are deleted if the drain is forced, otherwise the node is uncordoned so it does not stay
+// unschedulable and a DrainTimeoutError is returned, a later call restarts the drain. Drains ordered by priority only evict the pods of the lowest priority remaining on the node, including terminating pods
+func (k KubernetesClientSet) DrainNode(nodeName string, opts *DrainOptions) (bool, error) {
+	if opts == nil {
+		opts = &DrainOptions{}
+	}
+
+	node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, errors.Wrapf(err, "failed to get node %v", nodeName)
+	}
+
+	started, err := time.Parse(time.RFC3339, node.GetAnnotations()[DrainStartedAnnotation])
+	if !node.Spec.Unschedulable || err != nil {
+		started = time.Now()
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{DrainStartedAnnotation: started.UTC().Format(time.RFC3339)},
+			},
+			"spec": map[string]interface{}{
+				"unschedulable": true,
+			},
+		})
+		if err != nil {
+			return false, err
+		}
+		if _, err := k.Kubernetes.CoreV1().Nodes().Patch(context.Background(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return false, errors.Wrapf(err, "failed to cordon node %v", nodeName)
+		}
+	}
+	timedOut := opts.Timeout > 0 && time.Since(started) > opts.Timeout
+
+	pods, err := k.Kubernetes.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list pods of node %v", nodeName)
+	}
+
+	var (
+		remaining = make([]corev1.Pod, 0)
+		blocking  = make([]corev1.Pod, 0)
+	)
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName || !ShouldEvictPod(pod, opts) {
+			continue
+		}
+		if opts.IgnoreTerminating && pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		remaining = append(remaining, pod)
+	}
+
+	evictable := remaining
+	if opts.PriorityOrdering {
+		evictable = LowestPriorityPods(remaining)
+	}
+
+	gone := 0
+	for _, pod := range evictable {
+		if pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		if IsSafeToEvictDisabled(pod) {
+			blocking = append(blocking, pod)
+			continue
+		}
+
+		err := k.Kubernetes.CoreV1().Pods(pod.GetNamespace()).EvictV1(context.Background(), &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod.GetName(),
+				Namespace: pod.GetNamespace(),
+			},
+		})
+		switch {
+		case err == nil:
+			log.Info("evicted pod", "node", nodeName, "pod", podName(pod))
+		case kerrors.IsNotFound(err):
+			gone++
+		case kerrors.IsTooManyRequests(err):
+			blocking = append(blocking, pod)
+		default:
+			return false, errors.Wrapf(err, "failed to evict pod %v", podName(pod))
+		}
+	}
+
+	if len(blocking) == 0 {
+		return len(remaining) == gone, nil
+	}
+
+	names := make([]string, 0, len(blocking))
+	for _, pod := range blocking {
+		names = append(names, podName(pod))
+	}
+
+	if !timedOut {
+		log.Info("drain is waiting on blocking pods", "node", nodeName, "pods", names)
+		return false, nil
+	}
+
+	if !opts.Force {
+		if err := k.uncordonDrainedNode(nodeName); err != nil {
+			return false, err
+		}
+		return false, &DrainTimeoutError{Node: nodeName, Timeout: opts.Timeout, Pods: names}
+	}
+
+	log.Info("drain timeout exceeded, deleting blocking pods", "node", nodeName, "pods", names)
+	for _, pod := range blocking {
+		err := k.Kubernetes.CoreV1().Pods(pod.GetNamespace()).Delete(context.Background(), pod.GetName(), metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "failed to delete pod %v", podName(pod))
+		}
+	}
+	return false, nil
+}
+
+func podName(pod corev1.Pod) string {
+	return fmt.Sprintf("%v/%v", pod.GetNamespace(), pod.GetName())
+}
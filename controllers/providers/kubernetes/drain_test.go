@@ -0,0 +1,268 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func mockDrainPod(namespace, name, node, daemonSet string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+	if daemonSet != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "DaemonSet", Name: daemonSet, Controller: &controller},
+		}
+	}
+	return pod
+}
+
+// MockDrainClient returns a client set which deletes pods when they are evicted, and records the evicted pods
+func MockDrainClient(objects ...runtime.Object) (KubernetesClientSet, *[]string) {
+	evicted := make([]string, 0)
+	client := fake.NewSimpleClientset(objects...)
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create := action.(k8stesting.CreateAction)
+		if create.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := create.GetObject().(*policyv1.Eviction)
+		evicted = append(evicted, eviction.GetNamespace()+"/"+eviction.GetName())
+		err := client.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.GetNamespace(), eviction.GetName())
+		return true, nil, err
+	})
+	return KubernetesClientSet{Kubernetes: client}, &evicted
+}
+
+func TestShouldEvictPod(t *testing.T) {
+	mirror := mockDrainPod("kube-system", "kube-proxy", "node-1", "")
+	mirror.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "mirror"}
+	completed := mockDrainPod("default", "job", "node-1", "")
+	completed.Status.Phase = corev1.PodSucceeded
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		opts     *DrainOptions
+		expected bool
+	}{
+		{name: "workload pod", pod: mockDrainPod("default", "app", "node-1", ""), opts: &DrainOptions{}, expected: true},
+		{name: "mirror pod", pod: mirror, opts: &DrainOptions{}, expected: false},
+		{name: "completed pod", pod: completed, opts: &DrainOptions{}, expected: false},
+		{name: "daemonset pods ignored", pod: mockDrainPod("kube-system", "aws-node-1", "node-1", "aws-node"), opts: &DrainOptions{}, expected: false},
+		{name: "daemonset pods ignored without options", pod: mockDrainPod("kube-system", "aws-node-1", "node-1", "aws-node"), expected: false},
+		{name: "daemonset not listed", pod: mockDrainPod("kube-system", "aws-node-1", "node-1", "aws-node"), opts: &DrainOptions{EvictDaemonSets: []string{"log-agent"}}, expected: false},
+		{name: "daemonset listed by name", pod: mockDrainPod("logging", "log-agent-1", "node-1", "log-agent"), opts: &DrainOptions{EvictDaemonSets: []string{"log-agent"}}, expected: true},
+		{name: "daemonset listed by namespace", pod: mockDrainPod("logging", "log-agent-1", "node-1", "log-agent"), opts: &DrainOptions{EvictDaemonSets: []string{"logging/log-agent"}}, expected: true},
+		{name: "daemonset listed in other namespace", pod: mockDrainPod("logging", "log-agent-1", "node-1", "log-agent"), opts: &DrainOptions{EvictDaemonSets: []string{"default/log-agent"}}, expected: false},
+	}
+
+	for _, tc := range tests {
+		if result := ShouldEvictPod(*tc.pod, tc.opts); result != tc.expected {
+			t.Fatalf("Unexpected result %v. expected %v from %s", result, tc.expected, tc.name)
+		}
+	}
+}
+
+func TestDrainNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *DrainOptions
+		expected []string
+	}{
+		{name: "ignore all daemonsets", opts: &DrainOptions{}, expected: []string{"default/app"}},
+		{name: "evict listed daemonsets", opts: &DrainOptions{EvictDaemonSets: []string{"logging/log-agent"}}, expected: []string{"default/app", "logging/log-agent-1"}},
+	}
+
+	for _, tc := range tests {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+		k, evicted := MockDrainClient(
+			node,
+			mockDrainPod("default", "app", "node-1", ""),
+			mockDrainPod("default", "other-app", "node-2", ""),
+			mockDrainPod("kube-system", "aws-node-1", "node-1", "aws-node"),
+			mockDrainPod("logging", "log-agent-1", "node-1", "log-agent"),
+		)
+
+		// evictions are confirmed on the next drain once the pods are gone
+		drained, err := k.DrainNode("node-1", tc.opts)
+		if err != nil || drained {
+			t.Fatalf("Expected node to be draining, got %v, %v from %s", drained, err, tc.name)
+		}
+		drained, err = k.DrainNode("node-1", tc.opts)
+		if err != nil || !drained {
+			t.Fatalf("Expected node to be drained, got %v, %v from %s", drained, err, tc.name)
+		}
+
+		sort.Strings(*evicted)
+		if len(*evicted) != len(tc.expected) {
+			t.Fatalf("Unexpected evicted pods %v. expected %v from %s", *evicted, tc.expected, tc.name)
+		}
+		for i := range tc.expected {
+			if (*evicted)[i] != tc.expected[i] {
+				t.Fatalf("Unexpected evicted pods %v. expected %v from %s", *evicted, tc.expected, tc.name)
+			}
+		}
+
+		cordoned, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), "node-1", metav1.GetOptions{})
+		if err != nil || !cordoned.Spec.Unschedulable {
+			t.Fatalf("Expected node to be cordoned from %s", tc.name)
+		}
+
+		if _, err := k.Kubernetes.CoreV1().Pods("kube-system").Get(context.Background(), "aws-node-1", metav1.GetOptions{}); err != nil {
+			t.Fatalf("Expected ignored daemonset pod to remain from %s", tc.name)
+		}
+	}
+
+	// nodes which no longer exist are drained
+	k, _ := MockDrainClient()
+	if drained, err := k.DrainNode("node-1", &DrainOptions{}); err != nil || !drained {
+		t.Fatalf("Expected missing node to be drained, got %v, %v", drained, err)
+	}
+}
+
+func TestDrainNodeSafeToEvict(t *testing.T) {
+	mockNode := func(drainStarted time.Time) *corev1.Node {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+		if !drainStarted.IsZero() {
+			node.Annotations = map[string]string{DrainStartedAnnotation: drainStarted.Format(time.RFC3339)}
+			node.Spec.Unschedulable = true
+		}
+		return node
+	}
+	mockPod := func(name, safeToEvict string) *corev1.Pod {
+		pod := mockDrainPod("default", name, "node-1", "")
+		pod.Annotations = map[string]string{SafeToEvictAnnotation: safeToEvict}
+		return pod
+	}
+
+	tests := []struct {
+		name         string
+		drainStarted time.Time
+		opts         *DrainOptions
+		drained      bool
+		timedOut     bool
+		evicted      []string
+		remaining    bool
+	}{
+		{name: "waits after cordon", opts: &DrainOptions{Timeout: 10 * time.Minute}, evicted: []string{"default/evictable"}, remaining: true},
+		{name: "waits without timeout", drainStarted: time.Now().Add(-time.Hour), opts: &DrainOptions{}, evicted: []string{"default/evictable"}, remaining: true},
+		{name: "waits within timeout", drainStarted: time.Now().Add(-time.Minute), opts: &DrainOptions{Timeout: 10 * time.Minute}, evicted: []string{"default/evictable"}, remaining: true},
+		{name: "aborts after timeout", drainStarted: time.Now().Add(-time.Hour), opts: &DrainOptions{Timeout: 10 * time.Minute}, timedOut: true, evicted: []string{"default/evictable"}, remaining: true},
+		{name: "forces after timeout", drainStarted: time.Now().Add(-time.Hour), opts: &DrainOptions{Timeout: 10 * time.Minute, Force: true}, drained: true, evicted: []string{"default/evictable"}},
+	}
+
+	for _, tc := range tests {
+		k, evicted := MockDrainClient(mockNode(tc.drainStarted), mockPod("evictable", "true"), mockPod("not-evictable", "false"))
+
+		drained, err := k.DrainNode("node-1", tc.opts)
+		if !tc.timedOut {
+			drained, err = k.DrainNode("node-1", tc.opts)
+		}
+		if _, ok := err.(*DrainTimeoutError); ok != tc.timedOut {
+			t.Fatalf("Unexpected error %v from %s", err, tc.name)
+		}
+		if tc.timedOut && !strings.Contains(err.Error(), "default/not-evictable") {
+			t.Fatalf("Expected blocking pod in error %v from %s", err, tc.name)
+		}
+		if !tc.timedOut && err != nil {
+			t.Fatalf("Unexpected error %v from %s", err, tc.name)
+		}
+		if drained != tc.drained {
+			t.Fatalf("Unexpected drained %v. expected %v from %s", drained, tc.drained, tc.name)
+		}
+
+		if len(*evicted) != len(tc.evicted) || (*evicted)[0] != tc.evicted[0] {
+			t.Fatalf("Unexpected evicted pods %v. expected %v from %s", *evicted, tc.evicted, tc.name)
+		}
+
+		_, err = k.Kubernetes.CoreV1().Pods("default").Get(context.Background(), "not-evictable", metav1.GetOptions{})
+		if tc.remaining != (err == nil) {
+			t.Fatalf("Unexpected pod not-evictable remaining %v from %s", err == nil, tc.name)
+		}
+
+		// a timed out drain uncordons the node, the next drain starts over
+		node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), "node-1", metav1.GetOptions{})
+		if err != nil || HasAnnotation(node.GetAnnotations(), DrainStartedAnnotation) == tc.timedOut {
+			t.Fatalf("Expected node to be annotated with drain start %v from %s", !tc.timedOut, tc.name)
+		}
+		if node.Spec.Unschedulable == tc.timedOut {
+			t.Fatalf("Expected node to be cordoned %v from %s", !tc.timedOut, tc.name)
+		}
+	}
+}
+
+func TestDrainNodePriorityOrdering(t *testing.T) {
+	mockPod := func(name string, priority *int32) *corev1.Pod {
+		pod := mockDrainPod("default", name, "node-1", "")
+		pod.Spec.Priority = priority
+		return pod
+	}
+	priority := func(value int32) *int32 {
+		return &value
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	// pods are evicted one priority at a time, lowest first, pods without a priority have the default priority
+	k, evicted := MockDrainClient(
+		node,
+		mockPod("critical-1", priority(1000000)),
+		mockPod("batch", priority(-10)),
+		mockPod("critical-2", priority(1000000)),
+		mockPod("app", nil),
+		mockPod("web", priority(0)),
+	)
+	opts := &DrainOptions{PriorityOrdering: true}
+	expected := [][]string{
+		{"default/batch"},
+		{"default/batch", "default/app", "default/web"},
+		{"default/batch", "default/app", "default/web", "default/critical-1", "default/critical-2"},
+	}
+	for i, evictions := range expected {
+		drained, err := k.DrainNode("node-1", opts)
+		if err != nil || drained {
+			t.Fatalf("Expected node to be draining, got %v, %v from drain #%v", drained, err, i)
+		}
+		if strings.Join(*evicted, ",") != strings.Join(evictions, ",") {
+			t.Fatalf("Unexpected evicted pods %v. expected %v from drain #%v", *evicted, evictions, i)
+		}
+	}
+	if drained, err := k.DrainNode("node-1", opts); err != nil || !drained {
+		t.Fatalf("Expected node to be drained, got %v, %v", drained, err)
+	}
+
+	// terminating pods of a lower priority hold back the eviction of higher priorities
+	terminating := mockPod("batch", priority(-10))
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	terminating.Finalizers = []string{"example.com/finalizer"}
+	k, evicted = MockDrainClient(node, terminating, mockPod("app", nil))
+	if drained, err := k.DrainNode("node-1", opts); err != nil || drained || len(*evicted) != 0 {
+		t.Fatalf("Expected drain to wait on terminating pod, got %v, %v, evicted %v", drained, err, *evicted)
+	}
+
+	// all pods are evicted at once without priority ordering
+	k, evicted = MockDrainClient(node, mockPod("critical-1", priority(1000000)), mockPod("batch", priority(-10)), mockPod("app", nil))
+	if drained, err := k.DrainNode("node-1", &DrainOptions{}); err != nil || drained || len(*evicted) != 3 {
+		t.Fatalf("Expected all pods to be evicted, got %v, %v, evicted %v", drained, err, *evicted)
+	}
+}
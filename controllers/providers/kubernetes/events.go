@@ -51,6 +51,23 @@ var (
 	NodesReadyEvent                 EventKind = "InstanceGroupNodesReady"
 	NodesNotReadyEvent              EventKind = "InstanceGroupNodesNotReady"
 	InstanceGroupUpgradeFailedEvent EventKind = "InstanceGroupUpgradeFailed"
+	NodesProtectedEvent             EventKind = "InstanceGroupNodesProtected"
+	PublicIpPrivateSubnetEvent      EventKind = "InstanceGroupPublicIpPrivateSubnet"
+	NodesStartupTaintTimeoutEvent   EventKind = "InstanceGroupNodesStartupTaintTimeout"
+	NodesFailedToJoinEvent          EventKind = "InstanceGroupNodesFailedToJoin"
+	RotationUnschedulableEvent      EventKind = "InstanceGroupRotationUnschedulable"
+	UnhealthyProtectedEvent         EventKind = "InstanceGroupUnhealthyProtected"
+	RotationDeniedEvent             EventKind = "InstanceGroupRotationDenied"
+	ReservedTagsIgnoredEvent        EventKind = "InstanceGroupReservedTagsIgnored"
+	BlueGreenRolledBackEvent        EventKind = "InstanceGroupBlueGreenRolledBack"
+	NodesNotReadyReplacedEvent      EventKind = "InstanceGroupNodesNotReadyReplaced"
+	NodeBootstrapDiagnosticsEvent   EventKind = "InstanceGroupNodeBootstrapDiagnostics"
+	ImageUpdateAvailableEvent       EventKind = "InstanceGroupImageUpdateAvailable"
+	ReadinessSLAExceededEvent       EventKind = "InstanceGroupReadinessSLAExceeded"
+	NodeCordonedUnhealthyEvent      EventKind = "InstanceGroupNodeCordonedUnhealthy"
+	InstanceGroupAutoscaledEvent    EventKind = "InstanceGroupAutoscaled"
+	IAMTagsIgnoredEvent             EventKind = "InstanceGroupIAMTagsIgnored"
+	MetricsIgnoredEvent             EventKind = "InstanceGroupMetricsIgnored"
 
 	EventLevels = map[EventKind]string{
 		InstanceGroupCreatedEvent:       EventLevelNormal,
@@ -58,6 +75,23 @@ var (
 		NodesNotReadyEvent:              EventLevelWarning,
 		NodesReadyEvent:                 EventLevelNormal,
 		InstanceGroupUpgradeFailedEvent: EventLevelWarning,
+		NodesProtectedEvent:             EventLevelWarning,
+		PublicIpPrivateSubnetEvent:      EventLevelWarning,
+		NodesStartupTaintTimeoutEvent:   EventLevelWarning,
+		NodesFailedToJoinEvent:          EventLevelWarning,
+		RotationUnschedulableEvent:      EventLevelWarning,
+		UnhealthyProtectedEvent:         EventLevelWarning,
+		RotationDeniedEvent:             EventLevelWarning,
+		ReservedTagsIgnoredEvent:        EventLevelWarning,
+		BlueGreenRolledBackEvent:        EventLevelWarning,
+		NodesNotReadyReplacedEvent:      EventLevelWarning,
+		NodeBootstrapDiagnosticsEvent:   EventLevelWarning,
+		ImageUpdateAvailableEvent:       EventLevelNormal,
+		ReadinessSLAExceededEvent:       EventLevelWarning,
+		NodeCordonedUnhealthyEvent:      EventLevelWarning,
+		InstanceGroupAutoscaledEvent:    EventLevelNormal,
+		IAMTagsIgnoredEvent:             EventLevelWarning,
+		MetricsIgnoredEvent:             EventLevelWarning,
 	}
 
 	EventMessages = map[EventKind]string{
@@ -66,6 +100,23 @@ var (
 		InstanceGroupUpgradeFailedEvent: "instance group has failed upgrading",
 		NodesNotReadyEvent:              "instance group nodes are not ready",
 		NodesReadyEvent:                 "instance group nodes are ready",
+		NodesProtectedEvent:             "instance group nodes are protected from rotation",
+		PublicIpPrivateSubnetEvent:      "instance group requests public IPs for nodes in private subnets",
+		NodesStartupTaintTimeoutEvent:   "instance group nodes have not removed their startup taint",
+		NodesFailedToJoinEvent:          "instance group instances have not joined the cluster",
+		RotationUnschedulableEvent:      "instance group rotation is deferred, evicted pods cannot be scheduled",
+		UnhealthyProtectedEvent:         "instance group instances are unhealthy and protected from scale in",
+		RotationDeniedEvent:             "instance group rotation is blocked by the pre-rotation hook",
+		ReservedTagsIgnoredEvent:        "instance group tags using the reserved aws: prefix are not propagated",
+		BlueGreenRolledBackEvent:        "instance group blue/green rotation was rolled back, green nodes did not become ready",
+		NodesNotReadyReplacedEvent:      "instance group instances are being replaced, their nodes have not been ready beyond the threshold",
+		NodeBootstrapDiagnosticsEvent:   "instance group instance has not joined the cluster, consoleOutput is the tail of its console output",
+		ImageUpdateAvailableEvent:       "instance group image reference resolved to a newer image, nodes are rotated through the upgrade strategy",
+		ReadinessSLAExceededEvent:       "instance group nodes have not become ready within the readiness SLA after the instance group was created or scaled up",
+		NodeCordonedUnhealthyEvent:      "instance group node reports an unhealthy condition and was cordoned until it is rotated",
+		InstanceGroupAutoscaledEvent:    "instance group desired capacity was scaled by its autoscaler",
+		IAMTagsIgnoredEvent:             "instance group tags which do not meet IAM tag constraints are not propagated to the managed role",
+		MetricsIgnoredEvent:             "instance group metricsCollection entries which are not scaling group metrics are not collected",
 	}
 )
 
@@ -0,0 +1,106 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// ListRunningPods returns the running pods of the nodes grouped by node name, the running pods of the cluster are listed
+// once rather than node by node
+func (k KubernetesClientSet) ListRunningPods(nodeNames []string) (map[string][]corev1.Pod, error) {
+	running := make(map[string][]corev1.Pod)
+	if len(nodeNames) == 0 {
+		return running, nil
+	}
+
+	pods, err := k.Kubernetes.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("status.phase", string(corev1.PodRunning)).String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list running pods")
+	}
+
+	for _, nodeName := range nodeNames {
+		running[nodeName] = make([]corev1.Pod, 0)
+	}
+	for _, pod := range pods.Items {
+		if _, ok := running[pod.Spec.NodeName]; ok && pod.Status.Phase == corev1.PodRunning {
+			running[pod.Spec.NodeName] = append(running[pod.Spec.NodeName], pod)
+		}
+	}
+	return running, nil
+}
+
+// GetNodeNamesByInstance returns the names of the nodes of the instances
+func GetNodeNamesByInstance(instanceIds []string, nodes *corev1.NodeList) []string {
+	names := make([]string, 0)
+	for _, id := range instanceIds {
+		if node, ok := nodeByInstance(nodes, id); ok {
+			names = append(names, node.GetName())
+		}
+	}
+	return names
+}
+
+// IsDaemonSetPodOf returns true if the pod is controlled by a DaemonSet matching the name or namespace/name
+func IsDaemonSetPodOf(pod corev1.Pod, daemonSet string) bool {
+	owner := metav1.GetControllerOf(&pod)
+	if owner == nil || owner.Kind != "DaemonSet" {
+		return false
+	}
+	return strings.EqualFold(daemonSet, owner.Name) ||
+		strings.EqualFold(daemonSet, fmt.Sprintf("%v/%v", pod.GetNamespace(), owner.Name))
+}
+
+// WithRunningDaemonSetPods returns the nodes which run a pod of every listed DaemonSet, given the running pods grouped by
+// node name
+func WithRunningDaemonSetPods(nodes *corev1.NodeList, running map[string][]corev1.Pod, daemonSets []string) *corev1.NodeList {
+	filtered := &corev1.NodeList{}
+	if nodes == nil {
+		return filtered
+	}
+
+	for _, node := range nodes.Items {
+		if hasDaemonSetPods(running[node.GetName()], daemonSets) {
+			filtered.Items = append(filtered.Items, node)
+		}
+	}
+	return filtered
+}
+
+func hasDaemonSetPods(pods []corev1.Pod, daemonSets []string) bool {
+	for _, daemonSet := range daemonSets {
+		var found bool
+		for _, pod := range pods {
+			if IsDaemonSetPodOf(pod, daemonSet) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package kubernetes
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestWithRunningDaemonSetPods(t *testing.T) {
+	pending := mockDrainPod("kube-system", "aws-node-3", "node-3", "aws-node")
+	pending.Status.Phase = corev1.PodPending
+
+	nodes := &corev1.NodeList{
+		Items: []corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-3"}},
+		},
+	}
+	pods := []*corev1.Pod{
+		mockDrainPod("kube-system", "aws-node-1", "node-1", "aws-node"),
+		mockDrainPod("logging", "log-agent-1", "node-1", "log-agent"),
+		mockDrainPod("kube-system", "aws-node-2", "node-2", "aws-node"),
+		mockDrainPod("default", "log-agent-2", "node-2", ""),
+		pending,
+		mockDrainPod("logging", "log-agent-3", "node-3", "log-agent"),
+		mockDrainPod("kube-system", "aws-node-4", "node-4", "aws-node"),
+	}
+
+	objects := make([]runtime.Object, 0)
+	for _, pod := range pods {
+		objects = append(objects, pod)
+	}
+	k, _ := MockDrainClient(objects...)
+	running, err := k.ListRunningPods([]string{"node-1", "node-2", "node-3"})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// only the running pods of the listed nodes are returned
+	if _, ok := running["node-4"]; ok {
+		t.Fatalf("Unexpected pods %v of node-4", running["node-4"])
+	}
+	if len(running["node-1"]) != 2 || len(running["node-3"]) != 1 {
+		t.Fatalf("Unexpected running pods %v", running)
+	}
+
+	tests := []struct {
+		name       string
+		daemonSets []string
+		expected   []string
+	}{
+		{name: "no daemonsets required", expected: []string{"node-1", "node-2", "node-3"}},
+		{name: "daemonset present", daemonSets: []string{"aws-node"}, expected: []string{"node-1", "node-2"}},
+		{name: "daemonset by namespace", daemonSets: []string{"logging/log-agent"}, expected: []string{"node-1", "node-3"}},
+		{name: "all daemonsets present", daemonSets: []string{"aws-node", "log-agent"}, expected: []string{"node-1"}},
+		{name: "daemonset absent", daemonSets: []string{"kube-proxy"}, expected: []string{}},
+		{name: "daemonset in other namespace", daemonSets: []string{"default/aws-node"}, expected: []string{}},
+	}
+
+	for _, tc := range tests {
+		filtered := WithRunningDaemonSetPods(nodes, running, tc.daemonSets)
+		if len(filtered.Items) != len(tc.expected) {
+			t.Fatalf("Unexpected nodes %v. expected %v from %s", filtered.Items, tc.expected, tc.name)
+		}
+		for i, node := range filtered.Items {
+			if node.GetName() != tc.expected[i] {
+				t.Fatalf("Unexpected node %v. expected %v from %s", node.GetName(), tc.expected[i], tc.name)
+			}
+		}
+	}
+
+	if filtered := WithRunningDaemonSetPods(nil, running, []string{"aws-node"}); len(filtered.Items) != 0 {
+		t.Fatalf("Expected no nodes, got %v", filtered.Items)
+	}
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package kubernetes
 
 import (
+	"github.com/keikoproj/instance-manager/controllers/common"
 	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
 	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -31,12 +32,21 @@ var (
 
 type RollingUpdateRequest struct {
 	AwsWorker        awsprovider.AwsWorker
+	KubernetesClient KubernetesClientSet
 	ClusterNodes     *corev1.NodeList
 	ScalingGroupName string
 	MaxUnavailable   int
 	DesiredCapacity  int
 	AllInstances     []string
 	UpdateTargets    []string
+	Drain            *DrainOptions
+	// FailureDomainLabel is the node label whose values are failure domains, at most one node of each failure domain
+	// is rotated at a time
+	FailureDomainLabel string
+	// SchedulingCheck defers the rotation while pods evicted from the targets cannot be scheduled on the remaining nodes
+	SchedulingCheck bool
+	// ReadinessDaemonSets are the DaemonSets a pod of which must be running on a node before it is counted as ready
+	ReadinessDaemonSets []string
 }
 
 func ProcessRollingUpgradeStrategy(req *RollingUpdateRequest) (bool, error) {
@@ -71,13 +81,47 @@ func ProcessRollingUpgradeStrategy(req *RollingUpdateRequest) (bool, error) {
 		return false, nil
 	}
 
+	// nodes which are ready but do not run the readiness daemonsets yet, such as replacements of the previous
+	// targets, are not available and the next targets are not rotated until they are
+	if len(req.ReadinessDaemonSets) > 0 {
+		pods, err := req.KubernetesClient.ListRunningPods(GetNodeNamesByInstance(req.AllInstances, req.ClusterNodes))
+		if err != nil {
+			return false, err
+		}
+		ready := GetReadyNodesByInstance(req.AllInstances, req.ClusterNodes)
+		available := GetReadyNodesByInstance(req.AllInstances, WithRunningDaemonSetPods(req.ClusterNodes, pods, req.ReadinessDaemonSets))
+		if len(available) < len(ready) {
+			log.Info("desired nodes are not running readiness daemonsets", "scalinggroup", req.ScalingGroupName, "daemonsets", req.ReadinessDaemonSets)
+			return false, nil
+		}
+	}
+
 	var terminateTargets []string
-	if req.MaxUnavailable <= len(req.UpdateTargets) {
+	if req.FailureDomainLabel != "" {
+		terminateTargets = failureDomainTargets(req)
+	} else if req.MaxUnavailable <= len(req.UpdateTargets) {
 		terminateTargets = req.UpdateTargets[:req.MaxUnavailable]
 	} else {
 		terminateTargets = req.UpdateTargets
 	}
 
+	if req.SchedulingCheck {
+		if err := checkTargetsSchedulable(req, terminateTargets); err != nil {
+			return false, err
+		}
+	}
+
+	if req.Drain != nil {
+		var err error
+		if terminateTargets, err = drainTargets(req, terminateTargets); err != nil {
+			return false, err
+		}
+		if len(terminateTargets) == 0 {
+			log.Info("waiting for targets to drain", "scalinggroup", req.ScalingGroupName)
+			return false, nil
+		}
+	}
+
 	log.Info("terminating targets", "scalinggroup", req.ScalingGroupName, "targets", terminateTargets)
 	if err := req.AwsWorker.TerminateScalingInstances(terminateTargets); err != nil {
 		// terminate failures are retryable
@@ -86,3 +130,101 @@ func ProcessRollingUpgradeStrategy(req *RollingUpdateRequest) (bool, error) {
 	}
 	return false, nil
 }
+
+// failureDomainTargets returns up to maxUnavailable targets with at most one target of each failure domain, nodes
+// without the failure domain label share a failure domain. Instances which did not join the cluster are not part of a
+// failure domain
+func failureDomainTargets(req *RollingUpdateRequest) []string {
+	var (
+		targets = make([]string, 0)
+		skipped = make([]string, 0)
+		domains = make(map[string]bool)
+	)
+	for _, instanceID := range req.UpdateTargets {
+		if len(targets) >= req.MaxUnavailable {
+			break
+		}
+		node, ok := nodeByInstance(req.ClusterNodes, instanceID)
+		if !ok {
+			targets = append(targets, instanceID)
+			continue
+		}
+		domain := node.GetLabels()[req.FailureDomainLabel]
+		if domains[domain] {
+			skipped = append(skipped, instanceID)
+			continue
+		}
+		domains[domain] = true
+		targets = append(targets, instanceID)
+	}
+	if len(skipped) > 0 {
+		log.Info("deferring targets in failure domains which are already rotating", "scalinggroup", req.ScalingGroupName, "label", req.FailureDomainLabel, "targets", skipped)
+	}
+	return targets
+}
+
+func nodeByInstance(nodes *corev1.NodeList, instanceID string) (corev1.Node, bool) {
+	if nodes == nil {
+		return corev1.Node{}, false
+	}
+	for _, node := range nodes.Items {
+		if common.GetLastElementBy(node.Spec.ProviderID, "/") == instanceID {
+			return node, true
+		}
+	}
+	return corev1.Node{}, false
+}
+
+// checkTargetsSchedulable returns an UnschedulablePodsError if the pods which would be evicted from the targets cannot
+// be scheduled on the remaining nodes, targets which are already draining are not checked again
+func checkTargetsSchedulable(req *RollingUpdateRequest, targets []string) error {
+	nodeNames := make([]string, 0)
+	for _, instanceID := range targets {
+		node, ok := nodeByInstance(req.ClusterNodes, instanceID)
+		if !ok || HasAnnotation(node.GetAnnotations(), DrainStartedAnnotation) {
+			continue
+		}
+		nodeNames = append(nodeNames, node.GetName())
+	}
+	if len(nodeNames) == 0 {
+		return nil
+	}
+
+	pods, err := req.KubernetesClient.ListScheduledPods()
+	if err != nil {
+		return err
+	}
+
+	if unschedulable := GetUnschedulablePods(req.ClusterNodes, pods, nodeNames, req.Drain); len(unschedulable) > 0 {
+		return &UnschedulablePodsError{Nodes: nodeNames, Pods: unschedulable}
+	}
+	return nil
+}
+
+// drainTargets drains the nodes of the targets and returns the targets which are ready to be terminated, instances
+// which did not join the cluster have no node to drain. Drain timeouts abort the rolling update
+func drainTargets(req *RollingUpdateRequest, targets []string) ([]string, error) {
+	drained := make([]string, 0)
+	for _, instanceID := range targets {
+		node, ok := nodeByInstance(req.ClusterNodes, instanceID)
+		if !ok {
+			drained = append(drained, instanceID)
+			continue
+		}
+		nodeName := node.GetName()
+
+		ok, err := req.KubernetesClient.DrainNode(nodeName, req.Drain)
+		if _, timedOut := err.(*DrainTimeoutError); timedOut {
+			return nil, err
+		}
+		if err != nil {
+			// drain failures are retryable
+			log.Info("failed to drain target", "reason", err.Error(), "scalinggroup", req.ScalingGroupName, "target", instanceID, "node", nodeName)
+			continue
+		}
+		if ok {
+			drained = append(drained, instanceID)
+		}
+	}
+	return drained, nil
+}
@@ -0,0 +1,206 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/keikoproj/instance-manager/controllers/common"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	// scheduledPodsPageSize is the number of pods listed per request when checking whether evicted pods can be scheduled
+	scheduledPodsPageSize = 500
+)
+
+// UnschedulablePodsError is returned when the pods evicted from rotated nodes cannot be scheduled on the remaining nodes
+type UnschedulablePodsError struct {
+	Nodes []string
+	Pods  []string
+}
+
+func (e *UnschedulablePodsError) Error() string {
+	return fmt.Sprintf("pods %v evicted from nodes %v cannot be scheduled on the remaining nodes", e.Pods, e.Nodes)
+}
+
+// ListScheduledPods returns the pods of all namespaces which are bound to a node and have not terminated, the pods are
+// filtered by the API server and listed in pages of scheduledPodsPageSize
+func (k KubernetesClientSet) ListScheduledPods() ([]corev1.Pod, error) {
+	var (
+		scheduled = make([]corev1.Pod, 0)
+		selector  = fields.AndSelectors(
+			fields.OneTermNotEqualSelector("spec.nodeName", ""),
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+		).String()
+		next string
+	)
+
+	for {
+		pods, err := k.Kubernetes.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{
+			FieldSelector: selector,
+			Limit:         scheduledPodsPageSize,
+			Continue:      next,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list pods")
+		}
+		for _, pod := range pods.Items {
+			if pod.Spec.NodeName != "" {
+				scheduled = append(scheduled, pod)
+			}
+		}
+		if next = pods.GetContinue(); next == "" {
+			return scheduled, nil
+		}
+	}
+}
+
+// GetUnschedulablePods returns the pods which would be evicted from the drained nodes and cannot be scheduled on any other
+// ready and schedulable node. Pods fit a node when they tolerate its NoSchedule and NoExecute taints, match its node
+// selector and their requests fit its free CPU, memory and pod capacity, pods are placed one by one so that they compete
+// for the same free capacity
+func GetUnschedulablePods(nodes *corev1.NodeList, pods []corev1.Pod, drainedNodes []string, opts *DrainOptions) []string {
+	unschedulable := make([]string, 0)
+	if nodes == nil {
+		return unschedulable
+	}
+
+	candidates := make(map[string]*nodeCapacity)
+	for i := range nodes.Items {
+		node := nodes.Items[i]
+		if common.ContainsEqualFold(drainedNodes, node.GetName()) || node.Spec.Unschedulable || !IsNodeReady(node) {
+			continue
+		}
+		candidates[node.GetName()] = newNodeCapacity(node)
+	}
+
+	evicted := make([]corev1.Pod, 0)
+	for _, pod := range pods {
+		if isTerminalPod(pod) {
+			continue
+		}
+		if common.ContainsEqualFold(drainedNodes, pod.Spec.NodeName) {
+			if ShouldEvictPod(pod, opts) && pod.GetDeletionTimestamp() == nil {
+				evicted = append(evicted, pod)
+			}
+			continue
+		}
+		if capacity, ok := candidates[pod.Spec.NodeName]; ok {
+			capacity.add(pod)
+		}
+	}
+
+	for _, pod := range evicted {
+		var scheduled bool
+		for i := range nodes.Items {
+			capacity, ok := candidates[nodes.Items[i].GetName()]
+			if !ok || !capacity.fits(pod) {
+				continue
+			}
+			capacity.add(pod)
+			scheduled = true
+			break
+		}
+		if !scheduled {
+			unschedulable = append(unschedulable, podName(pod))
+		}
+	}
+	return unschedulable
+}
+
+type nodeCapacity struct {
+	node   corev1.Node
+	cpu    resource.Quantity
+	memory resource.Quantity
+	pods   int64
+}
+
+func newNodeCapacity(node corev1.Node) *nodeCapacity {
+	allocatable := node.Status.Allocatable
+	return &nodeCapacity{
+		node:   node,
+		cpu:    allocatable.Cpu().DeepCopy(),
+		memory: allocatable.Memory().DeepCopy(),
+		pods:   allocatable.Pods().Value(),
+	}
+}
+
+func (c *nodeCapacity) add(pod corev1.Pod) {
+	cpu, memory := podRequests(pod)
+	c.cpu.Sub(cpu)
+	c.memory.Sub(memory)
+	c.pods--
+}
+
+func (c *nodeCapacity) fits(pod corev1.Pod) bool {
+	for key, value := range pod.Spec.NodeSelector {
+		if c.node.GetLabels()[key] != value {
+			return false
+		}
+	}
+
+	for i := range c.node.Spec.Taints {
+		taint := c.node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(pod.Spec.Tolerations, &taint) {
+			return false
+		}
+	}
+
+	cpu, memory := podRequests(pod)
+	return c.pods > 0 && cpu.Cmp(c.cpu) <= 0 && memory.Cmp(c.memory) <= 0
+}
+
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// podRequests returns the CPU and memory requests of a pod, init containers run before the containers so the largest of
+// their requests and the sum of the container requests is used
+func podRequests(pod corev1.Pod) (resource.Quantity, resource.Quantity) {
+	var cpu, memory resource.Quantity
+	for _, container := range pod.Spec.Containers {
+		cpu.Add(*container.Resources.Requests.Cpu())
+		memory.Add(*container.Resources.Requests.Memory())
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if request := container.Resources.Requests.Cpu(); request.Cmp(cpu) > 0 {
+			cpu = request.DeepCopy()
+		}
+		if request := container.Resources.Requests.Memory(); request.Cmp(memory) > 0 {
+			memory = request.DeepCopy()
+		}
+	}
+	return cpu, memory
+}
+
+func isTerminalPod(pod corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
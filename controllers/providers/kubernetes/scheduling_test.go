@@ -0,0 +1,167 @@
+package kubernetes
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func mockSchedulingNode(name, cpu, memory string, taints ...corev1.Taint) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"node.kubernetes.io/instance-type": "m5.large"},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: taints,
+		},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+				corev1.ResourcePods:   resource.MustParse("10"),
+			},
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func mockSchedulingPod(name, node, cpu, memory string) corev1.Pod {
+	pod := mockDrainPod("default", name, node, "")
+	pod.Spec.Containers = []corev1.Container{
+		{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		},
+	}
+	return *pod
+}
+
+func TestGetUnschedulablePods(t *testing.T) {
+	dedicated := corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}
+	tolerating := mockSchedulingPod("tolerating", "node-1", "500m", "1Gi")
+	tolerating.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	selecting := mockSchedulingPod("selecting", "node-1", "500m", "1Gi")
+	selecting.Spec.NodeSelector = map[string]string{"node.kubernetes.io/instance-type": "m5.xlarge"}
+	cordoned := mockSchedulingNode("node-2", "2", "8Gi")
+	cordoned.Spec.Unschedulable = true
+	notReady := mockSchedulingNode("node-2", "2", "8Gi")
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+
+	tests := []struct {
+		name     string
+		nodes    []corev1.Node
+		pods     []corev1.Pod
+		expected []string
+	}{
+		{
+			name:  "pods fit remaining node",
+			nodes: []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), mockSchedulingNode("node-2", "2", "8Gi")},
+			pods:  []corev1.Pod{mockSchedulingPod("app", "node-1", "500m", "1Gi"), mockSchedulingPod("other-app", "node-2", "1", "4Gi")},
+		},
+		{
+			name:     "pods exceed free cpu",
+			nodes:    []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), mockSchedulingNode("node-2", "2", "8Gi")},
+			pods:     []corev1.Pod{mockSchedulingPod("app", "node-1", "1500m", "1Gi"), mockSchedulingPod("other-app", "node-2", "1", "4Gi")},
+			expected: []string{"default/app"},
+		},
+		{
+			name:     "pods compete for free memory",
+			nodes:    []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), mockSchedulingNode("node-2", "2", "8Gi")},
+			pods:     []corev1.Pod{mockSchedulingPod("app", "node-1", "100m", "3Gi"), mockSchedulingPod("app-2", "node-1", "100m", "3Gi"), mockSchedulingPod("other-app", "node-2", "100m", "4Gi")},
+			expected: []string{"default/app-2"},
+		},
+		{
+			name:     "taint is not tolerated",
+			nodes:    []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), mockSchedulingNode("node-2", "2", "8Gi", dedicated)},
+			pods:     []corev1.Pod{mockSchedulingPod("app", "node-1", "500m", "1Gi")},
+			expected: []string{"default/app"},
+		},
+		{
+			name:  "taint is tolerated",
+			nodes: []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), mockSchedulingNode("node-2", "2", "8Gi", dedicated)},
+			pods:  []corev1.Pod{tolerating},
+		},
+		{
+			name:  "prefer no schedule taint is ignored",
+			nodes: []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), mockSchedulingNode("node-2", "2", "8Gi", corev1.Taint{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule})},
+			pods:  []corev1.Pod{mockSchedulingPod("app", "node-1", "500m", "1Gi")},
+		},
+		{
+			name:     "node selector does not match",
+			nodes:    []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), mockSchedulingNode("node-2", "2", "8Gi")},
+			pods:     []corev1.Pod{selecting},
+			expected: []string{"default/selecting"},
+		},
+		{
+			name:     "cordoned nodes are not candidates",
+			nodes:    []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), cordoned},
+			pods:     []corev1.Pod{mockSchedulingPod("app", "node-1", "500m", "1Gi")},
+			expected: []string{"default/app"},
+		},
+		{
+			name:     "nodes which are not ready are not candidates",
+			nodes:    []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi"), notReady},
+			pods:     []corev1.Pod{mockSchedulingPod("app", "node-1", "500m", "1Gi")},
+			expected: []string{"default/app"},
+		},
+		{
+			name:  "daemonset pods are not evicted",
+			nodes: []corev1.Node{mockSchedulingNode("node-1", "2", "8Gi")},
+			pods:  []corev1.Pod{*mockDrainPod("kube-system", "aws-node-1", "node-1", "aws-node")},
+		},
+	}
+
+	for _, tc := range tests {
+		result := GetUnschedulablePods(&corev1.NodeList{Items: tc.nodes}, tc.pods, []string{"node-1"}, &DrainOptions{})
+		if len(result) != len(tc.expected) {
+			t.Fatalf("Unexpected result %v. expected %v from %s", result, tc.expected, tc.name)
+		}
+		for i := range tc.expected {
+			if result[i] != tc.expected[i] {
+				t.Fatalf("Unexpected result %v. expected %v from %s", result, tc.expected, tc.name)
+			}
+		}
+	}
+}
+
+func TestListScheduledPods(t *testing.T) {
+	scheduled := mockSchedulingPod("scheduled", "node-1", "500m", "1Gi")
+	unbound := mockSchedulingPod("unbound", "", "500m", "1Gi")
+	client := fake.NewSimpleClientset(&scheduled, &unbound)
+
+	var restrictions []k8stesting.ListRestrictions
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		restrictions = append(restrictions, action.(k8stesting.ListAction).GetListRestrictions())
+		return false, nil, nil
+	})
+
+	k := KubernetesClientSet{Kubernetes: client}
+	pods, err := k.ListScheduledPods()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(pods) != 1 || pods[0].GetName() != "scheduled" {
+		t.Fatalf("Unexpected pods %v, expected only the scheduled pod", pods)
+	}
+
+	// unbound and terminated pods are filtered by the API server
+	if len(restrictions) != 1 {
+		t.Fatalf("Unexpected list requests %v", restrictions)
+	}
+	if selector := restrictions[0].Fields.String(); selector != "spec.nodeName!=,status.phase!=Failed,status.phase!=Succeeded" {
+		t.Fatalf("Unexpected field selector %v", selector)
+	}
+}
@@ -21,13 +21,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/ghodss/yaml"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
 	"github.com/keikoproj/instance-manager/controllers/common"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -42,6 +45,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	// ProtectedNodeAnnotation excludes a node from rotation, the value is either 'true' or an RFC3339 time at which the protection expires
+	ProtectedNodeAnnotation = "instancemgr.keikoproj.io/protect"
+	// ENIConfigAnnotation selects the ENIConfig the VPC CNI allocates the pod IPs of a node from with custom networking,
+	// unless the CNI is configured with another annotation
+	ENIConfigAnnotation = "k8s.amazonaws.com/eniConfig"
+	// UnhealthyCordonedAnnotation marks a node cordoned for an unhealthy node condition, the value is the condition type
+	UnhealthyCordonedAnnotation = "instancemgr.keikoproj.io/cordoned-unhealthy"
+)
+
 type KubernetesClientSet struct {
 	Kubernetes  kubernetes.Interface
 	KubeDynamic dynamic.Interface
@@ -100,6 +113,218 @@ func GetReadyNodesByInstance(instanceIds []string, nodes *corev1.NodeList) []str
 	return readyInstances
 }
 
+// HasTaint returns true if the node has a taint with the given key
+func HasTaint(node corev1.Node, key string) bool {
+	for _, t := range node.Spec.Taints {
+		if t.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutTaint returns the nodes which do not have a taint with the given key
+func WithoutTaint(nodes *corev1.NodeList, key string) *corev1.NodeList {
+	filtered := &corev1.NodeList{}
+	if nodes == nil {
+		return filtered
+	}
+	for _, node := range nodes.Items {
+		if !HasTaint(node, key) {
+			filtered.Items = append(filtered.Items, node)
+		}
+	}
+	return filtered
+}
+
+// GetStuckTaintedNodesByInstance returns the instance ids of nodes which still have a taint with the given key
+// after they have been registered for longer than the timeout
+func GetStuckTaintedNodesByInstance(instanceIds []string, nodes *corev1.NodeList, key string, timeout time.Duration) []string {
+	stuckInstances := make([]string, 0)
+	if nodes == nil {
+		return stuckInstances
+	}
+	for _, id := range instanceIds {
+		for _, node := range nodes.Items {
+			if common.GetLastElementBy(node.Spec.ProviderID, "/") != id || !HasTaint(node, key) {
+				continue
+			}
+			if time.Since(node.CreationTimestamp.Time) > timeout {
+				stuckInstances = append(stuckInstances, id)
+			}
+		}
+	}
+	return stuckInstances
+}
+
+// GetNotReadyNodesByInstance returns the instance ids of nodes whose ready condition has not been true for longer than
+// the threshold, the instances which have been NotReady the longest come first
+func GetNotReadyNodesByInstance(instanceIds []string, nodes *corev1.NodeList, threshold time.Duration) []string {
+	notReadyInstances := make([]string, 0)
+	if nodes == nil {
+		return notReadyInstances
+	}
+	notReadySince := make(map[string]time.Time)
+	for _, id := range instanceIds {
+		for _, node := range nodes.Items {
+			if common.GetLastElementBy(node.Spec.ProviderID, "/") != id || IsNodeReady(node) {
+				continue
+			}
+			for _, condition := range node.Status.Conditions {
+				if condition.Type != corev1.NodeReady {
+					continue
+				}
+				if time.Since(condition.LastTransitionTime.Time) > threshold {
+					notReadyInstances = append(notReadyInstances, id)
+					notReadySince[id] = condition.LastTransitionTime.Time
+				}
+			}
+		}
+	}
+	sort.SliceStable(notReadyInstances, func(i, j int) bool {
+		return notReadySince[notReadyInstances[i]].Before(notReadySince[notReadyInstances[j]])
+	})
+	return notReadyInstances
+}
+
+// GetNotReadyThresholdInterval returns the time until the next node of the instances which is NotReady, but not for
+// longer than the threshold yet, exceeds the threshold, or zero if there is no such node
+func GetNotReadyThresholdInterval(instanceIds []string, nodes *corev1.NodeList, threshold time.Duration) time.Duration {
+	var interval time.Duration
+	if nodes == nil {
+		return interval
+	}
+	for _, node := range nodes.Items {
+		if !common.ContainsString(instanceIds, common.GetLastElementBy(node.Spec.ProviderID, "/")) || IsNodeReady(node) {
+			continue
+		}
+		for _, condition := range node.Status.Conditions {
+			if condition.Type != corev1.NodeReady {
+				continue
+			}
+			remaining := threshold - time.Since(condition.LastTransitionTime.Time)
+			if remaining > 0 && (interval == 0 || remaining < interval) {
+				interval = remaining
+			}
+		}
+	}
+	return interval
+}
+
+// GetUnjoinedInstances returns the instance ids which have not registered as a node
+func GetUnjoinedInstances(instanceIds []string, nodes *corev1.NodeList) []string {
+	joined := make(map[string]bool)
+	if nodes != nil {
+		for _, node := range nodes.Items {
+			joined[common.GetLastElementBy(node.Spec.ProviderID, "/")] = true
+		}
+	}
+	unjoined := make([]string, 0)
+	for _, id := range instanceIds {
+		if !joined[id] {
+			unjoined = append(unjoined, id)
+		}
+	}
+	return unjoined
+}
+
+// GetProtectedNodesByInstance returns the instance ids of nodes which are protected from rotation
+func GetProtectedNodesByInstance(instanceIds []string, nodes *corev1.NodeList) []string {
+	protectedInstances := make([]string, 0)
+	if nodes == nil {
+		return protectedInstances
+	}
+	for _, id := range instanceIds {
+		for _, node := range nodes.Items {
+			if IsNodeProtected(node) && common.GetLastElementBy(node.Spec.ProviderID, "/") == id {
+				protectedInstances = append(protectedInstances, id)
+			}
+		}
+	}
+	return protectedInstances
+}
+
+// GetUnhealthyCordonedInstances returns the instances whose nodes were cordoned for an unhealthy node condition
+func GetUnhealthyCordonedInstances(instanceIds []string, nodes *corev1.NodeList) []string {
+	cordonedInstances := make([]string, 0)
+	if nodes == nil {
+		return cordonedInstances
+	}
+	for _, node := range nodes.Items {
+		if _, ok := node.GetAnnotations()[UnhealthyCordonedAnnotation]; !ok {
+			continue
+		}
+		if id := common.GetLastElementBy(node.Spec.ProviderID, "/"); common.ContainsString(instanceIds, id) {
+			cordonedInstances = append(cordonedInstances, id)
+		}
+	}
+	return cordonedInstances
+}
+
+// AnnotateNode merges the annotations into the annotations of a node
+func (k KubernetesClientSet) AnnotateNode(nodeName string, annotations map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := k.Kubernetes.CoreV1().Nodes().Patch(context.Background(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to annotate node %v", nodeName)
+	}
+	return nil
+}
+
+// LabelNode merges the labels into the labels of a node
+func (k KubernetesClientSet) LabelNode(nodeName string, labels map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": labels,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := k.Kubernetes.CoreV1().Nodes().Patch(context.Background(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to label node %v", nodeName)
+	}
+	return nil
+}
+
+// CordonNode marks a node unschedulable
+func (k KubernetesClientSet) CordonNode(nodeName string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"unschedulable": true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := k.Kubernetes.CoreV1().Nodes().Patch(context.Background(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to cordon node %v", nodeName)
+	}
+	return nil
+}
+
+// IsNodeProtected returns true if a node has the protect annotation set to 'true' or to a time which has not passed
+func IsNodeProtected(n corev1.Node) bool {
+	value, ok := n.GetAnnotations()[ProtectedNodeAnnotation]
+	if !ok {
+		return false
+	}
+	if strings.EqualFold(value, "true") {
+		return true
+	}
+	expiry, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	return time.Now().Before(expiry)
+}
+
 func IsNodeReady(n corev1.Node) bool {
 	for _, condition := range n.Status.Conditions {
 		if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
@@ -2,9 +2,13 @@ package kubernetes
 
 import (
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/keikoproj/instance-manager/controllers/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -105,7 +109,219 @@ func TestHasAnnotationWithValue(t *testing.T) {
 	}
 }
 
+func TestIsNodeProtected(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name:        "no annotation",
+			annotations: map[string]string{},
+			expected:    false,
+		},
+		{
+			name:        "protected",
+			annotations: map[string]string{ProtectedNodeAnnotation: "true"},
+			expected:    true,
+		},
+		{
+			name:        "protection disabled",
+			annotations: map[string]string{ProtectedNodeAnnotation: "false"},
+			expected:    false,
+		},
+		{
+			name:        "protected until future time",
+			annotations: map[string]string{ProtectedNodeAnnotation: time.Now().Add(time.Hour).Format(time.RFC3339)},
+			expected:    true,
+		},
+		{
+			name:        "protection expired",
+			annotations: map[string]string{ProtectedNodeAnnotation: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		node := corev1.Node{}
+		node.SetAnnotations(tc.annotations)
+		result := IsNodeProtected(node)
+		if result != tc.expected {
+			t.Fatalf("Unexpected result %v. expected %v from %s", result, tc.expected, tc.name)
+		}
+	}
+}
+
 // Test IsStorageError
+func TestGetStuckTaintedNodesByInstance(t *testing.T) {
+	mockNode := func(id string, age time.Duration, taints ...string) corev1.Node {
+		node := corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+			Spec: corev1.NodeSpec{
+				ProviderID: "aws:///us-west-2a/" + id,
+			},
+		}
+		for _, key := range taints {
+			node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{Key: key, Effect: corev1.TaintEffectNoSchedule})
+		}
+		return node
+	}
+
+	nodes := &corev1.NodeList{
+		Items: []corev1.Node{
+			mockNode("i-1", time.Hour),
+			mockNode("i-2", time.Hour, "startup", "other"),
+			mockNode("i-3", time.Minute, "startup"),
+			mockNode("i-4", time.Hour, "other"),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		instanceIds []string
+		timeout     time.Duration
+		expected    []string
+	}{
+		{name: "no instances", instanceIds: []string{}, timeout: 10 * time.Minute, expected: []string{}},
+		{name: "taint cleared", instanceIds: []string{"i-1", "i-4"}, timeout: 10 * time.Minute, expected: []string{}},
+		{name: "taint within timeout", instanceIds: []string{"i-3"}, timeout: 10 * time.Minute, expected: []string{}},
+		{name: "taint stuck", instanceIds: []string{"i-1", "i-2", "i-3"}, timeout: 10 * time.Minute, expected: []string{"i-2"}},
+		{name: "short timeout", instanceIds: []string{"i-1", "i-2", "i-3"}, timeout: time.Second, expected: []string{"i-2", "i-3"}},
+	}
+
+	for _, tc := range tests {
+		result := GetStuckTaintedNodesByInstance(tc.instanceIds, nodes, "startup", tc.timeout)
+		if !common.StringSliceEquals(result, tc.expected) {
+			t.Fatalf("Unexpected result %v. expected %v from %s", result, tc.expected, tc.name)
+		}
+	}
+
+	untainted := WithoutTaint(nodes, "startup")
+	if len(untainted.Items) != 2 || HasTaint(untainted.Items[0], "startup") || HasTaint(untainted.Items[1], "startup") {
+		t.Fatalf("Unexpected nodes %+v without taint", untainted.Items)
+	}
+}
+
+func TestGetUnjoinedInstances(t *testing.T) {
+	nodes := &corev1.NodeList{
+		Items: []corev1.Node{
+			{Spec: corev1.NodeSpec{ProviderID: "aws:///us-west-2a/i-1"}},
+			{Spec: corev1.NodeSpec{ProviderID: "aws:///us-west-2b/i-2"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		instanceIds []string
+		nodes       *corev1.NodeList
+		expected    []string
+	}{
+		{name: "no instances", instanceIds: []string{}, nodes: nodes, expected: []string{}},
+		{name: "all joined", instanceIds: []string{"i-1", "i-2"}, nodes: nodes, expected: []string{}},
+		{name: "never joined", instanceIds: []string{"i-1", "i-3", "i-2", "i-4"}, nodes: nodes, expected: []string{"i-3", "i-4"}},
+		{name: "no nodes", instanceIds: []string{"i-1"}, nodes: nil, expected: []string{"i-1"}},
+	}
+
+	for _, tc := range tests {
+		result := GetUnjoinedInstances(tc.instanceIds, tc.nodes)
+		if !common.StringSliceEquals(result, tc.expected) {
+			t.Fatalf("Unexpected result %v. expected %v from %s", result, tc.expected, tc.name)
+		}
+	}
+}
+
+func TestGetNotReadyNodesByInstance(t *testing.T) {
+	mockNode := func(id string, status corev1.ConditionStatus, since time.Duration) corev1.Node {
+		return corev1.Node{
+			Spec: corev1.NodeSpec{
+				ProviderID: "aws:///us-west-2a/" + id,
+			},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(time.Now().Add(-since))},
+				},
+			},
+		}
+	}
+
+	nodes := &corev1.NodeList{
+		Items: []corev1.Node{
+			mockNode("i-1", corev1.ConditionTrue, time.Hour),
+			mockNode("i-2", corev1.ConditionFalse, 20*time.Minute),
+			mockNode("i-3", corev1.ConditionUnknown, time.Hour),
+			mockNode("i-4", corev1.ConditionFalse, time.Minute),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		instanceIds []string
+		nodes       *corev1.NodeList
+		threshold   time.Duration
+		expected    []string
+	}{
+		{name: "no instances", instanceIds: []string{}, nodes: nodes, threshold: 10 * time.Minute, expected: []string{}},
+		{name: "ready", instanceIds: []string{"i-1"}, nodes: nodes, threshold: 10 * time.Minute, expected: []string{}},
+		{name: "not ready within threshold", instanceIds: []string{"i-4"}, nodes: nodes, threshold: 10 * time.Minute, expected: []string{}},
+		{name: "not ready beyond threshold", instanceIds: []string{"i-1", "i-2", "i-3", "i-4"}, nodes: nodes, threshold: 10 * time.Minute, expected: []string{"i-3", "i-2"}},
+		{name: "unjoined", instanceIds: []string{"i-5"}, nodes: nodes, threshold: time.Second, expected: []string{}},
+		{name: "no nodes", instanceIds: []string{"i-2"}, nodes: nil, threshold: time.Second, expected: []string{}},
+	}
+
+	for _, tc := range tests {
+		result := GetNotReadyNodesByInstance(tc.instanceIds, tc.nodes, tc.threshold)
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Fatalf("Unexpected result %v. expected %v from %s", result, tc.expected, tc.name)
+		}
+	}
+}
+
+func TestGetNotReadyThresholdInterval(t *testing.T) {
+	mockNode := func(id string, status corev1.ConditionStatus, since time.Duration) corev1.Node {
+		return corev1.Node{
+			Spec: corev1.NodeSpec{
+				ProviderID: "aws:///us-west-2a/" + id,
+			},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(time.Now().Add(-since))},
+				},
+			},
+		}
+	}
+
+	nodes := &corev1.NodeList{
+		Items: []corev1.Node{
+			mockNode("i-1", corev1.ConditionTrue, time.Hour),
+			mockNode("i-2", corev1.ConditionFalse, 20*time.Minute),
+			mockNode("i-3", corev1.ConditionUnknown, 5*time.Minute),
+			mockNode("i-4", corev1.ConditionFalse, time.Minute),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		instanceIds []string
+		nodes       *corev1.NodeList
+		expected    time.Duration
+	}{
+		{name: "no instances", instanceIds: []string{}, nodes: nodes, expected: 0},
+		{name: "ready", instanceIds: []string{"i-1"}, nodes: nodes, expected: 0},
+		{name: "not ready beyond threshold", instanceIds: []string{"i-2"}, nodes: nodes, expected: 0},
+		{name: "not ready within threshold", instanceIds: []string{"i-1", "i-2", "i-3", "i-4"}, nodes: nodes, expected: 5 * time.Minute},
+		{name: "no nodes", instanceIds: []string{"i-4"}, nodes: nil, expected: 0},
+	}
+
+	for _, tc := range tests {
+		result := GetNotReadyThresholdInterval(tc.instanceIds, tc.nodes, 10*time.Minute)
+		if result.Round(time.Minute) != tc.expected {
+			t.Fatalf("Unexpected result %v. expected %v from %s", result, tc.expected, tc.name)
+		}
+	}
+}
+
 func TestIsStorageError(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -17,7 +17,9 @@ package eks
 
 import (
 	"context"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
 	"github.com/keikoproj/instance-manager/controllers/common"
@@ -45,16 +47,32 @@ type DiscoveredState struct {
 	ClusterNodes         *corev1.NodeList
 	OwnedScalingGroups   []*autoscaling.Group
 	ScalingGroup         *autoscaling.Group
+	ZoneScalingGroups    map[string]*autoscaling.Group
+	SubnetZones          map[string]string
 	LifecycleHooks       []*autoscaling.LifecycleHook
 	ScalingConfiguration scaling.Configuration
 	IAMRole              *iam.Role
 	AttachedPolicies     []*iam.AttachedPolicy
+	InlinePolicies       []string
 	InstanceProfile      *iam.InstanceProfile
 	Publisher            kubeprovider.EventPublisher
 	Cluster              *eks.Cluster
 	VPCId                string
+	VPCCidrs             []string
+	CABundle             string
 	InstancePool         InstancePoolSpec
 	InstanceTypeInfo     []*ec2.InstanceTypeInfo
+	TransientReason      string
+	ExpiredInstances     []string
+	DriftedSGInstances   []string
+	CordonedInstances    []string
+	ProtectedInstances   []string
+	FailedJoinInstances  []string
+	NotReadyInstances    []string
+	NotReadyInterval     time.Duration
+	UnhealthyProtected   []string
+	ClusterEndpoint      string
+	ClusterCA            string
 }
 
 func (ctx *EksInstanceGroupContext) CloudDiscovery() error {
@@ -76,6 +94,10 @@ func (ctx *EksInstanceGroupContext) CloudDiscovery() error {
 		ResourceVersion: instanceGroup.GetResourceVersion(),
 	}
 
+	if configuration.GetDataVolume() != nil && !strings.EqualFold(ctx.GetOsFamily(), OsFamilyBottleRocket) {
+		return errors.Errorf("validation failed, 'dataVolume' is only supported for os family '%v'", OsFamilyBottleRocket)
+	}
+
 	status.SetLifecycle(v1alpha1.LifecycleStateNormal)
 
 	if spec.IsLaunchConfiguration() {
@@ -150,6 +172,12 @@ func (ctx *EksInstanceGroupContext) CloudDiscovery() error {
 				return errors.Wrap(err, "failed to list attached role policies")
 			}
 			state.SetAttachedPolicies(policies)
+
+			inlinePolicies, err := ctx.AwsWorker.ListRoleInlinePolicies(roleName)
+			if err != nil {
+				return errors.Wrap(err, "failed to list inline role policies")
+			}
+			state.SetInlinePolicies(inlinePolicies)
 		}
 	}
 
@@ -162,15 +190,43 @@ func (ctx *EksInstanceGroupContext) CloudDiscovery() error {
 		return errors.Wrap(err, "failed to describe autoscaling groups")
 	}
 
-	cluster, err := ctx.AwsWorker.DescribeEKSCluster(clusterName)
+	cluster, err := ctx.discoverCluster(clusterName)
 	if err != nil {
-		return errors.Wrap(err, "failed to describe cluster")
+		return errors.Wrapf(err, "failed to describe cluster '%v'", clusterName)
 	}
 	state.SetCluster(cluster)
 
+	// explicitly provided apiEndpoint and clusterCA take precedence over discovered values
+	clusterEndpoint, clusterCA := configuration.GetApiEndpoint(), configuration.GetClusterCA()
+	if common.StringEmpty(clusterEndpoint) {
+		clusterEndpoint = aws.StringValue(cluster.Endpoint)
+	}
+	if common.StringEmpty(clusterCA) && cluster.CertificateAuthority != nil {
+		clusterCA = aws.StringValue(cluster.CertificateAuthority.Data)
+	}
+	state.SetClusterEndpoint(clusterEndpoint)
+	state.SetClusterCA(clusterCA)
+
 	vpcID := aws.StringValue(cluster.ResourcesVpcConfig.VpcId)
 	state.SetVPCId(vpcID)
 
+	// nodes reach the VPC directly when their bootstrap is configured with a proxy
+	if configuration.GetProxy() != nil && !common.StringEmpty(vpcID) {
+		cidrs, err := ctx.AwsWorker.VpcCidrs(vpcID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to discover cidrs of vpc '%v'", vpcID)
+		}
+		state.SetVPCCidrs(cidrs)
+	}
+
+	if caBundle := configuration.GetCABundle(); caBundle != nil {
+		bundle, err := ctx.discoverCABundle(caBundle)
+		if err != nil {
+			return errors.Wrap(err, "failed to discover ca bundle")
+		}
+		state.SetCABundle(bundle)
+	}
+
 	instanceTypes, err := ctx.AwsWorker.DescribeInstanceTypes()
 	if err != nil {
 		return errors.Wrap(err, "failed to discover instance types")
@@ -200,12 +256,36 @@ func (ctx *EksInstanceGroupContext) CloudDiscovery() error {
 	// find all owned scaling groups
 	ownedScalingGroups := ctx.findOwnedScalingGroups(scalingGroups)
 	state.SetOwnedScalingGroups(ownedScalingGroups)
+	if instanceGroup.GetDeletionTimestamp().IsZero() {
+		if err := ctx.validateZoneSharding(ownedScalingGroups); err != nil {
+			return err
+		}
+	}
 	// cache the scaling group we are reconciling for if it exists
-	targetScalingGroup := ctx.findTargetScalingGroup(ownedScalingGroups)
+	var targetScalingGroup *autoscaling.Group
+	if configuration.IsZoneSharded() {
+		zones, err := ctx.AwsWorker.SubnetZones(ctx.ResolveSubnets())
+		if err != nil {
+			return errors.Wrap(err, "failed to discover subnet availability zones")
+		}
+		state.SetSubnetZones(zones)
+
+		// zone scaling groups are reconciled as a single aggregated scaling group
+		zoneScalingGroups := ctx.findZoneScalingGroups(ownedScalingGroups)
+		state.SetZoneScalingGroups(zoneScalingGroups)
+		status.SetZoneCapacity(ctx.GetZoneCapacity())
+		targetScalingGroup = aggregateScalingGroups(zoneScalingGroups)
+	} else {
+		targetScalingGroup = ctx.findTargetScalingGroup(ownedScalingGroups)
+	}
 
 	// if there is no scaling group found, it's deprovisioned
 	if targetScalingGroup == nil {
 		state.SetProvisioned(false)
+		status.SetProtectedInstances(nil)
+		status.SetUnhealthyProtectedInstances(nil)
+		status.SetLifecycleCapacity(nil)
+		status.SetImageUpdate(nil)
 		return nil
 	}
 
@@ -223,16 +303,128 @@ func (ctx *EksInstanceGroupContext) CloudDiscovery() error {
 		status.SetLifecycle(v1alpha1.LifecycleStateSpot)
 	}
 
-	state.LifecycleHooks, err = ctx.AwsWorker.DescribeLifecycleHooks(asgName)
+	// lifecycle hooks of zone scaling groups are discovered when each zone scaling group is reconciled
+	if !configuration.IsZoneSharded() {
+		state.LifecycleHooks, err = ctx.AwsWorker.DescribeLifecycleHooks(asgName)
+		if err != nil {
+			return errors.Wrap(err, "failed to describe lifecycle hooks")
+		}
+	}
+
+	// scaling groups in the middle of an operation should not be modified until it completes
+	if instanceGroup.GetDeletionTimestamp().IsZero() {
+		reason, err := ctx.discoverTransientState()
+		if err != nil {
+			return errors.Wrap(err, "failed to discover scaling group state")
+		}
+		state.SetTransientReason(reason)
+	}
+
+	// instances older than the node TTL are replaced through the rolling update
+	if ttl := configuration.GetNodeTTL(); ttl > 0 {
+		expired, err := ctx.discoverExpiredInstances(ttl)
+		if err != nil {
+			return errors.Wrap(err, "failed to discover instance launch times")
+		}
+		state.SetExpiredInstances(expired)
+	}
+
+	// instances whose security groups were changed out-of-band are replaced through the upgrade strategy
+	if configuration.IsSecurityGroupDriftDetectionEnabled() {
+		drifted, err := ctx.discoverSecurityGroupDriftedInstances()
+		if err != nil {
+			return errors.Wrap(err, "failed to discover instance security groups")
+		}
+		state.SetSecurityGroupDriftedInstances(drifted)
+	}
+
+	capacity, err := ctx.discoverLifecycleCapacity(targetScalingGroup)
 	if err != nil {
-		return errors.Wrap(err, "failed to describe lifecycle hooks")
+		ctx.Log.Error(err, "failed to discover instance lifecycles")
+	} else {
+		status.SetLifecycleCapacity(capacity)
 	}
 
+	// the images of running instances are compared with the image published for the image reference at every scheduled
+	// check, or as soon as the reference resolves to a different image
+	if interval := configuration.GetImageUpdateCheckInterval(); interval > 0 {
+		if ctx.imageUpdateCheckDue(interval) {
+			update, err := ctx.discoverImageUpdate(targetScalingGroup)
+			if err != nil {
+				ctx.Log.Error(err, "failed to discover instance images")
+			} else {
+				status.SetImageUpdate(update)
+			}
+		}
+	} else {
+		status.SetImageUpdate(nil)
+	}
+
+	ctx.Log.V(1).Info("discovered scaling group", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName,
+		"instances", len(targetScalingGroup.Instances), "desired", aws.Int64Value(targetScalingGroup.DesiredCapacity), "transient", state.GetTransientReason())
+
 	// update status with scaling group info
 	status.SetActiveScalingGroupName(asgName)
 	status.SetCurrentMin(int(aws.Int64Value(targetScalingGroup.MinSize)))
 	status.SetCurrentMax(int(aws.Int64Value(targetScalingGroup.MaxSize)))
 
+	// nodes annotated with the protect annotation are excluded from rotation
+	instanceIds := make([]string, 0)
+	for _, instance := range targetScalingGroup.Instances {
+		instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+	}
+	protected := kubeprovider.GetProtectedNodesByInstance(instanceIds, state.GetClusterNodes())
+	if len(protected) == 0 {
+		protected = nil
+	}
+	state.SetProtectedInstances(protected)
+	status.SetProtectedInstances(protected)
+
+	// instances whose nodes were cordoned for unhealthy conditions are replaced through the upgrade strategy
+	if len(configuration.GetUnhealthyNodeConditions()) > 0 {
+		if cordoned := kubeprovider.GetUnhealthyCordonedInstances(instanceIds, state.GetClusterNodes()); len(cordoned) > 0 {
+			state.SetUnhealthyCordonedInstances(cordoned)
+		}
+	}
+
+	// instances which have not registered as nodes by the join deadline have failed to join, instances of a newly created
+	// scaling group are still launching
+	var failedJoin []string
+	if deadline := configuration.GetNodeJoinDeadline(); deadline != nil && !ctx.InInitialGracePeriod() {
+		failedJoin, err = ctx.discoverFailedJoinInstances(deadline.GetTimeout())
+		if err != nil {
+			return errors.Wrap(err, "failed to discover instance launch times")
+		}
+	}
+	// console output is collected while instances remain failed to join, it is often empty or incomplete when they are
+	// first reported
+	if deadline := configuration.GetNodeJoinDeadline(); deadline != nil && deadline.CollectConsoleOutput {
+		ctx.PublishBootstrapDiagnostics(failedJoin)
+	} else {
+		status.SetBootstrapDiagnostics(nil)
+	}
+	state.SetFailedJoinInstances(failedJoin)
+	status.SetFailedJoinInstances(failedJoin)
+
+	// nodes which have not been ready for longer than the threshold are replaced, nodes of a newly created scaling group
+	// are still joining
+	var (
+		notReady         []string
+		notReadyInterval time.Duration
+	)
+	if replacement := configuration.GetNotReadyReplacement(); replacement != nil && !ctx.InInitialGracePeriod() {
+		notReady = ctx.discoverNotReadyInstances(replacement.GetThreshold())
+		notReadyInterval = kubeprovider.GetNotReadyThresholdInterval(ctx.inServiceUnprotectedInstances(), state.GetClusterNodes(), replacement.GetThreshold())
+	}
+	state.SetNotReadyInstances(notReady)
+	state.SetNotReadyInterval(notReadyInterval)
+	status.SetNotReadyInstances(notReady)
+
+	// the scaling group cannot replace unhealthy instances while they are protected from scale in
+	unhealthyProtected := discoverUnhealthyProtectedInstances(targetScalingGroup)
+	state.SetUnhealthyProtectedInstances(unhealthyProtected)
+	status.SetUnhealthyProtectedInstances(unhealthyProtected)
+
 	if spec.IsLaunchConfiguration() {
 
 		state.ScalingConfiguration, err = scaling.NewLaunchConfiguration(instanceGroup.NamespacedName(), ctx.AwsWorker, &scaling.DiscoverConfigurationInput{
@@ -270,19 +462,31 @@ func (ctx *EksInstanceGroupContext) CloudDiscovery() error {
 		)
 
 		state.SetSubFamilyFlexiblePool(pool)
+		if err := state.InstancePool.SubFamilyFlexiblePool.Validate(); err != nil {
+			return err
+		}
 		status.SetActiveLaunchTemplateName(resourceName)
 		status.SetLatestTemplateVersion(latestVersionStr)
 	}
 
-	// delete old launch configurations
+	// delete old launch configurations, a pinned launch template version and versions in use by the scaling groups are always retained
+	pinnedVersion, _ := ctx.GetPinnedLaunchTemplateVersion()
 	if err := state.ScalingConfiguration.Delete(&scaling.DeleteConfigurationInput{
 		Name:           state.ScalingConfiguration.Name(),
 		Prefix:         ctx.ResourcePrefix,
 		DeleteAll:      false,
 		RetainVersions: ctx.ConfigRetention,
+		PinnedVersion:  pinnedVersion,
+		ScalingGroups:  state.GetOwnedScalingGroups(),
 	}); err != nil {
 		ctx.Log.Error(err, "failed to delete old scaling configurations")
 	}
+	if template, ok := state.ScalingConfiguration.(*scaling.LaunchTemplate); ok {
+		status.AddCleanedLaunchTemplateVersions(template.CleanedVersions)
+	}
+	ctx.UpdateScalingConfigurationStatus()
+	ctx.UpdateCostEstimateStatus()
+	ctx.resetImageUpdate(targetScalingGroup)
 
 	switch status.GetNodesReadyCondition() {
 	case corev1.ConditionTrue:
@@ -306,6 +510,456 @@ func (d *DiscoveredState) GetScalingGroup() *autoscaling.Group {
 	return &autoscaling.Group{}
 }
 
+func (d *DiscoveredState) SetZoneScalingGroups(groups map[string]*autoscaling.Group) {
+	d.ZoneScalingGroups = groups
+}
+func (d *DiscoveredState) GetZoneScalingGroups() map[string]*autoscaling.Group {
+	if d.ZoneScalingGroups == nil {
+		return map[string]*autoscaling.Group{}
+	}
+	return d.ZoneScalingGroups
+}
+func (d *DiscoveredState) SetSubnetZones(zones map[string]string) {
+	d.SubnetZones = zones
+}
+
+// discoverCABundle returns the certificates of a CA bundle, reading them from the referenced ConfigMap in the instance
+// group's namespace unless they are set inline
+func (ctx *EksInstanceGroupContext) discoverCABundle(caBundle *v1alpha1.CABundleSpec) (string, error) {
+	bundle := caBundle.Certificates
+	if ref := caBundle.ConfigMapRef; ref != nil {
+		namespace := ctx.GetInstanceGroup().GetNamespace()
+		cm, err := ctx.KubernetesClient.Kubernetes.CoreV1().ConfigMaps(namespace).Get(context.Background(), ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get configmap %v/%v", namespace, ref.Name)
+		}
+		var ok bool
+		if bundle, ok = cm.Data[ref.GetKey()]; !ok {
+			return "", errors.Errorf("configmap %v/%v has no key %v", namespace, ref.Name, ref.GetKey())
+		}
+	}
+	if _, err := common.ParseCertificates(bundle); err != nil {
+		return "", errors.Wrap(err, "invalid ca bundle")
+	}
+	return bundle, nil
+}
+
+// discoverCluster describes the EKS cluster, resolving it from clusterNameSource when the control plane name differs from clusterName.
+// Clusters are listed and described through the worker's cache, so resolving a cluster by endpoint does not describe
+// every cluster of the region on each reconcile
+func (ctx *EksInstanceGroupContext) discoverCluster(clusterName string) (*eks.Cluster, error) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		source        = configuration.GetClusterNameSource()
+	)
+
+	if source == nil {
+		return ctx.AwsWorker.DescribeEKSCluster(clusterName)
+	}
+
+	if !common.StringEmpty(source.Arn) {
+		name := source.ClusterName()
+		cluster, err := ctx.AwsWorker.DescribeEKSCluster(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to describe cluster %v", name)
+		}
+		if !strings.EqualFold(aws.StringValue(cluster.Arn), source.Arn) {
+			return nil, errors.Errorf("cluster %v does not match arn %v", name, source.Arn)
+		}
+		return cluster, nil
+	}
+
+	clusters, err := ctx.AwsWorker.ListEKSClusters()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list clusters")
+	}
+
+	for _, name := range clusters {
+		cluster, err := ctx.AwsWorker.DescribeEKSCluster(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to describe cluster %v", name)
+		}
+		if strings.EqualFold(strings.TrimSuffix(aws.StringValue(cluster.Endpoint), "/"), strings.TrimSuffix(source.Endpoint, "/")) {
+			ctx.Log.Info("resolved cluster by endpoint", "instancegroup", instanceGroup.NamespacedName(), "cluster", name, "endpoint", source.Endpoint)
+			return cluster, nil
+		}
+	}
+	return nil, errors.Errorf("could not find a cluster with endpoint %v", source.Endpoint)
+}
+
+// discoverExpiredInstances returns the in-service instances which have exceeded the node TTL, oldest first
+func (ctx *EksInstanceGroupContext) discoverExpiredInstances(ttl time.Duration) ([]string, error) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		instanceIds   = make([]string, 0)
+		expired       = make([]string, 0)
+	)
+
+	for _, instance := range scalingGroup.Instances {
+		if aws.StringValue(instance.LifecycleState) != autoscaling.LifecycleStateInService {
+			continue
+		}
+		instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+	}
+
+	launchTimes, err := ctx.AwsWorker.DescribeInstanceLaunchTimes(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range instanceIds {
+		launchTime, ok := launchTimes[id]
+		if !ok {
+			continue
+		}
+		if time.Since(launchTime) > ttl {
+			expired = append(expired, id)
+		}
+	}
+
+	sort.SliceStable(expired, func(i, j int) bool {
+		return launchTimes[expired[i]].Before(launchTimes[expired[j]])
+	})
+
+	if len(expired) > 0 {
+		ctx.Log.Info("instances exceeded node ttl", "instancegroup", instanceGroup.NamespacedName(), "ttl", ttl.String(), "instances", expired)
+	}
+	return expired, nil
+}
+
+// discoverSecurityGroupDriftedInstances returns the in-service instances whose primary network interface does not have
+// exactly the node security groups, instances are not checked while a security group cannot be resolved
+func (ctx *EksInstanceGroupContext) discoverSecurityGroupDriftedInstances() ([]string, error) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		desired       = ctx.ResolveSecurityGroups()
+		instanceIds   = make([]string, 0)
+		drifted       = make([]string, 0)
+	)
+
+	if len(desired) != len(configuration.GetSecurityGroups()) {
+		ctx.Log.Info("skipping security group drift detection, not all security groups were resolved", "instancegroup", instanceGroup.NamespacedName())
+		return drifted, nil
+	}
+
+	for _, instance := range scalingGroup.Instances {
+		if aws.StringValue(instance.LifecycleState) != autoscaling.LifecycleStateInService {
+			continue
+		}
+		instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+	}
+
+	instances, err := ctx.AwsWorker.DescribeInstances(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances {
+		groups := make([]string, 0)
+		for _, group := range instance.SecurityGroups {
+			groups = append(groups, aws.StringValue(group.GroupId))
+		}
+		for _, eni := range instance.NetworkInterfaces {
+			if eni.Attachment != nil && aws.Int64Value(eni.Attachment.DeviceIndex) == 0 {
+				groups = make([]string, 0)
+				for _, group := range eni.Groups {
+					groups = append(groups, aws.StringValue(group.GroupId))
+				}
+			}
+		}
+
+		if !common.StringSliceEquals(groups, append([]string{}, desired...)) {
+			drifted = append(drifted, aws.StringValue(instance.InstanceId))
+			ctx.Log.Info("detected security group drift", "instancegroup", instanceGroup.NamespacedName(), "instance", aws.StringValue(instance.InstanceId),
+				"previousValue", groups,
+				"newValue", desired,
+			)
+		}
+	}
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// discoverFailedJoinInstances returns the in-service instances which have not registered as nodes within the timeout
+// of launching, or nil if all instances have joined
+func (ctx *EksInstanceGroupContext) discoverFailedJoinInstances(timeout time.Duration) ([]string, error) {
+	var (
+		state        = ctx.GetDiscoveredState()
+		scalingGroup = state.GetScalingGroup()
+		instanceIds  = make([]string, 0)
+		failed       []string
+	)
+
+	for _, instance := range scalingGroup.Instances {
+		if aws.StringValue(instance.LifecycleState) != autoscaling.LifecycleStateInService {
+			continue
+		}
+		instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+	}
+
+	unjoined := kubeprovider.GetUnjoinedInstances(instanceIds, state.GetClusterNodes())
+	if len(unjoined) == 0 {
+		return nil, nil
+	}
+
+	launchTimes, err := ctx.AwsWorker.DescribeInstanceLaunchTimes(unjoined)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range unjoined {
+		launchTime, ok := launchTimes[id]
+		if !ok {
+			continue
+		}
+		if time.Since(launchTime) > timeout {
+			failed = append(failed, id)
+		}
+	}
+	return failed, nil
+}
+
+// discoverNotReadyInstances returns the in-service instances whose nodes have not been ready for longer than the
+// threshold, those NotReady the longest first, or nil if there are none. Protected instances are not replaced
+func (ctx *EksInstanceGroupContext) discoverNotReadyInstances(threshold time.Duration) []string {
+	state := ctx.GetDiscoveredState()
+	notReady := kubeprovider.GetNotReadyNodesByInstance(ctx.inServiceUnprotectedInstances(), state.GetClusterNodes(), threshold)
+	if len(notReady) == 0 {
+		return nil
+	}
+	return notReady
+}
+
+// inServiceUnprotectedInstances returns the InService instances of the scaling group which are not protected from
+// replacement
+func (ctx *EksInstanceGroupContext) inServiceUnprotectedInstances() []string {
+	var (
+		state        = ctx.GetDiscoveredState()
+		scalingGroup = state.GetScalingGroup()
+		protected    = state.GetProtectedInstances()
+		instanceIds  = make([]string, 0)
+	)
+
+	for _, instance := range scalingGroup.Instances {
+		instanceId := aws.StringValue(instance.InstanceId)
+		if aws.StringValue(instance.LifecycleState) != autoscaling.LifecycleStateInService || common.ContainsEqualFold(protected, instanceId) {
+			continue
+		}
+		instanceIds = append(instanceIds, instanceId)
+	}
+	return instanceIds
+}
+
+// discoverUnhealthyProtectedInstances returns the instances of the scaling group which are unhealthy and protected from
+// scale in, or nil if there are none
+func discoverUnhealthyProtectedInstances(scalingGroup *autoscaling.Group) []string {
+	var unhealthy []string
+	for _, instance := range scalingGroup.Instances {
+		if aws.BoolValue(instance.ProtectedFromScaleIn) && strings.EqualFold(aws.StringValue(instance.HealthStatus), awsprovider.InstanceHealthStatusUnhealthy) {
+			unhealthy = append(unhealthy, aws.StringValue(instance.InstanceId))
+		}
+	}
+	return unhealthy
+}
+
+// discoverLifecycleCapacity returns the number of on-demand and spot instances in the scaling group, instances are only
+// described when the group's lifecycle is mixed
+func (ctx *EksInstanceGroupContext) discoverLifecycleCapacity(scalingGroup *autoscaling.Group) (*v1alpha1.LifecycleCapacityStatus, error) {
+	var (
+		status    = ctx.GetInstanceGroup().GetStatus()
+		instances = scalingGroup.Instances
+		capacity  = &v1alpha1.LifecycleCapacityStatus{}
+	)
+
+	switch status.GetLifecycle() {
+	case v1alpha1.LifecycleStateSpot:
+		capacity.Spot = len(instances)
+		return capacity, nil
+	case v1alpha1.LifecycleStateNormal:
+		capacity.OnDemand = len(instances)
+		return capacity, nil
+	}
+
+	instanceIds := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+	}
+
+	lifecycles, err := ctx.AwsWorker.DescribeInstanceLifecycles(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range instanceIds {
+		lifecycle, ok := lifecycles[id]
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(lifecycle, ec2.InstanceLifecycleTypeSpot) {
+			capacity.Spot++
+		} else {
+			capacity.OnDemand++
+		}
+	}
+	return capacity, nil
+}
+
+// imageUpdateCheckDue returns true if the images of running instances were not checked within the interval, or the image
+// reference resolved to a different image since the last check. Checks are not repeated at every reconcile since
+// updating the check time in the status triggers another reconcile.
+func (ctx *EksInstanceGroupContext) imageUpdateCheckDue(interval time.Duration) bool {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		previous      = instanceGroup.GetStatus().GetImageUpdate()
+	)
+	if previous == nil || previous.AvailableImageID != instanceGroup.GetEKSConfiguration().GetImage() {
+		return true
+	}
+	return time.Since(previous.LastCheckTime.Time) >= interval
+}
+
+// discoverImageUpdate compares the image resolved for the image reference with the images the scaling group's instances
+// were launched from, an event is published when the reference resolves to a different image than at the last check
+func (ctx *EksInstanceGroupContext) discoverImageUpdate(scalingGroup *autoscaling.Group) (*v1alpha1.ImageUpdateStatus, error) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		state         = ctx.GetDiscoveredState()
+		available     = instanceGroup.GetEKSConfiguration().GetImage()
+		update        = &v1alpha1.ImageUpdateStatus{
+			AvailableImageID: available,
+			LastCheckTime:    metav1.Now(),
+		}
+	)
+
+	instanceIds := make([]string, 0, len(scalingGroup.Instances))
+	for _, instance := range scalingGroup.Instances {
+		instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+	}
+
+	images, err := ctx.AwsWorker.DescribeInstanceImages(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range instanceIds {
+		image, ok := images[id]
+		if !ok {
+			continue
+		}
+		if !common.ContainsString(update.RunningImageIDs, image) {
+			update.RunningImageIDs = append(update.RunningImageIDs, image)
+		}
+		if image != available {
+			update.UpdateAvailable = true
+		}
+	}
+	sort.Strings(update.RunningImageIDs)
+
+	if previous := status.GetImageUpdate(); previous != nil && previous.AvailableImageID != available {
+		ctx.Log.Info("image reference resolved to a new image", "instancegroup", instanceGroup.NamespacedName(), "previous", previous.AvailableImageID, "image", available)
+		state.Publisher.Publish(kubeprovider.ImageUpdateAvailableEvent, "instancegroup", instanceGroup.NamespacedName(), "previousImage", previous.AvailableImageID, "image", available)
+	}
+	return update, nil
+}
+
+// resetImageUpdate clears an available image update once the scaling configuration uses the available image and all
+// instances were launched from it, rather than reporting the update until the next scheduled check
+func (ctx *EksInstanceGroupContext) resetImageUpdate(scalingGroup *autoscaling.Group) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		status        = instanceGroup.GetStatus()
+		state         = ctx.GetDiscoveredState()
+		update        = status.GetImageUpdate()
+	)
+	if update == nil || !update.UpdateAvailable || status.GetActiveImageID() != update.AvailableImageID {
+		return
+	}
+
+	pinnedVersion, _ := ctx.GetPinnedLaunchTemplateVersion()
+	if state.ScalingConfiguration.RotationNeeded(&scaling.DiscoverConfigurationInput{
+		ScalingGroup:          scalingGroup,
+		PinnedVersion:         pinnedVersion,
+		IgnoreCosmeticChanges: configuration.IsCosmeticChangeRotationSkipped(),
+	}) {
+		return
+	}
+
+	ctx.Log.Info("instances run the available image", "instancegroup", instanceGroup.NamespacedName(), "image", update.AvailableImageID)
+	update.UpdateAvailable = false
+	update.RunningImageIDs = nil
+	if len(scalingGroup.Instances) > 0 {
+		update.RunningImageIDs = []string{update.AvailableImageID}
+	}
+}
+
+func (d *DiscoveredState) SetTransientReason(reason string) {
+	d.TransientReason = reason
+}
+func (d *DiscoveredState) GetTransientReason() string {
+	return d.TransientReason
+}
+func (d *DiscoveredState) IsTransient() bool {
+	return d.TransientReason != ""
+}
+func (d *DiscoveredState) SetExpiredInstances(instances []string) {
+	d.ExpiredInstances = instances
+}
+func (d *DiscoveredState) GetExpiredInstances() []string {
+	return d.ExpiredInstances
+}
+func (d *DiscoveredState) SetSecurityGroupDriftedInstances(instances []string) {
+	d.DriftedSGInstances = instances
+}
+func (d *DiscoveredState) GetSecurityGroupDriftedInstances() []string {
+	return d.DriftedSGInstances
+}
+func (d *DiscoveredState) SetUnhealthyCordonedInstances(instances []string) {
+	d.CordonedInstances = instances
+}
+func (d *DiscoveredState) GetUnhealthyCordonedInstances() []string {
+	return d.CordonedInstances
+}
+func (d *DiscoveredState) SetProtectedInstances(instances []string) {
+	d.ProtectedInstances = instances
+}
+func (d *DiscoveredState) GetProtectedInstances() []string {
+	return d.ProtectedInstances
+}
+func (d *DiscoveredState) SetFailedJoinInstances(instances []string) {
+	d.FailedJoinInstances = instances
+}
+func (d *DiscoveredState) GetFailedJoinInstances() []string {
+	return d.FailedJoinInstances
+}
+func (d *DiscoveredState) SetNotReadyInstances(instances []string) {
+	d.NotReadyInstances = instances
+}
+func (d *DiscoveredState) GetNotReadyInstances() []string {
+	return d.NotReadyInstances
+}
+func (d *DiscoveredState) SetNotReadyInterval(interval time.Duration) {
+	d.NotReadyInterval = interval
+}
+
+// GetNotReadyInterval returns the time until the next NotReady node exceeds the not ready replacement threshold, the
+// instance group is requeued then since node events are only received when nodes become NotReady
+func (d *DiscoveredState) GetNotReadyInterval() time.Duration {
+	return d.NotReadyInterval
+}
+func (d *DiscoveredState) SetUnhealthyProtectedInstances(instances []string) {
+	d.UnhealthyProtected = instances
+}
+func (d *DiscoveredState) GetUnhealthyProtectedInstances() []string {
+	return d.UnhealthyProtected
+}
 func (d *DiscoveredState) SetCluster(cluster *eks.Cluster) {
 	d.Cluster = cluster
 }
@@ -322,6 +976,22 @@ func (d *DiscoveredState) GetVPCId() string {
 	return d.VPCId
 }
 
+func (d *DiscoveredState) SetVPCCidrs(cidrs []string) {
+	d.VPCCidrs = cidrs
+}
+
+func (d *DiscoveredState) GetVPCCidrs() []string {
+	return d.VPCCidrs
+}
+
+func (d *DiscoveredState) SetCABundle(bundle string) {
+	d.CABundle = bundle
+}
+
+func (d *DiscoveredState) GetCABundle() string {
+	return d.CABundle
+}
+
 func (d *DiscoveredState) GetClusterVersion() string {
 	if d.Cluster == nil {
 		return ""
@@ -329,14 +999,28 @@ func (d *DiscoveredState) GetClusterVersion() string {
 	return aws.StringValue(d.Cluster.Version)
 }
 
+func (d *DiscoveredState) SetClusterCA(ca string) {
+	d.ClusterCA = ca
+}
+
 func (d *DiscoveredState) GetClusterCA() string {
-	if d.Cluster == nil {
+	if !common.StringEmpty(d.ClusterCA) {
+		return d.ClusterCA
+	}
+	if d.Cluster == nil || d.Cluster.CertificateAuthority == nil {
 		return ""
 	}
 	return aws.StringValue(d.Cluster.CertificateAuthority.Data)
 }
 
+func (d *DiscoveredState) SetClusterEndpoint(endpoint string) {
+	d.ClusterEndpoint = endpoint
+}
+
 func (d *DiscoveredState) GetClusterEndpoint() string {
+	if !common.StringEmpty(d.ClusterEndpoint) {
+		return d.ClusterEndpoint
+	}
 	if d.Cluster == nil {
 		return ""
 	}
@@ -352,6 +1036,12 @@ func (d *DiscoveredState) GetOwnedScalingGroups() []*autoscaling.Group {
 func (d *DiscoveredState) GetScalingConfiguration() scaling.Configuration {
 	return d.ScalingConfiguration
 }
+func (d *DiscoveredState) SetInlinePolicies(policies []string) {
+	d.InlinePolicies = policies
+}
+func (d *DiscoveredState) GetInlinePolicies() []string {
+	return d.InlinePolicies
+}
 func (d *DiscoveredState) SetAttachedPolicies(policies []*iam.AttachedPolicy) {
 	d.AttachedPolicies = policies
 }
@@ -17,7 +17,10 @@ package eks
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,8 +30,11 @@ import (
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
 	"github.com/keikoproj/instance-manager/controllers/provisioners"
 	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -116,6 +122,986 @@ func TestCloudDiscoveryPositive(t *testing.T) {
 	g.Expect(status.GetCurrentMax()).To(gomega.Equal(6))
 }
 
+func TestCloudDiscoveryZoneSharded(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	status := ig.GetStatus()
+	configuration := ig.GetEKSConfiguration()
+	configuration.ZoneSharding = true
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2"})
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+
+	ec2Mock.Subnets = []*ec2.Subnet{
+		{SubnetId: aws.String("subnet-1"), AvailabilityZone: aws.String("us-west-2a")},
+		{SubnetId: aws.String("subnet-2"), AvailabilityZone: aws.String("us-west-2b")},
+	}
+
+	var (
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, configuration.GetClusterName())
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		zoneA        = MockScalingGroup("scaling-group-a", false, ownershipTag, nameTag, namespaceTag, MockTagDescription(provisioners.TagAvailabilityZone, "us-west-2a"))
+		zoneB        = MockScalingGroup("scaling-group-b", false, ownershipTag, nameTag, namespaceTag, MockTagDescription(provisioners.TagAvailabilityZone, "us-west-2b"))
+	)
+	zoneA.DesiredCapacity = aws.Int64(2)
+	zoneA.Instances = []*autoscaling.Instance{
+		{InstanceId: aws.String("i-1"), LifecycleState: aws.String(autoscaling.LifecycleStateInService)},
+		{InstanceId: aws.String("i-2"), LifecycleState: aws.String(autoscaling.LifecycleStatePending)},
+	}
+	zoneB.DesiredCapacity = aws.Int64(1)
+	zoneB.Instances = []*autoscaling.Instance{
+		{InstanceId: aws.String("i-3"), LifecycleState: aws.String(autoscaling.LifecycleStateInService)},
+	}
+
+	asgMock.AutoScalingGroups = []*autoscaling.Group{
+		zoneB,
+		zoneA,
+		MockScalingGroup("scaling-group-2", false, ownershipTag),
+	}
+
+	eksMock.EksCluster = &eks.Cluster{
+		ResourcesVpcConfig: &eks.VpcConfigResponse{
+			VpcId: aws.String("vpc-1234567890"),
+		},
+	}
+
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	aggregate := state.GetScalingGroup()
+	g.Expect(state.IsProvisioned()).To(gomega.BeTrue())
+	g.Expect(state.GetZoneScalingGroups()).To(gomega.HaveLen(2))
+	g.Expect(aws.StringValue(aggregate.AutoScalingGroupName)).To(gomega.Equal("scaling-group-a"))
+	g.Expect(aggregate.Instances).To(gomega.HaveLen(3))
+	g.Expect(aws.Int64Value(aggregate.DesiredCapacity)).To(gomega.Equal(int64(3)))
+	g.Expect(status.GetCurrentMin()).To(gomega.Equal(6))
+	g.Expect(status.GetCurrentMax()).To(gomega.Equal(12))
+	g.Expect(status.GetZoneCapacity()).To(gomega.Equal([]v1alpha1.ZoneCapacityStatus{
+		{Zone: "us-west-2a", ScalingGroupName: "scaling-group-a", CurrentMin: 3, CurrentMax: 6, DesiredCapacity: 2, InService: 1},
+		{Zone: "us-west-2b", ScalingGroupName: "scaling-group-b", CurrentMin: 3, CurrentMax: 6, DesiredCapacity: 1, InService: 1},
+	}))
+	// settings of the zone scaling groups are reconciled for each of them and are not taken from the first zone
+	g.Expect(aggregate.Tags).To(gomega.BeNil())
+	g.Expect(aggregate.SuspendedProcesses).To(gomega.BeNil())
+	g.Expect(aggregate.EnabledMetrics).To(gomega.BeNil())
+	g.Expect(zoneA.Tags).NotTo(gomega.BeEmpty())
+
+	// zone sharding cannot be disabled while zone scaling groups exist
+	configuration.ZoneSharding = false
+	err = ctx.CloudDiscovery()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// zone sharding cannot be enabled while an unsharded scaling group exists
+	configuration.ZoneSharding = true
+	asgMock.AutoScalingGroups = []*autoscaling.Group{
+		MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag),
+	}
+	err = ctx.CloudDiscovery()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestCloudDiscoveryClusterNameSource(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+
+	var (
+		eksClusterName = "my-eks-cluster"
+		eksClusterArn  = "arn:aws:eks:us-west-2:123456789012:cluster/my-eks-cluster"
+		eksEndpoint    = "https://ABCDEF.gr7.us-west-2.eks.amazonaws.com"
+	)
+
+	otherCluster := MockEksCluster("1.30")
+	otherCluster.Name = aws.String("other-cluster")
+	otherCluster.Arn = aws.String("arn:aws:eks:us-west-2:123456789012:cluster/other-cluster")
+	otherCluster.Endpoint = aws.String("https://012345.gr7.us-west-2.eks.amazonaws.com")
+	otherCluster.ResourcesVpcConfig.VpcId = aws.String("vpc-0987654321")
+
+	eksCluster := MockEksCluster("1.30")
+	eksCluster.Name = aws.String(eksClusterName)
+	eksCluster.Arn = aws.String(eksClusterArn)
+	eksCluster.Endpoint = aws.String(eksEndpoint)
+	eksCluster.ResourcesVpcConfig.VpcId = aws.String("vpc-1234567890")
+
+	eksMock.EksClusters = []*eks.Cluster{otherCluster, eksCluster}
+
+	tests := []struct {
+		source      *v1alpha1.ClusterNameSourceSpec
+		shouldErr   bool
+		clusterName string
+	}{
+		{source: nil, shouldErr: true},
+		{source: &v1alpha1.ClusterNameSourceSpec{Arn: eksClusterArn}, clusterName: eksClusterName},
+		{source: &v1alpha1.ClusterNameSourceSpec{Endpoint: eksEndpoint + "/"}, clusterName: eksClusterName},
+		{source: &v1alpha1.ClusterNameSourceSpec{Arn: "arn:aws:eks:us-west-2:123456789012:cluster/missing-cluster"}, shouldErr: true},
+		{source: &v1alpha1.ClusterNameSourceSpec{Arn: "arn:aws:eks:us-east-1:123456789012:cluster/my-eks-cluster"}, shouldErr: true},
+		{source: &v1alpha1.ClusterNameSourceSpec{Endpoint: "https://missing.gr7.us-west-2.eks.amazonaws.com"}, shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v -> %+v", i, tc.source)
+		ctx.SetDiscoveredState(&DiscoveredState{})
+		configuration.ClusterNameSource = tc.source
+
+		err := ctx.CloudDiscovery()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		userData, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData(ctx.GetBootstrapClusterName(), "", "", UserDataPayload{}, nil))
+		g.Expect(ctx.GetBootstrapClusterName()).To(gomega.Equal(tc.clusterName))
+		g.Expect(string(userData)).To(gomega.ContainSubstring(fmt.Sprintf("/etc/eks/bootstrap.sh %v", tc.clusterName)))
+		g.Expect(ctx.ResourcePrefix).To(gomega.HavePrefix(configuration.GetClusterName()))
+		g.Expect(ctx.GetDiscoveredState().GetVPCId()).To(gomega.Equal("vpc-1234567890"))
+	}
+
+	configuration.ClusterNameSource = &v1alpha1.ClusterNameSourceSpec{Endpoint: eksEndpoint}
+	eksMock.ListClustersErr = errors.New("some-error")
+	err := ctx.CloudDiscovery()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestCloudDiscoveryClusterEndpointAndCA(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration := ig.GetEKSConfiguration()
+	ig.Annotations[OsFamilyAnnotation] = OsFamilyBottleRocket
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+	eksMock.EksCluster.Endpoint = aws.String("https://discovered.gr7.us-west-2.eks.amazonaws.com")
+	eksMock.EksCluster.CertificateAuthority.Data = aws.String("ZGlzY292ZXJlZA==")
+
+	tests := []struct {
+		apiEndpoint      string
+		clusterCA        string
+		expectedEndpoint string
+		expectedCA       string
+	}{
+		{expectedEndpoint: "https://discovered.gr7.us-west-2.eks.amazonaws.com", expectedCA: "ZGlzY292ZXJlZA=="},
+		{apiEndpoint: "https://explicit.gr7.us-west-2.eks.amazonaws.com", expectedEndpoint: "https://explicit.gr7.us-west-2.eks.amazonaws.com", expectedCA: "ZGlzY292ZXJlZA=="},
+		{clusterCA: "ZXhwbGljaXQ=", expectedEndpoint: "https://discovered.gr7.us-west-2.eks.amazonaws.com", expectedCA: "ZXhwbGljaXQ="},
+		{apiEndpoint: "https://explicit.gr7.us-west-2.eks.amazonaws.com", clusterCA: "ZXhwbGljaXQ=", expectedEndpoint: "https://explicit.gr7.us-west-2.eks.amazonaws.com", expectedCA: "ZXhwbGljaXQ="},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v -> %v, %v", i, tc.expectedEndpoint, tc.expectedCA)
+		ctx.SetDiscoveredState(&DiscoveredState{})
+		configuration.ApiEndpoint = tc.apiEndpoint
+		configuration.ClusterCA = tc.clusterCA
+
+		err := ctx.CloudDiscovery()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		state := ctx.GetDiscoveredState()
+		g.Expect(state.GetClusterEndpoint()).To(gomega.Equal(tc.expectedEndpoint))
+		g.Expect(state.GetClusterCA()).To(gomega.Equal(tc.expectedCA))
+
+		userData, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData(ctx.GetBootstrapClusterName(), "", "", UserDataPayload{}, nil))
+		g.Expect(string(userData)).To(gomega.ContainSubstring(fmt.Sprintf("api-server   = \"%v\"", tc.expectedEndpoint)))
+		g.Expect(string(userData)).To(gomega.ContainSubstring(fmt.Sprintf("cluster-certificate = \"%v\"", tc.expectedCA)))
+	}
+
+	configuration.ApiEndpoint = ""
+	configuration.ClusterCA = ""
+	eksMock.DescribeClusterErr = errors.New("some-error")
+	err := ctx.CloudDiscovery()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring(fmt.Sprintf("failed to describe cluster '%v'", configuration.GetClusterName())))
+}
+
+func TestCloudDiscoveryCABundle(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	_, err := k.Kubernetes.CoreV1().ConfigMaps(ig.GetNamespace()).Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "internal-ca", Namespace: ig.GetNamespace()},
+		Data:       map[string]string{"ca-bundle.crt": MockCertificate, "ca.crt": MockCertificate, "invalid.crt": "not a certificate"},
+	}, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	tests := []struct {
+		caBundle    *v1alpha1.CABundleSpec
+		expected    string
+		expectedErr bool
+	}{
+		{},
+		{caBundle: &v1alpha1.CABundleSpec{Certificates: MockCertificate}, expected: MockCertificate},
+		{caBundle: &v1alpha1.CABundleSpec{ConfigMapRef: &v1alpha1.CABundleConfigMapReference{Name: "internal-ca"}}, expected: MockCertificate},
+		{caBundle: &v1alpha1.CABundleSpec{ConfigMapRef: &v1alpha1.CABundleConfigMapReference{Name: "internal-ca", Key: "ca.crt"}}, expected: MockCertificate},
+		{caBundle: &v1alpha1.CABundleSpec{ConfigMapRef: &v1alpha1.CABundleConfigMapReference{Name: "internal-ca", Key: "other.crt"}}, expectedErr: true},
+		{caBundle: &v1alpha1.CABundleSpec{ConfigMapRef: &v1alpha1.CABundleConfigMapReference{Name: "internal-ca", Key: "invalid.crt"}}, expectedErr: true},
+		{caBundle: &v1alpha1.CABundleSpec{ConfigMapRef: &v1alpha1.CABundleConfigMapReference{Name: "other-ca"}}, expectedErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v -> %+v", i, tc.caBundle)
+		ctx.SetDiscoveredState(&DiscoveredState{})
+		configuration.CABundle = tc.caBundle
+
+		err := ctx.CloudDiscovery()
+		if tc.expectedErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			g.Expect(err.Error()).To(gomega.ContainSubstring("failed to discover ca bundle"))
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(ctx.GetDiscoveredState().GetCABundle()).To(gomega.Equal(tc.expected))
+	}
+}
+
+func TestCloudDiscoveryDataVolume(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration := ig.GetEKSConfiguration()
+	configuration.DataVolume = &v1alpha1.NodeVolume{Name: v1alpha1.DefaultDataVolumeName, Type: "gp2", Size: 50}
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	tests := []struct {
+		osFamily  string
+		shouldErr bool
+	}{
+		{osFamily: OsFamilyBottleRocket, shouldErr: false},
+		{osFamily: OsFamilyAmazonLinux2, shouldErr: true},
+		{osFamily: OsFamilyWindows, shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v -> %v", i, tc.osFamily)
+		ig.Annotations[OsFamilyAnnotation] = tc.osFamily
+		err := ctx.CloudDiscovery()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			g.Expect(err.Error()).To(gomega.Equal("validation failed, 'dataVolume' is only supported for os family 'bottlerocket'"))
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+}
+
+func TestCloudDiscoveryNodeTTL(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	var (
+		clusterName  = configuration.GetClusterName()
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		scalingGroup = MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+		now          = time.Now()
+	)
+
+	mockInstance := func(id, lifecycleState string, age time.Duration) {
+		scalingGroup.Instances = append(scalingGroup.Instances, &autoscaling.Instance{
+			InstanceId:     aws.String(id),
+			LifecycleState: aws.String(lifecycleState),
+		})
+		ec2Mock.Instances = append(ec2Mock.Instances, &ec2.Instance{
+			InstanceId: aws.String(id),
+			LaunchTime: aws.Time(now.Add(-age)),
+		})
+	}
+
+	scalingGroup.Instances = []*autoscaling.Instance{}
+	mockInstance("i-000000001", autoscaling.LifecycleStateInService, 48*time.Hour)
+	mockInstance("i-000000002", autoscaling.LifecycleStateInService, time.Hour)
+	mockInstance("i-000000003", autoscaling.LifecycleStateInService, 72*time.Hour)
+	mockInstance("i-000000004", autoscaling.LifecycleStateTerminating, 96*time.Hour)
+	asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+
+	// node ttl is opt-in
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetExpiredInstances()).To(gomega.BeEmpty())
+
+	// aged in-service instances are expired, oldest first
+	configuration.NodeTTL = "24h"
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetExpiredInstances()).To(gomega.Equal([]string{"i-000000003", "i-000000001"}))
+
+	configuration.NodeTTL = "100h"
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetExpiredInstances()).To(gomega.BeEmpty())
+
+	ec2Mock.DescribeInstancesErr = errors.New("some-error")
+	err = ctx.CloudDiscovery()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestCloudDiscoverySecurityGroupDrift(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	configuration := ig.GetEKSConfiguration()
+	configuration.NodeSecurityGroups = []string{"sg-222222", "sg-111111"}
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	var (
+		clusterName  = configuration.GetClusterName()
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		scalingGroup = MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+	)
+
+	mockInstance := func(id, lifecycleState string, groups ...string) {
+		scalingGroup.Instances = append(scalingGroup.Instances, &autoscaling.Instance{
+			InstanceId:     aws.String(id),
+			LifecycleState: aws.String(lifecycleState),
+		})
+		identifiers := []*ec2.GroupIdentifier{}
+		for _, group := range groups {
+			identifiers = append(identifiers, &ec2.GroupIdentifier{GroupId: aws.String(group)})
+		}
+		ec2Mock.Instances = append(ec2Mock.Instances, &ec2.Instance{
+			InstanceId:     aws.String(id),
+			SecurityGroups: identifiers,
+			NetworkInterfaces: []*ec2.InstanceNetworkInterface{
+				{
+					Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(0)},
+					Groups:     identifiers,
+				},
+			},
+		})
+	}
+
+	scalingGroup.Instances = []*autoscaling.Instance{}
+	mockInstance("i-000000001", autoscaling.LifecycleStateInService, "sg-111111", "sg-222222")
+	mockInstance("i-000000002", autoscaling.LifecycleStateInService, "sg-111111")
+	mockInstance("i-000000003", autoscaling.LifecycleStateInService, "sg-111111", "sg-222222", "sg-333333")
+	mockInstance("i-000000004", autoscaling.LifecycleStateTerminating, "sg-333333")
+	asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+
+	// security group drift detection is opt-in
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetSecurityGroupDriftedInstances()).To(gomega.BeEmpty())
+
+	// in-service instances with missing or additional security groups have drifted
+	configuration.DetectSecurityGroupDrift = true
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetSecurityGroupDriftedInstances()).To(gomega.Equal([]string{"i-000000002", "i-000000003"}))
+
+	// only the primary network interface is compared with the node security groups
+	ec2Mock.Instances[1].NetworkInterfaces = []*ec2.InstanceNetworkInterface{
+		{
+			Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(1)},
+			Groups:     []*ec2.GroupIdentifier{{GroupId: aws.String("sg-111111")}},
+		},
+		{
+			Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(0)},
+			Groups:     []*ec2.GroupIdentifier{{GroupId: aws.String("sg-111111")}, {GroupId: aws.String("sg-222222")}},
+		},
+	}
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetSecurityGroupDriftedInstances()).To(gomega.Equal([]string{"i-000000003"}))
+
+	// instances are not considered drifted while a security group cannot be resolved
+	configuration.NodeSecurityGroups = []string{"sg-111111", "missing-group"}
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetSecurityGroupDriftedInstances()).To(gomega.BeEmpty())
+
+	configuration.NodeSecurityGroups = []string{"sg-111111", "sg-222222"}
+	ec2Mock.DescribeInstancesErr = errors.New("some-error")
+	err = ctx.CloudDiscovery()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestCloudDiscoveryFailedJoin(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	status := ig.GetStatus()
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	var (
+		clusterName  = configuration.GetClusterName()
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		scalingGroup = MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+		now          = time.Now()
+	)
+
+	mockInstance := func(id, lifecycleState string, age time.Duration, joined bool) {
+		scalingGroup.Instances = append(scalingGroup.Instances, &autoscaling.Instance{
+			InstanceId:     aws.String(id),
+			LifecycleState: aws.String(lifecycleState),
+		})
+		ec2Mock.Instances = append(ec2Mock.Instances, &ec2.Instance{
+			InstanceId: aws.String(id),
+			LaunchTime: aws.Time(now.Add(-age)),
+		})
+		if joined {
+			_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), MockNode(id, corev1.ConditionTrue), metav1.CreateOptions{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+	}
+
+	scalingGroup.Instances = []*autoscaling.Instance{}
+	mockInstance("i-000000001", autoscaling.LifecycleStateInService, time.Hour, true)
+	mockInstance("i-000000002", autoscaling.LifecycleStateInService, time.Hour, false)
+	mockInstance("i-000000003", autoscaling.LifecycleStateInService, time.Minute, false)
+	mockInstance("i-000000004", autoscaling.LifecycleStateTerminating, time.Hour, false)
+	asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+
+	// join deadline is opt-in
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetFailedJoinInstances()).To(gomega.BeEmpty())
+
+	// in-service instances which have not joined after the deadline have failed to join
+	configuration.NodeJoinDeadline = &v1alpha1.NodeJoinDeadlineSpec{Timeout: "15m"}
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetFailedJoinInstances()).To(gomega.Equal([]string{"i-000000002"}))
+	g.Expect(status.GetFailedJoinInstances()).To(gomega.Equal([]string{"i-000000002"}))
+
+	// instances of a newly created scaling group are still launching
+	scalingGroup.CreatedTime = aws.Time(now.Add(-time.Minute))
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetFailedJoinInstances()).To(gomega.BeEmpty())
+	scalingGroup.CreatedTime = nil
+
+	configuration.NodeJoinDeadline.Timeout = "2h"
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetFailedJoinInstances()).To(gomega.BeEmpty())
+	g.Expect(status.GetFailedJoinInstances()).To(gomega.BeEmpty())
+
+	ec2Mock.DescribeInstancesErr = errors.New("some-error")
+	err = ctx.CloudDiscovery()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestCloudDiscoveryFailedJoinDiagnostics(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	var (
+		clusterName  = configuration.GetClusterName()
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		scalingGroup = MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+	)
+
+	scalingGroup.Instances = []*autoscaling.Instance{
+		{InstanceId: aws.String("i-000000001"), LifecycleState: aws.String(autoscaling.LifecycleStateInService)},
+	}
+	ec2Mock.Instances = []*ec2.Instance{
+		{InstanceId: aws.String("i-000000001"), LaunchTime: aws.Time(time.Now().Add(-time.Hour))},
+	}
+	asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+
+	// the console output of the failed instance ends with the bootstrap failure
+	var consoleOutput strings.Builder
+	for i := 0; i < 100; i++ {
+		consoleOutput.WriteString(fmt.Sprintf("[  %v.000000] booting\r\n", i))
+	}
+	consoleOutput.WriteString("bootstrap.sh: error: unable to reach the cluster endpoint\r\n")
+	ec2Mock.ConsoleOutputs = map[string]string{"i-000000001": ""}
+
+	diagnostics := func() []map[string]string {
+		events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		messages := make([]map[string]string, 0)
+		for _, e := range events.Items {
+			if e.Reason != string(kubeprovider.NodeBootstrapDiagnosticsEvent) {
+				continue
+			}
+			message := make(map[string]string)
+			g.Expect(json.Unmarshal([]byte(e.Message), &message)).To(gomega.Succeed())
+			messages = append(messages, message)
+			k.Kubernetes.CoreV1().Events(e.Namespace).Delete(context.Background(), e.Name, metav1.DeleteOptions{})
+		}
+		return messages
+	}
+
+	// console output is only collected when enabled
+	configuration.NodeJoinDeadline = &v1alpha1.NodeJoinDeadlineSpec{Timeout: "15m"}
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(diagnostics()).To(gomega.BeEmpty())
+
+	// console output which is still empty is collected again while the instance remains failed to join
+	configuration.NodeJoinDeadline.CollectConsoleOutput = true
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(diagnostics()).To(gomega.BeEmpty())
+	g.Expect(ig.GetStatus().GetBootstrapDiagnostics()).To(gomega.BeEmpty())
+
+	// the tail of the console output is published once the instance has written to it
+	ec2Mock.ConsoleOutputs["i-000000001"] = consoleOutput.String()
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	messages := diagnostics()
+	g.Expect(messages).To(gomega.HaveLen(1))
+	g.Expect(messages[0]["instance"]).To(gomega.Equal("i-000000001"))
+	g.Expect(messages[0]["consoleOutput"]).To(gomega.HaveSuffix("bootstrap.sh: error: unable to reach the cluster endpoint"))
+	g.Expect(messages[0]["consoleOutput"]).NotTo(gomega.ContainSubstring("\r"))
+	g.Expect(strings.Split(messages[0]["consoleOutput"], "\n")).To(gomega.HaveLen(BootstrapDiagnosticsMaxLines))
+
+	g.Expect(ig.GetStatus().GetBootstrapDiagnostics()).To(gomega.HaveKey("i-000000001"))
+
+	// unchanged console output is not published again while the instance remains failed to join
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(diagnostics()).To(gomega.BeEmpty())
+
+	// console output which changed is published again
+	consoleOutput.WriteString("kubelet: error: node not authorized\r\n")
+	ec2Mock.ConsoleOutputs["i-000000001"] = consoleOutput.String()
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	messages = diagnostics()
+	g.Expect(messages).To(gomega.HaveLen(1))
+	g.Expect(messages[0]["consoleOutput"]).To(gomega.HaveSuffix("kubelet: error: node not authorized"))
+
+	// failures to get the console output do not fail discovery, and keep the digest of the published output
+	ec2Mock.GetConsoleOutputErr = errors.New("some-error")
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(diagnostics()).To(gomega.BeEmpty())
+	g.Expect(ig.GetStatus().GetBootstrapDiagnostics()).To(gomega.HaveKey("i-000000001"))
+
+	// digests are removed once console output is no longer collected
+	configuration.NodeJoinDeadline.CollectConsoleOutput = false
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(ig.GetStatus().GetBootstrapDiagnostics()).To(gomega.BeNil())
+}
+
+func TestCloudDiscoveryImageUpdate(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration := ig.GetEKSConfiguration()
+	status := ig.GetStatus()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.18")
+	ec2Mock.InstanceTypes = []*ec2.InstanceTypeInfo{
+		{InstanceType: aws.String("m5.large"), ProcessorInfo: &ec2.ProcessorInfo{SupportedArchitectures: aws.StringSlice([]string{"x86_64"})}},
+	}
+
+	var (
+		clusterName  = configuration.GetClusterName()
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		scalingGroup = MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+		ssmKey       = "/aws/service/eks/optimized-ami/1.18/amazon-linux-2/recommended/image_id"
+	)
+
+	scalingGroup.Instances = []*autoscaling.Instance{
+		{InstanceId: aws.String("i-000000001"), LifecycleState: aws.String(autoscaling.LifecycleStateInService)},
+		{InstanceId: aws.String("i-000000002"), LifecycleState: aws.String(autoscaling.LifecycleStateInService)},
+	}
+	ec2Mock.Instances = []*ec2.Instance{
+		{InstanceId: aws.String("i-000000001"), ImageId: aws.String("ami-111111111111")},
+		{InstanceId: aws.String("i-000000002"), ImageId: aws.String("ami-111111111111")},
+	}
+	asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+
+	imageUpdateEvents := func() []string {
+		events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		messages := make([]string, 0)
+		for _, e := range events.Items {
+			if e.Reason != string(kubeprovider.ImageUpdateAvailableEvent) {
+				continue
+			}
+			messages = append(messages, e.Message)
+			k.Kubernetes.CoreV1().Events(e.Namespace).Delete(context.Background(), e.Name, metav1.DeleteOptions{})
+		}
+		return messages
+	}
+
+	// the image update is not reported without scheduled checks
+	ssmMock.parameterMap = map[string]string{ssmKey: "ami-111111111111"}
+	configuration.Image = v1alpha1.ImageLatestValue
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(status.GetImageUpdate()).To(gomega.BeNil())
+
+	// instances run the image published for the reference
+	configuration.Image = v1alpha1.ImageLatestValue
+	configuration.ImageUpdateCheckInterval = "6h"
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(status.GetImageUpdate().AvailableImageID).To(gomega.Equal("ami-111111111111"))
+	g.Expect(status.GetImageUpdate().RunningImageIDs).To(gomega.Equal([]string{"ami-111111111111"}))
+	g.Expect(status.GetImageUpdate().UpdateAvailable).To(gomega.BeFalse())
+	g.Expect(imageUpdateEvents()).To(gomega.BeEmpty())
+
+	// a newer image is published, the configuration drifts to the new image and an event is published once
+	ssmMock.parameterMap[ssmKey] = "ami-222222222222"
+	configuration.Image = v1alpha1.ImageLatestValue
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(configuration.Image).To(gomega.Equal("ami-222222222222"))
+	g.Expect(status.GetImageUpdate().AvailableImageID).To(gomega.Equal("ami-222222222222"))
+	g.Expect(status.GetImageUpdate().RunningImageIDs).To(gomega.Equal([]string{"ami-111111111111"}))
+	g.Expect(status.GetImageUpdate().UpdateAvailable).To(gomega.BeTrue())
+	events := imageUpdateEvents()
+	g.Expect(events).To(gomega.HaveLen(1))
+	g.Expect(events[0]).To(gomega.ContainSubstring("ami-222222222222"))
+
+	configuration.Image = v1alpha1.ImageLatestValue
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(imageUpdateEvents()).To(gomega.BeEmpty())
+
+	// instance images are not described again until the next scheduled check
+	ec2Mock.Instances[0].ImageId = aws.String("ami-222222222222")
+	ec2Mock.Instances[1].ImageId = aws.String("ami-222222222222")
+	lastCheckTime := status.GetImageUpdate().LastCheckTime
+	configuration.Image = v1alpha1.ImageLatestValue
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(status.GetImageUpdate().LastCheckTime).To(gomega.Equal(lastCheckTime))
+	g.Expect(status.GetImageUpdate().RunningImageIDs).To(gomega.Equal([]string{"ami-111111111111"}))
+
+	// the update is no longer available once the rotated instances run the new image
+	status.GetImageUpdate().LastCheckTime = metav1.NewTime(time.Now().Add(-6 * time.Hour))
+	configuration.Image = v1alpha1.ImageLatestValue
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(status.GetImageUpdate().RunningImageIDs).To(gomega.Equal([]string{"ami-222222222222"}))
+	g.Expect(status.GetImageUpdate().UpdateAvailable).To(gomega.BeFalse())
+	g.Expect(status.GetImageUpdate().LastCheckTime.After(lastCheckTime.Time)).To(gomega.BeTrue())
+
+	// the update is reset before the next scheduled check once the launch configuration uses the available image and
+	// all instances were launched from it
+	ssmMock.parameterMap[ssmKey] = "ami-333333333333"
+	configuration.Image = v1alpha1.ImageLatestValue
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(status.GetImageUpdate().UpdateAvailable).To(gomega.BeTrue())
+	imageUpdateEvents()
+
+	lastCheckTime = status.GetImageUpdate().LastCheckTime
+	asgMock.LaunchConfigurations = []*autoscaling.LaunchConfiguration{
+		{LaunchConfigurationName: aws.String("some-launch-configuration"), ImageId: aws.String("ami-333333333333")},
+	}
+	for _, instance := range scalingGroup.Instances {
+		instance.LaunchConfigurationName = aws.String("some-launch-configuration")
+	}
+	configuration.Image = v1alpha1.ImageLatestValue
+	g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+	g.Expect(status.GetImageUpdate().UpdateAvailable).To(gomega.BeFalse())
+	g.Expect(status.GetImageUpdate().RunningImageIDs).To(gomega.Equal([]string{"ami-333333333333"}))
+	g.Expect(status.GetImageUpdate().LastCheckTime).To(gomega.Equal(lastCheckTime))
+}
+
+func TestCloudDiscoveryUnhealthyProtected(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	status := ig.GetStatus()
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	var (
+		clusterName  = configuration.GetClusterName()
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		scalingGroup = MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+	)
+
+	mockInstance := func(id, health string, protected bool) *autoscaling.Instance {
+		return &autoscaling.Instance{
+			InstanceId:           aws.String(id),
+			LifecycleState:       aws.String(autoscaling.LifecycleStateInService),
+			HealthStatus:         aws.String(health),
+			ProtectedFromScaleIn: aws.Bool(protected),
+		}
+	}
+
+	scalingGroup.Instances = []*autoscaling.Instance{
+		mockInstance("i-000000001", "Healthy", true),
+		mockInstance("i-000000002", "Unhealthy", true),
+		mockInstance("i-000000003", "Unhealthy", false),
+	}
+	asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+
+	// only unhealthy instances which are protected from scale in are discovered
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetUnhealthyProtectedInstances()).To(gomega.Equal([]string{"i-000000002"}))
+	g.Expect(status.GetUnhealthyProtectedInstances()).To(gomega.Equal([]string{"i-000000002"}))
+
+	scalingGroup.Instances[1].HealthStatus = aws.String("Healthy")
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(state.GetUnhealthyProtectedInstances()).To(gomega.BeEmpty())
+	g.Expect(status.GetUnhealthyProtectedInstances()).To(gomega.BeEmpty())
+}
+
+func TestCloudDiscoveryLifecycleCapacity(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	status := ig.GetStatus()
+	configuration := ig.GetEKSConfiguration()
+	spec := ig.GetEKSSpec()
+	spec.Type = v1alpha1.LaunchTemplate
+	spotRatio := intstr.FromInt(50)
+	configuration.MixedInstancesPolicy = &v1alpha1.MixedInstancesPolicySpec{
+		InstancePool: aws.String(v1alpha1.SubFamilyFlexibleInstancePool),
+		SpotRatio:    &spotRatio,
+	}
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	var (
+		clusterName  = configuration.GetClusterName()
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		scalingGroup = MockScalingGroup("scaling-group-1", true, ownershipTag, nameTag, namespaceTag)
+	)
+
+	mockInstance := func(id, lifecycle string) {
+		scalingGroup.Instances = append(scalingGroup.Instances, &autoscaling.Instance{
+			InstanceId:     aws.String(id),
+			LifecycleState: aws.String(autoscaling.LifecycleStateInService),
+		})
+		instance := &ec2.Instance{InstanceId: aws.String(id)}
+		if lifecycle != "" {
+			instance.InstanceLifecycle = aws.String(lifecycle)
+		}
+		ec2Mock.Instances = append(ec2Mock.Instances, instance)
+	}
+
+	scalingGroup.Instances = []*autoscaling.Instance{}
+	mockInstance("i-000000001", ec2.InstanceLifecycleTypeSpot)
+	mockInstance("i-000000002", "")
+	mockInstance("i-000000003", ec2.InstanceLifecycleTypeSpot)
+	mockInstance("i-000000004", ec2.InstanceLifecycleTypeScheduled)
+	asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+	ec2Mock.LaunchTemplates = []*ec2.LaunchTemplate{
+		{LaunchTemplateName: aws.String("some-launch-template")},
+	}
+
+	// instances of mixed groups are described
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status.GetLifecycle()).To(gomega.Equal(v1alpha1.LifecycleStateMixed))
+	g.Expect(status.GetLifecycleCapacity()).To(gomega.Equal(&v1alpha1.LifecycleCapacityStatus{OnDemand: 2, Spot: 2}))
+
+	// the capacity is kept when instances cannot be described
+	ec2Mock.DescribeInstancesErr = errors.New("some-error")
+	scalingGroup.Instances = scalingGroup.Instances[:2]
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status.GetLifecycleCapacity()).To(gomega.Equal(&v1alpha1.LifecycleCapacityStatus{OnDemand: 2, Spot: 2}))
+
+	// groups without a spot ratio only run on-demand instances
+	configuration.MixedInstancesPolicy = nil
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status.GetLifecycle()).To(gomega.Equal(v1alpha1.LifecycleStateNormal))
+	g.Expect(status.GetLifecycleCapacity()).To(gomega.Equal(&v1alpha1.LifecycleCapacityStatus{OnDemand: 2}))
+
+	// groups with a spot price only run spot instances
+	configuration.SpotPrice = "0.5"
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status.GetLifecycle()).To(gomega.Equal(v1alpha1.LifecycleStateSpot))
+	g.Expect(status.GetLifecycleCapacity()).To(gomega.Equal(&v1alpha1.LifecycleCapacityStatus{Spot: 2}))
+}
+
 func TestCloudDiscoveryWithTemplatePositive(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
@@ -447,6 +1433,77 @@ func TestCloudDiscoverySpotPrice(t *testing.T) {
 	g.Expect(configuration.GetSpotPrice()).To(gomega.BeEmpty())
 }
 
+func TestCloudDiscoverySpotPriceCapacityTypeEnforcement(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	status := ig.GetStatus()
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		RoleName: aws.String("some-role"),
+		Arn:      aws.String("some-arn"),
+	}
+
+	iamMock.InstanceProfile = &iam.InstanceProfile{
+		InstanceProfileName: aws.String("some-profile"),
+	}
+
+	var (
+		clusterName           = "some-cluster"
+		resourceName          = "some-instance-group"
+		resourceNamespace     = "default"
+		ownedScalingGroupName = "scaling-group-1"
+		ownershipTag          = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag               = MockTagDescription(provisioners.TagInstanceGroupName, resourceName)
+		namespaceTag          = MockTagDescription(provisioners.TagInstanceGroupNamespace, resourceNamespace)
+	)
+
+	ig.SetName(resourceName)
+	ig.SetNamespace(resourceNamespace)
+	configuration.SetClusterName(clusterName)
+	asgMock.AutoScalingGroups = []*autoscaling.Group{
+		MockScalingGroup(ownedScalingGroupName, false, ownershipTag, nameTag, namespaceTag),
+	}
+	asgMock.LaunchConfigurations = []*autoscaling.LaunchConfiguration{
+		{
+			LaunchConfigurationName: aws.String("some-launch-configuration"),
+		},
+	}
+
+	// recommendations to use spot are ignored by on-demand-only instance groups
+	configuration.CapacityTypeEnforcement = v1alpha1.CapacityTypeEnforcementOnDemandOnly
+	_, err := k.Kubernetes.CoreV1().Events("").Create(context.Background(), MockSpotEvent("1", ownedScalingGroupName, "0.80", true, time.Now()), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configuration.GetSpotPrice()).To(gomega.BeEmpty())
+	g.Expect(status.GetUsingSpotRecommendation()).To(gomega.BeFalse())
+	g.Expect(status.GetLifecycle()).To(gomega.Equal("normal"))
+
+	// recommendations to stop using spot are ignored by spot-only instance groups
+	configuration.CapacityTypeEnforcement = v1alpha1.CapacityTypeEnforcementSpotOnly
+	configuration.SetSpotPrice("0.67")
+	_, err = k.Kubernetes.CoreV1().Events("").Create(context.Background(), MockSpotEvent("2", ownedScalingGroupName, "0.90", false, time.Now().Add(time.Minute*time.Duration(3))), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configuration.GetSpotPrice()).To(gomega.Equal("0.67"))
+	g.Expect(status.GetLifecycle()).To(gomega.Equal("spot"))
+}
+
 func TestLaunchConfigDeletion(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
@@ -513,3 +1570,97 @@ func TestLaunchConfigDeletion(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	g.Expect(asgMock.DeleteLaunchConfigurationCallCount).To(gomega.Equal(uint(2)))
 }
+
+func TestCloudDiscoveryScalingConfigurationStatus(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	status := ig.GetStatus()
+	configuration := ig.GetEKSConfiguration()
+	spec := ig.GetEKSSpec()
+
+	iamMock.Role = &iam.Role{
+		RoleName: aws.String("some-role"),
+		Arn:      aws.String("some-arn"),
+	}
+
+	var (
+		clusterName             = "some-cluster"
+		resourceName            = "some-instance-group"
+		resourceNamespace       = "default"
+		launchConfigurationName = "some-launch-configuration"
+		launchTemplateName      = "some-launch-template"
+		ownershipTag            = MockTagDescription(provisioners.TagClusterName, clusterName)
+		nameTag                 = MockTagDescription(provisioners.TagInstanceGroupName, resourceName)
+		namespaceTag            = MockTagDescription(provisioners.TagInstanceGroupNamespace, resourceNamespace)
+		ownedScalingGroup       = MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+	)
+
+	ig.SetName(resourceName)
+	ig.SetNamespace(resourceNamespace)
+	configuration.SetClusterName(clusterName)
+	asgMock.AutoScalingGroups = []*autoscaling.Group{ownedScalingGroup}
+
+	// launch configurations only record the image
+	spec.Type = v1alpha1.LaunchConfiguration
+	ownedScalingGroup.LaunchConfigurationName = aws.String(launchConfigurationName)
+	asgMock.LaunchConfigurations = []*autoscaling.LaunchConfiguration{
+		{
+			LaunchConfigurationName: aws.String(launchConfigurationName),
+			ImageId:                 aws.String("ami-111111111111"),
+			CreatedTime:             aws.Time(time.Now()),
+		},
+	}
+
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status.GetActiveLaunchConfigurationName()).To(gomega.Equal(launchConfigurationName))
+	g.Expect(status.GetActiveImageID()).To(gomega.Equal("ami-111111111111"))
+	g.Expect(status.GetActiveLaunchTemplateID()).To(gomega.BeEmpty())
+	g.Expect(status.GetActiveLaunchTemplateVersion()).To(gomega.BeEmpty())
+
+	// launch templates record the id, latest version and its image
+	spec.Type = v1alpha1.LaunchTemplate
+	ownedScalingGroup.LaunchConfigurationName = nil
+	ownedScalingGroup.LaunchTemplate = &autoscaling.LaunchTemplateSpecification{
+		LaunchTemplateName: aws.String(launchTemplateName),
+		Version:            aws.String("$Latest"),
+	}
+	ec2Mock.LaunchTemplates = []*ec2.LaunchTemplate{
+		{
+			LaunchTemplateId:    aws.String("lt-0123456789abcdef0"),
+			LaunchTemplateName:  aws.String(launchTemplateName),
+			LatestVersionNumber: aws.Int64(3),
+		},
+	}
+	ec2Mock.LaunchTemplateVersions = []*ec2.LaunchTemplateVersion{
+		{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			VersionNumber:      aws.Int64(2),
+			LaunchTemplateData: &ec2.ResponseLaunchTemplateData{ImageId: aws.String("ami-222222222222")},
+		},
+		{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			VersionNumber:      aws.Int64(3),
+			LaunchTemplateData: &ec2.ResponseLaunchTemplateData{ImageId: aws.String("ami-333333333333")},
+		},
+	}
+
+	err = ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status.GetActiveLaunchTemplateName()).To(gomega.Equal(launchTemplateName))
+	g.Expect(status.GetActiveLaunchTemplateID()).To(gomega.Equal("lt-0123456789abcdef0"))
+	g.Expect(status.GetActiveLaunchTemplateVersion()).To(gomega.Equal("3"))
+	g.Expect(status.GetLatestTemplateVersion()).To(gomega.Equal("3"))
+	g.Expect(status.GetActiveImageID()).To(gomega.Equal("ami-333333333333"))
+}
@@ -38,13 +38,13 @@ func (ctx *EksInstanceGroupContext) Create() error {
 		args            = ctx.GetBootstrapArgs()
 		kubeletArgs     = ctx.GetKubeletExtraArgs()
 		userDataPayload = ctx.GetUserDataStages()
-		clusterName     = configuration.GetClusterName()
+		clusterName     = ctx.GetBootstrapClusterName()
 		mounts          = ctx.GetMountOpts()
 		userData        = ctx.GetBasicUserData(clusterName, args, kubeletArgs, userDataPayload, mounts)
 		sgs             = ctx.ResolveSecurityGroups()
 		spotPrice       = configuration.GetSpotPrice()
 		placement       = configuration.GetPlacement()
-		metadataOptions = configuration.GetMetadataOptions()
+		metadataOptions = ctx.GetMetadataOptions()
 	)
 	ctx.SetState(v1alpha1.ReconcileModifying)
 
@@ -55,6 +55,81 @@ func (ctx *EksInstanceGroupContext) Create() error {
 	}
 	instanceProfile := state.GetInstanceProfile()
 
+	networkInterfaces, err := ctx.ResolveNetworkInterfaces()
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve network interfaces")
+	}
+	if err := ctx.ValidateNetworkInterfaces(networkInterfaces); err != nil {
+		return errors.Wrap(err, "invalid network interfaces")
+	}
+	ctx.WarnPublicIpPrivateSubnets()
+	ctx.WarnReservedTags()
+
+	if err := ctx.SyncRegistryCredentials(); err != nil {
+		return errors.Wrap(err, "failed to sync registry credentials")
+	}
+
+	if err := ctx.ValidateWindowsContainerd(); err != nil {
+		return errors.Wrap(err, "invalid windows containerd configuration")
+	}
+
+	if err := ctx.ValidatePrePullImages(); err != nil {
+		return errors.Wrap(err, "invalid pre-pulled images")
+	}
+
+	if err := ctx.ValidateInstanceStorage(); err != nil {
+		return errors.Wrap(err, "invalid instance storage")
+	}
+
+	if err := ctx.ValidateBootstrapRetries(); err != nil {
+		return errors.Wrap(err, "invalid bootstrap retries")
+	}
+
+	if err := ctx.ValidateImagePulls(); err != nil {
+		return errors.Wrap(err, "invalid image pull settings")
+	}
+
+	if err := ctx.ValidateShutdownGracePeriod(); err != nil {
+		return errors.Wrap(err, "invalid shutdown grace period")
+	}
+
+	if err := ctx.ValidateKubeletConfiguration(); err != nil {
+		return errors.Wrap(err, "invalid kubelet configuration")
+	}
+
+	if err := ctx.ValidateVersionSkew(); err != nil {
+		return errors.Wrap(err, "invalid node version")
+	}
+
+	if err := ctx.ValidateAuthMappings(); err != nil {
+		return errors.Wrap(err, "invalid auth mappings")
+	}
+
+	if err := ctx.ValidateImageAllowlist(); err != nil {
+		return errors.Wrap(err, "invalid image")
+	}
+
+	if err := ctx.ValidateAutoscaler(); err != nil {
+		return errors.Wrap(err, "invalid autoscaler")
+	}
+
+	if err := ctx.ValidateSysctls(); err != nil {
+		return errors.Wrap(err, "invalid sysctls")
+	}
+
+	if err := ctx.ValidateBootstrapFlags(); err != nil {
+		return errors.Wrap(err, "invalid bootstrap flags")
+	}
+
+	if err := ctx.ValidateMaxPodsFormula(); err != nil {
+		return errors.Wrap(err, "invalid max pods formula")
+	}
+
+	if err := ctx.ValidatePrefixAssignment(); err != nil {
+		return errors.Wrap(err, "invalid prefix assignment configuration")
+	}
+	instanceGroup.GetStatus().SetPrefixDensity(ctx.GetPrefixDensityStatus())
+
 	var configName = scalingConfig.Name()
 
 	if common.StringEmpty(configName) {
@@ -62,56 +137,117 @@ func (ctx *EksInstanceGroupContext) Create() error {
 	}
 
 	config := &scaling.CreateConfigurationInput{
-		Name:                  configName,
-		IamInstanceProfileArn: aws.StringValue(instanceProfile.Arn),
-		ImageId:               configuration.Image,
-		InstanceType:          configuration.InstanceType,
-		KeyName:               configuration.KeyPairName,
-		SecurityGroups:        sgs,
-		Volumes:               configuration.Volumes,
-		UserData:              userData,
-		SpotPrice:             spotPrice,
-		LicenseSpecifications: configuration.LicenseSpecifications,
-		Placement:             placement,
-		MetadataOptions:       metadataOptions,
+		Name:                     configName,
+		IamInstanceProfileArn:    aws.StringValue(instanceProfile.Arn),
+		ImageId:                  configuration.Image,
+		InstanceType:             configuration.InstanceType,
+		KeyName:                  configuration.KeyPairName,
+		SecurityGroups:           sgs,
+		Volumes:                  ctx.GetVolumes(),
+		UserData:                 userData,
+		SpotPrice:                spotPrice,
+		LicenseSpecifications:    configuration.LicenseSpecifications,
+		Placement:                placement,
+		MetadataOptions:          metadataOptions,
+		NetworkInterfaces:        networkInterfaces,
+		AssociatePublicIpAddress: configuration.GetAssociatePublicIpAddress(),
+	}
+
+	ctx.ExportUserData(userData)
+	if err := ctx.ValidateUserData(userData); err != nil {
+		return errors.Wrap(err, "failed to validate userdata")
 	}
 
 	if err := scalingConfig.Create(config); err != nil {
+		if ctx.IsInstanceProfilePropagating(err) {
+			ctx.Log.Info("instance profile has not propagated yet, will requeue", "instancegroup", instanceGroup.NamespacedName(), "instanceprofile", aws.StringValue(instanceProfile.Arn))
+			return nil
+		}
 		return errors.Wrap(err, "failed to create scaling configuration")
 	}
+	ctx.UpdateScalingConfigurationStatus()
 
 	// create scaling group
 	err = ctx.CreateScalingGroup(configName)
 	if err != nil {
+		if ctx.IsInstanceProfilePropagating(err) {
+			ctx.Log.Info("instance profile has not propagated yet, will requeue", "instancegroup", instanceGroup.NamespacedName(), "instanceprofile", aws.StringValue(instanceProfile.Arn))
+			return nil
+		}
 		return errors.Wrap(err, "failed to create scaling group")
 	}
+	ctx.StartProvisioning()
 
 	ctx.SetState(v1alpha1.ReconcileModified)
 	return nil
 }
 
 func (ctx *EksInstanceGroupContext) CreateScalingGroup(name string) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+	)
+
+	shards, err := ctx.GetScalingGroupShards()
+	if err != nil {
+		return err
+	}
+
+	if !configuration.IsZoneSharded() {
+		if state.HasScalingGroup() {
+			return nil
+		}
+		return ctx.createScalingGroup(name, shards[0])
+	}
+
+	if len(shards) == 0 {
+		return errors.New("failed to resolve availability zones for zone sharding")
+	}
+
+	zoneScalingGroups := state.GetZoneScalingGroups()
+	for _, shard := range shards {
+		if _, ok := zoneScalingGroups[shard.Zone]; ok {
+			continue
+		}
+		if err := ctx.withScalingGroup(nil, func() error {
+			return ctx.createScalingGroup(name, shard)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctx *EksInstanceGroupContext) createScalingGroup(name string, shard ScalingGroupShard) error {
 	var (
 		instanceGroup = ctx.GetInstanceGroup()
 		status        = instanceGroup.GetStatus()
 		spec          = instanceGroup.GetEKSSpec()
 		configuration = instanceGroup.GetEKSConfiguration()
 		state         = ctx.GetDiscoveredState()
-		asgName       = ctx.ResourcePrefix
+		asgName       = shard.Name
 		tags          = ctx.GetAddedTags(asgName)
+		desired       = shard.MinSize
 	)
 
-	if state.HasScalingGroup() {
-		return nil
+	// instance groups scaled by an autoscaler start at the capacity of the metric if it was reported
+	if capacity, ok := ctx.GetAutoscalerCapacity(shard, 0); ok {
+		desired = capacity
 	}
 
 	input := &autoscaling.CreateAutoScalingGroupInput{
-		AutoScalingGroupName: aws.String(asgName),
-		DesiredCapacity:      aws.Int64(spec.GetMinSize()),
-		MinSize:              aws.Int64(spec.GetMinSize()),
-		MaxSize:              aws.Int64(spec.GetMaxSize()),
-		VPCZoneIdentifier:    aws.String(common.ConcatenateList(ctx.ResolveSubnets(), ",")),
-		Tags:                 tags,
+		AutoScalingGroupName:             aws.String(asgName),
+		DesiredCapacity:                  aws.Int64(desired),
+		MinSize:                          aws.Int64(shard.MinSize),
+		MaxSize:                          aws.Int64(shard.MaxSize),
+		VPCZoneIdentifier:                aws.String(common.ConcatenateList(shard.Subnets, ",")),
+		TerminationPolicies:              aws.StringSlice(ctx.GetDesiredTerminationPolicies()),
+		CapacityRebalance:                aws.Bool(configuration.IsCapacityRebalanceEnabled()),
+		DefaultCooldown:                  aws.Int64(configuration.GetDefaultCooldown()),
+		NewInstancesProtectedFromScaleIn: aws.Bool(configuration.IsScaleInProtectionEnabled()),
+		InstanceMaintenancePolicy:        ctx.GetDesiredInstanceMaintenancePolicy(nil),
+		Tags:                             tags,
 	}
 
 	if spec.IsLaunchConfiguration() {
@@ -136,7 +272,7 @@ func (ctx *EksInstanceGroupContext) CreateScalingGroup(name string) error {
 		return err
 	}
 
-	ctx.Log.Info("created scaling group", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName)
+	ctx.Log.Info("created scaling group", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName, "zone", shard.Zone)
 
 	if err := ctx.UpdateScalingProcesses(asgName); err != nil {
 		return err
@@ -182,6 +318,16 @@ func (ctx *EksInstanceGroupContext) CreateManagedRole() error {
 		return errors.Wrap(err, "failed to update managed policies")
 	}
 
+	err = ctx.UpdateRegistryCredentialsPolicy(roleName, role)
+	if err != nil {
+		return errors.Wrap(err, "failed to update registry credentials policy")
+	}
+
+	err = ctx.UpdateManagedRoleTags(roleName, role, profile)
+	if err != nil {
+		return errors.Wrap(err, "failed to update managed role tags")
+	}
+
 	ctx.Log.Info("reconciled managed role", "instancegroup", instanceGroup.NamespacedName(), "iamrole", roleName)
 
 	state.SetRole(role)
@@ -17,9 +17,12 @@ package eks
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
 	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -30,6 +33,7 @@ import (
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
 	"github.com/onsi/gomega"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestCreateManagedRolePositive(t *testing.T) {
@@ -104,6 +108,61 @@ func TestCreateLaunchConfigurationPositive(t *testing.T) {
 	g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileModified))
 }
 
+func TestCreateResourcePrefixTemplate(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	tmpl, err := provisioners.ParseResourcePrefixTemplate("k8s.{{ .ClusterName }}.{{ .Name }}")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "instance-group-1", expected: "k8s.my-cluster.instance-group-1"},
+		{name: strings.Repeat("a", 120), expected: "k8s.my-cluster." + strings.Repeat("a", 89)},
+	}
+
+	for _, tc := range tests {
+		ig := MockInstanceGroup()
+		ig.SetName(tc.name)
+		iamMock.Role = &iam.Role{
+			Arn:      aws.String("some-arn"),
+			RoleName: aws.String("some-role"),
+		}
+
+		ctx := MockContext(ig, k, w)
+		custom := New(provisioners.ProvisionerInput{
+			AwsWorker:              w,
+			Kubernetes:             k,
+			InstanceGroup:          ig,
+			Log:                    ctx.Log,
+			Metrics:                ctx.Metrics,
+			ResourcePrefixTemplate: tmpl,
+		})
+		custom.DiscoveredState = ctx.DiscoveredState
+
+		g.Expect(custom.ResourcePrefix).To(gomega.HavePrefix(tc.expected))
+		g.Expect(len(custom.ResourcePrefix)).To(gomega.BeNumerically("<=", provisioners.MaxResourcePrefixLength))
+
+		g.Expect(custom.CloudDiscovery()).To(gomega.Succeed())
+		g.Expect(custom.Create()).To(gomega.Succeed())
+
+		// scaling configurations are named after the prefix and fit the launch template name limit
+		configName := ig.GetStatus().GetActiveLaunchConfigurationName()
+		g.Expect(configName).To(gomega.HavePrefix(custom.ResourcePrefix + "-"))
+		g.Expect(len(configName)).To(gomega.BeNumerically("<=", 128))
+	}
+}
+
 func TestCreateLaunchTemplatePositive(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
@@ -342,6 +401,83 @@ func TestCreateAutoScalingGroupNegative(t *testing.T) {
 	g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileModifying))
 }
 
+func TestCreateInstanceProfilePropagation(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	var (
+		invalidConfigProfileErr = awserr.New("ValidationError", "Invalid IamInstanceProfile: some-profile", nil)
+		invalidGroupProfileErr  = awserr.New("ValidationError", "You must use a valid fully-formed launch template. Value (some-profile) for parameter iamInstanceProfile.name is invalid. Invalid IAM Instance Profile name", nil)
+		otherValidationErr      = awserr.New("ValidationError", "some-error", nil)
+	)
+
+	tests := []struct {
+		profileAge     time.Duration
+		createdProfile bool
+		configErr      error
+		groupErr       error
+		shouldErr      bool
+	}{
+		// a newly created profile which is not yet propagated is requeued
+		{profileAge: time.Second * 10, createdProfile: true, configErr: invalidConfigProfileErr},
+		{profileAge: time.Second * 10, createdProfile: true, groupErr: invalidGroupProfileErr},
+		// profiles which are past the propagation timeout, or missing, are invalid
+		{profileAge: time.Hour, createdProfile: true, configErr: invalidConfigProfileErr, shouldErr: true},
+		{profileAge: time.Hour, createdProfile: true, groupErr: invalidGroupProfileErr, shouldErr: true},
+		{createdProfile: false, configErr: invalidConfigProfileErr, shouldErr: true},
+		// other errors are not retried
+		{profileAge: time.Second * 10, createdProfile: true, configErr: otherValidationErr, shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - profile age: %v, created: %v", i, tc.profileAge, tc.createdProfile)
+		iamMock.InstanceProfile = &iam.InstanceProfile{
+			Arn:                 aws.String("some-profile-arn"),
+			InstanceProfileName: aws.String("some-profile"),
+		}
+		if tc.createdProfile {
+			iamMock.InstanceProfile.CreateDate = aws.Time(time.Now().Add(-tc.profileAge))
+		}
+		asgMock.CreateLaunchConfigurationErr = tc.configErr
+		asgMock.CreateAutoScalingGroupErr = tc.groupErr
+
+		err = ctx.Create()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+		g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileModifying))
+	}
+
+	// the propagation timeout is configurable
+	ctx.InstanceProfilePropagationTimeout = time.Second
+	iamMock.InstanceProfile.CreateDate = aws.Time(time.Now().Add(-time.Second * 10))
+	asgMock.CreateLaunchConfigurationErr = invalidConfigProfileErr
+	asgMock.CreateAutoScalingGroupErr = nil
+	err = ctx.Create()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
 type AMITest struct {
 	igImage     string
 	expectedAmi string
@@ -416,3 +552,140 @@ func TestCreateLatestAMI(t *testing.T) {
 		g.Expect(ctx.GetInstanceGroup().Spec.EKSSpec.EKSConfiguration.Image).To(gomega.Equal(tc.expectedAmi))
 	}
 }
+
+func TestCreateWithImageAllowlist(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		status        = ig.GetStatus()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	configuration.SetInstanceProfileName("some-profile")
+	configuration.SetRoleName("some-role")
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	ssmMock.parameterMap = map[string]string{
+		"/aws/service/eks/optimized-ami/1.18/amazon-linux-2/recommended/image_id": "ami-12345678",
+	}
+	ec2Mock.Images = []*ec2.Image{
+		{ImageId: aws.String("ami-12345678"), OwnerId: aws.String("602401143452"), Name: aws.String("amazon-eks-node-1.18-v20220226")},
+	}
+	instanceTypes := []*ec2.InstanceTypeInfo{
+		{
+			InstanceType: aws.String("m5.large"),
+			ProcessorInfo: &ec2.ProcessorInfo{
+				SupportedArchitectures: []*string{aws.String("x86_64")},
+			},
+		},
+	}
+	ec2Mock.InstanceTypes = instanceTypes
+
+	tests := []struct {
+		allowlist *v1alpha1.ImageAllowlistSpec
+		wantErr   bool
+	}{
+		{allowlist: &v1alpha1.ImageAllowlistSpec{IDs: []string{"ami-12345678"}}},
+		{allowlist: &v1alpha1.ImageAllowlistSpec{Owners: []string{"602401143452"}}},
+		{allowlist: &v1alpha1.ImageAllowlistSpec{NamePatterns: []string{"amazon-eks-node-1.18-*"}}},
+		{allowlist: &v1alpha1.ImageAllowlistSpec{IDs: []string{"ami-87654321"}, Owners: []string{"123456789012"}, NamePatterns: []string{"bottlerocket-*"}}, wantErr: true},
+		{allowlist: nil},
+	}
+
+	for i, tc := range tests {
+		// images resolved from SSM are validated after resolution
+		configuration.Image = "latest"
+		configuration.ImageAllowlist = tc.allowlist
+		g.Expect(ctx.CloudDiscovery()).To(gomega.Succeed())
+		ctx.GetDiscoveredState().SetInstanceTypeInfo(instanceTypes)
+
+		err := ctx.Create()
+		if tc.wantErr {
+			g.Expect(err).To(gomega.HaveOccurred(), "test %v", i)
+			g.Expect(status.GetImageNotAllowedCondition()).To(gomega.Equal(corev1.ConditionTrue))
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred(), "test %v", i)
+		g.Expect(status.GetImageNotAllowedCondition()).To(gomega.Equal(corev1.ConditionFalse))
+	}
+}
+
+func TestCreateZoneShardedScalingGroups(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		spec          = ig.GetEKSSpec()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+
+	spec.Type = v1alpha1.LaunchTemplate
+	spec.MinSize = 3
+	spec.MaxSize = 5
+	configuration.ZoneSharding = true
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2", "subnet-3"})
+	ec2Mock.Subnets = []*ec2.Subnet{
+		{SubnetId: aws.String("subnet-1"), AvailabilityZone: aws.String("us-west-2a")},
+		{SubnetId: aws.String("subnet-2"), AvailabilityZone: aws.String("us-west-2b")},
+		{SubnetId: aws.String("subnet-3"), AvailabilityZone: aws.String("us-west-2a")},
+	}
+
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = ctx.Create()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileModified))
+
+	inputs := asgMock.CreateAutoScalingGroupInputs
+	g.Expect(inputs).To(gomega.HaveLen(2))
+
+	expected := []struct {
+		zone    string
+		subnets string
+		min     int64
+		max     int64
+	}{
+		{zone: "us-west-2a", subnets: "subnet-1,subnet-3", min: 2, max: 3},
+		{zone: "us-west-2b", subnets: "subnet-2", min: 1, max: 2},
+	}
+
+	for i, tc := range expected {
+		input := inputs[i]
+		g.Expect(aws.StringValue(input.AutoScalingGroupName)).To(gomega.Equal(fmt.Sprintf("%v-%v", ctx.ResourcePrefix, tc.zone)))
+		g.Expect(aws.StringValue(input.VPCZoneIdentifier)).To(gomega.Equal(tc.subnets))
+		g.Expect(aws.Int64Value(input.MinSize)).To(gomega.Equal(tc.min))
+		g.Expect(aws.Int64Value(input.MaxSize)).To(gomega.Equal(tc.max))
+
+		tags := make(map[string]string)
+		for _, tag := range input.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		g.Expect(tags).To(gomega.HaveKeyWithValue(provisioners.TagAvailabilityZone, tc.zone))
+		g.Expect(tags).To(gomega.HaveKeyWithValue("k8s.io/cluster-autoscaler/node-template/label/topology.kubernetes.io/zone", tc.zone))
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/keikoproj/instance-manager/controllers/common"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
 	"github.com/pkg/errors"
 
@@ -34,6 +35,11 @@ func (ctx *EksInstanceGroupContext) Delete() error {
 	)
 
 	ctx.SetState(v1alpha1.ReconcileDeleting)
+	// remove the scaling groups from the priority expander while their names are still discovered
+	if err := ctx.RemoveClusterAutoscalerPriority(); err != nil {
+		return errors.Wrap(err, "failed to remove cluster-autoscaler priority")
+	}
+
 	// delete scaling group
 	err := ctx.DeleteScalingGroup()
 	if err != nil {
@@ -45,6 +51,10 @@ func (ctx *EksInstanceGroupContext) Delete() error {
 		return errors.Wrap(err, "failed to remove auth role")
 	}
 
+	if err := ctx.RemoveAuthMappings(); err != nil {
+		return errors.Wrap(err, "failed to remove additional aws-auth mappings")
+	}
+
 	// delete launchconfig
 	if err := scalingConfig.Delete(&scaling.DeleteConfigurationInput{
 		Prefix:    ctx.ResourcePrefix,
@@ -59,6 +69,56 @@ func (ctx *EksInstanceGroupContext) Delete() error {
 		return errors.Wrap(err, "failed to delete scaling group role")
 	}
 
+	if ctx.UserDataExporter != nil {
+		instanceGroup := ctx.GetInstanceGroup()
+		ctx.UserDataExporter.Remove(instanceGroup.GetNamespace(), instanceGroup.GetName())
+	}
+	if ctx.StateExporter != nil {
+		instanceGroup := ctx.GetInstanceGroup()
+		ctx.StateExporter.Remove(instanceGroup.GetNamespace(), instanceGroup.GetName())
+	}
+
+	// delete the registry credentials parameter if one was created
+	status := ctx.GetInstanceGroup().GetStatus()
+	parameters := []string{ctx.GetRegistryCredentialsParameter()}
+	if previous := status.GetRegistryCredentialsParameter(); previous != parameters[0] {
+		parameters = append(parameters, previous)
+	}
+	for _, parameter := range parameters {
+		if parameter == "" {
+			continue
+		}
+		if err := ctx.AwsWorker.DeleteParameter(parameter); err != nil {
+			return errors.Wrap(err, "failed to delete registry credentials parameter")
+		}
+	}
+	status.SetRegistryCredentialsParameter("")
+
+	return nil
+}
+
+// RemoveClusterAutoscalerPriority removes the instance group's scaling groups from the cluster-autoscaler priority
+// expander configmap, including those listed in status which no longer exist
+func (ctx *EksInstanceGroupContext) RemoveClusterAutoscalerPriority() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		state         = ctx.GetDiscoveredState()
+	)
+
+	if status.GetClusterAutoscalerPriority() == nil && len(status.GetClusterAutoscalerPriorityScalingGroups()) == 0 {
+		return nil
+	}
+
+	names := append([]string{}, status.GetClusterAutoscalerPriorityScalingGroups()...)
+	for _, group := range state.GetOwnedScalingGroups() {
+		names = append(names, aws.StringValue(group.AutoScalingGroupName))
+	}
+	if err := common.RemovePriorityExpander(ctx.KubernetesClient.Kubernetes, names); err != nil {
+		return err
+	}
+	status.SetClusterAutoscalerPriority(nil)
+	status.SetClusterAutoscalerPriorityScalingGroups(nil)
 	return nil
 }
 
@@ -67,6 +127,7 @@ func (ctx *EksInstanceGroupContext) DeleteScalingGroup() error {
 		state         = ctx.GetDiscoveredState()
 		scalingGroup  = state.GetScalingGroup()
 		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
 		asgName       = aws.StringValue(scalingGroup.AutoScalingGroupName)
 	)
 
@@ -74,6 +135,18 @@ func (ctx *EksInstanceGroupContext) DeleteScalingGroup() error {
 		return nil
 	}
 
+	if configuration.IsZoneSharded() {
+		for zone, group := range state.GetZoneScalingGroups() {
+			zoneGroupName := aws.StringValue(group.AutoScalingGroupName)
+			if err := ctx.AwsWorker.DeleteScalingGroup(zoneGroupName); err != nil {
+				return err
+			}
+			ctx.Log.Info("deleted scaling group", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", zoneGroupName, "zone", zone)
+			state.Publisher.Publish(kubeprovider.InstanceGroupDeletedEvent, "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", zoneGroupName)
+		}
+		return nil
+	}
+
 	err := ctx.AwsWorker.DeleteScalingGroup(asgName)
 	if err != nil {
 		return err
@@ -99,6 +172,13 @@ func (ctx *EksInstanceGroupContext) DeleteManagedRole() error {
 
 	managedPolicies := ctx.GetManagedPoliciesList(additionalPolicies)
 
+	// inline policies must be deleted before the role
+	if common.ContainsString(state.GetInlinePolicies(), RegistryCredentialsPolicyName) {
+		if err := ctx.AwsWorker.DeleteRoleInlinePolicy(roleName, RegistryCredentialsPolicyName); err != nil {
+			return err
+		}
+	}
+
 	err := ctx.AwsWorker.DeleteScalingGroupRole(roleName, managedPolicies)
 	if err != nil {
 		return err
@@ -25,6 +25,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam"
 	awsauth "github.com/keikoproj/aws-auth/pkg/mapper"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
 	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 	"github.com/onsi/gomega"
@@ -244,3 +245,129 @@ func TestRemoveAuthRoleNegative(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	g.Expect(len(auth.MapRoles)).To(gomega.Equal(0))
 }
+
+func TestAccessEntryNodeAuthentication(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	roleArn := "arn:aws:iam::123456789012:role/node-role"
+	config.NodeAuthentication = v1alpha1.NodeAuthenticationAccessEntry
+	ig.Status.NodesArn = roleArn
+	igObj, err := kubeprovider.GetUnstructuredInstanceGroup(ig)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = ctx.KubernetesClient.KubeDynamic.Resource(v1alpha1.GroupVersionResource).Namespace(ig.Namespace).Create(context.Background(), igObj, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		IAMRole: &iam.Role{
+			Arn: aws.String(roleArn),
+		},
+		ScalingGroup: &autoscaling.Group{},
+		ScalingConfiguration: &scaling.LaunchConfiguration{
+			AwsWorker: w,
+		},
+	})
+
+	// an access entry is created instead of an aws-auth mapping
+	g.Expect(ctx.BootstrapNodes()).To(gomega.Succeed())
+	g.Expect(eksMock.CreateAccessEntryCallCount).To(gomega.Equal(uint(1)))
+	g.Expect(eksMock.AccessEntries).To(gomega.HaveKey(roleArn))
+	g.Expect(aws.StringValue(eksMock.AccessEntries[roleArn].Type)).To(gomega.Equal(awsprovider.AccessEntryTypeLinux))
+	g.Expect(aws.StringValue(eksMock.AccessEntries[roleArn].ClusterName)).To(gomega.Equal(config.GetClusterName()))
+	_, err = k.Kubernetes.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "aws-auth", metav1.GetOptions{})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// existing access entries are not recreated
+	g.Expect(ctx.BootstrapNodes()).To(gomega.Succeed())
+	g.Expect(eksMock.CreateAccessEntryCallCount).To(gomega.Equal(uint(1)))
+
+	// access entries of the wrong type are replaced
+	eksMock.AccessEntries[roleArn].Type = aws.String(awsprovider.AccessEntryTypeWindows)
+	g.Expect(ctx.BootstrapNodes()).To(gomega.Succeed())
+	g.Expect(eksMock.DeleteAccessEntryCallCount).To(gomega.Equal(uint(1)))
+	g.Expect(eksMock.CreateAccessEntryCallCount).To(gomega.Equal(uint(2)))
+	g.Expect(aws.StringValue(eksMock.AccessEntries[roleArn].Type)).To(gomega.Equal(awsprovider.AccessEntryTypeLinux))
+
+	// access entries of roles shared with instancegroups of another type are not replaced
+	ig2 := MockInstanceGroup()
+	ig2.Name = "windows-instance-group"
+	ig2.Status.NodesArn = roleArn
+	ig2Obj, err := kubeprovider.GetUnstructuredInstanceGroup(ig2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = ctx.KubernetesClient.KubeDynamic.Resource(v1alpha1.GroupVersionResource).Namespace(ig2.Namespace).Create(context.Background(), ig2Obj, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	eksMock.AccessEntries[roleArn].Type = aws.String(awsprovider.AccessEntryTypeWindows)
+	err = ctx.BootstrapNodes()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("windows-instance-group"))
+	g.Expect(eksMock.DeleteAccessEntryCallCount).To(gomega.Equal(uint(1)))
+	g.Expect(aws.StringValue(eksMock.AccessEntries[roleArn].Type)).To(gomega.Equal(awsprovider.AccessEntryTypeWindows))
+	err = ctx.KubernetesClient.KubeDynamic.Resource(v1alpha1.GroupVersionResource).Namespace(ig2.Namespace).Delete(context.Background(), ig2.Name, metav1.DeleteOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	eksMock.AccessEntries[roleArn].Type = aws.String(awsprovider.AccessEntryTypeLinux)
+
+	// the access entry is removed on teardown
+	g.Expect(ctx.Delete()).To(gomega.Succeed())
+	g.Expect(eksMock.DeleteAccessEntryCallCount).To(gomega.Equal(uint(2)))
+	g.Expect(eksMock.AccessEntries).NotTo(gomega.HaveKey(roleArn))
+
+	// deleting an access entry which no longer exists succeeds
+	g.Expect(ctx.Delete()).To(gomega.Succeed())
+
+	// create failures are returned
+	eksMock.CreateAccessEntryErr = errors.New("access denied")
+	g.Expect(ctx.BootstrapNodes()).NotTo(gomega.Succeed())
+}
+
+func TestDeleteRegistryCredentialsParameter(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{},
+		ScalingConfiguration: &scaling.LaunchConfiguration{
+			AwsWorker: w,
+		},
+		IAMRole: &iam.Role{},
+	})
+
+	// no parameter is deleted when registry credentials are not referenced
+	err := ctx.Delete()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ssmMock.DeletedParameters).To(gomega.BeEmpty())
+
+	config.RegistryCredentials = &v1alpha1.RegistryCredentialsSpec{SecretName: "registry-credentials"}
+	err = ctx.Delete()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ssmMock.DeletedParameters).To(gomega.ConsistOf("/instance-manager/my-cluster/instance-manager/instance-group-1/registry-credentials"))
+}
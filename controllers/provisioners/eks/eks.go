@@ -16,10 +16,18 @@ limitations under the License.
 package eks
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/go-logr/logr"
@@ -40,11 +48,43 @@ const (
 	CustomNetworkingEnabledAnnotation                 = "instancemgr.keikoproj.io/custom-networking-enabled"
 	CustomNetworkingHostPodsAnnotation                = "instancemgr.keikoproj.io/custom-networking-host-pods"
 	CustomNetworkingPrefixAssignmentEnabledAnnotation = "instancemgr.keikoproj.io/custom-networking-prefix-assignment-enabled"
+	CustomNetworkingENIConfigLabelAnnotation          = "instancemgr.keikoproj.io/custom-networking-eniconfig-label"
+	CustomNetworkingENIConfigAnnotationAnnotation     = "instancemgr.keikoproj.io/custom-networking-eniconfig-annotation"
+	LaunchTemplateVersionPinAnnotation                = "instancemgr.keikoproj.io/pin-launch-template-version"
+	InstanceLifecycleLabelsAnnotation                 = "instancemgr.keikoproj.io/instance-lifecycle-labels"
 
 	OsFamilyWindows         = "windows"
 	OsFamilyBottleRocket    = "bottlerocket"
 	OsFamilyAmazonLinux2    = "amazonlinux2"
 	OsFamilyAmazonLinux2023 = "amazonlinux2023"
+
+	// DefaultMetadataHopLimit is the metadata hop limit of instances whose pods do not need to reach the metadata service
+	DefaultMetadataHopLimit = 1
+	// DefaultPodMetadataHopLimit is the metadata hop limit of instances whose pods reach the metadata service through
+	// their node's network
+	DefaultPodMetadataHopLimit = 2
+
+	// BootstrapDiagnosticsMaxLines and BootstrapDiagnosticsMaxBytes bound the tail of the console output published for
+	// instances which failed to join the cluster
+	BootstrapDiagnosticsMaxLines = 40
+	BootstrapDiagnosticsMaxBytes = 4096
+
+	// MetadataEndpoint and MetadataEndpointIPv6 are the addresses of the instance metadata service, which nodes never
+	// reach through a proxy
+	MetadataEndpoint     = "169.254.169.254"
+	MetadataEndpointIPv6 = "fd00:ec2::254"
+
+	// ImageOwnerAliasAmazon is the owner alias of images published by Amazon, such as the EKS optimized images
+	ImageOwnerAliasAmazon = "amazon"
+
+	// IPsPerPrefix is the number of IPs in a /28 prefix assigned to an interface
+	IPsPerPrefix = 16
+
+	RegistryCredentialsParameterFmt = "/instance-manager/%v/%v/%v/registry-credentials"
+	// RegistryCredentialsPolicyName is the inline policy of managed roles which allows nodes to read and decrypt the
+	// registry credentials parameter
+	RegistryCredentialsPolicyName        = "instance-manager-registry-credentials"
+	RegistryCredentialsPolicyDocumentFmt = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"ssm:GetParameter","Resource":"arn:%v:ssm:*:%v:parameter%v"},{"Effect":"Allow","Action":"kms:Decrypt","Resource":"*","Condition":{"StringLike":{"kms:ViaService":"ssm.*.amazonaws.com"}}}]}`
 )
 
 var (
@@ -54,13 +94,84 @@ var (
 	RoleOldLabelFmt           = "node-role.kubernetes.io/%s=\"\""
 	InstanceMgrLifecycleLabel = "instancemgr.keikoproj.io/lifecycle"
 	InstanceMgrImageLabel     = "instancemgr.keikoproj.io/image"
+	InstanceMgrVpcCNILabel    = "instancemgr.keikoproj.io/vpc-cni-version"
+
+	VpcCNIWarmIPTargetLabel    = "instancemgr.keikoproj.io/vpc-cni-warm-ip-target"
+	VpcCNIMinimumIPTargetLabel = "instancemgr.keikoproj.io/vpc-cni-minimum-ip-target"
+	VpcCNIWarmENITargetLabel   = "instancemgr.keikoproj.io/vpc-cni-warm-eni-target"
 
 	AllowedOsFamilies      = []string{OsFamilyWindows, OsFamilyBottleRocket, OsFamilyAmazonLinux2, OsFamilyAmazonLinux2023}
 	DefaultManagedPolicies = []string{"AmazonEKSWorkerNodePolicy", "AmazonEC2ContainerRegistryReadOnly"}
 	CNIManagedPolicy       = "AmazonEKS_CNI_Policy"
 	SupportedArchitectures = []string{"x86_64", "arm64"}
+	ECRImageRegex          = regexp.MustCompile(`^[0-9]{12}\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?/`)
+	// ImageKubernetesVersionRegex matches the Kubernetes version in the names of EKS optimized images, such as
+	// amazon-eks-node-1.30-v20240807, bottlerocket-aws-k8s-1.30-x86_64-v1.21.0 or
+	// Windows_Server-2022-English-Core-EKS_Optimized-1.30-2024.08.13
+	ImageKubernetesVersionRegex = regexp.MustCompile(`^(?:amazon-eks-(?:[a-z0-9_]+-)*node-(?:[a-z0-9_]+-)*|bottlerocket-aws-k8s-|Windows_Server-.+-EKS_Optimized-)(1\.[0-9]+)(?:[-_]|$)`)
+
+	// DefaultNoProxy are the hosts and domains nodes configured with a proxy always reach directly, .internal covers the
+	// private DNS names of instances
+	DefaultNoProxy = []string{"localhost", "127.0.0.1", MetadataEndpoint, MetadataEndpointIPv6, ".internal"}
+
+	// SupportedBootstrapFlags are the flags of each OS family's bootstrap script which can be set with
+	// bootstrapOptions.flags, flags the controller sets itself are not included. OS families without a bootstrap
+	// script do not support flags.
+	SupportedBootstrapFlags = map[string]map[string]BootstrapFlag{
+		OsFamilyAmazonLinux2: {
+			"use-max-pods":            boolBootstrapFlag,
+			"enable-docker-bridge":    boolBootstrapFlag,
+			"enable-local-outpost":    boolBootstrapFlag,
+			"aws-api-retry-attempts":  {Description: "a positive integer", IsValid: isPositiveInteger},
+			"ip-family":               {Description: "one of ipv4 or ipv6", IsValid: isOneOf("ipv4", "ipv6")},
+			"service-ipv6-cidr":       cidrBootstrapFlag,
+			"pause-container-account": {Description: "an AWS account id", IsValid: regexp.MustCompile(`^[0-9]{12}$`).MatchString},
+			"pause-container-version": {Description: "an image tag", IsValid: regexp.MustCompile(`^[a-zA-Z0-9._-]+$`).MatchString},
+			"local-disks":             {Description: "one of mount, raid0 or raid10", IsValid: isOneOf("mount", "raid0", "raid10")},
+		},
+		OsFamilyWindows: {
+			"DNSClusterIP":      {Description: "an IP address", IsValid: func(v string) bool { return net.ParseIP(v) != nil }},
+			"ServiceCIDR":       cidrBootstrapFlag,
+			"ExcludedSnatCIDRs": {Description: "a comma separated list of CIDRs", IsValid: isCIDRList},
+		},
+	}
+
+	boolBootstrapFlag = BootstrapFlag{Description: "true or false", IsValid: isOneOf("true", "false")}
+	cidrBootstrapFlag = BootstrapFlag{Description: "a CIDR", IsValid: isCIDR}
 )
 
+// BootstrapFlag is a flag of a bootstrap script which can be set with bootstrapOptions.flags
+type BootstrapFlag struct {
+	// Description describes the values the flag accepts
+	Description string
+	IsValid     func(value string) bool
+}
+
+func isOneOf(allowed ...string) func(string) bool {
+	return func(value string) bool {
+		return common.ContainsString(allowed, value)
+	}
+}
+
+func isPositiveInteger(value string) bool {
+	i, err := strconv.Atoi(value)
+	return err == nil && i > 0
+}
+
+func isCIDR(value string) bool {
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
+
+func isCIDRList(value string) bool {
+	for _, cidr := range strings.Split(value, ",") {
+		if !isCIDR(cidr) {
+			return false
+		}
+	}
+	return true
+}
+
 // New constructs a new instance group provisioner of EKS type
 func New(p provisioners.ProvisionerInput) *EksInstanceGroupContext {
 	var (
@@ -71,16 +182,37 @@ func New(p provisioners.ProvisionerInput) *EksInstanceGroupContext {
 	)
 
 	ctx := &EksInstanceGroupContext{
-		InstanceGroup:              instanceGroup,
-		KubernetesClient:           p.Kubernetes,
-		AwsWorker:                  p.AwsWorker,
-		Log:                        p.Log.WithName("eks"),
-		ResourcePrefix:             fmt.Sprintf("%v-%v-%v", configuration.GetClusterName(), instanceGroup.GetNamespace(), instanceGroup.GetName()),
-		ConfigRetention:            p.ConfigRetention,
-		Metrics:                    p.Metrics,
-		DisableWinClusterInjection: p.DisableWinClusterInjection,
+		InstanceGroup:                     instanceGroup,
+		KubernetesClient:                  p.Kubernetes,
+		AwsWorker:                         p.AwsWorker,
+		Log:                               p.Log.WithName("eks"),
+		ConfigRetention:                   p.ConfigRetention,
+		Metrics:                           p.Metrics,
+		DisableWinClusterInjection:        p.DisableWinClusterInjection,
+		UserDataValidator:                 p.UserDataValidator,
+		UserDataExporter:                  p.UserDataExporter,
+		StateExporter:                     p.StateExporter,
+		InstanceProfilePropagationTimeout: p.InstanceProfilePropagationTimeout,
+		PricingTable:                      p.PricingTable,
+		RotationNotifier:                  p.RotationNotifier,
+		OsFamilyManagedPolicies:           p.OsFamilyManagedPolicies,
+		Autoscaler:                        p.Autoscaler,
+		CrossAccountAuthMappings:          p.CrossAccountAuthMappings,
+		reconcileStartTime:                time.Now(),
 	}
 
+	variables := provisioners.ResourcePrefixVariables{
+		ClusterName: configuration.GetClusterName(),
+		Namespace:   instanceGroup.GetNamespace(),
+		Name:        instanceGroup.GetName(),
+	}
+	prefix, err := p.ResourcePrefixTemplate.RenderExisting(variables, status.GetActiveScalingGroupName())
+	if err != nil {
+		ctx.Log.Error(err, "failed to render resource prefix, using the default resource prefix", "instancegroup", instanceGroup.NamespacedName())
+		prefix = provisioners.TruncateResourcePrefix(fmt.Sprintf("%v-%v-%v", variables.ClusterName, variables.Namespace, variables.Name), status.GetActiveScalingGroupName())
+	}
+	ctx.ResourcePrefix = prefix
+
 	ctx.SetState(v1alpha1.ReconcileInit)
 	status.SetProvisioner(ProvisionerName)
 	status.SetStrategy(strategy.Type)
@@ -90,16 +222,30 @@ func New(p provisioners.ProvisionerInput) *EksInstanceGroupContext {
 
 type EksInstanceGroupContext struct {
 	sync.Mutex
-	InstanceGroup              *v1alpha1.InstanceGroup
-	KubernetesClient           kubeprovider.KubernetesClientSet
-	AwsWorker                  awsprovider.AwsWorker
-	DiscoveredState            *DiscoveredState
-	Log                        logr.Logger
-	Configuration              *provisioners.ProvisionerConfiguration
-	ConfigRetention            int
-	ResourcePrefix             string
-	Metrics                    *common.MetricsCollector
-	DisableWinClusterInjection bool
+	InstanceGroup                     *v1alpha1.InstanceGroup
+	KubernetesClient                  kubeprovider.KubernetesClientSet
+	AwsWorker                         awsprovider.AwsWorker
+	DiscoveredState                   *DiscoveredState
+	Log                               logr.Logger
+	Configuration                     *provisioners.ProvisionerConfiguration
+	ConfigRetention                   int
+	ResourcePrefix                    string
+	Metrics                           *common.MetricsCollector
+	DisableWinClusterInjection        bool
+	UserDataValidator                 *provisioners.UserDataValidator
+	UserDataExporter                  *provisioners.UserDataExporter
+	StateExporter                     *provisioners.StateExporter
+	InstanceProfilePropagationTimeout time.Duration
+	PricingTable                      awsprovider.PricingTable
+	RotationNotifier                  *provisioners.RotationNotifier
+	OsFamilyManagedPolicies           provisioners.OsFamilyManagedPolicies
+	Autoscaler                        *v1alpha1.InstanceGroupAutoscaler
+	CrossAccountAuthMappings          bool
+
+	// stateTransitionTime is the time the current state was set during this reconcile
+	stateTransitionTime time.Time
+	// reconcileStartTime is the time this reconcile started, provisioning is measured from it
+	reconcileStartTime time.Time
 }
 
 type UserDataPayload struct {
@@ -115,7 +261,75 @@ type MountOpts struct {
 	Persistance bool
 }
 
+// InstanceStorageOpts is where nodes mount the RAID0 array of their instance store volumes
+type InstanceStorageOpts struct {
+	MountPath  string
+	FileSystem string
+}
+
+// PrePullImage is an image pulled by nodes at bootstrap, ECR images are pulled with credentials of the instance profile
+type PrePullImage struct {
+	Image     string
+	ECRRegion string
+}
+
+// BootstrapRetriesOpts is how often nodes attempt the network operations of the bootstrap, waiting BackoffSeconds
+// times the number of failed attempts between them
+type BootstrapRetriesOpts struct {
+	Attempts       int64
+	BackoffSeconds int64
+}
+
+// ProxyOpts are the proxies the bootstrap, containerd and the kubelet reach the internet through, NoProxy are the
+// hosts, domains and CIDRs they reach directly
+type ProxyOpts struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    []string
+}
+
+// ProxyVariable is a proxy environment variable
+type ProxyVariable struct {
+	Name  string
+	Value string
+}
+
+// Variables returns the proxy environment variables, renderers also set their lower case names on Linux
+func (p *ProxyOpts) Variables() []ProxyVariable {
+	variables := make([]ProxyVariable, 0)
+	if p.HTTPProxy != "" {
+		variables = append(variables, ProxyVariable{Name: "HTTP_PROXY", Value: p.HTTPProxy})
+	}
+	if p.HTTPSProxy != "" {
+		variables = append(variables, ProxyVariable{Name: "HTTPS_PROXY", Value: p.HTTPSProxy})
+	}
+	return append(variables, ProxyVariable{Name: "NO_PROXY", Value: strings.Join(p.NoProxy, ",")})
+}
+
+// Proxy returns the HTTPS proxy, or the HTTP proxy if no HTTPS proxy is set, for OS families which configure a single
+// proxy
+func (p *ProxyOpts) Proxy() string {
+	if p.HTTPSProxy != "" {
+		return p.HTTPSProxy
+	}
+	return p.HTTPProxy
+}
+
+// CABundleOpts are the certificates of the certificate authorities nodes trust, PEM is the PEM encoded bundle and
+// Certificates are the base64 encoded DER of each certificate
+type CABundleOpts struct {
+	PEM          string
+	Certificates []string
+}
+
+// Base64 returns the base64 encoded PEM bundle
+func (b *CABundleOpts) Base64() string {
+	return base64.StdEncoding.EncodeToString([]byte(b.PEM + "\n"))
+}
+
+// EKSUserData is the input of a UserDataRenderer
 type EKSUserData struct {
+	OsFamily         string
 	ApiEndpoint      string
 	ClusterCA        string
 	ClusterName      string
@@ -129,6 +343,27 @@ type EKSUserData struct {
 	MaxPods          int64
 	ClusterIP        string
 	NodeConfigYaml   string
+
+	RegistryCredentialsParameter    string
+	WindowsContainerd               *v1alpha1.WindowsContainerdSpec
+	PrePullImages                   []PrePullImage
+	ImageGCHighThreshold            *int64
+	ImageGCLowThreshold             *int64
+	SerializeImagePulls             *bool
+	MaxParallelImagePulls           *int64
+	ShutdownGracePeriod             string
+	ShutdownGracePeriodCriticalPods string
+	AllowedUnsafeSysctls            []string
+	InstanceStorage                 *InstanceStorageOpts
+	BootstrapRetries                *BootstrapRetriesOpts
+	Proxy                           *ProxyOpts
+	CABundle                        *CABundleOpts
+	// KubeletConfig are the fields of the kubelet configuration fragment mapped to their JSON encoded values
+	KubeletConfig map[string]string
+	// Sysctls are the kernel parameters applied at boot, mapped to their values
+	Sysctls map[string]string
+	// Variables are user-defined template variables, TemplateRenderer exposes them alongside the other fields
+	Variables map[string]string
 }
 
 func (ctx *EksInstanceGroupContext) GetInstanceGroup() *v1alpha1.InstanceGroup {
@@ -148,7 +383,7 @@ func (ctx *EksInstanceGroupContext) GetOsFamily() string {
 		ctx.Log.Info("using amazonlinux2023 for os family")
 		return OsFamilyAmazonLinux2023
 	} else if v, exists := annotations[OsFamilyAnnotation]; exists {
-		if common.ContainsEqualFold(AllowedOsFamilies, v) {
+		if IsRegisteredOsFamily(v) {
 			ctx.Log.Info("using amazon linux os family annotation", "value", v)
 			return annotations[OsFamilyAnnotation]
 		}
@@ -177,7 +412,7 @@ func (ctx *EksInstanceGroupContext) IsAmazonLinux2023() bool {
 
 func (ctx *EksInstanceGroupContext) GetUpgradeStrategy() *v1alpha1.AwsUpgradeStrategy {
 	// Check if the upgrade strategy has been set (non-zero value)
-	if ctx.InstanceGroup.Spec.AwsUpgradeStrategy != (v1alpha1.AwsUpgradeStrategy{}) {
+	if !reflect.DeepEqual(ctx.InstanceGroup.Spec.AwsUpgradeStrategy, v1alpha1.AwsUpgradeStrategy{}) {
 		return &ctx.InstanceGroup.Spec.AwsUpgradeStrategy
 	}
 	return &v1alpha1.AwsUpgradeStrategy{}
@@ -192,6 +427,13 @@ func (ctx *EksInstanceGroupContext) SetState(state v1alpha1.ReconcileState) {
 		name     = ctx.GetInstanceGroup().NamespacedName()
 		stateStr = string(state)
 	)
+	// the time spent in the previous state is only known for states set during this reconcile
+	now := time.Now()
+	if !ctx.stateTransitionTime.IsZero() {
+		ctx.Metrics.ObserveStateDuration(name, string(ctx.GetState()), now.Sub(ctx.stateTransitionTime))
+	}
+	ctx.stateTransitionTime = now
+	ctx.Log.V(1).Info("setting reconcile state", "instancegroup", name, "from", ctx.GetState(), "to", state)
 	ctx.Metrics.SetInstanceGroup(name, stateStr)
 	ctx.InstanceGroup.SetState(state)
 }
@@ -218,6 +460,27 @@ type InstanceSpec struct {
 	Weight string
 }
 
+// Validate returns an error if the weight cannot be used as an ASG weighted capacity
+func (s InstanceSpec) Validate() error {
+	weight, err := strconv.ParseInt(s.Weight, 10, 64)
+	if err != nil {
+		return errors.Errorf("validation failed, weight for instance type '%v' must be an integer, got '%v'", s.Type, s.Weight)
+	}
+	if !common.Int64InRange(weight, awsprovider.MinWeightedCapacity, awsprovider.MaxWeightedCapacity) {
+		return errors.Errorf("validation failed, weight for instance type '%v' must be between %v and %v, got %v", s.Type, awsprovider.MinWeightedCapacity, awsprovider.MaxWeightedCapacity, s.Weight)
+	}
+	return nil
+}
+
+// ScalingGroupShard represents a desired scaling group of an instance group
+type ScalingGroupShard struct {
+	Name    string
+	Zone    string
+	Subnets []string
+	MinSize int64
+	MaxSize int64
+}
+
 type InstancePool struct {
 	Type InstancePoolType
 	Pool map[string][]InstanceSpec
@@ -230,6 +493,24 @@ func (p *InstancePool) GetPool(key string) ([]InstanceSpec, bool) {
 	return nil, false
 }
 
+// Validate returns an error if an instance type of the pool has a weight which cannot be used as an override
+func (p *InstancePool) Validate() error {
+	keys := make([]string, 0, len(p.Pool))
+	for key := range p.Pool {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, spec := range p.Pool[key] {
+			if err := spec.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (ctx *EksInstanceGroupContext) Locked() bool {
 	return ctx.InstanceGroup.Locked()
 }
@@ -16,11 +16,13 @@ limitations under the License.
 package eks
 
 import (
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -37,6 +39,7 @@ import (
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
 	"github.com/keikoproj/instance-manager/controllers/provisioners"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -91,6 +94,20 @@ func MockAwsWorker(asgClient *MockAutoScalingClient, iamClient *MockIamClient, e
 	}
 }
 
+// MockCertificate is a self-signed CA certificate
+const MockCertificate = `-----BEGIN CERTIFICATE-----
+MIIBlDCCATmgAwIBAgIUIF/BL+oJz5pj6sGiKoNpe9uhgaIwCgYIKoZIzj0EAwIw
+HjEcMBoGA1UEAwwTRXhhbXBsZSBJbnRlcm5hbCBDQTAgFw0yNjEwMTUwMDA2NDBa
+GA8yMTI2MDkyMTAwMDY0MFowHjEcMBoGA1UEAwwTRXhhbXBsZSBJbnRlcm5hbCBD
+QTBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABOQn1G1y4huNLo0ELuB/lgjv7MNQ
+WO+wncfm3PWp0DY1a84FRgTrJ5m8NjxBZCixqPyWcXVeSyRlULAtMr7rat2jUzBR
+MB0GA1UdDgQWBBRFDUHk6nsdLecCdmEqmAcNxWcYMDAfBgNVHSMEGDAWgBRFDUHk
+6nsdLecCdmEqmAcNxWcYMDAPBgNVHRMBAf8EBTADAQH/MAoGCCqGSM49BAMCA0kA
+MEYCIQDhTMfxDBHW2TZie2J3Jb/iVgPDcXEpZRxyaGSmjJaU9AIhAI+txVvcJfWk
+M2LKzua6qSyf7rjBKHpJYwYfqrj1WC3v
+-----END CERTIFICATE-----
+`
+
 func MockEksCluster(version string) *eks.Cluster {
 	return &eks.Cluster{
 		CertificateAuthority: &eks.Certificate{
@@ -184,6 +201,24 @@ func MockInstanceGroup() *v1alpha1.InstanceGroup {
 	}
 }
 
+func MockInstanceGroupAutoscaler(target string, min, max int64, targetValue string) *v1alpha1.InstanceGroupAutoscaler {
+	return &v1alpha1.InstanceGroupAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "autoscaler-1",
+			Namespace: "instance-manager",
+		},
+		Spec: v1alpha1.InstanceGroupAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetReference{Name: target},
+			MinCapacity:    min,
+			MaxCapacity:    max,
+			Metric: v1alpha1.AutoscalerMetricSpec{
+				Name:               "queue-depth",
+				TargetAverageValue: resource.MustParse(targetValue),
+			},
+		},
+	}
+}
+
 func MockWindowsInstanceGroup() *v1alpha1.InstanceGroup {
 	return &v1alpha1.InstanceGroup{
 		ObjectMeta: metav1.ObjectMeta{
@@ -332,6 +367,7 @@ func MockScalingGroup(name string, withTemplate bool, t ...*autoscaling.TagDescr
 		MinSize:              aws.Int64(3),
 		MaxSize:              aws.Int64(6),
 		VPCZoneIdentifier:    aws.String("subnet-1,subnet-2,subnet-3"),
+		DefaultCooldown:      aws.Int64(300),
 		Instances: []*autoscaling.Instance{
 			{
 				InstanceType: aws.String("m5.xlarge"),
@@ -364,6 +400,24 @@ func MockSecurityGroup(id string, withTag bool, name string) *ec2.SecurityGroup
 	return sg
 }
 
+func MockRouteTable(main, withInternetGateway bool, subnets ...string) *ec2.RouteTable {
+	rt := &ec2.RouteTable{
+		Routes: []*ec2.Route{
+			{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local")},
+		},
+	}
+	if withInternetGateway {
+		rt.Routes = append(rt.Routes, &ec2.Route{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-1234")})
+	}
+	if main {
+		rt.Associations = append(rt.Associations, &ec2.RouteTableAssociation{Main: aws.Bool(true)})
+	}
+	for _, s := range subnets {
+		rt.Associations = append(rt.Associations, &ec2.RouteTableAssociation{Main: aws.Bool(false), SubnetId: aws.String(s)})
+	}
+	return rt
+}
+
 func MockSubnet(id string, withTag bool, name string) *ec2.Subnet {
 	sn := &ec2.Subnet{
 		SubnetId: aws.String(id),
@@ -391,6 +445,34 @@ func MockTypeOffering(region string, types ...string) []*ec2.InstanceTypeOfferin
 	return out
 }
 
+// MockMetricsSink records the events it receives from a metrics collector
+type MockMetricsSink struct {
+	States    []string
+	Durations []string
+	Readiness []time.Duration
+	Failures  []string
+}
+
+func (m *MockMetricsSink) SetInstanceGroupState(instanceGroup, state string) {
+	m.States = append(m.States, state)
+}
+
+func (m *MockMetricsSink) ObserveStateDuration(instanceGroup, state string, duration time.Duration) {
+	m.Durations = append(m.Durations, state)
+}
+
+func (m *MockMetricsSink) ObserveReadinessDuration(instanceGroup string, duration time.Duration) {
+	m.Readiness = append(m.Readiness, duration)
+}
+
+func (m *MockMetricsSink) IncSuccess(instanceGroup string) {}
+
+func (m *MockMetricsSink) IncFail(instanceGroup, reason string) {
+	m.Failures = append(m.Failures, reason)
+}
+
+func (m *MockMetricsSink) IncThrottle(serviceName, operationName string) {}
+
 type MockInstanceTypeInfo struct {
 	InstanceType string
 	VCpus        int64
@@ -519,49 +601,71 @@ func MockEnabledMetrics(metrics ...string) []*autoscaling.EnabledMetric {
 
 type MockAutoScalingClient struct {
 	autoscalingiface.AutoScalingAPI
-	DescribeLaunchConfigurationsErr        error
-	DescribeAutoScalingGroupsErr           error
-	CreateLaunchConfigurationErr           error
-	DeleteLaunchConfigurationErr           error
-	CreateAutoScalingGroupErr              error
-	UpdateAutoScalingGroupErr              error
-	DeleteAutoScalingGroupErr              error
-	TerminateInstanceInAutoScalingGroupErr error
-	EnableMetricsCollectionErr             error
-	DisableMetricsCollectionErr            error
-	UpdateSuspendProcessesErr              error
-	DescribeLifecycleHooksErr              error
-	PutLifecycleHookErr                    error
-	DeleteLifecycleHookErr                 error
-	DescribeWarmPoolErr                    error
-	DeleteWarmPoolErr                      error
-	PutWarmPoolErr                         error
-	DeleteLaunchConfigurationCallCount     uint
-	PutLifecycleHookCallCount              uint
-	DeleteLifecycleHookCallCount           uint
-	PutWarmPoolCallCount                   uint
-	DeleteWarmPoolCallCount                uint
-	DescribeWarmPoolCallCount              uint
-	LaunchConfiguration                    *autoscaling.LaunchConfiguration
-	LaunchConfigurations                   []*autoscaling.LaunchConfiguration
-	AutoScalingGroup                       *autoscaling.Group
-	AutoScalingGroups                      []*autoscaling.Group
-	WarmPoolInstances                      []*autoscaling.Instance
-	LifecycleHooks                         []*autoscaling.LifecycleHook
+	DescribeLaunchConfigurationsErr              error
+	DescribeAutoScalingGroupsErr                 error
+	CreateLaunchConfigurationErr                 error
+	DeleteLaunchConfigurationErr                 error
+	CreateAutoScalingGroupErr                    error
+	UpdateAutoScalingGroupErr                    error
+	DeleteAutoScalingGroupErr                    error
+	TerminateInstanceInAutoScalingGroupErr       error
+	EnableMetricsCollectionErr                   error
+	DisableMetricsCollectionErr                  error
+	EnabledMetrics                               []string
+	DisabledMetrics                              []string
+	UpdateSuspendProcessesErr                    error
+	DescribeLifecycleHooksErr                    error
+	PutLifecycleHookErr                          error
+	DeleteLifecycleHookErr                       error
+	DescribeWarmPoolErr                          error
+	DeleteWarmPoolErr                            error
+	PutWarmPoolErr                               error
+	DescribeInstanceRefreshesErr                 error
+	DeleteLaunchConfigurationCallCount           uint
+	DeleteAutoScalingGroupCallCount              uint
+	TerminateInstanceInAutoScalingGroupCallCount uint
+	PutLifecycleHookCallCount                    uint
+	DeleteLifecycleHookCallCount                 uint
+	PutWarmPoolCallCount                         uint
+	DeleteWarmPoolCallCount                      uint
+	DescribeWarmPoolCallCount                    uint
+	CreateAutoScalingGroupInputs                 []*autoscaling.CreateAutoScalingGroupInput
+	UpdateAutoScalingGroupInputs                 []*autoscaling.UpdateAutoScalingGroupInput
+	SuspendProcessesInputs                       []*autoscaling.ScalingProcessQuery
+	ResumeProcessesInputs                        []*autoscaling.ScalingProcessQuery
+	TerminatedInstanceIds                        []string
+	SetInstanceProtectionErr                     error
+	SetInstanceProtectionInputs                  []*autoscaling.SetInstanceProtectionInput
+	LaunchConfiguration                          *autoscaling.LaunchConfiguration
+	LaunchConfigurations                         []*autoscaling.LaunchConfiguration
+	AutoScalingGroup                             *autoscaling.Group
+	AutoScalingGroups                            []*autoscaling.Group
+	WarmPoolInstances                            []*autoscaling.Instance
+	LifecycleHooks                               []*autoscaling.LifecycleHook
+	InstanceRefreshes                            []*autoscaling.InstanceRefresh
 }
 
 func (a *MockAutoScalingClient) EnableMetricsCollection(input *autoscaling.EnableMetricsCollectionInput) (*autoscaling.EnableMetricsCollectionOutput, error) {
+	a.EnabledMetrics = append(a.EnabledMetrics, aws.StringValueSlice(input.Metrics)...)
 	return &autoscaling.EnableMetricsCollectionOutput{}, a.EnableMetricsCollectionErr
 }
 
 func (a *MockAutoScalingClient) DisableMetricsCollection(input *autoscaling.DisableMetricsCollectionInput) (*autoscaling.DisableMetricsCollectionOutput, error) {
+	a.DisabledMetrics = append(a.DisabledMetrics, aws.StringValueSlice(input.Metrics)...)
 	return &autoscaling.DisableMetricsCollectionOutput{}, a.DisableMetricsCollectionErr
 }
 
 func (a *MockAutoScalingClient) TerminateInstanceInAutoScalingGroup(input *autoscaling.TerminateInstanceInAutoScalingGroupInput) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error) {
+	a.TerminateInstanceInAutoScalingGroupCallCount++
+	a.TerminatedInstanceIds = append(a.TerminatedInstanceIds, aws.StringValue(input.InstanceId))
 	return &autoscaling.TerminateInstanceInAutoScalingGroupOutput{}, a.TerminateInstanceInAutoScalingGroupErr
 }
 
+func (a *MockAutoScalingClient) SetInstanceProtection(input *autoscaling.SetInstanceProtectionInput) (*autoscaling.SetInstanceProtectionOutput, error) {
+	a.SetInstanceProtectionInputs = append(a.SetInstanceProtectionInputs, input)
+	return &autoscaling.SetInstanceProtectionOutput{}, a.SetInstanceProtectionErr
+}
+
 func (a *MockAutoScalingClient) CreateOrUpdateTags(input *autoscaling.CreateOrUpdateTagsInput) (*autoscaling.CreateOrUpdateTagsOutput, error) {
 	return &autoscaling.CreateOrUpdateTagsOutput{}, nil
 }
@@ -584,10 +688,12 @@ func (a *MockAutoScalingClient) DeleteLaunchConfiguration(input *autoscaling.Del
 }
 
 func (a *MockAutoScalingClient) CreateAutoScalingGroup(input *autoscaling.CreateAutoScalingGroupInput) (*autoscaling.CreateAutoScalingGroupOutput, error) {
+	a.CreateAutoScalingGroupInputs = append(a.CreateAutoScalingGroupInputs, input)
 	return &autoscaling.CreateAutoScalingGroupOutput{}, a.CreateAutoScalingGroupErr
 }
 
 func (a *MockAutoScalingClient) DeleteAutoScalingGroup(input *autoscaling.DeleteAutoScalingGroupInput) (*autoscaling.DeleteAutoScalingGroupOutput, error) {
+	a.DeleteAutoScalingGroupCallCount++
 	return &autoscaling.DeleteAutoScalingGroupOutput{}, a.DeleteAutoScalingGroupErr
 }
 
@@ -614,14 +720,17 @@ func (a *MockAutoScalingClient) DescribeLaunchConfigurationsPages(input *autosca
 }
 
 func (a *MockAutoScalingClient) UpdateAutoScalingGroup(input *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	a.UpdateAutoScalingGroupInputs = append(a.UpdateAutoScalingGroupInputs, input)
 	return &autoscaling.UpdateAutoScalingGroupOutput{}, a.UpdateAutoScalingGroupErr
 }
 
 func (a *MockAutoScalingClient) SuspendProcesses(input *autoscaling.ScalingProcessQuery) (*autoscaling.SuspendProcessesOutput, error) {
+	a.SuspendProcessesInputs = append(a.SuspendProcessesInputs, input)
 	return &autoscaling.SuspendProcessesOutput{}, a.UpdateSuspendProcessesErr
 }
 
 func (a *MockAutoScalingClient) ResumeProcesses(input *autoscaling.ScalingProcessQuery) (*autoscaling.ResumeProcessesOutput, error) {
+	a.ResumeProcessesInputs = append(a.ResumeProcessesInputs, input)
 	return &autoscaling.ResumeProcessesOutput{}, a.UpdateSuspendProcessesErr
 }
 
@@ -654,24 +763,42 @@ func (a *MockAutoScalingClient) PutWarmPool(input *autoscaling.PutWarmPoolInput)
 	return &autoscaling.PutWarmPoolOutput{}, a.PutWarmPoolErr
 }
 
+func (a *MockAutoScalingClient) DescribeInstanceRefreshes(input *autoscaling.DescribeInstanceRefreshesInput) (*autoscaling.DescribeInstanceRefreshesOutput, error) {
+	return &autoscaling.DescribeInstanceRefreshesOutput{InstanceRefreshes: a.InstanceRefreshes}, a.DescribeInstanceRefreshesErr
+}
+
 type MockEc2Client struct {
 	ec2iface.EC2API
 	DescribeSubnetsErr                   error
 	DescribeSecurityGroupsErr            error
+	DescribeInstancesErr                 error
+	DescribeTagsErr                      error
+	DescribeTagsCallCount                uint
+	DescribeImagesErr                    error
+	ModifyInstanceAttributeErr           error
+	ModifiedInstances                    []string
 	CreateLaunchTemplateCallCount        uint
 	CreateLaunchTemplateVersionCallCount uint
 	ModifyLaunchTemplateCallCount        uint
 	DeleteLaunchTemplateCallCount        uint
+	LaunchTemplateData                   *ec2.RequestLaunchTemplateData
 	Subnets                              []*ec2.Subnet
+	Vpcs                                 []*ec2.Vpc
+	RouteTables                          []*ec2.RouteTable
 	SecurityGroups                       []*ec2.SecurityGroup
 	LaunchTemplates                      []*ec2.LaunchTemplate
 	LaunchTemplateVersions               []*ec2.LaunchTemplateVersion
 	InstanceTypeOfferings                []*ec2.InstanceTypeOffering
 	InstanceTypes                        []*ec2.InstanceTypeInfo
+	Instances                            []*ec2.Instance
+	ConsoleOutputs                       map[string]string
+	Images                               []*ec2.Image
+	GetConsoleOutputErr                  error
 }
 
 func (c *MockEc2Client) CreateLaunchTemplate(input *ec2.CreateLaunchTemplateInput) (*ec2.CreateLaunchTemplateOutput, error) {
 	c.CreateLaunchTemplateCallCount++
+	c.LaunchTemplateData = input.LaunchTemplateData
 	return &ec2.CreateLaunchTemplateOutput{}, nil
 }
 
@@ -682,6 +809,7 @@ func (c *MockEc2Client) ModifyLaunchTemplate(input *ec2.ModifyLaunchTemplateInpu
 
 func (c *MockEc2Client) CreateLaunchTemplateVersion(input *ec2.CreateLaunchTemplateVersionInput) (*ec2.CreateLaunchTemplateVersionOutput, error) {
 	c.CreateLaunchTemplateVersionCallCount++
+	c.LaunchTemplateData = input.LaunchTemplateData
 	out := &ec2.CreateLaunchTemplateVersionOutput{
 		LaunchTemplateVersion: &ec2.LaunchTemplateVersion{
 			VersionNumber: aws.Int64(1),
@@ -761,6 +889,84 @@ func (c *MockEc2Client) DescribeSubnetsPages(input *ec2.DescribeSubnetsInput, ca
 	return nil
 }
 
+func (c *MockEc2Client) DescribeVpcs(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
+	return &ec2.DescribeVpcsOutput{Vpcs: c.Vpcs}, nil
+}
+
+func (c *MockEc2Client) DescribeRouteTablesPages(input *ec2.DescribeRouteTablesInput, callback func(*ec2.DescribeRouteTablesOutput, bool) bool) error {
+	callback(&ec2.DescribeRouteTablesOutput{RouteTables: c.RouteTables}, false)
+	return nil
+}
+
+func (c *MockEc2Client) DescribeInstancesPages(input *ec2.DescribeInstancesInput, callback func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	page, err := c.DescribeInstances(input)
+	if err != nil {
+		return err
+	}
+	callback(page, false)
+	return nil
+}
+
+func (c *MockEc2Client) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	instances := []*ec2.Instance{}
+	for _, instance := range c.Instances {
+		if common.ContainsEqualFold(aws.StringValueSlice(input.InstanceIds), aws.StringValue(instance.InstanceId)) {
+			instances = append(instances, instance)
+		}
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: instances}}}, c.DescribeInstancesErr
+}
+
+func (c *MockEc2Client) DescribeTagsPages(input *ec2.DescribeTagsInput, callback func(*ec2.DescribeTagsOutput, bool) bool) error {
+	c.DescribeTagsCallCount++
+	if c.DescribeTagsErr != nil {
+		return c.DescribeTagsErr
+	}
+	var resourceIds []string
+	for _, filter := range input.Filters {
+		if aws.StringValue(filter.Name) == "resource-id" {
+			resourceIds = aws.StringValueSlice(filter.Values)
+		}
+	}
+	tags := []*ec2.TagDescription{}
+	for _, instance := range c.Instances {
+		if !common.ContainsEqualFold(resourceIds, aws.StringValue(instance.InstanceId)) {
+			continue
+		}
+		for _, tag := range instance.Tags {
+			tags = append(tags, &ec2.TagDescription{ResourceId: instance.InstanceId, Key: tag.Key, Value: tag.Value})
+		}
+	}
+	callback(&ec2.DescribeTagsOutput{Tags: tags}, false)
+	return nil
+}
+
+func (c *MockEc2Client) GetConsoleOutput(input *ec2.GetConsoleOutputInput) (*ec2.GetConsoleOutputOutput, error) {
+	if c.GetConsoleOutputErr != nil {
+		return &ec2.GetConsoleOutputOutput{}, c.GetConsoleOutputErr
+	}
+	output := base64.StdEncoding.EncodeToString([]byte(c.ConsoleOutputs[aws.StringValue(input.InstanceId)]))
+	return &ec2.GetConsoleOutputOutput{InstanceId: input.InstanceId, Output: aws.String(output)}, nil
+}
+
+func (c *MockEc2Client) DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+	images := []*ec2.Image{}
+	for _, image := range c.Images {
+		if common.ContainsEqualFold(aws.StringValueSlice(input.ImageIds), aws.StringValue(image.ImageId)) {
+			images = append(images, image)
+		}
+	}
+	return &ec2.DescribeImagesOutput{Images: images}, c.DescribeImagesErr
+}
+
+func (c *MockEc2Client) ModifyInstanceAttribute(input *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error) {
+	if c.ModifyInstanceAttributeErr != nil {
+		return &ec2.ModifyInstanceAttributeOutput{}, c.ModifyInstanceAttributeErr
+	}
+	c.ModifiedInstances = append(c.ModifiedInstances, aws.StringValue(input.InstanceId))
+	return &ec2.ModifyInstanceAttributeOutput{}, nil
+}
+
 func (c *MockEc2Client) DescribeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
 	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: c.SecurityGroups}, c.DescribeSecurityGroupsErr
 }
@@ -771,12 +977,64 @@ func (c *MockEc2Client) DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.D
 
 type MockEksClient struct {
 	eksiface.EKSAPI
-	DescribeClusterErr error
-	EksCluster         *eks.Cluster
+	DescribeClusterErr         error
+	ListClustersErr            error
+	CreateAccessEntryErr       error
+	CreateAccessEntryCallCount uint
+	DeleteAccessEntryCallCount uint
+	EksCluster                 *eks.Cluster
+	EksClusters                []*eks.Cluster
+	AccessEntries              map[string]*eks.AccessEntry
+}
+
+func (e *MockEksClient) DescribeAccessEntry(input *eks.DescribeAccessEntryInput) (*eks.DescribeAccessEntryOutput, error) {
+	if entry, ok := e.AccessEntries[aws.StringValue(input.PrincipalArn)]; ok {
+		return &eks.DescribeAccessEntryOutput{AccessEntry: entry}, nil
+	}
+	return &eks.DescribeAccessEntryOutput{}, awserr.New(eks.ErrCodeResourceNotFoundException, "access entry not found", nil)
+}
+
+func (e *MockEksClient) CreateAccessEntry(input *eks.CreateAccessEntryInput) (*eks.CreateAccessEntryOutput, error) {
+	e.CreateAccessEntryCallCount++
+	if e.CreateAccessEntryErr != nil {
+		return &eks.CreateAccessEntryOutput{}, e.CreateAccessEntryErr
+	}
+	if e.AccessEntries == nil {
+		e.AccessEntries = make(map[string]*eks.AccessEntry)
+	}
+	entry := &eks.AccessEntry{ClusterName: input.ClusterName, PrincipalArn: input.PrincipalArn, Type: input.Type}
+	e.AccessEntries[aws.StringValue(input.PrincipalArn)] = entry
+	return &eks.CreateAccessEntryOutput{AccessEntry: entry}, nil
+}
+
+func (e *MockEksClient) DeleteAccessEntry(input *eks.DeleteAccessEntryInput) (*eks.DeleteAccessEntryOutput, error) {
+	e.DeleteAccessEntryCallCount++
+	if _, ok := e.AccessEntries[aws.StringValue(input.PrincipalArn)]; !ok {
+		return &eks.DeleteAccessEntryOutput{}, awserr.New(eks.ErrCodeResourceNotFoundException, "access entry not found", nil)
+	}
+	delete(e.AccessEntries, aws.StringValue(input.PrincipalArn))
+	return &eks.DeleteAccessEntryOutput{}, nil
 }
 
 func (e *MockEksClient) DescribeCluster(input *eks.DescribeClusterInput) (*eks.DescribeClusterOutput, error) {
-	return &eks.DescribeClusterOutput{Cluster: e.EksCluster}, e.DescribeClusterErr
+	if len(e.EksClusters) == 0 {
+		return &eks.DescribeClusterOutput{Cluster: e.EksCluster}, e.DescribeClusterErr
+	}
+	for _, cluster := range e.EksClusters {
+		if aws.StringValue(cluster.Name) == aws.StringValue(input.Name) {
+			return &eks.DescribeClusterOutput{Cluster: cluster}, e.DescribeClusterErr
+		}
+	}
+	return &eks.DescribeClusterOutput{}, awserr.New(eks.ErrCodeResourceNotFoundException, "cluster not found", nil)
+}
+
+func (e *MockEksClient) ListClustersPages(input *eks.ListClustersInput, callback func(*eks.ListClustersOutput, bool) bool) error {
+	names := make([]string, 0)
+	for _, cluster := range e.EksClusters {
+		names = append(names, aws.StringValue(cluster.Name))
+	}
+	callback(&eks.ListClustersOutput{Clusters: aws.StringSlice(names)}, true)
+	return e.ListClustersErr
 }
 
 type MockIamClient struct {
@@ -795,9 +1053,15 @@ type MockIamClient struct {
 	DetachRolePolicyCallCount         uint
 	WaitUntilInstanceProfileExistsErr error
 	ListAttachedRolePoliciesErr       error
+	TagRoleErr                        error
+	TagRoleCallCount                  uint
+	UntagRoleCallCount                uint
+	TagInstanceProfileCallCount       uint
+	UntagInstanceProfileCallCount     uint
 	Role                              *iam.Role
 	InstanceProfile                   *iam.InstanceProfile
 	AttachedPolicies                  []*iam.AttachedPolicy
+	InlinePolicies                    map[string]string
 }
 
 func (i *MockIamClient) ListAttachedRolePolicies(input *iam.ListAttachedRolePoliciesInput) (*iam.ListAttachedRolePoliciesOutput, error) {
@@ -816,6 +1080,28 @@ func (i *MockIamClient) ListAttachedRolePoliciesPages(input *iam.ListAttachedRol
 	return nil
 }
 
+func (i *MockIamClient) ListRolePoliciesPages(input *iam.ListRolePoliciesInput, callback func(*iam.ListRolePoliciesOutput, bool) bool) error {
+	names := make([]string, 0)
+	for name := range i.InlinePolicies {
+		names = append(names, name)
+	}
+	callback(&iam.ListRolePoliciesOutput{PolicyNames: aws.StringSlice(names)}, false)
+	return nil
+}
+
+func (i *MockIamClient) PutRolePolicy(input *iam.PutRolePolicyInput) (*iam.PutRolePolicyOutput, error) {
+	if i.InlinePolicies == nil {
+		i.InlinePolicies = make(map[string]string)
+	}
+	i.InlinePolicies[aws.StringValue(input.PolicyName)] = aws.StringValue(input.PolicyDocument)
+	return &iam.PutRolePolicyOutput{}, nil
+}
+
+func (i *MockIamClient) DeleteRolePolicy(input *iam.DeleteRolePolicyInput) (*iam.DeleteRolePolicyOutput, error) {
+	delete(i.InlinePolicies, aws.StringValue(input.PolicyName))
+	return &iam.DeleteRolePolicyOutput{}, nil
+}
+
 func (i *MockIamClient) CreateRole(input *iam.CreateRoleInput) (*iam.CreateRoleOutput, error) {
 	if i.Role != nil {
 		return &iam.CreateRoleOutput{Role: i.Role}, i.CreateRoleErr
@@ -864,13 +1150,75 @@ func (i *MockIamClient) GetInstanceProfile(input *iam.GetInstanceProfileInput) (
 	return &iam.GetInstanceProfileOutput{InstanceProfile: i.InstanceProfile}, i.GetInstanceProfileErr
 }
 
+func (i *MockIamClient) TagRole(input *iam.TagRoleInput) (*iam.TagRoleOutput, error) {
+	i.TagRoleCallCount++
+	if i.TagRoleErr != nil {
+		return &iam.TagRoleOutput{}, i.TagRoleErr
+	}
+	i.Role.Tags = MockUpsertIAMTags(i.Role.Tags, input.Tags)
+	return &iam.TagRoleOutput{}, nil
+}
+
+func (i *MockIamClient) UntagRole(input *iam.UntagRoleInput) (*iam.UntagRoleOutput, error) {
+	i.UntagRoleCallCount++
+	i.Role.Tags = MockRemoveIAMTags(i.Role.Tags, aws.StringValueSlice(input.TagKeys))
+	return &iam.UntagRoleOutput{}, nil
+}
+
+func (i *MockIamClient) TagInstanceProfile(input *iam.TagInstanceProfileInput) (*iam.TagInstanceProfileOutput, error) {
+	i.TagInstanceProfileCallCount++
+	i.InstanceProfile.Tags = MockUpsertIAMTags(i.InstanceProfile.Tags, input.Tags)
+	return &iam.TagInstanceProfileOutput{}, nil
+}
+
+func (i *MockIamClient) UntagInstanceProfile(input *iam.UntagInstanceProfileInput) (*iam.UntagInstanceProfileOutput, error) {
+	i.UntagInstanceProfileCallCount++
+	i.InstanceProfile.Tags = MockRemoveIAMTags(i.InstanceProfile.Tags, aws.StringValueSlice(input.TagKeys))
+	return &iam.UntagInstanceProfileOutput{}, nil
+}
+
+func MockUpsertIAMTags(current, tags []*iam.Tag) []*iam.Tag {
+	keys := make([]string, 0)
+	for _, tag := range tags {
+		keys = append(keys, aws.StringValue(tag.Key))
+	}
+	return append(MockRemoveIAMTags(current, keys), tags...)
+}
+
+func MockRemoveIAMTags(current []*iam.Tag, keys []string) []*iam.Tag {
+	tags := make([]*iam.Tag, 0)
+	for _, tag := range current {
+		if !common.ContainsString(keys, aws.StringValue(tag.Key)) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 func (i *MockIamClient) WaitUntilInstanceProfileExists(input *iam.GetInstanceProfileInput) error {
 	return i.WaitUntilInstanceProfileExistsErr
 }
 
 type MockSsmClient struct {
 	ssmiface.SSMAPI
-	parameterMap map[string]string
+	parameterMap       map[string]string
+	PutParameterInputs []*ssm.PutParameterInput
+	DeletedParameters  []string
+}
+
+func (i *MockSsmClient) PutParameter(input *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	if i.parameterMap == nil {
+		i.parameterMap = make(map[string]string)
+	}
+	i.PutParameterInputs = append(i.PutParameterInputs, input)
+	i.parameterMap[*input.Name] = *input.Value
+	return &ssm.PutParameterOutput{}, nil
+}
+
+func (i *MockSsmClient) DeleteParameter(input *ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error) {
+	i.DeletedParameters = append(i.DeletedParameters, *input.Name)
+	delete(i.parameterMap, *input.Name)
+	return &ssm.DeleteParameterOutput{}, nil
 }
 
 func (i *MockSsmClient) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
@@ -16,25 +16,30 @@ limitations under the License.
 package eks
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math"
+	"net/url"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
-	"text/template"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
 	"github.com/keikoproj/instance-manager/controllers/common"
 	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
 	"github.com/keikoproj/instance-manager/controllers/provisioners"
+	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -110,6 +115,1052 @@ func (ctx *EksInstanceGroupContext) ResolveSecurityGroups() []string {
 	return resolved
 }
 
+// ResolveNetworkInterfaces resolves the subnets and security groups of additional network interfaces to IDs, and makes
+// sure each subnet belongs to the cluster VPC and to the availability zone of the instance group's subnets, as the
+// interfaces are pinned to their subnet. Interfaces without security groups use the node security groups.
+func (ctx *EksInstanceGroupContext) ResolveNetworkInterfaces() ([]scaling.NetworkInterfaceInput, error) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		vpcId         = state.GetVPCId()
+		subnets       = ctx.ResolveSubnets()
+		resolved      = make([]scaling.NetworkInterfaceInput, 0)
+	)
+
+	for _, n := range configuration.GetNetworkInterfaces() {
+		var subnetId string
+		if strings.HasPrefix(n.Subnet, "subnet-") {
+			subnets, err := ctx.AwsWorker.DescribeSubnets([]string{n.Subnet})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to describe subnet %v", n.Subnet)
+			}
+			for _, sn := range subnets {
+				if aws.StringValue(sn.SubnetId) != n.Subnet {
+					continue
+				}
+				if aws.StringValue(sn.VpcId) != vpcId {
+					return nil, errors.Errorf("subnet %v of network interface %v is not in cluster vpc %v", n.Subnet, n.DeviceIndex, vpcId)
+				}
+				subnetId = n.Subnet
+			}
+		} else {
+			sn, err := ctx.AwsWorker.SubnetByName(n.Subnet, vpcId)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve subnet id by name %v", n.Subnet)
+			}
+			if sn != nil {
+				subnetId = aws.StringValue(sn.SubnetId)
+			}
+		}
+		if common.StringEmpty(subnetId) {
+			return nil, errors.Errorf("subnet %v of network interface %v not found in cluster vpc %v", n.Subnet, n.DeviceIndex, vpcId)
+		}
+
+		// instances launched in another availability zone than the interface's subnet would fail to launch
+		zones, err := ctx.AwsWorker.SubnetZones(append([]string{subnetId}, subnets...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to discover availability zone of subnet %v", subnetId)
+		}
+		zone := zones[subnetId]
+		for _, s := range subnets {
+			if zones[s] != zone {
+				return nil, errors.Errorf("subnet %v of network interface %v is in availability zone %v, network interfaces require all subnets of the instance group to be in the same availability zone, subnet %v is in %v", n.Subnet, n.DeviceIndex, zone, s, zones[s])
+			}
+		}
+
+		sgs := make([]string, 0)
+		for _, g := range n.SecurityGroups {
+			if strings.HasPrefix(g, "sg-") {
+				sgs = append(sgs, g)
+				continue
+			}
+			sg, err := ctx.AwsWorker.SecurityGroupByName(g, vpcId)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve security group by name %v", g)
+			}
+			if sg == nil {
+				return nil, errors.Errorf("security group %v of network interface %v not found in cluster vpc %v", g, n.DeviceIndex, vpcId)
+			}
+			sgs = append(sgs, aws.StringValue(sg.GroupId))
+		}
+		if len(sgs) == 0 {
+			sgs = ctx.ResolveSecurityGroups()
+		}
+		sort.Strings(sgs)
+
+		resolved = append(resolved, scaling.NetworkInterfaceInput{
+			DeviceIndex:              n.DeviceIndex,
+			SubnetId:                 subnetId,
+			SecurityGroups:           sgs,
+			AssociatePublicIpAddress: n.AssociatePublicIpAddress,
+			InterfaceType:            n.InterfaceType,
+		})
+	}
+
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].DeviceIndex < resolved[j].DeviceIndex
+	})
+	return resolved, nil
+}
+
+// ValidateNetworkInterfaces makes sure EFA interfaces are only requested for instance types with EFA support, and that
+// each EFA interface has a security group which allows all traffic from and to itself as EFA requires
+func (ctx *EksInstanceGroupContext) ValidateNetworkInterfaces(interfaces []scaling.NetworkInterfaceInput) error {
+	var (
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		instanceTypes = []string{configuration.InstanceType}
+		efaInterfaces = make([]scaling.NetworkInterfaceInput, 0)
+	)
+
+	for _, n := range interfaces {
+		if n.InterfaceType == v1alpha1.NetworkInterfaceTypeEFA {
+			efaInterfaces = append(efaInterfaces, n)
+		}
+	}
+	if len(efaInterfaces) == 0 {
+		return nil
+	}
+
+	for _, override := range ctx.GetOverrides() {
+		instanceTypes = append(instanceTypes, aws.StringValue(override.InstanceType))
+	}
+	for _, instanceType := range instanceTypes {
+		networkInfo := awsprovider.GetInstanceTypeNetworkInfo(state.GetInstanceTypeInfo(), instanceType)
+		if networkInfo == nil {
+			return errors.Errorf("failed to get network info of instance type %v", instanceType)
+		}
+		if !aws.BoolValue(networkInfo.EfaSupported) {
+			return errors.Errorf("instance type %v does not support EFA network interfaces", instanceType)
+		}
+		if efaInfo := networkInfo.EfaInfo; efaInfo != nil && int64(len(efaInterfaces)) > aws.Int64Value(efaInfo.MaximumEfaInterfaces) {
+			return errors.Errorf("instance type %v supports %v EFA network interfaces, got %v", instanceType, aws.Int64Value(efaInfo.MaximumEfaInterfaces), len(efaInterfaces))
+		}
+	}
+
+	for _, n := range efaInterfaces {
+		groups, err := ctx.AwsWorker.DescribeSecurityGroups(n.SecurityGroups)
+		if err != nil {
+			return errors.Wrapf(err, "failed to describe security groups of network interface %v", n.DeviceIndex)
+		}
+		var selfReferencing bool
+		for _, g := range groups {
+			if awsprovider.IsSelfReferencingSecurityGroup(g) {
+				selfReferencing = true
+				break
+			}
+		}
+		if !selfReferencing {
+			return errors.Errorf("security groups %v of EFA network interface %v must include a group which allows all inbound and outbound traffic from and to itself", n.SecurityGroups, n.DeviceIndex)
+		}
+	}
+	return nil
+}
+
+// WarnPublicIpPrivateSubnets publishes a warning when public IPs are requested for nodes in private subnets, without a
+// route to an internet gateway the public IP cannot be used
+func (ctx *EksInstanceGroupContext) WarnPublicIpPrivateSubnets() {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+	)
+
+	if !configuration.IsPublicIpAddressAssociated() {
+		return
+	}
+
+	private, err := ctx.AwsWorker.PrivateSubnets(ctx.ResolveSubnets(), state.GetVPCId())
+	if err != nil {
+		ctx.Log.Error(err, "failed to determine private subnets", "instancegroup", instanceGroup.NamespacedName())
+		return
+	}
+	if len(private) == 0 {
+		return
+	}
+
+	ctx.Log.Info("public IPs requested for nodes in private subnets", "instancegroup", instanceGroup.NamespacedName(), "subnets", private)
+	state.Publisher.Publish(kubeprovider.PublicIpPrivateSubnetEvent, "instancegroup", instanceGroup.NamespacedName(), "subnets", strings.Join(private, ","))
+}
+
+// GetCustomTags returns the custom tags of the instance group which are propagated to its AWS resources, tags using the
+// reserved aws: prefix are left out
+func (ctx *EksInstanceGroupContext) GetCustomTags() []map[string]string {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+	)
+
+	tags, _ := provisioners.FilterReservedTags(configuration.GetTags())
+	return tags
+}
+
+// WarnReservedTags publishes a warning when custom tags use the reserved aws: prefix, these tags are not propagated since
+// tagging APIs reject them
+func (ctx *EksInstanceGroupContext) WarnReservedTags() {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+	)
+
+	_, reserved := provisioners.FilterReservedTags(configuration.GetTags())
+	if len(reserved) == 0 {
+		return
+	}
+
+	ctx.Log.Info("custom tags use the reserved prefix and are not propagated", "instancegroup", instanceGroup.NamespacedName(), "prefix", awsprovider.ReservedTagPrefix, "tags", reserved)
+	state.Publisher.Publish(kubeprovider.ReservedTagsIgnoredEvent, "instancegroup", instanceGroup.NamespacedName(), "tags", strings.Join(reserved, ","))
+}
+
+// GetIAMTags returns the custom tags which are propagated to the managed role and instance profile, tags which do not meet
+// the constraints of IAM tags are skipped with a warning rather than failing the reconcile of existing instance groups
+func (ctx *EksInstanceGroupContext) GetIAMTags() map[string]string {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		state         = ctx.GetDiscoveredState()
+		tags          = make(map[string]string)
+		keys          = make([]string, 0)
+		skipped       = make([]string, 0)
+	)
+
+	for _, tag := range ctx.GetCustomTags() {
+		key, value := tag["key"], tag["value"]
+		if err := awsprovider.ValidateIAMTag(key, value); err != nil || common.ContainsEqualFold(keys, key) || len(keys) >= awsprovider.IAMMaxTags {
+			skipped = append(skipped, key)
+			continue
+		}
+		keys = append(keys, key)
+		tags[key] = value
+	}
+
+	if len(skipped) > 0 {
+		ctx.Log.Info("custom tags do not meet iam tag constraints and are not propagated", "instancegroup", instanceGroup.NamespacedName(), "tags", skipped)
+		state.Publisher.Publish(kubeprovider.IAMTagsIgnoredEvent, "instancegroup", instanceGroup.NamespacedName(), "tags", strings.Join(skipped, ","))
+	}
+	return tags
+}
+
+// IsInstanceProfilePropagating returns true if a launch was rejected for an invalid instance profile which was created
+// within the propagation timeout, and is likely not yet visible to EC2. Profiles which do not exist or were created before
+// the timeout are reported as errors
+func (ctx *EksInstanceGroupContext) IsInstanceProfilePropagating(err error) bool {
+	var (
+		profile = ctx.GetDiscoveredState().GetInstanceProfile()
+		timeout = ctx.InstanceProfilePropagationTimeout
+	)
+
+	if !awsprovider.IsInvalidInstanceProfileErr(err) || profile.CreateDate == nil {
+		return false
+	}
+
+	if timeout == 0 {
+		timeout = awsprovider.DefaultInstanceProfilePropagationTimeout
+	}
+	return time.Since(aws.TimeValue(profile.CreateDate)) < timeout
+}
+
+// ValidateUserData submits the userdata to the validation hook when one is configured, a rejected userdata is reflected in
+// the UserDataValidationFailed condition
+func (ctx *EksInstanceGroupContext) ValidateUserData(userData string) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+	)
+
+	if ctx.UserDataValidator == nil {
+		return nil
+	}
+
+	if err := ctx.UserDataValidator.Validate(instanceGroup.GetNamespace(), instanceGroup.GetName(), userData); err != nil {
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.UserDataValidationFailed, corev1.ConditionTrue))
+		return err
+	}
+
+	if status.GetUserDataValidationFailedCondition() == corev1.ConditionTrue {
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.UserDataValidationFailed, corev1.ConditionFalse))
+	}
+	return nil
+}
+
+// ValidateImageAllowlist rejects resolved images which do not match the image allowlist, a rejected image is reflected
+// in the ImageNotAllowed condition. Images resolved from latest or an SSM parameter are validated after resolution
+func (ctx *EksInstanceGroupContext) ValidateImageAllowlist() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		status        = instanceGroup.GetStatus()
+		allowlist     = configuration.GetImageAllowlist()
+		imageId       = configuration.Image
+	)
+
+	if allowlist == nil {
+		if status.GetImageNotAllowedCondition() == corev1.ConditionTrue {
+			status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.ImageNotAllowed, corev1.ConditionFalse))
+		}
+		return nil
+	}
+
+	allowed := allowlist.IsAllowed(imageId, "", "", "")
+	if !allowed && (len(allowlist.Owners) > 0 || len(allowlist.NamePatterns) > 0) {
+		image, err := ctx.AwsWorker.DescribeImage(imageId)
+		if err != nil {
+			return errors.Wrapf(err, "failed to describe image %v", imageId)
+		}
+		if image != nil {
+			allowed = allowlist.IsAllowed(imageId, aws.StringValue(image.OwnerId), aws.StringValue(image.ImageOwnerAlias), aws.StringValue(image.Name))
+		}
+	}
+
+	if !allowed {
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.ImageNotAllowed, corev1.ConditionTrue))
+		return errors.Errorf("image %v does not match the image allowlist", imageId)
+	}
+
+	if status.GetImageNotAllowedCondition() == corev1.ConditionTrue {
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.ImageNotAllowed, corev1.ConditionFalse))
+	}
+	return nil
+}
+
+// ExportUserData records the hash of the rendered userdata in the instance group's status and, when the userdata debug
+// endpoint is enabled, exports the decoded userdata for inspection with sensitive values redacted
+func (ctx *EksInstanceGroupContext) ExportUserData(userData string) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		status        = instanceGroup.GetStatus()
+	)
+
+	decoded, err := base64.StdEncoding.DecodeString(userData)
+	if err != nil {
+		ctx.Log.Error(err, "failed to decode rendered userdata", "instancegroup", instanceGroup.NamespacedName())
+		return
+	}
+
+	status.SetUserDataHash(provisioners.UserDataHash(string(decoded)))
+	if ctx.UserDataExporter != nil {
+		ctx.UserDataExporter.Export(instanceGroup.GetNamespace(), instanceGroup.GetName(), string(decoded), configuration.GetUserDataVariables())
+	}
+}
+
+// GetRegistryCredentialsParameter returns the name of the SSM parameter nodes retrieve registry credentials from, or an
+// empty string if no registry credentials are referenced
+func (ctx *EksInstanceGroupContext) GetRegistryCredentialsParameter() string {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+	)
+
+	if configuration.GetRegistryCredentials() == nil {
+		return ""
+	}
+	return fmt.Sprintf(RegistryCredentialsParameterFmt, configuration.GetClusterName(), instanceGroup.GetNamespace(), instanceGroup.GetName())
+}
+
+// SyncRegistryCredentials copies the referenced registry credentials secret into an encrypted SSM parameter, keeping the
+// credentials out of the userdata, a previously created parameter is deleted once it is no longer referenced
+func (ctx *EksInstanceGroupContext) SyncRegistryCredentials() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		status        = instanceGroup.GetStatus()
+		credentials   = configuration.GetRegistryCredentials()
+		parameter     = ctx.GetRegistryCredentialsParameter()
+		previous      = status.GetRegistryCredentialsParameter()
+	)
+
+	if previous != "" && previous != parameter {
+		if err := ctx.AwsWorker.DeleteParameter(previous); err != nil {
+			return errors.Wrap(err, "failed to delete registry credentials parameter")
+		}
+		ctx.Log.Info("deleted registry credentials parameter", "instancegroup", instanceGroup.NamespacedName(), "parameter", previous)
+		status.SetRegistryCredentialsParameter("")
+	}
+
+	if credentials == nil {
+		return nil
+	}
+
+	if osFamily := ctx.GetOsFamily(); !strings.EqualFold(osFamily, OsFamilyAmazonLinux2) && !strings.EqualFold(osFamily, OsFamilyAmazonLinux2023) {
+		return errors.Errorf("registry credentials are not supported for os family %v", osFamily)
+	}
+
+	secret, err := ctx.KubernetesClient.Kubernetes.CoreV1().Secrets(instanceGroup.GetNamespace()).Get(context.Background(), credentials.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get registry credentials secret %v", credentials.SecretName)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		return errors.Errorf("registry credentials secret %v must be of type %v, got %v", credentials.SecretName, corev1.SecretTypeDockerConfigJson, secret.Type)
+	}
+	value, ok := secret.Data[corev1.DockerConfigJsonKey]
+	if !ok || len(value) == 0 {
+		return errors.Errorf("registry credentials secret %v is missing key %v", credentials.SecretName, corev1.DockerConfigJsonKey)
+	}
+
+	current, err := ctx.AwsWorker.GetSecureParameter(parameter)
+	if err != nil {
+		return errors.Wrap(err, "failed to get registry credentials parameter")
+	}
+	if current == string(value) {
+		status.SetRegistryCredentialsParameter(parameter)
+		return nil
+	}
+
+	if err := ctx.AwsWorker.PutSecureParameter(parameter, string(value)); err != nil {
+		return errors.Wrap(err, "failed to put registry credentials parameter")
+	}
+	status.SetRegistryCredentialsParameter(parameter)
+	ctx.Log.Info("updated registry credentials parameter", "instancegroup", instanceGroup.NamespacedName(), "parameter", parameter, "secret", credentials.SecretName)
+	return nil
+}
+
+// ValidateWindowsContainerd rejects windows containerd configuration for instance groups which do not run Windows
+func (ctx *EksInstanceGroupContext) ValidateWindowsContainerd() error {
+	configuration := ctx.GetInstanceGroup().GetEKSConfiguration()
+	if configuration.GetWindowsContainerd() == nil {
+		return nil
+	}
+	if osFamily := ctx.GetOsFamily(); !strings.EqualFold(osFamily, OsFamilyWindows) {
+		return errors.Errorf("windows containerd configuration is not supported for os family %v", osFamily)
+	}
+	return nil
+}
+
+// ValidatePrePullImages rejects pre-pulled images for instance groups which do not run Amazon Linux
+func (ctx *EksInstanceGroupContext) ValidatePrePullImages() error {
+	configuration := ctx.GetInstanceGroup().GetEKSConfiguration()
+	if len(configuration.GetPrePullImages()) == 0 {
+		return nil
+	}
+	if osFamily := ctx.GetOsFamily(); !strings.EqualFold(osFamily, OsFamilyAmazonLinux2) && !strings.EqualFold(osFamily, OsFamilyAmazonLinux2023) {
+		return errors.Errorf("pre-pulled images are not supported for os family %v", osFamily)
+	}
+	return nil
+}
+
+// GetPrePullImages returns the images nodes pull at bootstrap, along with the region of images hosted in ECR
+func (ctx *EksInstanceGroupContext) GetPrePullImages() []PrePullImage {
+	configuration := ctx.GetInstanceGroup().GetEKSConfiguration()
+
+	images := make([]PrePullImage, 0)
+	for _, image := range configuration.GetPrePullImages() {
+		prePull := PrePullImage{Image: image}
+		if match := ECRImageRegex.FindStringSubmatch(image); match != nil {
+			prePull.ECRRegion = match[2]
+		}
+		images = append(images, prePull)
+	}
+	return images
+}
+
+// ValidateBootstrapRetries rejects bootstrap retries for instance groups which do not run Amazon Linux or Windows
+func (ctx *EksInstanceGroupContext) ValidateBootstrapRetries() error {
+	configuration := ctx.GetInstanceGroup().GetEKSConfiguration()
+	if configuration.GetBootstrapRetries() == nil {
+		return nil
+	}
+	if osFamily := ctx.GetOsFamily(); strings.EqualFold(osFamily, OsFamilyBottleRocket) {
+		return errors.Errorf("bootstrap retries are not supported for os family %v", osFamily)
+	}
+	return nil
+}
+
+// GetBootstrapRetries returns how often nodes attempt the network operations of the bootstrap, or nil if they are not
+// retried
+func (ctx *EksInstanceGroupContext) GetBootstrapRetries() *BootstrapRetriesOpts {
+	retries := ctx.GetInstanceGroup().GetEKSConfiguration().GetBootstrapRetries()
+	if retries == nil {
+		return nil
+	}
+	return &BootstrapRetriesOpts{
+		Attempts:       retries.Attempts,
+		BackoffSeconds: int64(retries.GetBackoff().Seconds()),
+	}
+}
+
+// GetProxy returns the proxies nodes bootstrap with, or nil if they reach the internet directly. Nodes reach the
+// metadata endpoint, the cluster's API endpoint and its VPC and service CIDRs directly, as well as any noProxy entries.
+func (ctx *EksInstanceGroupContext) GetProxy() *ProxyOpts {
+	var (
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		proxy         = configuration.GetProxy()
+		state         = ctx.GetDiscoveredState()
+		cluster       = state.GetCluster()
+	)
+
+	if proxy == nil {
+		return nil
+	}
+
+	var (
+		noProxy = make([]string, 0)
+		seen    = make(map[string]bool)
+	)
+	add := func(hosts ...string) {
+		for _, host := range hosts {
+			if common.StringEmpty(host) || seen[host] {
+				continue
+			}
+			seen[host] = true
+			noProxy = append(noProxy, host)
+		}
+	}
+
+	add(DefaultNoProxy...)
+	if endpoint, err := url.Parse(state.GetClusterEndpoint()); err == nil {
+		add(endpoint.Hostname())
+	}
+	add(state.GetVPCCidrs()...)
+	if cluster != nil && cluster.KubernetesNetworkConfig != nil {
+		add(aws.StringValue(cluster.KubernetesNetworkConfig.ServiceIpv4Cidr), aws.StringValue(cluster.KubernetesNetworkConfig.ServiceIpv6Cidr))
+	}
+	add(proxy.NoProxy...)
+
+	return &ProxyOpts{
+		HTTPProxy:  proxy.HTTPProxy,
+		HTTPSProxy: proxy.HTTPSProxy,
+		NoProxy:    noProxy,
+	}
+}
+
+// GetCABundle returns the certificates nodes trust in addition to the system trust store, or nil if no CA bundle is
+// configured
+func (ctx *EksInstanceGroupContext) GetCABundle() *CABundleOpts {
+	var (
+		state  = ctx.GetDiscoveredState()
+		bundle = state.GetCABundle()
+	)
+
+	if common.StringEmpty(bundle) {
+		return nil
+	}
+
+	certificates, err := common.ParseCertificates(bundle)
+	if err != nil {
+		ctx.Log.Error(err, "failed to parse ca bundle", "instancegroup", ctx.GetInstanceGroup().NamespacedName())
+		return nil
+	}
+
+	var (
+		encoded = make([]string, 0)
+		blocks  = make([]string, 0)
+	)
+	for _, certificate := range certificates {
+		encoded = append(encoded, base64.StdEncoding.EncodeToString(certificate.Raw))
+		blocks = append(blocks, strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))))
+	}
+
+	return &CABundleOpts{
+		PEM:          strings.Join(blocks, "\n"),
+		Certificates: encoded,
+	}
+}
+
+// ValidateInstanceStorage rejects instance storage for instance groups which do not run Amazon Linux
+func (ctx *EksInstanceGroupContext) ValidateInstanceStorage() error {
+	configuration := ctx.GetInstanceGroup().GetEKSConfiguration()
+	if configuration.GetInstanceStorage() == nil {
+		return nil
+	}
+	if osFamily := ctx.GetOsFamily(); !strings.EqualFold(osFamily, OsFamilyAmazonLinux2) && !strings.EqualFold(osFamily, OsFamilyAmazonLinux2023) {
+		return errors.Errorf("instance storage is not supported for os family %v", osFamily)
+	}
+	return nil
+}
+
+// ValidateImagePulls rejects image pull settings for instance groups which run Bottlerocket or Windows, their
+// settings and bootstrap do not configure the kubelet's image pulls
+func (ctx *EksInstanceGroupContext) ValidateImagePulls() error {
+	options := ctx.GetInstanceGroup().GetEKSConfiguration().GetBootstrapOptions()
+	if options == nil || (options.SerializeImagePulls == nil && options.MaxParallelImagePulls == nil) {
+		return nil
+	}
+	if osFamily := ctx.GetOsFamily(); strings.EqualFold(osFamily, OsFamilyBottleRocket) || strings.EqualFold(osFamily, OsFamilyWindows) {
+		return errors.Errorf("image pull settings are not supported for os family %v", osFamily)
+	}
+	return nil
+}
+
+// ValidateShutdownGracePeriod rejects graceful node shutdown settings for instance groups which run Windows, its
+// kubelet does not delay node shutdown to terminate pods
+func (ctx *EksInstanceGroupContext) ValidateShutdownGracePeriod() error {
+	options := ctx.GetInstanceGroup().GetEKSConfiguration().GetBootstrapOptions()
+	if options == nil || (common.StringEmpty(options.ShutdownGracePeriod) && common.StringEmpty(options.ShutdownGracePeriodCriticalPods)) {
+		return nil
+	}
+	if osFamily := ctx.GetOsFamily(); strings.EqualFold(osFamily, OsFamilyWindows) {
+		return errors.Errorf("shutdown grace period is not supported for os family %v", osFamily)
+	}
+	return nil
+}
+
+// ValidateKubeletConfiguration rejects kubelet configuration fragments for instance groups which do not run AL2 or
+// AL2023, whose kubelet config cannot be merged with a fragment
+func (ctx *EksInstanceGroupContext) ValidateKubeletConfiguration() error {
+	options := ctx.GetInstanceGroup().GetEKSConfiguration().GetBootstrapOptions()
+	if options == nil || options.KubeletConfiguration == nil {
+		return nil
+	}
+	if osFamily := ctx.GetOsFamily(); !strings.EqualFold(osFamily, OsFamilyAmazonLinux2) && !strings.EqualFold(osFamily, OsFamilyAmazonLinux2023) {
+		return errors.Errorf("kubelet configuration is not supported for os family %v", osFamily)
+	}
+	return nil
+}
+
+// GetNodeKubernetesVersion returns the Kubernetes version of the instance group's nodes, an explicit kubeletVersion
+// takes precedence over the version in the name of the image. An empty string is returned if the version is unknown
+func (ctx *EksInstanceGroupContext) GetNodeKubernetesVersion() (string, error) {
+	configuration := ctx.GetInstanceGroup().GetEKSConfiguration()
+	if version := configuration.GetKubeletVersion(); !common.StringEmpty(version) {
+		return version, nil
+	}
+
+	image, err := ctx.AwsWorker.DescribeImage(configuration.Image)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to describe image %v", configuration.Image)
+	}
+	// only the names of EKS optimized images published by Amazon are known to carry the Kubernetes version
+	if image == nil || aws.StringValue(image.ImageOwnerAlias) != ImageOwnerAliasAmazon {
+		return "", nil
+	}
+	if match := ImageKubernetesVersionRegex.FindStringSubmatch(aws.StringValue(image.Name)); match != nil {
+		return match[1], nil
+	}
+	return "", nil
+}
+
+// MaxKubeletVersionSkew returns the number of minor versions nodes may be older than a control plane of the given minor
+// version, the supported skew was raised from 2 to 3 minor versions in Kubernetes 1.28
+func MaxKubeletVersionSkew(clusterMinor int64) int64 {
+	if clusterMinor >= 28 {
+		return 3
+	}
+	return 2
+}
+
+// ValidateVersionSkew rejects nodes whose Kubernetes version is newer than the cluster's control plane, or more minor
+// versions older than the supported version skew. Nodes whose version is unknown, or cannot be looked up, are not
+// validated
+func (ctx *EksInstanceGroupContext) ValidateVersionSkew() error {
+	var (
+		instanceGroup  = ctx.GetInstanceGroup()
+		clusterVersion = ctx.GetDiscoveredState().GetClusterVersion()
+	)
+
+	if common.StringEmpty(clusterVersion) {
+		return nil
+	}
+	nodeVersion, err := ctx.GetNodeKubernetesVersion()
+	if err != nil {
+		ctx.Log.Info("failed to determine node kubernetes version, skipping version skew validation", "error", err, "instancegroup", instanceGroup.NamespacedName())
+		return nil
+	}
+	if common.StringEmpty(nodeVersion) {
+		ctx.Log.Info("node kubernetes version is unknown, skipping version skew validation", "instancegroup", instanceGroup.NamespacedName(), "image", instanceGroup.GetEKSConfiguration().Image)
+		return nil
+	}
+
+	cluster, err := semver.NewVersion(clusterVersion)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse cluster version %v", clusterVersion)
+	}
+	node, err := semver.NewVersion(nodeVersion)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse node version %v", nodeVersion)
+	}
+
+	if node.Major() != cluster.Major() || node.Minor() > cluster.Minor() {
+		return errors.Errorf("node kubernetes version %v is newer than the cluster version %v", nodeVersion, clusterVersion)
+	}
+	if skew := MaxKubeletVersionSkew(cluster.Minor()); cluster.Minor()-node.Minor() > skew {
+		return errors.Errorf("node kubernetes version %v is more than %v minor versions older than the cluster version %v", nodeVersion, skew, clusterVersion)
+	}
+	return nil
+}
+
+// ValidateAuthMappings rejects authMappings of IAM roles and users of accounts other than the cluster's account, unless
+// cross account auth mappings are allowed. Mappings are not validated when the cluster's account is unknown
+func (ctx *EksInstanceGroupContext) ValidateAuthMappings() error {
+	var (
+		mappings = ctx.GetInstanceGroup().GetEKSConfiguration().GetAuthMappings()
+		cluster  = ctx.GetDiscoveredState().GetCluster()
+	)
+
+	if mappings == nil || ctx.CrossAccountAuthMappings || cluster == nil {
+		return nil
+	}
+	clusterArn, err := arn.Parse(aws.StringValue(cluster.Arn))
+	if err != nil {
+		return nil
+	}
+
+	validate := func(field string, mappings []v1alpha1.AuthMapping) error {
+		for _, mapping := range mappings {
+			parsed, err := arn.Parse(mapping.ARN)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse arn %v", mapping.ARN)
+			}
+			if parsed.AccountID != clusterArn.AccountID {
+				return errors.Errorf("'authMappings.%v' entry '%v' is not of the cluster's account %v, cross account auth mappings are not allowed", field, mapping.ARN, clusterArn.AccountID)
+			}
+		}
+		return nil
+	}
+
+	if err := validate("roles", mappings.Roles); err != nil {
+		return err
+	}
+	return validate("users", mappings.Users)
+}
+
+// ValidateSysctls rejects sysctls and allowed unsafe sysctls for Windows instance groups, which have no kernel
+// parameters to apply them to
+func (ctx *EksInstanceGroupContext) ValidateSysctls() error {
+	var (
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		osFamily      = ctx.GetOsFamily()
+	)
+
+	if !strings.EqualFold(osFamily, OsFamilyWindows) {
+		return nil
+	}
+	if len(configuration.GetSysctls()) > 0 {
+		return errors.Errorf("sysctls are not supported for os family %v", osFamily)
+	}
+	if options := configuration.GetBootstrapOptions(); options != nil && len(options.AllowedUnsafeSysctls) > 0 {
+		return errors.Errorf("allowed unsafe sysctls are not supported for os family %v", osFamily)
+	}
+	return nil
+}
+
+// GetKubeletConfig returns the fields of the kubelet configuration fragment with their JSON encoded values, fields
+// managed by instance-manager or the node bootstrap are left out
+func (ctx *EksInstanceGroupContext) GetKubeletConfig() map[string]string {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		options       = instanceGroup.GetEKSConfiguration().GetBootstrapOptions()
+	)
+
+	fragment, err := options.GetKubeletConfiguration()
+	if err != nil {
+		ctx.Log.Error(err, "failed to parse kubelet configuration", "instancegroup", instanceGroup.NamespacedName())
+		return nil
+	}
+	if len(fragment) == 0 {
+		return nil
+	}
+
+	config := make(map[string]string)
+	for key, value := range fragment {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			ctx.Log.Error(err, "failed to encode kubelet configuration", "instancegroup", instanceGroup.NamespacedName(), "field", key)
+			continue
+		}
+		config[key] = string(encoded)
+	}
+	return config
+}
+
+// GetInstanceStorage returns where nodes mount their instance store volumes, or nil if container storage stays on EBS
+// because instance storage is not configured, the policy is ebs-only or none of the instance types have instance store
+func (ctx *EksInstanceGroupContext) GetInstanceStorage() *InstanceStorageOpts {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		storage       = instanceGroup.GetEKSConfiguration().GetInstanceStorage()
+	)
+
+	if storage == nil || storage.GetPolicy() == v1alpha1.InstanceStoragePolicyEBSOnly {
+		return nil
+	}
+	if !ctx.HasInstanceStoreTypes() {
+		ctx.Log.V(1).Info("instance types have no instance store, container storage falls back to EBS", "instancegroup", instanceGroup.NamespacedName())
+		return nil
+	}
+	return &InstanceStorageOpts{
+		MountPath:  storage.GetMountPath(),
+		FileSystem: storage.GetFileSystem(),
+	}
+}
+
+// HasInstanceStoreTypes returns true if any of the instance group's instance types has instance store volumes, instance
+// types which were not discovered are assumed to have them since nodes skip the instance storage setup without them
+func (ctx *EksInstanceGroupContext) HasInstanceStoreTypes() bool {
+	var (
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		instanceTypes = []string{configuration.InstanceType}
+	)
+
+	for _, override := range ctx.GetOverrides() {
+		instanceTypes = append(instanceTypes, aws.StringValue(override.InstanceType))
+	}
+
+	for _, instanceType := range instanceTypes {
+		info := awsprovider.GetInstanceTypeInfo(state.GetInstanceTypeInfo(), instanceType)
+		if info == nil || aws.BoolValue(info.InstanceStorageSupported) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPinnedLaunchTemplateVersion returns the launch template version the instance group is rolled back to with the pin
+// annotation, pins are only honored for launch templates
+func (ctx *EksInstanceGroupContext) GetPinnedLaunchTemplateVersion() (int64, bool) {
+	instanceGroup := ctx.GetInstanceGroup()
+	if !instanceGroup.GetEKSSpec().IsLaunchTemplate() {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(instanceGroup.GetAnnotations()[LaunchTemplateVersionPinAnnotation], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// GetDesiredLaunchTemplateVersion returns the launch template version the scaling group should launch instances with,
+// the pinned version during a rollback and the latest version otherwise
+func (ctx *EksInstanceGroupContext) GetDesiredLaunchTemplateVersion() string {
+	if version, ok := ctx.GetPinnedLaunchTemplateVersion(); ok {
+		return common.Int64ToStr(version)
+	}
+	return awsprovider.LaunchTemplateLatestVersionKey
+}
+
+// GetActiveLaunchTemplateVersion returns the launch template version instances are expected to run
+func (ctx *EksInstanceGroupContext) GetActiveLaunchTemplateVersion(template *ec2.LaunchTemplate) string {
+	if version, ok := ctx.GetPinnedLaunchTemplateVersion(); ok {
+		return common.Int64ToStr(version)
+	}
+	return common.Int64ToStr(aws.Int64Value(template.LatestVersionNumber))
+}
+
+// ValidateLaunchTemplateVersionPin rejects pins to launch template versions which do not exist, such as versions
+// which were pruned by the version retention
+func (ctx *EksInstanceGroupContext) ValidateLaunchTemplateVersionPin() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		state         = ctx.GetDiscoveredState()
+	)
+
+	val, ok := instanceGroup.GetAnnotations()[LaunchTemplateVersionPinAnnotation]
+	if !ok {
+		return nil
+	}
+	if !instanceGroup.GetEKSSpec().IsLaunchTemplate() {
+		return errors.Errorf("annotation %v is only supported for %v", LaunchTemplateVersionPinAnnotation, v1alpha1.LaunchTemplate)
+	}
+
+	version, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || version <= 0 {
+		return errors.Errorf("annotation %v must be a launch template version number, got '%v'", LaunchTemplateVersionPinAnnotation, val)
+	}
+
+	template, ok := state.GetScalingConfiguration().(*scaling.LaunchTemplate)
+	if !ok || !template.Provisioned() {
+		return errors.Errorf("cannot pin launch template version %v, launch template is not provisioned", version)
+	}
+	if template.GetVersion(version) == nil {
+		return errors.Errorf("cannot pin launch template version %v of %v, the version does not exist or was pruned", version, template.Name())
+	}
+	return nil
+}
+
+// UpdateScalingConfigurationStatus records the launch template version and the image the scaling group launches
+// instances with, which is the latest version of its launch template unless a version is pinned
+func (ctx *EksInstanceGroupContext) UpdateScalingConfigurationStatus() {
+	var (
+		status = ctx.GetInstanceGroup().GetStatus()
+		state  = ctx.GetDiscoveredState()
+	)
+
+	switch config := state.GetScalingConfiguration().(type) {
+	case *scaling.LaunchTemplate:
+		if config.TargetResource != nil {
+			status.SetActiveLaunchTemplateID(aws.StringValue(config.TargetResource.LaunchTemplateId))
+		}
+		activeVersion := config.LatestVersion
+		if pinned, ok := ctx.GetPinnedLaunchTemplateVersion(); ok {
+			activeVersion = config.GetVersion(pinned)
+		}
+		if activeVersion != nil {
+			status.SetActiveLaunchTemplateVersion(common.Int64ToStr(aws.Int64Value(activeVersion.VersionNumber)))
+			if data := activeVersion.LaunchTemplateData; data != nil {
+				status.SetActiveImageID(aws.StringValue(data.ImageId))
+			}
+		}
+	case *scaling.LaunchConfiguration:
+		if config.TargetResource != nil {
+			status.SetActiveImageID(aws.StringValue(config.TargetResource.ImageId))
+		}
+	}
+}
+
+// UpdateCostEstimateStatus estimates the monthly cost of the scaling group's desired capacity from the pricing table,
+// capacity of a mixed instances policy is assumed to be spread evenly across its instance types
+func (ctx *EksInstanceGroupContext) UpdateCostEstimateStatus() {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		mixedPolicy   = configuration.GetMixedInstancesPolicy()
+		status        = instanceGroup.GetStatus()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		pricing       = ctx.PricingTable
+	)
+
+	if scalingGroup == nil {
+		status.SetCostEstimate(nil)
+		return
+	}
+	if pricing == nil {
+		pricing = awsprovider.DefaultPricingTable
+	}
+
+	var (
+		capacity = aws.Int64Value(scalingGroup.DesiredCapacity)
+		onDemand = capacity
+		spot     int64
+		weights  = make(map[string]int64)
+	)
+
+	if mixedPolicy != nil {
+		for _, override := range ctx.GetOverrides() {
+			weight, err := strconv.ParseInt(aws.StringValue(override.WeightedCapacity), 10, 64)
+			if err != nil || weight <= 0 {
+				weight = 1
+			}
+			weights[aws.StringValue(override.InstanceType)] = weight
+		}
+
+		base := common.Int64Value(mixedPolicy.BaseCapacity)
+		if base > capacity {
+			base = capacity
+		}
+		var (
+			aboveBase         = capacity - base
+			onDemandRatio     = float64(100-common.IntOrStrValue(mixedPolicy.SpotRatio)) / 100
+			onDemandAboveBase = int64(math.Ceil(float64(aboveBase) * onDemandRatio))
+		)
+		onDemand = base + onDemandAboveBase
+		spot = aboveBase - onDemandAboveBase
+	} else if !common.StringEmpty(configuration.GetSpotPrice()) {
+		onDemand, spot = 0, capacity
+	}
+
+	if len(weights) == 0 {
+		weights[configuration.InstanceType] = 1
+	}
+
+	var (
+		onDemandUnitPrice float64
+		spotUnitPrice     float64
+		priced            int
+		unpriced          = make([]string, 0)
+	)
+	for instanceType, weight := range weights {
+		price, ok := pricing.Get(instanceType)
+		if !ok {
+			unpriced = append(unpriced, instanceType)
+			continue
+		}
+
+		spotPrice := price.GetSpot()
+		// instances are never charged more than the maximum spot price
+		if maxPrice, err := strconv.ParseFloat(configuration.GetSpotPrice(), 64); err == nil && maxPrice > 0 && maxPrice < spotPrice {
+			spotPrice = maxPrice
+		}
+		onDemandUnitPrice += price.OnDemand / float64(weight)
+		spotUnitPrice += spotPrice / float64(weight)
+		priced++
+	}
+	sort.Strings(unpriced)
+
+	if priced == 0 {
+		ctx.Log.Info("instance types are missing from the pricing table, skipping cost estimate", "instancegroup", instanceGroup.NamespacedName(), "instancetypes", unpriced)
+		status.SetCostEstimate(nil)
+		return
+	}
+
+	hourly := (float64(onDemand)*onDemandUnitPrice + float64(spot)*spotUnitPrice) / float64(priced)
+	estimate := &v1alpha1.CostEstimateStatus{
+		EstimatedMonthlyCost: strconv.FormatFloat(hourly*awsprovider.HoursPerMonth, 'f', 2, 64),
+		Currency:             awsprovider.PricingCurrency,
+		OnDemandCapacity:     onDemand,
+		SpotCapacity:         spot,
+	}
+	if len(unpriced) > 0 {
+		estimate.UnpricedInstanceTypes = unpriced
+	}
+	status.SetCostEstimate(estimate)
+}
+
+// GetDesiredTerminationPolicies returns the termination policies of the scaling group, scaling groups use the Default
+// policy unless others are configured
+func (ctx *EksInstanceGroupContext) GetDesiredTerminationPolicies() []string {
+	configuration := ctx.GetInstanceGroup().GetEKSConfiguration()
+	if policies := configuration.GetTerminationPolicies(); len(policies) > 0 {
+		return policies
+	}
+	return []string{v1alpha1.TerminationPolicyDefault}
+}
+
+// GetDesiredInstanceMaintenancePolicy returns the instance maintenance policy of the scaling group, a policy set on the
+// scaling group which is no longer configured is cleared by setting both percentages to -1
+func (ctx *EksInstanceGroupContext) GetDesiredInstanceMaintenancePolicy(current *autoscaling.InstanceMaintenancePolicy) *autoscaling.InstanceMaintenancePolicy {
+	configuration := ctx.GetInstanceGroup().GetEKSConfiguration()
+	if policy := configuration.GetInstanceMaintenancePolicy(); policy != nil {
+		return &autoscaling.InstanceMaintenancePolicy{
+			MinHealthyPercentage: aws.Int64(policy.MinHealthyPercentage),
+			MaxHealthyPercentage: aws.Int64(policy.MaxHealthyPercentage),
+		}
+	}
+	if isInstanceMaintenancePolicySet(current) {
+		return &autoscaling.InstanceMaintenancePolicy{
+			MinHealthyPercentage: aws.Int64(-1),
+			MaxHealthyPercentage: aws.Int64(-1),
+		}
+	}
+	return nil
+}
+
+// InstanceMaintenancePolicyEqual returns true if the policies are equal, a policy which is cleared is equal to none
+func InstanceMaintenancePolicyEqual(a, b *autoscaling.InstanceMaintenancePolicy) bool {
+	if !isInstanceMaintenancePolicySet(a) || !isInstanceMaintenancePolicySet(b) {
+		return isInstanceMaintenancePolicySet(a) == isInstanceMaintenancePolicySet(b)
+	}
+	return aws.Int64Value(a.MinHealthyPercentage) == aws.Int64Value(b.MinHealthyPercentage) &&
+		aws.Int64Value(a.MaxHealthyPercentage) == aws.Int64Value(b.MaxHealthyPercentage)
+}
+
+func isInstanceMaintenancePolicySet(policy *autoscaling.InstanceMaintenancePolicy) bool {
+	if policy == nil || policy.MinHealthyPercentage == nil || policy.MaxHealthyPercentage == nil {
+		return false
+	}
+	return aws.Int64Value(policy.MinHealthyPercentage) != -1 || aws.Int64Value(policy.MaxHealthyPercentage) != -1
+}
+
+// GetBootstrapClusterName returns the name of the EKS cluster nodes should join, which differs from the
+// spec clusterName when the control plane is resolved from clusterNameSource
+func (ctx *EksInstanceGroupContext) GetBootstrapClusterName() string {
+	var (
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		cluster       = state.GetCluster()
+	)
+
+	if configuration.GetClusterNameSource() != nil && cluster != nil {
+		if name := aws.StringValue(cluster.Name); !common.StringEmpty(name) {
+			return name
+		}
+	}
+	return configuration.GetClusterName()
+}
+
 func (ctx *EksInstanceGroupContext) GetBasicUserData(clusterName, args string, kubeletExtraArgs string, payload UserDataPayload, mounts []MountOpts) string {
 	var (
 		instanceGroup    = ctx.GetInstanceGroup()
@@ -124,133 +1175,30 @@ func (ctx *EksInstanceGroupContext) GetBasicUserData(clusterName, args string, k
 		cluster          = state.GetCluster()
 		clusterIP        = ctx.AwsWorker.GetDNSClusterIP(cluster)
 	)
-	var maxPods int64
+	var (
+		maxPods                                   int64
+		imageGCHighThreshold, imageGCLowThreshold *int64
+		serializeImagePulls                       *bool
+		maxParallelImagePulls                     *int64
+		shutdownGracePeriod, shutdownCritical     string
+		allowedUnsafeSysctls                      []string
+	)
 
 	if bootstrapOptions != nil {
 		maxPods = bootstrapOptions.MaxPods
+		imageGCHighThreshold = bootstrapOptions.ImageGCHighThreshold
+		imageGCLowThreshold = bootstrapOptions.ImageGCLowThreshold
+		serializeImagePulls = bootstrapOptions.SerializeImagePulls
+		// the kubelet only pulls images in parallel when image pulls are not serialized
+		if serializeImagePulls != nil && !*serializeImagePulls {
+			maxParallelImagePulls = bootstrapOptions.MaxParallelImagePulls
+		}
+		shutdownGracePeriod = bootstrapOptions.ShutdownGracePeriod
+		shutdownCritical = bootstrapOptions.ShutdownGracePeriodCriticalPods
+		allowedUnsafeSysctls = bootstrapOptions.AllowedUnsafeSysctls
 	}
-	var UserDataTemplate string
-	switch strings.ToLower(osFamily) {
-	case OsFamilyWindows:
-		UserDataTemplate = `
-<powershell>
-  {{range $pre := .PreBootstrap}}{{$pre}}{{end}}
-  [string]$EKSBinDir = "$env:ProgramFiles\Amazon\EKS"
-  [string]$EKSBootstrapScriptName = 'Start-EKSBootstrap.ps1'
-  [string]$EKSBootstrapScriptFile = "$EKSBinDir\$EKSBootstrapScriptName"
-  [string]$IMDSToken=(curl -UseBasicParsing -Method PUT "http://169.254.169.254/latest/api/token" -H @{ "X-aws-ec2-metadata-token-ttl-seconds" = "21600"} | % { Echo $_.Content})
-  [string]$InstanceID=(curl -UseBasicParsing -Method GET "http://169.254.169.254/latest/meta-data/instance-id" -H @{ "X-aws-ec2-metadata-token" = "$IMDSToken"} | % { Echo $_.Content})
-  [string]$Lifecycle=(curl -UseBasicParsing -Method GET "http://169.254.169.254/latest/meta-data/autoscaling/target-lifecycle-state" -H @{ "X-aws-ec2-metadata-token" = "$IMDSToken"} | % { Echo $_.Content})
-  if ($Lifecycle -like "*Warmed*") {
-    Echo "Not starting Kubelet due to warmed state."
-    & C:\ProgramData\Amazon\EC2-Windows\Launch\Scripts\InitializeInstance.ps1 -Schedule
-  } else {
-    & $EKSBootstrapScriptFile -EKSClusterName {{ .ClusterName }} {{ .Arguments }} 3>&1 4>&1 5>&1 6>&1
-    {{range $post := .PostBootstrap}}{{$post}}{{end}}
-  }
-</powershell>`
-	case OsFamilyBottleRocket:
-		UserDataTemplate = `
-{{range $pre := .PreBootstrap}}{{$pre}}{{end}}
-[settings.kubernetes]
-api-server   = "{{ .ApiEndpoint }}"
-cluster-certificate = "{{ .ClusterCA }}"
-cluster-name = "{{ .ClusterName }}"
-{{- if .MaxPods}}
-max-pods = {{ .MaxPods }}
-{{- end}}
-[settings.kubernetes.node-labels]
-{{- range $key, $value := .NodeLabels }}
-"{{ $key }}" = "{{ $value }}"
-{{- end}}
-[settings.kubernetes.node-taints]
-{{- range .NodeTaints}}
-"{{ .Key }}" = "{{ .Value }}:{{ .Effect }}"
-{{- end}}
-{{range $post := .PostBootstrap}}{{$post}}{{end}}
-`
-	case OsFamilyAmazonLinux2:
-		UserDataTemplate = `#!/bin/bash
-{{range $pre := .PreBootstrap}}{{$pre}}{{end}}
-{{- range .MountOptions}}
-mkfs.{{ .FileSystem | ToLower }} {{ .Device }}
-mkdir {{ .Mount }}
-mount {{ .Device }} {{ .Mount }}
-mount
-{{- if .Persistance}}
-echo "{{ .Device}}    {{ .Mount }}    {{ .FileSystem | ToLower }}    defaults    0    2" >> /etc/fstab
-{{- end}}
-{{- end}}
-if [[ $(type -P $(which aws)) ]] && [[ $(type -P $(which jq)) ]] ; then
-	TOKEN=$(curl -X PUT "http://169.254.169.254/latest/api/token" -H "X-aws-ec2-metadata-token-ttl-seconds: 21600")
-	INSTANCE_ID=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/instance-id)
-	REGION=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/placement/region)
-	LIFECYCLE=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/autoscaling/target-lifecycle-state)
-	if [[ $LIFECYCLE == *"Warmed"* ]]; then
-		rm /var/lib/cloud/instances/$INSTANCE_ID/sem/config_scripts_user
-		exit 0
-	fi
-fi
-set -o xtrace
-/etc/eks/bootstrap.sh {{ .ClusterName }} {{ .Arguments }}
-set +o xtrace
-{{range $post := .PostBootstrap}}{{$post}}{{end}}`
-	case OsFamilyAmazonLinux2023:
-		UserDataTemplate = `MIME-Version: 1.0
-Content-Type: multipart/mixed; boundary="BOUNDARY"
-
---BOUNDARY
-Content-Type: text/x-shellscript; charset="us-ascii"
-
-#!/bin/bash
-echo "IG manager using AL2023 amis"
-{{range $pre := .PreBootstrap}}{{$pre}}{{end}}
-{{- range .MountOptions}}
-mkfs.{{ .FileSystem | ToLower }} {{ .Device }}
-mkdir {{ .Mount }}
-mount {{ .Device }} {{ .Mount }}
-mount
-{{- if .Persistance}}
-echo "{{ .Device}}    {{ .Mount }}    {{ .FileSystem | ToLower }}    defaults    0    2" >> /etc/fstab
-{{- end}}
-{{- end}}
-if [[ $(type -P $(which aws)) ]] && [[ $(type -P $(which jq)) ]] ; then
-	TOKEN=$(curl -X PUT "http://169.254.169.254/latest/api/token" -H "X-aws-ec2-metadata-token-ttl-seconds: 21600")
-	INSTANCE_ID=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/instance-id)
-	REGION=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/placement/region)
-	LIFECYCLE=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/autoscaling/target-lifecycle-state)
-	if [[ $LIFECYCLE == *"Warmed"* ]]; then
-		rm /var/lib/cloud/instances/$INSTANCE_ID/sem/config_scripts_user
-		exit 0
-	fi
-fi
---BOUNDARY
-Content-Type: application/node.eks.aws
-
-{{ .NodeConfigYaml }}
-
---BOUNDARY
-Content-Type: application/node.eks.aws
-
----
-apiVersion: node.eks.aws/v1alpha1
-kind: NodeConfig
-spec:
-  kubelet:
-    flags:
-      - --node-labels={{ $first := true }}{{ range $key, $value := .NodeLabels }}{{if not $first}},{{end}}{{ $key }}={{ $value }}{{ $first = false}}{{- end}}
-      - --register-with-taints={{ $first := true }}{{- range .NodeTaints}}{{if not $first}},{{end}}{{ .Key }}={{ .Value }}:{{ .Effect }}{{ $first = false}}{{- end}}
-
---BOUNDARY
-Content-Type: text/x-shellscript; charset="us-ascii"
-
-#!/bin/bash
-set +o xtrace
-{{range $post := .PostBootstrap}}{{$post}}{{end}}
---BOUNDARY--`
-	}
-
 	data := EKSUserData{
+		OsFamily:         strings.ToLower(osFamily),
 		ApiEndpoint:      apiEndpoint,
 		ClusterCA:        clusterCa,
 		ClusterName:      clusterName,
@@ -264,19 +1212,36 @@ set +o xtrace
 		NodeConfigYaml:   payload.NodeConfigYaml,
 		MountOptions:     mounts,
 		ClusterIP:        clusterIP,
+
+		RegistryCredentialsParameter:    ctx.GetRegistryCredentialsParameter(),
+		WindowsContainerd:               configuration.GetWindowsContainerd(),
+		PrePullImages:                   ctx.GetPrePullImages(),
+		ImageGCHighThreshold:            imageGCHighThreshold,
+		ImageGCLowThreshold:             imageGCLowThreshold,
+		SerializeImagePulls:             serializeImagePulls,
+		MaxParallelImagePulls:           maxParallelImagePulls,
+		ShutdownGracePeriod:             shutdownGracePeriod,
+		ShutdownGracePeriodCriticalPods: shutdownCritical,
+		AllowedUnsafeSysctls:            allowedUnsafeSysctls,
+		InstanceStorage:                 ctx.GetInstanceStorage(),
+		BootstrapRetries:                ctx.GetBootstrapRetries(),
+		Proxy:                           ctx.GetProxy(),
+		CABundle:                        ctx.GetCABundle(),
+		KubeletConfig:                   ctx.GetKubeletConfig(),
+		Sysctls:                         configuration.GetSysctls(),
+		Variables:                       configuration.GetUserDataVariables(),
+	}
+
+	renderer, ok := GetUserDataRenderer(osFamily)
+	if !ok {
+		ctx.Log.Error(errors.Errorf("no userdata renderer registered for os family %v", osFamily), "failed to render userData")
+		return ""
+	}
+	userData, err := renderer.Render(data)
+	if err != nil {
+		ctx.Log.Error(err, "failed to render userData", "osfamily", osFamily)
 	}
-	out := &bytes.Buffer{}
-	tmpl := template.New("userData").Funcs(template.FuncMap{
-		"ToLower": strings.ToLower,
-	})
-	var err error
-	if tmpl, err = tmpl.Parse(UserDataTemplate); err != nil {
-		ctx.Log.Error(err, "failed to parse userData template")
-	}
-	if err := tmpl.Execute(out, data); err != nil {
-		ctx.Log.Error(err, "failed to execute userData template")
-	}
-	return base64.StdEncoding.EncodeToString(out.Bytes())
+	return base64.StdEncoding.EncodeToString([]byte(userData))
 }
 
 func (ctx *EksInstanceGroupContext) GetUserDataStages() UserDataPayload {
@@ -284,7 +1249,7 @@ func (ctx *EksInstanceGroupContext) GetUserDataStages() UserDataPayload {
 	var (
 		instanceGroup = ctx.GetInstanceGroup()
 		configuration = instanceGroup.GetEKSConfiguration()
-		userData      = configuration.GetUserData()
+		userData      = sortUserDataStages(configuration.GetUserData())
 	)
 
 	payload := UserDataPayload{}
@@ -316,6 +1281,34 @@ func (ctx *EksInstanceGroupContext) GetUserDataStages() UserDataPayload {
 	return payload
 }
 
+// sortUserDataStages orders stages by their order, stages without an order keep their position after ordered stages
+func sortUserDataStages(stages []v1alpha1.UserDataStage) []v1alpha1.UserDataStage {
+	sorted := append([]v1alpha1.UserDataStage{}, stages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aOrdered := sorted[i].GetOrder()
+		b, bOrdered := sorted[j].GetOrder()
+		if aOrdered && bOrdered {
+			return a < b
+		}
+		return aOrdered && !bOrdered
+	})
+	return sorted
+}
+
+// GetVolumes returns the block devices for the scaling configuration, including the bottlerocket data volume
+func (ctx *EksInstanceGroupContext) GetVolumes() []v1alpha1.NodeVolume {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		volumes       = configuration.GetVolumes()
+	)
+
+	if dataVolume := configuration.GetDataVolume(); dataVolume != nil {
+		volumes = append(append([]v1alpha1.NodeVolume{}, volumes...), *dataVolume)
+	}
+	return volumes
+}
+
 func (ctx *EksInstanceGroupContext) GetMountOpts() []MountOpts {
 	var (
 		mountOpts     = make([]MountOpts, 0)
@@ -360,7 +1353,6 @@ func (ctx *EksInstanceGroupContext) GetAddedTags(asgName string) []*autoscaling.
 		instanceGroup    = ctx.GetInstanceGroup()
 		configuration    = instanceGroup.GetEKSConfiguration()
 		clusterName      = configuration.GetClusterName()
-		annotations      = instanceGroup.GetAnnotations()
 		labels           = ctx.GetComputedLabels()
 		taints           = configuration.GetTaints()
 		osFamily         = ctx.GetOsFamily()
@@ -374,7 +1366,12 @@ func (ctx *EksInstanceGroupContext) GetAddedTags(asgName string) []*autoscaling.
 	tags = append(tags, ctx.AwsWorker.NewTag(provisioners.TagInstanceGroupNamespace, instanceGroup.GetNamespace(), asgName))
 	tags = append(tags, ctx.AwsWorker.NewTag(provisioners.TagInstanceGroupName, instanceGroup.GetName(), asgName))
 
-	if annotations[ClusterAutoscalerEnabledAnnotation] == "true" {
+	zone, isZoneShard := ctx.scalingGroupZone(asgName)
+	if isZoneShard {
+		tags = append(tags, ctx.AwsWorker.NewTag(provisioners.TagAvailabilityZone, zone, asgName))
+	}
+
+	if ctx.IsClusterAutoscalerEnabled() {
 		tags = append(tags, ctx.AwsWorker.NewTag(fmt.Sprintf("k8s.io/cluster-autoscaler/%v", clusterName), "owned", asgName))
 		tags = append(tags, ctx.AwsWorker.NewTag("k8s.io/cluster-autoscaler/enabled", "true", asgName))
 
@@ -396,6 +1393,10 @@ func (ctx *EksInstanceGroupContext) GetAddedTags(asgName string) []*autoscaling.
 			tags = append(tags, ctx.AwsWorker.NewTag(fmt.Sprintf("k8s.io/cluster-autoscaler/node-template/label/%v", label), labelValue, asgName))
 		}
 
+		if isZoneShard {
+			tags = append(tags, ctx.AwsWorker.NewTag("k8s.io/cluster-autoscaler/node-template/label/topology.kubernetes.io/zone", zone, asgName))
+		}
+
 		for _, taint := range taints {
 			tagValue := fmt.Sprintf("%v:%v", taint.Value, taint.Effect)
 			tag := ctx.AwsWorker.NewTag(fmt.Sprintf("k8s.io/cluster-autoscaler/node-template/taint/%s", taint.Key), tagValue, asgName)
@@ -404,7 +1405,7 @@ func (ctx *EksInstanceGroupContext) GetAddedTags(asgName string) []*autoscaling.
 	}
 
 	// custom tags
-	for _, tagSlice := range configuration.GetTags() {
+	for _, tagSlice := range ctx.GetCustomTags() {
 		tags = append(tags, ctx.AwsWorker.NewTag(tagSlice["key"], tagSlice["value"], asgName))
 	}
 	return tags
@@ -419,6 +1420,10 @@ func (ctx *EksInstanceGroupContext) GetRemovedTags(asgName string) []*autoscalin
 	)
 
 	for _, tag := range scalingGroup.Tags {
+		// tags created by AWS, e.g. by CloudFormation, cannot be removed
+		if awsprovider.IsReservedTagKey(aws.StringValue(tag.Key)) {
+			continue
+		}
 		var match bool
 		for _, t := range addedTags {
 			if aws.StringValue(t.Key) == aws.StringValue(tag.Key) {
@@ -489,13 +1494,16 @@ func (ctx *EksInstanceGroupContext) GetTaintList() []string {
 
 func (ctx *EksInstanceGroupContext) GetComputedLabels() map[string]string {
 	var (
-		isOverride    bool
-		labelMap      = make(map[string]string)
-		instanceGroup = ctx.GetInstanceGroup()
-		status        = instanceGroup.GetStatus()
-		annotations   = instanceGroup.GetAnnotations()
-		configuration = instanceGroup.GetEKSConfiguration()
-		customLabels  = configuration.GetLabels()
+		isOverride     bool
+		labelMap       = make(map[string]string)
+		suppressLabels = make([]string, 0)
+		instanceGroup  = ctx.GetInstanceGroup()
+		status         = instanceGroup.GetStatus()
+		annotations    = instanceGroup.GetAnnotations()
+		configuration  = instanceGroup.GetEKSConfiguration()
+		customLabels   = configuration.GetLabels()
+		roleOldLabel   = fmt.Sprintf(RoleOldLabel, instanceGroup.GetName())
+		defaultLabels  = []string{RoleNewLabel, roleOldLabel, InstanceMgrLifecycleLabel, InstanceMgrImageLabel}
 	)
 
 	// get custom labels
@@ -505,15 +1513,20 @@ func (ctx *EksInstanceGroupContext) GetComputedLabels() map[string]string {
 		}
 	}
 
-	// allow override default labels
+	// allow override default labels, keys of default labels without a value are suppressed while
+	// any other label replaces the default role labels
 	if val, ok := annotations[OverrideDefaultLabelsAnnotation]; ok {
-		isOverride = true
 		overrideLabels := strings.Split(val, ",")
 		for _, label := range overrideLabels {
+			label = strings.TrimSpace(label)
 			keyVal := strings.Split(label, "=")
 			if len(keyVal) == 2 {
+				isOverride = true
 				labelMap[keyVal[0]] = keyVal[1]
+			} else if common.ContainsString(defaultLabels, keyVal[0]) {
+				suppressLabels = append(suppressLabels, keyVal[0])
 			} else {
+				isOverride = true
 				labelMap[keyVal[0]] = ""
 			}
 		}
@@ -528,29 +1541,76 @@ func (ctx *EksInstanceGroupContext) GetComputedLabels() map[string]string {
 		ver, err := semver.NewVersion(clusterVersion)
 		if err != nil {
 			ctx.Log.Error(err, "Failed parsing the cluster's kubernetes version", "instancegroup", instanceGroup.NamespacedName())
-			labelMap[fmt.Sprintf(RoleOldLabel, instanceGroup.GetName())] = ""
+			labelMap[roleOldLabel] = ""
 		} else {
 			c, _ := semver.NewConstraint("< 1.16-0")
 			if c.Check(ver) {
-				labelMap[fmt.Sprintf(RoleOldLabel, instanceGroup.GetName())] = ""
+				labelMap[roleOldLabel] = ""
 			}
 		}
 	}
 
+	// nodes of mixed instance groups which opted into instance lifecycle labels register without a lifecycle label, which
+	// is set to the lifecycle of their instance once it launched
 	switch status.GetLifecycle() {
 	case v1alpha1.LifecycleStateNormal:
 		labelMap[InstanceMgrLifecycleLabel] = v1alpha1.LifecycleStateNormal
 	case v1alpha1.LifecycleStateSpot:
 		labelMap[InstanceMgrLifecycleLabel] = v1alpha1.LifecycleStateSpot
 	case v1alpha1.LifecycleStateMixed:
-		labelMap[InstanceMgrLifecycleLabel] = v1alpha1.LifecycleStateMixed
+		if !ctx.IsInstanceLifecycleLabelsEnabled() {
+			labelMap[InstanceMgrLifecycleLabel] = v1alpha1.LifecycleStateMixed
+		}
 	}
 
 	labelMap[InstanceMgrImageLabel] = configuration.GetImage()
 
+	// the pinned CNI version is consumed by aws-node daemonsets selecting nodes by version
+	if version := configuration.GetVpcCNIVersion(); version != "" {
+		labelMap[InstanceMgrVpcCNILabel] = version
+	}
+
+	// warm targets are consumed the same way, by aws-node daemonsets configured with matching targets
+	if targets := configuration.GetVpcCNIWarmTargets(); targets != nil {
+		for label, target := range map[string]*int64{
+			VpcCNIWarmIPTargetLabel:    targets.WarmIPTarget,
+			VpcCNIMinimumIPTargetLabel: targets.MinimumIPTarget,
+			VpcCNIWarmENITargetLabel:   targets.WarmENITarget,
+		} {
+			if target != nil {
+				labelMap[label] = strconv.FormatInt(*target, 10)
+			}
+		}
+	}
+
+	for _, label := range suppressLabels {
+		delete(labelMap, label)
+	}
+
 	return labelMap
 }
 
+// IsInstanceLifecycleLabelsEnabled returns true if the instance group is annotated to label the nodes of mixed instance
+// groups with the lifecycle of their instance instead of mixed
+func (ctx *EksInstanceGroupContext) IsInstanceLifecycleLabelsEnabled() bool {
+	annotations := ctx.GetInstanceGroup().GetAnnotations()
+	return strings.EqualFold(annotations[InstanceLifecycleLabelsAnnotation], "true")
+}
+
+// IsDefaultLabelSuppressed returns true if the default labels annotation suppresses the default label
+func (ctx *EksInstanceGroupContext) IsDefaultLabelSuppressed(label string) bool {
+	val, ok := ctx.GetInstanceGroup().GetAnnotations()[OverrideDefaultLabelsAnnotation]
+	if !ok {
+		return false
+	}
+	for _, l := range strings.Split(val, ",") {
+		if strings.TrimSpace(l) == label {
+			return true
+		}
+	}
+	return false
+}
+
 func (ctx *EksInstanceGroupContext) GetLabelList() []string {
 	var (
 		labelList []string
@@ -566,30 +1626,144 @@ func (ctx *EksInstanceGroupContext) GetLabelList() []string {
 	return labelList
 }
 
+// IsPrefixAssignmentEnabled returns true when the VPC CNI assigns pod IPs from /28 prefixes attached to the node's ENIs
+func (ctx *EksInstanceGroupContext) IsPrefixAssignmentEnabled() bool {
+	return ctx.GetInstanceGroup().GetAnnotations()[CustomNetworkingPrefixAssignmentEnabledAnnotation] == "true"
+}
+
+// ValidatePrefixAssignment rejects networking annotations which conflict with prefix assignment
+func (ctx *EksInstanceGroupContext) ValidatePrefixAssignment() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+	)
+
+	if !ctx.IsPrefixAssignmentEnabled() {
+		return nil
+	}
+
+	if strings.EqualFold(ctx.GetOsFamily(), OsFamilyWindows) {
+		return errors.Errorf("annotation %v is not supported for os family %v", CustomNetworkingPrefixAssignmentEnabledAnnotation, OsFamilyWindows)
+	}
+
+	// prefixes can only be attached to ENIs of nitro instances
+	if info := awsprovider.GetInstanceTypeInfo(state.GetInstanceTypeInfo(), configuration.InstanceType); info != nil {
+		if hypervisor := aws.StringValue(info.Hypervisor); hypervisor != ec2.InstanceTypeHypervisorNitro {
+			return errors.Errorf("annotation %v requires a nitro instance type, %v uses hypervisor '%v'", CustomNetworkingPrefixAssignmentEnabledAnnotation, configuration.InstanceType, hypervisor)
+		}
+	}
+	return nil
+}
+
+// GetPrefixDensityStatus returns the max pods nodes bootstrap with for prefix assignment, or nil if it is disabled
+func (ctx *EksInstanceGroupContext) GetPrefixDensityStatus() *v1alpha1.PrefixDensityStatus {
+	if !ctx.IsPrefixAssignmentEnabled() {
+		return nil
+	}
+
+	status := &v1alpha1.PrefixDensityStatus{
+		CustomNetworkingEnabled: ctx.GetInstanceGroup().GetAnnotations()[CustomNetworkingEnabledAnnotation] == "true",
+	}
+	if bootstrapOptions := ctx.GetComputedBootstrapOptions(); bootstrapOptions != nil {
+		status.MaxPods = bootstrapOptions.MaxPods
+	}
+	return status
+}
+
+// EvaluateMaxPodsFormula evaluates a max pods formula with the network limits of an instance type
+func EvaluateMaxPodsFormula(expression string, networkInfo *ec2.NetworkInfo, hostNetworkPods int64) (int64, error) {
+	formula, err := common.ParseFormula(expression, v1alpha1.AllowedMaxPodsFormulaVariables)
+	if err != nil {
+		return 0, err
+	}
+
+	maxPods, err := formula.Evaluate(map[string]int64{
+		v1alpha1.MaxPodsFormulaENIs:         aws.Int64Value(networkInfo.MaximumNetworkInterfaces),
+		v1alpha1.MaxPodsFormulaIPsPerENI:    aws.Int64Value(networkInfo.Ipv4AddressesPerInterface),
+		v1alpha1.MaxPodsFormulaIPsPerPrefix: IPsPerPrefix,
+		v1alpha1.MaxPodsFormulaHostPods:     hostNetworkPods,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to evaluate formula '%v'", expression)
+	}
+	if maxPods <= 0 {
+		return 0, errors.Errorf("formula '%v' evaluated to %v, max pods must be positive", expression, maxPods)
+	}
+	return maxPods, nil
+}
+
+// ValidateMaxPodsFormula evaluates the max pods formula for the instance type, which fails for formulas that divide by
+// zero or compute a non-positive number of pods
+func (ctx *EksInstanceGroupContext) ValidateMaxPodsFormula() error {
+	var (
+		instanceGroup    = ctx.GetInstanceGroup()
+		configuration    = instanceGroup.GetEKSConfiguration()
+		state            = ctx.GetDiscoveredState()
+		bootstrapOptions = configuration.BootstrapOptions
+	)
+
+	if bootstrapOptions == nil || bootstrapOptions.MaxPodsFormula == "" {
+		return nil
+	}
+
+	networkInfo := awsprovider.GetInstanceTypeNetworkInfo(state.GetInstanceTypeInfo(), configuration.InstanceType)
+	if networkInfo == nil {
+		return errors.Errorf("failed to get network info of instance type %v", configuration.InstanceType)
+	}
+	_, err := EvaluateMaxPodsFormula(bootstrapOptions.MaxPodsFormula, networkInfo, ctx.GetHostNetworkPods())
+	return err
+}
+
+// GetHostNetworkPods returns the number of host network pods for max pods calculations
+func (ctx *EksInstanceGroupContext) GetHostNetworkPods() int64 {
+	hostNetworkPods, err := strconv.ParseInt(ctx.GetInstanceGroup().GetAnnotations()[CustomNetworkingHostPodsAnnotation], 10, 64)
+	if err != nil {
+		hostNetworkPods = 2 //Default on EKS. Kube-Proxy and AWS VPC CNI
+	}
+	return hostNetworkPods
+}
+
 func (ctx *EksInstanceGroupContext) GetComputedBootstrapOptions() *v1alpha1.BootstrapOptions {
 	var (
 		instanceGroup = ctx.GetInstanceGroup()
 		state         = ctx.GetDiscoveredState()
 		configuration = instanceGroup.GetEKSConfiguration()
 	)
-	if instanceGroup.GetAnnotations()[CustomNetworkingEnabledAnnotation] == "true" {
-		hostNetworkPods, err := strconv.ParseInt(instanceGroup.GetAnnotations()[CustomNetworkingHostPodsAnnotation], 10, 64)
-		if err != nil {
-			hostNetworkPods = 2 //Default on EKS. Kube-Proxy and AWS VPC CNI
-		}
+	var customNetworkingEnabled = instanceGroup.GetAnnotations()[CustomNetworkingEnabledAnnotation] == "true"
+	var prefixAssignmentEnabled = ctx.IsPrefixAssignmentEnabled()
+	var maxPodsFormula string
+	if configuration.BootstrapOptions != nil {
+		maxPodsFormula = configuration.BootstrapOptions.MaxPodsFormula
+	}
 
+	if customNetworkingEnabled || prefixAssignmentEnabled || maxPodsFormula != "" {
+		hostNetworkPods := ctx.GetHostNetworkPods()
 		instanceTypeNetworkInfo := awsprovider.GetInstanceTypeNetworkInfo(state.GetInstanceTypeInfo(), configuration.InstanceType)
-		var prefixAssignmentEnabled = instanceGroup.GetAnnotations()[CustomNetworkingPrefixAssignmentEnabledAnnotation] == "true"
+		if instanceTypeNetworkInfo == nil {
+			return configuration.BootstrapOptions
+		}
 		var maxPods int64
 
-		var enis = aws.Int64Value(instanceTypeNetworkInfo.MaximumNetworkInterfaces) - 1 //Primary interface is not used for pod networking when custom networking is enabled
-		var ipsPerInterface int64 = 1
-		if prefixAssignmentEnabled {
-			ipsPerInterface = 16 //Number of ips in a /28 block
-		}
+		if maxPodsFormula != "" {
+			var err error
+			if maxPods, err = EvaluateMaxPodsFormula(maxPodsFormula, instanceTypeNetworkInfo, hostNetworkPods); err != nil {
+				ctx.Log.Error(err, "failed to evaluate max pods formula", "instancegroup", instanceGroup.NamespacedName())
+				return configuration.BootstrapOptions
+			}
+		} else {
+			var enis = aws.Int64Value(instanceTypeNetworkInfo.MaximumNetworkInterfaces)
+			if customNetworkingEnabled {
+				enis-- //Primary interface is not used for pod networking when custom networking is enabled
+			}
+			var ipsPerInterface int64 = 1
+			if prefixAssignmentEnabled {
+				ipsPerInterface = IPsPerPrefix
+			}
 
-		// Don't set maxPods above Kubernetes-recommended 110 per node for large clusters.
-		maxPods = common.Min(enis*((aws.Int64Value(instanceTypeNetworkInfo.Ipv4AddressesPerInterface)-1)*ipsPerInterface)+hostNetworkPods, 110)
+			// Don't set maxPods above Kubernetes-recommended 110 per node for large clusters.
+			maxPods = common.Min(enis*((aws.Int64Value(instanceTypeNetworkInfo.Ipv4AddressesPerInterface)-1)*ipsPerInterface)+hostNetworkPods, 110)
+		}
 
 		if configuration.BootstrapOptions == nil {
 			return &v1alpha1.BootstrapOptions{
@@ -618,13 +1792,14 @@ func (ctx *EksInstanceGroupContext) GetBootstrapArgs() string {
 	switch strings.ToLower(osFamily) {
 	case OsFamilyWindows:
 		if state.Cluster != nil && !ctx.DisableWinClusterInjection {
-			sb.WriteString(fmt.Sprintf("-Base64ClusterCA %v ", aws.StringValue(state.Cluster.CertificateAuthority.Data)))
-			sb.WriteString(fmt.Sprintf("-APIServerEndpoint %v ", aws.StringValue(state.Cluster.Endpoint)))
+			sb.WriteString(fmt.Sprintf("-Base64ClusterCA %v ", state.GetClusterCA()))
+			sb.WriteString(fmt.Sprintf("-APIServerEndpoint %v ", state.GetClusterEndpoint()))
 		}
 		if bootstrapOptions != nil && bootstrapOptions.ContainerRuntime != "" {
 			sb.WriteString(fmt.Sprintf("-ContainerRuntime %v ", bootstrapOptions.ContainerRuntime))
 		}
 		sb.WriteString(fmt.Sprintf("-KubeletExtraArgs '%v'", ctx.GetKubeletExtraArgs()))
+		sb.WriteString(ctx.getBootstrapFlags(osFamily, "-"))
 	case OsFamilyAmazonLinux2, OsFamilyAmazonLinux2023:
 		if bootstrapOptions != nil && bootstrapOptions.MaxPods > 0 {
 			sb.WriteString("--use-max-pods false ")
@@ -634,19 +1809,97 @@ func (ctx *EksInstanceGroupContext) GetBootstrapArgs() string {
 			sb.WriteString(fmt.Sprintf("--container-runtime %v ", bootstrapOptions.ContainerRuntime))
 		}
 		if state.Cluster != nil {
-			sb.WriteString(fmt.Sprintf("--b64-cluster-ca %v ", aws.StringValue(state.Cluster.CertificateAuthority.Data)))
-			sb.WriteString(fmt.Sprintf("--apiserver-endpoint %v ", aws.StringValue(state.Cluster.Endpoint)))
+			sb.WriteString(fmt.Sprintf("--b64-cluster-ca %v ", state.GetClusterCA()))
+			sb.WriteString(fmt.Sprintf("--apiserver-endpoint %v ", state.GetClusterEndpoint()))
 			if !common.StringEmpty(clusterIP) {
 				sb.WriteString(fmt.Sprintf("--dns-cluster-ip %v ", clusterIP))
 			}
 		}
 
 		sb.WriteString(fmt.Sprintf("--kubelet-extra-args '%v'", ctx.GetKubeletExtraArgs()))
+		sb.WriteString(ctx.getBootstrapFlags(osFamily, "--"))
+	}
+
+	return sb.String()
+}
+
+// getBootstrapFlags returns the configured bootstrap flags supported by the OS family in name order, each preceded by a space
+func (ctx *EksInstanceGroupContext) getBootstrapFlags(osFamily, prefix string) string {
+	var (
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		options       = configuration.GetBootstrapOptions()
+		supported     = SupportedBootstrapFlags[strings.ToLower(osFamily)]
+	)
+
+	if options == nil || len(options.Flags) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(options.Flags))
+	for name := range options.Flags {
+		if _, ok := supported[name]; ok {
+			names = append(names, name)
+		}
 	}
+	sort.Strings(names)
 
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf(" %v%v %v", prefix, name, options.Flags[name]))
+	}
 	return sb.String()
 }
 
+// ValidateBootstrapFlags rejects bootstrap flags which are not supported by the instance group's OS family, or whose
+// values are not accepted by the flag
+func (ctx *EksInstanceGroupContext) ValidateBootstrapFlags() error {
+	var (
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		options       = configuration.GetBootstrapOptions()
+		osFamily      = ctx.GetOsFamily()
+	)
+
+	if options == nil || len(options.Flags) == 0 {
+		return nil
+	}
+
+	supported, ok := SupportedBootstrapFlags[strings.ToLower(osFamily)]
+	if !ok {
+		return errors.Errorf("bootstrap flags are not supported for os family %v", osFamily)
+	}
+
+	supportedNames := make([]string, 0, len(supported))
+	for name := range supported {
+		supportedNames = append(supportedNames, name)
+	}
+	sort.Strings(supportedNames)
+
+	names := make([]string, 0, len(options.Flags))
+	for name := range options.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := options.Flags[name]
+		flag, ok := supported[name]
+		if !ok {
+			return errors.Errorf("bootstrap flag %v is not supported for os family %v, supported flags are %v", name, osFamily, supportedNames)
+		}
+		if !flag.IsValid(value) {
+			return errors.Errorf("bootstrap flag %v must be %v, got '%v'", name, flag.Description, value)
+		}
+	}
+
+	// the controller disables use-max-pods whenever it sets max pods
+	if _, ok := options.Flags["use-max-pods"]; ok {
+		if computed := ctx.GetComputedBootstrapOptions(); computed != nil && computed.MaxPods > 0 {
+			return errors.Errorf("bootstrap flag use-max-pods cannot be combined with max pods, which sets it to false")
+		}
+	}
+	return nil
+}
+
 func (ctx *EksInstanceGroupContext) GetKubeletExtraArgs() string {
 	var (
 		instanceGroup    = ctx.GetInstanceGroup()
@@ -662,6 +1915,12 @@ func (ctx *EksInstanceGroupContext) GetKubeletExtraArgs() string {
 	if bootstrapOptions != nil && bootstrapOptions.MaxPods > 0 {
 		sb.WriteString(fmt.Sprintf(" --max-pods=%v", bootstrapOptions.MaxPods))
 	}
+	if bootstrapOptions != nil && bootstrapOptions.ImageGCHighThreshold != nil {
+		sb.WriteString(fmt.Sprintf(" --image-gc-high-threshold=%v", *bootstrapOptions.ImageGCHighThreshold))
+	}
+	if bootstrapOptions != nil && bootstrapOptions.ImageGCLowThreshold != nil {
+		sb.WriteString(fmt.Sprintf(" --image-gc-low-threshold=%v", *bootstrapOptions.ImageGCLowThreshold))
+	}
 	return sb.String()
 }
 
@@ -699,6 +1958,14 @@ func (ctx *EksInstanceGroupContext) discoverSpotPrice() error {
 		return nil
 	}
 
+	// recommendations cannot launch a capacity type the enforcement does not allow
+	enforcement := configuration.GetCapacityTypeEnforcement()
+	if (recommendation.UseSpot && enforcement == v1alpha1.CapacityTypeEnforcementOnDemandOnly) || (!recommendation.UseSpot && enforcement == v1alpha1.CapacityTypeEnforcementSpotOnly) {
+		ctx.Log.Info("ignoring spot recommendation due to capacity type enforcement", "instancegroup", instanceGroup.NamespacedName(), "useSpot", recommendation.UseSpot, "capacityTypeEnforcement", enforcement)
+		status.SetUsingSpotRecommendation(false)
+		return nil
+	}
+
 	// set the recommendation given
 	status.SetUsingSpotRecommendation(true)
 
@@ -761,16 +2028,412 @@ func (ctx *EksInstanceGroupContext) findTargetScalingGroup(groups []*autoscaling
 		}
 	}
 
-	return nil
+	return nil
+}
+
+// IsClusterAutoscalerEnabled returns true if cluster-autoscaler manages the desired capacity of the instance group
+func (ctx *EksInstanceGroupContext) IsClusterAutoscalerEnabled() bool {
+	annotations := ctx.GetInstanceGroup().GetAnnotations()
+	return annotations[ClusterAutoscalerEnabledAnnotation] == "true"
+}
+
+// GetDesiredCapacity returns the desired capacity to set when updating a scaling group, or nil to leave it untouched.
+// The desired capacity is set to the capacity computed by the instance group's autoscaler, or only to bring it back
+// within the shard's min/max, and never when cluster-autoscaler manages the instance group, in which case only min/max
+// are reconciled after the scaling group is created
+func (ctx *EksInstanceGroupContext) GetDesiredCapacity(shard ScalingGroupShard) *int64 {
+	if ctx.IsClusterAutoscalerEnabled() {
+		return nil
+	}
+
+	var (
+		state        = ctx.GetDiscoveredState()
+		scalingGroup = state.GetScalingGroup()
+		desired      = aws.Int64Value(scalingGroup.DesiredCapacity)
+	)
+
+	if capacity, ok := ctx.GetAutoscalerCapacity(shard, desired); ok {
+		if capacity == desired {
+			return nil
+		}
+		return aws.Int64(capacity)
+	}
+
+	switch {
+	case desired < shard.MinSize:
+		return aws.Int64(shard.MinSize)
+	case desired > shard.MaxSize:
+		return aws.Int64(shard.MaxSize)
+	}
+	return nil
+}
+
+// GetAutoscalerCapacity returns the capacity the instance group's autoscaler scales the shard to from its current
+// capacity, bounded by the shard's min/max, false is returned if no autoscaler targets the instance group or its metric
+// has not been reported
+func (ctx *EksInstanceGroupContext) GetAutoscalerCapacity(shard ScalingGroupShard, current int64) (int64, bool) {
+	if ctx.Autoscaler == nil {
+		return 0, false
+	}
+
+	capacity, ok := ctx.Autoscaler.GetDesiredCapacity(current)
+	if !ok {
+		if ctx.Autoscaler.Status.CurrentValue != nil {
+			ctx.Log.Info("autoscaler metric value is stale, desired capacity is not changed", "instancegroup", ctx.GetInstanceGroup().NamespacedName(), "autoscaler", ctx.Autoscaler.NamespacedName(), "maxValueAge", ctx.Autoscaler.GetMaxValueAge())
+		}
+		return 0, false
+	}
+
+	switch {
+	case capacity < shard.MinSize:
+		capacity = shard.MinSize
+	case capacity > shard.MaxSize:
+		capacity = shard.MaxSize
+	}
+	return capacity, true
+}
+
+// ValidateAutoscaler rejects instance groups whose desired capacity is managed by both an autoscaler and
+// cluster-autoscaler, and zone sharded instance groups, whose capacity is distributed across scaling groups
+func (ctx *EksInstanceGroupContext) ValidateAutoscaler() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+	)
+
+	if ctx.Autoscaler == nil {
+		return nil
+	}
+	if ctx.IsClusterAutoscalerEnabled() {
+		return errors.Errorf("instance group is scaled by autoscaler %v and cannot also set annotation %v", ctx.Autoscaler.NamespacedName(), ClusterAutoscalerEnabledAnnotation)
+	}
+	if configuration.IsZoneSharded() {
+		return errors.Errorf("zone sharded instance groups cannot be scaled by autoscaler %v", ctx.Autoscaler.NamespacedName())
+	}
+	return nil
+}
+
+// UpdateAutoscalerStatus records the desired capacity of the instance group in the status of its autoscaler, and when
+// the autoscaler scaled the instance group
+func (ctx *EksInstanceGroupContext) UpdateAutoscalerStatus(previous, desired int64) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		state         = ctx.GetDiscoveredState()
+	)
+
+	if ctx.Autoscaler == nil {
+		return
+	}
+
+	status := &ctx.Autoscaler.Status
+	status.SetDesiredCapacity(desired)
+	if previous == desired {
+		return
+	}
+
+	status.SetLastScaleTime(metav1.Now())
+	ctx.Log.Info("scaled instance group", "instancegroup", instanceGroup.NamespacedName(), "autoscaler", ctx.Autoscaler.NamespacedName(), "previous", previous, "desired", desired)
+	state.Publisher.Publish(kubeprovider.InstanceGroupAutoscaledEvent, "instancegroup", instanceGroup.NamespacedName(), "autoscaler", ctx.Autoscaler.NamespacedName(), "previous", strconv.FormatInt(previous, 10), "desired", strconv.FormatInt(desired, 10))
+}
+
+// GetScalingGroupShards returns the desired scaling groups, when zone sharding is enabled a scaling group
+// is desired per availability zone and the min/max capacity is distributed across zones
+func (ctx *EksInstanceGroupContext) GetScalingGroupShards() ([]ScalingGroupShard, error) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		spec          = instanceGroup.GetEKSSpec()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		subnets       = ctx.ResolveSubnets()
+	)
+
+	if !configuration.IsZoneSharded() {
+		return []ScalingGroupShard{ctx.scalingGroupShard()}, nil
+	}
+
+	// a zone which cannot be resolved must not lose its shard, its scaling group would be retired
+	zoneSubnets := make(map[string][]string)
+	for _, s := range subnets {
+		zone, ok := state.SubnetZones[s]
+		if !ok {
+			return nil, errors.Errorf("failed to resolve availability zone of subnet %v", s)
+		}
+		zoneSubnets[zone] = append(zoneSubnets[zone], s)
+	}
+
+	zones := make([]string, 0)
+	for zone := range zoneSubnets {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	shards := make([]ScalingGroupShard, 0)
+	for i, zone := range zones {
+		shards = append(shards, ScalingGroupShard{
+			Name:    ctx.zoneScalingGroupName(zone),
+			Zone:    zone,
+			Subnets: zoneSubnets[zone],
+			MinSize: splitCapacity(spec.GetMinSize(), len(zones), i),
+			MaxSize: splitCapacity(spec.GetMaxSize(), len(zones), i),
+		})
+	}
+	return shards, nil
+}
+
+// scalingGroupShard returns the shard of an instance group which is not zone sharded
+func (ctx *EksInstanceGroupContext) scalingGroupShard() ScalingGroupShard {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		spec          = instanceGroup.GetEKSSpec()
+	)
+	return ScalingGroupShard{
+		Name:    ctx.ResourcePrefix,
+		Subnets: ctx.ResolveSubnets(),
+		MinSize: spec.GetMinSize(),
+		// the green instances of a blue/green rotation are launched in addition to the desired capacity
+		MaxSize: spec.GetMaxSize() + instanceGroup.GetStatus().GetBlueGreenSurge(),
+	}
+}
+
+// splitCapacity returns the share of capacity for a shard index, with the remainder going to the first shards
+func splitCapacity(capacity int64, count, index int) int64 {
+	var (
+		n     = int64(count)
+		share = capacity / n
+	)
+	if int64(index) < capacity%n {
+		share++
+	}
+	return share
+}
+
+func (ctx *EksInstanceGroupContext) zoneScalingGroupName(zone string) string {
+	return fmt.Sprintf("%v-%v", ctx.ResourcePrefix, zone)
+}
+
+func (ctx *EksInstanceGroupContext) scalingGroupZone(asgName string) (string, bool) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		prefix        = fmt.Sprintf("%v-", ctx.ResourcePrefix)
+	)
+	if !configuration.IsZoneSharded() || !strings.HasPrefix(asgName, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(asgName, prefix), true
+}
+
+// findZoneScalingGroups returns the owned scaling groups of a zone sharded instance group by availability zone
+func (ctx *EksInstanceGroupContext) findZoneScalingGroups(groups []*autoscaling.Group) map[string]*autoscaling.Group {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		zoneGroups    = make(map[string]*autoscaling.Group)
+	)
+
+	for _, group := range groups {
+		var (
+			nameMatch      bool
+			namespaceMatch bool
+			zone           string
+		)
+		for _, tag := range group.Tags {
+			var (
+				key   = aws.StringValue(tag.Key)
+				value = aws.StringValue(tag.Value)
+			)
+			switch key {
+			case provisioners.TagInstanceGroupName:
+				nameMatch = value == instanceGroup.GetName()
+			case provisioners.TagInstanceGroupNamespace:
+				namespaceMatch = value == instanceGroup.GetNamespace()
+			case provisioners.TagAvailabilityZone:
+				zone = value
+			}
+		}
+		if nameMatch && namespaceMatch && zone != "" {
+			zoneGroups[zone] = group
+		}
+	}
+	return zoneGroups
+}
+
+// validateZoneSharding makes sure zone sharding was not enabled or disabled on an instance group with existing scaling
+// groups, the scaling groups of the previous layout would otherwise be orphaned
+func (ctx *EksInstanceGroupContext) validateZoneSharding(groups []*autoscaling.Group) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+	)
+
+	for _, group := range groups {
+		var (
+			nameMatch      bool
+			namespaceMatch bool
+			zoned          bool
+		)
+		for _, tag := range group.Tags {
+			var (
+				key   = aws.StringValue(tag.Key)
+				value = aws.StringValue(tag.Value)
+			)
+			switch key {
+			case provisioners.TagInstanceGroupName:
+				nameMatch = value == instanceGroup.GetName()
+			case provisioners.TagInstanceGroupNamespace:
+				namespaceMatch = value == instanceGroup.GetNamespace()
+			case provisioners.TagAvailabilityZone:
+				zoned = true
+			}
+		}
+		if !nameMatch || !namespaceMatch {
+			continue
+		}
+
+		asgName := aws.StringValue(group.AutoScalingGroupName)
+		if configuration.IsZoneSharded() && !zoned {
+			return errors.Errorf("'zoneSharding' cannot be enabled on an instance group with existing scaling group %v, the instance group must be recreated", asgName)
+		}
+		if !configuration.IsZoneSharded() && zoned {
+			return errors.Errorf("'zoneSharding' cannot be disabled on an instance group with existing zone scaling group %v, the instance group must be recreated", asgName)
+		}
+	}
+	return nil
+}
+
+// aggregateScalingGroups merges zone scaling groups into a single view of instances and capacity, settings of the
+// individual scaling groups such as tags, suspended processes and metrics are not part of the view as they are
+// reconciled for each zone scaling group
+func aggregateScalingGroups(groups map[string]*autoscaling.Group) *autoscaling.Group {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	zones := make([]string, 0)
+	for zone := range groups {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	var (
+		aggregate                  = *groups[zones[0]]
+		instances                  = make([]*autoscaling.Instance, 0)
+		minSize, maxSize, capacity int64
+	)
+	for _, zone := range zones {
+		group := groups[zone]
+		instances = append(instances, group.Instances...)
+		minSize += aws.Int64Value(group.MinSize)
+		maxSize += aws.Int64Value(group.MaxSize)
+		capacity += aws.Int64Value(group.DesiredCapacity)
+	}
+	aggregate.Instances = instances
+	aggregate.Tags = nil
+	aggregate.SuspendedProcesses = nil
+	aggregate.EnabledMetrics = nil
+	aggregate.MinSize = aws.Int64(minSize)
+	aggregate.MaxSize = aws.Int64(maxSize)
+	aggregate.DesiredCapacity = aws.Int64(capacity)
+	return &aggregate
+}
+
+// GetZoneCapacity returns the capacity of each zone scaling group
+func (ctx *EksInstanceGroupContext) GetZoneCapacity() []v1alpha1.ZoneCapacityStatus {
+	var (
+		state    = ctx.GetDiscoveredState()
+		capacity = make([]v1alpha1.ZoneCapacityStatus, 0)
+	)
+
+	for zone, group := range state.ZoneScalingGroups {
+		var inService int
+		for _, instance := range group.Instances {
+			if aws.StringValue(instance.LifecycleState) == autoscaling.LifecycleStateInService {
+				inService++
+			}
+		}
+		capacity = append(capacity, v1alpha1.ZoneCapacityStatus{
+			Zone:             zone,
+			ScalingGroupName: aws.StringValue(group.AutoScalingGroupName),
+			CurrentMin:       int(aws.Int64Value(group.MinSize)),
+			CurrentMax:       int(aws.Int64Value(group.MaxSize)),
+			DesiredCapacity:  int(aws.Int64Value(group.DesiredCapacity)),
+			InService:        inService,
+		})
+	}
+
+	sort.Slice(capacity, func(i, j int) bool {
+		return capacity[i].Zone < capacity[j].Zone
+	})
+	return capacity
+}
+
+// withScalingGroup scopes the discovered scaling group and its lifecycle hooks to a single zone scaling group while fn runs
+func (ctx *EksInstanceGroupContext) withScalingGroup(group *autoscaling.Group, fn func() error) error {
+	var (
+		state        = ctx.GetDiscoveredState()
+		scalingGroup = state.ScalingGroup
+		hooks        = state.LifecycleHooks
+	)
+	defer func() {
+		state.ScalingGroup = scalingGroup
+		state.LifecycleHooks = hooks
+	}()
+
+	state.ScalingGroup = group
+	state.LifecycleHooks = []*autoscaling.LifecycleHook{}
+	if group != nil {
+		groupHooks, err := ctx.AwsWorker.DescribeLifecycleHooks(aws.StringValue(group.AutoScalingGroupName))
+		if err != nil {
+			return errors.Wrap(err, "failed to describe lifecycle hooks")
+		}
+		state.LifecycleHooks = groupHooks
+	}
+	return fn()
+}
+
+// InInitialGracePeriod returns true while the scaling group was created more recently than the initial grace period,
+// its instances are still expected to be launching
+func (ctx *EksInstanceGroupContext) InInitialGracePeriod() bool {
+	var (
+		state         = ctx.GetDiscoveredState()
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		scalingGroup  = state.GetScalingGroup()
+	)
+
+	if scalingGroup == nil || scalingGroup.CreatedTime == nil {
+		return false
+	}
+	return time.Since(aws.TimeValue(scalingGroup.CreatedTime)) < configuration.GetInitialGracePeriod()
+}
+
+// nodeConditionTypes are the conditions owned by UpdateNodeReadyCondition
+var nodeConditionTypes = []v1alpha1.InstanceGroupConditionType{
+	v1alpha1.NodesReady,
+	v1alpha1.NodesStartupTaintTimeout,
+	v1alpha1.NodesFailedToJoin,
+	v1alpha1.UnhealthyInstancesProtected,
+}
+
+// setNodeConditions upserts the node conditions, node conditions which are not given are removed while conditions of
+// other types such as BlueGreenRolledBack are left untouched
+func setNodeConditions(status *v1alpha1.InstanceGroupStatus, conditions []v1alpha1.InstanceGroupCondition) {
+	for _, conditionType := range nodeConditionTypes {
+		status.RemoveCondition(conditionType)
+	}
+	for _, condition := range conditions {
+		status.SetCondition(condition)
+	}
 }
 
 func (ctx *EksInstanceGroupContext) UpdateNodeReadyCondition() bool {
 	var (
 		state         = ctx.GetDiscoveredState()
 		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
 		status        = instanceGroup.GetStatus()
 		scalingGroup  = state.GetScalingGroup()
 		nodes         = state.GetClusterNodes()
+		launching     = ctx.InInitialGracePeriod()
 		desiredCount  int
 	)
 
@@ -794,6 +2457,47 @@ func (ctx *EksInstanceGroupContext) UpdateNodeReadyCondition() bool {
 	instances := strings.Join(instanceIds, ",")
 
 	var conditions []v1alpha1.InstanceGroupCondition
+
+	// nodes registered with a startup taint are only counted as ready once the taint is removed
+	if startupTaint := configuration.GetStartupTaint(); startupTaint != nil {
+		stuck := kubeprovider.GetStuckTaintedNodesByInstance(instanceIds, nodes, startupTaint.Key, startupTaint.GetTimeout())
+		if len(stuck) > 0 && !launching {
+			if status.GetStartupTaintTimeoutCondition() != corev1.ConditionTrue {
+				state.Publisher.Publish(kubeprovider.NodesStartupTaintTimeoutEvent, "instancegroup", instanceGroup.NamespacedName(), "instances", strings.Join(stuck, ","), "taint", startupTaint.Key)
+			}
+			ctx.Log.Info("nodes have not removed startup taint", "instancegroup", instanceGroup.NamespacedName(), "instances", stuck, "taint", startupTaint.Key)
+			conditions = append(conditions, v1alpha1.NewInstanceGroupCondition(v1alpha1.NodesStartupTaintTimeout, corev1.ConditionTrue))
+		}
+		nodes = kubeprovider.WithoutTaint(nodes, startupTaint.Key)
+	}
+
+	if failed := state.GetFailedJoinInstances(); len(failed) > 0 {
+		if status.GetFailedJoinCondition() != corev1.ConditionTrue {
+			state.Publisher.Publish(kubeprovider.NodesFailedToJoinEvent, "instancegroup", instanceGroup.NamespacedName(), "instances", strings.Join(failed, ","))
+		}
+		ctx.Log.Info("instances have not joined the cluster", "instancegroup", instanceGroup.NamespacedName(), "instances", failed)
+		conditions = append(conditions, v1alpha1.NewInstanceGroupCondition(v1alpha1.NodesFailedToJoin, corev1.ConditionTrue))
+	}
+
+	// unhealthy instances whose protection is not cleared by the controller cannot be replaced without an operator
+	if unhealthy := state.GetUnhealthyProtectedInstances(); len(unhealthy) > 0 && configuration.GetUnhealthyProtectedPolicy() == v1alpha1.UnhealthyProtectedPolicyReport {
+		if status.GetUnhealthyInstancesProtectedCondition() != corev1.ConditionTrue {
+			state.Publisher.Publish(kubeprovider.UnhealthyProtectedEvent, "instancegroup", instanceGroup.NamespacedName(), "instances", strings.Join(unhealthy, ","))
+		}
+		ctx.Log.Info("unhealthy instances are protected from scale in", "instancegroup", instanceGroup.NamespacedName(), "instances", unhealthy)
+		conditions = append(conditions, v1alpha1.NewInstanceGroupCondition(v1alpha1.UnhealthyInstancesProtected, corev1.ConditionTrue))
+	}
+
+	// nodes are only counted as ready once the required daemonset pods are running on them
+	if checks := configuration.GetReadinessChecks(); checks != nil && len(checks.GetDaemonSets()) > 0 {
+		pods, err := ctx.KubernetesClient.ListRunningPods(kubeprovider.GetNodeNamesByInstance(instanceIds, nodes))
+		if err != nil {
+			ctx.Log.Error(err, "could not check node readiness", "instancegroup", instanceGroup.NamespacedName())
+			return false
+		}
+		nodes = kubeprovider.WithRunningDaemonSetPods(nodes, pods, checks.GetDaemonSets())
+	}
+
 	ok, err := kubeprovider.IsDesiredNodesReady(nodes, instanceIds, desiredCount)
 	if err != nil {
 		ctx.Log.Error(err, "could not update node conditions", "instancegroup", instanceGroup.NamespacedName())
@@ -806,38 +2510,388 @@ func (ctx *EksInstanceGroupContext) UpdateNodeReadyCondition() bool {
 		ctx.Log.Info("desired nodes are ready", "instancegroup", instanceGroup.NamespacedName(), "instances", instances)
 		state.SetNodesReady(true)
 		conditions = append(conditions, v1alpha1.NewInstanceGroupCondition(v1alpha1.NodesReady, corev1.ConditionTrue))
-		status.SetConditions(conditions)
+		setNodeConditions(status, conditions)
 		return true
 	}
 
+	// nodes of a newly created scaling group are expected to be launching
+	if launching {
+		ctx.Log.Info("desired nodes are launching", "instancegroup", instanceGroup.NamespacedName(), "instances", instances)
+		state.SetNodesReady(false)
+		conditions = append(conditions, v1alpha1.NewInstanceGroupCondition(v1alpha1.NodesReady, corev1.ConditionFalse))
+		setNodeConditions(status, conditions)
+		return false
+	}
+
 	if state.IsNodesReady() {
 		state.Publisher.Publish(kubeprovider.NodesNotReadyEvent, "instancegroup", instanceGroup.NamespacedName(), "instances", instances)
 	}
 	ctx.Log.Info("desired nodes are not ready", "instancegroup", instanceGroup.NamespacedName(), "instances", instances)
 	state.SetNodesReady(false)
 	conditions = append(conditions, v1alpha1.NewInstanceGroupCondition(v1alpha1.NodesReady, corev1.ConditionFalse))
-	status.SetConditions(conditions)
+	setNodeConditions(status, conditions)
 	return false
 }
 
-func (ctx *EksInstanceGroupContext) GetEnabledMetrics() ([]string, bool) {
+// StartProvisioning starts measuring the time until all desired nodes are ready from the start of this reconcile, a
+// provisioning already in progress keeps its start time
+func (ctx *EksInstanceGroupContext) StartProvisioning() {
 	var (
-		instanceGroup  = ctx.GetInstanceGroup()
-		configuration  = instanceGroup.GetEKSConfiguration()
-		metrics        = configuration.GetMetricsCollection()
-		state          = ctx.GetDiscoveredState()
-		scalingGroup   = state.GetScalingGroup()
-		enableMetrics  = make([]string, 0)
-		enabledMetrics = make([]string, 0)
-		desiredMetrics []string
+		status    = ctx.GetInstanceGroup().GetStatus()
+		readiness = status.GetReadiness()
+	)
+
+	if readiness == nil {
+		readiness = &v1alpha1.ReadinessStatus{}
+	}
+	if readiness.ProvisioningStartTime == nil {
+		start := metav1.NewTime(ctx.reconcileStartTime)
+		readiness.ProvisioningStartTime = &start
+		readiness.SLAExceeded = false
+	}
+	status.SetReadiness(readiness)
+}
+
+// UpdateReadinessStatus records the time the instance group took to become fully ready after it was created or scaled
+// up. A provisioning starts when the desired capacity exceeds the capacity at which all nodes were last ready, and ends
+// once all desired nodes are ready, when its duration is observed and compared with the readiness SLA
+func (ctx *EksInstanceGroupContext) UpdateReadinessStatus(ready bool, now time.Time) {
+	var (
+		state         = ctx.GetDiscoveredState()
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		status        = instanceGroup.GetStatus()
+		scalingGroup  = state.GetScalingGroup()
+		sla           = configuration.GetReadinessSLA()
+	)
+
+	if scalingGroup == nil {
+		return
+	}
+	desired := aws.Int64Value(scalingGroup.DesiredCapacity)
+
+	if readiness := status.GetReadiness(); !ready && (readiness == nil || desired > readiness.ReadyCapacity) {
+		ctx.StartProvisioning()
+	}
+
+	readiness := status.GetReadiness()
+	if readiness == nil {
+		// nodes which were ready before readiness was tracked are not measured
+		readyTime := metav1.NewTime(now)
+		status.SetReadiness(&v1alpha1.ReadinessStatus{ReadyCapacity: desired, LastReadyTime: &readyTime})
+		return
+	}
+
+	if readiness.ProvisioningStartTime == nil {
+		if ready {
+			readiness.ReadyCapacity = desired
+		}
+		return
+	}
+
+	elapsed := now.Sub(readiness.ProvisioningStartTime.Time)
+	if !ready {
+		if sla > 0 && elapsed > sla && !readiness.SLAExceeded {
+			state.Publisher.Publish(kubeprovider.ReadinessSLAExceededEvent, "instancegroup", instanceGroup.NamespacedName(), "sla", sla.String(), "elapsed", elapsed.Round(time.Second).String())
+			readiness.SLAExceeded = true
+		}
+		return
+	}
+
+	exceeded := sla > 0 && elapsed > sla
+	if exceeded && !readiness.SLAExceeded {
+		state.Publisher.Publish(kubeprovider.ReadinessSLAExceededEvent, "instancegroup", instanceGroup.NamespacedName(), "sla", sla.String(), "elapsed", elapsed.Round(time.Second).String())
+	}
+
+	readyTime := metav1.NewTime(now)
+	readiness.ProvisioningStartTime = nil
+	readiness.ReadyCapacity = desired
+	readiness.LastReadyTime = &readyTime
+	readiness.LastProvisioningDuration = elapsed.Round(time.Second).String()
+	readiness.SLAExceeded = exceeded
+	ctx.Metrics.ObserveReadinessDuration(instanceGroup.NamespacedName(), elapsed)
+	ctx.Log.Info("instance group provisioning is ready", "instancegroup", instanceGroup.NamespacedName(), "duration", readiness.LastProvisioningDuration, "desired", desired)
+}
+
+// PublishBootstrapDiagnostics publishes the tail of the console output of instances which failed to join the cluster, so
+// bootstrap failures can be debugged without access to the instances. The console output is collected again at every
+// call and only published when it changed since it was last published for the instance. Diagnostics are best effort,
+// instances whose console output cannot be retrieved are logged and skipped
+func (ctx *EksInstanceGroupContext) PublishBootstrapDiagnostics(instanceIds []string) {
+	var (
+		state         = ctx.GetDiscoveredState()
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		published     = status.GetBootstrapDiagnostics()
+		diagnostics   = make(map[string]string)
+	)
+
+	// digests of instances which joined or were replaced are dropped
+	defer func() {
+		if len(diagnostics) == 0 {
+			diagnostics = nil
+		}
+		status.SetBootstrapDiagnostics(diagnostics)
+	}()
+
+	for _, id := range instanceIds {
+		if digest, ok := published[id]; ok {
+			diagnostics[id] = digest
+		}
+
+		output, err := ctx.AwsWorker.GetConsoleOutput(id)
+		if err != nil {
+			ctx.Log.Info("failed to get console output of instance which has not joined the cluster", "instancegroup", instanceGroup.NamespacedName(), "instance", id, "error", err)
+			continue
+		}
+
+		tail := consoleOutputTail(output, BootstrapDiagnosticsMaxLines, BootstrapDiagnosticsMaxBytes)
+		if tail == "" {
+			ctx.Log.Info("instance which has not joined the cluster has no console output", "instancegroup", instanceGroup.NamespacedName(), "instance", id)
+			continue
+		}
+		digest := common.StringMD5(tail)
+		if diagnostics[id] == digest {
+			continue
+		}
+		diagnostics[id] = digest
+		state.Publisher.Publish(kubeprovider.NodeBootstrapDiagnosticsEvent, "instancegroup", instanceGroup.NamespacedName(), "instance", id, "consoleOutput", tail)
+	}
+}
+
+// consoleOutputTail returns the last lines of console output, without carriage returns and trailing whitespace, and at
+// most maxBytes long
+func consoleOutputTail(output string, maxLines, maxBytes int) string {
+	output = strings.TrimRight(strings.ReplaceAll(output, "\r", ""), " \n\t")
+	if output == "" {
+		return ""
+	}
+
+	lines := strings.Split(output, "\n")
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	tail := strings.Join(lines, "\n")
+	if len(tail) > maxBytes {
+		tail = strings.ToValidUTF8(tail[len(tail)-maxBytes:], "")
+	}
+	return tail
+}
+
+// ReplaceFailedJoinInstances terminates instances which have failed to join the cluster when replacement is enabled,
+// the scaling group launches replacements since desired capacity is not decremented
+func (ctx *EksInstanceGroupContext) ReplaceFailedJoinInstances() error {
+	var (
+		state         = ctx.GetDiscoveredState()
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		deadline      = configuration.GetNodeJoinDeadline()
+		failed        = state.GetFailedJoinInstances()
+	)
+
+	if deadline == nil || !deadline.Replace || len(failed) == 0 {
+		return nil
+	}
+
+	ctx.Log.Info("terminating instances which have not joined the cluster", "instancegroup", instanceGroup.NamespacedName(), "instances", failed)
+	return ctx.AwsWorker.TerminateScalingInstances(failed)
+}
+
+// ReplaceNotReadyInstances drains and terminates instances whose nodes have not been ready beyond the threshold, the
+// scaling group launches replacements since desired capacity is not decremented. Instances which are not in service
+// and all NotReady nodes count as unavailable, replacements are deferred while more than maxUnavailable instances are
+// unavailable, e.g. during an outage of the cluster, instead of terminating the instances which might still recover
+func (ctx *EksInstanceGroupContext) ReplaceNotReadyInstances() error {
+	var (
+		state         = ctx.GetDiscoveredState()
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		replacement   = configuration.GetNotReadyReplacement()
+		scalingGroup  = state.GetScalingGroup()
+		notReady      = state.GetNotReadyInstances()
+	)
+
+	if replacement == nil || len(notReady) == 0 || scalingGroup == nil {
+		return nil
+	}
+
+	var (
+		unavailable int
+		inService   = make([]string, 0)
+	)
+	for _, instance := range scalingGroup.Instances {
+		if aws.StringValue(instance.LifecycleState) != autoscaling.LifecycleStateInService {
+			unavailable++
+			continue
+		}
+		inService = append(inService, aws.StringValue(instance.InstanceId))
+	}
+	unavailable += len(kubeprovider.GetNotReadyNodesByInstance(inService, state.GetClusterNodes(), 0))
+
+	maxUnavailable := replacement.GetMaxUnavailable(len(scalingGroup.Instances))
+	if unavailable > maxUnavailable {
+		ctx.Log.Info("deferring replacement of not ready instances, more than maxUnavailable instances are unavailable", "instancegroup", instanceGroup.NamespacedName(), "instances", notReady, "unavailable", unavailable, "maxunavailable", maxUnavailable)
+		return nil
+	}
+
+	drained := ctx.drainNotReadyInstances(notReady)
+	if len(drained) == 0 {
+		ctx.Log.Info("waiting for not ready nodes to drain", "instancegroup", instanceGroup.NamespacedName(), "instances", notReady)
+		return nil
+	}
+
+	state.Publisher.Publish(kubeprovider.NodesNotReadyReplacedEvent, "instancegroup", instanceGroup.NamespacedName(), "instances", strings.Join(drained, ","), "threshold", replacement.Threshold)
+	ctx.Log.Info("terminating instances whose nodes have not been ready beyond the threshold", "instancegroup", instanceGroup.NamespacedName(), "instances", drained, "threshold", replacement.Threshold)
+	return ctx.AwsWorker.TerminateScalingInstances(drained)
+}
+
+// drainNotReadyInstances drains the nodes of NotReady instances with the drain spec of the upgrade strategy and returns
+// the instances which are ready to be terminated. Terminating pods are not waited on since the kubelet of a NotReady
+// node cannot confirm their termination
+func (ctx *EksInstanceGroupContext) drainNotReadyInstances(instanceIds []string) []string {
+	opts := ctx.GetDrainOptions()
+	opts.IgnoreTerminating = true
+	return ctx.drainInstances(instanceIds, opts)
+}
+
+// GetDrainOptions returns the drain options of the drain spec of the rolling update or blue/green strategy, nodes are
+// drained with the default options if neither has a drain spec
+func (ctx *EksInstanceGroupContext) GetDrainOptions() *kubeprovider.DrainOptions {
+	var (
+		strategy = ctx.GetInstanceGroup().GetUpgradeStrategy()
+		opts     = &kubeprovider.DrainOptions{}
+		drain    *v1alpha1.DrainSpec
+	)
+
+	if rollingUpdate := strategy.GetRollingUpdateType(); rollingUpdate != nil {
+		drain = rollingUpdate.GetDrain()
+	}
+	if drain == nil {
+		drain = strategy.GetBlueGreenType().GetDrain()
+	}
+	if drain != nil {
+		opts.EvictDaemonSets = drain.GetEvictDaemonSets()
+		opts.Timeout = drain.GetTimeout()
+		opts.Force = drain.GetForce()
+		opts.PriorityOrdering = drain.GetPriorityOrdering()
+	}
+	return opts
+}
+
+// drainInstances drains the nodes of instances and returns the instances which are ready to be terminated, instances
+// without a node have nothing to drain. Nodes whose drain timed out are uncordoned and skipped until the next reconcile
+// rather than failing it
+func (ctx *EksInstanceGroupContext) drainInstances(instanceIds []string, opts *kubeprovider.DrainOptions) []string {
+	var (
+		state         = ctx.GetDiscoveredState()
+		instanceGroup = ctx.GetInstanceGroup()
+		nodeNames     = make(map[string]string)
+	)
+
+	if nodes := state.GetClusterNodes(); nodes != nil {
+		for _, node := range nodes.Items {
+			nodeNames[common.GetLastElementBy(node.Spec.ProviderID, "/")] = node.GetName()
+		}
+	}
+
+	drained := make([]string, 0)
+	for _, instanceId := range instanceIds {
+		nodeName, ok := nodeNames[instanceId]
+		if !ok {
+			drained = append(drained, instanceId)
+			continue
+		}
+		ok, err := ctx.KubernetesClient.DrainNode(nodeName, opts)
+		if _, timedOut := err.(*kubeprovider.DrainTimeoutError); timedOut {
+			ctx.Log.Info("drain of node timed out, skipping node", "error", err, "instancegroup", instanceGroup.NamespacedName(), "node", nodeName)
+			continue
+		}
+		if err != nil {
+			// drain failures are retryable
+			ctx.Log.Info("failed to drain node", "error", err, "instancegroup", instanceGroup.NamespacedName(), "node", nodeName)
+			continue
+		}
+		if ok {
+			drained = append(drained, instanceId)
+		}
+	}
+	return drained
+}
+
+// ClearUnhealthyInstanceProtection clears the scale in protection of unhealthy instances when the policy is
+// clear-protection, so the scaling group can replace them
+func (ctx *EksInstanceGroupContext) ClearUnhealthyInstanceProtection() error {
+	var (
+		state         = ctx.GetDiscoveredState()
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		unhealthy     = state.GetUnhealthyProtectedInstances()
+		groups        = []*autoscaling.Group{state.GetScalingGroup()}
+	)
+
+	if len(unhealthy) == 0 || configuration.GetUnhealthyProtectedPolicy() != v1alpha1.UnhealthyProtectedPolicyClearProtection {
+		return nil
+	}
+
+	// instances of zone sharded instance groups are cleared in the scaling group of their zone
+	if configuration.IsZoneSharded() {
+		groups = make([]*autoscaling.Group, 0)
+		for _, group := range state.GetZoneScalingGroups() {
+			groups = append(groups, group)
+		}
+	}
+
+	for _, group := range groups {
+		instanceIds := make([]string, 0)
+		for _, instance := range group.Instances {
+			if common.ContainsString(unhealthy, aws.StringValue(instance.InstanceId)) {
+				instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+			}
+		}
+		if len(instanceIds) == 0 {
+			continue
+		}
+		asgName := aws.StringValue(group.AutoScalingGroupName)
+		ctx.Log.Info("clearing scale in protection of unhealthy instances", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName, "instances", instanceIds)
+		if err := ctx.AwsWorker.SetInstancesProtection(asgName, instanceIds, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getDesiredMetrics returns the scaling group metrics selected by metricsCollection, and the selected names which are
+// not scaling group metrics
+func (ctx *EksInstanceGroupContext) getDesiredMetrics() ([]string, []string) {
+	var (
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		metrics       = configuration.GetMetricsCollection()
+		desired       = make([]string, 0)
+		ignored       = make([]string, 0)
 	)
 
 	// handle 'all' metrics provided
 	if common.ContainsEqualFold(metrics, "all") {
-		desiredMetrics = awsprovider.DefaultAutoscalingMetrics
-	} else {
-		desiredMetrics = metrics
+		return awsprovider.DefaultAutoscalingMetrics, ignored
 	}
+	for _, m := range metrics {
+		if !common.ContainsString(awsprovider.DefaultAutoscalingMetrics, m) {
+			ignored = append(ignored, m)
+			continue
+		}
+		desired = append(desired, m)
+	}
+	return desired, ignored
+}
+
+func (ctx *EksInstanceGroupContext) GetEnabledMetrics() ([]string, bool) {
+	var (
+		state             = ctx.GetDiscoveredState()
+		scalingGroup      = state.GetScalingGroup()
+		enableMetrics     = make([]string, 0)
+		enabledMetrics    = make([]string, 0)
+		desiredMetrics, _ = ctx.getDesiredMetrics()
+	)
 
 	// get all already enabled metrics
 	for _, m := range scalingGroup.EnabledMetrics {
@@ -861,22 +2915,12 @@ func (ctx *EksInstanceGroupContext) GetEnabledMetrics() ([]string, bool) {
 
 func (ctx *EksInstanceGroupContext) GetDisabledMetrics() ([]string, bool) {
 	var (
-		instanceGroup   = ctx.GetInstanceGroup()
-		configuration   = instanceGroup.GetEKSConfiguration()
-		metrics         = configuration.GetMetricsCollection()
-		state           = ctx.GetDiscoveredState()
-		scalingGroup    = state.GetScalingGroup()
-		disabledMetrics = make([]string, 0)
-		desiredMetrics  []string
+		state             = ctx.GetDiscoveredState()
+		scalingGroup      = state.GetScalingGroup()
+		disabledMetrics   = make([]string, 0)
+		desiredMetrics, _ = ctx.getDesiredMetrics()
 	)
 
-	// handle 'all' metrics provided
-	if common.ContainsEqualFold(metrics, "all") {
-		desiredMetrics = awsprovider.DefaultAutoscalingMetrics
-	} else {
-		desiredMetrics = metrics
-	}
-
 	// find metrics that need to be disabled
 	for _, m := range scalingGroup.EnabledMetrics {
 		metricName := aws.StringValue(m.Metric)
@@ -895,8 +2939,14 @@ func (ctx *EksInstanceGroupContext) GetDisabledMetrics() ([]string, bool) {
 func (ctx *EksInstanceGroupContext) UpdateMetricsCollection(asgName string) error {
 	var (
 		instanceGroup = ctx.GetInstanceGroup()
+		state         = ctx.GetDiscoveredState()
 	)
 
+	if _, ignored := ctx.getDesiredMetrics(); len(ignored) > 0 {
+		ctx.Log.Info("metricsCollection entries are not scaling group metrics and are not collected", "instancegroup", instanceGroup.NamespacedName(), "metrics", ignored)
+		state.Publisher.Publish(kubeprovider.MetricsIgnoredEvent, "instancegroup", instanceGroup.NamespacedName(), "metrics", strings.Join(ignored, ","))
+	}
+
 	if metrics, ok := ctx.GetDisabledMetrics(); ok {
 		if err := ctx.AwsWorker.DisableMetrics(asgName, metrics); err != nil {
 			return errors.Wrapf(err, "failed to disable metrics %v", metrics)
@@ -1088,12 +3138,58 @@ func (ctx *EksInstanceGroupContext) UpdateLifecycleHooks(asgName string) error {
 	return nil
 }
 
-func (ctx *EksInstanceGroupContext) GetManagedPoliciesList(additionalPolicies []string) []string {
+// IsIRSAEnabled returns true if the instance group is annotated that its pods use IAM roles for service accounts
+func (ctx *EksInstanceGroupContext) IsIRSAEnabled() bool {
 	var (
 		instanceGroup = ctx.GetInstanceGroup()
 		annotations   = instanceGroup.GetAnnotations()
 	)
+	return strings.EqualFold(annotations[IRSAEnabledAnnotation], "true")
+}
+
+// IsCNIPolicyRequired returns true if the VPC CNI on the group's nodes assigns pod IPs with the node role's credentials,
+// other CNI plugins and the VPC CNI with IRSA do not need the CNI managed policy on the node role
+func (ctx *EksInstanceGroupContext) IsCNIPolicyRequired() bool {
+	var configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+	return strings.EqualFold(configuration.GetCNIPlugin(), v1alpha1.CNIPluginAwsVpcCNI) && !ctx.IsIRSAEnabled()
+}
+
+// GetMetadataOptions returns the instance metadata options instances are launched with, or nil if metadataOptions is
+// not set so that launch templates keep the EC2 defaults. Options which are not set are defaulted. Without IRSA pods
+// need a hop limit of 2 to reach the metadata service for their node's credentials, with IRSA only host agents need it
+// and the hop limit is 1, except on windows where pods still reach it through the host
+func (ctx *EksInstanceGroupContext) GetMetadataOptions() *v1alpha1.MetadataOptions {
+	configured := ctx.GetInstanceGroup().GetEKSConfiguration().GetMetadataOptions()
+	if configured == nil {
+		return nil
+	}
+
+	options := configured.DeepCopy()
+	if options.HttpEndpoint == "" {
+		options.HttpEndpoint = v1alpha1.MetadataHttpEndpointEnabled
+	}
+	if options.HttpTokens == "" {
+		options.HttpTokens = v1alpha1.MetadataHttpTokensOptional
+	}
+	if options.HttpPutHopLimit == 0 {
+		options.HttpPutHopLimit = DefaultPodMetadataHopLimit
+		if ctx.IsIRSAEnabled() && !strings.EqualFold(ctx.GetOsFamily(), OsFamilyWindows) {
+			options.HttpPutHopLimit = DefaultMetadataHopLimit
+		}
+	}
+	return options
+}
+
+// GetDefaultManagedPolicies returns the managed policies configured for the instance group's OS family, or the default
+// managed policies if none are configured
+func (ctx *EksInstanceGroupContext) GetDefaultManagedPolicies() []string {
+	if policies := ctx.OsFamilyManagedPolicies.Get(ctx.GetOsFamily()); len(policies) > 0 {
+		return policies
+	}
+	return DefaultManagedPolicies
+}
 
+func (ctx *EksInstanceGroupContext) GetManagedPoliciesList(additionalPolicies []string) []string {
 	managedPolicies := make([]string, 0)
 	for _, name := range additionalPolicies {
 		switch {
@@ -1104,44 +3200,52 @@ func (ctx *EksInstanceGroupContext) GetManagedPoliciesList(additionalPolicies []
 		}
 	}
 
-	var irsaEnabled bool
-	if val, ok := annotations[IRSAEnabledAnnotation]; ok {
-		if strings.EqualFold(val, "true") {
-			irsaEnabled = true
+	for _, name := range ctx.GetDefaultManagedPolicies() {
+		switch {
+		case arn.IsARN(name):
+			managedPolicies = append(managedPolicies, name)
+		default:
+			managedPolicies = append(managedPolicies, fmt.Sprintf("%s/%s", awsprovider.IAMPolicyPrefix, name))
 		}
 	}
 
-	for _, name := range DefaultManagedPolicies {
-		managedPolicies = append(managedPolicies, fmt.Sprintf("%s/%s", awsprovider.IAMPolicyPrefix, name))
-	}
-
-	if !irsaEnabled {
+	if ctx.IsCNIPolicyRequired() {
 		managedPolicies = append(managedPolicies, fmt.Sprintf("%s/%s", awsprovider.IAMPolicyPrefix, CNIManagedPolicy))
 	}
 
 	return managedPolicies
 }
 
-func (ctx *EksInstanceGroupContext) RemoveAuthRole(arn string) error {
-	ctx.Lock()
-	defer ctx.Unlock()
-
-	var instanceGroup = ctx.GetInstanceGroup()
-	var osFamily = ctx.GetOsFamily()
+// getSharedRoleGroups returns the names of the instance groups whose status has the role arn as nodesInstanceRoleArn
+func (ctx *EksInstanceGroupContext) getSharedRoleGroups(arn string) ([]string, error) {
 	list, err := ctx.KubernetesClient.KubeDynamic.Resource(v1alpha1.GroupVersionResource).List(context.Background(), metav1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// find objects which share the same nodesInstanceRoleArn
 	var sharedGroups = make([]string, 0)
 	for _, obj := range list.Items {
 		if val, ok, _ := unstructured.NestedString(obj.Object, "status", "nodesInstanceRoleArn"); ok {
 			if strings.EqualFold(arn, val) {
-				sharedGroups = append(sharedGroups, obj.GetName())
+				sharedGroups = append(sharedGroups, fmt.Sprintf("%v/%v", obj.GetNamespace(), obj.GetName()))
 			}
 		}
 	}
+	return sharedGroups, nil
+}
+
+func (ctx *EksInstanceGroupContext) RemoveAuthRole(arn string) error {
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	var instanceGroup = ctx.GetInstanceGroup()
+	var osFamily = ctx.GetOsFamily()
+
+	// find objects which share the same nodesInstanceRoleArn
+	sharedGroups, err := ctx.getSharedRoleGroups(arn)
+	if err != nil {
+		return err
+	}
 
 	// If there are other instance groups using the same role we should not remove it from aws-auth
 	if len(sharedGroups) > 1 {
@@ -1154,6 +3258,13 @@ func (ctx *EksInstanceGroupContext) RemoveAuthRole(arn string) error {
 		return nil
 	}
 
+	if configuration := instanceGroup.GetEKSConfiguration(); strings.EqualFold(configuration.GetNodeAuthentication(), v1alpha1.NodeAuthenticationAccessEntry) {
+		if arn == "" {
+			return nil
+		}
+		return ctx.AwsWorker.DeleteAccessEntry(configuration.GetClusterName(), arn)
+	}
+
 	return common.RemoveAuthConfigMap(ctx.KubernetesClient.Kubernetes, []string{arn}, []string{osFamily})
 }
 
@@ -1259,7 +3370,7 @@ func (ctx *EksInstanceGroupContext) GetDesiredMixedInstancesPolicy(name string)
 		LaunchTemplate: &autoscaling.LaunchTemplate{
 			LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
 				LaunchTemplateName: aws.String(name),
-				Version:            aws.String(awsprovider.LaunchTemplateLatestVersionKey),
+				Version:            aws.String(ctx.GetDesiredLaunchTemplateVersion()),
 			},
 			Overrides: overrides,
 		},
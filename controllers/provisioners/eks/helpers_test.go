@@ -16,20 +16,35 @@ limitations under the License.
 package eks
 
 import (
+	"context"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/keikoproj/instance-manager/controllers/common"
 	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
+	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 	"github.com/onsi/gomega"
 	"github.com/pkg/errors"
 )
@@ -288,6 +303,7 @@ spec:
     flags:
       - --node-labels=foo=bar,instancemgr.keikoproj.io/image=ami-123456789012,node.kubernetes.io/role=instance-group-1
       - --register-with-taints=foo=bar:NoSchedule
+      - --max-pods=4
 
 --BOUNDARY
 Content-Type: text/x-shellscript; charset="us-ascii"
@@ -647,11 +663,12 @@ func TestResolveSubnets(t *testing.T) {
 	}
 }
 
-func TestGetDisabledMetrics(t *testing.T) {
+func TestResolveNetworkInterfaces(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
 		k       = MockKubernetesClientSet()
 		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
 		asgMock = NewAutoScalingMocker()
 		iamMock = NewIamMocker()
 		eksMock = NewEksMocker()
@@ -661,58 +678,66 @@ func TestGetDisabledMetrics(t *testing.T) {
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
+	ctx.GetDiscoveredState().SetVPCId("vpc-1111")
+
+	inVpc := MockSubnet("subnet-111", true, "my-subnet-1")
+	inVpc.VpcId = aws.String("vpc-1111")
+	inVpc.AvailabilityZone = aws.String("us-west-2a")
+	otherVpc := MockSubnet("subnet-222", false, "")
+	otherVpc.VpcId = aws.String("vpc-2222")
+	sameZone := MockSubnet("subnet-444", false, "")
+	sameZone.AvailabilityZone = aws.String("us-west-2a")
+	otherZone := MockSubnet("subnet-555", false, "")
+	otherZone.AvailabilityZone = aws.String("us-west-2b")
+	ec2Mock.Subnets = []*ec2.Subnet{inVpc, otherVpc, sameZone, otherZone}
+	config.Subnets = []string{"subnet-444"}
+	ec2Mock.SecurityGroups = []*ec2.SecurityGroup{MockSecurityGroup("sg-111", true, "my-sg-1")}
+	config.NodeSecurityGroups = []string{"sg-000"}
 
-	// No disable required
-	ig.GetEKSConfiguration().SetMetricsCollection([]string{"all"})
-	ctx.SetDiscoveredState(&DiscoveredState{
-		Publisher: kubeprovider.EventPublisher{
-			Client: k.Kubernetes,
-		},
-		ScalingGroup: &autoscaling.Group{
-			EnabledMetrics: MockEnabledMetrics("GroupMinSize"),
-		},
-	})
-
-	metrics, ok := ctx.GetDisabledMetrics()
-	g.Expect(ok).To(gomega.BeFalse())
-	g.Expect(metrics).To(gomega.BeEmpty())
-
-	// Disable all metrics
-	ig.GetEKSConfiguration().SetMetricsCollection([]string{})
-	ctx.SetDiscoveredState(&DiscoveredState{
-		Publisher: kubeprovider.EventPublisher{
-			Client: k.Kubernetes,
-		},
-		ScalingGroup: &autoscaling.Group{
-			EnabledMetrics: MockEnabledMetrics(awsprovider.DefaultAutoscalingMetrics...),
+	tests := []struct {
+		requested []v1alpha1.NetworkInterfaceSpec
+		result    []scaling.NetworkInterfaceInput
+		withErr   bool
+	}{
+		{requested: nil, result: []scaling.NetworkInterfaceInput{}},
+		{
+			requested: []v1alpha1.NetworkInterfaceSpec{{DeviceIndex: 2, Subnet: "my-subnet-1", InterfaceType: "efa"}, {DeviceIndex: 1, Subnet: "subnet-111", SecurityGroups: []string{"my-sg-1"}}},
+			result: []scaling.NetworkInterfaceInput{
+				{DeviceIndex: 1, SubnetId: "subnet-111", SecurityGroups: []string{"sg-111"}},
+				{DeviceIndex: 2, SubnetId: "subnet-111", SecurityGroups: []string{"sg-000"}, InterfaceType: "efa"},
+			},
 		},
-	})
-
-	metrics, ok = ctx.GetDisabledMetrics()
-	g.Expect(ok).To(gomega.BeTrue())
-	g.Expect(metrics).To(gomega.ConsistOf(awsprovider.DefaultAutoscalingMetrics))
+		{requested: []v1alpha1.NetworkInterfaceSpec{{DeviceIndex: 1, Subnet: "subnet-222"}}, withErr: true},
+		{requested: []v1alpha1.NetworkInterfaceSpec{{DeviceIndex: 1, Subnet: "subnet-333"}}, withErr: true},
+		{requested: []v1alpha1.NetworkInterfaceSpec{{DeviceIndex: 1, Subnet: "my-subnet-2"}}, withErr: true},
+		{requested: []v1alpha1.NetworkInterfaceSpec{{DeviceIndex: 1, Subnet: "subnet-111", SecurityGroups: []string{"my-sg-2"}}}, withErr: true},
+	}
 
-	// Disable specific metric
-	ig.GetEKSConfiguration().SetMetricsCollection([]string{"GroupMinSize"})
-	ctx.SetDiscoveredState(&DiscoveredState{
-		Publisher: kubeprovider.EventPublisher{
-			Client: k.Kubernetes,
-		},
-		ScalingGroup: &autoscaling.Group{
-			EnabledMetrics: MockEnabledMetrics("GroupMinSize", "GroupMaxSize"),
-		},
-	})
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		config.NetworkInterfaces = tc.requested
+		interfaces, err := ctx.ResolveNetworkInterfaces()
+		if tc.withErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(interfaces).To(gomega.Equal(tc.result))
+	}
 
-	metrics, ok = ctx.GetDisabledMetrics()
-	g.Expect(ok).To(gomega.BeTrue())
-	g.Expect(metrics).To(gomega.ContainElement("GroupMaxSize"))
+	// interfaces are pinned to their subnet's availability zone, which instance groups across zones cannot launch in
+	config.Subnets = []string{"subnet-444", "subnet-555"}
+	config.NetworkInterfaces = []v1alpha1.NetworkInterfaceSpec{{DeviceIndex: 1, Subnet: "subnet-111"}}
+	_, err := ctx.ResolveNetworkInterfaces()
+	g.Expect(err).To(gomega.HaveOccurred())
 }
 
-func TestGetEnabledMetrics(t *testing.T) {
+func TestValidateNetworkInterfaces(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
 		k       = MockKubernetesClientSet()
 		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
 		asgMock = NewAutoScalingMocker()
 		iamMock = NewIamMocker()
 		eksMock = NewEksMocker()
@@ -722,223 +747,2729 @@ func TestGetEnabledMetrics(t *testing.T) {
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
-
-	// Enable all metrics
-	ig.GetEKSConfiguration().SetMetricsCollection([]string{"all"})
-	ctx.SetDiscoveredState(&DiscoveredState{
-		Publisher: kubeprovider.EventPublisher{
-			Client: k.Kubernetes,
+	ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
+		{
+			InstanceType: aws.String("p4d.24xlarge"),
+			NetworkInfo: &ec2.NetworkInfo{
+				EfaSupported: aws.Bool(true),
+				EfaInfo:      &ec2.EfaInfo{MaximumEfaInterfaces: aws.Int64(2)},
+			},
 		},
-		ScalingGroup: &autoscaling.Group{
-			EnabledMetrics: MockEnabledMetrics(),
+		{
+			InstanceType: aws.String("m5.xlarge"),
+			NetworkInfo:  &ec2.NetworkInfo{EfaSupported: aws.Bool(false)},
 		},
 	})
 
-	metrics, ok := ctx.GetEnabledMetrics()
-	g.Expect(ok).To(gomega.BeTrue())
-	g.Expect(metrics).To(gomega.ConsistOf(awsprovider.DefaultAutoscalingMetrics))
+	allowAll := func(groupId string) []*ec2.IpPermission {
+		return []*ec2.IpPermission{
+			{IpProtocol: aws.String("-1"), UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String(groupId)}}},
+		}
+	}
+	selfReferencing := MockSecurityGroup("sg-111", true, "efa")
+	selfReferencing.IpPermissions = allowAll("sg-111")
+	selfReferencing.IpPermissionsEgress = allowAll("sg-111")
+	ingressOnly := MockSecurityGroup("sg-222", true, "ingress-only")
+	ingressOnly.IpPermissions = allowAll("sg-222")
+	ingressOnly.IpPermissionsEgress = []*ec2.IpPermission{{IpProtocol: aws.String("tcp"), UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String("sg-222")}}}}
+
+	efa := func(deviceIndex int64) scaling.NetworkInterfaceInput {
+		return scaling.NetworkInterfaceInput{DeviceIndex: deviceIndex, SubnetId: "subnet-111", SecurityGroups: []string{"sg-111"}, InterfaceType: v1alpha1.NetworkInterfaceTypeEFA}
+	}
 
-	// Enable specific metric
-	ig.GetEKSConfiguration().SetMetricsCollection([]string{"GroupMinSize", "GroupMaxSize"})
-	ctx.SetDiscoveredState(&DiscoveredState{
-		Publisher: kubeprovider.EventPublisher{
-			Client: k.Kubernetes,
-		},
-		ScalingGroup: &autoscaling.Group{
-			EnabledMetrics: MockEnabledMetrics("GroupMinSize"),
-		},
-	})
+	tests := []struct {
+		instanceType   string
+		interfaces     []scaling.NetworkInterfaceInput
+		securityGroups []*ec2.SecurityGroup
+		withErr        bool
+	}{
+		// interfaces without efa are not validated
+		{instanceType: "m5.xlarge", interfaces: []scaling.NetworkInterfaceInput{{DeviceIndex: 1, SubnetId: "subnet-111"}}},
+		{instanceType: "p4d.24xlarge", interfaces: []scaling.NetworkInterfaceInput{efa(1)}, securityGroups: []*ec2.SecurityGroup{selfReferencing}},
+		{instanceType: "p4d.24xlarge", interfaces: []scaling.NetworkInterfaceInput{efa(1), efa(2)}, securityGroups: []*ec2.SecurityGroup{selfReferencing}},
+		// instance types without efa support, or with fewer efa interfaces, are rejected
+		{instanceType: "m5.xlarge", interfaces: []scaling.NetworkInterfaceInput{efa(1)}, securityGroups: []*ec2.SecurityGroup{selfReferencing}, withErr: true},
+		{instanceType: "c5.xlarge", interfaces: []scaling.NetworkInterfaceInput{efa(1)}, securityGroups: []*ec2.SecurityGroup{selfReferencing}, withErr: true},
+		{instanceType: "p4d.24xlarge", interfaces: []scaling.NetworkInterfaceInput{efa(1), efa(2), efa(3)}, securityGroups: []*ec2.SecurityGroup{selfReferencing}, withErr: true},
+		// security groups must allow all traffic from and to themselves
+		{instanceType: "p4d.24xlarge", interfaces: []scaling.NetworkInterfaceInput{efa(1)}, securityGroups: []*ec2.SecurityGroup{ingressOnly}, withErr: true},
+		{instanceType: "p4d.24xlarge", interfaces: []scaling.NetworkInterfaceInput{efa(1)}, securityGroups: []*ec2.SecurityGroup{MockSecurityGroup("sg-111", true, "efa")}, withErr: true},
+	}
 
-	metrics, ok = ctx.GetEnabledMetrics()
-	g.Expect(ok).To(gomega.BeTrue())
-	g.Expect(metrics).To(gomega.ContainElement("GroupMaxSize"))
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		config.InstanceType = tc.instanceType
+		ec2Mock.SecurityGroups = tc.securityGroups
+		err := ctx.ValidateNetworkInterfaces(tc.interfaces)
+		if tc.withErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
 }
 
-func TestGetLabelList(t *testing.T) {
+func TestWarnPublicIpPrivateSubnets(t *testing.T) {
 	var (
-		g                          = gomega.NewGomegaWithT(t)
-		k                          = MockKubernetesClientSet()
-		ig                         = MockInstanceGroup()
-		configuration              = ig.GetEKSConfiguration()
-		status                     = ig.GetStatus()
-		asgMock                    = NewAutoScalingMocker()
-		iamMock                    = NewIamMocker()
-		eksMock                    = NewEksMocker()
-		ec2Mock                    = NewEc2Mocker()
-		ssmMock                    = NewSsmMocker()
-		defaultLifecycleLabel      = "instancemgr.keikoproj.io/lifecycle=normal"
-		defaultImageLabel          = fmt.Sprintf("instancemgr.keikoproj.io/image=%v", configuration.GetImage())
-		expectedLabels115          = []string{defaultImageLabel, defaultLifecycleLabel, "node-role.kubernetes.io/instance-group-1=\"\"", "node.kubernetes.io/role=instance-group-1"}
-		expectedLabels116          = []string{defaultImageLabel, defaultLifecycleLabel, "node.kubernetes.io/role=instance-group-1"}
-		expectedLabelsWithCustom   = []string{defaultImageLabel, defaultLifecycleLabel, "custom.kubernetes.io=customlabel", "node.kubernetes.io/role=instance-group-1"}
-		expectedLabelsWithOverride = []string{defaultImageLabel, defaultLifecycleLabel, "custom.kubernetes.io=customlabel", "override.kubernetes.io=instance-group-1", "override2.kubernetes.io=instance-group-1"}
-		overrideAnnotation         = map[string]string{OverrideDefaultLabelsAnnotation: "override.kubernetes.io=instance-group-1,override2.kubernetes.io=instance-group-1"}
-		expectedSpotLabel          = []string{defaultImageLabel, "instancemgr.keikoproj.io/lifecycle=spot", "node-role.kubernetes.io/instance-group-1=\"\"", "node.kubernetes.io/role=instance-group-1"}
-		expectedMixedLabel         = []string{defaultImageLabel, "instancemgr.keikoproj.io/lifecycle=mixed", "node-role.kubernetes.io/instance-group-1=\"\"", "node.kubernetes.io/role=instance-group-1"}
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
 	)
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
+	ctx.GetDiscoveredState().Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
+	config.Subnets = []string{"subnet-111", "subnet-222", "subnet-333"}
 
 	tests := []struct {
-		clusterVersion           string
-		instanceGroupLabels      map[string]string
-		instanceGroupAnnotations map[string]string
-		expectedLabels           []string
-		withSpot                 bool
-		withMixedInstances       bool
+		associatePublicIp *bool
+		routeTables       []*ec2.RouteTable
+		expectedWarning   string
 	}{
-		{clusterVersion: "", withSpot: true, expectedLabels: expectedSpotLabel},
-		{clusterVersion: "", withMixedInstances: true, expectedLabels: expectedMixedLabel},
-		// Default labels with missing cluster version
-		{clusterVersion: "", expectedLabels: expectedLabels115},
-		// Kubernetes 1.15 default labels
-		{clusterVersion: "1.15", expectedLabels: expectedLabels115},
-		// Kubernetes 1.16 default labels
-		{clusterVersion: "1.16", expectedLabels: expectedLabels116},
-		// Kubernetes 1.16 default labels with custom labels
-		{clusterVersion: "1.16", instanceGroupLabels: map[string]string{"custom.kubernetes.io": "customlabel"}, expectedLabels: expectedLabelsWithCustom},
-		// custom labels with override labels
-		{clusterVersion: "1.16", instanceGroupAnnotations: overrideAnnotation, instanceGroupLabels: map[string]string{"custom.kubernetes.io": "customlabel"}, expectedLabels: expectedLabelsWithOverride},
+		// public IPs not requested
+		{associatePublicIp: nil, routeTables: []*ec2.RouteTable{MockRouteTable(true, false)}},
+		{associatePublicIp: aws.Bool(false), routeTables: []*ec2.RouteTable{MockRouteTable(true, false)}},
+		// all subnets route to an internet gateway
+		{associatePublicIp: aws.Bool(true), routeTables: []*ec2.RouteTable{MockRouteTable(true, true)}},
+		{associatePublicIp: aws.Bool(true), routeTables: []*ec2.RouteTable{MockRouteTable(true, false), MockRouteTable(false, true, "subnet-111", "subnet-222", "subnet-333")}},
+		// subnets without an explicit association use the main route table
+		{associatePublicIp: aws.Bool(true), routeTables: []*ec2.RouteTable{MockRouteTable(true, false), MockRouteTable(false, true, "subnet-111")}, expectedWarning: "subnet-222,subnet-333"},
+		{associatePublicIp: aws.Bool(true), routeTables: []*ec2.RouteTable{MockRouteTable(true, true), MockRouteTable(false, false, "subnet-333")}, expectedWarning: "subnet-333"},
 	}
 
 	for i, tc := range tests {
 		t.Logf("Test #%v - %+v", i, tc)
-		configuration.SetLabels(tc.instanceGroupLabels)
-		ig.SetAnnotations(tc.instanceGroupAnnotations)
-		status.SetLifecycle(v1alpha1.LifecycleStateNormal)
-		if tc.withSpot {
-			status.SetLifecycle(v1alpha1.LifecycleStateSpot)
-		} else if tc.withMixedInstances {
-			status.SetLifecycle(v1alpha1.LifecycleStateMixed)
+		config.AssociatePublicIpAddress = tc.associatePublicIp
+		ec2Mock.RouteTables = tc.routeTables
+		ctx.WarnPublicIpPrivateSubnets()
+
+		events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		var warnings []string
+		for _, e := range events.Items {
+			if e.Reason == string(kubeprovider.PublicIpPrivateSubnetEvent) {
+				warnings = append(warnings, e.Message)
+				k.Kubernetes.CoreV1().Events(e.Namespace).Delete(context.Background(), e.Name, metav1.DeleteOptions{})
+			}
 		}
-		ctx.SetDiscoveredState(&DiscoveredState{
-			Publisher: kubeprovider.EventPublisher{
-				Client: k.Kubernetes,
-			},
-			Cluster: MockEksCluster(tc.clusterVersion),
-		})
-		sort.Strings(tc.expectedLabels)
-		labels := ctx.GetLabelList()
-		g.Expect(labels).To(gomega.Equal(tc.expectedLabels))
+		if tc.expectedWarning == "" {
+			g.Expect(warnings).To(gomega.BeEmpty())
+			continue
+		}
+		g.Expect(warnings).To(gomega.HaveLen(1))
+		g.Expect(warnings[0]).To(gomega.ContainSubstring(tc.expectedWarning))
 	}
 }
 
-func TestGetMountOpts(t *testing.T) {
+func TestReservedTags(t *testing.T) {
 	var (
-		g             = gomega.NewGomegaWithT(t)
-		k             = MockKubernetesClientSet()
-		ig            = MockInstanceGroup()
-		configuration = ig.GetEKSConfiguration()
-		asgMock       = NewAutoScalingMocker()
-		iamMock       = NewIamMocker()
-		eksMock       = NewEksMocker()
-		ec2Mock       = NewEc2Mocker()
-		ssmMock       = NewSsmMocker()
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
 	)
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
 
-	volumeNoOpts := v1alpha1.NodeVolume{
-		Name:                "/dev/xvda1",
-		Type:                "gp2",
-		Size:                100,
-		DeleteOnTermination: aws.Bool(true),
-		Encrypted:           aws.Bool(true),
+	warnings := func() []string {
+		events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		var messages []string
+		for _, e := range events.Items {
+			if e.Reason == string(kubeprovider.ReservedTagsIgnoredEvent) {
+				messages = append(messages, e.Message)
+				k.Kubernetes.CoreV1().Events(e.Namespace).Delete(context.Background(), e.Name, metav1.DeleteOptions{})
+			}
+		}
+		return messages
 	}
 
-	volumeWithOpts := v1alpha1.NodeVolume{
-		Name:                "/dev/xvda2",
-		Type:                "gp2",
-		Size:                100,
-		DeleteOnTermination: aws.Bool(true),
-		Encrypted:           aws.Bool(true),
-		MountOptions: &v1alpha1.NodeVolumeMountOptions{
-			FileSystem:  "xfs",
-			Mount:       "/data",
-			Persistance: aws.Bool(false),
-		},
+	// no warning without reserved tags
+	config.Tags = []map[string]string{{"key": "team", "value": "platform"}}
+	ctx.WarnReservedTags()
+	g.Expect(warnings()).To(gomega.BeEmpty())
+
+	// reserved tags are not propagated to the scaling group and a warning is published
+	config.Tags = []map[string]string{{"key": "team", "value": "platform"}, {"key": "aws:cloudformation:stack-name", "value": "my-stack"}}
+	ctx.WarnReservedTags()
+	messages := warnings()
+	g.Expect(messages).To(gomega.HaveLen(1))
+	g.Expect(messages[0]).To(gomega.ContainSubstring("aws:cloudformation:stack-name"))
+	g.Expect(ctx.GetCustomTags()).To(gomega.Equal([]map[string]string{{"key": "team", "value": "platform"}}))
+
+	asg := MockScalingGroup("asg-1", false)
+	state.SetScalingGroup(asg)
+	added := ctx.GetAddedTags("asg-1")
+	g.Expect(added).NotTo(gomega.BeEmpty())
+	for _, tag := range added {
+		g.Expect(aws.StringValue(tag.Key)).NotTo(gomega.HavePrefix("aws:"))
 	}
 
-	volumeWithOpts2 := v1alpha1.NodeVolume{
-		Name:                "/dev/xvda3",
-		Type:                "gp2",
-		Size:                200,
-		DeleteOnTermination: aws.Bool(true),
-		Encrypted:           aws.Bool(true),
-		MountOptions: &v1alpha1.NodeVolumeMountOptions{
-			FileSystem: "xfs",
-			Mount:      "/data2",
-		},
+	// reserved tags created by AWS on the scaling group are not removed
+	asg.Tags = []*autoscaling.TagDescription{{Key: aws.String("aws:cloudformation:stack-name"), Value: aws.String("my-stack")}}
+	for _, tag := range added {
+		asg.Tags = append(asg.Tags, &autoscaling.TagDescription{Key: tag.Key, Value: tag.Value})
 	}
+	g.Expect(ctx.GetRemovedTags("asg-1")).To(gomega.BeEmpty())
+}
 
-	volumeInvalidOpts := v1alpha1.NodeVolume{
-		Name:                "/dev/xvda2",
-		Type:                "gp2",
-		Size:                100,
-		DeleteOnTermination: aws.Bool(true),
-		Encrypted:           aws.Bool(true),
-		MountOptions: &v1alpha1.NodeVolumeMountOptions{
-			FileSystem:  "ext3",
-			Mount:       "/data",
-			Persistance: aws.Bool(true),
-		},
+func TestIAMTags(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
 	}
 
-	volumeInvalidOpts2 := v1alpha1.NodeVolume{
-		Name:                "/dev/xvda2",
-		Type:                "gp2",
-		Size:                100,
-		DeleteOnTermination: aws.Bool(true),
-		Encrypted:           aws.Bool(true),
-		MountOptions: &v1alpha1.NodeVolumeMountOptions{
-			FileSystem:  "ext4",
-			Mount:       "data",
-			Persistance: aws.Bool(false),
-		},
+	warnings := func() []string {
+		events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		var messages []string
+		for _, e := range events.Items {
+			if e.Reason == string(kubeprovider.IAMTagsIgnoredEvent) {
+				messages = append(messages, e.Message)
+				k.Kubernetes.CoreV1().Events(e.Namespace).Delete(context.Background(), e.Name, metav1.DeleteOptions{})
+			}
+		}
+		return messages
 	}
 
-	tests := []struct {
+	// compatible tags are propagated without a warning
+	config.Tags = []map[string]string{{"key": "team", "value": "team@example.com"}}
+	g.Expect(ctx.GetIAMTags()).To(gomega.Equal(map[string]string{"team": "team@example.com"}))
+	g.Expect(warnings()).To(gomega.BeEmpty())
+
+	// incompatible and duplicated tags are skipped with a warning
+	config.Tags = []map[string]string{
+		{"key": "team", "value": "platform"},
+		{"key": "cost-center", "value": "#1234"},
+		{"key": "Team", "value": "other"},
+		{"key": "owner", "value": strings.Repeat("a", 257)},
+	}
+	g.Expect(ctx.GetIAMTags()).To(gomega.Equal(map[string]string{"team": "platform"}))
+	messages := warnings()
+	g.Expect(messages).To(gomega.HaveLen(1))
+	g.Expect(messages[0]).To(gomega.ContainSubstring("cost-center,Team,owner"))
+}
+
+func TestUpdateNodeReadyConditionStartupTaint(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
+
+	startupTaint := corev1.Taint{Key: "node.example.com/startup", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+	config.Taints = []corev1.Taint{startupTaint}
+	config.StartupTaint = &v1alpha1.StartupTaintSpec{Key: startupTaint.Key, Timeout: "5m"}
+
+	asg := MockScalingGroup("asg-1", false)
+	asg.Instances = MockScalingInstances(0, 2)
+	asg.DesiredCapacity = aws.Int64(2)
+	state.SetScalingGroup(asg)
+
+	mockNodes := func(age time.Duration, taintedIds ...string) *corev1.NodeList {
+		nodes := &corev1.NodeList{}
+		for _, instance := range asg.Instances {
+			id := aws.StringValue(instance.InstanceId)
+			node := MockNode(id, corev1.ConditionTrue)
+			node.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+			if common.ContainsString(taintedIds, id) {
+				node.Spec.Taints = []corev1.Taint{startupTaint}
+			}
+			nodes.Items = append(nodes.Items, *node)
+		}
+		return nodes
+	}
+
+	tests := []struct {
+		nodes           *corev1.NodeList
+		expectedReady   bool
+		expectedTimeout corev1.ConditionStatus
+	}{
+		// taint not yet removed
+		{nodes: mockNodes(time.Minute, "i-100000000", "i-100000001"), expectedReady: false, expectedTimeout: corev1.ConditionFalse},
+		// taint stuck past the timeout
+		{nodes: mockNodes(time.Hour, "i-100000001"), expectedReady: false, expectedTimeout: corev1.ConditionTrue},
+		// taint cleared
+		{nodes: mockNodes(time.Hour), expectedReady: true, expectedTimeout: corev1.ConditionFalse},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v", i)
+		state.SetClusterNodes(tc.nodes)
+		g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.Equal(tc.expectedReady))
+		g.Expect(state.IsNodesReady()).To(gomega.Equal(tc.expectedReady))
+		g.Expect(status.GetStartupTaintTimeoutCondition()).To(gomega.Equal(tc.expectedTimeout))
+	}
+
+	// without a startup taint configured, tainted nodes are counted as ready
+	config.StartupTaint = nil
+	state.SetClusterNodes(mockNodes(time.Hour, "i-100000001"))
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeTrue())
+}
+
+func TestUpdateNodeReadyConditionReadinessChecks(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
+
+	config.ReadinessChecks = &v1alpha1.ReadinessChecksSpec{DaemonSets: []string{"kube-system/aws-node"}}
+
+	asg := MockScalingGroup("asg-1", false)
+	asg.Instances = MockScalingInstances(0, 2)
+	asg.DesiredCapacity = aws.Int64(2)
+	state.SetScalingGroup(asg)
+
+	nodes := &corev1.NodeList{}
+	for _, instance := range asg.Instances {
+		nodes.Items = append(nodes.Items, *MockNode(aws.StringValue(instance.InstanceId), corev1.ConditionTrue))
+	}
+	state.SetClusterNodes(nodes)
+
+	controller := true
+	createDaemonSetPod := func(node corev1.Node) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("aws-node-%v", node.GetName()),
+				Namespace:       "kube-system",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "aws-node", Controller: &controller}},
+			},
+			Spec:   corev1.PodSpec{NodeName: node.GetName()},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		_, err := k.Kubernetes.CoreV1().Pods("kube-system").Create(context.Background(), pod, metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	// daemonset pods absent
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeFalse())
+
+	// daemonset pod present on only one of the nodes
+	createDaemonSetPod(nodes.Items[0])
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeFalse())
+
+	// daemonset pods present on all nodes
+	createDaemonSetPod(nodes.Items[1])
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeTrue())
+
+	// other daemonsets are not running on the nodes
+	config.ReadinessChecks.DaemonSets = append(config.ReadinessChecks.DaemonSets, "log-agent")
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeFalse())
+
+	// without readiness checks, nodes are ready regardless of daemonset pods
+	config.ReadinessChecks = nil
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeTrue())
+}
+
+func TestUnhealthyProtectedInstances(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
+
+	asg := MockScalingGroup("asg-1", false)
+	asg.Instances = MockScalingInstances(0, 2)
+	asg.DesiredCapacity = aws.Int64(2)
+	state.SetScalingGroup(asg)
+	state.SetClusterNodes(&corev1.NodeList{})
+	unhealthy := []string{aws.StringValue(asg.Instances[1].InstanceId)}
+	state.SetUnhealthyProtectedInstances(unhealthy)
+
+	// unhealthy protected instances are reported by default and their protection is left unchanged
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeFalse())
+	g.Expect(status.GetUnhealthyInstancesProtectedCondition()).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(ctx.ClearUnhealthyInstanceProtection()).To(gomega.Succeed())
+	g.Expect(asgMock.SetInstanceProtectionInputs).To(gomega.BeEmpty())
+
+	// with clear-protection the scaling group can replace them, and they are not reported
+	config.UnhealthyProtectedPolicy = v1alpha1.UnhealthyProtectedPolicyClearProtection
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeFalse())
+	g.Expect(status.GetUnhealthyInstancesProtectedCondition()).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(ctx.ClearUnhealthyInstanceProtection()).To(gomega.Succeed())
+	g.Expect(asgMock.SetInstanceProtectionInputs).To(gomega.HaveLen(1))
+	input := asgMock.SetInstanceProtectionInputs[0]
+	g.Expect(aws.StringValue(input.AutoScalingGroupName)).To(gomega.Equal("asg-1"))
+	g.Expect(aws.StringValueSlice(input.InstanceIds)).To(gomega.Equal(unhealthy))
+	g.Expect(aws.BoolValue(input.ProtectedFromScaleIn)).To(gomega.BeFalse())
+
+	// instances of zone sharded instance groups are cleared in the scaling group of their zone
+	asgMock.SetInstanceProtectionInputs = nil
+	config.ZoneSharding = true
+	zoneA, zoneB := MockScalingGroup("asg-1-us-west-2a", false), MockScalingGroup("asg-1-us-west-2b", false)
+	zoneA.Instances = asg.Instances[:1]
+	zoneB.Instances = asg.Instances[1:]
+	state.SetZoneScalingGroups(map[string]*autoscaling.Group{"us-west-2a": zoneA, "us-west-2b": zoneB})
+	g.Expect(ctx.ClearUnhealthyInstanceProtection()).To(gomega.Succeed())
+	g.Expect(asgMock.SetInstanceProtectionInputs).To(gomega.HaveLen(1))
+	g.Expect(aws.StringValue(asgMock.SetInstanceProtectionInputs[0].AutoScalingGroupName)).To(gomega.Equal("asg-1-us-west-2b"))
+
+	asgMock.SetInstanceProtectionErr = errors.New("some-error")
+	g.Expect(ctx.ClearUnhealthyInstanceProtection()).NotTo(gomega.Succeed())
+}
+
+func TestFailedJoinInstances(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
+
+	asg := MockScalingGroup("asg-1", false)
+	asg.Instances = MockScalingInstances(0, 2)
+	asg.DesiredCapacity = aws.Int64(2)
+	state.SetScalingGroup(asg)
+	state.SetClusterNodes(&corev1.NodeList{Items: []corev1.Node{*MockNode(aws.StringValue(asg.Instances[0].InstanceId), corev1.ConditionTrue)}})
+
+	// all instances joined
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeFalse())
+	g.Expect(status.GetFailedJoinCondition()).To(gomega.Equal(corev1.ConditionFalse))
+
+	// instances which failed to join are reported
+	failed := []string{aws.StringValue(asg.Instances[1].InstanceId)}
+	config.NodeJoinDeadline = &v1alpha1.NodeJoinDeadlineSpec{Timeout: "15m"}
+	state.SetFailedJoinInstances(failed)
+	g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeFalse())
+	g.Expect(status.GetFailedJoinCondition()).To(gomega.Equal(corev1.ConditionTrue))
+
+	// instances are only terminated when replacement is enabled
+	g.Expect(ctx.ReplaceFailedJoinInstances()).To(gomega.Succeed())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.BeEmpty())
+
+	config.NodeJoinDeadline.Replace = true
+	g.Expect(ctx.ReplaceFailedJoinInstances()).To(gomega.Succeed())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.Equal(failed))
+
+	asgMock.TerminateInstanceInAutoScalingGroupErr = errors.New("some-error")
+	g.Expect(ctx.ReplaceFailedJoinInstances()).NotTo(gomega.Succeed())
+}
+
+func TestReplaceNotReadyInstances(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
+
+	asg := MockScalingGroup("asg-1", false)
+	asg.Instances = MockScalingInstances(0, 4)
+	asg.DesiredCapacity = aws.Int64(4)
+	for _, instance := range asg.Instances {
+		instance.LifecycleState = aws.String(autoscaling.LifecycleStateInService)
+	}
+	state.SetScalingGroup(asg)
+
+	// nodes which have been NotReady for an hour, or only for a minute
+	notReadyNode := func(id string, since time.Duration) corev1.Node {
+		node := MockNode(id, corev1.ConditionFalse)
+		node.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-since))
+		return *node
+	}
+	state.SetClusterNodes(&corev1.NodeList{Items: []corev1.Node{
+		*MockNode("i-100000000", corev1.ConditionTrue),
+		notReadyNode("i-100000001", 30*time.Minute),
+		notReadyNode("i-100000002", time.Hour),
+		notReadyNode("i-100000003", time.Minute),
+	}})
+
+	// the node NotReady the longest is drained, its terminating pod cannot be confirmed by the kubelet
+	_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), &state.GetClusterNodes().Items[2], metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	terminating := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default", DeletionTimestamp: &metav1.Time{Time: time.Now()}},
+		Spec:       corev1.PodSpec{NodeName: "node-i-100000002"},
+	}
+	_, err = k.Kubernetes.CoreV1().Pods("default").Create(context.Background(), terminating, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// instances are not replaced without a threshold
+	g.Expect(ctx.ReplaceNotReadyInstances()).To(gomega.Succeed())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.BeEmpty())
+
+	// nodes which stayed NotReady beyond the threshold are not replaced while more than maxUnavailable nodes are NotReady
+	config.NotReadyReplacement = &v1alpha1.NotReadyReplacementSpec{Threshold: "10m"}
+	state.SetNotReadyInstances(ctx.discoverNotReadyInstances(config.NotReadyReplacement.GetThreshold()))
+	g.Expect(state.GetNotReadyInstances()).To(gomega.Equal([]string{"i-100000002", "i-100000001"}))
+	g.Expect(ctx.ReplaceNotReadyInstances()).To(gomega.Succeed())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.BeEmpty())
+
+	// a larger maxUnavailable drains and replaces them, those NotReady the longest first
+	config.NotReadyReplacement.MaxUnavailable = &intstr.IntOrString{Type: intstr.Int, IntVal: 3}
+	g.Expect(ctx.ReplaceNotReadyInstances()).To(gomega.Succeed())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.Equal([]string{"i-100000002", "i-100000001"}))
+	node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), "node-i-100000002", metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(node.Spec.Unschedulable).To(gomega.BeTrue())
+
+	// the remaining instance is not replaced while its replacement launches
+	asgMock.TerminatedInstanceIds = nil
+	asg.Instances[2].LifecycleState = aws.String(autoscaling.LifecycleStateTerminating)
+	state.SetNotReadyInstances(ctx.discoverNotReadyInstances(config.NotReadyReplacement.GetThreshold()))
+	g.Expect(state.GetNotReadyInstances()).To(gomega.Equal([]string{"i-100000001"}))
+	config.NotReadyReplacement.MaxUnavailable = &intstr.IntOrString{Type: intstr.Int, IntVal: 2}
+	g.Expect(ctx.ReplaceNotReadyInstances()).To(gomega.Succeed())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.BeEmpty())
+
+	// protected instances are not replaced
+	asg.Instances[2].LifecycleState = aws.String(autoscaling.LifecycleStateInService)
+	state.SetProtectedInstances([]string{"i-100000001", "i-100000002"})
+	g.Expect(ctx.discoverNotReadyInstances(config.NotReadyReplacement.GetThreshold())).To(gomega.BeEmpty())
+
+	config.NotReadyReplacement.MaxUnavailable = &intstr.IntOrString{Type: intstr.Int, IntVal: 3}
+	asgMock.TerminateInstanceInAutoScalingGroupErr = errors.New("some-error")
+	g.Expect(ctx.ReplaceNotReadyInstances()).NotTo(gomega.Succeed())
+}
+
+func TestConsoleOutputTail(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(consoleOutputTail("", 10, 100)).To(gomega.BeEmpty())
+	g.Expect(consoleOutputTail("\r\n \n", 10, 100)).To(gomega.BeEmpty())
+	g.Expect(consoleOutputTail("one\r\ntwo\r\nthree\r\n", 2, 100)).To(gomega.Equal("two\nthree"))
+	g.Expect(consoleOutputTail("one\ntwo\nthree", 10, 5)).To(gomega.Equal("three"))
+	// truncation does not split multi-byte characters
+	g.Expect(consoleOutputTail("abécd", 10, 3)).To(gomega.Equal("cd"))
+}
+
+func TestInitialGracePeriod(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
+
+	// the instances of the scaling group have not registered as nodes yet
+	asg := MockScalingGroup("asg-1", false)
+	asg.Instances = MockScalingInstances(0, 2)
+	asg.DesiredCapacity = aws.Int64(2)
+	state.SetScalingGroup(asg)
+	state.SetClusterNodes(&corev1.NodeList{})
+
+	tests := []struct {
+		created     *time.Time
+		gracePeriod string
+		launching   bool
+	}{
+		{created: aws.Time(time.Now().Add(-time.Minute)), launching: true},
+		{created: aws.Time(time.Now().Add(-10 * time.Minute)), gracePeriod: "15m", launching: true},
+		{created: aws.Time(time.Now().Add(-10 * time.Minute))},
+		{created: aws.Time(time.Now().Add(-time.Minute)), gracePeriod: "0s"},
+		{created: nil},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		asg.CreatedTime = tc.created
+		config.InitialGracePeriod = tc.gracePeriod
+		g.Expect(ctx.InInitialGracePeriod()).To(gomega.Equal(tc.launching))
+
+		// nodes which are not ready are only reported once the grace period has passed
+		state.SetNodesReady(true)
+		state.SetFailedJoinInstances(nil)
+		g.Expect(ctx.UpdateNodeReadyCondition()).To(gomega.BeFalse())
+		g.Expect(status.GetNodesReadyCondition()).To(gomega.Equal(corev1.ConditionFalse))
+
+		events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		var notReady int
+		for _, e := range events.Items {
+			if e.Reason == string(kubeprovider.NodesNotReadyEvent) {
+				notReady++
+				k.Kubernetes.CoreV1().Events(e.Namespace).Delete(context.Background(), e.Name, metav1.DeleteOptions{})
+			}
+		}
+		if tc.launching {
+			g.Expect(notReady).To(gomega.BeZero())
+		} else {
+			g.Expect(notReady).To(gomega.Equal(1))
+		}
+	}
+}
+
+func TestGetDisabledMetrics(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	// No disable required
+	ig.GetEKSConfiguration().SetMetricsCollection([]string{"all"})
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			EnabledMetrics: MockEnabledMetrics("GroupMinSize"),
+		},
+	})
+
+	metrics, ok := ctx.GetDisabledMetrics()
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(metrics).To(gomega.BeEmpty())
+
+	// Disable all metrics
+	ig.GetEKSConfiguration().SetMetricsCollection([]string{})
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			EnabledMetrics: MockEnabledMetrics(awsprovider.DefaultAutoscalingMetrics...),
+		},
+	})
+
+	metrics, ok = ctx.GetDisabledMetrics()
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(metrics).To(gomega.ConsistOf(awsprovider.DefaultAutoscalingMetrics))
+
+	// Disable specific metric
+	ig.GetEKSConfiguration().SetMetricsCollection([]string{"GroupMinSize"})
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			EnabledMetrics: MockEnabledMetrics("GroupMinSize", "GroupMaxSize"),
+		},
+	})
+
+	metrics, ok = ctx.GetDisabledMetrics()
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(metrics).To(gomega.ContainElement("GroupMaxSize"))
+}
+
+func TestGetEnabledMetrics(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	// Enable all metrics
+	ig.GetEKSConfiguration().SetMetricsCollection([]string{"all"})
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			EnabledMetrics: MockEnabledMetrics(),
+		},
+	})
+
+	metrics, ok := ctx.GetEnabledMetrics()
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(metrics).To(gomega.ConsistOf(awsprovider.DefaultAutoscalingMetrics))
+
+	// Enable specific metric
+	ig.GetEKSConfiguration().SetMetricsCollection([]string{"GroupMinSize", "GroupMaxSize"})
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			EnabledMetrics: MockEnabledMetrics("GroupMinSize"),
+		},
+	})
+
+	metrics, ok = ctx.GetEnabledMetrics()
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(metrics).To(gomega.ContainElement("GroupMaxSize"))
+}
+
+func TestUpdateMetricsCollection(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	tests := []struct {
+		desired []string
+		enabled []string
+		enable  []string
+		disable []string
+	}{
+		// a subset of metrics is enabled
+		{desired: []string{"GroupDesiredCapacity", "GroupInServiceInstances"}, enable: []string{"GroupDesiredCapacity", "GroupInServiceInstances"}},
+		{desired: []string{"GroupDesiredCapacity", "GroupInServiceInstances"}, enabled: []string{"GroupDesiredCapacity"}, enable: []string{"GroupInServiceInstances"}},
+		// metrics which are no longer selected are disabled
+		{desired: []string{"GroupDesiredCapacity"}, enabled: []string{"GroupDesiredCapacity", "GroupMinSize"}, disable: []string{"GroupMinSize"}},
+		{desired: []string{"GroupMaxSize"}, enabled: []string{"GroupMinSize"}, enable: []string{"GroupMaxSize"}, disable: []string{"GroupMinSize"}},
+		// metrics collection is disabled when no metrics are selected
+		{desired: nil, enabled: []string{"GroupDesiredCapacity", "GroupMinSize"}, disable: []string{"GroupDesiredCapacity", "GroupMinSize"}},
+		{desired: []string{"all"}, enabled: awsprovider.DefaultAutoscalingMetrics},
+		// names which are not scaling group metrics are skipped
+		{desired: []string{"GroupDesiredCapacity", "GroupSize"}, enabled: []string{"GroupMinSize"}, enable: []string{"GroupDesiredCapacity"}, disable: []string{"GroupMinSize"}},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		asgMock := NewAutoScalingMocker()
+		w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+		ctx := MockContext(ig, k, w)
+		ig.GetEKSConfiguration().SetMetricsCollection(tc.desired)
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("some-scaling-group"),
+				EnabledMetrics:       MockEnabledMetrics(tc.enabled...),
+			},
+		})
+
+		g.Expect(ctx.UpdateMetricsCollection("some-scaling-group")).To(gomega.Succeed())
+		g.Expect(asgMock.EnabledMetrics).To(gomega.ConsistOf(tc.enable))
+		g.Expect(asgMock.DisabledMetrics).To(gomega.ConsistOf(tc.disable))
+	}
+}
+
+func TestGetLabelList(t *testing.T) {
+	var (
+		g                          = gomega.NewGomegaWithT(t)
+		k                          = MockKubernetesClientSet()
+		ig                         = MockInstanceGroup()
+		configuration              = ig.GetEKSConfiguration()
+		status                     = ig.GetStatus()
+		asgMock                    = NewAutoScalingMocker()
+		iamMock                    = NewIamMocker()
+		eksMock                    = NewEksMocker()
+		ec2Mock                    = NewEc2Mocker()
+		ssmMock                    = NewSsmMocker()
+		defaultLifecycleLabel      = fmt.Sprintf("%v=%v", InstanceMgrLifecycleLabel, v1alpha1.LifecycleStateNormal)
+		defaultImageLabel          = fmt.Sprintf("instancemgr.keikoproj.io/image=%v", configuration.GetImage())
+		expectedLabels115          = []string{defaultImageLabel, defaultLifecycleLabel, "node-role.kubernetes.io/instance-group-1=\"\"", "node.kubernetes.io/role=instance-group-1"}
+		expectedLabels116          = []string{defaultImageLabel, defaultLifecycleLabel, "node.kubernetes.io/role=instance-group-1"}
+		expectedLabelsWithCustom   = []string{defaultImageLabel, defaultLifecycleLabel, "custom.kubernetes.io=customlabel", "node.kubernetes.io/role=instance-group-1"}
+		expectedLabelsWithOverride = []string{defaultImageLabel, defaultLifecycleLabel, "custom.kubernetes.io=customlabel", "override.kubernetes.io=instance-group-1", "override2.kubernetes.io=instance-group-1"}
+		overrideAnnotation         = map[string]string{OverrideDefaultLabelsAnnotation: "override.kubernetes.io=instance-group-1,override2.kubernetes.io=instance-group-1"}
+		suppressImageAnnotation    = map[string]string{OverrideDefaultLabelsAnnotation: "instancemgr.keikoproj.io/image"}
+		suppressMultipleAnnotation = map[string]string{OverrideDefaultLabelsAnnotation: "instancemgr.keikoproj.io/image, node-role.kubernetes.io/instance-group-1"}
+		suppressWithOverride       = map[string]string{OverrideDefaultLabelsAnnotation: InstanceMgrLifecycleLabel + ",override.kubernetes.io=instance-group-1"}
+		expectedSuppressedImage    = []string{defaultLifecycleLabel, "node.kubernetes.io/role=instance-group-1"}
+		expectedSuppressedMultiple = []string{defaultLifecycleLabel, "node.kubernetes.io/role=instance-group-1"}
+		expectedSuppressedOverride = []string{defaultImageLabel, "override.kubernetes.io=instance-group-1"}
+		expectedSpotLabel          = []string{defaultImageLabel, fmt.Sprintf("%v=%v", InstanceMgrLifecycleLabel, v1alpha1.LifecycleStateSpot), "node-role.kubernetes.io/instance-group-1=\"\"", "node.kubernetes.io/role=instance-group-1"}
+		expectedMixedLabel         = []string{defaultImageLabel, fmt.Sprintf("%v=%v", InstanceMgrLifecycleLabel, v1alpha1.LifecycleStateMixed), "node-role.kubernetes.io/instance-group-1=\"\"", "node.kubernetes.io/role=instance-group-1"}
+		expectedInstanceLifecycle  = []string{defaultImageLabel, "node-role.kubernetes.io/instance-group-1=\"\"", "node.kubernetes.io/role=instance-group-1"}
+		instanceLifecycleLabels    = map[string]string{InstanceLifecycleLabelsAnnotation: "true"}
+		expectedVpcCNILabel        = []string{defaultImageLabel, defaultLifecycleLabel, "instancemgr.keikoproj.io/vpc-cni-version=v1.18.3-eksbuild.1", "node.kubernetes.io/role=instance-group-1"}
+		expectedSuppressedVpcCNI   = []string{defaultLifecycleLabel, "instancemgr.keikoproj.io/vpc-cni-version=v1.18.3-eksbuild.1", "node.kubernetes.io/role=instance-group-1"}
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		clusterVersion           string
+		instanceGroupLabels      map[string]string
+		instanceGroupAnnotations map[string]string
+		expectedLabels           []string
+		withSpot                 bool
+		withMixedInstances       bool
+		vpcCNIVersion            string
+	}{
+		{clusterVersion: "", withSpot: true, expectedLabels: expectedSpotLabel},
+		{clusterVersion: "", withMixedInstances: true, expectedLabels: expectedMixedLabel},
+		// nodes of mixed instance groups which opted in are labeled with the lifecycle of their instance after they join
+		{clusterVersion: "", withMixedInstances: true, instanceGroupAnnotations: instanceLifecycleLabels, expectedLabels: expectedInstanceLifecycle},
+		// Default labels with missing cluster version
+		{clusterVersion: "", expectedLabels: expectedLabels115},
+		// Kubernetes 1.15 default labels
+		{clusterVersion: "1.15", expectedLabels: expectedLabels115},
+		// Kubernetes 1.16 default labels
+		{clusterVersion: "1.16", expectedLabels: expectedLabels116},
+		// Kubernetes 1.16 default labels with custom labels
+		{clusterVersion: "1.16", instanceGroupLabels: map[string]string{"custom.kubernetes.io": "customlabel"}, expectedLabels: expectedLabelsWithCustom},
+		// custom labels with override labels
+		{clusterVersion: "1.16", instanceGroupAnnotations: overrideAnnotation, instanceGroupLabels: map[string]string{"custom.kubernetes.io": "customlabel"}, expectedLabels: expectedLabelsWithOverride},
+		// suppress a single default label
+		{clusterVersion: "1.16", instanceGroupAnnotations: suppressImageAnnotation, expectedLabels: expectedSuppressedImage},
+		// suppress multiple default labels
+		{clusterVersion: "1.15", instanceGroupAnnotations: suppressMultipleAnnotation, expectedLabels: expectedSuppressedMultiple},
+		// suppress a default label with override labels
+		{clusterVersion: "1.16", instanceGroupAnnotations: suppressWithOverride, expectedLabels: expectedSuppressedOverride},
+		// pinned vpc cni version is labeled and not suppressed with default labels
+		{clusterVersion: "1.16", vpcCNIVersion: "v1.18.3-eksbuild.1", expectedLabels: expectedVpcCNILabel},
+		{clusterVersion: "1.16", vpcCNIVersion: "v1.18.3-eksbuild.1", instanceGroupAnnotations: suppressImageAnnotation, expectedLabels: expectedSuppressedVpcCNI},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		configuration.SetLabels(tc.instanceGroupLabels)
+		configuration.VpcCNIVersion = tc.vpcCNIVersion
+		ig.SetAnnotations(tc.instanceGroupAnnotations)
+		status.SetLifecycle(v1alpha1.LifecycleStateNormal)
+		if tc.withSpot {
+			status.SetLifecycle(v1alpha1.LifecycleStateSpot)
+		} else if tc.withMixedInstances {
+			status.SetLifecycle(v1alpha1.LifecycleStateMixed)
+		}
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			Cluster: MockEksCluster(tc.clusterVersion),
+		})
+		sort.Strings(tc.expectedLabels)
+		labels := ctx.GetLabelList()
+		g.Expect(labels).To(gomega.Equal(tc.expectedLabels))
+	}
+}
+
+func TestGetMountOpts(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	volumeNoOpts := v1alpha1.NodeVolume{
+		Name:                "/dev/xvda1",
+		Type:                "gp2",
+		Size:                100,
+		DeleteOnTermination: aws.Bool(true),
+		Encrypted:           aws.Bool(true),
+	}
+
+	volumeWithOpts := v1alpha1.NodeVolume{
+		Name:                "/dev/xvda2",
+		Type:                "gp2",
+		Size:                100,
+		DeleteOnTermination: aws.Bool(true),
+		Encrypted:           aws.Bool(true),
+		MountOptions: &v1alpha1.NodeVolumeMountOptions{
+			FileSystem:  "xfs",
+			Mount:       "/data",
+			Persistance: aws.Bool(false),
+		},
+	}
+
+	volumeWithOpts2 := v1alpha1.NodeVolume{
+		Name:                "/dev/xvda3",
+		Type:                "gp2",
+		Size:                200,
+		DeleteOnTermination: aws.Bool(true),
+		Encrypted:           aws.Bool(true),
+		MountOptions: &v1alpha1.NodeVolumeMountOptions{
+			FileSystem: "xfs",
+			Mount:      "/data2",
+		},
+	}
+
+	volumeInvalidOpts := v1alpha1.NodeVolume{
+		Name:                "/dev/xvda2",
+		Type:                "gp2",
+		Size:                100,
+		DeleteOnTermination: aws.Bool(true),
+		Encrypted:           aws.Bool(true),
+		MountOptions: &v1alpha1.NodeVolumeMountOptions{
+			FileSystem:  "ext3",
+			Mount:       "/data",
+			Persistance: aws.Bool(true),
+		},
+	}
+
+	volumeInvalidOpts2 := v1alpha1.NodeVolume{
+		Name:                "/dev/xvda2",
+		Type:                "gp2",
+		Size:                100,
+		DeleteOnTermination: aws.Bool(true),
+		Encrypted:           aws.Bool(true),
+		MountOptions: &v1alpha1.NodeVolumeMountOptions{
+			FileSystem:  "ext4",
+			Mount:       "data",
+			Persistance: aws.Bool(false),
+		},
+	}
+
+	tests := []struct {
 		volumes        []v1alpha1.NodeVolume
 		expectedMounts []MountOpts
 	}{
-		{volumes: []v1alpha1.NodeVolume{volumeNoOpts}, expectedMounts: []MountOpts{}},
-		{volumes: []v1alpha1.NodeVolume{volumeNoOpts, volumeWithOpts}, expectedMounts: []MountOpts{
-			{
-				FileSystem:  "xfs",
-				Device:      "/dev/xvda2",
-				Mount:       "/data",
-				Persistance: false,
-			},
+		{volumes: []v1alpha1.NodeVolume{volumeNoOpts}, expectedMounts: []MountOpts{}},
+		{volumes: []v1alpha1.NodeVolume{volumeNoOpts, volumeWithOpts}, expectedMounts: []MountOpts{
+			{
+				FileSystem:  "xfs",
+				Device:      "/dev/xvda2",
+				Mount:       "/data",
+				Persistance: false,
+			},
+		}},
+		{volumes: []v1alpha1.NodeVolume{volumeWithOpts2, volumeWithOpts}, expectedMounts: []MountOpts{
+			{
+				FileSystem:  "xfs",
+				Device:      "/dev/xvda2",
+				Mount:       "/data",
+				Persistance: false,
+			},
+			{
+				FileSystem:  "xfs",
+				Device:      "/dev/xvda3",
+				Mount:       "/data2",
+				Persistance: true,
+			},
+		}},
+		{volumes: []v1alpha1.NodeVolume{volumeNoOpts}, expectedMounts: []MountOpts{}},
+		{volumes: []v1alpha1.NodeVolume{volumeInvalidOpts, volumeInvalidOpts2}, expectedMounts: []MountOpts{}},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		configuration.Volumes = tc.volumes
+		mounts := ctx.GetMountOpts()
+		g.Expect(mounts).To(gomega.ConsistOf(tc.expectedMounts))
+	}
+}
+
+func TestGetOverrides(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+
+	instancePool := "SubFamilyFlexible"
+
+	tests := []struct {
+		primaryType        string
+		scalingGroup       *autoscaling.Group
+		mixedInstancesSpec *v1alpha1.MixedInstancesPolicySpec
+		expectedOverrides  []*autoscaling.LaunchTemplateOverrides
+	}{
+		{
+			primaryType:  "m5.xlarge",
+			scalingGroup: MockScalingGroup("asg-1", true),
+			mixedInstancesSpec: &v1alpha1.MixedInstancesPolicySpec{
+				InstanceTypes: []*v1alpha1.InstanceTypeSpec{
+					{
+						Type:   "m5a.xlarge",
+						Weight: 1,
+					},
+					{
+						Type:   "m5g.xlarge",
+						Weight: 1,
+					},
+				},
+			},
+			expectedOverrides: MockTemplateOverrides("1", "m5a.xlarge", "m5g.xlarge", "m5.xlarge"),
+		},
+		{
+			primaryType:  "m5.xlarge",
+			scalingGroup: MockScalingGroup("asg-1", true),
+			mixedInstancesSpec: &v1alpha1.MixedInstancesPolicySpec{
+				InstancePool: &instancePool,
+			},
+			expectedOverrides: MockTemplateOverrides("1", "m5.xlarge"),
+		},
+		{
+			primaryType:  "t2.xlarge",
+			scalingGroup: MockScalingGroup("asg-1", true),
+			mixedInstancesSpec: &v1alpha1.MixedInstancesPolicySpec{
+				InstanceTypes: []*v1alpha1.InstanceTypeSpec{
+					{
+						Type:   "t2a.xlarge",
+						Weight: 1,
+					},
+					{
+						Type:   "t2g.xlarge",
+						Weight: 1,
+					},
+				},
+			},
+			expectedOverrides: MockTemplateOverrides("1", "t2a.xlarge", "t2g.xlarge", "t2.xlarge", "m5.xlarge"),
+		},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc.expectedOverrides)
+		configuration.MixedInstancesPolicy = tc.mixedInstancesSpec
+		state.ScalingGroup = tc.scalingGroup
+		ig.Spec.EKSSpec.EKSConfiguration.InstanceType = tc.primaryType
+		overrides := ctx.GetOverrides()
+		g.Expect(overrides).To(gomega.ConsistOf(tc.expectedOverrides))
+	}
+}
+
+func TestUpdateCostEstimateStatus(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		status        = ig.GetStatus()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+
+	spotRatio := intstr.FromString("50%")
+	mixedInstancesPolicy := &v1alpha1.MixedInstancesPolicySpec{
+		BaseCapacity: aws.Int64(2),
+		SpotRatio:    &spotRatio,
+		InstanceTypes: []*v1alpha1.InstanceTypeSpec{
+			{Type: "m5.xlarge", Weight: 2},
+			{Type: "x9.large", Weight: 1},
+		},
+	}
+	customPricing := awsprovider.PricingTable{
+		"m5.large":  {OnDemand: 0.096, Spot: 0.03},
+		"m5.xlarge": {OnDemand: 0.192, Spot: 0.07},
+	}
+
+	tests := []struct {
+		desired          int64
+		mixedPolicy      *v1alpha1.MixedInstancesPolicySpec
+		spotPrice        string
+		pricing          awsprovider.PricingTable
+		instanceType     string
+		expectedEstimate *v1alpha1.CostEstimateStatus
+	}{
+		// on-demand instances of a single type
+		{desired: 3, instanceType: "m5.large", expectedEstimate: &v1alpha1.CostEstimateStatus{EstimatedMonthlyCost: "210.24", Currency: "USD", OnDemandCapacity: 3}},
+		// spot instances are estimated at no more than the maximum spot price
+		{desired: 3, instanceType: "m5.large", spotPrice: "0.02", expectedEstimate: &v1alpha1.CostEstimateStatus{EstimatedMonthlyCost: "43.80", Currency: "USD", SpotCapacity: 3}},
+		// mixed instances split capacity above the base capacity by the spot ratio, unpriced types are reported
+		{desired: 10, instanceType: "m5.large", mixedPolicy: mixedInstancesPolicy, expectedEstimate: &v1alpha1.CostEstimateStatus{EstimatedMonthlyCost: "532.61", Currency: "USD", OnDemandCapacity: 6, SpotCapacity: 4, UnpricedInstanceTypes: []string{"x9.large"}}},
+		{desired: 10, instanceType: "m5.large", mixedPolicy: mixedInstancesPolicy, pricing: customPricing, expectedEstimate: &v1alpha1.CostEstimateStatus{EstimatedMonthlyCost: "515.38", Currency: "USD", OnDemandCapacity: 6, SpotCapacity: 4, UnpricedInstanceTypes: []string{"x9.large"}}},
+		// capacity changes update the estimate
+		{desired: 1, instanceType: "m5.large", mixedPolicy: mixedInstancesPolicy, expectedEstimate: &v1alpha1.CostEstimateStatus{EstimatedMonthlyCost: "70.08", Currency: "USD", OnDemandCapacity: 1, UnpricedInstanceTypes: []string{"x9.large"}}},
+		// no estimate without any priced instance type
+		{desired: 3, instanceType: "x9.large", expectedEstimate: nil},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc.expectedEstimate)
+		configuration.InstanceType = tc.instanceType
+		configuration.MixedInstancesPolicy = tc.mixedPolicy
+		configuration.SpotPrice = tc.spotPrice
+		ctx.PricingTable = tc.pricing
+		state.ScalingGroup = &autoscaling.Group{
+			AutoScalingGroupName: aws.String("some-scaling-group"),
+			DesiredCapacity:      aws.Int64(tc.desired),
+		}
+
+		ctx.UpdateCostEstimateStatus()
+		g.Expect(status.GetCostEstimate()).To(gomega.Equal(tc.expectedEstimate))
+	}
+}
+
+func TestInstanceSpecWeight(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		weight  string
+		wantErr bool
+	}{
+		{weight: "1", wantErr: false},
+		{weight: "999", wantErr: false},
+		{weight: "abc", wantErr: true},
+		{weight: "", wantErr: true},
+		{weight: "0", wantErr: true},
+		{weight: "-2", wantErr: true},
+		{weight: "1000", wantErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - weight %v", i, tc.weight)
+		err := InstanceSpec{Type: "m5.xlarge", Weight: tc.weight}.Validate()
+		if tc.wantErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+	}
+
+	// pools with weights which cannot be used as overrides are rejected
+	pool := &InstancePool{Pool: map[string][]InstanceSpec{
+		"m5.xlarge": {{Type: "m5.xlarge", Weight: "1"}, {Type: "m5a.xlarge", Weight: "1"}},
+	}}
+	g.Expect(pool.Validate()).To(gomega.Succeed())
+	pool.Pool["m5.2xlarge"] = []InstanceSpec{{Type: "m5.2xlarge", Weight: "abc"}}
+	g.Expect(pool.Validate()).To(gomega.MatchError(gomega.ContainSubstring("validation failed")))
+}
+
+func TestGetUserDataStages(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		preBootstrapScript  []string
+		postBootstrapScript []string
+		bootstrapScript     string
+		expectedPayload     UserDataPayload
+	}{
+		{},
+		{preBootstrapScript: []string{""}, postBootstrapScript: []string{""}, expectedPayload: UserDataPayload{PreBootstrap: []string{""}, PostBootstrap: []string{""}}},
+		{preBootstrapScript: []string{"dGVzdA=="}, postBootstrapScript: []string{"dGVzdDE="}, expectedPayload: UserDataPayload{PreBootstrap: []string{"test"}, PostBootstrap: []string{"test1"}}},
+		{preBootstrapScript: []string{"prebootstrap1"}, postBootstrapScript: []string{"postbootstrap"}, expectedPayload: UserDataPayload{PreBootstrap: []string{"prebootstrap1"}, PostBootstrap: []string{"postbootstrap"}}},
+		{preBootstrapScript: []string{"prebootstrap1", "prebootstrap2"}, postBootstrapScript: []string{"postbootstrap1", "postbootstrap2"}, expectedPayload: UserDataPayload{PreBootstrap: []string{"prebootstrap1", "prebootstrap2"}, PostBootstrap: []string{"postbootstrap1", "postbootstrap2"}}},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		configuration.UserData = []v1alpha1.UserDataStage{}
+		for _, data := range tc.preBootstrapScript {
+			configuration.UserData = append(configuration.UserData, v1alpha1.UserDataStage{
+				Name:  fmt.Sprintf("stage-%v", i),
+				Stage: v1alpha1.PreBootstrapStage,
+				Data:  data,
+			})
+		}
+
+		for _, data := range tc.postBootstrapScript {
+			configuration.UserData = append(configuration.UserData, v1alpha1.UserDataStage{
+				Name:  fmt.Sprintf("stage-%v", i),
+				Stage: v1alpha1.PostBootstrapStage,
+				Data:  data,
+			})
+		}
+
+		configuration.UserData = append(configuration.UserData, v1alpha1.UserDataStage{
+			Name:  fmt.Sprintf("stage-%v", i),
+			Stage: "invalid-stage",
+			Data:  "test",
+		})
+		payload := ctx.GetUserDataStages()
+		g.Expect(payload).To(gomega.Equal(tc.expectedPayload))
+	}
+}
+
+func TestMaxPodsSetCorrectly(t *testing.T) {
+	var (
+		k                         = MockKubernetesClientSet()
+		bottleRocketIgWithMaxPods = MockBottleRocketInstanceGroup()
+		bottleRocketIg            = MockBottleRocketInstanceGroup()
+		amazonLinuxIgWithMaxPods  = MockInstanceGroup()
+		asgMock                   = NewAutoScalingMocker()
+		iamMock                   = NewIamMocker()
+		eksMock                   = NewEksMocker()
+		ec2Mock                   = NewEc2Mocker()
+		ssmMock                   = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+
+	bottleRocketIgWithMaxPods.Spec.EKSSpec.EKSConfiguration.BootstrapOptions = &v1alpha1.BootstrapOptions{
+		MaxPods: 15,
+	}
+
+	amazonLinuxIgWithMaxPods.Spec.EKSSpec.EKSConfiguration.BootstrapOptions = &v1alpha1.BootstrapOptions{
+		MaxPods: 15,
+	}
+
+	tests := []struct {
+		ig                         *v1alpha1.InstanceGroup
+		expectedScriptSubstrings   string
+		unexpectedScriptSubstrings string
+	}{
+		{
+			ig:                       bottleRocketIgWithMaxPods,
+			expectedScriptSubstrings: "max-pods = 15",
+		},
+		{
+			ig:                       amazonLinuxIgWithMaxPods,
+			expectedScriptSubstrings: "--max-pods=15",
+		},
+		{
+			ig:                         bottleRocketIg,
+			expectedScriptSubstrings:   "",
+			unexpectedScriptSubstrings: "max-pods",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ctx := MockContext(tc.ig, k, w)
+		args := ctx.GetBootstrapArgs()
+		basicUserData := ctx.GetBasicUserData("", args, "", UserDataPayload{}, []MountOpts{})
+		basicUserDataDecoded, _ := base64.StdEncoding.DecodeString(basicUserData)
+		basicUserDataString := string(basicUserDataDecoded)
+		if !strings.Contains(basicUserDataString, tc.expectedScriptSubstrings) {
+			t.Fatalf("Cound not find expected string %v script in %v", tc.expectedScriptSubstrings, basicUserDataString)
+		}
+		if tc.unexpectedScriptSubstrings != "" && strings.Contains(basicUserDataString, tc.unexpectedScriptSubstrings) {
+			t.Fatalf("Found unexpected string %v script in %v", tc.unexpectedScriptSubstrings, basicUserDataString)
+		}
+	}
+}
+
+func TestBootstrapDataForOSFamily(t *testing.T) {
+	var (
+		k              = MockKubernetesClientSet()
+		bottleRocketIg = MockBottleRocketInstanceGroup()
+		linuxIg        = MockInstanceGroup()
+		windowsIg      = MockWindowsInstanceGroup()
+		asgMock        = NewAutoScalingMocker()
+		iamMock        = NewIamMocker()
+		eksMock        = NewEksMocker()
+		ec2Mock        = NewEc2Mocker()
+		ssmMock        = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+
+	tests := []struct {
+		ig                       *v1alpha1.InstanceGroup
+		expectedScriptSubstrings string
+	}{
+		{
+			ig:                       linuxIg,
+			expectedScriptSubstrings: "/etc/eks/bootstrap.sh",
+		},
+		{
+			ig:                       windowsIg,
+			expectedScriptSubstrings: "<powershell>",
+		},
+		{
+			ig:                       bottleRocketIg,
+			expectedScriptSubstrings: "[settings.kubernetes]",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ctx := MockContext(tc.ig, k, w)
+		basicUserData := ctx.GetBasicUserData("", "", "", UserDataPayload{}, []MountOpts{})
+		basicUserDataDecoded, _ := base64.StdEncoding.DecodeString(basicUserData)
+		basicUserDataString := string(basicUserDataDecoded)
+		if !strings.Contains(basicUserDataString, tc.expectedScriptSubstrings) {
+			t.Fatalf("Cound not find expected string %v script in %v", tc.expectedScriptSubstrings, basicUserDataString)
+		}
+	}
+
+}
+
+func TestUpdateLifecycleHooks(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	testScalingHooks := []*autoscaling.LifecycleHook{
+		{
+			LifecycleHookName: aws.String("my-hook-1"),
+		},
+		{
+			LifecycleHookName: aws.String("my-hook-2"),
+		},
+	}
+
+	hook1 := v1alpha1.LifecycleHookSpec{
+		Name: "my-hook-1",
+	}
+
+	hook2 := v1alpha1.LifecycleHookSpec{
+		Name: "my-hook-2",
+	}
+
+	hook3 := v1alpha1.LifecycleHookSpec{
+		Name: "my-hook-3",
+	}
+
+	tests := []struct {
+		asgHooks        []*autoscaling.LifecycleHook
+		desiredHooks    []v1alpha1.LifecycleHookSpec
+		expectedRemoved []string
+		expectedAdded   []v1alpha1.LifecycleHookSpec
+		shouldRemove    bool
+		shouldAdd       bool
+	}{
+		{expectedRemoved: []string{}, expectedAdded: []v1alpha1.LifecycleHookSpec{}},
+		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{hook1, hook2, hook3}, expectedRemoved: []string{}, expectedAdded: []v1alpha1.LifecycleHookSpec{hook3}, shouldAdd: true},
+		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{hook1, hook3}, expectedRemoved: []string{"my-hook-2"}, expectedAdded: []v1alpha1.LifecycleHookSpec{hook3}, shouldAdd: true, shouldRemove: true},
+		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{hook1, hook2}, expectedRemoved: []string{}, expectedAdded: []v1alpha1.LifecycleHookSpec{}},
+		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{hook1}, expectedRemoved: []string{"my-hook-2"}, expectedAdded: []v1alpha1.LifecycleHookSpec{}, shouldRemove: true},
+		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{}, expectedRemoved: []string{"my-hook-1", "my-hook-2"}, expectedAdded: []v1alpha1.LifecycleHookSpec{}, shouldRemove: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		asgMock.DeleteLifecycleHookCallCount = 0
+		asgMock.PutLifecycleHookCallCount = 0
+
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			LifecycleHooks: tc.asgHooks,
+		})
+		configuration.SetLifecycleHooks(tc.desiredHooks)
+		removed, ok := ctx.GetRemovedHooks()
+		g.Expect(ok).To(gomega.Equal(tc.shouldRemove))
+		g.Expect(removed).To(gomega.Equal(tc.expectedRemoved))
+
+		added, ok := ctx.GetAddedHooks()
+		g.Expect(ok).To(gomega.Equal(tc.shouldAdd))
+		g.Expect(added).To(gomega.Equal(tc.expectedAdded))
+
+		err := ctx.UpdateLifecycleHooks("my-asg")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		if tc.shouldRemove {
+			g.Expect(asgMock.DeleteLifecycleHookCallCount).To(gomega.Equal(uint(len(tc.expectedRemoved))))
+		}
+		if tc.shouldAdd {
+			g.Expect(asgMock.PutLifecycleHookCallCount).To(gomega.Equal(uint(len(tc.expectedAdded))))
+		}
+	}
+}
+
+func TestUpdateWarmPool(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		warmPoolSpec          *v1alpha1.WarmPoolSpec
+		warmPoolConfiguration *autoscaling.WarmPoolConfiguration
+		shouldDelete          bool
+		shouldUpdate          bool
+		shouldRequeue         bool
+	}{
+		// no update/delete needed
+		{warmPoolConfiguration: nil, warmPoolSpec: nil},
+		// enable warm pool - update
+		{warmPoolConfiguration: nil, warmPoolSpec: MockWarmPoolSpec(-1, 0), shouldUpdate: true},
+		// disable warm pool - delete
+		{warmPoolConfiguration: MockWarmPool(-1, 0, ""), warmPoolSpec: nil, shouldDelete: true},
+		// scale change (min)
+		{warmPoolConfiguration: MockWarmPool(-1, 1, ""), warmPoolSpec: MockWarmPoolSpec(-1, 0), shouldUpdate: true},
+		{warmPoolConfiguration: MockWarmPool(-1, 0, ""), warmPoolSpec: MockWarmPoolSpec(-1, 1), shouldUpdate: true},
+		// scale change (max)
+		{warmPoolConfiguration: MockWarmPool(3, 0, ""), warmPoolSpec: MockWarmPoolSpec(-1, 0), shouldUpdate: true},
+		{warmPoolConfiguration: MockWarmPool(-1, 0, ""), warmPoolSpec: MockWarmPoolSpec(3, 0), shouldUpdate: true},
+		// deleting - should requeue
+		{warmPoolConfiguration: MockWarmPool(-1, 0, autoscaling.WarmPoolStatusPendingDelete), warmPoolSpec: nil, shouldRequeue: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("test #%v", i)
+		asgMock.DeleteWarmPoolCallCount = 0
+		asgMock.PutWarmPoolCallCount = 0
+		ig.Spec.EKSSpec.WarmPool = tc.warmPoolSpec
+		scalingGroup := MockScalingGroup("my-asg", false)
+		scalingGroup.WarmPoolConfiguration = tc.warmPoolConfiguration
+
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup: scalingGroup,
+		})
+
+		err := ctx.UpdateWarmPool("my-asg")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		if tc.shouldDelete {
+			g.Expect(asgMock.DeleteWarmPoolCallCount).To(gomega.Equal(uint(1)))
+		}
+		if tc.shouldUpdate {
+			g.Expect(asgMock.PutWarmPoolCallCount).To(gomega.Equal(uint(1)))
+		}
+		if tc.shouldRequeue {
+			g.Expect(asgMock.DeleteWarmPoolCallCount).To(gomega.Equal(uint(0)))
+			g.Expect(asgMock.PutWarmPoolCallCount).To(gomega.Equal(uint(0)))
+		}
+	}
+}
+
+func TestFilterSupportedArch(t *testing.T) {
+	var (
+		g = gomega.NewGomegaWithT(t)
+	)
+
+	tests := []struct {
+		name          string
+		architectures []string
+		expected      string
+	}{
+		{
+			name:          "supported architecture x86",
+			architectures: []string{"x86_64"},
+			expected:      "x86_64",
+		},
+		{
+			name:          "supported architecture arm64",
+			architectures: []string{"arm64"},
+			expected:      "arm64",
+		},
+		{
+			name:          "no supported architecture",
+			architectures: []string{},
+			expected:      "",
+		},
+	}
+
+	for _, tc := range tests {
+		result := FilterSupportedArch(tc.architectures)
+		g.Expect(result).To(gomega.Equal(tc.expected))
+	}
+
+}
+
+func TestGetEksLatestAmi(t *testing.T) {
+	var (
+		k            = MockKubernetesClientSet()
+		ig           = MockInstanceGroup()
+		config       = ig.GetEKSConfiguration()
+		asgMock      = NewAutoScalingMocker()
+		iamMock      = NewIamMocker()
+		eksMock      = NewEksMocker()
+		ec2Mock      = NewEc2Mocker()
+		ssmMock      = NewSsmMocker()
+		instanceType = "m5.large"
+	)
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+
+	tests := []struct {
+		name          string
+		OSFamily      string
+		arch          string
+		expectedError error
+	}{
+		{
+			name:          "AmazonLinux2-x86_64",
+			OSFamily:      "amazonlinux2",
+			arch:          "x86_64",
+			expectedError: nil,
+		},
+		{
+			name:          "bottlerocket-x86_64",
+			OSFamily:      "bottlerocket",
+			arch:          "x86_64",
+			expectedError: nil,
+		},
+		{
+			name:          "AmazonLinux2-noarch",
+			OSFamily:      "amazonlinux2",
+			arch:          "noarch",
+			expectedError: fmt.Errorf("no supported CPU architecture found for instance type %s", instanceType),
+		},
+	}
+
+	for _, tc := range tests {
+		ig.SetAnnotations(map[string]string{
+			OsFamilyAnnotation: tc.OSFamily,
+		})
+		config.InstanceType = instanceType
+		ctx := MockContext(ig, k, w)
+		ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
+			{
+				InstanceType: aws.String(instanceType),
+				ProcessorInfo: &ec2.ProcessorInfo{
+					SupportedArchitectures: []*string{aws.String(tc.arch)},
+				},
+			},
+		})
+		_, err := ctx.GetEksLatestAmi()
+		if err == nil && tc.expectedError == nil {
+			continue
+		}
+		if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
+			t.Fatalf("expected %v got %v, test %s", tc.expectedError, err, tc.name)
+		}
+
+	}
+}
+
+func TestGetEksLatestAmiForAL2023(t *testing.T) {
+	var (
+		k            = MockKubernetesClientSet()
+		ig           = MockInstanceGroup()
+		config       = ig.GetEKSConfiguration()
+		asgMock      = NewAutoScalingMocker()
+		iamMock      = NewIamMocker()
+		eksMock      = NewEksMocker()
+		ec2Mock      = NewEc2Mocker()
+		ssmMock      = NewSsmMocker()
+		instanceType = "m5.large"
+	)
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ig.GetEKSConfiguration().UserData = mockUserDataStages()
+
+	tests := []struct {
+		name          string
+		OSFamily      string
+		arch          string
+		expectedError error
+	}{
+		{
+			name:          "amazonlinux2023",
+			OSFamily:      "",
+			arch:          "x86_64",
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		config.InstanceType = instanceType
+		ctx := MockContext(ig, k, w)
+		ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
+			{
+				InstanceType: aws.String(instanceType),
+				ProcessorInfo: &ec2.ProcessorInfo{
+					SupportedArchitectures: []*string{aws.String(tc.arch)},
+				},
+			},
+		})
+		_, err := ctx.GetEksLatestAmi()
+		if err == nil && tc.expectedError == nil {
+			continue
+		}
+		if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
+			t.Fatalf("expected %v got %v, test %s", tc.expectedError, err, tc.name)
+		}
+
+	}
+}
+
+func TestGetVolumesBottlerocketDataVolume(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ig.Annotations[OsFamilyAnnotation] = OsFamilyBottleRocket
+
+	configuration.Volumes = []v1alpha1.NodeVolume{
+		{Name: "/dev/xvda", Type: "gp3", Size: 10},
+	}
+	configuration.DataVolume = &v1alpha1.NodeVolume{Name: v1alpha1.DefaultDataVolumeName, Type: "gp3", Size: 50}
+
+	volumes := ctx.GetVolumes()
+	g.Expect(volumes).To(gomega.HaveLen(2))
+	g.Expect(volumes[1]).To(gomega.Equal(*configuration.DataVolume))
+	g.Expect(configuration.GetVolumes()).To(gomega.HaveLen(1))
+
+	input := &scaling.CreateConfigurationInput{
+		ImageId:               "ami-123456789012",
+		InstanceType:          "m5.large",
+		IamInstanceProfileArn: "some-arn",
+		SecurityGroups:        []string{},
+		Volumes:               volumes,
+	}
+
+	lt := &scaling.LaunchTemplate{
+		AwsWorker: w,
+		LatestVersion: &ec2.LaunchTemplateVersion{
+			LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+				ImageId:      aws.String(input.ImageId),
+				InstanceType: aws.String(input.InstanceType),
+				IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecification{
+					Arn: aws.String(input.IamInstanceProfileArn),
+				},
+				BlockDeviceMappings: []*ec2.LaunchTemplateBlockDeviceMapping{
+					w.GetLaunchTemplateBlockDevice("/dev/xvda", "gp3", "", 10, 0, 0, nil, nil),
+					w.GetLaunchTemplateBlockDevice(v1alpha1.DefaultDataVolumeName, "gp3", "", 50, 0, 0, nil, nil),
+				},
+			},
+		},
+	}
+	g.Expect(lt.Drifted(input)).To(gomega.BeFalse())
+
+	// resizing the data volume should cause drift, and therefore a rotation
+	configuration.DataVolume.Size = 100
+	input.Volumes = ctx.GetVolumes()
+	g.Expect(lt.Drifted(input)).To(gomega.BeTrue())
+}
+
+func TestValidateUserData(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	// the mock endpoint denies any userdata containing a forbidden command
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &provisioners.UserDataValidationRequest{}
+		json.NewDecoder(r.Body).Decode(request)
+		response := &provisioners.UserDataValidationResponse{Approved: true}
+		if strings.Contains(request.UserData, "curl") {
+			response = &provisioners.UserDataValidationResponse{Approved: false, Reason: "curl is not allowed"}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	// no validation hook configured
+	g.Expect(ctx.ValidateUserData("curl http://example.com")).To(gomega.Succeed())
+	g.Expect(status.GetConditions()).To(gomega.BeEmpty())
+
+	ctx.UserDataValidator = provisioners.NewUserDataValidator(server.URL, time.Second, false, ctx.Log)
+	status.SetConditions([]v1alpha1.InstanceGroupCondition{v1alpha1.NewInstanceGroupCondition(v1alpha1.NodesReady, corev1.ConditionTrue)})
+
+	tests := []struct {
+		userData          string
+		shouldErr         bool
+		expectedCondition corev1.ConditionStatus
+	}{
+		{userData: "echo hello", expectedCondition: corev1.ConditionFalse},
+		{userData: "curl http://example.com", shouldErr: true, expectedCondition: corev1.ConditionTrue},
+		{userData: "curl http://example.com", shouldErr: true, expectedCondition: corev1.ConditionTrue},
+		{userData: "echo hello", expectedCondition: corev1.ConditionFalse},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		err := ctx.ValidateUserData(tc.userData)
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+		g.Expect(status.GetUserDataValidationFailedCondition()).To(gomega.Equal(tc.expectedCondition))
+		// other conditions are preserved
+		g.Expect(status.GetNodesReadyCondition()).To(gomega.Equal(corev1.ConditionTrue))
+	}
+	g.Expect(status.GetConditions()).To(gomega.HaveLen(2))
+}
+
+func TestSyncRegistryCredentials(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	dockerConfig := `{"auths":{"registry.example.com":{"auth":"dXNlcjpwYXNzd29yZA=="}}}`
+	secrets := []*corev1.Secret{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-credentials", Namespace: "instance-manager"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(dockerConfig)},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "opaque-credentials", Namespace: "instance-manager"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("password")},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-credentials", Namespace: "other-namespace"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(dockerConfig)},
+		},
+	}
+	for _, s := range secrets {
+		_, err := k.Kubernetes.CoreV1().Secrets(s.GetNamespace()).Create(context.Background(), s, metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	expectedParameter := "/instance-manager/my-cluster/instance-manager/instance-group-1/registry-credentials"
+
+	tests := []struct {
+		credentials       *v1alpha1.RegistryCredentialsSpec
+		osFamily          string
+		shouldErr         bool
+		expectedParameter string
+		expectedPuts      int
+	}{
+		// no credentials referenced
+		{credentials: nil, expectedParameter: "", expectedPuts: 0},
+		// secrets are resolved from the instance group's namespace
+		{credentials: &v1alpha1.RegistryCredentialsSpec{SecretName: "registry-credentials"}, expectedParameter: expectedParameter, expectedPuts: 1},
+		// parameter is not rewritten when the secret did not change
+		{credentials: &v1alpha1.RegistryCredentialsSpec{SecretName: "registry-credentials"}, expectedParameter: expectedParameter, expectedPuts: 1},
+		{credentials: &v1alpha1.RegistryCredentialsSpec{SecretName: "registry-credentials"}, osFamily: OsFamilyAmazonLinux2023, expectedParameter: expectedParameter, expectedPuts: 1},
+		// missing secrets, secrets of the wrong type and unsupported os families fail
+		{credentials: &v1alpha1.RegistryCredentialsSpec{SecretName: "missing-credentials"}, shouldErr: true, expectedParameter: expectedParameter, expectedPuts: 1},
+		{credentials: &v1alpha1.RegistryCredentialsSpec{SecretName: "opaque-credentials"}, shouldErr: true, expectedParameter: expectedParameter, expectedPuts: 1},
+		{credentials: &v1alpha1.RegistryCredentialsSpec{SecretName: "registry-credentials"}, osFamily: OsFamilyBottleRocket, shouldErr: true, expectedParameter: expectedParameter, expectedPuts: 1},
+		{credentials: &v1alpha1.RegistryCredentialsSpec{SecretName: "registry-credentials"}, osFamily: OsFamilyWindows, shouldErr: true, expectedParameter: expectedParameter, expectedPuts: 1},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		config.RegistryCredentials = tc.credentials
+		ig.SetAnnotations(map[string]string{})
+		if tc.osFamily != "" {
+			ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+		}
+
+		err := ctx.SyncRegistryCredentials()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+		g.Expect(ctx.GetRegistryCredentialsParameter()).To(gomega.Equal(tc.expectedParameter))
+		g.Expect(ssmMock.PutParameterInputs).To(gomega.HaveLen(tc.expectedPuts))
+	}
+
+	input := ssmMock.PutParameterInputs[0]
+	g.Expect(aws.StringValue(input.Name)).To(gomega.Equal(expectedParameter))
+	g.Expect(aws.StringValue(input.Value)).To(gomega.Equal(dockerConfig))
+	g.Expect(aws.StringValue(input.Type)).To(gomega.Equal("SecureString"))
+	g.Expect(aws.BoolValue(input.Overwrite)).To(gomega.BeTrue())
+	g.Expect(ig.GetStatus().GetRegistryCredentialsParameter()).To(gomega.Equal(expectedParameter))
+	g.Expect(ssmMock.DeletedParameters).To(gomega.BeEmpty())
+
+	// the parameter is deleted once registry credentials are removed
+	config.RegistryCredentials = nil
+	ig.SetAnnotations(map[string]string{})
+	g.Expect(ctx.SyncRegistryCredentials()).To(gomega.Succeed())
+	g.Expect(ssmMock.DeletedParameters).To(gomega.Equal([]string{expectedParameter}))
+	g.Expect(ig.GetStatus().GetRegistryCredentialsParameter()).To(gomega.BeEmpty())
+}
+
+func TestUpdateRegistryCredentialsPolicy(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	role := &iam.Role{Arn: aws.String("arn:aws:iam::123456789012:role/some-role"), RoleName: aws.String("some-role")}
+
+	// no policy without registry credentials
+	g.Expect(ctx.UpdateRegistryCredentialsPolicy("some-role", role)).To(gomega.Succeed())
+	g.Expect(iamMock.InlinePolicies).To(gomega.BeEmpty())
+
+	// the managed role is allowed to read and decrypt the parameter
+	config.RegistryCredentials = &v1alpha1.RegistryCredentialsSpec{SecretName: "registry-credentials"}
+	g.Expect(ctx.UpdateRegistryCredentialsPolicy("some-role", role)).To(gomega.Succeed())
+	g.Expect(iamMock.InlinePolicies).To(gomega.HaveKey(RegistryCredentialsPolicyName))
+	document := iamMock.InlinePolicies[RegistryCredentialsPolicyName]
+	g.Expect(document).To(gomega.ContainSubstring(`"Action":"ssm:GetParameter","Resource":"arn:aws:ssm:*:123456789012:parameter/instance-manager/my-cluster/instance-manager/instance-group-1/registry-credentials"`))
+	g.Expect(document).To(gomega.ContainSubstring(`"Action":"kms:Decrypt"`))
+	g.Expect(json.Valid([]byte(document))).To(gomega.BeTrue())
+
+	// the policy is deleted once registry credentials are no longer referenced
+	state.SetInlinePolicies([]string{RegistryCredentialsPolicyName})
+	config.RegistryCredentials = nil
+	g.Expect(ctx.UpdateRegistryCredentialsPolicy("some-role", role)).To(gomega.Succeed())
+	g.Expect(iamMock.InlinePolicies).To(gomega.BeEmpty())
+}
+
+func TestGetBasicUserDataRegistryCredentials(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	for _, osFamily := range []string{OsFamilyAmazonLinux2, OsFamilyAmazonLinux2023} {
+		t.Logf("Test - %v", osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: osFamily})
+
+		config.RegistryCredentials = nil
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil))
+		userData := string(decoded)
+		g.Expect(userData).NotTo(gomega.ContainSubstring("registry-credentials"))
+		g.Expect(userData).NotTo(gomega.ContainSubstring("/var/lib/kubelet/config.json"))
+
+		config.RegistryCredentials = &v1alpha1.RegistryCredentialsSpec{SecretName: "my-secret"}
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil))
+		userData = string(decoded)
+		g.Expect(userData).To(gomega.ContainSubstring(`--name "/instance-manager/my-cluster/instance-manager/instance-group-1/registry-credentials" --with-decryption`))
+		g.Expect(userData).To(gomega.ContainSubstring("> /var/lib/kubelet/config.json"))
+		// only the parameter is referenced, the secret itself never appears in userdata
+		g.Expect(userData).NotTo(gomega.ContainSubstring("my-secret"))
+	}
+}
+
+func TestGetBasicUserDataPrePullImages(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	for _, osFamily := range []string{OsFamilyAmazonLinux2, OsFamilyAmazonLinux2023} {
+		t.Logf("Test - %v", osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: osFamily})
+
+		config.PrePullImages = nil
+		g.Expect(ctx.ValidatePrePullImages()).To(gomega.Succeed())
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("crictl pull"))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("pre-pull-images.service"))
+
+		config.PrePullImages = []string{
+			"123456789012.dkr.ecr.us-west-2.amazonaws.com/app:v1",
+			"docker.io/library/busybox:1.36",
+		}
+		g.Expect(ctx.ValidatePrePullImages()).To(gomega.Succeed())
+		payload := UserDataPayload{PostBootstrap: []string{"echo post-bootstrap"}}
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", payload, nil))
+		userData := string(decoded)
+
+		ecrPull := `crictl pull --creds "AWS:$(aws ecr get-login-password --region us-west-2)" 123456789012.dkr.ecr.us-west-2.amazonaws.com/app:v1`
+		publicPull := "crictl pull docker.io/library/busybox:1.36"
+		g.Expect(userData).To(gomega.ContainSubstring(ecrPull))
+		g.Expect(userData).To(gomega.ContainSubstring(publicPull))
+		// images are pulled by a unit which runs after containerd is started and before the kubelet starts, the unit
+		// is installed before the node bootstraps
+		g.Expect(userData).To(gomega.ContainSubstring("After=containerd.service\nBefore=kubelet.service"))
+		g.Expect(userData).To(gomega.ContainSubstring("[Unit]\nWants=pre-pull-images.service\nAfter=pre-pull-images.service"))
+		g.Expect(userData).To(gomega.ContainSubstring("ExecStart=/etc/eks/pre-pull-images.sh"))
+		bootstrap := strings.Index(userData, "/etc/eks/bootstrap.sh")
+		if osFamily == OsFamilyAmazonLinux2023 {
+			bootstrap = strings.Index(userData, "kind: NodeConfig")
+		}
+		g.Expect(strings.Index(userData, publicPull)).To(gomega.BeNumerically("<", strings.Index(userData, "systemctl daemon-reload")))
+		g.Expect(strings.Index(userData, "systemctl daemon-reload")).To(gomega.BeNumerically("<", bootstrap))
+	}
+
+	for _, osFamily := range []string{OsFamilyBottleRocket, OsFamilyWindows} {
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: osFamily})
+		g.Expect(ctx.ValidatePrePullImages()).NotTo(gomega.Succeed())
+	}
+}
+
+func TestGetBasicUserDataBootstrapRetries(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	config.RegistryCredentials = &v1alpha1.RegistryCredentialsSpec{SecretName: "my-secret"}
+	config.PrePullImages = []string{
+		"123456789012.dkr.ecr.us-west-2.amazonaws.com/app:v1",
+		"docker.io/library/busybox:1.36",
+	}
+
+	for _, osFamily := range []string{OsFamilyAmazonLinux2, OsFamilyAmazonLinux2023} {
+		t.Logf("Test - %v", osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: osFamily})
+
+		config.BootstrapRetries = nil
+		g.Expect(ctx.ValidateBootstrapRetries()).To(gomega.Succeed())
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		userData := string(decoded)
+		g.Expect(userData).NotTo(gomega.ContainSubstring("retry"))
+
+		config.BootstrapRetries = &v1alpha1.BootstrapRetriesSpec{Attempts: 5, Backoff: "10s"}
+		g.Expect(ctx.ValidateBootstrapRetries()).To(gomega.Succeed())
+		payload := UserDataPayload{PreBootstrap: []string{"retry yum install -y amazon-efs-utils"}}
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", payload, nil))
+		userData = string(decoded)
+
+		// the retry function is defined before pre-bootstrap userdata, in every shell script of the userdata
+		g.Expect(userData).To(gomega.ContainSubstring("retry() {"))
+		g.Expect(userData).To(gomega.ContainSubstring("if [[ $attempt -ge 5 ]]; then"))
+		g.Expect(userData).To(gomega.ContainSubstring("sleep $(( 10 * attempt ))"))
+		g.Expect(strings.Index(userData, "retry() {")).To(gomega.BeNumerically("<", strings.Index(userData, "retry yum install")))
+		g.Expect(strings.Count(userData, "retry() {")).To(gomega.Equal(strings.Count(userData, "#!/bin/bash")))
+
+		// registry and image pull operations are retried
+		g.Expect(userData).To(gomega.ContainSubstring(`(umask 077 && retry aws ssm get-parameter --region "$REGISTRY_REGION"`))
+		g.Expect(userData).To(gomega.ContainSubstring(`retry crictl pull --creds "AWS:$(retry aws ecr get-login-password --region us-west-2)" 123456789012.dkr.ecr.us-west-2.amazonaws.com/app:v1`))
+		g.Expect(userData).To(gomega.ContainSubstring("retry crictl pull docker.io/library/busybox:1.36"))
+	}
+
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyWindows})
+	config.RegistryCredentials = nil
+	config.PrePullImages = nil
+	config.BootstrapRetries = nil
+	decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+	g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("Invoke-WithRetry"))
+
+	config.BootstrapRetries = &v1alpha1.BootstrapRetriesSpec{Attempts: 3}
+	g.Expect(ctx.ValidateBootstrapRetries()).To(gomega.Succeed())
+	decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+	userData := string(decoded)
+	g.Expect(userData).To(gomega.ContainSubstring("function Invoke-WithRetry([scriptblock]$ScriptBlock) {"))
+	g.Expect(userData).To(gomega.ContainSubstring("if ($Attempt -ge 3) { throw }"))
+	g.Expect(userData).To(gomega.ContainSubstring("Start-Sleep -Seconds (5 * $Attempt)"))
+	g.Expect(userData).To(gomega.ContainSubstring(`[string]$IMDSToken=(Invoke-WithRetry { curl -UseBasicParsing -Method PUT`))
+
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyBottleRocket})
+	g.Expect(ctx.ValidateBootstrapRetries()).NotTo(gomega.Succeed())
+}
+
+func TestGetBasicUserDataInstanceStorage(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	for _, osFamily := range []string{OsFamilyAmazonLinux2, OsFamilyAmazonLinux2023} {
+		t.Logf("Test - %v", osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: osFamily})
+
+		config.InstanceStorage = nil
+		g.Expect(ctx.ValidateInstanceStorage()).To(gomega.Succeed())
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("Amazon EC2 NVMe Instance Storage"))
+
+		// defaults to an xfs array mounted at the containerd root
+		config.InstanceStorage = &v1alpha1.InstanceStorageSpec{}
+		g.Expect(ctx.ValidateInstanceStorage()).To(gomega.Succeed())
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		userData := string(decoded)
+
+		g.Expect(userData).To(gomega.ContainSubstring("awk '/Amazon EC2 NVMe Instance Storage/ {print $1}'"))
+		g.Expect(userData).To(gomega.ContainSubstring(`echo "no instance store volumes found, skipping instance storage setup"`))
+		g.Expect(userData).To(gomega.ContainSubstring("mdadm --create --force --verbose $INSTANCE_STORE_DEVICE --level=0 --name=instance-store --raid-devices=$INSTANCE_STORE_COUNT $INSTANCE_STORE_DEVICES"))
+		g.Expect(userData).To(gomega.ContainSubstring("mkfs.xfs -f $INSTANCE_STORE_DEVICE"))
+		g.Expect(userData).To(gomega.ContainSubstring("mount $INSTANCE_STORE_DEVICE /var/lib/containerd"))
+		g.Expect(userData).To(gomega.ContainSubstring(`echo "$INSTANCE_STORE_DEVICE    /var/lib/containerd    xfs    defaults,nofail    0    2" >> /etc/fstab`))
+		// warmed instances exit before setting up instance storage, which is set up before the node bootstraps
+		g.Expect(strings.Index(userData, "exit 0")).To(gomega.BeNumerically("<", strings.Index(userData, "mdadm --create")))
+		if osFamily == OsFamilyAmazonLinux2 {
+			g.Expect(strings.Index(userData, "mdadm --create")).To(gomega.BeNumerically("<", strings.Index(userData, "/etc/eks/bootstrap.sh")))
+		} else {
+			g.Expect(strings.Index(userData, "mdadm --create")).To(gomega.BeNumerically("<", strings.Index(userData, "kind: NodeConfig")))
+		}
+
+		config.InstanceStorage = &v1alpha1.InstanceStorageSpec{MountPath: "/mnt/data", FileSystem: "ext4"}
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		userData = string(decoded)
+		g.Expect(userData).To(gomega.ContainSubstring("mkfs.ext4 -F $INSTANCE_STORE_DEVICE"))
+		g.Expect(userData).To(gomega.ContainSubstring("mount $INSTANCE_STORE_DEVICE /mnt/data"))
+		g.Expect(userData).NotTo(gomega.ContainSubstring("/var/lib/containerd"))
+	}
+
+	for _, osFamily := range []string{OsFamilyBottleRocket, OsFamilyWindows} {
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: osFamily})
+		g.Expect(ctx.ValidateInstanceStorage()).NotTo(gomega.Succeed())
+	}
+}
+
+func TestGetBasicUserDataInstanceStoragePolicy(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
+		{InstanceType: aws.String("m5.large"), InstanceStorageSupported: aws.Bool(false)},
+		{InstanceType: aws.String("m5d.large"), InstanceStorageSupported: aws.Bool(true)},
+	})
+
+	tests := []struct {
+		policy        string
+		instanceType  string
+		mixedTypes    []string
+		instanceStore bool
+	}{
+		// instance store is preferred by default
+		{policy: "", instanceType: "m5d.large", instanceStore: true},
+		{policy: v1alpha1.InstanceStoragePolicyPreferInstanceStore, instanceType: "m5d.large", instanceStore: true},
+		// instance types without instance store fall back to EBS
+		{policy: v1alpha1.InstanceStoragePolicyPreferInstanceStore, instanceType: "m5.large", instanceStore: false},
+		// nodes of mixed instance types without instance store skip the setup at bootstrap
+		{policy: v1alpha1.InstanceStoragePolicyPreferInstanceStore, instanceType: "m5.large", mixedTypes: []string{"m5d.large"}, instanceStore: true},
+		// instance types which were not discovered may have instance store
+		{policy: v1alpha1.InstanceStoragePolicyPreferInstanceStore, instanceType: "r5d.large", instanceStore: true},
+		// container storage always lives on EBS
+		{policy: v1alpha1.InstanceStoragePolicyEBSOnly, instanceType: "m5d.large", instanceStore: false},
+		{policy: v1alpha1.InstanceStoragePolicyEBSOnly, instanceType: "m5.large", instanceStore: false},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		config.InstanceType = tc.instanceType
+		config.MixedInstancesPolicy = nil
+		if tc.mixedTypes != nil {
+			config.MixedInstancesPolicy = &v1alpha1.MixedInstancesPolicySpec{}
+			for _, instanceType := range tc.mixedTypes {
+				config.MixedInstancesPolicy.InstanceTypes = append(config.MixedInstancesPolicy.InstanceTypes, &v1alpha1.InstanceTypeSpec{Type: instanceType, Weight: 1})
+			}
+		}
+		config.InstanceStorage = &v1alpha1.InstanceStorageSpec{Policy: tc.policy}
+
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		userData := string(decoded)
+		if tc.instanceStore {
+			g.Expect(ctx.GetInstanceStorage()).NotTo(gomega.BeNil())
+			g.Expect(userData).To(gomega.ContainSubstring("awk '/Amazon EC2 NVMe Instance Storage/ {print $1}'"))
+			g.Expect(userData).To(gomega.ContainSubstring("mount $INSTANCE_STORE_DEVICE /var/lib/containerd"))
+		} else {
+			g.Expect(ctx.GetInstanceStorage()).To(gomega.BeNil())
+			g.Expect(userData).NotTo(gomega.ContainSubstring("Amazon EC2 NVMe Instance Storage"))
+			g.Expect(userData).NotTo(gomega.ContainSubstring("mdadm"))
+		}
+	}
+}
+
+func TestGetBasicUserDataImageGCThresholds(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		osFamily string
+		expected []string
+	}{
+		{osFamily: OsFamilyAmazonLinux2, expected: []string{"--image-gc-high-threshold=70", "--image-gc-low-threshold=50"}},
+		{osFamily: OsFamilyWindows, expected: []string{"--image-gc-high-threshold=70", "--image-gc-low-threshold=50"}},
+		{osFamily: OsFamilyBottleRocket, expected: []string{"image-gc-high-threshold-percent = 70", "image-gc-low-threshold-percent = 50"}},
+		{osFamily: OsFamilyAmazonLinux2023, expected: []string{"    config:\n      imageGCHighThresholdPercent: 70\n      imageGCLowThresholdPercent: 50\n    flags:"}},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test - %v", tc.osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+
+		config.BootstrapOptions = nil
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(strings.ToLower(string(decoded))).NotTo(gomega.ContainSubstring("image-gc"))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("imageGC"))
+
+		config.BootstrapOptions = &v1alpha1.BootstrapOptions{
+			ImageGCHighThreshold: aws.Int64(70),
+			ImageGCLowThreshold:  aws.Int64(50),
+		}
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		for _, expected := range tc.expected {
+			g.Expect(string(decoded)).To(gomega.ContainSubstring(expected))
+		}
+	}
+
+	// a single threshold is rendered without the other
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyBottleRocket})
+	config.BootstrapOptions = &v1alpha1.BootstrapOptions{ImageGCHighThreshold: aws.Int64(90)}
+	decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil))
+	g.Expect(string(decoded)).To(gomega.ContainSubstring("image-gc-high-threshold-percent = 90"))
+	g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("image-gc-low-threshold-percent"))
+}
+
+func TestGetBasicUserDataImagePulls(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		osFamily string
+		expected []string
+	}{
+		{osFamily: OsFamilyAmazonLinux2, expected: []string{`echo "$(jq '.serializeImagePulls=false | .maxParallelImagePulls=5' $KUBELET_CONFIG)" > $KUBELET_CONFIG`}},
+		{osFamily: OsFamilyAmazonLinux2023, expected: []string{"    config:\n      serializeImagePulls: false\n      maxParallelImagePulls: 5\n    flags:"}},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test - %v", tc.osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+
+		config.BootstrapOptions = nil
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("image-pulls"))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("ImagePulls"))
+
+		config.BootstrapOptions = &v1alpha1.BootstrapOptions{
+			SerializeImagePulls:   aws.Bool(false),
+			MaxParallelImagePulls: aws.Int64(5),
+		}
+		g.Expect(ctx.ValidateImagePulls()).To(gomega.Succeed())
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		for _, expected := range tc.expected {
+			g.Expect(string(decoded)).To(gomega.ContainSubstring(expected))
+		}
+		g.Expect(ctx.GetKubeletExtraArgs()).NotTo(gomega.ContainSubstring("image-pulls"))
+	}
+
+	// serialized image pulls are rendered without a parallel pull limit
+	for _, osFamily := range []string{OsFamilyAmazonLinux2, OsFamilyAmazonLinux2023} {
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: osFamily})
+		config.BootstrapOptions = &v1alpha1.BootstrapOptions{SerializeImagePulls: aws.Bool(true), MaxParallelImagePulls: aws.Int64(1)}
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil))
+		g.Expect(string(decoded)).To(gomega.Or(gomega.ContainSubstring("serializeImagePulls: true"), gomega.ContainSubstring(".serializeImagePulls=true'")))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("maxParallelImagePulls"))
+	}
+
+	// bottlerocket settings and the windows bootstrap do not configure image pulls
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyWindows})
+	config.BootstrapOptions = &v1alpha1.BootstrapOptions{SerializeImagePulls: aws.Bool(false)}
+	g.Expect(ctx.ValidateImagePulls()).NotTo(gomega.Succeed())
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyBottleRocket})
+	g.Expect(ctx.ValidateImagePulls()).NotTo(gomega.Succeed())
+	config.BootstrapOptions = &v1alpha1.BootstrapOptions{ImageGCHighThreshold: aws.Int64(90)}
+	g.Expect(ctx.ValidateImagePulls()).To(gomega.Succeed())
+}
+
+func TestGetBasicUserDataShutdownGracePeriod(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		osFamily string
+		expected []string
+	}{
+		{osFamily: OsFamilyAmazonLinux2, expected: []string{`jq '.shutdownGracePeriod="60s" | .shutdownGracePeriodCriticalPods="20s"' $KUBELET_CONFIG`}},
+		{osFamily: OsFamilyAmazonLinux2023, expected: []string{"    config:\n      shutdownGracePeriod: 60s\n      shutdownGracePeriodCriticalPods: 20s\n    flags:"}},
+		{osFamily: OsFamilyBottleRocket, expected: []string{"shutdown-grace-period = \"60s\"\nshutdown-grace-period-for-critical-pods = \"20s\"\n"}},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test - %v", tc.osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+
+		config.BootstrapOptions = nil
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("shutdownGracePeriod"))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("shutdown-grace-period"))
+
+		config.BootstrapOptions = &v1alpha1.BootstrapOptions{
+			ShutdownGracePeriod:             "60s",
+			ShutdownGracePeriodCriticalPods: "20s",
+		}
+		g.Expect(ctx.ValidateShutdownGracePeriod()).To(gomega.Succeed())
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		for _, expected := range tc.expected {
+			g.Expect(string(decoded)).To(gomega.ContainSubstring(expected))
+		}
+	}
+
+	// the period reserved for critical pods is left to the kubelet default when it is not set
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyAmazonLinux2023})
+	config.BootstrapOptions = &v1alpha1.BootstrapOptions{ShutdownGracePeriod: "45s"}
+	decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil))
+	g.Expect(string(decoded)).To(gomega.ContainSubstring("shutdownGracePeriod: 45s"))
+	g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("shutdownGracePeriodCriticalPods"))
+
+	// the windows kubelet does not delay node shutdown
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyWindows})
+	g.Expect(ctx.ValidateShutdownGracePeriod()).NotTo(gomega.Succeed())
+	config.BootstrapOptions = &v1alpha1.BootstrapOptions{MaxPods: 10}
+	g.Expect(ctx.ValidateShutdownGracePeriod()).To(gomega.Succeed())
+}
+
+func TestGetBasicUserDataKubeletConfiguration(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	fragment := `{"apiVersion": "kubelet.config.k8s.io/v1beta1", "kind": "KubeletConfiguration", "cpuManagerPolicy": "static", "featureGates": {"InPlacePodVerticalScaling": true}, "maxPods": 300}`
+	tests := []struct {
+		osFamily string
+		expected []string
+	}{
+		{osFamily: OsFamilyAmazonLinux2, expected: []string{
+			"cat <<'EOF' > /etc/kubernetes/kubelet/kubelet-config-fragment.json\n{\"cpuManagerPolicy\": \"static\",\"featureGates\": {\"InPlacePodVerticalScaling\":true}}\nEOF\n",
+			`jq -s '.[0] * .[1]' $KUBELET_CONFIG /etc/kubernetes/kubelet/kubelet-config-fragment.json`,
+		}},
+		{osFamily: OsFamilyAmazonLinux2023, expected: []string{
+			"    config:\n      cpuManagerPolicy: \"static\"\n      featureGates: {\"InPlacePodVerticalScaling\":true}\n      shutdownGracePeriod: 60s\n    flags:",
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test - %v", tc.osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+
+		config.BootstrapOptions = nil
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("kubelet-config-fragment"))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("config:"))
+
+		// the fragment is merged into the kubelet config, managed fields keep their managed values
+		config.BootstrapOptions = &v1alpha1.BootstrapOptions{
+			ShutdownGracePeriod:  "60s",
+			KubeletConfiguration: &runtime.RawExtension{Raw: []byte(fragment)},
+		}
+		g.Expect(ctx.ValidateKubeletConfiguration()).To(gomega.Succeed())
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		for _, expected := range tc.expected {
+			g.Expect(string(decoded)).To(gomega.ContainSubstring(expected))
+		}
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("300"))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("KubeletConfiguration"))
+	}
+
+	// only the kubelet config of AL2 and AL2023 can be merged with a fragment
+	for _, osFamily := range []string{OsFamilyBottleRocket, OsFamilyWindows} {
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: osFamily})
+		g.Expect(ctx.ValidateKubeletConfiguration()).NotTo(gomega.Succeed())
+	}
+	config.BootstrapOptions = &v1alpha1.BootstrapOptions{MaxPods: 10}
+	g.Expect(ctx.ValidateKubeletConfiguration()).To(gomega.Succeed())
+}
+
+func TestGetBasicUserDataSysctls(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	dropIn := "cat <<'EOF' > /etc/sysctl.d/99-instance-manager.conf\nnet.core.somaxconn = 4096\nnet.ipv4.tcp_rmem = 4096 87380 16777216\nvm.max_map_count = 262144\nEOF\nsysctl --system\n"
+	tests := []struct {
+		osFamily string
+		expected string
+	}{
+		{osFamily: OsFamilyAmazonLinux2, expected: dropIn},
+		{osFamily: OsFamilyAmazonLinux2023, expected: dropIn},
+		{osFamily: OsFamilyBottleRocket, expected: "[settings.kernel.sysctl]\n\"net.core.somaxconn\" = \"4096\"\n\"net.ipv4.tcp_rmem\" = \"4096 87380 16777216\"\n\"vm.max_map_count\" = \"262144\"\n"},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test - %v", tc.osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+
+		config.Sysctls = nil
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("sysctl"))
+
+		config.Sysctls = map[string]string{
+			"vm.max_map_count":   "262144",
+			"net.core.somaxconn": "4096",
+			"net.ipv4.tcp_rmem":  "4096 87380 16777216",
+		}
+		g.Expect(ctx.ValidateSysctls()).To(gomega.Succeed())
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(string(decoded)).To(gomega.ContainSubstring(tc.expected))
+	}
+
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyWindows})
+	g.Expect(ctx.ValidateSysctls()).NotTo(gomega.Succeed())
+	config.Sysctls = nil
+	g.Expect(ctx.ValidateSysctls()).To(gomega.Succeed())
+}
+
+func TestGetBasicUserDataProxy(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.SetClusterEndpoint("https://ABCDEF.gr7.us-west-2.eks.amazonaws.com")
+	state.SetVPCCidrs([]string{"10.0.0.0/16"})
+	state.SetCluster(&eks.Cluster{
+		KubernetesNetworkConfig: &eks.KubernetesNetworkConfigResponse{ServiceIpv4Cidr: aws.String("172.20.0.0/16")},
+	})
+
+	noProxy := "localhost,127.0.0.1,169.254.169.254,fd00:ec2::254,.internal,ABCDEF.gr7.us-west-2.eks.amazonaws.com,10.0.0.0/16,172.20.0.0/16,.corp.example.com"
+	linuxProxy := "cat <<'EOF' >> /etc/environment\nHTTPS_PROXY=http://proxy.corp.example.com:3128\nhttps_proxy=http://proxy.corp.example.com:3128\nNO_PROXY=" + noProxy + "\nno_proxy=" + noProxy + "\nEOF\n"
+	dropIn := "cat <<'EOF' > /etc/systemd/system/$unit.service.d/http-proxy.conf\n[Service]\nEnvironmentFile=/etc/environment\nEOF\n"
+	tests := []struct {
+		osFamily string
+		expected []string
+	}{
+		{osFamily: OsFamilyAmazonLinux2, expected: []string{linuxProxy, dropIn, "systemctl daemon-reload\nset -a\n. /etc/environment\nset +a\n"}},
+		{osFamily: OsFamilyAmazonLinux2023, expected: []string{linuxProxy, dropIn, "#!/bin/bash" + "\nset -a\n. /etc/environment\nset +a\nset +o xtrace"}},
+		{osFamily: OsFamilyBottleRocket, expected: []string{"[settings.network]\nhttps-proxy = \"http://proxy.corp.example.com:3128\"\nno-proxy = [\"localhost\", \"127.0.0.1\", \"169.254.169.254\", \"fd00:ec2::254\", \".internal\", \"ABCDEF.gr7.us-west-2.eks.amazonaws.com\", \"10.0.0.0/16\", \"172.20.0.0/16\", \".corp.example.com\"]\n"}},
+		{osFamily: OsFamilyWindows, expected: []string{
+			"$ProxyEnvironment = @(\"HTTPS_PROXY=http://proxy.corp.example.com:3128\", \"NO_PROXY=" + noProxy + "\")",
+			"Set-ItemProperty -Path HKLM:\\SYSTEM\\CurrentControlSet\\Services\\containerd -Name Environment -Type MultiString -Value $ProxyEnvironment\n    Restart-Service containerd",
+			"Set-ItemProperty -Path HKLM:\\SYSTEM\\CurrentControlSet\\Services\\kubelet -Name Environment -Type MultiString -Value $ProxyEnvironment\n    Restart-Service kubelet",
 		}},
-		{volumes: []v1alpha1.NodeVolume{volumeWithOpts2, volumeWithOpts}, expectedMounts: []MountOpts{
-			{
-				FileSystem:  "xfs",
-				Device:      "/dev/xvda2",
-				Mount:       "/data",
-				Persistance: false,
-			},
-			{
-				FileSystem:  "xfs",
-				Device:      "/dev/xvda3",
-				Mount:       "/data2",
-				Persistance: true,
-			},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test - %v", tc.osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+
+		config.Proxy = nil
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(strings.ToLower(string(decoded))).NotTo(gomega.ContainSubstring("proxy"))
+
+		config.Proxy = &v1alpha1.ProxySpec{
+			HTTPSProxy: "http://proxy.corp.example.com:3128",
+			NoProxy:    []string{".corp.example.com", "localhost"},
+		}
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		for _, expected := range tc.expected {
+			g.Expect(string(decoded)).To(gomega.ContainSubstring(expected))
+		}
+	}
+}
+
+func TestGetBasicUserDataCABundle(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+
+	certificate := strings.TrimSpace(MockCertificate)
+	block, _ := pem.Decode([]byte(MockCertificate))
+	encoded := base64.StdEncoding.EncodeToString(block.Bytes)
+	linuxCABundle := "cat <<'EOF' > /etc/pki/ca-trust/source/anchors/instance-manager-ca-bundle.crt\n" + certificate + "\n" + certificate + "\nEOF\nupdate-ca-trust extract\nsystemctl try-restart containerd\n"
+	tests := []struct {
+		osFamily string
+		expected []string
+	}{
+		{osFamily: OsFamilyAmazonLinux2, expected: []string{linuxCABundle}},
+		{osFamily: OsFamilyAmazonLinux2023, expected: []string{linuxCABundle}},
+		{osFamily: OsFamilyBottleRocket, expected: []string{"[settings.pki.instance-manager-ca-bundle]\ndata = \"" + base64.StdEncoding.EncodeToString([]byte(certificate+"\n"+certificate+"\n")) + "\"\ntrusted = true\n"}},
+		{osFamily: OsFamilyWindows, expected: []string{
+			"foreach ($Certificate in @(\"" + encoded + "\", \"" + encoded + "\")) {",
+			"$CertificateStore.Add([System.Security.Cryptography.X509Certificates.X509Certificate2]::new([Convert]::FromBase64String($Certificate)))\n    }\n    $CertificateStore.Close()\n    Restart-Service containerd",
 		}},
-		{volumes: []v1alpha1.NodeVolume{volumeNoOpts}, expectedMounts: []MountOpts{}},
-		{volumes: []v1alpha1.NodeVolume{volumeInvalidOpts, volumeInvalidOpts2}, expectedMounts: []MountOpts{}},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test - %v", tc.osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+
+		state.SetCABundle("")
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("ca-bundle"))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("CertificateStore"))
+
+		state.SetCABundle(MockCertificate + "\n" + MockCertificate)
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		for _, expected := range tc.expected {
+			g.Expect(string(decoded)).To(gomega.ContainSubstring(expected))
+		}
+	}
+}
+
+func TestGetMetadataOptions(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		osFamily        string
+		irsaEnabled     bool
+		metadataOptions *v1alpha1.MetadataOptions
+		expected        *v1alpha1.MetadataOptions
+	}{
+		// launch templates keep the EC2 defaults unless metadata options are set
+		{osFamily: OsFamilyAmazonLinux2},
+		{osFamily: OsFamilyAmazonLinux2, irsaEnabled: true},
+		// pods reach the metadata service unless they use IRSA
+		{osFamily: OsFamilyAmazonLinux2, metadataOptions: &v1alpha1.MetadataOptions{HttpTokens: "required"}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "required", HttpPutHopLimit: 2}},
+		{osFamily: OsFamilyAmazonLinux2, irsaEnabled: true, metadataOptions: &v1alpha1.MetadataOptions{HttpTokens: "required"}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "required", HttpPutHopLimit: 1}},
+		{osFamily: OsFamilyAmazonLinux2023, irsaEnabled: true, metadataOptions: &v1alpha1.MetadataOptions{HttpTokens: "required"}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "required", HttpPutHopLimit: 1}},
+		{osFamily: OsFamilyBottleRocket, metadataOptions: &v1alpha1.MetadataOptions{HttpTokens: "required"}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "required", HttpPutHopLimit: 2}},
+		{osFamily: OsFamilyBottleRocket, irsaEnabled: true, metadataOptions: &v1alpha1.MetadataOptions{HttpTokens: "required"}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "required", HttpPutHopLimit: 1}},
+		{osFamily: OsFamilyWindows, irsaEnabled: true, metadataOptions: &v1alpha1.MetadataOptions{HttpTokens: "required"}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "required", HttpPutHopLimit: 2}},
+		// the hop limit can be set without the other options
+		{osFamily: OsFamilyAmazonLinux2, irsaEnabled: true, metadataOptions: &v1alpha1.MetadataOptions{HttpPutHopLimit: 3}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "optional", HttpPutHopLimit: 3}},
+		{osFamily: OsFamilyAmazonLinux2, metadataOptions: &v1alpha1.MetadataOptions{HttpTokens: "required", HttpPutHopLimit: 1}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "enabled", HttpTokens: "required", HttpPutHopLimit: 1}},
+		{osFamily: OsFamilyAmazonLinux2, metadataOptions: &v1alpha1.MetadataOptions{HttpEndpoint: "disabled"}, expected: &v1alpha1.MetadataOptions{HttpEndpoint: "disabled", HttpTokens: "optional", HttpPutHopLimit: 2}},
 	}
 
 	for i, tc := range tests {
-		t.Logf("Test #%v - %+v", i, tc)
-		configuration.Volumes = tc.volumes
-		mounts := ctx.GetMountOpts()
-		g.Expect(mounts).To(gomega.ConsistOf(tc.expectedMounts))
+		t.Logf("Test #%v - %v, irsa: %v", i, tc.osFamily, tc.irsaEnabled)
+		ig.SetAnnotations(map[string]string{
+			OsFamilyAnnotation:    tc.osFamily,
+			IRSAEnabledAnnotation: strconv.FormatBool(tc.irsaEnabled),
+		})
+		config.MetadataOptions = tc.metadataOptions
+		g.Expect(ctx.GetMetadataOptions()).To(gomega.Equal(tc.expected))
+	}
+
+	// the configured options are not modified by defaulting
+	config.MetadataOptions = &v1alpha1.MetadataOptions{HttpPutHopLimit: 3}
+	ctx.GetMetadataOptions()
+	g.Expect(config.MetadataOptions).To(gomega.Equal(&v1alpha1.MetadataOptions{HttpPutHopLimit: 3}))
+
+	// launch templates created without metadata options do not drift unless metadata options are set
+	lt := &scaling.LaunchTemplate{
+		LatestVersion: &ec2.LaunchTemplateVersion{
+			LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+				IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecification{Arn: aws.String("")},
+				InstanceType:       aws.String(""),
+				SecurityGroupIds:   aws.StringSlice([]string{}),
+				ImageId:            aws.String(""),
+				KeyName:            aws.String(""),
+				UserData:           aws.String(""),
+			},
+		},
 	}
+	config.MetadataOptions = nil
+	g.Expect(lt.Drifted(&scaling.CreateConfigurationInput{SecurityGroups: []string{}, MetadataOptions: ctx.GetMetadataOptions()})).To(gomega.BeFalse())
+	config.MetadataOptions = &v1alpha1.MetadataOptions{HttpTokens: "required"}
+	g.Expect(lt.Drifted(&scaling.CreateConfigurationInput{SecurityGroups: []string{}, MetadataOptions: ctx.GetMetadataOptions()})).To(gomega.BeTrue())
 }
 
-func TestGetOverrides(t *testing.T) {
+func TestGetBasicUserDataAllowedUnsafeSysctls(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		osFamily string
+		expected string
+	}{
+		{osFamily: OsFamilyAmazonLinux2, expected: `echo "$(jq '.allowedUnsafeSysctls=["net.core.somaxconn","kernel.msg*"]' $KUBELET_CONFIG)" > $KUBELET_CONFIG`},
+		{osFamily: OsFamilyAmazonLinux2023, expected: "    config:\n      allowedUnsafeSysctls:\n        - \"net.core.somaxconn\"\n        - \"kernel.msg*\"\n    flags:"},
+		{osFamily: OsFamilyBottleRocket, expected: `allowed-unsafe-sysctls = ["net.core.somaxconn", "kernel.msg*"]`},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test - %v", tc.osFamily)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+
+		config.BootstrapOptions = nil
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(string(decoded)).NotTo(gomega.ContainSubstring("llowed"))
+
+		config.BootstrapOptions = &v1alpha1.BootstrapOptions{AllowedUnsafeSysctls: []string{"net.core.somaxconn", "kernel.msg*"}}
+		g.Expect(ctx.ValidateSysctls()).To(gomega.Succeed())
+		decoded, _ = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), ctx.GetKubeletExtraArgs(), UserDataPayload{}, nil))
+		g.Expect(string(decoded)).To(gomega.ContainSubstring(tc.expected))
+	}
+
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyWindows})
+	g.Expect(ctx.ValidateSysctls()).NotTo(gomega.Succeed())
+}
+
+func TestVpcCNIVersionPin(t *testing.T) {
 	var (
 		g             = gomega.NewGomegaWithT(t)
 		k             = MockKubernetesClientSet()
@@ -953,71 +3484,257 @@ func TestGetOverrides(t *testing.T) {
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
-	state := ctx.GetDiscoveredState()
-
-	instancePool := "SubFamilyFlexible"
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		Cluster: MockEksCluster("1.29"),
+	})
 
 	tests := []struct {
-		primaryType        string
-		scalingGroup       *autoscaling.Group
-		mixedInstancesSpec *v1alpha1.MixedInstancesPolicySpec
-		expectedOverrides  []*autoscaling.LaunchTemplateOverrides
+		osFamily      string
+		vpcCNIVersion string
+		expectedPin   string
 	}{
+		{osFamily: OsFamilyAmazonLinux2, expectedPin: ""},
+		{osFamily: OsFamilyAmazonLinux2, vpcCNIVersion: "v1.18.3", expectedPin: "instancemgr.keikoproj.io/vpc-cni-version=v1.18.3"},
+		{osFamily: OsFamilyAmazonLinux2023, vpcCNIVersion: "v1.18.3-eksbuild.1", expectedPin: "instancemgr.keikoproj.io/vpc-cni-version=v1.18.3-eksbuild.1"},
+		{osFamily: OsFamilyBottleRocket, vpcCNIVersion: "v1.18.3", expectedPin: `"instancemgr.keikoproj.io/vpc-cni-version" = "v1.18.3"`},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+		configuration.VpcCNIVersion = tc.vpcCNIVersion
+
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		userData := string(decoded)
+		if tc.expectedPin == "" {
+			g.Expect(userData).NotTo(gomega.ContainSubstring(InstanceMgrVpcCNILabel))
+			continue
+		}
+		g.Expect(userData).To(gomega.ContainSubstring(tc.expectedPin))
+	}
+}
+
+func TestPrefixAssignmentConfiguration(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	config.InstanceType = "t3.nano"
+	ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
 		{
-			primaryType:  "m5.xlarge",
-			scalingGroup: MockScalingGroup("asg-1", true),
-			mixedInstancesSpec: &v1alpha1.MixedInstancesPolicySpec{
-				InstanceTypes: []*v1alpha1.InstanceTypeSpec{
-					{
-						Type:   "m5a.xlarge",
-						Weight: 1,
-					},
-					{
-						Type:   "m5g.xlarge",
-						Weight: 1,
-					},
-				},
+			InstanceType: aws.String("t3.nano"),
+			Hypervisor:   aws.String("nitro"),
+			NetworkInfo: &ec2.NetworkInfo{
+				MaximumNetworkInterfaces:  aws.Int64(2),
+				Ipv4AddressesPerInterface: aws.Int64(2),
 			},
-			expectedOverrides: MockTemplateOverrides("1", "m5a.xlarge", "m5g.xlarge", "m5.xlarge"),
 		},
+	})
+
+	tests := []struct {
+		annotations      map[string]string
+		bootstrapOptions *v1alpha1.BootstrapOptions
+		expectedArgs     string
+		expectedStatus   *v1alpha1.PrefixDensityStatus
+	}{
+		// prefix assignment disabled
+		{annotations: map[string]string{}, expectedArgs: "", expectedStatus: nil},
+		{annotations: map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "false"}, expectedArgs: "", expectedStatus: nil},
+		// all interfaces are used for prefixes: 2 * ((2 - 1) * 16) + 2
 		{
-			primaryType:  "m5.xlarge",
-			scalingGroup: MockScalingGroup("asg-1", true),
-			mixedInstancesSpec: &v1alpha1.MixedInstancesPolicySpec{
-				InstancePool: &instancePool,
-			},
-			expectedOverrides: MockTemplateOverrides("1", "m5.xlarge"),
+			annotations:    map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true"},
+			expectedArgs:   "--max-pods=34",
+			expectedStatus: &v1alpha1.PrefixDensityStatus{MaxPods: 34},
 		},
 		{
-			primaryType:  "t2.xlarge",
-			scalingGroup: MockScalingGroup("asg-1", true),
-			mixedInstancesSpec: &v1alpha1.MixedInstancesPolicySpec{
-				InstanceTypes: []*v1alpha1.InstanceTypeSpec{
-					{
-						Type:   "t2a.xlarge",
-						Weight: 1,
-					},
-					{
-						Type:   "t2g.xlarge",
-						Weight: 1,
-					},
-				},
-			},
-			expectedOverrides: MockTemplateOverrides("1", "t2a.xlarge", "t2g.xlarge", "t2.xlarge", "m5.xlarge"),
+			annotations:    map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true", CustomNetworkingHostPodsAnnotation: "4"},
+			expectedArgs:   "--max-pods=36",
+			expectedStatus: &v1alpha1.PrefixDensityStatus{MaxPods: 36},
+		},
+		// the primary interface is not used for pods with custom networking: (2 - 1) * ((2 - 1) * 16) + 2
+		{
+			annotations:    map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true", CustomNetworkingEnabledAnnotation: "true"},
+			expectedArgs:   "--max-pods=18",
+			expectedStatus: &v1alpha1.PrefixDensityStatus{CustomNetworkingEnabled: true, MaxPods: 18},
+		},
+		// explicitly configured max pods are preserved
+		{
+			annotations:      map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true"},
+			bootstrapOptions: &v1alpha1.BootstrapOptions{MaxPods: 20},
+			expectedArgs:     "--max-pods=20",
+			expectedStatus:   &v1alpha1.PrefixDensityStatus{MaxPods: 20},
 		},
 	}
 
 	for i, tc := range tests {
-		t.Logf("Test #%v - %+v", i, tc.expectedOverrides)
-		configuration.MixedInstancesPolicy = tc.mixedInstancesSpec
-		state.ScalingGroup = tc.scalingGroup
-		ig.Spec.EKSSpec.EKSConfiguration.InstanceType = tc.primaryType
-		overrides := ctx.GetOverrides()
-		g.Expect(overrides).To(gomega.ConsistOf(tc.expectedOverrides))
+		t.Logf("Test #%v - %+v", i, tc.annotations)
+		config.BootstrapOptions = tc.bootstrapOptions
+
+		for _, osFamily := range []string{OsFamilyAmazonLinux2, OsFamilyAmazonLinux2023} {
+			annotations := map[string]string{OsFamilyAnnotation: osFamily}
+			for key, value := range tc.annotations {
+				annotations[key] = value
+			}
+			ig.SetAnnotations(annotations)
+
+			g.Expect(ctx.ValidatePrefixAssignment()).To(gomega.Succeed())
+			g.Expect(ctx.GetPrefixDensityStatus()).To(gomega.Equal(tc.expectedStatus))
+
+			args := ctx.GetBootstrapArgs()
+			decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", args, "", UserDataPayload{}, nil))
+			userData := string(decoded)
+			if tc.expectedArgs == "" {
+				g.Expect(args).NotTo(gomega.ContainSubstring("--max-pods"))
+				g.Expect(userData).NotTo(gomega.ContainSubstring("--max-pods"))
+				continue
+			}
+
+			switch osFamily {
+			case OsFamilyAmazonLinux2:
+				g.Expect(args).To(gomega.HavePrefix("--use-max-pods false "))
+				g.Expect(userData).To(gomega.ContainSubstring(tc.expectedArgs))
+			case OsFamilyAmazonLinux2023:
+				g.Expect(userData).To(gomega.ContainSubstring("      - " + tc.expectedArgs + "\n"))
+			}
+		}
+	}
+}
+
+func TestValidatePrefixAssignment(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
+		{InstanceType: aws.String("m5.large"), Hypervisor: aws.String("nitro")},
+		{InstanceType: aws.String("m4.large"), Hypervisor: aws.String("xen")},
+	})
+
+	tests := []struct {
+		annotations  map[string]string
+		instanceType string
+		shouldErr    bool
+	}{
+		{annotations: map[string]string{}, instanceType: "m4.large"},
+		{annotations: map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true"}, instanceType: "m5.large"},
+		{annotations: map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true", CustomNetworkingEnabledAnnotation: "true", CustomNetworkingHostPodsAnnotation: "3"}, instanceType: "m5.large"},
+		{annotations: map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true", OsFamilyAnnotation: OsFamilyBottleRocket}, instanceType: "m5.large"},
+		// annotation values which were accepted before are still accepted
+		{annotations: map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "yes"}, instanceType: "m4.large"},
+		{annotations: map[string]string{CustomNetworkingEnabledAnnotation: "true", CustomNetworkingHostPodsAnnotation: "-1"}, instanceType: "m5.large"},
+		{annotations: map[string]string{CustomNetworkingEnabledAnnotation: "true", CustomNetworkingHostPodsAnnotation: "two"}, instanceType: "m5.large"},
+		// prefixes are not supported on windows or non-nitro instances
+		{annotations: map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true", OsFamilyAnnotation: OsFamilyWindows}, instanceType: "m5.large", shouldErr: true},
+		{annotations: map[string]string{CustomNetworkingPrefixAssignmentEnabledAnnotation: "true"}, instanceType: "m4.large", shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ig.SetAnnotations(tc.annotations)
+		config.InstanceType = tc.instanceType
+		err := ctx.ValidatePrefixAssignment()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+	}
+}
+
+func TestValidateVersionSkew(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		clusterVersion string
+		imageName      string
+		ownerAlias     string
+		kubeletVersion string
+		shouldErr      bool
+	}{
+		// nodes within the supported skew
+		{clusterVersion: "1.30", imageName: "amazon-eks-node-1.30-v20240807", ownerAlias: "amazon"},
+		{clusterVersion: "1.30", imageName: "amazon-eks-node-al2023-x86_64-standard-1.27-v20240807", ownerAlias: "amazon"},
+		{clusterVersion: "1.27", imageName: "bottlerocket-aws-k8s-1.25-x86_64-v1.21.0-a1b2c3d4", ownerAlias: "amazon"},
+		{clusterVersion: "1.30", imageName: "Windows_Server-2022-English-Core-EKS_Optimized-1.29-2024.08.13", ownerAlias: "amazon"},
+		// nodes outside the supported skew
+		{clusterVersion: "1.30", imageName: "amazon-eks-node-1.26-v20240807", ownerAlias: "amazon", shouldErr: true},
+		{clusterVersion: "1.27", imageName: "bottlerocket-aws-k8s-1.24-x86_64-v1.21.0-a1b2c3d4", ownerAlias: "amazon", shouldErr: true},
+		{clusterVersion: "1.29", imageName: "amazon-eks-node-1.30-v20240807", ownerAlias: "amazon", shouldErr: true},
+		// an explicit kubelet version takes precedence over the image
+		{clusterVersion: "1.30", imageName: "amazon-eks-node-1.30-v20240807", ownerAlias: "amazon", kubeletVersion: "1.25", shouldErr: true},
+		{clusterVersion: "1.30", imageName: "my-custom-image", kubeletVersion: "v1.28.3"},
+		{clusterVersion: "1.30", imageName: "my-custom-image", kubeletVersion: "1.31", shouldErr: true},
+		// nodes whose version is unknown are not validated, custom images are not EKS optimized images
+		{clusterVersion: "1.30", imageName: "my-custom-image"},
+		{clusterVersion: "1.30", imageName: "app-1.2-build"},
+		{clusterVersion: "1.30", imageName: "amazon-eks-node-1.20-v20240807"},
+		{clusterVersion: "1.30", imageName: "app-1.2-build", ownerAlias: "amazon"},
+		{clusterVersion: "", imageName: "amazon-eks-node-1.20-v20240807", ownerAlias: "amazon"},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ctx.GetDiscoveredState().SetCluster(MockEksCluster(tc.clusterVersion))
+		image := &ec2.Image{ImageId: aws.String(config.Image), Name: aws.String(tc.imageName)}
+		if tc.ownerAlias != "" {
+			image.ImageOwnerAlias = aws.String(tc.ownerAlias)
+		}
+		ec2Mock.Images = []*ec2.Image{image}
+		config.KubeletVersion = tc.kubeletVersion
+		err := ctx.ValidateVersionSkew()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
 	}
+
+	// images which do not exist, or cannot be described, are not validated
+	ctx.GetDiscoveredState().SetCluster(MockEksCluster("1.30"))
+	ec2Mock.Images = nil
+	config.KubeletVersion = ""
+	g.Expect(ctx.ValidateVersionSkew()).To(gomega.Succeed())
+	ec2Mock.DescribeImagesErr = errors.New("some-error")
+	g.Expect(ctx.ValidateVersionSkew()).To(gomega.Succeed())
 }
 
-func TestGetUserDataStages(t *testing.T) {
+func TestVpcCNIWarmTargets(t *testing.T) {
 	var (
 		g             = gomega.NewGomegaWithT(t)
 		k             = MockKubernetesClientSet()
@@ -1032,155 +3749,212 @@ func TestGetUserDataStages(t *testing.T) {
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		Cluster: MockEksCluster("1.29"),
+	})
 
 	tests := []struct {
-		preBootstrapScript  []string
-		postBootstrapScript []string
-		bootstrapScript     string
-		expectedPayload     UserDataPayload
+		osFamily           string
+		targets            *v1alpha1.VpcCNIWarmTargetsSpec
+		expectedLabels     []string
+		expectedNotPresent []string
 	}{
-		{},
-		{preBootstrapScript: []string{""}, postBootstrapScript: []string{""}, expectedPayload: UserDataPayload{PreBootstrap: []string{""}, PostBootstrap: []string{""}}},
-		{preBootstrapScript: []string{"dGVzdA=="}, postBootstrapScript: []string{"dGVzdDE="}, expectedPayload: UserDataPayload{PreBootstrap: []string{"test"}, PostBootstrap: []string{"test1"}}},
-		{preBootstrapScript: []string{"prebootstrap1"}, postBootstrapScript: []string{"postbootstrap"}, expectedPayload: UserDataPayload{PreBootstrap: []string{"prebootstrap1"}, PostBootstrap: []string{"postbootstrap"}}},
-		{preBootstrapScript: []string{"prebootstrap1", "prebootstrap2"}, postBootstrapScript: []string{"postbootstrap1", "postbootstrap2"}, expectedPayload: UserDataPayload{PreBootstrap: []string{"prebootstrap1", "prebootstrap2"}, PostBootstrap: []string{"postbootstrap1", "postbootstrap2"}}},
+		{osFamily: OsFamilyAmazonLinux2, targets: nil, expectedNotPresent: []string{VpcCNIWarmIPTargetLabel, VpcCNIMinimumIPTargetLabel, VpcCNIWarmENITargetLabel}},
+		{
+			osFamily:           OsFamilyAmazonLinux2,
+			targets:            &v1alpha1.VpcCNIWarmTargetsSpec{WarmIPTarget: aws.Int64(5), MinimumIPTarget: aws.Int64(10)},
+			expectedLabels:     []string{"instancemgr.keikoproj.io/vpc-cni-warm-ip-target=5", "instancemgr.keikoproj.io/vpc-cni-minimum-ip-target=10"},
+			expectedNotPresent: []string{VpcCNIWarmENITargetLabel},
+		},
+		{
+			osFamily:           OsFamilyAmazonLinux2023,
+			targets:            &v1alpha1.VpcCNIWarmTargetsSpec{WarmIPTarget: aws.Int64(0), MinimumIPTarget: aws.Int64(3)},
+			expectedLabels:     []string{"instancemgr.keikoproj.io/vpc-cni-warm-ip-target=0", "instancemgr.keikoproj.io/vpc-cni-minimum-ip-target=3"},
+			expectedNotPresent: []string{VpcCNIWarmENITargetLabel},
+		},
+		{
+			osFamily:           OsFamilyBottleRocket,
+			targets:            &v1alpha1.VpcCNIWarmTargetsSpec{WarmENITarget: aws.Int64(1)},
+			expectedLabels:     []string{`"instancemgr.keikoproj.io/vpc-cni-warm-eni-target" = "1"`},
+			expectedNotPresent: []string{VpcCNIWarmIPTargetLabel, VpcCNIMinimumIPTargetLabel},
+		},
 	}
 
 	for i, tc := range tests {
 		t.Logf("Test #%v - %+v", i, tc)
-		configuration.UserData = []v1alpha1.UserDataStage{}
-		for _, data := range tc.preBootstrapScript {
-			configuration.UserData = append(configuration.UserData, v1alpha1.UserDataStage{
-				Name:  fmt.Sprintf("stage-%v", i),
-				Stage: v1alpha1.PreBootstrapStage,
-				Data:  data,
-			})
-		}
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+		configuration.VpcCNIWarmTargets = tc.targets
 
-		for _, data := range tc.postBootstrapScript {
-			configuration.UserData = append(configuration.UserData, v1alpha1.UserDataStage{
-				Name:  fmt.Sprintf("stage-%v", i),
-				Stage: v1alpha1.PostBootstrapStage,
-				Data:  data,
-			})
+		decoded, _ := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		userData := string(decoded)
+		for _, label := range tc.expectedLabels {
+			g.Expect(userData).To(gomega.ContainSubstring(label))
+		}
+		for _, label := range tc.expectedNotPresent {
+			g.Expect(userData).NotTo(gomega.ContainSubstring(label))
 		}
-
-		configuration.UserData = append(configuration.UserData, v1alpha1.UserDataStage{
-			Name:  fmt.Sprintf("stage-%v", i),
-			Stage: "invalid-stage",
-			Data:  "test",
-		})
-		payload := ctx.GetUserDataStages()
-		g.Expect(payload).To(gomega.Equal(tc.expectedPayload))
 	}
 }
 
-func TestMaxPodsSetCorrectly(t *testing.T) {
+func TestGetUserDataStagesOrder(t *testing.T) {
 	var (
-		k                         = MockKubernetesClientSet()
-		bottleRocketIgWithMaxPods = MockBottleRocketInstanceGroup()
-		bottleRocketIg            = MockBottleRocketInstanceGroup()
-		amazonLinuxIgWithMaxPods  = MockInstanceGroup()
-		asgMock                   = NewAutoScalingMocker()
-		iamMock                   = NewIamMocker()
-		eksMock                   = NewEksMocker()
-		ec2Mock                   = NewEc2Mocker()
-		ssmMock                   = NewSsmMocker()
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
 	)
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
 
-	bottleRocketIgWithMaxPods.Spec.EKSSpec.EKSConfiguration.BootstrapOptions = &v1alpha1.BootstrapOptions{
-		MaxPods: 15,
+	first, second, third := 1, 2, 3
+	configuration.UserData = []v1alpha1.UserDataStage{
+		{Name: "unordered-pre", Stage: v1alpha1.PreBootstrapStage, Data: "unordered-pre"},
+		{Name: "post-3", Stage: v1alpha1.PostBootstrapStage, Data: "post-3", Order: &third},
+		{Name: "pre-2", Stage: v1alpha1.PreBootstrapStage, Data: "pre-2", Order: &second},
+		{Name: "unordered-post", Stage: v1alpha1.PostBootstrapStage, Data: "unordered-post"},
+		{Name: "pre-1", Stage: v1alpha1.PreBootstrapStage, Data: "pre-1", Order: &first},
 	}
 
-	amazonLinuxIgWithMaxPods.Spec.EKSSpec.EKSConfiguration.BootstrapOptions = &v1alpha1.BootstrapOptions{
-		MaxPods: 15,
-	}
+	payload := ctx.GetUserDataStages()
+	g.Expect(payload).To(gomega.Equal(UserDataPayload{
+		PreBootstrap:  []string{"pre-1", "pre-2", "unordered-pre"},
+		PostBootstrap: []string{"post-3", "unordered-post"},
+	}))
+
+	// the declared order of the spec is left untouched
+	g.Expect(configuration.UserData[0].Name).To(gomega.Equal("unordered-pre"))
+
+	userData, err := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", payload, nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	rendered := string(userData)
+	g.Expect(strings.Index(rendered, "pre-1")).To(gomega.BeNumerically("<", strings.Index(rendered, "pre-2")))
+	g.Expect(strings.Index(rendered, "pre-2")).To(gomega.BeNumerically("<", strings.Index(rendered, "unordered-pre")))
+	g.Expect(strings.Index(rendered, "post-3")).To(gomega.BeNumerically("<", strings.Index(rendered, "unordered-post")))
+}
+
+func TestGetBasicUserDataWindowsContainerd(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyWindows})
+	configuration.BootstrapOptions = &v1alpha1.BootstrapOptions{ContainerRuntime: v1alpha1.ContainerDRuntime}
+
+	expectedConfig := `  } else {
+    [string]$ContainerdConfigFile = "$env:ProgramFiles\containerd\config.toml"
+    $ContainerdConfig = Get-Content -Raw -Path $ContainerdConfigFile
+    $ContainerdConfig = $ContainerdConfig -replace '(?m)^root\s*=.*$', 'root = ''D:\containerd\root'''
+    $ContainerdConfig = $ContainerdConfig -replace '(?m)^state\s*=.*$', 'state = ''D:\containerd\state'''
+    $ContainerdConfig = $ContainerdConfig -replace '(?m)^(\s*)sandbox_image\s*=.*$', '$1sandbox_image = "123456789012.dkr.ecr.us-west-2.amazonaws.com/eks/pause-windows:3.9"'
+    Set-Content -Path $ContainerdConfigFile -Value $ContainerdConfig
+    & $EKSBootstrapScriptFile -EKSClusterName my-cluster`
 
 	tests := []struct {
-		ig                         *v1alpha1.InstanceGroup
-		expectedScriptSubstrings   string
-		unexpectedScriptSubstrings string
+		containerd     *v1alpha1.WindowsContainerdSpec
+		expectedConfig string
+		unexpected     []string
 	}{
+		{containerd: nil, unexpected: []string{"$ContainerdConfigFile"}},
 		{
-			ig:                       bottleRocketIgWithMaxPods,
-			expectedScriptSubstrings: "max-pods = 15",
-		},
-		{
-			ig:                       amazonLinuxIgWithMaxPods,
-			expectedScriptSubstrings: "--max-pods=15",
+			containerd: &v1alpha1.WindowsContainerdSpec{
+				Root:         `D:\containerd\root`,
+				State:        `D:\containerd\state`,
+				SandboxImage: "123456789012.dkr.ecr.us-west-2.amazonaws.com/eks/pause-windows:3.9",
+			},
+			expectedConfig: expectedConfig,
 		},
 		{
-			ig:                         bottleRocketIg,
-			expectedScriptSubstrings:   "",
-			unexpectedScriptSubstrings: "max-pods",
+			containerd:     &v1alpha1.WindowsContainerdSpec{SandboxImage: "mcr.microsoft.com/oss/kubernetes/pause:3.9"},
+			expectedConfig: `'$1sandbox_image = "mcr.microsoft.com/oss/kubernetes/pause:3.9"'`,
+			unexpected:     []string{"'root = ", "'state = "},
 		},
 	}
 
 	for i, tc := range tests {
 		t.Logf("Test #%v - %+v", i, tc)
-		ctx := MockContext(tc.ig, k, w)
-		args := ctx.GetBootstrapArgs()
-		basicUserData := ctx.GetBasicUserData("", args, "", UserDataPayload{}, []MountOpts{})
-		basicUserDataDecoded, _ := base64.StdEncoding.DecodeString(basicUserData)
-		basicUserDataString := string(basicUserDataDecoded)
-		if !strings.Contains(basicUserDataString, tc.expectedScriptSubstrings) {
-			t.Fatalf("Cound not find expected string %v script in %v", tc.expectedScriptSubstrings, basicUserDataString)
-		}
-		if tc.unexpectedScriptSubstrings != "" && strings.Contains(basicUserDataString, tc.unexpectedScriptSubstrings) {
-			t.Fatalf("Found unexpected string %v script in %v", tc.unexpectedScriptSubstrings, basicUserDataString)
+		configuration.WindowsContainerd = tc.containerd
+		userData, err := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", ctx.GetBootstrapArgs(), "", UserDataPayload{}, nil))
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(string(userData)).To(gomega.ContainSubstring(tc.expectedConfig))
+		for _, s := range tc.unexpected {
+			g.Expect(string(userData)).NotTo(gomega.ContainSubstring(s))
 		}
 	}
 }
 
-func TestBootstrapDataForOSFamily(t *testing.T) {
+func TestValidateBootstrapFlags(t *testing.T) {
 	var (
-		k              = MockKubernetesClientSet()
-		bottleRocketIg = MockBottleRocketInstanceGroup()
-		linuxIg        = MockInstanceGroup()
-		windowsIg      = MockWindowsInstanceGroup()
-		asgMock        = NewAutoScalingMocker()
-		iamMock        = NewIamMocker()
-		eksMock        = NewEksMocker()
-		ec2Mock        = NewEc2Mocker()
-		ssmMock        = NewSsmMocker()
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
 	)
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
 
 	tests := []struct {
-		ig                       *v1alpha1.InstanceGroup
-		expectedScriptSubstrings string
+		osFamily string
+		maxPods  int64
+		flags    map[string]string
+		expected string
 	}{
-		{
-			ig:                       linuxIg,
-			expectedScriptSubstrings: "/etc/eks/bootstrap.sh",
-		},
-		{
-			ig:                       windowsIg,
-			expectedScriptSubstrings: "<powershell>",
-		},
-		{
-			ig:                       bottleRocketIg,
-			expectedScriptSubstrings: "[settings.kubernetes]",
-		},
+		{osFamily: OsFamilyAmazonLinux2, flags: nil},
+		{osFamily: OsFamilyAmazonLinux2, flags: map[string]string{"use-max-pods": "false", "enable-docker-bridge": "true", "aws-api-retry-attempts": "5"}},
+		{osFamily: OsFamilyAmazonLinux2, flags: map[string]string{"ip-family": "ipv6", "service-ipv6-cidr": "fd00::/108", "local-disks": "raid0"}},
+		{osFamily: OsFamilyAmazonLinux2, flags: map[string]string{"pause-container-account": "602401143452", "pause-container-version": "3.9"}},
+		{osFamily: OsFamilyAmazonLinux2, flags: map[string]string{"use-max-pods": "no"}, expected: "bootstrap flag use-max-pods must be true or false, got 'no'"},
+		{osFamily: OsFamilyAmazonLinux2, flags: map[string]string{"aws-api-retry-attempts": "0"}, expected: "bootstrap flag aws-api-retry-attempts must be a positive integer, got '0'"},
+		{osFamily: OsFamilyAmazonLinux2, flags: map[string]string{"local-disks": "raid5"}, expected: "bootstrap flag local-disks must be one of mount, raid0 or raid10, got 'raid5'"},
+		{osFamily: OsFamilyAmazonLinux2, flags: map[string]string{"b64-cluster-ca": "abc"}, expected: "bootstrap flag b64-cluster-ca is not supported for os family amazonlinux2"},
+		{osFamily: OsFamilyAmazonLinux2, flags: map[string]string{"ServiceCIDR": "10.100.0.0/16"}, expected: "bootstrap flag ServiceCIDR is not supported for os family amazonlinux2"},
+		{osFamily: OsFamilyAmazonLinux2, maxPods: 58, flags: map[string]string{"use-max-pods": "true"}, expected: "bootstrap flag use-max-pods cannot be combined with max pods, which sets it to false"},
+		{osFamily: OsFamilyWindows, flags: map[string]string{"ServiceCIDR": "10.100.0.0/16", "DNSClusterIP": "10.100.0.10", "ExcludedSnatCIDRs": "10.0.0.0/8,172.16.0.0/12"}},
+		{osFamily: OsFamilyWindows, flags: map[string]string{"DNSClusterIP": "10.100.0"}, expected: "bootstrap flag DNSClusterIP must be an IP address, got '10.100.0'"},
+		{osFamily: OsFamilyWindows, flags: map[string]string{"ExcludedSnatCIDRs": "10.0.0.0/8,foo"}, expected: "bootstrap flag ExcludedSnatCIDRs must be a comma separated list of CIDRs, got '10.0.0.0/8,foo'"},
+		{osFamily: OsFamilyWindows, flags: map[string]string{"enable-docker-bridge": "true"}, expected: "bootstrap flag enable-docker-bridge is not supported for os family windows"},
+		{osFamily: OsFamilyAmazonLinux2023, flags: map[string]string{"use-max-pods": "false"}, expected: "bootstrap flags are not supported for os family amazonlinux2023"},
+		{osFamily: OsFamilyBottleRocket, flags: map[string]string{"use-max-pods": "false"}, expected: "bootstrap flags are not supported for os family bottlerocket"},
 	}
 
 	for i, tc := range tests {
 		t.Logf("Test #%v - %+v", i, tc)
-		ctx := MockContext(tc.ig, k, w)
-		basicUserData := ctx.GetBasicUserData("", "", "", UserDataPayload{}, []MountOpts{})
-		basicUserDataDecoded, _ := base64.StdEncoding.DecodeString(basicUserData)
-		basicUserDataString := string(basicUserDataDecoded)
-		if !strings.Contains(basicUserDataString, tc.expectedScriptSubstrings) {
-			t.Fatalf("Cound not find expected string %v script in %v", tc.expectedScriptSubstrings, basicUserDataString)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+		configuration.BootstrapOptions = &v1alpha1.BootstrapOptions{MaxPods: tc.maxPods, Flags: tc.flags}
+		err := ctx.ValidateBootstrapFlags()
+		if tc.expected == "" {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(tc.expected)))
 		}
 	}
-
 }
 
-func TestUpdateLifecycleHooks(t *testing.T) {
+func TestGetBootstrapArgsFlags(t *testing.T) {
 	var (
 		g             = gomega.NewGomegaWithT(t)
 		k             = MockKubernetesClientSet()
@@ -1196,79 +3970,71 @@ func TestUpdateLifecycleHooks(t *testing.T) {
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
 
-	testScalingHooks := []*autoscaling.LifecycleHook{
-		{
-			LifecycleHookName: aws.String("my-hook-1"),
-		},
-		{
-			LifecycleHookName: aws.String("my-hook-2"),
-		},
-	}
-
-	hook1 := v1alpha1.LifecycleHookSpec{
-		Name: "my-hook-1",
-	}
+	// validated flags are appended to the bootstrap arguments in name order
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyAmazonLinux2})
+	configuration.BootstrapArguments = "--v=2"
+	configuration.BootstrapOptions = &v1alpha1.BootstrapOptions{Flags: map[string]string{"use-max-pods": "false", "enable-docker-bridge": "true"}}
+	args := ctx.GetBootstrapArgs()
+	g.Expect(args).To(gomega.HaveSuffix("--v=2' --enable-docker-bridge true --use-max-pods false"))
+
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyWindows})
+	configuration.BootstrapOptions = &v1alpha1.BootstrapOptions{Flags: map[string]string{"ServiceCIDR": "10.100.0.0/16", "DNSClusterIP": "10.100.0.10"}}
+	args = ctx.GetBootstrapArgs()
+	g.Expect(args).To(gomega.HaveSuffix("--v=2' -DNSClusterIP 10.100.0.10 -ServiceCIDR 10.100.0.0/16"))
+
+	// flags of other os families are never rendered
+	configuration.BootstrapOptions = &v1alpha1.BootstrapOptions{Flags: map[string]string{"use-max-pods": "false"}}
+	g.Expect(ctx.GetBootstrapArgs()).To(gomega.HaveSuffix("--v=2'"))
+}
 
-	hook2 := v1alpha1.LifecycleHookSpec{
-		Name: "my-hook-2",
-	}
+func TestValidateWindowsContainerd(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
 
-	hook3 := v1alpha1.LifecycleHookSpec{
-		Name: "my-hook-3",
-	}
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	containerd := &v1alpha1.WindowsContainerdSpec{SandboxImage: "mcr.microsoft.com/oss/kubernetes/pause:3.9"}
 
 	tests := []struct {
-		asgHooks        []*autoscaling.LifecycleHook
-		desiredHooks    []v1alpha1.LifecycleHookSpec
-		expectedRemoved []string
-		expectedAdded   []v1alpha1.LifecycleHookSpec
-		shouldRemove    bool
-		shouldAdd       bool
-	}{
-		{expectedRemoved: []string{}, expectedAdded: []v1alpha1.LifecycleHookSpec{}},
-		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{hook1, hook2, hook3}, expectedRemoved: []string{}, expectedAdded: []v1alpha1.LifecycleHookSpec{hook3}, shouldAdd: true},
-		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{hook1, hook3}, expectedRemoved: []string{"my-hook-2"}, expectedAdded: []v1alpha1.LifecycleHookSpec{hook3}, shouldAdd: true, shouldRemove: true},
-		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{hook1, hook2}, expectedRemoved: []string{}, expectedAdded: []v1alpha1.LifecycleHookSpec{}},
-		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{hook1}, expectedRemoved: []string{"my-hook-2"}, expectedAdded: []v1alpha1.LifecycleHookSpec{}, shouldRemove: true},
-		{asgHooks: testScalingHooks, desiredHooks: []v1alpha1.LifecycleHookSpec{}, expectedRemoved: []string{"my-hook-1", "my-hook-2"}, expectedAdded: []v1alpha1.LifecycleHookSpec{}, shouldRemove: true},
-	}
-
-	for i, tc := range tests {
-		t.Logf("Test #%v - %+v", i, tc)
-		asgMock.DeleteLifecycleHookCallCount = 0
-		asgMock.PutLifecycleHookCallCount = 0
-
-		ctx.SetDiscoveredState(&DiscoveredState{
-			Publisher: kubeprovider.EventPublisher{
-				Client: k.Kubernetes,
-			},
-			LifecycleHooks: tc.asgHooks,
-		})
-		configuration.SetLifecycleHooks(tc.desiredHooks)
-		removed, ok := ctx.GetRemovedHooks()
-		g.Expect(ok).To(gomega.Equal(tc.shouldRemove))
-		g.Expect(removed).To(gomega.Equal(tc.expectedRemoved))
-
-		added, ok := ctx.GetAddedHooks()
-		g.Expect(ok).To(gomega.Equal(tc.shouldAdd))
-		g.Expect(added).To(gomega.Equal(tc.expectedAdded))
-
-		err := ctx.UpdateLifecycleHooks("my-asg")
-		g.Expect(err).NotTo(gomega.HaveOccurred())
-		if tc.shouldRemove {
-			g.Expect(asgMock.DeleteLifecycleHookCallCount).To(gomega.Equal(uint(len(tc.expectedRemoved))))
-		}
-		if tc.shouldAdd {
-			g.Expect(asgMock.PutLifecycleHookCallCount).To(gomega.Equal(uint(len(tc.expectedAdded))))
+		containerd *v1alpha1.WindowsContainerdSpec
+		osFamily   string
+		shouldErr  bool
+	}{
+		{containerd: nil, osFamily: OsFamilyAmazonLinux2},
+		{containerd: containerd, osFamily: OsFamilyWindows},
+		{containerd: containerd, osFamily: OsFamilyAmazonLinux2, shouldErr: true},
+		{containerd: containerd, osFamily: OsFamilyAmazonLinux2023, shouldErr: true},
+		{containerd: containerd, osFamily: OsFamilyBottleRocket, shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ig.SetAnnotations(map[string]string{OsFamilyAnnotation: tc.osFamily})
+		configuration.WindowsContainerd = tc.containerd
+		err := ctx.ValidateWindowsContainerd()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
 		}
 	}
 }
 
-func TestUpdateWarmPool(t *testing.T) {
+func TestMaxPodsFormula(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
 		k       = MockKubernetesClientSet()
 		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
 		asgMock = NewAutoScalingMocker()
 		iamMock = NewIamMocker()
 		eksMock = NewEksMocker()
@@ -1278,206 +4044,334 @@ func TestUpdateWarmPool(t *testing.T) {
 
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
+	ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
+		{
+			InstanceType: aws.String("m5.large"),
+			NetworkInfo: &ec2.NetworkInfo{
+				MaximumNetworkInterfaces:  aws.Int64(3),
+				Ipv4AddressesPerInterface: aws.Int64(10),
+			},
+		},
+		{
+			InstanceType: aws.String("c5.4xlarge"),
+			NetworkInfo: &ec2.NetworkInfo{
+				MaximumNetworkInterfaces:  aws.Int64(8),
+				Ipv4AddressesPerInterface: aws.Int64(30),
+			},
+		},
+	})
 
 	tests := []struct {
-		warmPoolSpec          *v1alpha1.WarmPoolSpec
-		warmPoolConfiguration *autoscaling.WarmPoolConfiguration
-		shouldDelete          bool
-		shouldUpdate          bool
-		shouldRequeue         bool
+		instanceType    string
+		annotations     map[string]string
+		formula         string
+		expectedMaxPods int64
+		shouldErr       bool
 	}{
-		// no update/delete needed
-		{warmPoolConfiguration: nil, warmPoolSpec: nil},
-		// enable warm pool - update
-		{warmPoolConfiguration: nil, warmPoolSpec: MockWarmPoolSpec(-1, 0), shouldUpdate: true},
-		// disable warm pool - delete
-		{warmPoolConfiguration: MockWarmPool(-1, 0, ""), warmPoolSpec: nil, shouldDelete: true},
-		// scale change (min)
-		{warmPoolConfiguration: MockWarmPool(-1, 1, ""), warmPoolSpec: MockWarmPoolSpec(-1, 0), shouldUpdate: true},
-		{warmPoolConfiguration: MockWarmPool(-1, 0, ""), warmPoolSpec: MockWarmPoolSpec(-1, 1), shouldUpdate: true},
-		// scale change (max)
-		{warmPoolConfiguration: MockWarmPool(3, 0, ""), warmPoolSpec: MockWarmPoolSpec(-1, 0), shouldUpdate: true},
-		{warmPoolConfiguration: MockWarmPool(-1, 0, ""), warmPoolSpec: MockWarmPoolSpec(3, 0), shouldUpdate: true},
-		// deleting - should requeue
-		{warmPoolConfiguration: MockWarmPool(-1, 0, autoscaling.WarmPoolStatusPendingDelete), warmPoolSpec: nil, shouldRequeue: true},
+		// the EKS default: 3 * (10 - 1) + 2
+		{instanceType: "m5.large", formula: "ENIs * (IPsPerENI - 1) + HostPods", expectedMaxPods: 29},
+		{instanceType: "c5.4xlarge", formula: "ENIs * (IPsPerENI - 1) + HostPods", expectedMaxPods: 234},
+		// custom networking without the primary interface, with host pods from the annotation: 2 * (10 - 1) + 4
+		{instanceType: "m5.large", annotations: map[string]string{CustomNetworkingHostPodsAnnotation: "4"}, formula: "(ENIs - 1) * (IPsPerENI - 1) + HostPods", expectedMaxPods: 22},
+		// prefixes are not capped unless the formula does so
+		{instanceType: "m5.large", formula: "ENIs * (IPsPerENI - 1) * IPsPerPrefix + HostPods", expectedMaxPods: 434},
+		{instanceType: "c5.4xlarge", formula: "min(ENIs * (IPsPerENI - 1) * IPsPerPrefix + HostPods, 250)", expectedMaxPods: 250},
+		// formulas which cannot be evaluated for the instance type
+		{instanceType: "m5.large", formula: "IPsPerENI / (ENIs - 3)", shouldErr: true},
+		{instanceType: "m5.large", formula: "HostPods - ENIs", shouldErr: true},
+		{instanceType: "m5.large", formula: "ENIs * Prefixes", shouldErr: true},
+		{instanceType: "t3.nano", formula: "ENIs * (IPsPerENI - 1) + HostPods", shouldErr: true},
 	}
 
 	for i, tc := range tests {
-		t.Logf("test #%v", i)
-		asgMock.DeleteWarmPoolCallCount = 0
-		asgMock.PutWarmPoolCallCount = 0
-		ig.Spec.EKSSpec.WarmPool = tc.warmPoolSpec
-		scalingGroup := MockScalingGroup("my-asg", false)
-		scalingGroup.WarmPoolConfiguration = tc.warmPoolConfiguration
+		t.Logf("Test #%v - %+v", i, tc)
+		ig.SetAnnotations(tc.annotations)
+		config.InstanceType = tc.instanceType
+		config.BootstrapOptions = &v1alpha1.BootstrapOptions{MaxPodsFormula: tc.formula, ContainerRuntime: v1alpha1.ContainerDRuntime}
+
+		err := ctx.ValidateMaxPodsFormula()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			g.Expect(ctx.GetComputedBootstrapOptions().MaxPods).To(gomega.BeZero())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
 
-		ctx.SetDiscoveredState(&DiscoveredState{
-			Publisher: kubeprovider.EventPublisher{
-				Client: k.Kubernetes,
-			},
-			ScalingGroup: scalingGroup,
-		})
+		bootstrapOptions := ctx.GetComputedBootstrapOptions()
+		g.Expect(bootstrapOptions.MaxPods).To(gomega.Equal(tc.expectedMaxPods))
+		g.Expect(bootstrapOptions.ContainerRuntime).To(gomega.Equal(v1alpha1.ContainerDRuntime))
+		g.Expect(ctx.GetKubeletExtraArgs()).To(gomega.ContainSubstring(fmt.Sprintf("--max-pods=%v", tc.expectedMaxPods)))
+	}
+}
 
-		err := ctx.UpdateWarmPool("my-asg")
-		g.Expect(err).NotTo(gomega.HaveOccurred())
-		if tc.shouldDelete {
-			g.Expect(asgMock.DeleteWarmPoolCallCount).To(gomega.Equal(uint(1)))
+func TestUpdateScalingProcesses(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+
+	tests := []struct {
+		specProcesses    []string
+		groupProcesses   []string
+		expectedSuspends []string
+		expectedResumes  []string
+	}{
+		// missing processes are suspended
+		{specProcesses: []string{"AZRebalance"}, groupProcesses: nil, expectedSuspends: []string{"AZRebalance"}},
+		// processes suspended outside of the spec are resumed
+		{specProcesses: []string{"AZRebalance"}, groupProcesses: []string{"AZRebalance", "HealthCheck"}, expectedResumes: []string{"HealthCheck"}},
+		{specProcesses: nil, groupProcesses: []string{"AZRebalance"}, expectedResumes: []string{"AZRebalance"}},
+		// converged processes are left as is
+		{specProcesses: []string{"AZRebalance", "ScheduledActions"}, groupProcesses: []string{"ScheduledActions", "AZRebalance"}},
+		{specProcesses: []string{"All"}, groupProcesses: []string{"AZRebalance"}, expectedSuspends: []string{"Launch", "Terminate", "AddToLoadBalancer", "AlarmNotification", "HealthCheck", "InstanceRefresh", "ReplaceUnhealthy", "ScheduledActions"}},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		asgMock.SuspendProcessesInputs = nil
+		asgMock.ResumeProcessesInputs = nil
+		configuration.SuspendedProcesses = tc.specProcesses
+
+		suspended := make([]*autoscaling.SuspendedProcess, 0)
+		for _, process := range tc.groupProcesses {
+			suspended = append(suspended, &autoscaling.SuspendedProcess{ProcessName: aws.String(process)})
 		}
-		if tc.shouldUpdate {
-			g.Expect(asgMock.PutWarmPoolCallCount).To(gomega.Equal(uint(1)))
+		state.ScalingGroup = &autoscaling.Group{
+			AutoScalingGroupName: aws.String("some-scaling-group"),
+			SuspendedProcesses:   suspended,
 		}
-		if tc.shouldRequeue {
-			g.Expect(asgMock.DeleteWarmPoolCallCount).To(gomega.Equal(uint(0)))
-			g.Expect(asgMock.PutWarmPoolCallCount).To(gomega.Equal(uint(0)))
+
+		err := ctx.UpdateScalingProcesses("some-scaling-group")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		if tc.expectedSuspends == nil {
+			g.Expect(asgMock.SuspendProcessesInputs).To(gomega.BeEmpty())
+		} else {
+			g.Expect(asgMock.SuspendProcessesInputs).To(gomega.HaveLen(1))
+			g.Expect(aws.StringValueSlice(asgMock.SuspendProcessesInputs[0].ScalingProcesses)).To(gomega.Equal(tc.expectedSuspends))
+		}
+		if tc.expectedResumes == nil {
+			g.Expect(asgMock.ResumeProcessesInputs).To(gomega.BeEmpty())
+		} else {
+			g.Expect(asgMock.ResumeProcessesInputs).To(gomega.HaveLen(1))
+			g.Expect(aws.StringValueSlice(asgMock.ResumeProcessesInputs[0].ScalingProcesses)).To(gomega.Equal(tc.expectedResumes))
 		}
 	}
 }
 
-func TestFilterSupportedArch(t *testing.T) {
+func TestGetManagedPoliciesListOsFamily(t *testing.T) {
 	var (
-		g = gomega.NewGomegaWithT(t)
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
 	)
 
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ig.Annotations[IRSAEnabledAnnotation] = "true"
+
+	policyArn := func(name string) string {
+		return fmt.Sprintf("%s/%s", awsprovider.IAMPolicyPrefix, name)
+	}
+	defaultPolicies := []string{policyArn("AmazonEKSWorkerNodePolicy"), policyArn("AmazonEC2ContainerRegistryReadOnly")}
+	windowsPolicies := []string{policyArn("AmazonEKSWorkerNodePolicy"), policyArn("AmazonEC2ContainerRegistryReadOnly"), "arn:aws:iam::123456789012:policy/windows-nodes"}
+
+	managedPolicies, err := provisioners.ParseOsFamilyManagedPolicies("Windows=AmazonEKSWorkerNodePolicy,AmazonEC2ContainerRegistryReadOnly,arn:aws:iam::123456789012:policy/windows-nodes")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
 	tests := []struct {
-		name          string
-		architectures []string
-		expected      string
+		osFamily        string
+		managedPolicies provisioners.OsFamilyManagedPolicies
+		expected        []string
 	}{
-		{
-			name:          "supported architecture x86",
-			architectures: []string{"x86_64"},
-			expected:      "x86_64",
-		},
-		{
-			name:          "supported architecture arm64",
-			architectures: []string{"arm64"},
-			expected:      "arm64",
-		},
-		{
-			name:          "no supported architecture",
-			architectures: []string{},
-			expected:      "",
-		},
+		// every os family uses the default policies when none are configured
+		{osFamily: OsFamilyWindows, expected: defaultPolicies},
+		{osFamily: OsFamilyAmazonLinux2, expected: defaultPolicies},
+		// the configured policies replace the default policies of their os family only
+		{osFamily: OsFamilyWindows, managedPolicies: managedPolicies, expected: windowsPolicies},
+		{osFamily: OsFamilyAmazonLinux2, managedPolicies: managedPolicies, expected: defaultPolicies},
+		{osFamily: OsFamilyBottleRocket, managedPolicies: managedPolicies, expected: defaultPolicies},
 	}
 
-	for _, tc := range tests {
-		result := FilterSupportedArch(tc.architectures)
-		g.Expect(result).To(gomega.Equal(tc.expected))
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ig.Annotations[OsFamilyAnnotation] = tc.osFamily
+		ctx.OsFamilyManagedPolicies = tc.managedPolicies
+		g.Expect(ctx.GetManagedPoliciesList(nil)).To(gomega.Equal(tc.expected))
+		// additional policies are attached in addition to the os family's policies
+		g.Expect(ctx.GetManagedPoliciesList([]string{"policy-1"})).To(gomega.Equal(append([]string{policyArn("policy-1")}, tc.expected...)))
 	}
-
 }
 
-func TestGetEksLatestAmi(t *testing.T) {
+func TestUpdateReadinessStatus(t *testing.T) {
 	var (
-		k            = MockKubernetesClientSet()
-		ig           = MockInstanceGroup()
-		config       = ig.GetEKSConfiguration()
-		asgMock      = NewAutoScalingMocker()
-		iamMock      = NewIamMocker()
-		eksMock      = NewEksMocker()
-		ec2Mock      = NewEc2Mocker()
-		ssmMock      = NewSsmMocker()
-		instanceType = "m5.large"
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+		sink    = &MockMetricsSink{}
+		start   = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
 	)
-	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 
-	tests := []struct {
-		name          string
-		OSFamily      string
-		arch          string
-		expectedError error
-	}{
-		{
-			name:          "AmazonLinux2-x86_64",
-			OSFamily:      "amazonlinux2",
-			arch:          "x86_64",
-			expectedError: nil,
-		},
-		{
-			name:          "bottlerocket-x86_64",
-			OSFamily:      "bottlerocket",
-			arch:          "x86_64",
-			expectedError: nil,
-		},
-		{
-			name:          "AmazonLinux2-noarch",
-			OSFamily:      "amazonlinux2",
-			arch:          "noarch",
-			expectedError: fmt.Errorf("no supported CPU architecture found for instance type %s", instanceType),
-		},
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ctx.Metrics.RegisterSink(sink)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
 	}
+	config.ReadinessSLA = "10m"
 
-	for _, tc := range tests {
-		ig.SetAnnotations(map[string]string{
-			OsFamilyAnnotation: tc.OSFamily,
-		})
-		config.InstanceType = instanceType
-		ctx := MockContext(ig, k, w)
-		ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
-			{
-				InstanceType: aws.String(instanceType),
-				ProcessorInfo: &ec2.ProcessorInfo{
-					SupportedArchitectures: []*string{aws.String(tc.arch)},
-				},
-			},
-		})
-		_, err := ctx.GetEksLatestAmi()
-		if err == nil && tc.expectedError == nil {
-			continue
-		}
-		if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
-			t.Fatalf("expected %v got %v, test %s", tc.expectedError, err, tc.name)
+	slaEvents := func() int {
+		events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		var count int
+		for _, e := range events.Items {
+			if e.Reason == string(kubeprovider.ReadinessSLAExceededEvent) {
+				count++
+			}
 		}
-
+		return count
 	}
+
+	// the reconcile which creates the scaling group starts the provisioning
+	asg := MockScalingGroup("asg-1", false)
+	asg.DesiredCapacity = aws.Int64(2)
+	state.SetScalingGroup(asg)
+	ctx.reconcileStartTime = start
+	ctx.StartProvisioning()
+	g.Expect(status.GetReadiness().ProvisioningStartTime.Time).To(gomega.Equal(start))
+
+	// later reconciles do not restart it while nodes are not ready
+	ctx.reconcileStartTime = start.Add(2 * time.Minute)
+	ctx.UpdateReadinessStatus(false, start.Add(3*time.Minute))
+	g.Expect(status.GetReadiness().ProvisioningStartTime.Time).To(gomega.Equal(start))
+	g.Expect(status.GetReadiness().SLAExceeded).To(gomega.BeFalse())
+
+	// all nodes are ready after 4m30s
+	ctx.UpdateReadinessStatus(true, start.Add(4*time.Minute+30*time.Second))
+	readiness := status.GetReadiness()
+	g.Expect(readiness.ProvisioningStartTime).To(gomega.BeNil())
+	g.Expect(readiness.LastProvisioningDuration).To(gomega.Equal("4m30s"))
+	g.Expect(readiness.LastReadyTime.Time).To(gomega.Equal(start.Add(4*time.Minute + 30*time.Second)))
+	g.Expect(readiness.ReadyCapacity).To(gomega.Equal(int64(2)))
+	g.Expect(readiness.SLAExceeded).To(gomega.BeFalse())
+	g.Expect(sink.Readiness).To(gomega.Equal([]time.Duration{4*time.Minute + 30*time.Second}))
+
+	// nodes which are not ready at the same desired capacity do not start a provisioning
+	ctx.UpdateReadinessStatus(false, start.Add(5*time.Minute))
+	g.Expect(status.GetReadiness().ProvisioningStartTime).To(gomega.BeNil())
+
+	// a scale up starts a provisioning at the start of the reconcile which observed it
+	scaleUp := start.Add(time.Hour)
+	ctx.reconcileStartTime = scaleUp
+	asg.DesiredCapacity = aws.Int64(4)
+	ctx.UpdateReadinessStatus(false, scaleUp.Add(time.Second))
+	g.Expect(status.GetReadiness().ProvisioningStartTime.Time).To(gomega.Equal(scaleUp))
+
+	// the SLA is exceeded while nodes are not ready, and reported once
+	ctx.UpdateReadinessStatus(false, scaleUp.Add(11*time.Minute))
+	g.Expect(status.GetReadiness().SLAExceeded).To(gomega.BeTrue())
+	ctx.UpdateReadinessStatus(false, scaleUp.Add(12*time.Minute))
+	g.Expect(slaEvents()).To(gomega.Equal(1))
+
+	ctx.UpdateReadinessStatus(true, scaleUp.Add(15*time.Minute))
+	readiness = status.GetReadiness()
+	g.Expect(readiness.LastProvisioningDuration).To(gomega.Equal("15m0s"))
+	g.Expect(readiness.ReadyCapacity).To(gomega.Equal(int64(4)))
+	g.Expect(readiness.SLAExceeded).To(gomega.BeTrue())
+	g.Expect(sink.Readiness).To(gomega.Equal([]time.Duration{4*time.Minute + 30*time.Second, 15 * time.Minute}))
+	g.Expect(slaEvents()).To(gomega.Equal(1))
+
+	// a scale down is ready without a provisioning
+	asg.DesiredCapacity = aws.Int64(1)
+	ctx.UpdateReadinessStatus(true, scaleUp.Add(time.Hour))
+	g.Expect(status.GetReadiness().ReadyCapacity).To(gomega.Equal(int64(1)))
+	g.Expect(sink.Readiness).To(gomega.HaveLen(2))
+
+	// nodes which were ready before readiness was tracked are not measured
+	status.SetReadiness(nil)
+	ctx.UpdateReadinessStatus(true, scaleUp.Add(2*time.Hour))
+	g.Expect(status.GetReadiness().ProvisioningStartTime).To(gomega.BeNil())
+	g.Expect(status.GetReadiness().ReadyCapacity).To(gomega.Equal(int64(1)))
+	g.Expect(sink.Readiness).To(gomega.HaveLen(2))
 }
 
-func TestGetEksLatestAmiForAL2023(t *testing.T) {
+func TestValidateAuthMappings(t *testing.T) {
 	var (
-		k            = MockKubernetesClientSet()
-		ig           = MockInstanceGroup()
-		config       = ig.GetEKSConfiguration()
-		asgMock      = NewAutoScalingMocker()
-		iamMock      = NewIamMocker()
-		eksMock      = NewEksMocker()
-		ec2Mock      = NewEc2Mocker()
-		ssmMock      = NewSsmMocker()
-		instanceType = "m5.large"
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
 	)
+
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
-	ig.GetEKSConfiguration().UserData = mockUserDataStages()
+	ctx := MockContext(ig, k, w)
+	cluster := MockEksCluster("1.30")
+	cluster.Arn = aws.String("arn:aws:eks:us-west-2:123456789012:cluster/my-cluster")
+	ctx.GetDiscoveredState().SetCluster(cluster)
 
 	tests := []struct {
-		name          string
-		OSFamily      string
-		arch          string
-		expectedError error
+		roleARN      string
+		userARN      string
+		crossAccount bool
+		shouldErr    bool
 	}{
-		{
-			name:          "amazonlinux2023",
-			OSFamily:      "",
-			arch:          "x86_64",
-			expectedError: nil,
-		},
+		{roleARN: "arn:aws:iam::123456789012:role/debug-role", userARN: "arn:aws:iam::123456789012:user/debug-user"},
+		{roleARN: "arn:aws:iam::210987654321:role/debug-role", shouldErr: true},
+		{userARN: "arn:aws:iam::210987654321:user/debug-user", shouldErr: true},
+		{roleARN: "arn:aws:iam::210987654321:role/debug-role", userARN: "arn:aws:iam::210987654321:user/debug-user", crossAccount: true},
 	}
 
-	for _, tc := range tests {
-		config.InstanceType = instanceType
-		ctx := MockContext(ig, k, w)
-		ctx.GetDiscoveredState().SetInstanceTypeInfo([]*ec2.InstanceTypeInfo{
-			{
-				InstanceType: aws.String(instanceType),
-				ProcessorInfo: &ec2.ProcessorInfo{
-					SupportedArchitectures: []*string{aws.String(tc.arch)},
-				},
-			},
-		})
-		_, err := ctx.GetEksLatestAmi()
-		if err == nil && tc.expectedError == nil {
-			continue
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		mappings := &v1alpha1.AuthMappingsSpec{}
+		if tc.roleARN != "" {
+			mappings.Roles = []v1alpha1.AuthMapping{{ARN: tc.roleARN, Username: "debug"}}
 		}
-		if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
-			t.Fatalf("expected %v got %v, test %s", tc.expectedError, err, tc.name)
+		if tc.userARN != "" {
+			mappings.Users = []v1alpha1.AuthMapping{{ARN: tc.userARN, Username: "debug-user"}}
+		}
+		config.AuthMappings = mappings
+		ctx.CrossAccountAuthMappings = tc.crossAccount
+		err := ctx.ValidateAuthMappings()
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
 		}
-
 	}
+
+	// mappings are not validated when the cluster's account is unknown
+	ctx.CrossAccountAuthMappings = false
+	config.AuthMappings = &v1alpha1.AuthMappingsSpec{Roles: []v1alpha1.AuthMapping{{ARN: "arn:aws:iam::210987654321:role/debug-role", Username: "debug"}}}
+	ctx.GetDiscoveredState().SetCluster(MockEksCluster("1.30"))
+	g.Expect(ctx.ValidateAuthMappings()).To(gomega.Succeed())
 }
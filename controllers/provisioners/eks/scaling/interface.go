@@ -42,11 +42,21 @@ type DeleteConfigurationInput struct {
 	Prefix         string
 	DeleteAll      bool
 	RetainVersions int
+	// PinnedVersion is a launch template version which is retained regardless of its age
+	PinnedVersion int64
+	// ScalingGroups are the scaling groups whose referenced launch template versions, and the versions of their
+	// instances, are retained regardless of their age
+	ScalingGroups []*autoscaling.Group
 }
 
 type DiscoverConfigurationInput struct {
 	ScalingGroup     *autoscaling.Group
 	TargetConfigName string
+	// PinnedVersion is the launch template version instances should run instead of the latest version
+	PinnedVersion int64
+	// IgnoreCosmeticChanges skips rotating instances whose launch template version launches the same nodes as the
+	// latest version
+	IgnoreCosmeticChanges bool
 }
 
 type CreateConfigurationInput struct {
@@ -62,6 +72,18 @@ type CreateConfigurationInput struct {
 	LicenseSpecifications []string
 	Placement             *v1alpha1.PlacementSpec
 	MetadataOptions       *v1alpha1.MetadataOptions
+	NetworkInterfaces     []NetworkInterfaceInput
+	// AssociatePublicIpAddress overrides the public IP assignment of the subnet on the primary network interface
+	AssociatePublicIpAddress *bool
+}
+
+// NetworkInterfaceInput is an additional network interface with its subnet and security groups resolved to IDs
+type NetworkInterfaceInput struct {
+	DeviceIndex              int64
+	SubnetId                 string
+	SecurityGroups           []string
+	AssociatePublicIpAddress *bool
+	InterfaceType            string
 }
 
 func ConvertToLaunchTemplate(resource interface{}) *ec2.LaunchTemplate {
@@ -16,6 +16,7 @@ limitations under the License.
 package scaling
 
 import (
+	"encoding/json"
 	"reflect"
 	"sort"
 	"strconv"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/keikoproj/instance-manager/controllers/common"
 	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
@@ -38,6 +40,8 @@ type LaunchTemplate struct {
 	TargetVersions []*ec2.LaunchTemplateVersion
 	LatestVersion  *ec2.LaunchTemplateVersion
 	ResourceList   []*ec2.LaunchTemplate
+	// CleanedVersions is the number of versions deleted by the last call to Delete
+	CleanedVersions int
 }
 
 var (
@@ -56,12 +60,6 @@ func NewLaunchTemplate(ownerName string, w awsprovider.AwsWorker, input *Discove
 }
 
 func (lt *LaunchTemplate) Discover(input *DiscoverConfigurationInput) error {
-	launchTemplates, err := lt.DescribeLaunchTemplates()
-	if err != nil {
-		return errors.Wrap(err, "failed to describe autoscaling launch templates")
-	}
-	lt.ResourceList = launchTemplates
-
 	var targetName string
 	if !common.StringEmpty(input.TargetConfigName) {
 		targetName = input.TargetConfigName
@@ -72,6 +70,13 @@ func (lt *LaunchTemplate) Discover(input *DiscoverConfigurationInput) error {
 		return nil
 	}
 
+	// only the target launch template is described, so that the describes of concurrent reconciles are batched
+	launchTemplates, err := lt.DescribeLaunchTemplatesByName([]string{targetName})
+	if err != nil {
+		return errors.Wrap(err, "failed to describe autoscaling launch templates")
+	}
+	lt.ResourceList = launchTemplates
+
 	for _, config := range launchTemplates {
 		name := aws.StringValue(config.LaunchTemplateName)
 		if strings.EqualFold(name, targetName) {
@@ -82,7 +87,7 @@ func (lt *LaunchTemplate) Discover(input *DiscoverConfigurationInput) error {
 				return errors.Wrap(err, "failed to describe autoscaling launch template versions")
 			}
 			lt.TargetVersions = versions
-			lt.LatestVersion = lt.getVersion(latest)
+			lt.LatestVersion = lt.GetVersion(latest)
 		}
 	}
 
@@ -97,7 +102,8 @@ func (lt *LaunchTemplate) Create(input *CreateConfigurationInput) error {
 		ImageId:               aws.String(input.ImageId),
 		InstanceType:          aws.String(input.InstanceType),
 		KeyName:               aws.String(input.KeyName),
-		SecurityGroupIds:      aws.StringSlice(input.SecurityGroups),
+		SecurityGroupIds:      aws.StringSlice(lt.securityGroupIds(input)),
+		NetworkInterfaces:     lt.networkInterfacesRequest(input),
 		UserData:              aws.String(input.UserData),
 		BlockDeviceMappings:   lt.blockDeviceListRequest(input.Volumes),
 		LicenseSpecifications: lt.LaunchTemplateLicenseConfigurationRequest(input.LicenseSpecifications),
@@ -128,7 +134,7 @@ func (lt *LaunchTemplate) Create(input *CreateConfigurationInput) error {
 			return err
 		}
 		lt.TargetResource = modified
-		lt.LatestVersion = lt.getVersion(*createdVersion.VersionNumber)
+		lt.LatestVersion = lt.GetVersion(*createdVersion.VersionNumber)
 	}
 
 	return nil
@@ -151,6 +157,7 @@ func (lt *LaunchTemplate) Delete(input *DeleteConfigurationInput) error {
 		return nil
 	}
 
+	lt.CleanedVersions = 0
 	sortedVersions := sortVersions(lt.TargetVersions)
 
 	var deletable []*ec2.LaunchTemplateVersion
@@ -159,9 +166,16 @@ func (lt *LaunchTemplate) Delete(input *DeleteConfigurationInput) error {
 		deletable = sortedVersions[:d]
 	}
 
+	referencedVersions := lt.referencedVersions(input.ScalingGroups)
 	deletableVersions := make([]string, 0)
 	for _, d := range deletable {
 		versionNumber := aws.Int64Value(d.VersionNumber)
+		if input.PinnedVersion != 0 && versionNumber == input.PinnedVersion {
+			continue
+		}
+		if aws.BoolValue(d.DefaultVersion) || referencedVersions[versionNumber] {
+			continue
+		}
 		versionString := strconv.FormatInt(versionNumber, 10)
 		deletableVersions = append(deletableVersions, versionString)
 	}
@@ -175,10 +189,62 @@ func (lt *LaunchTemplate) Delete(input *DeleteConfigurationInput) error {
 	if err := lt.DeleteLaunchTemplateVersions(input.Name, deletableVersions); err != nil {
 		return errors.Wrap(err, "failed to delete launch template versions")
 	}
+	lt.CleanedVersions = len(deletableVersions)
 
 	return nil
 }
 
+// referencedVersions returns the default and latest versions of the launch template, and the versions referenced by
+// the scaling groups and their instances
+func (lt *LaunchTemplate) referencedVersions(groups []*autoscaling.Group) map[int64]bool {
+	referenced := make(map[int64]bool)
+	if lt.TargetResource == nil {
+		return referenced
+	}
+
+	var (
+		name           = lt.Name()
+		id             = aws.StringValue(lt.TargetResource.LaunchTemplateId)
+		defaultVersion = aws.Int64Value(lt.TargetResource.DefaultVersionNumber)
+		latestVersion  = aws.Int64Value(lt.TargetResource.LatestVersionNumber)
+	)
+	referenced[defaultVersion] = true
+	referenced[latestVersion] = true
+
+	reference := func(templateName, templateId, version *string) {
+		if !strings.EqualFold(aws.StringValue(templateName), name) && (id == "" || aws.StringValue(templateId) != id) {
+			return
+		}
+		switch v := aws.StringValue(version); v {
+		case "", "$Default":
+			referenced[defaultVersion] = true
+		case "$Latest":
+			referenced[latestVersion] = true
+		default:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				referenced[n] = true
+			}
+		}
+	}
+
+	for _, group := range groups {
+		if spec := group.LaunchTemplate; spec != nil {
+			reference(spec.LaunchTemplateName, spec.LaunchTemplateId, spec.Version)
+		}
+		if policy := group.MixedInstancesPolicy; policy != nil && policy.LaunchTemplate != nil {
+			if spec := policy.LaunchTemplate.LaunchTemplateSpecification; spec != nil {
+				reference(spec.LaunchTemplateName, spec.LaunchTemplateId, spec.Version)
+			}
+		}
+		for _, instance := range group.Instances {
+			if spec := instance.LaunchTemplate; spec != nil {
+				reference(spec.LaunchTemplateName, spec.LaunchTemplateId, spec.Version)
+			}
+		}
+	}
+	return referenced
+}
+
 func (lt *LaunchTemplate) Drifted(input *CreateConfigurationInput) bool {
 	var (
 		latestVersion = lt.LatestVersion
@@ -214,14 +280,24 @@ func (lt *LaunchTemplate) Drifted(input *CreateConfigurationInput) bool {
 		drift = true
 	}
 
-	if !common.StringSliceEquals(aws.StringValueSlice(latestVersion.LaunchTemplateData.SecurityGroupIds), input.SecurityGroups) {
+	if !common.StringSliceEquals(aws.StringValueSlice(latestVersion.LaunchTemplateData.SecurityGroupIds), lt.securityGroupIds(input)) {
 		log.Info("detected drift", "reason", "security-groups has changed", "instancegroup", lt.OwnerName,
-			"previousValue", aws.StringValueSlice(latestVersion.LaunchTemplateData.SecurityGroups),
+			"previousValue", aws.StringValueSlice(latestVersion.LaunchTemplateData.SecurityGroupIds),
 			"newValue", input.SecurityGroups,
 		)
 		drift = true
 	}
 
+	networkInterfaces := lt.networkInterfaces(input)
+	existingInterfaces := sortNetworkInterfaces(latestVersion.LaunchTemplateData.NetworkInterfaces)
+	if !networkInterfacesEqual(existingInterfaces, networkInterfaces) {
+		log.Info("detected drift", "reason", "network interfaces have changed", "instancegroup", lt.OwnerName,
+			"previousValue", latestVersion.LaunchTemplateData.NetworkInterfaces,
+			"newValue", networkInterfaces,
+		)
+		drift = true
+	}
+
 	if aws.StringValue(latestVersion.LaunchTemplateData.KeyName) != input.KeyName {
 		log.Info("detected drift", "reason", "key-pair has changed", "instancegroup", lt.OwnerName,
 			"previousValue", aws.StringValue(latestVersion.LaunchTemplateData.KeyName),
@@ -273,7 +349,7 @@ func (lt *LaunchTemplate) Drifted(input *CreateConfigurationInput) bool {
 
 	metadataOptions := lt.metadataOptions(input.MetadataOptions)
 
-	if !reflect.DeepEqual(metadataOptions, latestVersion.LaunchTemplateData.MetadataOptions) {
+	if !metadataOptionsEqual(metadataOptions, latestVersion.LaunchTemplateData.MetadataOptions) {
 		log.Info("detected drift", "reason", "metadata options have changed", "instancegroup", lt.OwnerName,
 			"previousValue", latestVersion.LaunchTemplateData.MetadataOptions,
 			"newValue", metadataOptions,
@@ -313,6 +389,9 @@ func (lt *LaunchTemplate) RotationNeeded(input *DiscoverConfigurationInput) bool
 	}
 
 	awsLatest := aws.Int64Value(lt.LatestVersion.VersionNumber)
+	if input.PinnedVersion != 0 {
+		awsLatest = input.PinnedVersion
+	}
 	latestVersion := strconv.FormatInt(awsLatest, 10)
 	configName := lt.Name()
 	for _, instance := range input.ScalingGroup.Instances {
@@ -325,6 +404,9 @@ func (lt *LaunchTemplate) RotationNeeded(input *DiscoverConfigurationInput) bool
 		}
 		currentVersion := aws.StringValue(instance.LaunchTemplate.Version)
 		if currentVersion != latestVersion {
+			if input.IgnoreCosmeticChanges && lt.NodeConfigurationEqual(currentVersion, latestVersion) {
+				continue
+			}
 			return true
 		}
 	}
@@ -367,6 +449,17 @@ func (lt *LaunchTemplate) metadataOptions(input *v1alpha1.MetadataOptions) *ec2.
 	}
 }
 
+// metadataOptionsEqual compares the configurable metadata options, described launch templates also report options such
+// as their state which are not configured
+func metadataOptionsEqual(a, b *ec2.LaunchTemplateInstanceMetadataOptions) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return aws.StringValue(a.HttpEndpoint) == aws.StringValue(b.HttpEndpoint) &&
+		aws.StringValue(a.HttpTokens) == aws.StringValue(b.HttpTokens) &&
+		aws.Int64Value(a.HttpPutResponseHopLimit) == aws.Int64Value(b.HttpPutResponseHopLimit)
+}
+
 func (lt *LaunchTemplate) metadataOptionsRequest(input *v1alpha1.MetadataOptions) *ec2.LaunchTemplateInstanceMetadataOptionsRequest {
 	if input == nil {
 		return nil
@@ -385,7 +478,113 @@ func (lt *LaunchTemplate) launchTemplatePlacement(input *v1alpha1.PlacementSpec)
 	return lt.LaunchTemplatePlacement(input.AvailabilityZone, input.HostResourceGroupArn, input.Tenancy)
 }
 
-func (lt *LaunchTemplate) getVersion(id int64) *ec2.LaunchTemplateVersion {
+// hasNetworkInterfaces returns true when the primary network interface needs to be part of the template, which is
+// the case when additional network interfaces are attached or public IP assignment is explicitly configured
+func (lt *LaunchTemplate) hasNetworkInterfaces(input *CreateConfigurationInput) bool {
+	return len(input.NetworkInterfaces) > 0 || input.AssociatePublicIpAddress != nil
+}
+
+// securityGroupIds returns the template level security groups, which cannot be used together with network interfaces
+// in that case the security groups are set on the primary network interface instead
+func (lt *LaunchTemplate) securityGroupIds(input *CreateConfigurationInput) []string {
+	if lt.hasNetworkInterfaces(input) {
+		return nil
+	}
+	return input.SecurityGroups
+}
+
+func (lt *LaunchTemplate) networkInterfacesRequest(input *CreateConfigurationInput) []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
+	if !lt.hasNetworkInterfaces(input) {
+		return nil
+	}
+	interfaces := []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+		{
+			DeviceIndex:              aws.Int64(0),
+			Groups:                   aws.StringSlice(input.SecurityGroups),
+			AssociatePublicIpAddress: input.AssociatePublicIpAddress,
+			DeleteOnTermination:      aws.Bool(true),
+		},
+	}
+	for _, n := range input.NetworkInterfaces {
+		interfaces = append(interfaces, &ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+			DeviceIndex:              aws.Int64(n.DeviceIndex),
+			SubnetId:                 aws.String(n.SubnetId),
+			Groups:                   aws.StringSlice(n.SecurityGroups),
+			AssociatePublicIpAddress: n.AssociatePublicIpAddress,
+			DeleteOnTermination:      aws.Bool(true),
+			InterfaceType:            networkInterfaceType(n.InterfaceType),
+		})
+	}
+	return interfaces
+}
+
+func (lt *LaunchTemplate) networkInterfaces(input *CreateConfigurationInput) []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification {
+	if !lt.hasNetworkInterfaces(input) {
+		return []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{}
+	}
+	interfaces := []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+		{
+			DeviceIndex:              aws.Int64(0),
+			Groups:                   aws.StringSlice(input.SecurityGroups),
+			AssociatePublicIpAddress: input.AssociatePublicIpAddress,
+			DeleteOnTermination:      aws.Bool(true),
+		},
+	}
+	for _, n := range input.NetworkInterfaces {
+		interfaces = append(interfaces, &ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+			DeviceIndex:              aws.Int64(n.DeviceIndex),
+			SubnetId:                 aws.String(n.SubnetId),
+			Groups:                   aws.StringSlice(n.SecurityGroups),
+			AssociatePublicIpAddress: n.AssociatePublicIpAddress,
+			DeleteOnTermination:      aws.Bool(true),
+			InterfaceType:            networkInterfaceType(n.InterfaceType),
+		})
+	}
+	return sortNetworkInterfaces(interfaces)
+}
+
+// networkInterfacesEqual compares the network interfaces of a template with the desired interfaces by the fields the
+// controller sets, templates returned by the API carry additional server defaults
+func networkInterfacesEqual(existing, desired []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	for i := range desired {
+		var (
+			e = existing[i]
+			d = desired[i]
+		)
+		if aws.Int64Value(e.DeviceIndex) != aws.Int64Value(d.DeviceIndex) ||
+			aws.StringValue(e.SubnetId) != aws.StringValue(d.SubnetId) ||
+			!common.StringSliceEquals(aws.StringValueSlice(e.Groups), aws.StringValueSlice(d.Groups)) ||
+			aws.BoolValue(e.AssociatePublicIpAddress) != aws.BoolValue(d.AssociatePublicIpAddress) ||
+			aws.BoolValue(e.DeleteOnTermination) != aws.BoolValue(d.DeleteOnTermination) ||
+			networkInterfaceTypeValue(e.InterfaceType) != networkInterfaceTypeValue(d.InterfaceType) {
+			return false
+		}
+	}
+	return true
+}
+
+// networkInterfaceTypeValue returns the type of an interface, interfaces without an explicit type are regular interfaces
+func networkInterfaceTypeValue(interfaceType *string) string {
+	if t := aws.StringValue(interfaceType); t != "" {
+		return t
+	}
+	return v1alpha1.NetworkInterfaceTypeInterface
+}
+
+// networkInterfaceType returns nil for interfaces without an explicit type, templates of those interfaces do not carry
+// the type so they remain unchanged
+func networkInterfaceType(interfaceType string) *string {
+	if interfaceType == "" {
+		return nil
+	}
+	return aws.String(interfaceType)
+}
+
+// GetVersion returns a discovered version of the launch template, or nil if the version does not exist
+func (lt *LaunchTemplate) GetVersion(id int64) *ec2.LaunchTemplateVersion {
 	for _, v := range lt.TargetVersions {
 		n := aws.Int64Value(v.VersionNumber)
 		if n == id {
@@ -395,6 +594,136 @@ func (lt *LaunchTemplate) getVersion(id int64) *ec2.LaunchTemplateVersion {
 	return nil
 }
 
+// NodeConfigurationEqual returns true if both launch template versions launch nodes with the same configuration, versions
+// which were not discovered are never equal
+func (lt *LaunchTemplate) NodeConfigurationEqual(version, otherVersion string) bool {
+	if version == otherVersion {
+		return true
+	}
+
+	var versions []*ec2.LaunchTemplateVersion
+	for _, v := range []string{version, otherVersion} {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return false
+		}
+		templateVersion := lt.GetVersion(n)
+		if templateVersion == nil || templateVersion.LaunchTemplateData == nil {
+			return false
+		}
+		versions = append(versions, templateVersion)
+	}
+	return NodeConfigurationHash(versions[0].LaunchTemplateData) == NodeConfigurationHash(versions[1].LaunchTemplateData)
+}
+
+// NodeConfigurationHash returns a hash of the launch template data the controller writes, versions only differ
+// cosmetically when they differ in the values AWS adds to that data, such as the order of lists, the default placement
+// tenancy, network interface type and volume encryption, or the state of metadata options. Data the controller does not
+// write is not hashed
+func NodeConfigurationHash(data *ec2.ResponseLaunchTemplateData) string {
+	type networkInterface struct {
+		DeviceIndex              int64
+		SubnetId                 string
+		Groups                   []string
+		AssociatePublicIpAddress bool
+		DeleteOnTermination      bool
+		InterfaceType            string
+	}
+	type blockDevice struct {
+		DeviceName          string
+		VolumeType          string
+		VolumeSize          int64
+		Iops                int64
+		Throughput          int64
+		SnapshotId          string
+		DeleteOnTermination bool
+		Encrypted           bool
+	}
+
+	config := struct {
+		ImageId               string
+		InstanceType          string
+		IamInstanceProfileArn string
+		KeyName               string
+		UserData              string
+		SecurityGroupIds      []string
+		NetworkInterfaces     []networkInterface
+		BlockDeviceMappings   []blockDevice
+		LicenseSpecifications []string
+		Placement             *ec2.LaunchTemplatePlacement
+		MetadataOptions       *ec2.LaunchTemplateInstanceMetadataOptions
+	}{
+		ImageId:               aws.StringValue(data.ImageId),
+		InstanceType:          aws.StringValue(data.InstanceType),
+		KeyName:               aws.StringValue(data.KeyName),
+		UserData:              aws.StringValue(data.UserData),
+		SecurityGroupIds:      aws.StringValueSlice(data.SecurityGroupIds),
+		NetworkInterfaces:     []networkInterface{},
+		BlockDeviceMappings:   []blockDevice{},
+		LicenseSpecifications: []string{},
+		Placement:             &ec2.LaunchTemplatePlacement{Tenancy: aws.String(ec2.TenancyDefault)},
+	}
+	if data.IamInstanceProfile != nil {
+		config.IamInstanceProfileArn = aws.StringValue(data.IamInstanceProfile.Arn)
+	}
+	sort.Strings(config.SecurityGroupIds)
+
+	for _, n := range sortNetworkInterfaces(append([]*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{}, data.NetworkInterfaces...)) {
+		groups := aws.StringValueSlice(n.Groups)
+		sort.Strings(groups)
+		config.NetworkInterfaces = append(config.NetworkInterfaces, networkInterface{
+			DeviceIndex:              aws.Int64Value(n.DeviceIndex),
+			SubnetId:                 aws.StringValue(n.SubnetId),
+			Groups:                   groups,
+			AssociatePublicIpAddress: aws.BoolValue(n.AssociatePublicIpAddress),
+			DeleteOnTermination:      aws.BoolValue(n.DeleteOnTermination),
+			InterfaceType:            networkInterfaceTypeValue(n.InterfaceType),
+		})
+	}
+
+	for _, d := range sortTemplateDevices(append([]*ec2.LaunchTemplateBlockDeviceMapping{}, data.BlockDeviceMappings...)) {
+		device := blockDevice{DeviceName: aws.StringValue(d.DeviceName)}
+		if ebs := d.Ebs; ebs != nil {
+			device.VolumeType = aws.StringValue(ebs.VolumeType)
+			device.VolumeSize = aws.Int64Value(ebs.VolumeSize)
+			device.Iops = aws.Int64Value(ebs.Iops)
+			device.Throughput = aws.Int64Value(ebs.Throughput)
+			device.SnapshotId = aws.StringValue(ebs.SnapshotId)
+			device.DeleteOnTermination = aws.BoolValue(ebs.DeleteOnTermination)
+			device.Encrypted = aws.BoolValue(ebs.Encrypted)
+		}
+		config.BlockDeviceMappings = append(config.BlockDeviceMappings, device)
+	}
+
+	for _, l := range data.LicenseSpecifications {
+		config.LicenseSpecifications = append(config.LicenseSpecifications, aws.StringValue(l.LicenseConfigurationArn))
+	}
+	sort.Strings(config.LicenseSpecifications)
+
+	if placement := data.Placement; placement != nil {
+		config.Placement.AvailabilityZone = placement.AvailabilityZone
+		config.Placement.HostResourceGroupArn = placement.HostResourceGroupArn
+		if !common.StringEmpty(aws.StringValue(placement.Tenancy)) {
+			config.Placement.Tenancy = placement.Tenancy
+		}
+	}
+
+	if options := data.MetadataOptions; options != nil {
+		// the state of the options is not part of the configuration
+		config.MetadataOptions = &ec2.LaunchTemplateInstanceMetadataOptions{
+			HttpEndpoint:            options.HttpEndpoint,
+			HttpPutResponseHopLimit: options.HttpPutResponseHopLimit,
+			HttpTokens:              options.HttpTokens,
+		}
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	return common.StringMD5(string(b))
+}
+
 func sortTemplateDevices(devices []*ec2.LaunchTemplateBlockDeviceMapping) []*ec2.LaunchTemplateBlockDeviceMapping {
 	if len(devices) == 0 {
 		return []*ec2.LaunchTemplateBlockDeviceMapping{}
@@ -405,6 +734,16 @@ func sortTemplateDevices(devices []*ec2.LaunchTemplateBlockDeviceMapping) []*ec2
 	return devices
 }
 
+func sortNetworkInterfaces(interfaces []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification) []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification {
+	if len(interfaces) == 0 {
+		return []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{}
+	}
+	sort.Slice(interfaces[:], func(i, j int) bool {
+		return aws.Int64Value(interfaces[i].DeviceIndex) < aws.Int64Value(interfaces[j].DeviceIndex)
+	})
+	return interfaces
+}
+
 func sortVersions(versions []*ec2.LaunchTemplateVersion) []*ec2.LaunchTemplateVersion {
 	// sort matching launch configs by created time
 	sort.Slice(versions, func(i, j int) bool {
@@ -57,6 +57,25 @@ func MockLaunchTemplateVersion() *ec2.LaunchTemplateVersion {
 	}
 }
 
+func MockLaunchTemplateVersionWithSecurityGroups(groups ...string) *ec2.LaunchTemplateVersion {
+	version := MockLaunchTemplateVersion()
+	version.LaunchTemplateData.SecurityGroupIds = aws.StringSlice(groups)
+	return version
+}
+
+func MockLaunchTemplateVersionWithMetadataOptions(hopLimit int64) *ec2.LaunchTemplateVersion {
+	version := MockLaunchTemplateVersion()
+	version.LaunchTemplateData.MetadataOptions = &ec2.LaunchTemplateInstanceMetadataOptions{
+		HttpEndpoint:            aws.String("enabled"),
+		HttpTokens:              aws.String("optional"),
+		HttpPutResponseHopLimit: aws.Int64(hopLimit),
+		HttpProtocolIpv6:        aws.String("disabled"),
+		InstanceMetadataTags:    aws.String("disabled"),
+		State:                   aws.String("applied"),
+	}
+	return version
+}
+
 func MockLaunchTemplate(name string) *ec2.LaunchTemplate {
 	return &ec2.LaunchTemplate{
 		LaunchTemplateName: aws.String(name),
@@ -77,10 +96,12 @@ type MockEc2Client struct {
 	DeleteLaunchTemplateCallCount         int
 	LaunchTemplates                       []*ec2.LaunchTemplate
 	LaunchTemplateVersions                []*ec2.LaunchTemplateVersion
+	CreatedLaunchTemplateData             *ec2.RequestLaunchTemplateData
 }
 
 func (c *MockEc2Client) CreateLaunchTemplate(input *ec2.CreateLaunchTemplateInput) (*ec2.CreateLaunchTemplateOutput, error) {
 	c.CreateLaunchTemplateCallCount++
+	c.CreatedLaunchTemplateData = input.LaunchTemplateData
 	return &ec2.CreateLaunchTemplateOutput{}, c.CreateLaunchTemplateErr
 }
 
@@ -210,11 +231,12 @@ func TestLaunchTemplateDiscover(t *testing.T) {
 	g.Expect(lt.Resource().(*ec2.LaunchTemplate)).To(gomega.BeNil())
 	g.Expect(lt.Name()).To(gomega.BeEmpty())
 
+	// launch templates are not described without a target name
 	discoveryInput.ScalingGroup = nil
 	lt, err = NewLaunchTemplate("", w, discoveryInput)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	g.Expect(lt.TargetResource).To(gomega.BeNil())
-	g.Expect(lt.ResourceList).To(gomega.Equal(resourceList))
+	g.Expect(lt.ResourceList).To(gomega.BeEmpty())
 	g.Expect(lt.Provisioned()).To(gomega.BeFalse())
 	g.Expect(lt.Resource().(*ec2.LaunchTemplate)).To(gomega.BeNil())
 	g.Expect(lt.Name()).To(gomega.BeEmpty())
@@ -312,7 +334,7 @@ func TestLaunchTemplateCreate(t *testing.T) {
 	lt, err = NewLaunchTemplate("", w, discoveryInput)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	g.Expect(lt.TargetResource).To(gomega.BeNil())
-	g.Expect(lt.ResourceList).To(gomega.Equal(resourceList))
+	g.Expect(lt.ResourceList).To(gomega.BeEmpty())
 	err = lt.Create(&CreateConfigurationInput{
 		Name:      "some-config",
 		SpotPrice: "1.0",
@@ -320,6 +342,106 @@ func TestLaunchTemplateCreate(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 }
 
+func TestLaunchTemplateCreateNetworkInterfaces(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		asgMock = &MockAutoScalingClient{}
+		ec2Mock = &MockEc2Client{}
+	)
+
+	w := awsprovider.AwsWorker{
+		AsgClient: asgMock,
+		Ec2Client: ec2Mock,
+	}
+
+	lt, err := NewLaunchTemplate("", w, &DiscoverConfigurationInput{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// without network interfaces security groups are set on the template
+	err = lt.Create(&CreateConfigurationInput{
+		Name:           "some-config",
+		SecurityGroups: []string{"sg-0000"},
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(aws.StringValueSlice(ec2Mock.CreatedLaunchTemplateData.SecurityGroupIds)).To(gomega.Equal([]string{"sg-0000"}))
+	g.Expect(ec2Mock.CreatedLaunchTemplateData.NetworkInterfaces).To(gomega.BeNil())
+
+	// with network interfaces security groups move to the primary interface
+	err = lt.Create(&CreateConfigurationInput{
+		Name:           "some-config",
+		SecurityGroups: []string{"sg-0000"},
+		NetworkInterfaces: []NetworkInterfaceInput{
+			{DeviceIndex: 1, SubnetId: "subnet-1111", SecurityGroups: []string{"sg-1111"}},
+			{DeviceIndex: 2, SubnetId: "subnet-2222", SecurityGroups: []string{"sg-2222", "sg-3333"}, AssociatePublicIpAddress: aws.Bool(false)},
+			{DeviceIndex: 3, SubnetId: "subnet-1111", SecurityGroups: []string{"sg-4444"}, InterfaceType: "efa"},
+		},
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ec2Mock.CreatedLaunchTemplateData.SecurityGroupIds).To(gomega.BeEmpty())
+	g.Expect(ec2Mock.CreatedLaunchTemplateData.NetworkInterfaces).To(gomega.Equal([]*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+		{
+			DeviceIndex:         aws.Int64(0),
+			Groups:              aws.StringSlice([]string{"sg-0000"}),
+			DeleteOnTermination: aws.Bool(true),
+		},
+		{
+			DeviceIndex:         aws.Int64(1),
+			SubnetId:            aws.String("subnet-1111"),
+			Groups:              aws.StringSlice([]string{"sg-1111"}),
+			DeleteOnTermination: aws.Bool(true),
+		},
+		{
+			DeviceIndex:              aws.Int64(2),
+			SubnetId:                 aws.String("subnet-2222"),
+			Groups:                   aws.StringSlice([]string{"sg-2222", "sg-3333"}),
+			AssociatePublicIpAddress: aws.Bool(false),
+			DeleteOnTermination:      aws.Bool(true),
+		},
+		{
+			DeviceIndex:         aws.Int64(3),
+			SubnetId:            aws.String("subnet-1111"),
+			Groups:              aws.StringSlice([]string{"sg-4444"}),
+			DeleteOnTermination: aws.Bool(true),
+			InterfaceType:       aws.String("efa"),
+		},
+	}))
+}
+
+func TestLaunchTemplateCreateAssociatePublicIpAddress(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		asgMock = &MockAutoScalingClient{}
+		ec2Mock = &MockEc2Client{}
+	)
+
+	w := awsprovider.AwsWorker{
+		AsgClient: asgMock,
+		Ec2Client: ec2Mock,
+	}
+
+	lt, err := NewLaunchTemplate("", w, &DiscoverConfigurationInput{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, associate := range []bool{true, false} {
+		t.Logf("associatePublicIpAddress: %v", associate)
+		err = lt.Create(&CreateConfigurationInput{
+			Name:                     "some-config",
+			SecurityGroups:           []string{"sg-0000"},
+			AssociatePublicIpAddress: aws.Bool(associate),
+		})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(ec2Mock.CreatedLaunchTemplateData.SecurityGroupIds).To(gomega.BeEmpty())
+		g.Expect(ec2Mock.CreatedLaunchTemplateData.NetworkInterfaces).To(gomega.Equal([]*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+			{
+				DeviceIndex:              aws.Int64(0),
+				Groups:                   aws.StringSlice([]string{"sg-0000"}),
+				AssociatePublicIpAddress: aws.Bool(associate),
+				DeleteOnTermination:      aws.Bool(true),
+			},
+		}))
+	}
+}
+
 func TestLaunchTemplateDelete(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
@@ -413,6 +535,20 @@ func TestLaunchTemplateDelete(t *testing.T) {
 	ec2Mock.DeletedLaunchTemplateVersionCount = 0
 	ec2Mock.DeleteLaunchTemplateVersionsCallCount = 0
 
+	// a pinned version is retained even if it is older than the retained versions
+	err = lt.Delete(&DeleteConfigurationInput{
+		Name:           "prefix-my-launch-template",
+		Prefix:         "prefix-",
+		RetainVersions: 2,
+		DeleteAll:      false,
+		PinnedVersion:  1,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ec2Mock.DeleteLaunchTemplateVersionsCallCount).To(gomega.Equal(1))
+	g.Expect(ec2Mock.DeletedLaunchTemplateVersionCount).To(gomega.Equal(2))
+	ec2Mock.DeletedLaunchTemplateVersionCount = 0
+	ec2Mock.DeleteLaunchTemplateVersionsCallCount = 0
+
 	err = lt.Delete(&DeleteConfigurationInput{
 		Name:      "prefix-my-launch-template",
 		Prefix:    "prefix-",
@@ -435,6 +571,106 @@ func TestLaunchTemplateDelete(t *testing.T) {
 	ec2Mock.DeleteLaunchTemplateErr = nil
 }
 
+func TestLaunchTemplateDeleteReferencedVersions(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		asgMock = &MockAutoScalingClient{}
+		ec2Mock = &MockEc2Client{}
+		now     = time.Now()
+	)
+
+	w := awsprovider.AwsWorker{
+		AsgClient: asgMock,
+		Ec2Client: ec2Mock,
+	}
+
+	versions := make([]*ec2.LaunchTemplateVersion, 0)
+	for i := 1; i <= 8; i++ {
+		versions = append(versions, &ec2.LaunchTemplateVersion{
+			LaunchTemplateName: aws.String("prefix-my-launch-template"),
+			VersionNumber:      aws.Int64(int64(i)),
+			DefaultVersion:     aws.Bool(i == 2),
+			CreateTime:         aws.Time(now.Add(time.Duration(i-10) * time.Minute)),
+		})
+	}
+	ec2Mock.LaunchTemplateVersions = versions
+	ec2Mock.LaunchTemplates = []*ec2.LaunchTemplate{
+		{
+			LaunchTemplateName:   aws.String("prefix-my-launch-template"),
+			LaunchTemplateId:     aws.String("lt-1234"),
+			DefaultVersionNumber: aws.Int64(2),
+			LatestVersionNumber:  aws.Int64(8),
+		},
+	}
+
+	scalingGroups := []*autoscaling.Group{
+		{
+			AutoScalingGroupName: aws.String("my-asg"),
+			MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+				LaunchTemplate: &autoscaling.LaunchTemplate{
+					LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
+						LaunchTemplateName: aws.String("prefix-my-launch-template"),
+						Version:            aws.String("$Latest"),
+					},
+				},
+			},
+			Instances: []*autoscaling.Instance{
+				{
+					InstanceId: aws.String("i-1"),
+					LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+						LaunchTemplateId: aws.String("lt-1234"),
+						Version:          aws.String("3"),
+					},
+				},
+				{
+					InstanceId: aws.String("i-2"),
+					LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+						LaunchTemplateName: aws.String("prefix-other-launch-template"),
+						Version:            aws.String("4"),
+					},
+				},
+			},
+		},
+		{
+			AutoScalingGroupName: aws.String("my-asg-zone"),
+			LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+				LaunchTemplateName: aws.String("prefix-my-launch-template"),
+				Version:            aws.String("5"),
+			},
+		},
+	}
+
+	lt, err := NewLaunchTemplate("", w, &DiscoverConfigurationInput{ScalingGroup: scalingGroups[0]})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// versions 2 (default), 3 (in use by an instance) and 5 (referenced by a scaling group) are retained,
+	// version 4 is only in use by another launch template
+	err = lt.Delete(&DeleteConfigurationInput{
+		Name:           "prefix-my-launch-template",
+		Prefix:         "prefix-",
+		RetainVersions: 2,
+		ScalingGroups:  scalingGroups,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ec2Mock.DeleteLaunchTemplateVersionsCallCount).To(gomega.Equal(1))
+	g.Expect(ec2Mock.DeletedLaunchTemplateVersionCount).To(gomega.Equal(3))
+	g.Expect(lt.CleanedVersions).To(gomega.Equal(3))
+	ec2Mock.DeletedLaunchTemplateVersionCount = 0
+	ec2Mock.DeleteLaunchTemplateVersionsCallCount = 0
+
+	// only unreferenced versions outside a larger retention window are deleted
+	err = lt.Delete(&DeleteConfigurationInput{
+		Name:           "prefix-my-launch-template",
+		Prefix:         "prefix-",
+		RetainVersions: 5,
+		ScalingGroups:  scalingGroups,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ec2Mock.DeleteLaunchTemplateVersionsCallCount).To(gomega.Equal(1))
+	g.Expect(ec2Mock.DeletedLaunchTemplateVersionCount).To(gomega.Equal(1))
+	g.Expect(lt.CleanedVersions).To(gomega.Equal(1))
+}
+
 func TestLaunchTemplateRotationNeeded(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
@@ -450,17 +686,22 @@ func TestLaunchTemplateRotationNeeded(t *testing.T) {
 	tests := []struct {
 		scalingInstances []*autoscaling.Instance
 		latestVersion    string
+		pinnedVersion    int64
 		rotationNeeded   bool
 	}{
 		{scalingInstances: []*autoscaling.Instance{}, latestVersion: "6", rotationNeeded: false},
 		{scalingInstances: []*autoscaling.Instance{MockLaunchTemplateScalingInstance("i-1234", "my-launch-template", "6"), MockLaunchTemplateScalingInstance("i-2222", "my-launch-template", "6")}, latestVersion: "6", rotationNeeded: false},
 		{scalingInstances: []*autoscaling.Instance{MockLaunchTemplateScalingInstance("i-1234", "my-launch-template", "6"), MockLaunchTemplateScalingInstance("i-2222", "my-launch-template", "5")}, latestVersion: "6", rotationNeeded: true},
 		{scalingInstances: []*autoscaling.Instance{MockLaunchTemplateScalingInstance("i-1234", "my-launch-template", "6"), MockLaunchTemplateScalingInstance("i-2222", "other-launch-template", "6")}, latestVersion: "6", rotationNeeded: true},
+		// instances are rotated to a pinned version rather than the latest version
+		{scalingInstances: []*autoscaling.Instance{MockLaunchTemplateScalingInstance("i-1234", "my-launch-template", "6")}, latestVersion: "6", pinnedVersion: 4, rotationNeeded: true},
+		{scalingInstances: []*autoscaling.Instance{MockLaunchTemplateScalingInstance("i-1234", "my-launch-template", "4")}, latestVersion: "6", pinnedVersion: 4, rotationNeeded: false},
 	}
 
 	for i, tc := range tests {
 		t.Logf("Test #%v", i)
 		discoveryInput := &DiscoverConfigurationInput{
+			PinnedVersion: tc.pinnedVersion,
 			ScalingGroup: &autoscaling.Group{
 				Instances:            tc.scalingInstances,
 				AutoScalingGroupName: aws.String("my-asg"),
@@ -492,6 +733,121 @@ func TestLaunchTemplateRotationNeeded(t *testing.T) {
 
 }
 
+func TestLaunchTemplateRotationNeededIgnoreCosmeticChanges(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		asgMock = &MockAutoScalingClient{}
+		ec2Mock = &MockEc2Client{}
+	)
+
+	w := awsprovider.AwsWorker{
+		AsgClient: asgMock,
+		Ec2Client: ec2Mock,
+	}
+
+	// versions with server defaults carry the default tenancy AWS adds to the placement the controller writes
+	mockVersion := func(number int64, imageId, userData string, serverDefaults ...bool) *ec2.LaunchTemplateVersion {
+		version := MockLaunchTemplateVersion()
+		version.VersionNumber = aws.Int64(number)
+		version.LaunchTemplateData.ImageId = aws.String(imageId)
+		version.LaunchTemplateData.UserData = aws.String(userData)
+		version.LaunchTemplateData.Placement = &ec2.LaunchTemplatePlacement{}
+		if len(serverDefaults) > 0 && serverDefaults[0] {
+			version.LaunchTemplateData.Placement.Tenancy = aws.String(ec2.TenancyDefault)
+		}
+		return version
+	}
+
+	tests := []struct {
+		name           string
+		running        *ec2.LaunchTemplateVersion
+		latest         *ec2.LaunchTemplateVersion
+		ignore         bool
+		rotationNeeded bool
+	}{
+		{name: "cosmetic change", running: mockVersion(1, "ami-123", "userdata"), latest: mockVersion(2, "ami-123", "userdata", true), ignore: true},
+		{name: "cosmetic change is rotated when not ignored", running: mockVersion(1, "ami-123", "userdata"), latest: mockVersion(2, "ami-123", "userdata", true), rotationNeeded: true},
+		{name: "image change", running: mockVersion(1, "ami-123", "userdata"), latest: mockVersion(2, "ami-456", "userdata", true), ignore: true, rotationNeeded: true},
+		{name: "userdata change", running: mockVersion(1, "ami-123", "userdata"), latest: mockVersion(2, "ami-123", "new-userdata"), ignore: true, rotationNeeded: true},
+		{name: "running version is not discovered", latest: mockVersion(2, "ami-123", "userdata"), ignore: true, rotationNeeded: true},
+	}
+
+	for _, tc := range tests {
+		t.Logf("Test: %v", tc.name)
+		discoveryInput := &DiscoverConfigurationInput{
+			IgnoreCosmeticChanges: tc.ignore,
+			ScalingGroup: &autoscaling.Group{
+				Instances:            []*autoscaling.Instance{MockLaunchTemplateScalingInstance("i-1234", "my-launch-template", "1")},
+				AutoScalingGroupName: aws.String("my-asg"),
+				LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+					LaunchTemplateName: aws.String("my-launch-template"),
+					Version:            aws.String("2"),
+				},
+			},
+		}
+
+		ec2Mock.LaunchTemplates = []*ec2.LaunchTemplate{MockLaunchTemplate("my-launch-template")}
+		lt, err := NewLaunchTemplate("", w, discoveryInput)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		lt.TargetVersions = []*ec2.LaunchTemplateVersion{tc.latest}
+		if tc.running != nil {
+			lt.TargetVersions = append(lt.TargetVersions, tc.running)
+		}
+		lt.LatestVersion = tc.latest
+
+		g.Expect(lt.RotationNeeded(discoveryInput)).To(gomega.Equal(tc.rotationNeeded))
+	}
+}
+
+func TestNodeConfigurationHash(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	w := awsprovider.AwsWorker{}
+	mockVersion := func(groups ...string) *ec2.LaunchTemplateVersion {
+		version := MockLaunchTemplateVersionWithSecurityGroups(groups...)
+		version.LaunchTemplateData.Placement = &ec2.LaunchTemplatePlacement{}
+		version.LaunchTemplateData.BlockDeviceMappings = []*ec2.LaunchTemplateBlockDeviceMapping{
+			w.GetLaunchTemplateBlockDevice("/dev/xvda", "gp3", "", 30, 0, 0, nil, nil),
+		}
+		version.LaunchTemplateData.NetworkInterfaces = []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+			{DeviceIndex: aws.Int64(0), Groups: aws.StringSlice(groups), DeleteOnTermination: aws.Bool(true)},
+		}
+		return version
+	}
+
+	version := mockVersion("sg-1", "sg-2")
+	hash := NodeConfigurationHash(version.LaunchTemplateData)
+
+	// ordering and the defaults AWS adds to the data the controller writes do not change the hash
+	cosmetic := mockVersion("sg-2", "sg-1")
+	cosmetic.LaunchTemplateData.Placement.Tenancy = aws.String(ec2.TenancyDefault)
+	cosmetic.LaunchTemplateData.BlockDeviceMappings[0].Ebs.Encrypted = aws.Bool(false)
+	cosmetic.LaunchTemplateData.NetworkInterfaces[0].InterfaceType = aws.String("interface")
+	cosmetic.LaunchTemplateData.NetworkInterfaces[0].AssociatePublicIpAddress = aws.Bool(false)
+	g.Expect(NodeConfigurationHash(cosmetic.LaunchTemplateData)).To(gomega.Equal(hash))
+
+	// node-affecting changes change the hash
+	changes := []func(*ec2.ResponseLaunchTemplateData){
+		func(d *ec2.ResponseLaunchTemplateData) { d.ImageId = aws.String("ami-456") },
+		func(d *ec2.ResponseLaunchTemplateData) { d.InstanceType = aws.String("m5.xlarge") },
+		func(d *ec2.ResponseLaunchTemplateData) { d.UserData = aws.String("new-userdata") },
+		func(d *ec2.ResponseLaunchTemplateData) { d.SecurityGroupIds = aws.StringSlice([]string{"sg-1"}) },
+		func(d *ec2.ResponseLaunchTemplateData) {
+			d.MetadataOptions = MockLaunchTemplateVersionWithMetadataOptions(2).LaunchTemplateData.MetadataOptions
+		},
+		func(d *ec2.ResponseLaunchTemplateData) { d.Placement.Tenancy = aws.String(ec2.TenancyDedicated) },
+		func(d *ec2.ResponseLaunchTemplateData) { d.BlockDeviceMappings[0].Ebs.Encrypted = aws.Bool(true) },
+		func(d *ec2.ResponseLaunchTemplateData) { d.NetworkInterfaces[0].InterfaceType = aws.String("efa") },
+	}
+	for i, change := range changes {
+		t.Logf("Change #%v", i)
+		changed := mockVersion("sg-1", "sg-2")
+		change(changed.LaunchTemplateData)
+		g.Expect(NodeConfigurationHash(changed.LaunchTemplateData)).NotTo(gomega.Equal(hash))
+	}
+}
+
 func TestLaunchTemplateDrifted(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
@@ -563,6 +919,23 @@ func TestLaunchTemplateDrifted(t *testing.T) {
 			},
 			shouldDrift: true,
 		},
+		{
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion:  MockLaunchTemplateVersionWithSecurityGroups("sg-2", "sg-1"),
+			input: &CreateConfigurationInput{
+				SecurityGroups: []string{"sg-1", "sg-2"},
+			},
+			shouldDrift: false,
+		},
+		{
+			// security groups added to the launch template out-of-band are corrected
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion:  MockLaunchTemplateVersionWithSecurityGroups("sg-1", "sg-2", "sg-3"),
+			input: &CreateConfigurationInput{
+				SecurityGroups: []string{"sg-1", "sg-2"},
+			},
+			shouldDrift: true,
+		},
 		{
 			launchTemplate: MockLaunchTemplate("my-launch-template"),
 			latestVersion:  MockLaunchTemplateVersion(),
@@ -623,6 +996,191 @@ func TestLaunchTemplateDrifted(t *testing.T) {
 			},
 			shouldDrift: true,
 		},
+		{
+			// launch templates without metadata options do not drift while none are configured
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion:  MockLaunchTemplateVersion(),
+			input:          &CreateConfigurationInput{},
+			shouldDrift:    false,
+		},
+		{
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion:  MockLaunchTemplateVersionWithMetadataOptions(2),
+			input: &CreateConfigurationInput{
+				MetadataOptions: &v1alpha1.MetadataOptions{
+					HttpEndpoint:    "enabled",
+					HttpTokens:      "optional",
+					HttpPutHopLimit: 2,
+				},
+			},
+			shouldDrift: false,
+		},
+		{
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion:  MockLaunchTemplateVersionWithMetadataOptions(2),
+			input: &CreateConfigurationInput{
+				MetadataOptions: &v1alpha1.MetadataOptions{
+					HttpEndpoint:    "enabled",
+					HttpTokens:      "optional",
+					HttpPutHopLimit: 1,
+				},
+			},
+			shouldDrift: true,
+		},
+		{
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion:  MockLaunchTemplateVersion(),
+			input: &CreateConfigurationInput{
+				NetworkInterfaces: []NetworkInterfaceInput{
+					{DeviceIndex: 1, SubnetId: "subnet-1111", SecurityGroups: []string{"sg-1111"}},
+				},
+			},
+			shouldDrift: true,
+		},
+		{
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion: &ec2.LaunchTemplateVersion{
+				LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+					IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecification{
+						Arn: aws.String(""),
+					},
+					InstanceType: aws.String(""),
+					ImageId:      aws.String(""),
+					KeyName:      aws.String(""),
+					UserData:     aws.String(""),
+					NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+						{
+							DeviceIndex:         aws.Int64(1),
+							SubnetId:            aws.String("subnet-1111"),
+							Groups:              aws.StringSlice([]string{"sg-1111"}),
+							DeleteOnTermination: aws.Bool(true),
+						},
+						{
+							DeviceIndex:         aws.Int64(0),
+							Groups:              aws.StringSlice([]string{"sg-0000"}),
+							DeleteOnTermination: aws.Bool(true),
+						},
+					},
+				},
+			},
+			input: &CreateConfigurationInput{
+				SecurityGroups: []string{"sg-0000"},
+				NetworkInterfaces: []NetworkInterfaceInput{
+					{DeviceIndex: 1, SubnetId: "subnet-1111", SecurityGroups: []string{"sg-1111"}},
+				},
+			},
+			shouldDrift: false,
+		},
+		{
+			// server defaults returned with the template are not drift
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion: &ec2.LaunchTemplateVersion{
+				LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+					IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecification{
+						Arn: aws.String(""),
+					},
+					InstanceType: aws.String(""),
+					ImageId:      aws.String(""),
+					KeyName:      aws.String(""),
+					UserData:     aws.String(""),
+					NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+						{
+							DeviceIndex:              aws.Int64(0),
+							Groups:                   aws.StringSlice([]string{"sg-0000"}),
+							AssociatePublicIpAddress: aws.Bool(false),
+							DeleteOnTermination:      aws.Bool(true),
+							NetworkCardIndex:         aws.Int64(0),
+							Ipv6Addresses:            []*ec2.InstanceIpv6Address{},
+						},
+						{
+							DeviceIndex:         aws.Int64(1),
+							SubnetId:            aws.String("subnet-1111"),
+							Groups:              aws.StringSlice([]string{"sg-1111"}),
+							DeleteOnTermination: aws.Bool(true),
+							Description:         aws.String(""),
+							InterfaceType:       aws.String("interface"),
+							NetworkCardIndex:    aws.Int64(0),
+						},
+					},
+				},
+			},
+			input: &CreateConfigurationInput{
+				SecurityGroups: []string{"sg-0000"},
+				NetworkInterfaces: []NetworkInterfaceInput{
+					{DeviceIndex: 1, SubnetId: "subnet-1111", SecurityGroups: []string{"sg-1111"}},
+				},
+			},
+			shouldDrift: false,
+		},
+		{
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion: &ec2.LaunchTemplateVersion{
+				LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+					IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecification{
+						Arn: aws.String(""),
+					},
+					InstanceType: aws.String(""),
+					ImageId:      aws.String(""),
+					KeyName:      aws.String(""),
+					UserData:     aws.String(""),
+					NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+						{
+							DeviceIndex:         aws.Int64(0),
+							Groups:              aws.StringSlice([]string{"sg-0000"}),
+							DeleteOnTermination: aws.Bool(true),
+						},
+						{
+							DeviceIndex:         aws.Int64(1),
+							SubnetId:            aws.String("subnet-1111"),
+							Groups:              aws.StringSlice([]string{"sg-1111"}),
+							DeleteOnTermination: aws.Bool(true),
+						},
+					},
+				},
+			},
+			input: &CreateConfigurationInput{
+				SecurityGroups: []string{"sg-0000"},
+				NetworkInterfaces: []NetworkInterfaceInput{
+					{DeviceIndex: 1, SubnetId: "subnet-2222", SecurityGroups: []string{"sg-1111"}},
+				},
+			},
+			shouldDrift: true,
+		},
+		{
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion:  MockLaunchTemplateVersion(),
+			input: &CreateConfigurationInput{
+				AssociatePublicIpAddress: aws.Bool(false),
+			},
+			shouldDrift: true,
+		},
+		{
+			launchTemplate: MockLaunchTemplate("my-launch-template"),
+			latestVersion: &ec2.LaunchTemplateVersion{
+				LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+					IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecification{
+						Arn: aws.String(""),
+					},
+					InstanceType: aws.String(""),
+					ImageId:      aws.String(""),
+					KeyName:      aws.String(""),
+					UserData:     aws.String(""),
+					NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+						{
+							DeviceIndex:              aws.Int64(0),
+							Groups:                   aws.StringSlice([]string{"sg-0000"}),
+							AssociatePublicIpAddress: aws.Bool(true),
+							DeleteOnTermination:      aws.Bool(true),
+						},
+					},
+				},
+			},
+			input: &CreateConfigurationInput{
+				SecurityGroups:           []string{"sg-0000"},
+				AssociatePublicIpAddress: aws.Bool(true),
+			},
+			shouldDrift: false,
+		},
 	}
 
 	for i, tc := range tests {
@@ -16,8 +16,14 @@ limitations under the License.
 package eks
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 )
 
 const (
@@ -31,6 +37,9 @@ func (ctx *EksInstanceGroupContext) StateDiscovery() {
 		provisioned   = state.IsProvisioned()
 		group         = state.GetScalingGroup()
 	)
+	// the exported state is refreshed every reconcile, with the state derived below
+	defer ctx.ExportDiscoveredState()
+
 	// only discover state if it's a new reconcile
 	if instanceGroup.GetState() != v1alpha1.ReconcileInit {
 		return
@@ -58,7 +67,11 @@ func (ctx *EksInstanceGroupContext) StateDiscovery() {
 		}
 	} else {
 		// resource is not being deleted
-		if provisioned {
+		if provisioned && state.IsTransient() {
+			// scaling group is mid-operation, wait for it to complete before modifying it
+			ctx.Log.Info("scaling group is in a transient state, will requeue", "instancegroup", instanceGroup.NamespacedName(), "reason", state.GetTransientReason())
+			ctx.SetState(v1alpha1.ReconcileModifying)
+		} else if provisioned {
 			// scaling group exists
 			ctx.SetState(v1alpha1.ReconcileInitUpdate)
 		} else {
@@ -69,7 +82,267 @@ func (ctx *EksInstanceGroupContext) StateDiscovery() {
 
 }
 
+// discoverTransientState returns the reason a provisioned scaling group cannot currently be modified, or an empty string
+func (ctx *EksInstanceGroupContext) discoverTransientState() (string, error) {
+	var (
+		state         = ctx.GetDiscoveredState()
+		configuration = ctx.GetInstanceGroup().GetEKSConfiguration()
+		groups        = []*autoscaling.Group{state.GetScalingGroup()}
+	)
+
+	if configuration.IsZoneSharded() {
+		zoneScalingGroups := state.GetZoneScalingGroups()
+		zones := make([]string, 0, len(zoneScalingGroups))
+		for zone := range zoneScalingGroups {
+			zones = append(zones, zone)
+		}
+		sort.Strings(zones)
+
+		groups = make([]*autoscaling.Group, 0, len(zones))
+		for _, zone := range zones {
+			groups = append(groups, zoneScalingGroups[zone])
+		}
+	}
+
+	for _, group := range groups {
+		asgName := aws.StringValue(group.AutoScalingGroupName)
+		if aws.StringValue(group.Status) == ScalingGroupDeletionStatus {
+			return fmt.Sprintf("scaling group %v is being deleted", asgName), nil
+		}
+
+		refreshes, err := ctx.AwsWorker.DescribeActiveInstanceRefreshes(asgName)
+		if err != nil {
+			return "", err
+		}
+		if len(refreshes) > 0 {
+			refresh := refreshes[0]
+			return fmt.Sprintf("scaling group %v has instance refresh %v in status %v", asgName, aws.StringValue(refresh.InstanceRefreshId), aws.StringValue(refresh.Status)), nil
+		}
+	}
+	return "", nil
+}
+
 func (ctx *EksInstanceGroupContext) IsReady() bool {
 	instanceGroup := ctx.GetInstanceGroup()
 	return instanceGroup.GetState() == v1alpha1.ReconcileModified
 }
+
+// DiscoveredStateView is the view of the discovered state served by the state debug endpoint, it leaves out userdata,
+// tags, policy documents and certificates, which may contain sensitive values
+type DiscoveredStateView struct {
+	Provisioned          bool                      `json:"provisioned"`
+	NodesReady           bool                      `json:"nodesReady"`
+	TransientReason      string                    `json:"transientReason,omitempty"`
+	Cluster              *ClusterView              `json:"cluster,omitempty"`
+	VPCId                string                    `json:"vpcId,omitempty"`
+	ScalingGroup         *ScalingGroupView         `json:"scalingGroup,omitempty"`
+	ZoneScalingGroups    map[string]string         `json:"zoneScalingGroups,omitempty"`
+	OwnedScalingGroups   []string                  `json:"ownedScalingGroups,omitempty"`
+	LifecycleHooks       []string                  `json:"lifecycleHooks,omitempty"`
+	ScalingConfiguration *ScalingConfigurationView `json:"scalingConfiguration,omitempty"`
+	IAMRole              *IAMResourceView          `json:"iamRole,omitempty"`
+	InstanceProfile      *IAMResourceView          `json:"instanceProfile,omitempty"`
+	AttachedPolicies     []string                  `json:"attachedPolicies,omitempty"`
+	ClusterNodes         []string                  `json:"clusterNodes,omitempty"`
+	ExpiredInstances     []string                  `json:"expiredInstances,omitempty"`
+	DriftedSGInstances   []string                  `json:"driftedSecurityGroupInstances,omitempty"`
+	CordonedInstances    []string                  `json:"unhealthyCordonedInstances,omitempty"`
+	ProtectedInstances   []string                  `json:"protectedInstances,omitempty"`
+	FailedJoinInstances  []string                  `json:"failedJoinInstances,omitempty"`
+	NotReadyInstances    []string                  `json:"notReadyInstances,omitempty"`
+	UnhealthyProtected   []string                  `json:"unhealthyProtectedInstances,omitempty"`
+}
+
+// ClusterView is the EKS cluster of an instance group, without its certificate authority
+type ClusterView struct {
+	Name     string `json:"name,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// ScalingGroupView is the scaling group of an instance group, without its tags
+type ScalingGroupView struct {
+	Name                    string                     `json:"name"`
+	ARN                     string                     `json:"arn,omitempty"`
+	Status                  string                     `json:"status,omitempty"`
+	MinSize                 int64                      `json:"minSize"`
+	MaxSize                 int64                      `json:"maxSize"`
+	DesiredCapacity         int64                      `json:"desiredCapacity"`
+	LaunchConfigurationName string                     `json:"launchConfigurationName,omitempty"`
+	LaunchTemplateName      string                     `json:"launchTemplateName,omitempty"`
+	LaunchTemplateVersion   string                     `json:"launchTemplateVersion,omitempty"`
+	MixedInstancesPolicy    bool                       `json:"mixedInstancesPolicy,omitempty"`
+	Subnets                 []string                   `json:"subnets,omitempty"`
+	TerminationPolicies     []string                   `json:"terminationPolicies,omitempty"`
+	SuspendedProcesses      []string                   `json:"suspendedProcesses,omitempty"`
+	Instances               []ScalingGroupInstanceView `json:"instances,omitempty"`
+}
+
+// ScalingGroupInstanceView is an instance of a scaling group
+type ScalingGroupInstanceView struct {
+	InstanceId            string `json:"instanceId"`
+	InstanceType          string `json:"instanceType,omitempty"`
+	AvailabilityZone      string `json:"availabilityZone,omitempty"`
+	LifecycleState        string `json:"lifecycleState,omitempty"`
+	HealthStatus          string `json:"healthStatus,omitempty"`
+	LaunchConfiguration   string `json:"launchConfigurationName,omitempty"`
+	LaunchTemplateVersion string `json:"launchTemplateVersion,omitempty"`
+	ProtectedFromScaleIn  bool   `json:"protectedFromScaleIn,omitempty"`
+}
+
+// ScalingConfigurationView is the launch template or launch configuration of an instance group, without its userdata
+type ScalingConfigurationView struct {
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	LatestVersion int64  `json:"latestVersion,omitempty"`
+	ImageId       string `json:"imageId,omitempty"`
+	InstanceType  string `json:"instanceType,omitempty"`
+}
+
+// IAMResourceView is an IAM role or instance profile of an instance group, without its policy documents
+type IAMResourceView struct {
+	Name string `json:"name"`
+	ARN  string `json:"arn,omitempty"`
+}
+
+// View returns the sanitized view of the discovered state served by the state debug endpoint
+func (d *DiscoveredState) View() *DiscoveredStateView {
+	view := &DiscoveredStateView{
+		Provisioned:         d.IsProvisioned(),
+		NodesReady:          d.IsNodesReady(),
+		TransientReason:     d.GetTransientReason(),
+		VPCId:               d.GetVPCId(),
+		ExpiredInstances:    d.GetExpiredInstances(),
+		DriftedSGInstances:  d.GetSecurityGroupDriftedInstances(),
+		CordonedInstances:   d.GetUnhealthyCordonedInstances(),
+		ProtectedInstances:  d.GetProtectedInstances(),
+		FailedJoinInstances: d.GetFailedJoinInstances(),
+		NotReadyInstances:   d.GetNotReadyInstances(),
+		UnhealthyProtected:  d.GetUnhealthyProtectedInstances(),
+	}
+
+	if cluster := d.GetCluster(); cluster != nil {
+		view.Cluster = &ClusterView{
+			Name:     aws.StringValue(cluster.Name),
+			Version:  aws.StringValue(cluster.Version),
+			Status:   aws.StringValue(cluster.Status),
+			Endpoint: aws.StringValue(cluster.Endpoint),
+		}
+	}
+
+	if group := d.GetScalingGroup(); group != nil {
+		view.ScalingGroup = scalingGroupView(group)
+	}
+	for zone, group := range d.GetZoneScalingGroups() {
+		if view.ZoneScalingGroups == nil {
+			view.ZoneScalingGroups = make(map[string]string)
+		}
+		view.ZoneScalingGroups[zone] = aws.StringValue(group.AutoScalingGroupName)
+	}
+	for _, group := range d.GetOwnedScalingGroups() {
+		view.OwnedScalingGroups = append(view.OwnedScalingGroups, aws.StringValue(group.AutoScalingGroupName))
+	}
+	for _, hook := range d.LifecycleHooks {
+		view.LifecycleHooks = append(view.LifecycleHooks, aws.StringValue(hook.LifecycleHookName))
+	}
+
+	switch config := d.GetScalingConfiguration().(type) {
+	case *scaling.LaunchTemplate:
+		if config != nil && config.TargetResource != nil {
+			view.ScalingConfiguration = &ScalingConfigurationView{
+				Type: string(v1alpha1.LaunchTemplate),
+				Name: aws.StringValue(config.TargetResource.LaunchTemplateName),
+			}
+			if latest := config.LatestVersion; latest != nil {
+				view.ScalingConfiguration.LatestVersion = aws.Int64Value(latest.VersionNumber)
+				if data := latest.LaunchTemplateData; data != nil {
+					view.ScalingConfiguration.ImageId = aws.StringValue(data.ImageId)
+					view.ScalingConfiguration.InstanceType = aws.StringValue(data.InstanceType)
+				}
+			}
+		}
+	case *scaling.LaunchConfiguration:
+		if config != nil && config.TargetResource != nil {
+			view.ScalingConfiguration = &ScalingConfigurationView{
+				Type:         string(v1alpha1.LaunchConfiguration),
+				Name:         aws.StringValue(config.TargetResource.LaunchConfigurationName),
+				ImageId:      aws.StringValue(config.TargetResource.ImageId),
+				InstanceType: aws.StringValue(config.TargetResource.InstanceType),
+			}
+		}
+	}
+
+	if role := d.GetRole(); role != nil {
+		view.IAMRole = &IAMResourceView{Name: aws.StringValue(role.RoleName), ARN: aws.StringValue(role.Arn)}
+	}
+	if profile := d.GetInstanceProfile(); profile != nil {
+		view.InstanceProfile = &IAMResourceView{Name: aws.StringValue(profile.InstanceProfileName), ARN: aws.StringValue(profile.Arn)}
+	}
+	for _, policy := range d.GetAttachedPolicies() {
+		view.AttachedPolicies = append(view.AttachedPolicies, aws.StringValue(policy.PolicyArn))
+	}
+	if nodes := d.GetClusterNodes(); nodes != nil {
+		for _, node := range nodes.Items {
+			view.ClusterNodes = append(view.ClusterNodes, node.GetName())
+		}
+	}
+	return view
+}
+
+func scalingGroupView(group *autoscaling.Group) *ScalingGroupView {
+	view := &ScalingGroupView{
+		Name:                    aws.StringValue(group.AutoScalingGroupName),
+		ARN:                     aws.StringValue(group.AutoScalingGroupARN),
+		Status:                  aws.StringValue(group.Status),
+		MinSize:                 aws.Int64Value(group.MinSize),
+		MaxSize:                 aws.Int64Value(group.MaxSize),
+		DesiredCapacity:         aws.Int64Value(group.DesiredCapacity),
+		LaunchConfigurationName: aws.StringValue(group.LaunchConfigurationName),
+		TerminationPolicies:     aws.StringValueSlice(group.TerminationPolicies),
+	}
+
+	template := group.LaunchTemplate
+	if policy := group.MixedInstancesPolicy; policy != nil && policy.LaunchTemplate != nil {
+		view.MixedInstancesPolicy = true
+		template = policy.LaunchTemplate.LaunchTemplateSpecification
+	}
+	if template != nil {
+		view.LaunchTemplateName = aws.StringValue(template.LaunchTemplateName)
+		view.LaunchTemplateVersion = aws.StringValue(template.Version)
+	}
+
+	if subnets := aws.StringValue(group.VPCZoneIdentifier); subnets != "" {
+		view.Subnets = strings.Split(subnets, ",")
+	}
+	for _, process := range group.SuspendedProcesses {
+		view.SuspendedProcesses = append(view.SuspendedProcesses, aws.StringValue(process.ProcessName))
+	}
+	for _, instance := range group.Instances {
+		instanceView := ScalingGroupInstanceView{
+			InstanceId:           aws.StringValue(instance.InstanceId),
+			InstanceType:         aws.StringValue(instance.InstanceType),
+			AvailabilityZone:     aws.StringValue(instance.AvailabilityZone),
+			LifecycleState:       aws.StringValue(instance.LifecycleState),
+			HealthStatus:         aws.StringValue(instance.HealthStatus),
+			LaunchConfiguration:  aws.StringValue(instance.LaunchConfigurationName),
+			ProtectedFromScaleIn: aws.BoolValue(instance.ProtectedFromScaleIn),
+		}
+		if instance.LaunchTemplate != nil {
+			instanceView.LaunchTemplateVersion = aws.StringValue(instance.LaunchTemplate.Version)
+		}
+		view.Instances = append(view.Instances, instanceView)
+	}
+	return view
+}
+
+// ExportDiscoveredState exports the view of the discovered state for the state debug endpoint, if it is enabled
+func (ctx *EksInstanceGroupContext) ExportDiscoveredState() {
+	if ctx.StateExporter == nil {
+		return
+	}
+	instanceGroup := ctx.GetInstanceGroup()
+	if err := ctx.StateExporter.Export(instanceGroup.GetNamespace(), instanceGroup.GetName(), ctx.GetDiscoveredState().View()); err != nil {
+		ctx.Log.Error(err, "failed to export discovered state", "instancegroup", instanceGroup.NamespacedName())
+	}
+}
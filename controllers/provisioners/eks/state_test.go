@@ -16,15 +16,23 @@ limitations under the License.
 package eks
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
+	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
 )
 
 func TestStateDiscovery(t *testing.T) {
@@ -79,6 +87,200 @@ func TestStateDiscovery(t *testing.T) {
 	}
 }
 
+func TestDiscoveredStateView(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	cluster := MockEksCluster("1.29")
+	cluster.Name = aws.String("my-cluster")
+	cluster.Status = aws.String(eks.ClusterStatusActive)
+	group := MockScalingGroup("my-scaling-group", true, &autoscaling.TagDescription{Key: aws.String("api-token"), Value: aws.String("secret-tag-value")})
+	group.LaunchTemplate.Version = aws.String("3")
+	group.DesiredCapacity = aws.Int64(3)
+	group.Instances[0].InstanceId = aws.String("i-1234")
+	group.Instances[0].LifecycleState = aws.String(autoscaling.LifecycleStateInService)
+	group.Instances[0].LaunchTemplate = &autoscaling.LaunchTemplateSpecification{Version: aws.String("2")}
+	group.SuspendedProcesses = []*autoscaling.SuspendedProcess{{ProcessName: aws.String("AZRebalance")}}
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Provisioned:        true,
+		NodesReady:         true,
+		Cluster:            cluster,
+		VPCId:              "vpc-1234",
+		ScalingGroup:       group,
+		OwnedScalingGroups: []*autoscaling.Group{group},
+		LifecycleHooks:     []*autoscaling.LifecycleHook{{LifecycleHookName: aws.String("my-hook")}},
+		ScalingConfiguration: &scaling.LaunchTemplate{
+			TargetResource: &ec2.LaunchTemplate{LaunchTemplateName: aws.String("my-launch-template")},
+			LatestVersion: &ec2.LaunchTemplateVersion{
+				VersionNumber: aws.Int64(3),
+				LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+					ImageId:      aws.String("ami-1234"),
+					InstanceType: aws.String("m5.xlarge"),
+					UserData:     aws.String("c2VjcmV0LXVzZXJkYXRh"),
+				},
+			},
+		},
+		IAMRole: &iam.Role{
+			RoleName:                 aws.String("my-role"),
+			Arn:                      aws.String("arn:aws:iam::123456789012:role/my-role"),
+			AssumeRolePolicyDocument: aws.String("secret-policy-document"),
+		},
+		InstanceProfile:  &iam.InstanceProfile{InstanceProfileName: aws.String("my-profile"), Arn: aws.String("arn:aws:iam::123456789012:instance-profile/my-profile")},
+		AttachedPolicies: []*iam.AttachedPolicy{{PolicyArn: aws.String("arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy")}},
+		ClusterNodes:     &corev1.NodeList{Items: []corev1.Node{*MockNode("i-1234", corev1.ConditionTrue)}},
+		ExpiredInstances: []string{"i-1234"},
+		ClusterCA:        "secret-certificate",
+	})
+
+	expected := &DiscoveredStateView{
+		Provisioned: true,
+		NodesReady:  true,
+		Cluster:     &ClusterView{Name: "my-cluster", Version: "1.29", Status: eks.ClusterStatusActive, Endpoint: "foo.amazonaws.com"},
+		VPCId:       "vpc-1234",
+		ScalingGroup: &ScalingGroupView{
+			Name:                  "my-scaling-group",
+			MinSize:               3,
+			MaxSize:               6,
+			DesiredCapacity:       3,
+			LaunchTemplateName:    "some-launch-template",
+			LaunchTemplateVersion: "3",
+			Subnets:               []string{"subnet-1", "subnet-2", "subnet-3"},
+			SuspendedProcesses:    []string{"AZRebalance"},
+			Instances: []ScalingGroupInstanceView{
+				{InstanceId: "i-1234", InstanceType: "m5.xlarge", LifecycleState: autoscaling.LifecycleStateInService, LaunchTemplateVersion: "2"},
+			},
+		},
+		OwnedScalingGroups:   []string{"my-scaling-group"},
+		LifecycleHooks:       []string{"my-hook"},
+		ScalingConfiguration: &ScalingConfigurationView{Type: "LaunchTemplate", Name: "my-launch-template", LatestVersion: 3, ImageId: "ami-1234", InstanceType: "m5.xlarge"},
+		IAMRole:              &IAMResourceView{Name: "my-role", ARN: "arn:aws:iam::123456789012:role/my-role"},
+		InstanceProfile:      &IAMResourceView{Name: "my-profile", ARN: "arn:aws:iam::123456789012:instance-profile/my-profile"},
+		AttachedPolicies:     []string{"arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy"},
+		ClusterNodes:         []string{"node-i-1234"},
+		ExpiredInstances:     []string{"i-1234"},
+	}
+
+	serialized, err := json.Marshal(ctx.GetDiscoveredState().View())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	view := &DiscoveredStateView{}
+	g.Expect(json.Unmarshal(serialized, view)).To(gomega.Succeed())
+	g.Expect(view).To(gomega.Equal(expected))
+
+	// sensitive fields are not serialized
+	for _, sensitive := range []string{"secret-tag-value", "c2VjcmV0LXVzZXJkYXRh", "secret-policy-document", "secret-certificate", "dGVzdA=="} {
+		g.Expect(string(serialized)).NotTo(gomega.ContainSubstring(sensitive))
+	}
+
+	// the view is exported on every reconcile when the state debug endpoint is enabled
+	ctx.ExportDiscoveredState()
+	ctx.StateExporter = provisioners.NewStateExporter(true)
+	ig.SetState(v1alpha1.ReconcileInit)
+	ctx.StateDiscovery()
+	exported, ok := ctx.StateExporter.Get(ig.GetNamespace(), ig.GetName())
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(string(exported.State)).To(gomega.MatchJSON(serialized))
+
+	ctx.GetDiscoveredState().SetTransientReason("instance refresh in progress")
+	ig.SetState(v1alpha1.ReconcileModified)
+	ctx.StateDiscovery()
+	exported, _ = ctx.StateExporter.Get(ig.GetNamespace(), ig.GetName())
+	g.Expect(string(exported.State)).To(gomega.ContainSubstring(`"transientReason":"instance refresh in progress"`))
+}
+
+func TestStateDiscoveryTransientScalingGroup(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = &eks.Cluster{
+		ResourcesVpcConfig: &eks.VpcConfigResponse{
+			VpcId: aws.String("vpc-1234567890"),
+		},
+	}
+
+	var (
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, configuration.GetClusterName())
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+	)
+
+	tests := []struct {
+		scalingGroupStatus string
+		refreshStatus      string
+		expectedState      v1alpha1.ReconcileState
+	}{
+		{expectedState: v1alpha1.ReconcileInitUpdate},
+		{refreshStatus: autoscaling.InstanceRefreshStatusSuccessful, expectedState: v1alpha1.ReconcileInitUpdate},
+		{refreshStatus: autoscaling.InstanceRefreshStatusFailed, expectedState: v1alpha1.ReconcileInitUpdate},
+		{refreshStatus: autoscaling.InstanceRefreshStatusPending, expectedState: v1alpha1.ReconcileModifying},
+		{refreshStatus: autoscaling.InstanceRefreshStatusInProgress, expectedState: v1alpha1.ReconcileModifying},
+		{refreshStatus: autoscaling.InstanceRefreshStatusCancelling, expectedState: v1alpha1.ReconcileModifying},
+		{refreshStatus: autoscaling.InstanceRefreshStatusRollbackInProgress, expectedState: v1alpha1.ReconcileModifying},
+		{scalingGroupStatus: ScalingGroupDeletionStatus, expectedState: v1alpha1.ReconcileModifying},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v -> %v", i, tc.expectedState)
+
+		ig.SetState(v1alpha1.ReconcileInit)
+		ctx.SetDiscoveredState(&DiscoveredState{})
+
+		scalingGroup := MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+		if tc.scalingGroupStatus != "" {
+			scalingGroup.Status = aws.String(tc.scalingGroupStatus)
+		}
+		asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+
+		asgMock.InstanceRefreshes = []*autoscaling.InstanceRefresh{}
+		if tc.refreshStatus != "" {
+			asgMock.InstanceRefreshes = append(asgMock.InstanceRefreshes, &autoscaling.InstanceRefresh{
+				AutoScalingGroupName: aws.String("scaling-group-1"),
+				InstanceRefreshId:    aws.String("some-refresh-id"),
+				Status:               aws.String(tc.refreshStatus),
+			})
+		}
+
+		err := ctx.CloudDiscovery()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx.StateDiscovery()
+		g.Expect(ctx.GetState()).To(gomega.Equal(tc.expectedState))
+		g.Expect(provisioners.IsRetryable(ig)).To(gomega.BeTrue())
+	}
+
+	asgMock.AutoScalingGroups = []*autoscaling.Group{MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)}
+	asgMock.DescribeInstanceRefreshesErr = errors.New("some-error")
+	err := ctx.CloudDiscovery()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
 func TestIsReady(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
@@ -116,3 +318,31 @@ func TestIsReady(t *testing.T) {
 	}
 
 }
+
+func TestStateMetricsSink(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+		sink    = &MockMetricsSink{}
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ctx.Metrics.RegisterSink(sink)
+
+	ctx.SetState(v1alpha1.ReconcileInitUpgrade)
+	ctx.SetState(v1alpha1.ReconcileModifying)
+	ctx.SetState(v1alpha1.ReconcileErr)
+	ctx.Metrics.IncFail(ig.NamespacedName(), "ReconcileFailed")
+
+	// only states which are exported are emitted, durations are emitted for every state left during the reconcile
+	g.Expect(sink.States).To(gomega.Equal([]string{"InitUpgrade", "ReconcileModifying", "Error"}))
+	g.Expect(sink.Durations).To(gomega.Equal([]string{"Init", "InitUpgrade", "ReconcileModifying"}))
+	g.Expect(sink.Failures).To(gomega.Equal([]string{"ReconcileFailed"}))
+}
@@ -18,19 +18,27 @@ package eks
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
 	"github.com/keikoproj/instance-manager/controllers/common"
 	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
 	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func (ctx *EksInstanceGroupContext) Update() error {
@@ -46,13 +54,13 @@ func (ctx *EksInstanceGroupContext) Update() error {
 		args            = ctx.GetBootstrapArgs()
 		kubeletArgs     = ctx.GetKubeletExtraArgs()
 		userDataPayload = ctx.GetUserDataStages()
-		clusterName     = configuration.GetClusterName()
+		clusterName     = ctx.GetBootstrapClusterName()
 		mounts          = ctx.GetMountOpts()
 		userData        = ctx.GetBasicUserData(clusterName, args, kubeletArgs, userDataPayload, mounts)
 		sgs             = ctx.ResolveSecurityGroups()
 		spotPrice       = configuration.GetSpotPrice()
 		placement       = configuration.GetPlacement()
-		metadataOptions = configuration.GetMetadataOptions()
+		metadataOptions = ctx.GetMetadataOptions()
 	)
 
 	ctx.SetState(v1alpha1.ReconcileModifying)
@@ -64,40 +72,152 @@ func (ctx *EksInstanceGroupContext) Update() error {
 	}
 	instanceProfile := state.GetInstanceProfile()
 
+	networkInterfaces, err := ctx.ResolveNetworkInterfaces()
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve network interfaces")
+	}
+	if err := ctx.ValidateNetworkInterfaces(networkInterfaces); err != nil {
+		return errors.Wrap(err, "invalid network interfaces")
+	}
+	ctx.WarnPublicIpPrivateSubnets()
+	ctx.WarnReservedTags()
+
+	if err := ctx.SyncRegistryCredentials(); err != nil {
+		return errors.Wrap(err, "failed to sync registry credentials")
+	}
+
+	if err := ctx.ValidateWindowsContainerd(); err != nil {
+		return errors.Wrap(err, "invalid windows containerd configuration")
+	}
+
+	if err := ctx.ValidatePrePullImages(); err != nil {
+		return errors.Wrap(err, "invalid pre-pulled images")
+	}
+
+	if err := ctx.ValidateInstanceStorage(); err != nil {
+		return errors.Wrap(err, "invalid instance storage")
+	}
+
+	if err := ctx.ValidateBootstrapRetries(); err != nil {
+		return errors.Wrap(err, "invalid bootstrap retries")
+	}
+
+	if err := ctx.ValidateImagePulls(); err != nil {
+		return errors.Wrap(err, "invalid image pull settings")
+	}
+
+	if err := ctx.ValidateShutdownGracePeriod(); err != nil {
+		return errors.Wrap(err, "invalid shutdown grace period")
+	}
+
+	if err := ctx.ValidateKubeletConfiguration(); err != nil {
+		return errors.Wrap(err, "invalid kubelet configuration")
+	}
+
+	if err := ctx.ValidateVersionSkew(); err != nil {
+		return errors.Wrap(err, "invalid node version")
+	}
+
+	if err := ctx.ValidateAuthMappings(); err != nil {
+		return errors.Wrap(err, "invalid auth mappings")
+	}
+
+	if err := ctx.ValidateImageAllowlist(); err != nil {
+		return errors.Wrap(err, "invalid image")
+	}
+
+	if err := ctx.ValidateAutoscaler(); err != nil {
+		return errors.Wrap(err, "invalid autoscaler")
+	}
+
+	if err := ctx.ValidateSysctls(); err != nil {
+		return errors.Wrap(err, "invalid sysctls")
+	}
+
+	if err := ctx.ValidateBootstrapFlags(); err != nil {
+		return errors.Wrap(err, "invalid bootstrap flags")
+	}
+
+	if err := ctx.ValidateMaxPodsFormula(); err != nil {
+		return errors.Wrap(err, "invalid max pods formula")
+	}
+
+	if err := ctx.ValidatePrefixAssignment(); err != nil {
+		return errors.Wrap(err, "invalid prefix assignment configuration")
+	}
+	status.SetPrefixDensity(ctx.GetPrefixDensityStatus())
+
 	config := &scaling.CreateConfigurationInput{
-		Name:                  scalingConfig.Name(),
-		IamInstanceProfileArn: aws.StringValue(instanceProfile.Arn),
-		ImageId:               configuration.Image,
-		InstanceType:          configuration.InstanceType,
-		KeyName:               configuration.KeyPairName,
-		SecurityGroups:        sgs,
-		Volumes:               configuration.Volumes,
-		UserData:              userData,
-		SpotPrice:             spotPrice,
-		LicenseSpecifications: configuration.LicenseSpecifications,
-		Placement:             placement,
-		MetadataOptions:       metadataOptions,
-	}
-
-	// create new launchconfig if it has drifted
-	if scalingConfig.Drifted(config) {
+		Name:                     scalingConfig.Name(),
+		IamInstanceProfileArn:    aws.StringValue(instanceProfile.Arn),
+		ImageId:                  configuration.Image,
+		InstanceType:             configuration.InstanceType,
+		KeyName:                  configuration.KeyPairName,
+		SecurityGroups:           sgs,
+		Volumes:                  ctx.GetVolumes(),
+		UserData:                 userData,
+		SpotPrice:                spotPrice,
+		LicenseSpecifications:    configuration.LicenseSpecifications,
+		Placement:                placement,
+		MetadataOptions:          metadataOptions,
+		NetworkInterfaces:        networkInterfaces,
+		AssociatePublicIpAddress: configuration.GetAssociatePublicIpAddress(),
+	}
+
+	ctx.ExportUserData(userData)
+
+	if err := ctx.ValidateLaunchTemplateVersionPin(); err != nil {
+		return errors.Wrap(err, "invalid launch template version pin")
+	}
+	pinnedVersion, pinned := ctx.GetPinnedLaunchTemplateVersion()
+
+	// create new launchconfig if it has drifted, a pinned launch template version overrides the desired configuration
+	if pinned {
+		ctx.Log.Info("launch template version is pinned, skipping drift detection", "instancegroup", instanceGroup.NamespacedName(), "version", pinnedVersion)
+	} else if scalingConfig.Drifted(config) {
 		if spec.IsLaunchConfiguration() || common.StringEmpty(config.Name) {
 			config.Name = fmt.Sprintf("%v-%v", ctx.ResourcePrefix, common.GetTimeString())
 		}
+		if err := ctx.ValidateUserData(userData); err != nil {
+			return errors.Wrap(err, "failed to validate userdata")
+		}
 		rotationNeeded = true
 		if err := scalingConfig.Create(config); err != nil {
+			if ctx.IsInstanceProfilePropagating(err) {
+				ctx.Log.Info("instance profile has not propagated yet, will requeue", "instancegroup", instanceGroup.NamespacedName(), "instanceprofile", aws.StringValue(instanceProfile.Arn))
+				ctx.SetState(v1alpha1.ReconcileModifying)
+				return nil
+			}
 			return errors.Wrap(err, "failed to create scaling configuration")
 		}
+		ctx.UpdateScalingConfigurationStatus()
 
 	}
 
 	if scalingConfig.RotationNeeded(&scaling.DiscoverConfigurationInput{
-		ScalingGroup: state.ScalingGroup,
+		ScalingGroup:          state.ScalingGroup,
+		PinnedVersion:         pinnedVersion,
+		IgnoreCosmeticChanges: configuration.IsCosmeticChangeRotationSkipped(),
 	}) {
 		ctx.Log.Info("node rotation required", "instancegroup", instanceGroup.NamespacedName(), "scalingconfig", config.Name)
 		rotationNeeded = true
 	}
 
+	if expired := state.GetExpiredInstances(); len(expired) > 0 {
+		ctx.Log.Info("node rotation required, instances exceeded node ttl", "instancegroup", instanceGroup.NamespacedName(), "instances", expired)
+		rotationNeeded = true
+	}
+
+	if drifted := state.GetSecurityGroupDriftedInstances(); len(drifted) > 0 {
+		ctx.Log.Info("node rotation required, instance security groups have drifted", "instancegroup", instanceGroup.NamespacedName(), "instances", drifted)
+		rotationNeeded = true
+	}
+
+	if cordoned := state.GetUnhealthyCordonedInstances(); len(cordoned) > 0 {
+		ctx.Log.Info("node rotation required, nodes were cordoned for unhealthy conditions", "instancegroup", instanceGroup.NamespacedName(), "instances", cordoned)
+		rotationNeeded = true
+	}
+
 	if kubeprovider.IsResourceActive(ctx.KubernetesClient.KubeDynamic, instanceGroup) {
 		ctx.Log.Info("upgrade resource is still active", "instancegroup", instanceGroup.NamespacedName(), "scalingconfig", config.Name)
 		rotationNeeded = true
@@ -114,9 +234,13 @@ func (ctx *EksInstanceGroupContext) Update() error {
 	updated, err := ctx.UpdateScalingGroup(config.Name, &scalingConfig)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
-			if aerr.Code() == autoscaling.ErrCodeScalingActivityInProgressFault {
+			switch aerr.Code() {
+			case autoscaling.ErrCodeScalingActivityInProgressFault:
 				ctx.Log.Info("cannot update scaling group due to autoscaling activity in progress", "instancegroup", instanceGroup.NamespacedName())
 				return nil
+			case autoscaling.ErrCodeResourceInUseFault:
+				ctx.Log.Info("cannot update scaling group due to another operation in progress", "instancegroup", instanceGroup.NamespacedName())
+				return nil
 			}
 		}
 		return errors.Wrap(err, "failed to update scaling group")
@@ -127,17 +251,68 @@ func (ctx *EksInstanceGroupContext) Update() error {
 		return nil
 	}
 
+	// source/dest check cannot be set in a launch template and is disabled, or enabled again, on running instances
+	if err = ctx.UpdateSourceDestCheck(); err != nil {
+		if !ctx.InInitialGracePeriod() {
+			return errors.Wrap(err, "failed to update source/dest check")
+		}
+		// instances of a newly created scaling group may not be modifiable while they launch
+		ctx.Log.Info("failed to update source/dest check of launching instances, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
+	}
+
+	// with custom networking the VPC CNI allocates pod IPs from the ENIConfig the node is annotated with
+	if err = ctx.UpdateENIConfigAnnotations(); err != nil {
+		ctx.Log.Info("failed to annotate nodes with their eniConfig, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
+	}
+
+	if err = ctx.UpdateLifecycleLabels(); err != nil {
+		ctx.Log.Info("failed to label nodes with their lifecycle, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
+	}
+
+	if err = ctx.UpdateImageLabels(); err != nil {
+		ctx.Log.Info("failed to label nodes with their image, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
+	}
+
+	if err = ctx.UpdateInstanceTagLabels(); err != nil {
+		ctx.Log.Info("failed to label nodes with their instance tags, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
+	}
+
+	if err = ctx.CordonUnhealthyNodes(); err != nil {
+		ctx.Log.Info("failed to cordon unhealthy nodes, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
+	}
+
+	if err = ctx.UpdateClusterAutoscalerPriority(); err != nil {
+		ctx.Log.Info("failed to update cluster-autoscaler priority, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
+	}
+
 	// we should try to bootstrap the role before we wait for nodes to be ready
 	// to avoid getting locked if someone made a manual change to aws-auth
 	if err = ctx.BootstrapNodes(); err != nil {
 		ctx.Log.Info("failed to bootstrap role, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
 	}
 
+	if err = ctx.UpdateAuthMappings(); err != nil {
+		ctx.Log.Info("failed to update additional aws-auth mappings, will retry", "error", err, "instancegroup", instanceGroup.NamespacedName())
+	}
+
 	// update readiness conditions
 	nodesReady := ctx.UpdateNodeReadyCondition()
+	ctx.UpdateReadinessStatus(nodesReady, time.Now())
 	if nodesReady {
 		ctx.SetState(v1alpha1.ReconcileModified)
 	}
+
+	if err = ctx.ReplaceFailedJoinInstances(); err != nil {
+		return errors.Wrap(err, "failed to replace instances which have not joined the cluster")
+	}
+
+	if err = ctx.ReplaceNotReadyInstances(); err != nil {
+		return errors.Wrap(err, "failed to replace instances whose nodes are not ready")
+	}
+
+	if err = ctx.ClearUnhealthyInstanceProtection(); err != nil {
+		return errors.Wrap(err, "failed to clear scale in protection of unhealthy instances")
+	}
 	if rotationNeeded {
 		ctx.SetState(v1alpha1.ReconcileInitUpgrade)
 	} else {
@@ -148,6 +323,149 @@ func (ctx *EksInstanceGroupContext) Update() error {
 }
 
 func (ctx *EksInstanceGroupContext) UpdateScalingGroup(configName string, scalingConfig *scaling.Configuration) (bool, error) {
+	var (
+		asgUpdated    bool
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		desiredZones  = make([]string, 0)
+	)
+
+	shards, err := ctx.GetScalingGroupShards()
+	if err != nil {
+		return asgUpdated, err
+	}
+
+	if !configuration.IsZoneSharded() {
+		return ctx.updateScalingGroup(configName, scalingConfig, shards[0])
+	}
+
+	if len(shards) == 0 {
+		return asgUpdated, errors.New("failed to resolve availability zones for zone sharding")
+	}
+
+	zoneScalingGroups := state.GetZoneScalingGroups()
+	for _, shard := range shards {
+		desiredZones = append(desiredZones, shard.Zone)
+
+		group, ok := zoneScalingGroups[shard.Zone]
+		if !ok {
+			// subnets in a new zone were added
+			if err := ctx.withScalingGroup(nil, func() error {
+				return ctx.createScalingGroup(configName, shard)
+			}); err != nil {
+				return asgUpdated, err
+			}
+			asgUpdated = true
+			continue
+		}
+
+		var updated bool
+		if err := ctx.withScalingGroup(group, func() error {
+			var err error
+			updated, err = ctx.updateScalingGroup(configName, scalingConfig, shard)
+			return err
+		}); err != nil {
+			return asgUpdated, err
+		}
+		if updated {
+			asgUpdated = true
+		}
+	}
+
+	// retire scaling groups of zones which no longer have subnets
+	for zone, group := range zoneScalingGroups {
+		if common.ContainsString(desiredZones, zone) {
+			continue
+		}
+		if err := ctx.retireZoneScalingGroup(zone, group); err != nil {
+			return asgUpdated, err
+		}
+		asgUpdated = true
+	}
+
+	return asgUpdated, nil
+}
+
+// retireZoneScalingGroup scales in the scaling group of a zone which no longer has subnets, its nodes are drained and
+// at most maxUnavailable instances of the rolling update strategy, or one, are removed at a time. Capacity is pinned to
+// the remaining instances so they are not replaced, and the scaling group is deleted once it is empty
+func (ctx *EksInstanceGroupContext) retireZoneScalingGroup(zone string, group *autoscaling.Group) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		state         = ctx.GetDiscoveredState()
+		asgName       = aws.StringValue(group.AutoScalingGroupName)
+		count         = int64(len(group.Instances))
+	)
+
+	if count == 0 {
+		if err := ctx.AwsWorker.DeleteScalingGroup(asgName); err != nil {
+			return err
+		}
+		ctx.Log.Info("deleted zone scaling group", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName, "zone", zone)
+		state.Publisher.Publish(kubeprovider.InstanceGroupDeletedEvent, "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName)
+		return nil
+	}
+
+	if aws.Int64Value(group.MinSize) > 0 || aws.Int64Value(group.MaxSize) > count || aws.Int64Value(group.DesiredCapacity) > count {
+		if err := ctx.AwsWorker.UpdateScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(asgName),
+			MinSize:              aws.Int64(0),
+			MaxSize:              aws.Int64(count),
+			DesiredCapacity:      aws.Int64(count),
+		}); err != nil {
+			return errors.Wrapf(err, "failed to scale in zone scaling group %v", asgName)
+		}
+	}
+
+	var (
+		unavailable int
+		inService   = make([]string, 0)
+	)
+	for _, instance := range group.Instances {
+		if aws.StringValue(instance.LifecycleState) != autoscaling.LifecycleStateInService {
+			unavailable++
+			continue
+		}
+		inService = append(inService, aws.StringValue(instance.InstanceId))
+	}
+
+	maxUnavailable := ctx.maxUnavailableInstances(len(group.Instances))
+	if unavailable >= maxUnavailable || len(inService) == 0 {
+		ctx.Log.Info("waiting for instances of zone scaling group to terminate", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName, "zone", zone)
+		return nil
+	}
+	if targets := maxUnavailable - unavailable; len(inService) > targets {
+		inService = inService[:targets]
+	}
+
+	drained := ctx.drainInstances(inService, ctx.GetDrainOptions())
+	if len(drained) == 0 {
+		ctx.Log.Info("waiting for nodes of zone scaling group to drain", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName, "zone", zone)
+		return nil
+	}
+	if err := ctx.AwsWorker.RemoveScalingInstances(drained); err != nil {
+		return errors.Wrapf(err, "failed to remove instances of zone scaling group %v", asgName)
+	}
+	ctx.Log.Info("removed instances of zone scaling group", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName, "zone", zone, "instances", drained)
+	return nil
+}
+
+// maxUnavailableInstances returns the number of instances out of count which may be unavailable according to the
+// rolling update strategy, at least 1
+func (ctx *EksInstanceGroupContext) maxUnavailableInstances(count int) int {
+	strategy := ctx.GetInstanceGroup().GetUpgradeStrategy().GetRollingUpdateType()
+	if strategy == nil || strategy.GetMaxUnavailable() == nil {
+		return 1
+	}
+	maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(strategy.GetMaxUnavailable(), count, true)
+	if err != nil || maxUnavailable < 1 {
+		return 1
+	}
+	return maxUnavailable
+}
+
+func (ctx *EksInstanceGroupContext) updateScalingGroup(configName string, scalingConfig *scaling.Configuration, shard ScalingGroupShard) (bool, error) {
 	var (
 		asgUpdated    bool
 		instanceGroup = ctx.GetInstanceGroup()
@@ -162,10 +480,16 @@ func (ctx *EksInstanceGroupContext) UpdateScalingGroup(configName string, scalin
 	)
 
 	input := &autoscaling.UpdateAutoScalingGroupInput{
-		AutoScalingGroupName: aws.String(asgName),
-		MinSize:              aws.Int64(spec.GetMinSize()),
-		MaxSize:              aws.Int64(spec.GetMaxSize()),
-		VPCZoneIdentifier:    aws.String(common.ConcatenateList(ctx.ResolveSubnets(), ",")),
+		AutoScalingGroupName:             aws.String(asgName),
+		MinSize:                          aws.Int64(shard.MinSize),
+		MaxSize:                          aws.Int64(shard.MaxSize),
+		VPCZoneIdentifier:                aws.String(common.ConcatenateList(shard.Subnets, ",")),
+		TerminationPolicies:              aws.StringSlice(ctx.GetDesiredTerminationPolicies()),
+		CapacityRebalance:                aws.Bool(configuration.IsCapacityRebalanceEnabled()),
+		DefaultCooldown:                  aws.Int64(configuration.GetDefaultCooldown()),
+		NewInstancesProtectedFromScaleIn: aws.Bool(configuration.IsScaleInProtectionEnabled()),
+		InstanceMaintenancePolicy:        ctx.GetDesiredInstanceMaintenancePolicy(scalingGroup.InstanceMaintenancePolicy),
+		DesiredCapacity:                  ctx.GetDesiredCapacity(shard),
 	}
 
 	if spec.IsLaunchConfiguration() {
@@ -178,7 +502,7 @@ func (ctx *EksInstanceGroupContext) UpdateScalingGroup(configName string, scalin
 		} else {
 			input.LaunchTemplate = &autoscaling.LaunchTemplateSpecification{
 				LaunchTemplateName: aws.String(configName),
-				Version:            aws.String("$Latest"),
+				Version:            aws.String(ctx.GetDesiredLaunchTemplateVersion()),
 			}
 		}
 
@@ -197,7 +521,7 @@ func (ctx *EksInstanceGroupContext) UpdateScalingGroup(configName string, scalin
 
 	}
 
-	if ctx.ScalingGroupUpdateNeeded(configName) {
+	if ctx.scalingGroupUpdateNeeded(configName, shard) {
 		err := ctx.AwsWorker.UpdateScalingGroup(input)
 		if err != nil {
 			return asgUpdated, err
@@ -206,6 +530,12 @@ func (ctx *EksInstanceGroupContext) UpdateScalingGroup(configName string, scalin
 		ctx.Log.Info("updated scaling group", "instancegroup", instanceGroup.NamespacedName(), "scalinggroup", asgName)
 	}
 
+	desired := aws.Int64Value(scalingGroup.DesiredCapacity)
+	if input.DesiredCapacity != nil {
+		desired = aws.Int64Value(input.DesiredCapacity)
+	}
+	ctx.UpdateAutoscalerStatus(aws.Int64Value(scalingGroup.DesiredCapacity), desired)
+
 	status.SetCurrentMin(int(spec.GetMinSize()))
 	status.SetCurrentMax(int(spec.GetMaxSize()))
 
@@ -269,14 +599,19 @@ func (ctx *EksInstanceGroupContext) TagsUpdateNeeded() bool {
 }
 
 func (ctx *EksInstanceGroupContext) ScalingGroupUpdateNeeded(configName string) bool {
+	return ctx.scalingGroupUpdateNeeded(configName, ctx.scalingGroupShard())
+}
+
+func (ctx *EksInstanceGroupContext) scalingGroupUpdateNeeded(configName string, shard ScalingGroupShard) bool {
 	var (
 		instanceGroup  = ctx.GetInstanceGroup()
 		spec           = instanceGroup.GetEKSSpec()
+		configuration  = instanceGroup.GetEKSConfiguration()
 		state          = ctx.GetDiscoveredState()
 		scalingGroup   = state.GetScalingGroup()
 		zoneIdentifier = aws.StringValue(scalingGroup.VPCZoneIdentifier)
 		groupSubnets   = strings.Split(zoneIdentifier, ",")
-		specSubnets    = ctx.ResolveSubnets()
+		specSubnets    = shard.Subnets
 		desiredPolicy  = ctx.GetDesiredMixedInstancesPolicy(configName)
 	)
 
@@ -295,6 +630,9 @@ func (ctx *EksInstanceGroupContext) ScalingGroupUpdateNeeded(configName string)
 		if !spec.IsLaunchTemplate() {
 			return true
 		}
+		if aws.StringValue(scalingGroup.LaunchTemplate.Version) != ctx.GetDesiredLaunchTemplateVersion() {
+			return true
+		}
 		if desiredPolicy != nil {
 			return true
 		}
@@ -313,11 +651,11 @@ func (ctx *EksInstanceGroupContext) ScalingGroupUpdateNeeded(configName string)
 		return true
 	}
 
-	if spec.GetMinSize() != aws.Int64Value(scalingGroup.MinSize) {
+	if shard.MinSize != aws.Int64Value(scalingGroup.MinSize) {
 		return true
 	}
 
-	if spec.GetMaxSize() != aws.Int64Value(scalingGroup.MaxSize) {
+	if shard.MaxSize != aws.Int64Value(scalingGroup.MaxSize) {
 		return true
 	}
 
@@ -325,9 +663,564 @@ func (ctx *EksInstanceGroupContext) ScalingGroupUpdateNeeded(configName string)
 		return true
 	}
 
+	if ctx.GetDesiredCapacity(shard) != nil {
+		return true
+	}
+
+	// termination policies are evaluated in order
+	groupPolicies := aws.StringValueSlice(scalingGroup.TerminationPolicies)
+	if len(groupPolicies) == 0 {
+		groupPolicies = []string{v1alpha1.TerminationPolicyDefault}
+	}
+	if !reflect.DeepEqual(groupPolicies, ctx.GetDesiredTerminationPolicies()) {
+		return true
+	}
+
+	if aws.BoolValue(scalingGroup.CapacityRebalance) != configuration.IsCapacityRebalanceEnabled() {
+		return true
+	}
+
+	if aws.Int64Value(scalingGroup.DefaultCooldown) != configuration.GetDefaultCooldown() {
+		return true
+	}
+
+	if aws.BoolValue(scalingGroup.NewInstancesProtectedFromScaleIn) != configuration.IsScaleInProtectionEnabled() {
+		return true
+	}
+
+	if !InstanceMaintenancePolicyEqual(scalingGroup.InstanceMaintenancePolicy, ctx.GetDesiredInstanceMaintenancePolicy(scalingGroup.InstanceMaintenancePolicy)) {
+		return true
+	}
+
 	return false
 }
 
+// UpdateSourceDestCheck disables the source/dest check on the primary network interface of instances in the scaling
+// group, and enables it again once the setting is removed
+func (ctx *EksInstanceGroupContext) UpdateSourceDestCheck() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		status        = instanceGroup.GetStatus()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		disabled      = configuration.IsSourceDestCheckDisabled()
+		instanceIds   = make([]string, 0)
+	)
+
+	// instances are only described to enable the check if it was disabled by the controller
+	if !disabled && !status.GetSourceDestCheckDisabled() {
+		return nil
+	}
+	if disabled {
+		status.SetSourceDestCheckDisabled(true)
+	}
+
+	for _, instance := range scalingGroup.Instances {
+		instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+	}
+
+	instances, err := ctx.AwsWorker.DescribeInstances(instanceIds)
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		if instance.State != nil && !common.ContainsEqualFold([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning}, aws.StringValue(instance.State.Name)) {
+			continue
+		}
+		// a nil value is the default, which is enabled
+		enabled := instance.SourceDestCheck == nil || aws.BoolValue(instance.SourceDestCheck)
+		if enabled != disabled {
+			continue
+		}
+		instanceId := aws.StringValue(instance.InstanceId)
+		if disabled {
+			if err := ctx.AwsWorker.DisableSourceDestCheck(instanceId); err != nil {
+				return err
+			}
+			ctx.Log.Info("disabled source/dest check", "instancegroup", instanceGroup.NamespacedName(), "instance", instanceId)
+			continue
+		}
+		if err := ctx.AwsWorker.EnableSourceDestCheck(instanceId); err != nil {
+			return err
+		}
+		ctx.Log.Info("enabled source/dest check", "instancegroup", instanceGroup.NamespacedName(), "instance", instanceId)
+	}
+
+	if !disabled {
+		status.SetSourceDestCheckDisabled(false)
+	}
+	return nil
+}
+
+// UpdateENIConfigAnnotations annotates the nodes of the scaling group's instances with their ENIConfig when custom
+// networking is enabled. The ENIConfig is named after the node's availability zone, or is the value of the node label
+// configured as the CNI's ENIConfig label. Nodes which already have the annotation are left as they are, so that
+// ENIConfigs set by users are kept
+func (ctx *EksInstanceGroupContext) UpdateENIConfigAnnotations() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		annotations   = instanceGroup.GetAnnotations()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		nodes         = state.GetClusterNodes()
+		label         = annotations[CustomNetworkingENIConfigLabelAnnotation]
+		annotation    = annotations[CustomNetworkingENIConfigAnnotationAnnotation]
+	)
+
+	if annotations[CustomNetworkingEnabledAnnotation] != "true" || scalingGroup == nil || nodes == nil {
+		return nil
+	}
+	if common.StringEmpty(annotation) {
+		annotation = kubeprovider.ENIConfigAnnotation
+	}
+
+	zones := make(map[string]string)
+	for _, instance := range scalingGroup.Instances {
+		zones[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.AvailabilityZone)
+	}
+
+	for _, node := range nodes.Items {
+		eniConfig, ok := zones[common.GetLastElementBy(node.Spec.ProviderID, "/")]
+		if !ok {
+			continue
+		}
+		if !common.StringEmpty(label) {
+			eniConfig = node.GetLabels()[label]
+		}
+		if _, annotated := node.GetAnnotations()[annotation]; annotated || eniConfig == "" {
+			continue
+		}
+		if err := ctx.KubernetesClient.AnnotateNode(node.GetName(), map[string]string{annotation: eniConfig}); err != nil {
+			return err
+		}
+		ctx.Log.Info("annotated node with eniConfig", "instancegroup", instanceGroup.NamespacedName(), "node", node.GetName(), "eniconfig", eniConfig)
+	}
+	return nil
+}
+
+// CordonUnhealthyNodes cordons the nodes of the instance group which report a condition mapped to the cordon action
+// with a status of True, such as a condition of node-problem-detector. Cordoned nodes are annotated and replaced through
+// the upgrade strategy, and no more nodes are cordoned than the rolling update allows to be unavailable
+func (ctx *EksInstanceGroupContext) CordonUnhealthyNodes() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		nodes         = state.GetClusterNodes()
+		conditions    = configuration.GetUnhealthyNodeConditions()
+	)
+
+	if scalingGroup == nil || nodes == nil || len(conditions) == 0 {
+		return nil
+	}
+
+	instanceIds := make([]string, 0)
+	for _, instance := range scalingGroup.Instances {
+		instanceIds = append(instanceIds, aws.StringValue(instance.InstanceId))
+	}
+
+	var (
+		cordoned       = len(kubeprovider.GetUnhealthyCordonedInstances(instanceIds, nodes))
+		maxUnavailable = ctx.maxUnavailableInstances(len(instanceIds))
+	)
+
+	for _, node := range nodes.Items {
+		instanceId := common.GetLastElementBy(node.Spec.ProviderID, "/")
+		if !common.ContainsString(instanceIds, instanceId) || node.Spec.Unschedulable {
+			continue
+		}
+		for _, condition := range node.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue || conditions[string(condition.Type)] != v1alpha1.UnhealthyNodeActionCordon {
+				continue
+			}
+			if cordoned >= maxUnavailable {
+				ctx.Log.Info("skipping cordon of unhealthy node, max unavailable nodes are cordoned", "instancegroup", instanceGroup.NamespacedName(), "node", node.GetName(), "condition", condition.Type, "maxunavailable", maxUnavailable)
+				break
+			}
+			if err := ctx.KubernetesClient.CordonNode(node.GetName()); err != nil {
+				return err
+			}
+			if err := ctx.KubernetesClient.AnnotateNode(node.GetName(), map[string]string{kubeprovider.UnhealthyCordonedAnnotation: string(condition.Type)}); err != nil {
+				return err
+			}
+			cordoned++
+			ctx.Log.Info("cordoned unhealthy node", "instancegroup", instanceGroup.NamespacedName(), "node", node.GetName(), "condition", condition.Type)
+			state.Publisher.Publish(kubeprovider.NodeCordonedUnhealthyEvent, "instancegroup", instanceGroup.NamespacedName(), "node", node.GetName(), "instance", instanceId, "condition", string(condition.Type))
+			break
+		}
+	}
+	return nil
+}
+
+// UpdateLifecycleLabels labels the nodes of the scaling group's instances with the lifecycle of their instance, either
+// spot or normal for on-demand instances. Only instances of nodes without the correct label are described, which are
+// the nodes mixed instance groups launched since the last reconcile.
+func (ctx *EksInstanceGroupContext) UpdateLifecycleLabels() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		nodes         = state.GetClusterNodes()
+	)
+
+	if scalingGroup == nil || nodes == nil || ctx.IsDefaultLabelSuppressed(InstanceMgrLifecycleLabel) {
+		return nil
+	}
+
+	instances := make(map[string]bool)
+	for _, instance := range scalingGroup.Instances {
+		instances[aws.StringValue(instance.InstanceId)] = true
+	}
+
+	isUniform := status.GetLifecycle() == v1alpha1.LifecycleStateSpot || status.GetLifecycle() == v1alpha1.LifecycleStateNormal
+	if !isUniform && !ctx.IsInstanceLifecycleLabelsEnabled() {
+		return nil
+	}
+	isLabeled := func(lifecycle string) bool {
+		if isUniform {
+			return lifecycle == status.GetLifecycle()
+		}
+		// the lifecycle of an instance never changes, so labeled nodes are not described again
+		return lifecycle == v1alpha1.LifecycleStateSpot || lifecycle == v1alpha1.LifecycleStateNormal
+	}
+
+	unlabeled := make(map[string]string)
+	for _, node := range nodes.Items {
+		instanceId := common.GetLastElementBy(node.Spec.ProviderID, "/")
+		if !instances[instanceId] || isLabeled(node.GetLabels()[InstanceMgrLifecycleLabel]) {
+			continue
+		}
+		unlabeled[instanceId] = node.GetName()
+	}
+	if len(unlabeled) == 0 {
+		return nil
+	}
+
+	lifecycles := make(map[string]string)
+	if isUniform {
+		for instanceId := range unlabeled {
+			lifecycles[instanceId] = status.GetLifecycle()
+		}
+	} else {
+		instanceIds := make([]string, 0, len(unlabeled))
+		for instanceId := range unlabeled {
+			instanceIds = append(instanceIds, instanceId)
+		}
+		instanceLifecycles, err := ctx.AwsWorker.DescribeInstanceLifecycles(instanceIds)
+		if err != nil {
+			return errors.Wrap(err, "failed to describe instance lifecycles")
+		}
+		for instanceId, lifecycle := range instanceLifecycles {
+			if strings.EqualFold(lifecycle, ec2.InstanceLifecycleTypeSpot) {
+				lifecycles[instanceId] = v1alpha1.LifecycleStateSpot
+			} else {
+				lifecycles[instanceId] = v1alpha1.LifecycleStateNormal
+			}
+		}
+	}
+
+	for instanceId, nodeName := range unlabeled {
+		lifecycle, ok := lifecycles[instanceId]
+		if !ok {
+			continue
+		}
+		if err := ctx.KubernetesClient.LabelNode(nodeName, map[string]string{InstanceMgrLifecycleLabel: lifecycle}); err != nil {
+			return err
+		}
+		ctx.Log.Info("labeled node with lifecycle", "instancegroup", instanceGroup.NamespacedName(), "node", nodeName, "lifecycle", lifecycle)
+	}
+	return nil
+}
+
+// UpdateImageLabels labels the nodes of the scaling group's instances with the image their instance runs, so nodes which
+// are pending rotation are labeled with their previous image. Only instances of nodes labeled with another image than the
+// instance group's are described.
+func (ctx *EksInstanceGroupContext) UpdateImageLabels() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		nodes         = state.GetClusterNodes()
+	)
+
+	if scalingGroup == nil || nodes == nil || ctx.IsDefaultLabelSuppressed(InstanceMgrImageLabel) {
+		return nil
+	}
+
+	instances := make(map[string]bool)
+	for _, instance := range scalingGroup.Instances {
+		instances[aws.StringValue(instance.InstanceId)] = true
+	}
+
+	pending := make(map[string]corev1.Node)
+	instanceIds := make([]string, 0)
+	for _, node := range nodes.Items {
+		instanceId := common.GetLastElementBy(node.Spec.ProviderID, "/")
+		if !instances[instanceId] || node.GetLabels()[InstanceMgrImageLabel] == configuration.GetImage() {
+			continue
+		}
+		pending[instanceId] = node
+		instanceIds = append(instanceIds, instanceId)
+	}
+	if len(instanceIds) == 0 {
+		return nil
+	}
+
+	images, err := ctx.AwsWorker.DescribeInstanceImages(instanceIds)
+	if err != nil {
+		return errors.Wrap(err, "failed to describe instance images")
+	}
+
+	for _, instanceId := range instanceIds {
+		node := pending[instanceId]
+		image := images[instanceId]
+		if image == "" || node.GetLabels()[InstanceMgrImageLabel] == image {
+			continue
+		}
+		if err := ctx.KubernetesClient.LabelNode(node.GetName(), map[string]string{InstanceMgrImageLabel: image}); err != nil {
+			return err
+		}
+		ctx.Log.Info("labeled node with image", "instancegroup", instanceGroup.NamespacedName(), "node", node.GetName(), "image", image)
+	}
+	return nil
+}
+
+// UpdateInstanceTagLabels labels the nodes of the scaling group's instances with the values of the instance tags mapped
+// by instanceTagLabels. Only instances of nodes which are missing a mapped label are described, so nodes are labeled as
+// they register and later changes to the tags of their instance are not reflected.
+func (ctx *EksInstanceGroupContext) UpdateInstanceTagLabels() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		tagLabels     = configuration.GetInstanceTagLabels()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		nodes         = state.GetClusterNodes()
+	)
+
+	if len(tagLabels) == 0 || scalingGroup == nil || nodes == nil {
+		return nil
+	}
+
+	instances := make(map[string]bool)
+	for _, instance := range scalingGroup.Instances {
+		instances[aws.StringValue(instance.InstanceId)] = true
+	}
+
+	pending := make(map[string]corev1.Node)
+	instanceIds := make([]string, 0)
+	for _, node := range nodes.Items {
+		instanceId := common.GetLastElementBy(node.Spec.ProviderID, "/")
+		if !instances[instanceId] {
+			continue
+		}
+		for _, label := range tagLabels {
+			if _, ok := node.GetLabels()[label]; !ok {
+				pending[instanceId] = node
+				instanceIds = append(instanceIds, instanceId)
+				break
+			}
+		}
+	}
+	if len(instanceIds) == 0 {
+		return nil
+	}
+
+	instanceTags, err := ctx.AwsWorker.DescribeInstanceTags(instanceIds)
+	if err != nil {
+		return errors.Wrap(err, "failed to describe instance tags")
+	}
+
+	for _, instanceId := range instanceIds {
+		node := pending[instanceId]
+		labels := make(map[string]string)
+		for tag, label := range tagLabels {
+			value, ok := instanceTags[instanceId][tag]
+			if !ok {
+				continue
+			}
+			if current, ok := node.GetLabels()[label]; ok && current == value {
+				continue
+			}
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				ctx.Log.Info("skipping label of instance tag with an invalid label value", "instancegroup", instanceGroup.NamespacedName(), "node", node.GetName(), "tag", tag, "value", value)
+				continue
+			}
+			labels[label] = value
+		}
+		if len(labels) == 0 {
+			continue
+		}
+		if err := ctx.KubernetesClient.LabelNode(node.GetName(), labels); err != nil {
+			return err
+		}
+		ctx.Log.Info("labeled node with instance tags", "instancegroup", instanceGroup.NamespacedName(), "node", node.GetName(), "labels", labels)
+	}
+	return nil
+}
+
+// UpdateClusterAutoscalerPriority lists the instance group's scaling groups under the configured priority in the
+// cluster-autoscaler priority expander configmap, or removes them once a priority is no longer configured. Scaling
+// groups which were listed but no longer exist, such as those of removed zones, are removed
+func (ctx *EksInstanceGroupContext) UpdateClusterAutoscalerPriority() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
+		status        = instanceGroup.GetStatus()
+		state         = ctx.GetDiscoveredState()
+		priority      = configuration.GetClusterAutoscalerPriority()
+	)
+
+	if priority == nil {
+		return ctx.RemoveClusterAutoscalerPriority()
+	}
+
+	names := make([]string, 0)
+	for _, group := range state.GetOwnedScalingGroups() {
+		names = append(names, aws.StringValue(group.AutoScalingGroupName))
+	}
+	stale := make([]string, 0)
+	for _, name := range status.GetClusterAutoscalerPriorityScalingGroups() {
+		if !common.ContainsString(names, name) {
+			stale = append(stale, name)
+		}
+	}
+	if err := common.RemovePriorityExpander(ctx.KubernetesClient.Kubernetes, stale); err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		ctx.Log.Info("removed cluster-autoscaler priority of deleted scaling groups", "instancegroup", instanceGroup.NamespacedName(), "scalinggroups", stale)
+	}
+	status.SetClusterAutoscalerPriorityScalingGroups(names)
+	if len(names) == 0 {
+		return nil
+	}
+
+	if err := common.UpsertPriorityExpander(ctx.KubernetesClient.Kubernetes, names, *priority); err != nil {
+		return err
+	}
+	if current := status.GetClusterAutoscalerPriority(); current == nil || *current != *priority {
+		ctx.Log.Info("updated cluster-autoscaler priority", "instancegroup", instanceGroup.NamespacedName(), "priority", *priority)
+	}
+	status.SetClusterAutoscalerPriority(aws.Int64(*priority))
+	return nil
+}
+
+// UpdateManagedRoleTags reconciles the custom tags of the instance group onto the managed role and instance profile,
+// tags which were not applied by the controller are never removed
+func (ctx *EksInstanceGroupContext) UpdateManagedRoleTags(name string, role *iam.Role, profile *iam.InstanceProfile) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		applied       = status.GetManagedRoleTags()
+		desired       = ctx.GetIAMTags()
+	)
+
+	if role != nil {
+		add, remove := iamTagsDiff(role.Tags, desired, applied)
+		if err := ctx.AwsWorker.TagRole(name, add); err != nil {
+			return err
+		}
+		if err := ctx.AwsWorker.UntagRole(name, remove); err != nil {
+			return err
+		}
+		if len(add) > 0 || len(remove) > 0 {
+			ctx.Log.Info("updated managed role tags", "instancegroup", instanceGroup.NamespacedName(), "iamrole", name, "added", add, "removed", remove)
+		}
+	}
+
+	if profile != nil {
+		add, remove := iamTagsDiff(profile.Tags, desired, applied)
+		if err := ctx.AwsWorker.TagInstanceProfile(name, add); err != nil {
+			return err
+		}
+		if err := ctx.AwsWorker.UntagInstanceProfile(name, remove); err != nil {
+			return err
+		}
+		if len(add) > 0 || len(remove) > 0 {
+			ctx.Log.Info("updated managed instance profile tags", "instancegroup", instanceGroup.NamespacedName(), "instanceprofile", name, "added", add, "removed", remove)
+		}
+	}
+
+	keys := make([]string, 0)
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		keys = nil
+	}
+	status.SetManagedRoleTags(keys)
+	return nil
+}
+
+// iamTagsDiff returns the tags which need to be added or updated, and the keys of previously applied tags which need to
+// be removed
+func iamTagsDiff(current []*iam.Tag, desired map[string]string, applied []string) (map[string]string, []string) {
+	var (
+		add    = make(map[string]string)
+		remove = make([]string, 0)
+		exists = make(map[string]string)
+	)
+
+	for _, tag := range current {
+		key := aws.StringValue(tag.Key)
+		exists[key] = aws.StringValue(tag.Value)
+		if _, ok := desired[key]; !ok && common.ContainsString(applied, key) && !awsprovider.IsReservedTagKey(key) {
+			remove = append(remove, key)
+		}
+	}
+
+	for key, value := range desired {
+		if v, ok := exists[key]; !ok || v != value {
+			add[key] = value
+		}
+	}
+	return add, remove
+}
+
+// UpdateRegistryCredentialsPolicy allows the managed role to read and decrypt the registry credentials parameter with an
+// inline policy, the policy is deleted once registry credentials are no longer referenced
+func (ctx *EksInstanceGroupContext) UpdateRegistryCredentialsPolicy(roleName string, role *iam.Role) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		state         = ctx.GetDiscoveredState()
+		parameter     = ctx.GetRegistryCredentialsParameter()
+		exists        = common.ContainsString(state.GetInlinePolicies(), RegistryCredentialsPolicyName)
+	)
+
+	if parameter == "" {
+		if !exists {
+			return nil
+		}
+		if err := ctx.AwsWorker.DeleteRoleInlinePolicy(roleName, RegistryCredentialsPolicyName); err != nil {
+			return err
+		}
+		ctx.Log.Info("deleted registry credentials policy", "instancegroup", instanceGroup.NamespacedName(), "iamrole", roleName)
+		return nil
+	}
+
+	if exists {
+		return nil
+	}
+
+	roleArn, err := arn.Parse(aws.StringValue(role.Arn))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse role arn '%v'", aws.StringValue(role.Arn))
+	}
+	document := fmt.Sprintf(RegistryCredentialsPolicyDocumentFmt, roleArn.Partition, roleArn.AccountID, parameter)
+	if err := ctx.AwsWorker.PutRoleInlinePolicy(roleName, RegistryCredentialsPolicyName, document); err != nil {
+		return err
+	}
+	ctx.Log.Info("created registry credentials policy", "instancegroup", instanceGroup.NamespacedName(), "iamrole", roleName, "parameter", parameter)
+	return nil
+}
+
 func (ctx *EksInstanceGroupContext) UpdateManagedPolicies(roleName string) error {
 	var (
 		instanceGroup      = ctx.GetInstanceGroup()
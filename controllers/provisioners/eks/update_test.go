@@ -17,12 +17,17 @@ package eks
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/keikoproj/instance-manager/controllers/common"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
 	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/eks"
@@ -31,6 +36,8 @@ import (
 	"github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -527,6 +534,17 @@ func TestUpdateScalingGroupNegative(t *testing.T) {
 	g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileModifying))
 	asgMock.UpdateAutoScalingGroupErr = nil
 
+	// transient scaling group errors should requeue rather than fail
+	for _, code := range []string{autoscaling.ErrCodeScalingActivityInProgressFault, autoscaling.ErrCodeResourceInUseFault} {
+		asgMock.UpdateAutoScalingGroupErr = awserr.New(code, "some-transient-error", nil)
+		err = ctx.Update()
+		t.Log(err)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileModifying))
+		g.Expect(provisioners.IsRetryable(ig)).To(gomega.BeTrue())
+	}
+	asgMock.UpdateAutoScalingGroupErr = nil
+
 	asgMock.CreateLaunchConfigurationErr = errors.New("some-create-error")
 	err = ctx.Update()
 	t.Log(err)
@@ -617,11 +635,12 @@ func TestScalingGroupUpdatePredicate(t *testing.T) {
 	}
 }
 
-func TestUpdateManagedPolicies(t *testing.T) {
+func TestScalingGroupTerminationPolicies(t *testing.T) {
 	var (
 		g             = gomega.NewGomegaWithT(t)
 		k             = MockKubernetesClientSet()
 		ig            = MockInstanceGroup()
+		spec          = ig.GetEKSSpec()
 		configuration = ig.GetEKSConfiguration()
 		asgMock       = NewAutoScalingMocker()
 		iamMock       = NewIamMocker()
@@ -630,194 +649,1602 @@ func TestUpdateManagedPolicies(t *testing.T) {
 		ssmMock       = NewSsmMocker()
 	)
 
-	defaultPolicies := []string{"AmazonEKSWorkerNodePolicy", "AmazonEKS_CNI_Policy", "AmazonEC2ContainerRegistryReadOnly"}
-	defaultPoliciesIrsa := []string{"AmazonEKSWorkerNodePolicy", "AmazonEC2ContainerRegistryReadOnly"}
-	defaultPoliciesWarmPool := []string{"AmazonEKSWorkerNodePolicy", "AmazonEKS_CNI_Policy", "AmazonEC2ContainerRegistryReadOnly"}
-
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
+	spec.MinSize = int64(3)
+	spec.MaxSize = int64(6)
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2", "subnet-3"})
 
+	lambdaPolicy := "arn:aws:lambda:us-west-2:123456789012:function:my-termination-policy"
 	tests := []struct {
-		attachedPolicies   []*iam.AttachedPolicy
-		additionalPolicies []string
-		expectedAttached   uint
-		expectedDetached   uint
-		irsaEnabled        bool
-		hasWarmPool        bool
+		configured     []string
+		groupPolicies  []string
+		expectedPolicy []string
+		expectedUpdate bool
 	}{
-		// default policies attached, no changes needed
-		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{}, expectedAttached: 0, expectedDetached: 0},
-		// when IRSA is enabled, cni policy needs to be detached
-		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{}, irsaEnabled: true, expectedAttached: 0, expectedDetached: 1},
-		// when IRSA is disabled, cni policy needs to be attached
-		{attachedPolicies: MockAttachedPolicies(defaultPoliciesIrsa...), additionalPolicies: []string{}, irsaEnabled: false, expectedAttached: 1, expectedDetached: 0},
-		// when warm pool is enabled, managed role is added
-		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{}, hasWarmPool: true, expectedAttached: 0, expectedDetached: 0},
-		// when warm pool is disabled, managed role is removed
-		{attachedPolicies: MockAttachedPolicies(defaultPoliciesWarmPool...), additionalPolicies: []string{}, hasWarmPool: false, expectedAttached: 0, expectedDetached: 0},
-		// default policies not attached
-		{attachedPolicies: MockAttachedPolicies(), additionalPolicies: []string{}, expectedAttached: 3, expectedDetached: 0},
-		// additional policies need to be attached
-		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{"policy-1", "policy-2"}, expectedAttached: 2, expectedDetached: 0},
-		// additional policies with ARN
-		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{"arn:aws:iam::aws:policy/policy-1", "arn:aws:iam::12345679012:policy/policy-2"}, expectedAttached: 2, expectedDetached: 0},
-		// additional policies need to be detached
-		{attachedPolicies: MockAttachedPolicies("AmazonEKSWorkerNodePolicy", "AmazonEKS_CNI_Policy", "AmazonEC2ContainerRegistryReadOnly", "policy-1"), additionalPolicies: []string{}, expectedAttached: 0, expectedDetached: 1},
-		// additional policies need to be attached & detached
-		{attachedPolicies: MockAttachedPolicies("AmazonEKSWorkerNodePolicy", "AmazonEKS_CNI_Policy", "AmazonEC2ContainerRegistryReadOnly", "policy-1"), additionalPolicies: []string{"policy-2"}, expectedAttached: 1, expectedDetached: 1},
+		{configured: nil, groupPolicies: nil, expectedPolicy: []string{"Default"}, expectedUpdate: false},
+		{configured: nil, groupPolicies: []string{"Default"}, expectedPolicy: []string{"Default"}, expectedUpdate: false},
+		{configured: nil, groupPolicies: []string{"OldestInstance"}, expectedPolicy: []string{"Default"}, expectedUpdate: true},
+		{configured: []string{"OldestInstance", "Default"}, groupPolicies: []string{"Default"}, expectedPolicy: []string{"OldestInstance", "Default"}, expectedUpdate: true},
+		{configured: []string{"OldestInstance", "Default"}, groupPolicies: []string{"OldestInstance", "Default"}, expectedPolicy: []string{"OldestInstance", "Default"}, expectedUpdate: false},
+		{configured: []string{"OldestInstance", "Default"}, groupPolicies: []string{"Default", "OldestInstance"}, expectedPolicy: []string{"OldestInstance", "Default"}, expectedUpdate: true},
+		{configured: []string{lambdaPolicy}, groupPolicies: []string{"Default"}, expectedPolicy: []string{lambdaPolicy}, expectedUpdate: true},
 	}
 
 	for i, tc := range tests {
-		t.Logf("test #%v", i)
-		iamMock.AttachRolePolicyCallCount = 0
-		iamMock.DetachRolePolicyCallCount = 0
-
-		if tc.irsaEnabled {
-			ig.Annotations[IRSAEnabledAnnotation] = "true"
-		} else {
-			ig.Annotations[IRSAEnabledAnnotation] = "false"
-		}
-
-		if tc.hasWarmPool {
-			ig.Spec.EKSSpec.WarmPool = &v1alpha1.WarmPoolSpec{
-				MaxSize: int64(-1),
-				MinSize: int64(0),
-			}
-		} else {
-			ig.Spec.EKSSpec.WarmPool = nil
+		t.Logf("Test #%v - %+v", i, tc)
+		configuration.TerminationPolicies = tc.configured
+		scalingGroup := MockScalingGroup("asg-1", false)
+		scalingGroup.TerminationPolicies = aws.StringSlice(tc.groupPolicies)
+		var scalingConfig scaling.Configuration = &scaling.LaunchConfiguration{
+			AwsWorker: w,
+			TargetResource: &autoscaling.LaunchConfiguration{
+				LaunchConfigurationName: aws.String("some-launch-configuration"),
+			},
 		}
-
 		ctx.SetDiscoveredState(&DiscoveredState{
+			Cluster: MockEksCluster(""),
 			Publisher: kubeprovider.EventPublisher{
 				Client: k.Kubernetes,
 			},
-			AttachedPolicies: tc.attachedPolicies,
+			ScalingGroup:         scalingGroup,
+			ScalingConfiguration: scalingConfig,
 		})
-		configuration.SetManagedPolicies(tc.additionalPolicies)
-		err := ctx.UpdateManagedPolicies("some-role")
+		g.Expect(ctx.ScalingGroupUpdateNeeded("some-launch-configuration")).To(gomega.Equal(tc.expectedUpdate))
+
+		asgMock.UpdateAutoScalingGroupInputs = nil
+		_, err := ctx.UpdateScalingGroup("some-launch-configuration", &scalingConfig)
 		g.Expect(err).NotTo(gomega.HaveOccurred())
-		g.Expect(iamMock.AttachRolePolicyCallCount).To(gomega.Equal(tc.expectedAttached))
-		g.Expect(iamMock.DetachRolePolicyCallCount).To(gomega.Equal(tc.expectedDetached))
-	}
-}
+		if tc.expectedUpdate {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+			g.Expect(aws.StringValueSlice(asgMock.UpdateAutoScalingGroupInputs[0].TerminationPolicies)).To(gomega.Equal(tc.expectedPolicy))
+		} else {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.BeEmpty())
+		}
 
-type amiTest struct {
-	igImage     string
-	expectedAmi string
-	osFamily    string
+		// scaling groups are created with the desired policies
+		ctx.GetDiscoveredState().ScalingGroup = nil
+		asgMock.CreateAutoScalingGroupInputs = nil
+		err = ctx.CreateScalingGroup("some-launch-configuration")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(asgMock.CreateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+		g.Expect(aws.StringValueSlice(asgMock.CreateAutoScalingGroupInputs[0].TerminationPolicies)).To(gomega.Equal(tc.expectedPolicy))
+	}
 }
 
-func TestUpdateWithLatestAmiID(t *testing.T) {
+func TestScalingGroupInstanceMaintenancePolicy(t *testing.T) {
 	var (
-		g       = gomega.NewGomegaWithT(t)
-		k       = MockKubernetesClientSet()
-		ig      = MockInstanceGroup()
-		asgMock = NewAutoScalingMocker()
-		iamMock = NewIamMocker()
-		eksMock = NewEksMocker()
-		ec2Mock = NewEc2Mocker()
-		ssmMock = NewSsmMocker()
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		spec          = ig.GetEKSSpec()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
 	)
 
-	testLatestAmiID := "ami-12345678"
-	testCustomAmi := "ami-98765432"
-	testBottlerocketAmi := "ami-56789101"
-	testWindowsAmi := "ami-56789201"
-
 	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
 	ctx := MockContext(ig, k, w)
+	spec.MinSize = int64(3)
+	spec.MaxSize = int64(6)
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2", "subnet-3"})
 
-	mockScalingGroup := &autoscaling.Group{
-		AutoScalingGroupName: aws.String("some-scaling-group"),
-		DesiredCapacity:      aws.Int64(1),
-		Instances: []*autoscaling.Instance{
-			{
-				InstanceId:              aws.String("i-1234"),
-				LaunchConfigurationName: aws.String("some-launch-config"),
-			},
-		},
+	policy := func(min, max int64) *autoscaling.InstanceMaintenancePolicy {
+		return &autoscaling.InstanceMaintenancePolicy{MinHealthyPercentage: aws.Int64(min), MaxHealthyPercentage: aws.Int64(max)}
 	}
-	asgMock.AutoScalingGroups = []*autoscaling.Group{mockScalingGroup}
-
-	// skip role creation
-	ig.GetEKSConfiguration().SetInstanceProfileName("some-profile")
-	ig.GetEKSConfiguration().SetRoleName("some-role")
-	iamMock.Role = &iam.Role{
-		Arn:      aws.String("some-arn"),
-		RoleName: aws.String("some-role"),
+	tests := []struct {
+		configured     *v1alpha1.InstanceMaintenanceSpec
+		groupPolicy    *autoscaling.InstanceMaintenancePolicy
+		expectedPolicy *autoscaling.InstanceMaintenancePolicy
+		expectedCreate *autoscaling.InstanceMaintenancePolicy
+		expectedUpdate bool
+	}{
+		{configured: nil, groupPolicy: nil, expectedPolicy: nil, expectedCreate: nil, expectedUpdate: false},
+		{configured: nil, groupPolicy: policy(-1, -1), expectedPolicy: nil, expectedCreate: nil, expectedUpdate: false},
+		{configured: nil, groupPolicy: policy(90, 120), expectedPolicy: policy(-1, -1), expectedCreate: nil, expectedUpdate: true},
+		{configured: &v1alpha1.InstanceMaintenanceSpec{MinHealthyPercentage: 90, MaxHealthyPercentage: 120}, groupPolicy: nil, expectedPolicy: policy(90, 120), expectedCreate: policy(90, 120), expectedUpdate: true},
+		{configured: &v1alpha1.InstanceMaintenanceSpec{MinHealthyPercentage: 90, MaxHealthyPercentage: 120}, groupPolicy: policy(90, 120), expectedPolicy: policy(90, 120), expectedCreate: policy(90, 120), expectedUpdate: false},
+		{configured: &v1alpha1.InstanceMaintenanceSpec{MinHealthyPercentage: 100, MaxHealthyPercentage: 110}, groupPolicy: policy(90, 120), expectedPolicy: policy(100, 110), expectedCreate: policy(100, 110), expectedUpdate: true},
 	}
 
-	// Setup Latest AMI
-	ssmMock.parameterMap = map[string]string{
-		"/aws/service/eks/optimized-ami/1.18/amazon-linux-2/recommended/image_id":                      testLatestAmiID,
-		"/aws/service/eks/optimized-ami/1.18/amazon-linux-2/amazon-eks-node-1.18-v20220226/image_id":   testCustomAmi,
-		"/aws/service/bottlerocket/aws-k8s-1.18/x86_64/1.6.1/image_id":                                 testBottlerocketAmi,
-		"/aws/service/ami-windows-latest/Windows_Server-2019-English-Core-EKS_Optimized-1.18/image_id": testWindowsAmi,
-	}
-	ec2Mock.InstanceTypes = []*ec2.InstanceTypeInfo{
-		&ec2.InstanceTypeInfo{
-			InstanceType: aws.String("m5.large"),
-			ProcessorInfo: &ec2.ProcessorInfo{
-				SupportedArchitectures: []*string{aws.String("x86_64")},
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		configuration.InstanceMaintenancePolicy = tc.configured
+		scalingGroup := MockScalingGroup("asg-1", false)
+		scalingGroup.InstanceMaintenancePolicy = tc.groupPolicy
+		var scalingConfig scaling.Configuration = &scaling.LaunchConfiguration{
+			AwsWorker: w,
+			TargetResource: &autoscaling.LaunchConfiguration{
+				LaunchConfigurationName: aws.String("some-launch-configuration"),
 			},
-		},
-	}
+		}
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Cluster: MockEksCluster(""),
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         scalingGroup,
+			ScalingConfiguration: scalingConfig,
+		})
+		g.Expect(ctx.ScalingGroupUpdateNeeded("some-launch-configuration")).To(gomega.Equal(tc.expectedUpdate))
 
-	testAmis := []amiTest{
-		{
-			igImage:     "latest",
-			expectedAmi: testLatestAmiID,
-			osFamily:    "amazonlinux2",
-		},
-		{
-			igImage:     "ssm://amazon-eks-node-1.18-v20220226",
-			expectedAmi: testCustomAmi,
-			osFamily:    "amazonlinux2",
-		},
-		{
-			igImage:     "ssm://1.6.1",
-			expectedAmi: testBottlerocketAmi,
-			osFamily:    "bottlerocket",
-		},
-		{
-			igImage:     "ssm://latest",
-			expectedAmi: testWindowsAmi,
-			osFamily:    "windows",
-		},
-	}
+		asgMock.UpdateAutoScalingGroupInputs = nil
+		_, err := ctx.UpdateScalingGroup("some-launch-configuration", &scalingConfig)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		if tc.expectedUpdate {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs[0].InstanceMaintenancePolicy).To(gomega.Equal(tc.expectedPolicy))
+		} else {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.BeEmpty())
+		}
 
-	for _, tc := range testAmis {
-		ig.GetEKSConfiguration().Image = tc.igImage
-		ig.Annotations[OsFamilyAnnotation] = tc.osFamily
-		err := ctx.CloudDiscovery()
+		// scaling groups are created with the configured policy
+		ctx.GetDiscoveredState().ScalingGroup = nil
+		asgMock.CreateAutoScalingGroupInputs = nil
+		err = ctx.CreateScalingGroup("some-launch-configuration")
 		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(asgMock.CreateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+		g.Expect(asgMock.CreateAutoScalingGroupInputs[0].InstanceMaintenancePolicy).To(gomega.Equal(tc.expectedCreate))
+	}
+}
+
+func TestScalingGroupCapacityRebalance(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		spec          = ig.GetEKSSpec()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	spec.MinSize = int64(3)
+	spec.MaxSize = int64(6)
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2", "subnet-3"})
+
+	tests := []struct {
+		enabled        bool
+		groupRebalance *bool
+		expectedUpdate bool
+	}{
+		{enabled: false, groupRebalance: nil, expectedUpdate: false},
+		{enabled: false, groupRebalance: aws.Bool(false), expectedUpdate: false},
+		{enabled: true, groupRebalance: aws.Bool(false), expectedUpdate: true},
+		{enabled: true, groupRebalance: aws.Bool(true), expectedUpdate: false},
+		{enabled: false, groupRebalance: aws.Bool(true), expectedUpdate: true},
+	}
 
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		configuration.CapacityRebalance = tc.enabled
+		scalingGroup := MockScalingGroup("asg-1", false)
+		scalingGroup.CapacityRebalance = tc.groupRebalance
+		var scalingConfig scaling.Configuration = &scaling.LaunchConfiguration{
+			AwsWorker: w,
+			TargetResource: &autoscaling.LaunchConfiguration{
+				LaunchConfigurationName: aws.String("some-launch-configuration"),
+			},
+		}
 		ctx.SetDiscoveredState(&DiscoveredState{
+			Cluster: MockEksCluster(""),
 			Publisher: kubeprovider.EventPublisher{
 				Client: k.Kubernetes,
 			},
-			ScalingGroup: mockScalingGroup,
-			ScalingConfiguration: &scaling.LaunchConfiguration{
-				AwsWorker: w,
-			},
-			InstanceProfile: &iam.InstanceProfile{
-				Arn: aws.String("some-instance-arn"),
-			},
-			ClusterNodes: nil,
-			Cluster:      nil,
-			InstanceTypeInfo: []*ec2.InstanceTypeInfo{
-				{
-					InstanceType: aws.String("m5.large"),
-					ProcessorInfo: &ec2.ProcessorInfo{
-						SupportedArchitectures: []*string{aws.String("x86_64")},
-					},
-				},
-			},
+			ScalingGroup:         scalingGroup,
+			ScalingConfiguration: scalingConfig,
 		})
+		g.Expect(ctx.ScalingGroupUpdateNeeded("some-launch-configuration")).To(gomega.Equal(tc.expectedUpdate))
 
-		err = ctx.Update()
+		asgMock.UpdateAutoScalingGroupInputs = nil
+		_, err := ctx.UpdateScalingGroup("some-launch-configuration", &scalingConfig)
 		g.Expect(err).NotTo(gomega.HaveOccurred())
-		g.Expect(ctx.GetInstanceGroup().Spec.EKSSpec.EKSConfiguration.Image).To(gomega.Equal(tc.expectedAmi))
+		if tc.expectedUpdate {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs[0].CapacityRebalance).To(gomega.Equal(aws.Bool(tc.enabled)))
+		} else {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.BeEmpty())
+		}
+
+		ctx.GetDiscoveredState().ScalingGroup = nil
+		asgMock.CreateAutoScalingGroupInputs = nil
+		err = ctx.CreateScalingGroup("some-launch-configuration")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(asgMock.CreateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+		g.Expect(asgMock.CreateAutoScalingGroupInputs[0].CapacityRebalance).To(gomega.Equal(aws.Bool(tc.enabled)))
+	}
+}
+
+func TestScalingGroupDesiredCapacityHandoff(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		spec          = ig.GetEKSSpec()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2", "subnet-3"})
+
+	tests := []struct {
+		clusterAutoscaler bool
+		minSize           int64
+		maxSize           int64
+		groupDesired      int64
+		expectedUpdate    bool
+		expectedDesired   *int64
+	}{
+		// desired capacity within bounds is never written
+		{clusterAutoscaler: false, minSize: 3, maxSize: 6, groupDesired: 5, expectedUpdate: false},
+		{clusterAutoscaler: false, minSize: 4, maxSize: 6, groupDesired: 5, expectedUpdate: true, expectedDesired: nil},
+		// desired capacity is brought within new bounds
+		{clusterAutoscaler: false, minSize: 5, maxSize: 8, groupDesired: 3, expectedUpdate: true, expectedDesired: aws.Int64(5)},
+		{clusterAutoscaler: false, minSize: 1, maxSize: 2, groupDesired: 3, expectedUpdate: true, expectedDesired: aws.Int64(2)},
+		// cluster-autoscaler owns desired capacity
+		{clusterAutoscaler: true, minSize: 3, maxSize: 6, groupDesired: 5, expectedUpdate: false},
+		{clusterAutoscaler: true, minSize: 5, maxSize: 8, groupDesired: 3, expectedUpdate: true, expectedDesired: nil},
+		{clusterAutoscaler: true, minSize: 1, maxSize: 2, groupDesired: 3, expectedUpdate: true, expectedDesired: nil},
 	}
 
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ig.SetAnnotations(map[string]string{})
+		if tc.clusterAutoscaler {
+			ig.SetAnnotations(map[string]string{ClusterAutoscalerEnabledAnnotation: "true"})
+		}
+		spec.MinSize = tc.minSize
+		spec.MaxSize = tc.maxSize
+		scalingGroup := MockScalingGroup("asg-1", false)
+		scalingGroup.DesiredCapacity = aws.Int64(tc.groupDesired)
+		var scalingConfig scaling.Configuration = &scaling.LaunchConfiguration{
+			AwsWorker: w,
+			TargetResource: &autoscaling.LaunchConfiguration{
+				LaunchConfigurationName: aws.String("some-launch-configuration"),
+			},
+		}
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Cluster: MockEksCluster(""),
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         scalingGroup,
+			ScalingConfiguration: scalingConfig,
+		})
+		g.Expect(ctx.ScalingGroupUpdateNeeded("some-launch-configuration")).To(gomega.Equal(tc.expectedUpdate))
+
+		asgMock.UpdateAutoScalingGroupInputs = nil
+		_, err := ctx.UpdateScalingGroup("some-launch-configuration", &scalingConfig)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		if !tc.expectedUpdate {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.BeEmpty())
+			continue
+		}
+		g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+		input := asgMock.UpdateAutoScalingGroupInputs[0]
+		g.Expect(input.DesiredCapacity).To(gomega.Equal(tc.expectedDesired))
+		g.Expect(aws.Int64Value(input.MinSize)).To(gomega.Equal(tc.minSize))
+		g.Expect(aws.Int64Value(input.MaxSize)).To(gomega.Equal(tc.maxSize))
+	}
+}
+
+func TestScalingGroupAutoscaler(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		spec          = ig.GetEKSSpec()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2", "subnet-3"})
+	ig.SetAnnotations(map[string]string{})
+	spec.MinSize = 3
+	spec.MaxSize = 6
+
+	tests := []struct {
+		currentValue    string
+		groupDesired    int64
+		expectedUpdate  bool
+		expectedDesired int64
+	}{
+		// desired capacity is not changed until the metric is reported
+		{currentValue: "", groupDesired: 4, expectedUpdate: false, expectedDesired: 4},
+		// desired capacity follows the metric
+		{currentValue: "500", groupDesired: 4, expectedUpdate: true, expectedDesired: 5},
+		{currentValue: "350", groupDesired: 5, expectedUpdate: true, expectedDesired: 4},
+		// metric within tolerance of its target
+		{currentValue: "410", groupDesired: 4, expectedUpdate: false, expectedDesired: 4},
+		// desired capacity is bounded by the autoscaler's max and the instance group's min
+		{currentValue: "900", groupDesired: 4, expectedUpdate: true, expectedDesired: 5},
+		{currentValue: "100", groupDesired: 4, expectedUpdate: true, expectedDesired: 3},
+		{currentValue: "150m", groupDesired: 3, expectedUpdate: false, expectedDesired: 3},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		autoscaler := MockInstanceGroupAutoscaler(ig.GetName(), 2, 5, "100")
+		if tc.currentValue != "" {
+			value := resource.MustParse(tc.currentValue)
+			autoscaler.Status.CurrentValue = &value
+			autoscaler.Status.CurrentValueTime = &metav1.Time{Time: time.Now()}
+		}
+		ctx.Autoscaler = autoscaler
+
+		scalingGroup := MockScalingGroup("asg-1", false)
+		scalingGroup.DesiredCapacity = aws.Int64(tc.groupDesired)
+		var scalingConfig scaling.Configuration = &scaling.LaunchConfiguration{
+			AwsWorker: w,
+			TargetResource: &autoscaling.LaunchConfiguration{
+				LaunchConfigurationName: aws.String("some-launch-configuration"),
+			},
+		}
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Cluster: MockEksCluster(""),
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         scalingGroup,
+			ScalingConfiguration: scalingConfig,
+		})
+		g.Expect(ctx.ScalingGroupUpdateNeeded("some-launch-configuration")).To(gomega.Equal(tc.expectedUpdate))
+
+		asgMock.UpdateAutoScalingGroupInputs = nil
+		_, err := ctx.UpdateScalingGroup("some-launch-configuration", &scalingConfig)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(autoscaler.Status.DesiredCapacity).To(gomega.Equal(tc.expectedDesired))
+		if !tc.expectedUpdate {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.BeEmpty())
+			g.Expect(autoscaler.Status.LastScaleTime).To(gomega.BeNil())
+			continue
+		}
+		g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+		g.Expect(aws.Int64Value(asgMock.UpdateAutoScalingGroupInputs[0].DesiredCapacity)).To(gomega.Equal(tc.expectedDesired))
+		g.Expect(autoscaler.Status.LastScaleTime).NotTo(gomega.BeNil())
+	}
+
+	events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	var scaled int
+	for _, e := range events.Items {
+		if e.Reason == string(kubeprovider.InstanceGroupAutoscaledEvent) {
+			scaled++
+		}
+	}
+	g.Expect(scaled).To(gomega.Equal(4))
+}
+
+func TestValidateAutoscaler(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		autoscaler        bool
+		clusterAutoscaler bool
+		zoneSharding      bool
+		expectedErr       bool
+	}{
+		{autoscaler: false, clusterAutoscaler: true, zoneSharding: true, expectedErr: false},
+		{autoscaler: true, clusterAutoscaler: false, zoneSharding: false, expectedErr: false},
+		{autoscaler: true, clusterAutoscaler: true, zoneSharding: false, expectedErr: true},
+		{autoscaler: true, clusterAutoscaler: false, zoneSharding: true, expectedErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		ctx.Autoscaler = nil
+		if tc.autoscaler {
+			ctx.Autoscaler = MockInstanceGroupAutoscaler(ig.GetName(), 1, 5, "100")
+		}
+		ig.SetAnnotations(map[string]string{})
+		if tc.clusterAutoscaler {
+			ig.SetAnnotations(map[string]string{ClusterAutoscalerEnabledAnnotation: "true"})
+		}
+		configuration.ZoneSharding = tc.zoneSharding
+
+		err := ctx.ValidateAutoscaler()
+		if tc.expectedErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+	}
+}
+
+func TestScalingGroupCooldownAndScaleInProtection(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		spec          = ig.GetEKSSpec()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	spec.MinSize = int64(3)
+	spec.MaxSize = int64(6)
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2", "subnet-3"})
+
+	tests := []struct {
+		cooldown         *int64
+		protected        bool
+		groupCooldown    *int64
+		groupProtected   *bool
+		expectedCooldown int64
+		expectedUpdate   bool
+	}{
+		{cooldown: nil, protected: false, groupCooldown: aws.Int64(300), groupProtected: nil, expectedCooldown: 300, expectedUpdate: false},
+		{cooldown: aws.Int64(300), protected: false, groupCooldown: aws.Int64(300), groupProtected: aws.Bool(false), expectedCooldown: 300, expectedUpdate: false},
+		{cooldown: aws.Int64(0), protected: false, groupCooldown: aws.Int64(300), groupProtected: aws.Bool(false), expectedCooldown: 0, expectedUpdate: true},
+		{cooldown: aws.Int64(60), protected: false, groupCooldown: aws.Int64(60), groupProtected: aws.Bool(false), expectedCooldown: 60, expectedUpdate: false},
+		{cooldown: nil, protected: false, groupCooldown: aws.Int64(60), groupProtected: aws.Bool(false), expectedCooldown: 300, expectedUpdate: true},
+		{cooldown: nil, protected: true, groupCooldown: aws.Int64(300), groupProtected: aws.Bool(false), expectedCooldown: 300, expectedUpdate: true},
+		{cooldown: nil, protected: true, groupCooldown: aws.Int64(300), groupProtected: aws.Bool(true), expectedCooldown: 300, expectedUpdate: false},
+		{cooldown: nil, protected: false, groupCooldown: aws.Int64(300), groupProtected: aws.Bool(true), expectedCooldown: 300, expectedUpdate: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc)
+		configuration.DefaultCooldown = tc.cooldown
+		configuration.ScaleInProtection = tc.protected
+		scalingGroup := MockScalingGroup("asg-1", false)
+		scalingGroup.DefaultCooldown = tc.groupCooldown
+		scalingGroup.NewInstancesProtectedFromScaleIn = tc.groupProtected
+		var scalingConfig scaling.Configuration = &scaling.LaunchConfiguration{
+			AwsWorker: w,
+			TargetResource: &autoscaling.LaunchConfiguration{
+				LaunchConfigurationName: aws.String("some-launch-configuration"),
+			},
+		}
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Cluster: MockEksCluster(""),
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         scalingGroup,
+			ScalingConfiguration: scalingConfig,
+		})
+		g.Expect(ctx.ScalingGroupUpdateNeeded("some-launch-configuration")).To(gomega.Equal(tc.expectedUpdate))
+
+		asgMock.UpdateAutoScalingGroupInputs = nil
+		_, err := ctx.UpdateScalingGroup("some-launch-configuration", &scalingConfig)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		if tc.expectedUpdate {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs[0].DefaultCooldown).To(gomega.Equal(aws.Int64(tc.expectedCooldown)))
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs[0].NewInstancesProtectedFromScaleIn).To(gomega.Equal(aws.Bool(tc.protected)))
+		} else {
+			g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.BeEmpty())
+		}
+
+		ctx.GetDiscoveredState().ScalingGroup = nil
+		asgMock.CreateAutoScalingGroupInputs = nil
+		err = ctx.CreateScalingGroup("some-launch-configuration")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(asgMock.CreateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+		g.Expect(asgMock.CreateAutoScalingGroupInputs[0].DefaultCooldown).To(gomega.Equal(aws.Int64(tc.expectedCooldown)))
+		g.Expect(asgMock.CreateAutoScalingGroupInputs[0].NewInstancesProtectedFromScaleIn).To(gomega.Equal(aws.Bool(tc.protected)))
+	}
+}
+
+func TestUpdateManagedPolicies(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	defaultPolicies := []string{"AmazonEKSWorkerNodePolicy", "AmazonEKS_CNI_Policy", "AmazonEC2ContainerRegistryReadOnly"}
+	defaultPoliciesIrsa := []string{"AmazonEKSWorkerNodePolicy", "AmazonEC2ContainerRegistryReadOnly"}
+	defaultPoliciesWarmPool := []string{"AmazonEKSWorkerNodePolicy", "AmazonEKS_CNI_Policy", "AmazonEC2ContainerRegistryReadOnly"}
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tests := []struct {
+		attachedPolicies   []*iam.AttachedPolicy
+		additionalPolicies []string
+		expectedAttached   uint
+		expectedDetached   uint
+		irsaEnabled        bool
+		hasWarmPool        bool
+		cniPlugin          string
+	}{
+		// default policies attached, no changes needed
+		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{}, expectedAttached: 0, expectedDetached: 0},
+		// when IRSA is enabled, cni policy needs to be detached
+		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{}, irsaEnabled: true, expectedAttached: 0, expectedDetached: 1},
+		// when IRSA is disabled, cni policy needs to be attached
+		{attachedPolicies: MockAttachedPolicies(defaultPoliciesIrsa...), additionalPolicies: []string{}, irsaEnabled: false, expectedAttached: 1, expectedDetached: 0},
+		// when the group runs another cni plugin, cni policy needs to be detached
+		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{}, cniPlugin: v1alpha1.CNIPluginOther, expectedAttached: 0, expectedDetached: 1},
+		{attachedPolicies: MockAttachedPolicies(defaultPoliciesIrsa...), additionalPolicies: []string{}, cniPlugin: v1alpha1.CNIPluginOther, expectedAttached: 0, expectedDetached: 0},
+		// when the group runs the vpc cni, cni policy needs to be attached
+		{attachedPolicies: MockAttachedPolicies(defaultPoliciesIrsa...), additionalPolicies: []string{}, cniPlugin: v1alpha1.CNIPluginAwsVpcCNI, expectedAttached: 1, expectedDetached: 0},
+		{attachedPolicies: MockAttachedPolicies(defaultPoliciesIrsa...), additionalPolicies: []string{}, cniPlugin: v1alpha1.CNIPluginAwsVpcCNI, irsaEnabled: true, expectedAttached: 0, expectedDetached: 0},
+		// when warm pool is enabled, managed role is added
+		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{}, hasWarmPool: true, expectedAttached: 0, expectedDetached: 0},
+		// when warm pool is disabled, managed role is removed
+		{attachedPolicies: MockAttachedPolicies(defaultPoliciesWarmPool...), additionalPolicies: []string{}, hasWarmPool: false, expectedAttached: 0, expectedDetached: 0},
+		// default policies not attached
+		{attachedPolicies: MockAttachedPolicies(), additionalPolicies: []string{}, expectedAttached: 3, expectedDetached: 0},
+		// additional policies need to be attached
+		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{"policy-1", "policy-2"}, expectedAttached: 2, expectedDetached: 0},
+		// additional policies with ARN
+		{attachedPolicies: MockAttachedPolicies(defaultPolicies...), additionalPolicies: []string{"arn:aws:iam::aws:policy/policy-1", "arn:aws:iam::12345679012:policy/policy-2"}, expectedAttached: 2, expectedDetached: 0},
+		// additional policies need to be detached
+		{attachedPolicies: MockAttachedPolicies("AmazonEKSWorkerNodePolicy", "AmazonEKS_CNI_Policy", "AmazonEC2ContainerRegistryReadOnly", "policy-1"), additionalPolicies: []string{}, expectedAttached: 0, expectedDetached: 1},
+		// additional policies need to be attached & detached
+		{attachedPolicies: MockAttachedPolicies("AmazonEKSWorkerNodePolicy", "AmazonEKS_CNI_Policy", "AmazonEC2ContainerRegistryReadOnly", "policy-1"), additionalPolicies: []string{"policy-2"}, expectedAttached: 1, expectedDetached: 1},
+	}
+
+	for i, tc := range tests {
+		t.Logf("test #%v", i)
+		iamMock.AttachRolePolicyCallCount = 0
+		iamMock.DetachRolePolicyCallCount = 0
+
+		if tc.irsaEnabled {
+			ig.Annotations[IRSAEnabledAnnotation] = "true"
+		} else {
+			ig.Annotations[IRSAEnabledAnnotation] = "false"
+		}
+
+		if tc.hasWarmPool {
+			ig.Spec.EKSSpec.WarmPool = &v1alpha1.WarmPoolSpec{
+				MaxSize: int64(-1),
+				MinSize: int64(0),
+			}
+		} else {
+			ig.Spec.EKSSpec.WarmPool = nil
+		}
+
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			AttachedPolicies: tc.attachedPolicies,
+		})
+		configuration.CNI = nil
+		if tc.cniPlugin != "" {
+			configuration.CNI = &v1alpha1.CNISpec{Plugin: tc.cniPlugin}
+		}
+		configuration.SetManagedPolicies(tc.additionalPolicies)
+		err := ctx.UpdateManagedPolicies("some-role")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(iamMock.AttachRolePolicyCallCount).To(gomega.Equal(tc.expectedAttached))
+		g.Expect(iamMock.DetachRolePolicyCallCount).To(gomega.Equal(tc.expectedDetached))
+	}
+}
+
+type amiTest struct {
+	igImage     string
+	expectedAmi string
+	osFamily    string
+}
+
+func TestUpdateManagedRoleTags(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	iamMock.InstanceProfile = &iam.InstanceProfile{
+		Arn:                 aws.String("some-arn"),
+		InstanceProfileName: aws.String("some-profile"),
+	}
+
+	tests := []struct {
+		tags             []map[string]string
+		expectedTags     map[string]string
+		expectedTagged   uint
+		expectedUntagged uint
+	}{
+		// tags are added to a role without tags
+		{tags: []map[string]string{{"key": "cost-center", "value": "1234"}, {"key": "team", "value": "platform"}}, expectedTags: map[string]string{"cost-center": "1234", "team": "platform"}, expectedTagged: 1},
+		// no changes needed
+		{tags: []map[string]string{{"key": "cost-center", "value": "1234"}, {"key": "team", "value": "platform"}}, expectedTags: map[string]string{"cost-center": "1234", "team": "platform"}},
+		// changed value is updated and removed tag is untagged
+		{tags: []map[string]string{{"key": "cost-center", "value": "5678"}}, expectedTags: map[string]string{"cost-center": "5678"}, expectedTagged: 1, expectedUntagged: 1},
+		// tags using the reserved prefix are not propagated
+		{tags: []map[string]string{{"key": "cost-center", "value": "5678"}, {"key": "aws:cloudformation:stack-name", "value": "my-stack"}}, expectedTags: map[string]string{"cost-center": "5678"}},
+		// all tags removed
+		{tags: []map[string]string{}, expectedTags: map[string]string{}, expectedUntagged: 1},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %+v", i, tc.tags)
+		iamMock.TagRoleCallCount = 0
+		iamMock.UntagRoleCallCount = 0
+		iamMock.TagInstanceProfileCallCount = 0
+		iamMock.UntagInstanceProfileCallCount = 0
+		configuration.Tags = tc.tags
+
+		err := ctx.CreateManagedRole()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		for _, tags := range [][]*iam.Tag{iamMock.Role.Tags, iamMock.InstanceProfile.Tags} {
+			actual := make(map[string]string)
+			for _, tag := range tags {
+				actual[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			g.Expect(actual).To(gomega.Equal(tc.expectedTags))
+		}
+		g.Expect(iamMock.TagRoleCallCount).To(gomega.Equal(tc.expectedTagged))
+		g.Expect(iamMock.TagInstanceProfileCallCount).To(gomega.Equal(tc.expectedTagged))
+		g.Expect(iamMock.UntagRoleCallCount).To(gomega.Equal(tc.expectedUntagged))
+		g.Expect(iamMock.UntagInstanceProfileCallCount).To(gomega.Equal(tc.expectedUntagged))
+	}
+
+	// tags which were not applied by the controller are kept
+	iamMock.Role.Tags = append(iamMock.Role.Tags, &iam.Tag{Key: aws.String("owner"), Value: aws.String("someone")})
+	configuration.Tags = []map[string]string{{"key": "cost-center", "value": "1234"}}
+	err := ctx.CreateManagedRole()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ig.GetStatus().GetManagedRoleTags()).To(gomega.Equal([]string{"cost-center"}))
+	configuration.Tags = []map[string]string{}
+	err = ctx.CreateManagedRole()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(iamMock.Role.Tags).To(gomega.Equal([]*iam.Tag{{Key: aws.String("owner"), Value: aws.String("someone")}}))
+	g.Expect(ig.GetStatus().GetManagedRoleTags()).To(gomega.BeNil())
+
+	// tagging failures are surfaced
+	configuration.Tags = []map[string]string{{"key": "cost-center", "value": "9999"}}
+	iamMock.TagRoleErr = errors.New("some-error")
+	err = ctx.CreateManagedRole()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestUpdateSourceDestCheck(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	mockInstance := func(id, state string, sourceDestCheck *bool) *ec2.Instance {
+		return &ec2.Instance{
+			InstanceId:      aws.String(id),
+			State:           &ec2.InstanceState{Name: aws.String(state)},
+			SourceDestCheck: sourceDestCheck,
+		}
+	}
+
+	ec2Mock.Instances = []*ec2.Instance{
+		mockInstance("i-000000000", ec2.InstanceStateNameRunning, aws.Bool(true)),
+		mockInstance("i-000000001", ec2.InstanceStateNameRunning, aws.Bool(false)),
+		mockInstance("i-000000002", ec2.InstanceStateNamePending, nil),
+		mockInstance("i-000000003", ec2.InstanceStateNameShuttingDown, aws.Bool(true)),
+	}
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			AutoScalingGroupName: aws.String("some-scaling-group"),
+			Instances:            MockScalingInstances(4, 0),
+		},
+	})
+
+	// source/dest check is left unchanged unless disabled
+	err := ctx.UpdateSourceDestCheck()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ec2Mock.ModifiedInstances).To(gomega.BeEmpty())
+
+	// only running instances which have source/dest check enabled are modified
+	configuration.DisableSourceDestCheck = true
+	err = ctx.UpdateSourceDestCheck()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ec2Mock.ModifiedInstances).To(gomega.Equal([]string{"i-000000000", "i-000000002"}))
+
+	g.Expect(ig.GetStatus().GetSourceDestCheckDisabled()).To(gomega.BeTrue())
+
+	ec2Mock.ModifyInstanceAttributeErr = errors.New("some-error")
+	err = ctx.UpdateSourceDestCheck()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	ec2Mock.ModifyInstanceAttributeErr = nil
+	ec2Mock.DescribeInstancesErr = errors.New("some-error")
+	err = ctx.UpdateSourceDestCheck()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the check is enabled again on running instances once the setting is removed
+	ec2Mock.DescribeInstancesErr = nil
+	ec2Mock.ModifiedInstances = nil
+	configuration.DisableSourceDestCheck = false
+	err = ctx.UpdateSourceDestCheck()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ec2Mock.ModifiedInstances).To(gomega.Equal([]string{"i-000000001"}))
+	g.Expect(ig.GetStatus().GetSourceDestCheckDisabled()).To(gomega.BeFalse())
+
+	// instances are not described again once the check is enabled
+	ec2Mock.DescribeInstancesErr = errors.New("some-error")
+	err = ctx.UpdateSourceDestCheck()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func TestUpdateENIConfigAnnotations(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	instances := MockScalingInstances(3, 0)
+	instances[0].AvailabilityZone = aws.String("us-west-2a")
+	instances[1].AvailabilityZone = aws.String("us-west-2b")
+	instances[2].AvailabilityZone = aws.String("us-west-2c")
+
+	// a node already annotated with an eniConfig keeps it, and nodes of other instance groups are ignored
+	nodes := []*corev1.Node{
+		MockNode("i-000000000", corev1.ConditionTrue),
+		MockNode("i-000000001", corev1.ConditionTrue),
+		MockNode("i-000000002", corev1.ConditionTrue),
+		MockNode("i-999999999", corev1.ConditionTrue),
+	}
+	nodes[0].SetAnnotations(map[string]string{"example.com/owner": "team"})
+	nodes[2].SetAnnotations(map[string]string{kubeprovider.ENIConfigAnnotation: "custom-eniconfig"})
+	for i, node := range nodes {
+		node.SetLabels(map[string]string{"example.com/eniconfig": fmt.Sprintf("eniconfig-%v", i)})
+	}
+
+	nodeList := &corev1.NodeList{}
+	for _, node := range nodes {
+		_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		nodeList.Items = append(nodeList.Items, *node)
+	}
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			AutoScalingGroupName: aws.String("some-scaling-group"),
+			Instances:            instances,
+		},
+		ClusterNodes: nodeList,
+	})
+
+	getAnnotations := func(name string) map[string]string {
+		node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return node.GetAnnotations()
+	}
+	getAnnotation := func(name string) string {
+		return getAnnotations(name)[kubeprovider.ENIConfigAnnotation]
+	}
+
+	// nodes are not annotated unless custom networking is enabled
+	g.Expect(ctx.UpdateENIConfigAnnotations()).To(gomega.Succeed())
+	g.Expect(getAnnotation("node-i-000000000")).To(gomega.BeEmpty())
+
+	ig.SetAnnotations(map[string]string{CustomNetworkingEnabledAnnotation: "true"})
+	g.Expect(ctx.UpdateENIConfigAnnotations()).To(gomega.Succeed())
+	g.Expect(getAnnotation("node-i-000000000")).To(gomega.Equal("us-west-2a"))
+	g.Expect(getAnnotation("node-i-000000001")).To(gomega.Equal("us-west-2b"))
+	g.Expect(getAnnotation("node-i-000000002")).To(gomega.Equal("custom-eniconfig"))
+	g.Expect(getAnnotation("node-i-999999999")).To(gomega.BeEmpty())
+
+	// annotations are merged into the annotations of the node
+	g.Expect(getAnnotations("node-i-000000000")).To(gomega.HaveKeyWithValue("example.com/owner", "team"))
+
+	// the eniConfig is resolved through the configured label, and set on the configured annotation
+	ig.SetAnnotations(map[string]string{
+		CustomNetworkingEnabledAnnotation:             "true",
+		CustomNetworkingENIConfigLabelAnnotation:      "example.com/eniconfig",
+		CustomNetworkingENIConfigAnnotationAnnotation: "example.com/eniconfig",
+	})
+	g.Expect(ctx.UpdateENIConfigAnnotations()).To(gomega.Succeed())
+	g.Expect(getAnnotations("node-i-000000001")).To(gomega.HaveKeyWithValue("example.com/eniconfig", "eniconfig-1"))
+	g.Expect(getAnnotations("node-i-000000002")).To(gomega.HaveKeyWithValue("example.com/eniconfig", "eniconfig-2"))
+	g.Expect(getAnnotations("node-i-999999999")).NotTo(gomega.HaveKey("example.com/eniconfig"))
+}
+
+func TestUpdateLifecycleLabels(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	ec2Mock.Instances = []*ec2.Instance{
+		{InstanceId: aws.String("i-000000000"), InstanceLifecycle: aws.String(ec2.InstanceLifecycleTypeSpot)},
+		{InstanceId: aws.String("i-000000001")},
+		{InstanceId: aws.String("i-000000002"), InstanceLifecycle: aws.String(ec2.InstanceLifecycleTypeSpot)},
+	}
+
+	// a node labeled with the lifecycle of the group before it was mixed is corrected, and nodes of other instance
+	// groups are ignored
+	nodes := []*corev1.Node{
+		MockNode("i-000000000", corev1.ConditionTrue),
+		MockNode("i-000000001", corev1.ConditionTrue),
+		MockNode("i-000000002", corev1.ConditionTrue),
+		MockNode("i-999999999", corev1.ConditionTrue),
+	}
+	nodes[2].SetLabels(map[string]string{InstanceMgrLifecycleLabel: v1alpha1.LifecycleStateMixed})
+
+	nodeList := &corev1.NodeList{}
+	for _, node := range nodes {
+		_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		nodeList.Items = append(nodeList.Items, *node)
+	}
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			AutoScalingGroupName: aws.String("some-scaling-group"),
+			Instances:            MockScalingInstances(3, 0),
+		},
+		ClusterNodes: nodeList,
+	})
+
+	getLabel := func(name string) string {
+		node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return node.GetLabels()[InstanceMgrLifecycleLabel]
+	}
+
+	// nodes are not labeled when the lifecycle label is suppressed
+	status.SetLifecycle(v1alpha1.LifecycleStateMixed)
+	ig.SetAnnotations(map[string]string{OverrideDefaultLabelsAnnotation: InstanceMgrLifecycleLabel})
+	g.Expect(ctx.UpdateLifecycleLabels()).To(gomega.Succeed())
+	g.Expect(getLabel("node-i-000000000")).To(gomega.BeEmpty())
+
+	// nodes of mixed groups are not labeled unless instance lifecycle labels are enabled
+	ig.SetAnnotations(map[string]string{})
+	g.Expect(ctx.UpdateLifecycleLabels()).To(gomega.Succeed())
+	g.Expect(getLabel("node-i-000000000")).To(gomega.BeEmpty())
+	g.Expect(getLabel("node-i-000000002")).To(gomega.Equal(v1alpha1.LifecycleStateMixed))
+
+	// nodes of mixed groups are labeled with the lifecycle of their instance
+	ig.SetAnnotations(map[string]string{InstanceLifecycleLabelsAnnotation: "true"})
+	g.Expect(ctx.UpdateLifecycleLabels()).To(gomega.Succeed())
+	g.Expect(getLabel("node-i-000000000")).To(gomega.Equal(v1alpha1.LifecycleStateSpot))
+	g.Expect(getLabel("node-i-000000001")).To(gomega.Equal(v1alpha1.LifecycleStateNormal))
+	g.Expect(getLabel("node-i-000000002")).To(gomega.Equal(v1alpha1.LifecycleStateSpot))
+	g.Expect(getLabel("node-i-999999999")).To(gomega.BeEmpty())
+
+	// labeled nodes of uniform groups are not described
+	ec2Mock.DescribeInstancesErr = errors.New("some-error")
+	for i := range nodeList.Items {
+		nodeList.Items[i].SetLabels(map[string]string{InstanceMgrLifecycleLabel: v1alpha1.LifecycleStateSpot})
+	}
+	status.SetLifecycle(v1alpha1.LifecycleStateSpot)
+	g.Expect(ctx.UpdateLifecycleLabels()).To(gomega.Succeed())
+
+	// nodes of mixed groups which are not labeled yet fail to be labeled when their instances cannot be described
+	status.SetLifecycle(v1alpha1.LifecycleStateMixed)
+	nodeList.Items[1].SetLabels(map[string]string{})
+	g.Expect(ctx.UpdateLifecycleLabels()).NotTo(gomega.Succeed())
+}
+
+func TestUpdateImageLabels(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	config.Image = "ami-222222222"
+
+	ec2Mock.Instances = []*ec2.Instance{
+		{InstanceId: aws.String("i-000000000"), ImageId: aws.String("ami-111111111")},
+		{InstanceId: aws.String("i-000000001"), ImageId: aws.String("ami-111111111")},
+		{InstanceId: aws.String("i-000000002"), ImageId: aws.String("ami-222222222")},
+		{InstanceId: aws.String("i-000000003"), ImageId: aws.String("ami-222222222")},
+	}
+
+	// nodes pending rotation are labeled with the image of their instance, a node without the label is labeled and
+	// nodes of other instance groups are ignored
+	nodes := []*corev1.Node{
+		MockNode("i-000000000", corev1.ConditionTrue),
+		MockNode("i-000000001", corev1.ConditionTrue),
+		MockNode("i-000000002", corev1.ConditionTrue),
+		MockNode("i-999999999", corev1.ConditionTrue),
+	}
+	nodes[0].SetLabels(map[string]string{InstanceMgrImageLabel: "ami-222222222"})
+	nodes[1].SetLabels(map[string]string{InstanceMgrImageLabel: "ami-000000000"})
+
+	nodeList := &corev1.NodeList{}
+	for _, node := range nodes {
+		_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		nodeList.Items = append(nodeList.Items, *node)
+	}
+
+	scalingGroup := &autoscaling.Group{
+		AutoScalingGroupName: aws.String("some-scaling-group"),
+		Instances:            MockScalingInstances(3, 0),
+	}
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: scalingGroup,
+		ClusterNodes: nodeList,
+	})
+
+	getLabel := func(name string) string {
+		node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return node.GetLabels()[InstanceMgrImageLabel]
+	}
+
+	// nodes are not labeled when the image label is suppressed
+	ig.SetAnnotations(map[string]string{OverrideDefaultLabelsAnnotation: InstanceMgrImageLabel})
+	g.Expect(ctx.UpdateImageLabels()).To(gomega.Succeed())
+	g.Expect(getLabel("node-i-000000001")).To(gomega.Equal("ami-000000000"))
+
+	ig.SetAnnotations(map[string]string{})
+	g.Expect(ctx.UpdateImageLabels()).To(gomega.Succeed())
+	// nodes labeled with the instance group's image are not described
+	g.Expect(getLabel("node-i-000000000")).To(gomega.Equal("ami-222222222"))
+	g.Expect(getLabel("node-i-000000001")).To(gomega.Equal("ami-111111111"))
+	g.Expect(getLabel("node-i-000000002")).To(gomega.Equal("ami-222222222"))
+	g.Expect(getLabel("node-i-999999999")).To(gomega.BeEmpty())
+
+	// the node of a replacement instance is labeled with its image
+	replacement := MockNode("i-000000003", corev1.ConditionTrue)
+	_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), replacement, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	nodeList.Items[1] = *replacement
+	scalingGroup.Instances[1].InstanceId = aws.String("i-000000003")
+	g.Expect(ctx.UpdateImageLabels()).To(gomega.Succeed())
+	g.Expect(getLabel("node-i-000000003")).To(gomega.Equal("ami-222222222"))
+
+	ec2Mock.DescribeInstancesErr = errors.New("some-error")
+	nodeList.Items[2].SetLabels(map[string]string{})
+	g.Expect(ctx.UpdateImageLabels()).NotTo(gomega.Succeed())
+}
+
+func TestUpdateInstanceTagLabels(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	tags := func(pairs ...string) []*ec2.Tag {
+		tags := make([]*ec2.Tag, 0)
+		for i := 0; i < len(pairs); i += 2 {
+			tags = append(tags, &ec2.Tag{Key: aws.String(pairs[i]), Value: aws.String(pairs[i+1])})
+		}
+		return tags
+	}
+	ec2Mock.Instances = []*ec2.Instance{
+		{InstanceId: aws.String("i-000000000"), Tags: tags("team", "payments", "cost-center", "cc-1234", "Name", "node")},
+		{InstanceId: aws.String("i-000000001"), Tags: tags("team", "search")},
+		{InstanceId: aws.String("i-000000002"), Tags: tags("team", "not a label value", "cost-center", "cc-5678")},
+		{InstanceId: aws.String("i-999999999"), Tags: tags("team", "other")},
+	}
+
+	// nodes of other instance groups are ignored
+	nodes := []*corev1.Node{
+		MockNode("i-000000000", corev1.ConditionTrue),
+		MockNode("i-000000001", corev1.ConditionTrue),
+		MockNode("i-000000002", corev1.ConditionTrue),
+		MockNode("i-999999999", corev1.ConditionTrue),
+	}
+	nodeList := &corev1.NodeList{}
+	for _, node := range nodes {
+		_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		nodeList.Items = append(nodeList.Items, *node)
+	}
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			AutoScalingGroupName: aws.String("some-scaling-group"),
+			Instances:            MockScalingInstances(3, 0),
+		},
+		ClusterNodes: nodeList,
+	})
+
+	getLabels := func(name string) map[string]string {
+		node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return node.GetLabels()
+	}
+
+	// nodes are not labeled when no instance tags are mapped
+	g.Expect(ctx.UpdateInstanceTagLabels()).To(gomega.Succeed())
+	g.Expect(getLabels("node-i-000000000")).NotTo(gomega.HaveKey("example.com/team"))
+
+	// mapped tags are labeled, tags which are not mapped or have an invalid label value are not
+	config.InstanceTagLabels = map[string]string{
+		"team":        "example.com/team",
+		"cost-center": "example.com/cost-center",
+	}
+	g.Expect(ctx.UpdateInstanceTagLabels()).To(gomega.Succeed())
+	g.Expect(getLabels("node-i-000000000")).To(gomega.Equal(map[string]string{"example.com/team": "payments", "example.com/cost-center": "cc-1234"}))
+	g.Expect(getLabels("node-i-000000001")).To(gomega.Equal(map[string]string{"example.com/team": "search"}))
+	g.Expect(getLabels("node-i-000000002")).To(gomega.Equal(map[string]string{"example.com/cost-center": "cc-5678"}))
+	g.Expect(getLabels("node-i-999999999")).To(gomega.BeEmpty())
+
+	// instances are described one at a time, so that instances without a mapped tag are cached per instance
+	g.Expect(ec2Mock.DescribeTagsCallCount).To(gomega.Equal(uint(3)))
+
+	// nodes which have every mapped label are not described
+	nodeList.Items[0].SetLabels(map[string]string{"example.com/team": "payments", "example.com/cost-center": "cc-1234"})
+	ec2Mock.DescribeTagsErr = errors.New("some-error")
+	nodeList.Items[1].SetLabels(map[string]string{"example.com/team": "search", "example.com/cost-center": "cc-0000"})
+	nodeList.Items[2].SetLabels(map[string]string{"example.com/team": "payments", "example.com/cost-center": "cc-5678"})
+	g.Expect(ctx.UpdateInstanceTagLabels()).To(gomega.Succeed())
+
+	// nodes which are missing a mapped label are described again
+	nodeList.Items[2].SetLabels(map[string]string{"example.com/cost-center": "cc-5678"})
+	g.Expect(ctx.UpdateInstanceTagLabels()).NotTo(gomega.Succeed())
+}
+
+func TestCordonUnhealthyNodes(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	withCondition := func(node *corev1.Node, conditionType string, status corev1.ConditionStatus) *corev1.Node {
+		node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{Type: corev1.NodeConditionType(conditionType), Status: status})
+		return node
+	}
+
+	// nodes of other instance groups are ignored
+	nodes := []*corev1.Node{
+		withCondition(MockNode("i-000000000", corev1.ConditionTrue), "KernelDeadlock", corev1.ConditionTrue),
+		withCondition(MockNode("i-000000001", corev1.ConditionTrue), "KernelDeadlock", corev1.ConditionFalse),
+		withCondition(MockNode("i-000000002", corev1.ConditionTrue), "FrequentContainerdRestart", corev1.ConditionTrue),
+		withCondition(MockNode("i-999999999", corev1.ConditionTrue), "KernelDeadlock", corev1.ConditionTrue),
+	}
+	nodeList := &corev1.NodeList{}
+	for _, node := range nodes {
+		_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		nodeList.Items = append(nodeList.Items, *node)
+	}
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: &autoscaling.Group{
+			AutoScalingGroupName: aws.String("some-scaling-group"),
+			Instances:            MockScalingInstances(3, 0),
+		},
+		ClusterNodes: nodeList,
+	})
+
+	isCordoned := func(name string) bool {
+		node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return node.Spec.Unschedulable
+	}
+	cordonEvents := func() int {
+		events, err := k.Kubernetes.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		var count int
+		for _, e := range events.Items {
+			if e.Reason == string(kubeprovider.NodeCordonedUnhealthyEvent) {
+				count++
+			}
+		}
+		return count
+	}
+
+	// nodes are not cordoned when no conditions are mapped
+	g.Expect(ctx.CordonUnhealthyNodes()).To(gomega.Succeed())
+	g.Expect(isCordoned("node-i-000000000")).To(gomega.BeFalse())
+
+	// only nodes of the instance group which report a mapped condition are cordoned
+	config.UnhealthyNodeConditions = map[string]string{"KernelDeadlock": v1alpha1.UnhealthyNodeActionCordon}
+	g.Expect(ctx.CordonUnhealthyNodes()).To(gomega.Succeed())
+	g.Expect(isCordoned("node-i-000000000")).To(gomega.BeTrue())
+	g.Expect(isCordoned("node-i-000000001")).To(gomega.BeFalse())
+	g.Expect(isCordoned("node-i-000000002")).To(gomega.BeFalse())
+	g.Expect(isCordoned("node-i-999999999")).To(gomega.BeFalse())
+	g.Expect(cordonEvents()).To(gomega.Equal(1))
+
+	// nodes which are already cordoned are not cordoned again
+	nodeList.Items[0].Spec.Unschedulable = true
+	g.Expect(ctx.CordonUnhealthyNodes()).To(gomega.Succeed())
+	g.Expect(cordonEvents()).To(gomega.Equal(1))
+
+	// cordoned nodes are annotated with their condition and replaced through the upgrade strategy
+	node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), "node-i-000000000", metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(node.GetAnnotations()).To(gomega.HaveKeyWithValue(kubeprovider.UnhealthyCordonedAnnotation, "KernelDeadlock"))
+	nodeList.Items[0].SetAnnotations(node.GetAnnotations())
+	cordoned := kubeprovider.GetUnhealthyCordonedInstances([]string{"i-000000000", "i-000000001", "i-000000002"}, nodeList)
+	g.Expect(cordoned).To(gomega.ConsistOf("i-000000000"))
+	ctx.GetDiscoveredState().SetUnhealthyCordonedInstances(cordoned)
+	g.Expect(ctx.getReplacedInstances()).To(gomega.ConsistOf("i-000000000"))
+
+	// cordoned nodes stay cordoned once their condition clears
+	nodeList.Items[0].Status.Conditions[1].Status = corev1.ConditionFalse
+	g.Expect(ctx.CordonUnhealthyNodes()).To(gomega.Succeed())
+	g.Expect(isCordoned("node-i-000000000")).To(gomega.BeTrue())
+
+	// no more nodes are cordoned than the upgrade strategy allows to be unavailable
+	config.UnhealthyNodeConditions["FrequentContainerdRestart"] = v1alpha1.UnhealthyNodeActionCordon
+	g.Expect(ctx.CordonUnhealthyNodes()).To(gomega.Succeed())
+	g.Expect(isCordoned("node-i-000000002")).To(gomega.BeFalse())
+	g.Expect(cordonEvents()).To(gomega.Equal(1))
+
+	maxUnavailable := intstr.FromInt(2)
+	ig.SetUpgradeStrategy(MockAwsRollingUpdateStrategy(&maxUnavailable))
+	g.Expect(ctx.CordonUnhealthyNodes()).To(gomega.Succeed())
+	g.Expect(isCordoned("node-i-000000002")).To(gomega.BeTrue())
+	g.Expect(cordonEvents()).To(gomega.Equal(2))
+}
+
+func TestUpdateClusterAutoscalerPriority(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		OwnedScalingGroups: []*autoscaling.Group{
+			MockScalingGroup("my-scaling-group-us-west-2a", false),
+			MockScalingGroup("my-scaling-group-us-west-2b", false),
+		},
+	})
+
+	getPriorities := func() string {
+		cm, err := k.Kubernetes.CoreV1().ConfigMaps(common.PriorityExpanderConfigMapNamespace).Get(context.Background(), common.PriorityExpanderConfigMapName, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			return ""
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return cm.Data[common.PriorityExpanderPrioritiesKey]
+	}
+
+	// the configmap is not created when no priority is configured
+	g.Expect(ctx.UpdateClusterAutoscalerPriority()).To(gomega.Succeed())
+	g.Expect(getPriorities()).To(gomega.BeEmpty())
+	g.Expect(status.GetClusterAutoscalerPriority()).To(gomega.BeNil())
+
+	// every scaling group of the instance group is listed under its priority
+	config.ClusterAutoscalerPriority = aws.Int64(50)
+	g.Expect(ctx.UpdateClusterAutoscalerPriority()).To(gomega.Succeed())
+	g.Expect(getPriorities()).To(gomega.Equal("50:\n  - \"^my-scaling-group-us-west-2a$\"\n  - \"^my-scaling-group-us-west-2b$\"\n"))
+	g.Expect(status.GetClusterAutoscalerPriority()).To(gomega.Equal(aws.Int64(50)))
+
+	// a changed priority moves the scaling groups
+	config.ClusterAutoscalerPriority = aws.Int64(10)
+	g.Expect(ctx.UpdateClusterAutoscalerPriority()).To(gomega.Succeed())
+	g.Expect(getPriorities()).To(gomega.Equal("10:\n  - \"^my-scaling-group-us-west-2a$\"\n  - \"^my-scaling-group-us-west-2b$\"\n"))
+	g.Expect(status.GetClusterAutoscalerPriority()).To(gomega.Equal(aws.Int64(10)))
+
+	// scaling groups which no longer exist are removed
+	ctx.GetDiscoveredState().OwnedScalingGroups = []*autoscaling.Group{
+		MockScalingGroup("my-scaling-group-us-west-2a", false),
+		MockScalingGroup("my-scaling-group-us-west-2c", false),
+	}
+	g.Expect(ctx.UpdateClusterAutoscalerPriority()).To(gomega.Succeed())
+	g.Expect(getPriorities()).To(gomega.Equal("10:\n  - \"^my-scaling-group-us-west-2a$\"\n  - \"^my-scaling-group-us-west-2c$\"\n"))
+	g.Expect(status.GetClusterAutoscalerPriorityScalingGroups()).To(gomega.ConsistOf("my-scaling-group-us-west-2a", "my-scaling-group-us-west-2c"))
+
+	// the scaling groups are removed once the priority is no longer configured, including those which were deleted
+	ctx.GetDiscoveredState().OwnedScalingGroups = []*autoscaling.Group{
+		MockScalingGroup("my-scaling-group-us-west-2a", false),
+	}
+	config.ClusterAutoscalerPriority = nil
+	g.Expect(ctx.UpdateClusterAutoscalerPriority()).To(gomega.Succeed())
+	g.Expect(getPriorities()).To(gomega.BeEmpty())
+	g.Expect(status.GetClusterAutoscalerPriority()).To(gomega.BeNil())
+	g.Expect(status.GetClusterAutoscalerPriorityScalingGroups()).To(gomega.BeEmpty())
+}
+
+func TestUpdateWithLatestAmiID(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	testLatestAmiID := "ami-12345678"
+	testCustomAmi := "ami-98765432"
+	testBottlerocketAmi := "ami-56789101"
+	testWindowsAmi := "ami-56789201"
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName: aws.String("some-scaling-group"),
+		DesiredCapacity:      aws.Int64(1),
+		Instances: []*autoscaling.Instance{
+			{
+				InstanceId:              aws.String("i-1234"),
+				LaunchConfigurationName: aws.String("some-launch-config"),
+			},
+		},
+	}
+	asgMock.AutoScalingGroups = []*autoscaling.Group{mockScalingGroup}
+
+	// skip role creation
+	ig.GetEKSConfiguration().SetInstanceProfileName("some-profile")
+	ig.GetEKSConfiguration().SetRoleName("some-role")
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+
+	// Setup Latest AMI
+	ssmMock.parameterMap = map[string]string{
+		"/aws/service/eks/optimized-ami/1.18/amazon-linux-2/recommended/image_id":                      testLatestAmiID,
+		"/aws/service/eks/optimized-ami/1.18/amazon-linux-2/amazon-eks-node-1.18-v20220226/image_id":   testCustomAmi,
+		"/aws/service/bottlerocket/aws-k8s-1.18/x86_64/1.6.1/image_id":                                 testBottlerocketAmi,
+		"/aws/service/ami-windows-latest/Windows_Server-2019-English-Core-EKS_Optimized-1.18/image_id": testWindowsAmi,
+	}
+	ec2Mock.InstanceTypes = []*ec2.InstanceTypeInfo{
+		&ec2.InstanceTypeInfo{
+			InstanceType: aws.String("m5.large"),
+			ProcessorInfo: &ec2.ProcessorInfo{
+				SupportedArchitectures: []*string{aws.String("x86_64")},
+			},
+		},
+	}
+
+	testAmis := []amiTest{
+		{
+			igImage:     "latest",
+			expectedAmi: testLatestAmiID,
+			osFamily:    "amazonlinux2",
+		},
+		{
+			igImage:     "ssm://amazon-eks-node-1.18-v20220226",
+			expectedAmi: testCustomAmi,
+			osFamily:    "amazonlinux2",
+		},
+		{
+			igImage:     "ssm://1.6.1",
+			expectedAmi: testBottlerocketAmi,
+			osFamily:    "bottlerocket",
+		},
+		{
+			igImage:     "ssm://latest",
+			expectedAmi: testWindowsAmi,
+			osFamily:    "windows",
+		},
+	}
+
+	for _, tc := range testAmis {
+		ig.GetEKSConfiguration().Image = tc.igImage
+		ig.Annotations[OsFamilyAnnotation] = tc.osFamily
+		err := ctx.CloudDiscovery()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup: mockScalingGroup,
+			ScalingConfiguration: &scaling.LaunchConfiguration{
+				AwsWorker: w,
+			},
+			InstanceProfile: &iam.InstanceProfile{
+				Arn: aws.String("some-instance-arn"),
+			},
+			ClusterNodes: nil,
+			Cluster:      nil,
+			InstanceTypeInfo: []*ec2.InstanceTypeInfo{
+				{
+					InstanceType: aws.String("m5.large"),
+					ProcessorInfo: &ec2.ProcessorInfo{
+						SupportedArchitectures: []*string{aws.String("x86_64")},
+					},
+				},
+			},
+		})
+
+		err = ctx.Update()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(ctx.GetInstanceGroup().Spec.EKSSpec.EKSConfiguration.Image).To(gomega.Equal(tc.expectedAmi))
+	}
+
+}
+
+func TestUpdateWithPinnedLaunchTemplateVersion(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		spec    = ig.GetEKSSpec()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	spec.Type = v1alpha1.LaunchTemplate
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName: aws.String("some-scaling-group"),
+		DesiredCapacity:      aws.Int64(1),
+		LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+			LaunchTemplateName: aws.String("some-launch-template"),
+			Version:            aws.String("$Latest"),
+		},
+		Instances: []*autoscaling.Instance{
+			{
+				InstanceId: aws.String("i-1234"),
+				LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+					LaunchTemplateName: aws.String("some-launch-template"),
+					Version:            aws.String("2"),
+				},
+			},
+		},
+	}
+	asgMock.AutoScalingGroups = []*autoscaling.Group{mockScalingGroup}
+
+	mockVersion := func(version int64, image string) *ec2.LaunchTemplateVersion {
+		return &ec2.LaunchTemplateVersion{
+			VersionNumber: aws.Int64(version),
+			LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+				ImageId: aws.String(image),
+			},
+		}
+	}
+	versions := []*ec2.LaunchTemplateVersion{mockVersion(1, "ami-1111"), mockVersion(2, "ami-2222")}
+
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup: mockScalingGroup,
+		ScalingConfiguration: &scaling.LaunchTemplate{
+			AwsWorker: w,
+			TargetResource: &ec2.LaunchTemplate{
+				LaunchTemplateId:   aws.String("lt-1234"),
+				LaunchTemplateName: aws.String("some-launch-template"),
+			},
+			TargetVersions: versions,
+			LatestVersion:  versions[1],
+		},
+		InstanceProfile: &iam.InstanceProfile{
+			Arn: aws.String("some-instance-arn"),
+		},
+		Cluster: MockEksCluster("1.15"),
+	})
+
+	// pinning a previous version rolls the scaling group back without creating a new version
+	ig.SetAnnotations(map[string]string{LaunchTemplateVersionPinAnnotation: "1"})
+	err := ctx.Update()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ec2Mock.CreateLaunchTemplateVersionCallCount).To(gomega.Equal(uint(0)))
+	g.Expect(asgMock.UpdateAutoScalingGroupInputs).NotTo(gomega.BeEmpty())
+	input := asgMock.UpdateAutoScalingGroupInputs[len(asgMock.UpdateAutoScalingGroupInputs)-1]
+	g.Expect(aws.StringValue(input.LaunchTemplate.Version)).To(gomega.Equal("1"))
+	g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileModifying))
+
+	ctx.UpdateScalingConfigurationStatus()
+	status := ig.GetStatus()
+	g.Expect(status.GetActiveLaunchTemplateVersion()).To(gomega.Equal("1"))
+	g.Expect(status.GetActiveImageID()).To(gomega.Equal("ami-1111"))
+
+	// versions which do not exist or were pruned cannot be pinned
+	ig.SetAnnotations(map[string]string{LaunchTemplateVersionPinAnnotation: "5"})
+	err = ctx.Update()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("does not exist or was pruned"))
+
+	ig.SetAnnotations(map[string]string{LaunchTemplateVersionPinAnnotation: "latest"})
+	err = ctx.Update()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("must be a launch template version number"))
+
+	// pinning is only supported for launch templates
+	spec.Type = v1alpha1.LaunchConfiguration
+	ig.SetAnnotations(map[string]string{LaunchTemplateVersionPinAnnotation: "1"})
+	g.Expect(ctx.ValidateLaunchTemplateVersionPin()).To(gomega.HaveOccurred())
+}
+
+func TestRetireZoneScalingGroup(t *testing.T) {
+	var (
+		g             = gomega.NewGomegaWithT(t)
+		k             = MockKubernetesClientSet()
+		ig            = MockInstanceGroup()
+		configuration = ig.GetEKSConfiguration()
+		asgMock       = NewAutoScalingMocker()
+		iamMock       = NewIamMocker()
+		eksMock       = NewEksMocker()
+		ec2Mock       = NewEc2Mocker()
+		ssmMock       = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	state.Publisher = kubeprovider.EventPublisher{
+		Client: k.Kubernetes,
+	}
+
+	// shards are not dropped when the zone of a subnet cannot be resolved
+	configuration.ZoneSharding = true
+	configuration.SetSubnets([]string{"subnet-1", "subnet-2"})
+	state.SetSubnetZones(map[string]string{"subnet-1": "us-west-2a"})
+	_, err := ctx.GetScalingGroupShards()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	asg := MockScalingGroup("asg-us-west-2b", false)
+	asg.Instances = MockScalingInstances(0, 3)
+	asg.DesiredCapacity = aws.Int64(3)
+	for _, instance := range asg.Instances {
+		instance.LifecycleState = aws.String(autoscaling.LifecycleStateInService)
+	}
+	node := MockNode("i-100000000", corev1.ConditionTrue)
+	state.SetClusterNodes(&corev1.NodeList{Items: []corev1.Node{*node}})
+	_, err = k.Kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// capacity is pinned to the instances and one node is drained before its instance is removed
+	g.Expect(ctx.retireZoneScalingGroup("us-west-2b", asg)).To(gomega.Succeed())
+	g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+	g.Expect(aws.Int64Value(asgMock.UpdateAutoScalingGroupInputs[0].MinSize)).To(gomega.BeZero())
+	g.Expect(aws.Int64Value(asgMock.UpdateAutoScalingGroupInputs[0].MaxSize)).To(gomega.Equal(int64(3)))
+	g.Expect(aws.Int64Value(asgMock.UpdateAutoScalingGroupInputs[0].DesiredCapacity)).To(gomega.Equal(int64(3)))
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.Equal([]string{"i-100000000"}))
+	drained, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), node.GetName(), metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(drained.Spec.Unschedulable).To(gomega.BeTrue())
+	g.Expect(asgMock.DeleteAutoScalingGroupCallCount).To(gomega.BeZero())
+
+	// no further instances are removed while maxUnavailable instances are terminating
+	asgMock.TerminatedInstanceIds = nil
+	asg.Instances[0].LifecycleState = aws.String(autoscaling.LifecycleStateTerminating)
+	g.Expect(ctx.retireZoneScalingGroup("us-west-2b", asg)).To(gomega.Succeed())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.BeEmpty())
+
+	// a larger maxUnavailable removes more instances at a time
+	ig.GetUpgradeStrategy().SetRollingUpdateType(&v1alpha1.RollingUpdateStrategy{
+		MaxUnavailable: &intstr.IntOrString{Type: intstr.Int, IntVal: 3},
+	})
+	g.Expect(ctx.retireZoneScalingGroup("us-west-2b", asg)).To(gomega.Succeed())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.Equal([]string{"i-100000001", "i-100000002"}))
+
+	// the scaling group is deleted once it is empty
+	asg.Instances = nil
+	g.Expect(ctx.retireZoneScalingGroup("us-west-2b", asg)).To(gomega.Succeed())
+	g.Expect(asgMock.DeleteAutoScalingGroupCallCount).To(gomega.Equal(uint(1)))
 }
@@ -17,6 +17,7 @@ package eks
 
 import (
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -24,14 +25,18 @@ import (
 	"github.com/keikoproj/instance-manager/controllers/common"
 	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
 	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func (ctx *EksInstanceGroupContext) UpgradeNodes() error {
 	var (
 		instanceGroup     = ctx.GetInstanceGroup()
+		status            = instanceGroup.GetStatus()
 		strategy          = ctx.GetUpgradeStrategy()
 		state             = ctx.GetDiscoveredState()
 		scalingGroup      = state.GetScalingGroup()
@@ -39,6 +44,26 @@ func (ctx *EksInstanceGroupContext) UpgradeNodes() error {
 		strategyType      = strings.ToLower(strategy.GetType())
 	)
 
+	// node replacement is deferred until the next maintenance window, ready conditions are still updated
+	if ctx.WaitForMaintenanceWindow() {
+		ctx.UpdateNodeReadyCondition()
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.WaitingForMaintenanceWindow, corev1.ConditionTrue))
+		return nil
+	}
+	if status.GetWaitingForMaintenanceWindowCondition() == corev1.ConditionTrue {
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.WaitingForMaintenanceWindow, corev1.ConditionFalse))
+	}
+
+	// node replacement is blocked until the pre-rotation hook approves it
+	if !ctx.NotifyPreRotation() {
+		ctx.UpdateNodeReadyCondition()
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.RotationDenied, corev1.ConditionTrue))
+		return nil
+	}
+	if status.GetRotationDeniedCondition() == corev1.ConditionTrue {
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.RotationDenied, corev1.ConditionFalse))
+	}
+
 	rotated, err := ctx.rotateWarmPool()
 	if err != nil {
 		ctx.Log.Info("failed to rotate warm pool", "error", err)
@@ -66,6 +91,18 @@ func (ctx *EksInstanceGroupContext) UpgradeNodes() error {
 	case kubeprovider.RollingUpdateStrategyName:
 		req := ctx.NewRollingUpdateRequest()
 		ok, err := kubeprovider.ProcessRollingUpgradeStrategy(req)
+		// rotation is deferred until the evicted pods can be scheduled on the remaining nodes
+		if unschedulable, deferred := err.(*kubeprovider.UnschedulablePodsError); deferred {
+			if status.GetRotationUnschedulableCondition() != corev1.ConditionTrue {
+				state.Publisher.Publish(kubeprovider.RotationUnschedulableEvent, "instancegroup", instanceGroup.NamespacedName(), "nodes", strings.Join(unschedulable.Nodes, ","), "pods", strings.Join(unschedulable.Pods, ","))
+			}
+			ctx.Log.Info("deferring rotation of nodes with unschedulable pods", "instancegroup", instanceGroup.NamespacedName(), "nodes", unschedulable.Nodes, "pods", unschedulable.Pods)
+			status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.RotationUnschedulable, corev1.ConditionTrue))
+			return nil
+		}
+		if status.GetRotationUnschedulableCondition() == corev1.ConditionTrue {
+			status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.RotationUnschedulable, corev1.ConditionFalse))
+		}
 		if err != nil {
 			state.Publisher.Publish(kubeprovider.InstanceGroupUpgradeFailedEvent, "instancegroup", instanceGroup.NamespacedName(), "type", kubeprovider.RollingUpdateStrategyName, "error", err)
 			ctx.SetState(v1alpha1.ReconcileErr)
@@ -75,10 +112,27 @@ func (ctx *EksInstanceGroupContext) UpgradeNodes() error {
 			break
 		}
 		return nil
+	case v1alpha1.BlueGreenStrategyName:
+		ok, err := ctx.ProcessBlueGreenStrategy()
+		if err != nil {
+			state.Publisher.Publish(kubeprovider.InstanceGroupUpgradeFailedEvent, "instancegroup", instanceGroup.NamespacedName(), "type", v1alpha1.BlueGreenStrategyName, "error", err.Error())
+			ctx.SetState(v1alpha1.ReconcileErr)
+			return errors.Wrap(err, "failed to process blue/green strategy")
+		}
+		// a rolled back rotation is not retried until the scaling configuration changes
+		if status.IsBlueGreenRolledBack() {
+			ctx.SetState(v1alpha1.ReconcileErr)
+			return nil
+		}
+		if ok {
+			break
+		}
+		return nil
 	default:
 		return errors.Errorf("'%v' is not an implemented upgrade type, will not process upgrade", strategy.GetType())
 	}
 	ctx.Log.Info("strategy processing completed", "instancegroup", instanceGroup.NamespacedName(), "strategy", strategy.GetType())
+	ctx.NotifyPostRotation()
 
 	if ctx.UpdateNodeReadyCondition() {
 		ctx.SetState(v1alpha1.ReconcileModified)
@@ -86,23 +140,497 @@ func (ctx *EksInstanceGroupContext) UpgradeNodes() error {
 	return nil
 }
 
+// ProcessBlueGreenStrategy advances a blue/green rotation by one phase and returns true once it completed. Green
+// instances are launched with the active scaling configuration in addition to the blue instances which are pending
+// rotation, once as many green nodes are ready the blue nodes are cordoned, drained and removed. Green instances whose
+// nodes do not become ready within the ready timeout are removed and the rotation is rolled back
+func (ctx *EksInstanceGroupContext) ProcessBlueGreenStrategy() (bool, error) {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		strategy      = instanceGroup.GetUpgradeStrategy().GetBlueGreenType()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		asgName       = aws.StringValue(scalingGroup.AutoScalingGroupName)
+		configuration = ctx.getActiveScalingConfiguration()
+		blueGreen     = status.GetBlueGreen()
+	)
+
+	if blueGreen != nil && blueGreen.Phase == v1alpha1.BlueGreenPhaseRolledBack {
+		if blueGreen.ScalingConfiguration == configuration {
+			ctx.Log.Info("blue/green rotation was rolled back, waiting for scaling configuration to change", "instancegroup", instanceGroup.NamespacedName(), "scalingconfig", configuration)
+			return false, nil
+		}
+		status.SetBlueGreen(nil)
+		status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.BlueGreenRolledBack, corev1.ConditionFalse))
+		blueGreen = nil
+	}
+
+	if blueGreen == nil {
+		blue := ctx.getPendingRotationInstances(scalingGroup)
+		if len(blue) == 0 {
+			return true, nil
+		}
+
+		var (
+			green   = int64(len(blue))
+			desired = aws.Int64Value(scalingGroup.DesiredCapacity) + green
+			maxSize = instanceGroup.GetEKSSpec().GetMaxSize() + green
+		)
+		if maxSize < desired {
+			maxSize = desired
+		}
+		if err := ctx.AwsWorker.UpdateScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(asgName),
+			MaxSize:              aws.Int64(maxSize),
+			DesiredCapacity:      aws.Int64(desired),
+		}); err != nil {
+			return false, errors.Wrap(err, "failed to launch green instances")
+		}
+
+		previous := make([]string, 0)
+		for _, instance := range scalingGroup.Instances {
+			previous = append(previous, aws.StringValue(instance.InstanceId))
+		}
+
+		status.SetBlueGreen(&v1alpha1.BlueGreenStatus{
+			Phase:                v1alpha1.BlueGreenPhaseProvisioning,
+			ScalingConfiguration: configuration,
+			BlueInstances:        blue,
+			PreviousInstances:    previous,
+			GreenCapacity:        len(blue),
+			StartTime:            metav1.Now(),
+		})
+		ctx.Log.Info("launching green instances", "instancegroup", instanceGroup.NamespacedName(), "scalingconfig", configuration, "blue", blue, "desired", desired)
+		return false, nil
+	}
+
+	var (
+		nodes          = state.GetClusterNodes()
+		blueInstances  = make([]string, 0)
+		greenInstances = make([]string, 0)
+	)
+	for _, instance := range scalingGroup.Instances {
+		instanceId := aws.StringValue(instance.InstanceId)
+		if common.ContainsEqualFold(blueGreen.BlueInstances, instanceId) {
+			blueInstances = append(blueInstances, instanceId)
+			continue
+		}
+		// instances which existed before the rotation started are neither blue nor green
+		if common.ContainsEqualFold(blueGreen.PreviousInstances, instanceId) {
+			continue
+		}
+		greenInstances = append(greenInstances, instanceId)
+	}
+	blueNodes := make(map[string]string)
+	if nodes != nil {
+		for _, node := range nodes.Items {
+			instanceId := common.GetLastElementBy(node.Spec.ProviderID, "/")
+			if common.ContainsEqualFold(blueInstances, instanceId) {
+				blueNodes[instanceId] = node.GetName()
+			}
+		}
+	}
+
+	switch blueGreen.Phase {
+	case v1alpha1.BlueGreenPhaseProvisioning:
+		ready := []string{}
+		if nodes != nil {
+			ready = kubeprovider.GetReadyNodesByInstance(greenInstances, nodes)
+		}
+		if len(ready) < blueGreen.GreenCapacity {
+			if timeout := strategy.GetReadyTimeout(); time.Since(blueGreen.StartTime.Time) > timeout {
+				return false, ctx.rollbackBlueGreen(greenInstances)
+			}
+			ctx.Log.Info("waiting for green nodes to be ready", "instancegroup", instanceGroup.NamespacedName(), "ready", len(ready), "desired", blueGreen.GreenCapacity)
+			return false, nil
+		}
+
+		// blue nodes are cordoned together so that pods drained from one are not scheduled on another
+		for _, nodeName := range blueNodes {
+			if err := ctx.KubernetesClient.CordonNode(nodeName); err != nil {
+				return false, err
+			}
+		}
+		blueGreen.Phase = v1alpha1.BlueGreenPhaseRetiring
+		ctx.Log.Info("green nodes are ready, retiring blue nodes", "instancegroup", instanceGroup.NamespacedName(), "blue", blueInstances)
+		return false, nil
+
+	case v1alpha1.BlueGreenPhaseRetiring:
+		if len(blueInstances) == 0 {
+			status.SetBlueGreen(nil)
+			ctx.Log.Info("blue/green rotation completed", "instancegroup", instanceGroup.NamespacedName(), "scalingconfig", blueGreen.ScalingConfiguration)
+			return true, nil
+		}
+
+		opts := &kubeprovider.DrainOptions{}
+		if drain := strategy.GetDrain(); drain != nil {
+			opts = &kubeprovider.DrainOptions{
+				EvictDaemonSets:  drain.GetEvictDaemonSets(),
+				Timeout:          drain.GetTimeout(),
+				Force:            drain.GetForce(),
+				PriorityOrdering: drain.GetPriorityOrdering(),
+			}
+		}
+
+		drained := make([]string, 0)
+		for _, instanceId := range blueInstances {
+			nodeName, ok := blueNodes[instanceId]
+			if !ok {
+				drained = append(drained, instanceId)
+				continue
+			}
+			ok, err := ctx.KubernetesClient.DrainNode(nodeName, opts)
+			if _, timedOut := err.(*kubeprovider.DrainTimeoutError); timedOut {
+				return false, err
+			}
+			if err != nil {
+				// drain failures are retryable
+				ctx.Log.Info("failed to drain blue node", "error", err, "instancegroup", instanceGroup.NamespacedName(), "node", nodeName)
+				continue
+			}
+			if ok {
+				drained = append(drained, instanceId)
+			}
+		}
+
+		if len(drained) == 0 {
+			ctx.Log.Info("waiting for blue nodes to drain", "instancegroup", instanceGroup.NamespacedName(), "blue", blueInstances)
+			return false, nil
+		}
+		if err := ctx.AwsWorker.RemoveScalingInstances(drained); err != nil {
+			return false, errors.Wrap(err, "failed to remove blue instances")
+		}
+		ctx.Log.Info("removed blue instances", "instancegroup", instanceGroup.NamespacedName(), "instances", drained)
+		return false, nil
+	}
+
+	return false, errors.Errorf("blue/green rotation is in unknown phase '%v'", blueGreen.Phase)
+}
+
+// rollbackBlueGreen removes the green instances of a blue/green rotation whose nodes did not become ready, the blue
+// nodes were not cordoned yet and keep running the previous configuration
+func (ctx *EksInstanceGroupContext) rollbackBlueGreen(greenInstances []string) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		blueGreen     = status.GetBlueGreen()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+		asgName       = aws.StringValue(scalingGroup.AutoScalingGroupName)
+	)
+
+	if err := ctx.AwsWorker.RemoveScalingInstances(greenInstances); err != nil {
+		return errors.Wrap(err, "failed to remove green instances")
+	}
+
+	// green capacity which was not launched yet is removed from the desired capacity
+	if pending := int64(blueGreen.GreenCapacity - len(greenInstances)); pending > 0 {
+		desired := aws.Int64Value(scalingGroup.DesiredCapacity) - int64(len(greenInstances)) - pending
+		if err := ctx.AwsWorker.UpdateScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(asgName),
+			DesiredCapacity:      aws.Int64(desired),
+		}); err != nil {
+			return errors.Wrap(err, "failed to remove pending green capacity")
+		}
+	}
+
+	blueGreen.Phase = v1alpha1.BlueGreenPhaseRolledBack
+	status.SetCondition(v1alpha1.NewInstanceGroupCondition(v1alpha1.BlueGreenRolledBack, corev1.ConditionTrue))
+	// the rotation did not complete, the pre-rotation hook is notified again once it is retried
+	status.SetRotationStarted(false)
+	state.Publisher.Publish(kubeprovider.BlueGreenRolledBackEvent, "instancegroup", instanceGroup.NamespacedName(), "scalingconfig", blueGreen.ScalingConfiguration, "instances", strings.Join(greenInstances, ","))
+	ctx.Log.Info("green nodes did not become ready, rolled back blue/green rotation", "instancegroup", instanceGroup.NamespacedName(), "scalingconfig", blueGreen.ScalingConfiguration, "green", greenInstances)
+	return nil
+}
+
+// getActiveScalingConfiguration returns the name of the scaling configuration new instances are launched with, launch
+// templates are suffixed with the version instances are launched with
+func (ctx *EksInstanceGroupContext) getActiveScalingConfiguration() string {
+	var (
+		state        = ctx.GetDiscoveredState()
+		scalingGroup = state.GetScalingGroup()
+		name         = awsprovider.GetScalingConfigName(scalingGroup)
+	)
+
+	if awsprovider.IsUsingLaunchConfiguration(scalingGroup) {
+		return name
+	}
+	launchTemplate := scaling.ConvertToLaunchTemplate(state.GetScalingConfiguration().Resource())
+	return name + ":" + ctx.GetActiveLaunchTemplateVersion(launchTemplate)
+}
+
+// WaitForMaintenanceWindow returns true if instances need to be rotated while the instance group is outside of its
+// maintenance windows
+func (ctx *EksInstanceGroupContext) WaitForMaintenanceWindow() bool {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		strategy      = ctx.GetUpgradeStrategy()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+	)
+
+	if len(strategy.GetMaintenanceWindows()) == 0 || strategy.InMaintenanceWindow(time.Now()) || scalingGroup == nil {
+		return false
+	}
+
+	pending := ctx.getPendingRotationInstances(scalingGroup)
+	if len(pending) == 0 {
+		return false
+	}
+
+	ctx.Log.Info("deferring rotation until maintenance window", "instancegroup", instanceGroup.NamespacedName(), "instances", pending)
+	return true
+}
+
+// getReplacedInstances returns the instances which are replaced regardless of their configuration, those whose
+// security groups drifted, then those which exceeded the node ttl, then those cordoned for unhealthy conditions
+func (ctx *EksInstanceGroupContext) getReplacedInstances() []string {
+	state := ctx.GetDiscoveredState()
+	replaced := make([]string, 0)
+	replaced = append(replaced, state.GetSecurityGroupDriftedInstances()...)
+	replaced = append(replaced, state.GetExpiredInstances()...)
+	return append(replaced, state.GetUnhealthyCordonedInstances()...)
+}
+
+// getPendingRotationInstances returns the instances which are to be rotated, either because their configuration drifted
+// or because they are replaced regardless of their configuration
+func (ctx *EksInstanceGroupContext) getPendingRotationInstances(scalingGroup *autoscaling.Group) []string {
+	pending := ctx.getDriftedInstances(scalingGroup.Instances)
+	for _, instanceId := range ctx.getReplacedInstances() {
+		if !common.ContainsEqualFold(pending, instanceId) {
+			pending = append(pending, instanceId)
+		}
+	}
+	return pending
+}
+
+// NotifyPreRotation submits a rotation to the pre-rotation hook before it begins and returns false while the hook blocks
+// the rotation, an approved rotation is not submitted again until it completes
+func (ctx *EksInstanceGroupContext) NotifyPreRotation() bool {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		state         = ctx.GetDiscoveredState()
+	)
+
+	if ctx.RotationNotifier == nil || status.GetRotationStarted() {
+		return true
+	}
+
+	if err := ctx.RotationNotifier.PreRotation(ctx.newRotationWebhookRequest()); err != nil {
+		if status.GetRotationDeniedCondition() != corev1.ConditionTrue {
+			state.Publisher.Publish(kubeprovider.RotationDeniedEvent, "instancegroup", instanceGroup.NamespacedName(), "error", err.Error())
+		}
+		ctx.Log.Info("rotation is blocked by pre-rotation hook", "error", err, "instancegroup", instanceGroup.NamespacedName())
+		return false
+	}
+
+	status.SetRotationStarted(true)
+	return true
+}
+
+// NotifyPostRotation notifies the post-rotation hook of a completed rotation, errors are logged since the rotation
+// cannot be undone
+func (ctx *EksInstanceGroupContext) NotifyPostRotation() {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+	)
+
+	if ctx.RotationNotifier == nil || !status.GetRotationStarted() {
+		return
+	}
+
+	if err := ctx.RotationNotifier.PostRotation(ctx.newRotationWebhookRequest()); err != nil {
+		ctx.Log.Error(err, "failed to notify post-rotation hook", "instancegroup", instanceGroup.NamespacedName())
+	}
+	status.SetRotationStarted(false)
+}
+
+func (ctx *EksInstanceGroupContext) newRotationWebhookRequest() *provisioners.RotationWebhookRequest {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		state         = ctx.GetDiscoveredState()
+		scalingGroup  = state.GetScalingGroup()
+	)
+
+	request := &provisioners.RotationWebhookRequest{
+		InstanceGroup: instanceGroup.GetName(),
+		Namespace:     instanceGroup.GetNamespace(),
+	}
+	if scalingGroup != nil {
+		request.DesiredCapacity = int(aws.Int64Value(scalingGroup.DesiredCapacity))
+		request.Instances = len(scalingGroup.Instances)
+		request.PendingInstances = len(ctx.getPendingRotationInstances(scalingGroup))
+	}
+	return request
+}
+
 func (ctx *EksInstanceGroupContext) BootstrapNodes() error {
 	var (
 		state         = ctx.GetDiscoveredState()
 		instanceGroup = ctx.GetInstanceGroup()
+		configuration = instanceGroup.GetEKSConfiguration()
 		osFamily      = ctx.GetOsFamily()
 		role          = state.GetRole()
 		roleARN       = aws.StringValue(role.Arn)
 	)
-	ctx.Log.Info("bootstrapping arn to aws-auth", "instancegroup", instanceGroup.NamespacedName(), "arn", roleARN)
 
 	// lock to guarantee Upsert and Remove cannot conflict when roles are shared between instancegroups
 	ctx.Lock()
 	defer ctx.Unlock()
 
+	if strings.EqualFold(configuration.GetNodeAuthentication(), v1alpha1.NodeAuthenticationAccessEntry) {
+		ctx.Log.Info("bootstrapping arn to access entry", "instancegroup", instanceGroup.NamespacedName(), "arn", roleARN)
+		return ctx.UpsertAccessEntry(roleARN)
+	}
+
+	ctx.Log.Info("bootstrapping arn to aws-auth", "instancegroup", instanceGroup.NamespacedName(), "arn", roleARN)
 	return common.UpsertAuthConfigMap(ctx.KubernetesClient.Kubernetes, []string{roleARN}, []string{osFamily})
 }
 
+// UpdateAuthMappings reconciles the additional aws-auth entries of the instance group, only entries owned by the
+// instance group are modified or removed
+func (ctx *EksInstanceGroupContext) UpdateAuthMappings() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+	)
+
+	if instanceGroup.GetEKSConfiguration().GetAuthMappings() == nil && status.GetAuthMappings() == nil {
+		return nil
+	}
+
+	roles, users := ctx.desiredAuthMappings()
+	return ctx.syncAuthMappings(roles, users)
+}
+
+// RemoveAuthMappings removes the additional aws-auth entries owned by the instance group, entries which exactly match
+// the instance group's mappings are removed even if their ownership was not recorded
+func (ctx *EksInstanceGroupContext) RemoveAuthMappings() error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		owned         common.OwnedAuthMappings
+	)
+
+	if instanceGroup.GetEKSConfiguration().GetAuthMappings() == nil && status.GetAuthMappings() == nil {
+		return nil
+	}
+
+	if current := status.GetAuthMappings(); current != nil {
+		owned = common.OwnedAuthMappings{Roles: current.Roles, Users: current.Users}
+	}
+	// ownership is recorded in status after the aws-auth configmap is written, entries which exactly match the
+	// instance group's mappings are adopted in case recording it failed
+	roles, users := ctx.desiredAuthMappings()
+	owned, err := common.AdoptAuthMappings(ctx.KubernetesClient.Kubernetes, roles, users, owned)
+	if err != nil {
+		return errors.Wrap(err, "failed to adopt aws-auth mappings")
+	}
+	if len(owned.Roles) == 0 && len(owned.Users) == 0 {
+		return nil
+	}
+	status.SetAuthMappings(&v1alpha1.AuthMappingsStatus{Roles: owned.Roles, Users: owned.Users})
+	return ctx.syncAuthMappings(nil, nil)
+}
+
+// desiredAuthMappings returns the aws-auth entries of the instance group's authMappings
+func (ctx *EksInstanceGroupContext) desiredAuthMappings() ([]common.RolesAuthMap, []common.UsersAuthMap) {
+	var (
+		mappings = ctx.GetInstanceGroup().GetEKSConfiguration().GetAuthMappings()
+		roles    = make([]common.RolesAuthMap, 0)
+		users    = make([]common.UsersAuthMap, 0)
+	)
+
+	if mappings == nil {
+		return roles, users
+	}
+	for _, role := range mappings.Roles {
+		roles = append(roles, common.RolesAuthMap{RoleARN: role.ARN, Username: role.Username, Groups: role.Groups})
+	}
+	for _, user := range mappings.Users {
+		users = append(users, common.UsersAuthMap{UserARN: user.ARN, Username: user.Username, Groups: user.Groups})
+	}
+	return roles, users
+}
+
+func (ctx *EksInstanceGroupContext) syncAuthMappings(roles []common.RolesAuthMap, users []common.UsersAuthMap) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		status        = instanceGroup.GetStatus()
+		owned         common.OwnedAuthMappings
+	)
+
+	if current := status.GetAuthMappings(); current != nil {
+		owned = common.OwnedAuthMappings{Roles: current.Roles, Users: current.Users}
+	}
+
+	owned, skipped, err := common.SyncAuthMappings(ctx.KubernetesClient.Kubernetes, roles, users, owned)
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		ctx.Log.Info("skipping aws-auth mappings of entries which are not owned by the instance group", "instancegroup", instanceGroup.NamespacedName(), "arns", skipped)
+	}
+
+	if len(owned.Roles) == 0 && len(owned.Users) == 0 {
+		status.SetAuthMappings(nil)
+		return nil
+	}
+	status.SetAuthMappings(&v1alpha1.AuthMappingsStatus{Roles: owned.Roles, Users: owned.Users})
+	return nil
+}
+
+// UpsertAccessEntry creates the access entry of the node role, access entries of the wrong type are replaced since the
+// type of an access entry cannot be updated, unless the role is shared with other instance groups which would replace it
+// back on each reconcile
+func (ctx *EksInstanceGroupContext) UpsertAccessEntry(roleARN string) error {
+	var (
+		instanceGroup = ctx.GetInstanceGroup()
+		clusterName   = instanceGroup.GetEKSConfiguration().GetClusterName()
+		entryType     = awsprovider.AccessEntryTypeLinux
+	)
+
+	if strings.EqualFold(ctx.GetOsFamily(), OsFamilyWindows) {
+		entryType = awsprovider.AccessEntryTypeWindows
+	}
+
+	entry, err := ctx.AwsWorker.DescribeAccessEntry(clusterName, roleARN)
+	if err != nil {
+		return errors.Wrap(err, "failed to describe access entry")
+	}
+
+	if entry != nil {
+		if strings.EqualFold(aws.StringValue(entry.Type), entryType) {
+			return nil
+		}
+		sharedGroups, err := ctx.getSharedRoleGroups(roleARN)
+		if err != nil {
+			return err
+		}
+		conflicts := make([]string, 0)
+		for _, name := range sharedGroups {
+			if name != instanceGroup.NamespacedName() {
+				conflicts = append(conflicts, name)
+			}
+		}
+		if len(conflicts) > 0 {
+			return errors.Errorf("access entry of role %v has type %v and is shared with instancegroups %v, linux and windows instancegroups using access entries cannot share a role", roleARN, aws.StringValue(entry.Type), strings.Join(conflicts, ","))
+		}
+		ctx.Log.Info("replacing access entry", "instancegroup", instanceGroup.NamespacedName(), "arn", roleARN, "type", aws.StringValue(entry.Type), "desired", entryType)
+		if err := ctx.AwsWorker.DeleteAccessEntry(clusterName, roleARN); err != nil {
+			return errors.Wrap(err, "failed to delete access entry")
+		}
+	}
+
+	if err := ctx.AwsWorker.CreateAccessEntry(clusterName, roleARN, entryType); err != nil {
+		return errors.Wrap(err, "failed to create access entry")
+	}
+	return nil
+}
+
 // rotateWarmPool checks for drifted instances and if there are any, it deletes the warm pool
 func (ctx *EksInstanceGroupContext) rotateWarmPool() (bool, error) {
 	var (
@@ -148,6 +676,7 @@ func (ctx *EksInstanceGroupContext) getDriftedInstances(instances []*autoscaling
 		scalingConfig   = state.GetScalingConfiguration()
 		scalingResource = scalingConfig.Resource()
 		scalingGroup    = state.GetScalingGroup()
+		ignoreCosmetic  = ctx.GetInstanceGroup().GetEKSConfiguration().IsCosmeticChangeRotationSkipped()
 	)
 
 	for _, instance := range instances {
@@ -178,14 +707,13 @@ func (ctx *EksInstanceGroupContext) getDriftedInstances(instances []*autoscaling
 			}
 
 			var (
-				config           = aws.StringValue(instance.LaunchTemplate.LaunchTemplateName)
-				version          = aws.StringValue(instance.LaunchTemplate.Version)
-				launchTemplate   = scaling.ConvertToLaunchTemplate(scalingResource)
-				activeConfig     = aws.StringValue(scalingGroup.LaunchTemplate.LaunchTemplateName)
-				activeVersionNum = aws.Int64Value(launchTemplate.LatestVersionNumber)
-				activeVersion    = common.Int64ToStr(activeVersionNum)
+				config         = aws.StringValue(instance.LaunchTemplate.LaunchTemplateName)
+				version        = aws.StringValue(instance.LaunchTemplate.Version)
+				launchTemplate = scaling.ConvertToLaunchTemplate(scalingResource)
+				activeConfig   = aws.StringValue(scalingGroup.LaunchTemplate.LaunchTemplateName)
+				activeVersion  = ctx.GetActiveLaunchTemplateVersion(launchTemplate)
 			)
-			if !strings.EqualFold(config, activeConfig) || !strings.EqualFold(version, activeVersion) {
+			if !strings.EqualFold(config, activeConfig) || !ctx.launchTemplateVersionCurrent(version, activeVersion, ignoreCosmetic) {
 				needsUpdate = append(needsUpdate, instanceId)
 			}
 		}
@@ -197,15 +725,14 @@ func (ctx *EksInstanceGroupContext) getDriftedInstances(instances []*autoscaling
 			}
 
 			var (
-				config           = aws.StringValue(instance.LaunchTemplate.LaunchTemplateName)
-				version          = aws.StringValue(instance.LaunchTemplate.Version)
-				launchTemplate   = scaling.ConvertToLaunchTemplate(scalingResource)
-				activeConfig     = aws.StringValue(scalingGroup.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification.LaunchTemplateName)
-				activeVersionNum = aws.Int64Value(launchTemplate.LatestVersionNumber)
-				activeVersion    = common.Int64ToStr(activeVersionNum)
+				config         = aws.StringValue(instance.LaunchTemplate.LaunchTemplateName)
+				version        = aws.StringValue(instance.LaunchTemplate.Version)
+				launchTemplate = scaling.ConvertToLaunchTemplate(scalingResource)
+				activeConfig   = aws.StringValue(scalingGroup.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification.LaunchTemplateName)
+				activeVersion  = ctx.GetActiveLaunchTemplateVersion(launchTemplate)
 			)
 
-			if !strings.EqualFold(config, activeConfig) || !strings.EqualFold(version, activeVersion) {
+			if !strings.EqualFold(config, activeConfig) || !ctx.launchTemplateVersionCurrent(version, activeVersion, ignoreCosmetic) {
 				needsUpdate = append(needsUpdate, instanceId)
 			}
 		}
@@ -215,6 +742,22 @@ func (ctx *EksInstanceGroupContext) getDriftedInstances(instances []*autoscaling
 	return needsUpdate
 }
 
+// launchTemplateVersionCurrent returns true if an instance running version does not need to be rotated to activeVersion,
+// when cosmetic changes are ignored versions which launch the same nodes are current
+func (ctx *EksInstanceGroupContext) launchTemplateVersionCurrent(version, activeVersion string, ignoreCosmetic bool) bool {
+	if strings.EqualFold(version, activeVersion) {
+		return true
+	}
+	if !ignoreCosmetic {
+		return false
+	}
+	lt, ok := ctx.GetDiscoveredState().GetScalingConfiguration().(*scaling.LaunchTemplate)
+	if !ok {
+		return false
+	}
+	return lt.NodeConfigurationEqual(version, activeVersion)
+}
+
 func (ctx *EksInstanceGroupContext) NewRollingUpdateRequest() *kubeprovider.RollingUpdateRequest {
 	var (
 		needsUpdate    []string
@@ -231,6 +774,14 @@ func (ctx *EksInstanceGroupContext) NewRollingUpdateRequest() *kubeprovider.Roll
 	// Get all Autoscaling Instances that needs update
 	needsUpdate = ctx.getDriftedInstances(scalingGroup.Instances)
 
+	// instances whose security groups drifted, instances which exceeded the node TTL, and then instances cordoned for
+	// unhealthy conditions are rotated after instances with a drifted scaling configuration
+	for _, instanceId := range ctx.getReplacedInstances() {
+		if !common.ContainsEqualFold(needsUpdate, instanceId) {
+			needsUpdate = append(needsUpdate, instanceId)
+		}
+	}
+
 	for _, instance := range scalingGroup.Instances {
 		var (
 			instanceId = aws.StringValue(instance.InstanceId)
@@ -239,6 +790,24 @@ func (ctx *EksInstanceGroupContext) NewRollingUpdateRequest() *kubeprovider.Roll
 		allInstances = append(allInstances, instanceId)
 	}
 
+	// protected instances are skipped until the annotation is removed or expires
+	if protected := state.GetProtectedInstances(); len(protected) > 0 {
+		targets := make([]string, 0)
+		skipped := make([]string, 0)
+		for _, instanceId := range needsUpdate {
+			if common.ContainsEqualFold(protected, instanceId) {
+				skipped = append(skipped, instanceId)
+				continue
+			}
+			targets = append(targets, instanceId)
+		}
+		if len(skipped) > 0 {
+			ctx.Log.Info("skipping rotation of protected instances", "instancegroup", instanceGroup.NamespacedName(), "instances", skipped)
+			state.Publisher.Publish(kubeprovider.NodesProtectedEvent, "instancegroup", instanceGroup.NamespacedName(), "instances", strings.Join(skipped, ","))
+		}
+		needsUpdate = targets
+	}
+
 	allCount := len(allInstances)
 
 	var unavailableInt int
@@ -252,13 +821,30 @@ func (ctx *EksInstanceGroupContext) NewRollingUpdateRequest() *kubeprovider.Roll
 		unavailableInt = 1
 	}
 
-	return &kubeprovider.RollingUpdateRequest{
-		AwsWorker:        ctx.AwsWorker,
-		ClusterNodes:     state.GetClusterNodes(),
-		MaxUnavailable:   unavailableInt,
-		DesiredCapacity:  desiredCount,
-		AllInstances:     allInstances,
-		UpdateTargets:    needsUpdate,
-		ScalingGroupName: asgName,
+	req := &kubeprovider.RollingUpdateRequest{
+		AwsWorker:          ctx.AwsWorker,
+		KubernetesClient:   ctx.KubernetesClient,
+		ClusterNodes:       state.GetClusterNodes(),
+		MaxUnavailable:     unavailableInt,
+		DesiredCapacity:    desiredCount,
+		AllInstances:       allInstances,
+		UpdateTargets:      needsUpdate,
+		ScalingGroupName:   asgName,
+		FailureDomainLabel: strategy.GetFailureDomainLabel(),
+		SchedulingCheck:    strategy.GetSchedulingCheck(),
+	}
+
+	if checks := instanceGroup.GetEKSConfiguration().GetReadinessChecks(); checks != nil {
+		req.ReadinessDaemonSets = checks.GetDaemonSets()
+	}
+
+	if drain := strategy.GetDrain(); drain != nil {
+		req.Drain = &kubeprovider.DrainOptions{
+			EvictDaemonSets:  drain.GetEvictDaemonSets(),
+			Timeout:          drain.GetTimeout(),
+			Force:            drain.GetForce(),
+			PriorityOrdering: drain.GetPriorityOrdering(),
+		}
 	}
+	return req
 }
@@ -17,17 +17,25 @@ package eks
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/ghodss/yaml"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
 	"github.com/keikoproj/instance-manager/controllers/provisioners/eks/scaling"
 	"github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -338,6 +346,145 @@ func TestUpgradeRollingUpdateStrategyPositive(t *testing.T) {
 	}
 }
 
+func TestUpgradeRollingUpdateExpiredInstances(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("some-scaling-group"),
+		LaunchConfigurationName: aws.String("some-launch-config"),
+		Instances:               append(MockScalingInstances(3, 0), MockScalingInstances(0, 1)...),
+		DesiredCapacity:         aws.Int64(4),
+	}
+	scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	tests := []struct {
+		maxUnavailable   intstr.IntOrString
+		expiredInstances []string
+		driftedSGs       []string
+		expectedTargets  []string
+	}{
+		{maxUnavailable: intstr.FromInt(1), expiredInstances: nil, expectedTargets: []string{"i-100000000"}},
+		{maxUnavailable: intstr.FromInt(1), expiredInstances: []string{"i-000000002", "i-000000000"}, expectedTargets: []string{"i-100000000", "i-000000002", "i-000000000"}},
+		{maxUnavailable: intstr.FromInt(2), expiredInstances: []string{"i-100000000", "i-000000001"}, expectedTargets: []string{"i-100000000", "i-000000001"}},
+		// instances whose security groups drifted are rotated before expired instances
+		{maxUnavailable: intstr.FromInt(1), expiredInstances: []string{"i-000000000"}, driftedSGs: []string{"i-000000002"}, expectedTargets: []string{"i-100000000", "i-000000002", "i-000000000"}},
+		{maxUnavailable: intstr.FromInt(1), expiredInstances: []string{"i-000000002"}, driftedSGs: []string{"i-000000002", "i-100000000"}, expectedTargets: []string{"i-100000000", "i-000000002"}},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %v", i, tc.expiredInstances)
+		ig.SetUpgradeStrategy(MockAwsRollingUpdateStrategy(&tc.maxUnavailable))
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         mockScalingGroup,
+			ScalingConfiguration: scalingConfig,
+			ExpiredInstances:     tc.expiredInstances,
+			DriftedSGInstances:   tc.driftedSGs,
+		})
+
+		req := ctx.NewRollingUpdateRequest()
+		g.Expect(req.UpdateTargets).To(gomega.Equal(tc.expectedTargets))
+		g.Expect(req.MaxUnavailable).To(gomega.Equal(tc.maxUnavailable.IntValue()))
+	}
+}
+
+func TestUpgradeRollingUpdateProtectedInstances(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	state := ctx.GetDiscoveredState()
+	status := ig.GetStatus()
+	configuration := ig.GetEKSConfiguration()
+
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	eksMock.EksCluster = MockEksCluster("1.30")
+
+	var (
+		ownershipTag = MockTagDescription(provisioners.TagClusterName, configuration.GetClusterName())
+		nameTag      = MockTagDescription(provisioners.TagInstanceGroupName, ig.GetName())
+		namespaceTag = MockTagDescription(provisioners.TagInstanceGroupNamespace, ig.GetNamespace())
+		scalingGroup = MockScalingGroup("scaling-group-1", false, ownershipTag, nameTag, namespaceTag)
+	)
+
+	// all instances are drifted from the active launch configuration
+	scalingGroup.Instances = MockScalingInstances(0, 3)
+	scalingGroup.DesiredCapacity = aws.Int64(3)
+	asgMock.AutoScalingGroups = []*autoscaling.Group{scalingGroup}
+	asgMock.LaunchConfigurations = []*autoscaling.LaunchConfiguration{
+		{LaunchConfigurationName: scalingGroup.LaunchConfigurationName},
+	}
+	maxUnavailable := intstr.FromInt(3)
+	ig.SetUpgradeStrategy(MockAwsRollingUpdateStrategy(&maxUnavailable))
+
+	tests := []struct {
+		annotations     map[string]string
+		expectedTargets []string
+		protected       []string
+	}{
+		{annotations: map[string]string{}, expectedTargets: []string{"i-100000000", "i-100000001", "i-100000002"}},
+		{annotations: map[string]string{"i-100000001": "true"}, expectedTargets: []string{"i-100000000", "i-100000002"}, protected: []string{"i-100000001"}},
+		{annotations: map[string]string{"i-100000000": time.Now().Add(time.Hour).Format(time.RFC3339), "i-100000002": "true"}, expectedTargets: []string{"i-100000001"}, protected: []string{"i-100000000", "i-100000002"}},
+		{annotations: map[string]string{"i-100000000": time.Now().Add(-time.Hour).Format(time.RFC3339)}, expectedTargets: []string{"i-100000000", "i-100000001", "i-100000002"}},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %v", i, tc.annotations)
+
+		allNodes, err := k.Kubernetes.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		for _, node := range allNodes.Items {
+			err = k.Kubernetes.CoreV1().Nodes().Delete(context.Background(), node.Name, metav1.DeleteOptions{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+
+		for _, instance := range scalingGroup.Instances {
+			id := aws.StringValue(instance.InstanceId)
+			node := MockNode(id, corev1.ConditionTrue)
+			if value, ok := tc.annotations[id]; ok {
+				node.SetAnnotations(map[string]string{kubeprovider.ProtectedNodeAnnotation: value})
+			}
+			_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+
+		err = ctx.CloudDiscovery()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(state.GetProtectedInstances()).To(gomega.Equal(tc.protected))
+		g.Expect(status.GetProtectedInstances()).To(gomega.Equal(tc.protected))
+
+		req := ctx.NewRollingUpdateRequest()
+		g.Expect(req.UpdateTargets).To(gomega.Equal(tc.expectedTargets))
+	}
+}
+
 func TestRotateWarmPool(t *testing.T) {
 	var (
 		g       = gomega.NewGomegaWithT(t)
@@ -410,3 +557,758 @@ func TestRotateWarmPool(t *testing.T) {
 		}
 	}
 }
+
+func TestUpgradeRollingUpdateDrain(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("some-scaling-group"),
+		LaunchConfigurationName: aws.String("some-launch-config"),
+		Instances:               MockScalingInstances(0, 1),
+		DesiredCapacity:         aws.Int64(1),
+	}
+	scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	node := MockNode("i-100000000", corev1.ConditionTrue)
+	_, err = k.Kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: node.GetName()},
+	}
+	_, err = k.Kubernetes.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	nodes, err := k.Kubernetes.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	maxUnavailable := intstr.FromInt(1)
+	strategy := MockAwsRollingUpdateStrategy(&maxUnavailable)
+	strategy.RollingUpdateType.SetDrain(&v1alpha1.DrainSpec{EvictDaemonSets: []string{"kube-system/log-agent"}, PriorityOrdering: true})
+	ig.SetUpgradeStrategy(strategy)
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup:         mockScalingGroup,
+		ScalingConfiguration: scalingConfig,
+		ClusterNodes:         nodes,
+	})
+
+	req := ctx.NewRollingUpdateRequest()
+	g.Expect(req.Drain).NotTo(gomega.BeNil())
+	g.Expect(req.Drain.EvictDaemonSets).To(gomega.Equal([]string{"kube-system/log-agent"}))
+	g.Expect(req.Drain.PriorityOrdering).To(gomega.BeTrue())
+
+	// the instance is not terminated while its pods are being evicted
+	ok, err := kubeprovider.ProcessRollingUpgradeStrategy(req)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.Equal(uint(0)))
+
+	cordoned, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), node.GetName(), metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cordoned.Spec.Unschedulable).To(gomega.BeTrue())
+
+	// once the evicted pods are gone the instance is terminated
+	err = k.Kubernetes.CoreV1().Pods("default").Delete(context.Background(), pod.GetName(), metav1.DeleteOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	ok, err = kubeprovider.ProcessRollingUpgradeStrategy(req)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.Equal(uint(1)))
+
+	// pods which are not safe to evict abort the rolling update once the drain timeout is exceeded
+	pod.SetAnnotations(map[string]string{kubeprovider.SafeToEvictAnnotation: "false"})
+	_, err = k.Kubernetes.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	cordoned.SetAnnotations(map[string]string{kubeprovider.DrainStartedAnnotation: time.Now().Add(-time.Hour).Format(time.RFC3339)})
+	_, err = k.Kubernetes.CoreV1().Nodes().Update(context.Background(), cordoned, metav1.UpdateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	strategy.RollingUpdateType.GetDrain().Timeout = "10m"
+	req = ctx.NewRollingUpdateRequest()
+	_, err = kubeprovider.ProcessRollingUpgradeStrategy(req)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.Equal(uint(1)))
+
+	// the node of the timed out drain is uncordoned
+	uncordoned, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), node.GetName(), metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(uncordoned.Spec.Unschedulable).To(gomega.BeFalse())
+	g.Expect(uncordoned.GetAnnotations()).NotTo(gomega.HaveKey(kubeprovider.DrainStartedAnnotation))
+
+	// forced drains delete the blocking pods instead
+	uncordoned.Spec.Unschedulable = true
+	uncordoned.SetAnnotations(map[string]string{kubeprovider.DrainStartedAnnotation: time.Now().Add(-time.Hour).Format(time.RFC3339)})
+	_, err = k.Kubernetes.CoreV1().Nodes().Update(context.Background(), uncordoned, metav1.UpdateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	strategy.RollingUpdateType.GetDrain().Force = true
+	req = ctx.NewRollingUpdateRequest()
+	_, err = kubeprovider.ProcessRollingUpgradeStrategy(req)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = k.Kubernetes.CoreV1().Pods("default").Get(context.Background(), pod.GetName(), metav1.GetOptions{})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestUpgradeRollingUpdateReadinessChecks(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		config  = ig.GetEKSConfiguration()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("some-scaling-group"),
+		LaunchConfigurationName: aws.String("some-launch-config"),
+		Instances:               MockScalingInstances(1, 1),
+		DesiredCapacity:         aws.Int64(2),
+	}
+	scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, instance := range mockScalingGroup.Instances {
+		_, err = k.Kubernetes.CoreV1().Nodes().Create(context.Background(), MockNode(aws.StringValue(instance.InstanceId), corev1.ConditionTrue), metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+	nodes, err := k.Kubernetes.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	maxUnavailable := intstr.FromInt(1)
+	ig.SetUpgradeStrategy(MockAwsRollingUpdateStrategy(&maxUnavailable))
+	config.ReadinessChecks = &v1alpha1.ReadinessChecksSpec{DaemonSets: []string{"kube-system/aws-node"}}
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup:         mockScalingGroup,
+		ScalingConfiguration: scalingConfig,
+		ClusterNodes:         nodes,
+	})
+
+	req := ctx.NewRollingUpdateRequest()
+	g.Expect(req.ReadinessDaemonSets).To(gomega.Equal([]string{"kube-system/aws-node"}))
+
+	// nodes are not counted as ready until the daemonset pods run on them
+	ok, err := kubeprovider.ProcessRollingUpgradeStrategy(req)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.Equal(uint(0)))
+
+	controller := true
+	for _, node := range nodes.Items {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("aws-node-%v", node.GetName()),
+				Namespace:       "kube-system",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "aws-node", Controller: &controller}},
+			},
+			Spec:   corev1.PodSpec{NodeName: node.GetName()},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		_, err = k.Kubernetes.CoreV1().Pods("kube-system").Create(context.Background(), pod, metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	ok, err = kubeprovider.ProcessRollingUpgradeStrategy(req)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.Equal(uint(1)))
+}
+
+func TestUpgradeRollingUpdateFailureDomain(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("some-scaling-group"),
+		LaunchConfigurationName: aws.String("some-launch-config"),
+		Instances:               MockScalingInstances(0, 6),
+		DesiredCapacity:         aws.Int64(6),
+	}
+	scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// instances are spread over three zones, the last node has no zone label
+	zones := []string{"us-west-2a", "us-west-2a", "us-west-2b", "us-west-2b", "us-west-2c", ""}
+	nodes := &corev1.NodeList{}
+	for i, instance := range mockScalingGroup.Instances {
+		node := MockNode(aws.StringValue(instance.InstanceId), corev1.ConditionTrue)
+		if zones[i] != "" {
+			node.SetLabels(map[string]string{corev1.LabelTopologyZone: zones[i]})
+		}
+		nodes.Items = append(nodes.Items, *node)
+	}
+	zoneOf := func(instanceID string) string {
+		for i, instance := range mockScalingGroup.Instances {
+			if aws.StringValue(instance.InstanceId) == instanceID {
+				return zones[i]
+			}
+		}
+		return ""
+	}
+
+	tests := []struct {
+		maxUnavailable int
+		label          string
+		expected       []string
+	}{
+		// without a failure domain, maxUnavailable instances are rotated regardless of their zone
+		{maxUnavailable: 4, expected: []string{"i-100000000", "i-100000001", "i-100000002", "i-100000003"}},
+		// at most one node per zone is rotated at a time
+		{maxUnavailable: 6, label: corev1.LabelTopologyZone, expected: []string{"i-100000000", "i-100000002", "i-100000004", "i-100000005"}},
+		// maxUnavailable still limits the number of rotated nodes
+		{maxUnavailable: 2, label: corev1.LabelTopologyZone, expected: []string{"i-100000000", "i-100000002"}},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v", i)
+		asgMock.TerminatedInstanceIds = nil
+
+		maxUnavailable := intstr.FromInt(tc.maxUnavailable)
+		strategy := MockAwsRollingUpdateStrategy(&maxUnavailable)
+		strategy.RollingUpdateType.FailureDomainLabel = tc.label
+		ig.SetUpgradeStrategy(strategy)
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         mockScalingGroup,
+			ScalingConfiguration: scalingConfig,
+			ClusterNodes:         nodes,
+		})
+
+		req := ctx.NewRollingUpdateRequest()
+		g.Expect(req.FailureDomainLabel).To(gomega.Equal(tc.label))
+		ok, err := kubeprovider.ProcessRollingUpgradeStrategy(req)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(ok).To(gomega.BeFalse())
+		g.Expect(asgMock.TerminatedInstanceIds).To(gomega.Equal(tc.expected))
+
+		if tc.label == "" {
+			continue
+		}
+		rotating := make(map[string]bool)
+		for _, instanceID := range asgMock.TerminatedInstanceIds {
+			zone := zoneOf(instanceID)
+			g.Expect(rotating).NotTo(gomega.HaveKey(zone), "instances in zone %q rotated concurrently", zone)
+			rotating[zone] = true
+		}
+	}
+}
+
+func TestUpgradeRollingUpdateSchedulingCheck(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("some-scaling-group"),
+		LaunchConfigurationName: aws.String("some-launch-config"),
+		Instances:               MockScalingInstances(1, 1),
+		DesiredCapacity:         aws.Int64(2),
+	}
+	scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the node which is not rotated is dedicated to pods tolerating its taint
+	nodes := &corev1.NodeList{}
+	for _, instance := range mockScalingGroup.Instances {
+		node := MockNode(aws.StringValue(instance.InstanceId), corev1.ConditionTrue)
+		node.Status.Allocatable = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+			corev1.ResourcePods:   resource.MustParse("10"),
+		}
+		nodes.Items = append(nodes.Items, *node)
+	}
+	nodes.Items[0].Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: nodes.Items[1].GetName()},
+	}
+	_, err = k.Kubernetes.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	maxUnavailable := intstr.FromInt(1)
+	strategy := MockAwsRollingUpdateStrategy(&maxUnavailable)
+	strategy.RollingUpdateType.SchedulingCheck = true
+	ig.SetUpgradeStrategy(strategy)
+
+	tests := []struct {
+		tolerations        []corev1.Toleration
+		expectedTerminated uint
+		expectedCondition  corev1.ConditionStatus
+	}{
+		// the evicted pod does not tolerate the taint of the remaining node, rotation is deferred
+		{expectedTerminated: 0, expectedCondition: corev1.ConditionTrue},
+		// the evicted pod can be scheduled on the remaining node, rotation proceeds
+		{tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}, expectedTerminated: 1, expectedCondition: corev1.ConditionFalse},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %+v", i, tc.tolerations)
+		asgMock.TerminateInstanceInAutoScalingGroupCallCount = 0
+		pod.Spec.Tolerations = tc.tolerations
+		_, err = k.Kubernetes.CoreV1().Pods("default").Update(context.Background(), pod, metav1.UpdateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ig.SetState(v1alpha1.ReconcileInitUpgrade)
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         mockScalingGroup,
+			ScalingConfiguration: scalingConfig,
+			ClusterNodes:         nodes,
+		})
+
+		err = ctx.UpgradeNodes()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.Equal(tc.expectedTerminated))
+		g.Expect(ig.GetStatus().GetRotationUnschedulableCondition()).To(gomega.Equal(tc.expectedCondition))
+		g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileInitUpgrade))
+	}
+}
+
+func TestUpgradeMaintenanceWindow(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	mockWindow := func(start, end time.Duration) v1alpha1.MaintenanceWindowSpec {
+		now := time.Now().UTC()
+		return v1alpha1.MaintenanceWindowSpec{
+			StartTime: now.Add(start).Format(v1alpha1.MaintenanceWindowTimeFormat),
+			EndTime:   now.Add(end).Format(v1alpha1.MaintenanceWindowTimeFormat),
+		}
+	}
+
+	tests := []struct {
+		window             v1alpha1.MaintenanceWindowSpec
+		instances          []*autoscaling.Instance
+		expectedTerminated uint
+		expectedCondition  corev1.ConditionStatus
+	}{
+		// rotation is deferred outside the window
+		{window: mockWindow(time.Hour, 2*time.Hour), instances: append(MockScalingInstances(1, 0), MockScalingInstances(0, 1)...), expectedTerminated: 0, expectedCondition: corev1.ConditionTrue},
+		// rotation proceeds inside the window
+		{window: mockWindow(-time.Hour, time.Hour), instances: append(MockScalingInstances(1, 0), MockScalingInstances(0, 1)...), expectedTerminated: 1, expectedCondition: corev1.ConditionFalse},
+		// nothing is waiting outside the window when no instances need rotation
+		{window: mockWindow(time.Hour, 2*time.Hour), instances: MockScalingInstances(2, 0), expectedTerminated: 0, expectedCondition: corev1.ConditionFalse},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %+v", i, tc.window)
+		asgMock.TerminateInstanceInAutoScalingGroupCallCount = 0
+		mockScalingGroup := &autoscaling.Group{
+			AutoScalingGroupName:    aws.String("some-scaling-group"),
+			LaunchConfigurationName: aws.String("some-launch-config"),
+			Instances:               tc.instances,
+			DesiredCapacity:         aws.Int64(2),
+		}
+		scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		maxUnavailable := intstr.FromInt(1)
+		strategy := MockAwsRollingUpdateStrategy(&maxUnavailable)
+		strategy.MaintenanceWindows = []v1alpha1.MaintenanceWindowSpec{tc.window}
+		ig.SetUpgradeStrategy(strategy)
+		ig.SetState(v1alpha1.ReconcileInitUpgrade)
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         mockScalingGroup,
+			ScalingConfiguration: scalingConfig,
+			ClusterNodes:         &corev1.NodeList{},
+		})
+
+		err = ctx.UpgradeNodes()
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.Equal(tc.expectedTerminated))
+		g.Expect(ig.GetStatus().GetWaitingForMaintenanceWindowCondition()).To(gomega.Equal(tc.expectedCondition))
+		g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileInitUpgrade))
+	}
+}
+
+func TestUpgradeRotationWebhooks(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		status  = ig.GetStatus()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	var (
+		approved bool
+		received []provisioners.RotationWebhookRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := provisioners.RotationWebhookRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode rotation request: %v", err)
+		}
+		received = append(received, request)
+		json.NewEncoder(w).Encode(&provisioners.RotationWebhookResponse{Approved: approved, Reason: "change freeze"})
+	}))
+	defer server.Close()
+	ctx.RotationNotifier = provisioners.NewRotationNotifier(server.URL+"/pre", server.URL+"/post", time.Second, false, ctx.Log)
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("some-scaling-group"),
+		LaunchConfigurationName: aws.String("some-launch-config"),
+		Instances:               append(MockScalingInstances(1, 0), MockScalingInstances(0, 1)...),
+		DesiredCapacity:         aws.Int64(2),
+	}
+	scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	maxUnavailable := intstr.FromInt(1)
+	ig.SetUpgradeStrategy(MockAwsRollingUpdateStrategy(&maxUnavailable))
+	ig.SetState(v1alpha1.ReconcileInitUpgrade)
+	ctx.SetDiscoveredState(&DiscoveredState{
+		Publisher: kubeprovider.EventPublisher{
+			Client: k.Kubernetes,
+		},
+		ScalingGroup:         mockScalingGroup,
+		ScalingConfiguration: scalingConfig,
+		ClusterNodes:         &corev1.NodeList{},
+	})
+
+	// a denied rotation is blocked
+	err = ctx.UpgradeNodes()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.BeZero())
+	g.Expect(status.GetRotationDeniedCondition()).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(status.GetRotationStarted()).To(gomega.BeFalse())
+	g.Expect(received).To(gomega.HaveLen(1))
+	g.Expect(received[0]).To(gomega.Equal(provisioners.RotationWebhookRequest{
+		Phase:            provisioners.RotationPhasePre,
+		InstanceGroup:    ig.GetName(),
+		Namespace:        ig.GetNamespace(),
+		DesiredCapacity:  2,
+		Instances:        2,
+		PendingInstances: 1,
+	}))
+
+	// an approved rotation begins and is not submitted again while it is in progress
+	approved = true
+	err = ctx.UpgradeNodes()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(asgMock.TerminateInstanceInAutoScalingGroupCallCount).To(gomega.Equal(uint(1)))
+	g.Expect(status.GetRotationDeniedCondition()).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(status.GetRotationStarted()).To(gomega.BeTrue())
+	g.Expect(received).To(gomega.HaveLen(2))
+
+	err = ctx.UpgradeNodes()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(received).To(gomega.HaveLen(2))
+
+	// the post-rotation hook is notified once the rotation completes
+	mockScalingGroup.Instances = MockScalingInstances(2, 0)
+	err = ctx.UpgradeNodes()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status.GetRotationStarted()).To(gomega.BeFalse())
+	g.Expect(received).To(gomega.HaveLen(3))
+	g.Expect(received[2].Phase).To(gomega.Equal(provisioners.RotationPhasePost))
+	g.Expect(received[2].PendingInstances).To(gomega.BeZero())
+}
+
+func TestUpgradeBlueGreenStrategy(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	status := ig.GetStatus()
+
+	ig.SetUpgradeStrategy(v1alpha1.AwsUpgradeStrategy{
+		Type:          v1alpha1.BlueGreenStrategyName,
+		BlueGreenType: &v1alpha1.BlueGreenStrategy{ReadyTimeout: "10m"},
+	})
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("some-scaling-group"),
+		LaunchConfigurationName: aws.String("some-launch-config"),
+		Instances:               MockScalingInstances(0, 2),
+		DesiredCapacity:         aws.Int64(2),
+	}
+	blue := []string{"i-100000000", "i-100000001"}
+	for _, id := range blue {
+		_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), MockNode(id, corev1.ConditionTrue), metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	discover := func() {
+		scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		nodes, err := k.Kubernetes.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         mockScalingGroup,
+			ScalingConfiguration: scalingConfig,
+			ClusterNodes:         nodes,
+		})
+	}
+	greenInstances := func(ids ...string) []*autoscaling.Instance {
+		instances := []*autoscaling.Instance{}
+		for _, id := range ids {
+			instances = append(instances, &autoscaling.Instance{
+				InstanceId:              aws.String(id),
+				LaunchConfigurationName: aws.String("some-launch-config"),
+			})
+		}
+		return instances
+	}
+
+	// green capacity is launched in addition to the blue instances
+	discover()
+	ok, err := ctx.ProcessBlueGreenStrategy()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+	g.Expect(aws.Int64Value(asgMock.UpdateAutoScalingGroupInputs[0].DesiredCapacity)).To(gomega.Equal(int64(4)))
+	g.Expect(aws.Int64Value(asgMock.UpdateAutoScalingGroupInputs[0].MaxSize)).To(gomega.Equal(int64(5)))
+	g.Expect(status.GetBlueGreen().Phase).To(gomega.Equal(v1alpha1.BlueGreenPhaseProvisioning))
+	g.Expect(status.GetBlueGreen().BlueInstances).To(gomega.Equal(blue))
+	g.Expect(status.GetBlueGreen().PreviousInstances).To(gomega.Equal(blue))
+	g.Expect(status.GetBlueGreen().ScalingConfiguration).To(gomega.Equal("some-launch-config"))
+	g.Expect(status.GetBlueGreenSurge()).To(gomega.Equal(int64(2)))
+
+	// the scaling group's max size keeps the surge while the rotation is in progress
+	shards, err := ctx.GetScalingGroupShards()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(shards).To(gomega.HaveLen(1))
+	g.Expect(shards[0].MaxSize).To(gomega.Equal(int64(5)))
+
+	// blue nodes are not cordoned while green nodes are not ready
+	mockScalingGroup.Instances = append(mockScalingGroup.Instances, greenInstances("i-200000000", "i-200000001")...)
+	mockScalingGroup.DesiredCapacity = aws.Int64(4)
+	_, err = k.Kubernetes.CoreV1().Nodes().Create(context.Background(), MockNode("i-200000000", corev1.ConditionTrue), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = k.Kubernetes.CoreV1().Nodes().Create(context.Background(), MockNode("i-200000001", corev1.ConditionFalse), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	discover()
+	ok, err = ctx.ProcessBlueGreenStrategy()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(status.GetBlueGreen().Phase).To(gomega.Equal(v1alpha1.BlueGreenPhaseProvisioning))
+
+	// blue nodes are cordoned once the green nodes are ready
+	_, err = k.Kubernetes.CoreV1().Nodes().Update(context.Background(), MockNode("i-200000001", corev1.ConditionTrue), metav1.UpdateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	discover()
+	ok, err = ctx.ProcessBlueGreenStrategy()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(status.GetBlueGreen().Phase).To(gomega.Equal(v1alpha1.BlueGreenPhaseRetiring))
+	for _, id := range blue {
+		node, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), "node-"+id, metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(node.Spec.Unschedulable).To(gomega.BeTrue())
+	}
+	green, err := k.Kubernetes.CoreV1().Nodes().Get(context.Background(), "node-i-200000000", metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(green.Spec.Unschedulable).To(gomega.BeFalse())
+
+	// drained blue instances are removed
+	discover()
+	ok, err = ctx.ProcessBlueGreenStrategy()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.ConsistOf(blue))
+
+	// the rotation completes once the blue instances are gone
+	mockScalingGroup.Instances = greenInstances("i-200000000", "i-200000001")
+	mockScalingGroup.DesiredCapacity = aws.Int64(2)
+	discover()
+	ok, err = ctx.ProcessBlueGreenStrategy()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(status.GetBlueGreen()).To(gomega.BeNil())
+	g.Expect(status.GetBlueGreenSurge()).To(gomega.BeZero())
+}
+
+func TestUpgradeBlueGreenRollback(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	status := ig.GetStatus()
+
+	ig.SetUpgradeStrategy(v1alpha1.AwsUpgradeStrategy{
+		Type:          v1alpha1.BlueGreenStrategyName,
+		BlueGreenType: &v1alpha1.BlueGreenStrategy{ReadyTimeout: "10m"},
+	})
+
+	mockScalingGroup := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("some-scaling-group"),
+		LaunchConfigurationName: aws.String("some-launch-config"),
+		Instances: append(MockScalingInstances(0, 2), &autoscaling.Instance{
+			InstanceId:              aws.String("i-300000000"),
+			LaunchConfigurationName: aws.String("some-launch-config"),
+		}, &autoscaling.Instance{
+			InstanceId:              aws.String("i-200000000"),
+			LaunchConfigurationName: aws.String("some-launch-config"),
+		}),
+		DesiredCapacity: aws.Int64(5),
+	}
+	_, err := k.Kubernetes.CoreV1().Nodes().Create(context.Background(), MockNode("i-200000000", corev1.ConditionFalse), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	// an instance which was already running the scaling configuration before the rotation is not green
+	_, err = k.Kubernetes.CoreV1().Nodes().Create(context.Background(), MockNode("i-300000000", corev1.ConditionTrue), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	discover := func() {
+		scalingConfig, err := scaling.NewLaunchConfiguration("", w, &scaling.DiscoverConfigurationInput{ScalingGroup: mockScalingGroup})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		nodes, err := k.Kubernetes.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		ctx.SetDiscoveredState(&DiscoveredState{
+			Publisher: kubeprovider.EventPublisher{
+				Client: k.Kubernetes,
+			},
+			ScalingGroup:         mockScalingGroup,
+			ScalingConfiguration: scalingConfig,
+			ClusterNodes:         nodes,
+		})
+	}
+
+	// green nodes which do not become ready within the timeout are rolled back
+	status.SetBlueGreen(&v1alpha1.BlueGreenStatus{
+		Phase:                v1alpha1.BlueGreenPhaseProvisioning,
+		ScalingConfiguration: "some-launch-config",
+		BlueInstances:        []string{"i-100000000", "i-100000001"},
+		PreviousInstances:    []string{"i-100000000", "i-100000001", "i-300000000"},
+		GreenCapacity:        2,
+		StartTime:            metav1.NewTime(time.Now().Add(-time.Hour)),
+	})
+	status.SetRotationStarted(true)
+	discover()
+	ok, err := ctx.ProcessBlueGreenStrategy()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(asgMock.TerminatedInstanceIds).To(gomega.Equal([]string{"i-200000000"}))
+	g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+	g.Expect(aws.Int64Value(asgMock.UpdateAutoScalingGroupInputs[0].DesiredCapacity)).To(gomega.Equal(int64(3)))
+	g.Expect(status.GetBlueGreen().Phase).To(gomega.Equal(v1alpha1.BlueGreenPhaseRolledBack))
+	g.Expect(status.GetBlueGreenRolledBackCondition()).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(status.GetBlueGreenSurge()).To(gomega.BeZero())
+	g.Expect(status.GetRotationStarted()).To(gomega.BeFalse())
+
+	// the rotation is not retried with the same scaling configuration
+	mockScalingGroup.Instances = MockScalingInstances(0, 2)
+	mockScalingGroup.DesiredCapacity = aws.Int64(2)
+	discover()
+	ctx.SetState(v1alpha1.ReconcileInitUpgrade)
+	err = ctx.UpgradeNodes()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileErr))
+	g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+
+	// updating the node conditions keeps the rolled back condition
+	ctx.UpdateNodeReadyCondition()
+	g.Expect(status.GetBlueGreenRolledBackCondition()).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(status.GetConditions()).To(gomega.ContainElement(gomega.HaveField("Type", v1alpha1.NodesReady)))
+
+	// a rolled back rotation is detected from its phase, even if its condition was lost
+	status.SetConditions(nil)
+	ctx.SetState(v1alpha1.ReconcileInitUpgrade)
+	err = ctx.UpgradeNodes()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ctx.GetState()).To(gomega.Equal(v1alpha1.ReconcileErr))
+	g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(1))
+
+	// a new scaling configuration starts a new rotation
+	mockScalingGroup.LaunchConfigurationName = aws.String("some-other-launch-config")
+	discover()
+	ok, err = ctx.ProcessBlueGreenStrategy()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(status.GetBlueGreenRolledBackCondition()).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(status.GetBlueGreen().Phase).To(gomega.Equal(v1alpha1.BlueGreenPhaseProvisioning))
+	g.Expect(status.GetBlueGreen().ScalingConfiguration).To(gomega.Equal("some-other-launch-config"))
+	g.Expect(asgMock.UpdateAutoScalingGroupInputs).To(gomega.HaveLen(2))
+}
@@ -0,0 +1,486 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eks
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// UserDataRenderer renders the userdata of an OS family, EKSUserData carries everything the provisioner resolved for
+// an instance group's nodes
+type UserDataRenderer interface {
+	Render(data EKSUserData) (string, error)
+}
+
+// TemplateRenderer renders userdata from a text/template executed with the fields of EKSUserData and its variables
+type TemplateRenderer struct {
+	Template string
+}
+
+var (
+	userDataRenderersMu sync.RWMutex
+	userDataRenderers   = map[string]UserDataRenderer{
+		OsFamilyWindows:         &TemplateRenderer{Template: WindowsUserDataTemplate},
+		OsFamilyBottleRocket:    &TemplateRenderer{Template: BottleRocketUserDataTemplate},
+		OsFamilyAmazonLinux2:    &TemplateRenderer{Template: AmazonLinux2UserDataTemplate},
+		OsFamilyAmazonLinux2023: &TemplateRenderer{Template: AmazonLinux2023UserDataTemplate},
+	}
+)
+
+// RegisterUserDataRenderer registers the renderer used for instance groups annotated with the OS family, replacing any
+// renderer registered for it, including the built-in ones. Renderers should be registered before the controller starts.
+func RegisterUserDataRenderer(osFamily string, renderer UserDataRenderer) {
+	userDataRenderersMu.Lock()
+	defer userDataRenderersMu.Unlock()
+	userDataRenderers[strings.ToLower(osFamily)] = renderer
+}
+
+// GetUserDataRenderer returns the renderer registered for the OS family
+func GetUserDataRenderer(osFamily string) (UserDataRenderer, bool) {
+	userDataRenderersMu.RLock()
+	defer userDataRenderersMu.RUnlock()
+	renderer, ok := userDataRenderers[strings.ToLower(osFamily)]
+	return renderer, ok && renderer != nil
+}
+
+// IsRegisteredOsFamily returns true if a renderer is registered for the OS family
+func IsRegisteredOsFamily(osFamily string) bool {
+	_, ok := GetUserDataRenderer(osFamily)
+	return ok
+}
+
+func (r *TemplateRenderer) Render(data EKSUserData) (string, error) {
+	tmpl, err := template.New("userData").Funcs(template.FuncMap{
+		"ToLower": strings.ToLower,
+	}).Option("missingkey=error").Parse(r.Template)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse userData template")
+	}
+
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, templateContext(data)); err != nil {
+		return "", errors.Wrap(err, "failed to execute userData template")
+	}
+	return out.String(), nil
+}
+
+// templateContext returns the fields of the userdata with its variables, variables never replace fields
+func templateContext(data EKSUserData) map[string]interface{} {
+	context := make(map[string]interface{}, len(data.Variables))
+	for name, value := range data.Variables {
+		context[name] = value
+	}
+
+	v := reflect.ValueOf(data)
+	for i := 0; i < v.NumField(); i++ {
+		context[v.Type().Field(i).Name] = v.Field(i).Interface()
+	}
+	return context
+}
+
+const (
+	WindowsUserDataTemplate = `
+<powershell>
+{{- with .BootstrapRetries}}
+  function Invoke-WithRetry([scriptblock]$ScriptBlock) {
+    for ($Attempt = 1; ; $Attempt++) {
+      try {
+        return & $ScriptBlock
+      } catch {
+        if ($Attempt -ge {{ .Attempts }}) { throw }
+        Start-Sleep -Seconds ({{ .BackoffSeconds }} * $Attempt)
+      }
+    }
+  }
+{{- end}}
+  {{range $pre := .PreBootstrap}}{{$pre}}{{end}}
+  [string]$EKSBinDir = "$env:ProgramFiles\Amazon\EKS"
+  [string]$EKSBootstrapScriptName = 'Start-EKSBootstrap.ps1'
+  [string]$EKSBootstrapScriptFile = "$EKSBinDir\$EKSBootstrapScriptName"
+  [string]$IMDSToken=({{if .BootstrapRetries}}Invoke-WithRetry { {{end}}curl -UseBasicParsing -Method PUT "http://169.254.169.254/latest/api/token" -H @{ "X-aws-ec2-metadata-token-ttl-seconds" = "21600"} | % { Echo $_.Content}{{if .BootstrapRetries}} }{{end}})
+  [string]$InstanceID=({{if .BootstrapRetries}}Invoke-WithRetry { {{end}}curl -UseBasicParsing -Method GET "http://169.254.169.254/latest/meta-data/instance-id" -H @{ "X-aws-ec2-metadata-token" = "$IMDSToken"} | % { Echo $_.Content}{{if .BootstrapRetries}} }{{end}})
+  [string]$Lifecycle=(curl -UseBasicParsing -Method GET "http://169.254.169.254/latest/meta-data/autoscaling/target-lifecycle-state" -H @{ "X-aws-ec2-metadata-token" = "$IMDSToken"} | % { Echo $_.Content})
+  if ($Lifecycle -like "*Warmed*") {
+    Echo "Not starting Kubelet due to warmed state."
+    & C:\ProgramData\Amazon\EC2-Windows\Launch\Scripts\InitializeInstance.ps1 -Schedule
+  } else {
+{{- with .Proxy}}
+    $ProxyEnvironment = @({{ range $i, $variable := .Variables }}{{ if $i }}, {{ end }}"{{ .Name }}={{ .Value }}"{{ end }})
+    foreach ($Variable in $ProxyEnvironment) {
+      $Name, $Value = $Variable -split '=', 2
+      [Environment]::SetEnvironmentVariable($Name, $Value, [EnvironmentVariableTarget]::Machine)
+      [Environment]::SetEnvironmentVariable($Name, $Value, [EnvironmentVariableTarget]::Process)
+    }
+    Set-ItemProperty -Path HKLM:\SYSTEM\CurrentControlSet\Services\containerd -Name Environment -Type MultiString -Value $ProxyEnvironment
+    Restart-Service containerd
+{{- end}}
+{{- with .CABundle}}
+    $CertificateStore = New-Object System.Security.Cryptography.X509Certificates.X509Store([System.Security.Cryptography.X509Certificates.StoreName]::Root, [System.Security.Cryptography.X509Certificates.StoreLocation]::LocalMachine)
+    $CertificateStore.Open([System.Security.Cryptography.X509Certificates.OpenFlags]::ReadWrite)
+    foreach ($Certificate in @({{ range $i, $certificate := .Certificates }}{{ if $i }}, {{ end }}"{{ $certificate }}"{{ end }})) {
+      $CertificateStore.Add([System.Security.Cryptography.X509Certificates.X509Certificate2]::new([Convert]::FromBase64String($Certificate)))
+    }
+    $CertificateStore.Close()
+    Restart-Service containerd
+{{- end}}
+{{- with .WindowsContainerd}}
+    [string]$ContainerdConfigFile = "$env:ProgramFiles\containerd\config.toml"
+    $ContainerdConfig = Get-Content -Raw -Path $ContainerdConfigFile
+{{- if .Root}}
+    $ContainerdConfig = $ContainerdConfig -replace '(?m)^root\s*=.*$', 'root = ''{{ .Root }}'''
+{{- end}}
+{{- if .State}}
+    $ContainerdConfig = $ContainerdConfig -replace '(?m)^state\s*=.*$', 'state = ''{{ .State }}'''
+{{- end}}
+{{- if .SandboxImage}}
+    $ContainerdConfig = $ContainerdConfig -replace '(?m)^(\s*)sandbox_image\s*=.*$', '$1sandbox_image = "{{ .SandboxImage }}"'
+{{- end}}
+    Set-Content -Path $ContainerdConfigFile -Value $ContainerdConfig
+{{- end}}
+    & $EKSBootstrapScriptFile -EKSClusterName {{ .ClusterName }} {{ .Arguments }} 3>&1 4>&1 5>&1 6>&1
+{{- if .Proxy}}
+    Set-ItemProperty -Path HKLM:\SYSTEM\CurrentControlSet\Services\kubelet -Name Environment -Type MultiString -Value $ProxyEnvironment
+    Restart-Service kubelet
+{{- end}}
+    {{range $post := .PostBootstrap}}{{$post}}{{end}}
+  }
+</powershell>`
+
+	BottleRocketUserDataTemplate = `
+{{range $pre := .PreBootstrap}}{{$pre}}{{end}}
+[settings.kubernetes]
+api-server   = "{{ .ApiEndpoint }}"
+cluster-certificate = "{{ .ClusterCA }}"
+cluster-name = "{{ .ClusterName }}"
+{{- if .MaxPods}}
+max-pods = {{ .MaxPods }}
+{{- end}}
+{{- with .ImageGCHighThreshold}}
+image-gc-high-threshold-percent = {{ . }}
+{{- end}}
+{{- with .ImageGCLowThreshold}}
+image-gc-low-threshold-percent = {{ . }}
+{{- end}}
+{{- with .ShutdownGracePeriod}}
+shutdown-grace-period = "{{ . }}"
+{{- end}}
+{{- with .ShutdownGracePeriodCriticalPods}}
+shutdown-grace-period-for-critical-pods = "{{ . }}"
+{{- end}}
+{{- with .AllowedUnsafeSysctls}}
+allowed-unsafe-sysctls = [{{ $first := true }}{{ range . }}{{ if not $first }}, {{ end }}"{{ . }}"{{ $first = false }}{{ end }}]
+{{- end}}
+[settings.kubernetes.node-labels]
+{{- range $key, $value := .NodeLabels }}
+"{{ $key }}" = "{{ $value }}"
+{{- end}}
+[settings.kubernetes.node-taints]
+{{- range .NodeTaints}}
+"{{ .Key }}" = "{{ .Value }}:{{ .Effect }}"
+{{- end}}
+{{- with .Sysctls}}
+[settings.kernel.sysctl]
+{{- range $key, $value := .}}
+"{{ $key }}" = "{{ $value }}"
+{{- end}}
+{{- end}}
+{{- with .Proxy}}
+[settings.network]
+https-proxy = "{{ .Proxy }}"
+no-proxy = [{{ $first := true }}{{ range .NoProxy }}{{ if not $first }}, {{ end }}"{{ . }}"{{ $first = false }}{{ end }}]
+{{- end}}
+{{- with .CABundle}}
+[settings.pki.instance-manager-ca-bundle]
+data = "{{ .Base64 }}"
+trusted = true
+{{- end}}
+{{range $post := .PostBootstrap}}{{$post}}{{end}}
+`
+
+	AmazonLinux2UserDataTemplate = `#!/bin/bash` + linuxRetryFunction + linuxProxyConfiguration + linuxCABundleConfiguration + `
+{{range $pre := .PreBootstrap}}{{$pre}}{{end}}
+{{- range .MountOptions}}
+mkfs.{{ .FileSystem | ToLower }} {{ .Device }}
+mkdir {{ .Mount }}
+mount {{ .Device }} {{ .Mount }}
+mount
+{{- if .Persistance}}
+echo "{{ .Device}}    {{ .Mount }}    {{ .FileSystem | ToLower }}    defaults    0    2" >> /etc/fstab
+{{- end}}
+{{- end}}
+if [[ $(type -P $(which aws)) ]] && [[ $(type -P $(which jq)) ]] ; then
+	TOKEN=$(curl -X PUT "http://169.254.169.254/latest/api/token" -H "X-aws-ec2-metadata-token-ttl-seconds: 21600")
+	INSTANCE_ID=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/instance-id)
+	REGION=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/placement/region)
+	LIFECYCLE=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/autoscaling/target-lifecycle-state)
+	if [[ $LIFECYCLE == *"Warmed"* ]]; then
+		rm /var/lib/cloud/instances/$INSTANCE_ID/sem/config_scripts_user
+		exit 0
+	fi
+fi
+` + linuxInstanceStorageConfiguration + linuxRegistryCredentials + linuxSysctlConfiguration + linuxPrePullImages + `
+{{- if .KubeletConfig}}
+KUBELET_CONFIG=/etc/kubernetes/kubelet/kubelet-config.json
+cat <<'EOF' > /etc/kubernetes/kubelet/kubelet-config-fragment.json
+{ {{- $first := true }}{{ range $key, $value := .KubeletConfig }}{{ if not $first }},{{ end }}"{{ $key }}": {{ $value }}{{ $first = false }}{{ end -}} }
+EOF
+echo "$(jq -s '.[0] * .[1]' $KUBELET_CONFIG /etc/kubernetes/kubelet/kubelet-config-fragment.json)" > $KUBELET_CONFIG
+{{- end}}
+{{- with .SerializeImagePulls}}
+KUBELET_CONFIG=/etc/kubernetes/kubelet/kubelet-config.json
+echo "$(jq '.serializeImagePulls={{ . }}{{ with $.MaxParallelImagePulls }} | .maxParallelImagePulls={{ . }}{{ end }}' $KUBELET_CONFIG)" > $KUBELET_CONFIG
+{{- end}}
+{{- if .ShutdownGracePeriod}}
+KUBELET_CONFIG=/etc/kubernetes/kubelet/kubelet-config.json
+echo "$(jq '.shutdownGracePeriod="{{ .ShutdownGracePeriod }}"{{ with .ShutdownGracePeriodCriticalPods }} | .shutdownGracePeriodCriticalPods="{{ . }}"{{ end }}' $KUBELET_CONFIG)" > $KUBELET_CONFIG
+{{- end}}
+{{- with .AllowedUnsafeSysctls}}
+KUBELET_CONFIG=/etc/kubernetes/kubelet/kubelet-config.json
+echo "$(jq '.allowedUnsafeSysctls=[{{ $first := true }}{{ range . }}{{ if not $first }},{{ end }}"{{ . }}"{{ $first = false }}{{ end }}]' $KUBELET_CONFIG)" > $KUBELET_CONFIG
+{{- end}}
+set -o xtrace
+/etc/eks/bootstrap.sh {{ .ClusterName }} {{ .Arguments }}
+set +o xtrace
+{{range $post := .PostBootstrap}}{{$post}}{{end}}`
+
+	AmazonLinux2023UserDataTemplate = `MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="BOUNDARY"
+
+--BOUNDARY
+Content-Type: text/x-shellscript; charset="us-ascii"
+
+#!/bin/bash` + linuxRetryFunction + linuxProxyConfiguration + linuxCABundleConfiguration + `
+echo "IG manager using AL2023 amis"
+{{range $pre := .PreBootstrap}}{{$pre}}{{end}}
+{{- range .MountOptions}}
+mkfs.{{ .FileSystem | ToLower }} {{ .Device }}
+mkdir {{ .Mount }}
+mount {{ .Device }} {{ .Mount }}
+mount
+{{- if .Persistance}}
+echo "{{ .Device}}    {{ .Mount }}    {{ .FileSystem | ToLower }}    defaults    0    2" >> /etc/fstab
+{{- end}}
+{{- end}}
+if [[ $(type -P $(which aws)) ]] && [[ $(type -P $(which jq)) ]] ; then
+	TOKEN=$(curl -X PUT "http://169.254.169.254/latest/api/token" -H "X-aws-ec2-metadata-token-ttl-seconds: 21600")
+	INSTANCE_ID=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/instance-id)
+	REGION=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/placement/region)
+	LIFECYCLE=$(curl url -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/autoscaling/target-lifecycle-state)
+	if [[ $LIFECYCLE == *"Warmed"* ]]; then
+		rm /var/lib/cloud/instances/$INSTANCE_ID/sem/config_scripts_user
+		exit 0
+	fi
+fi
+` + linuxInstanceStorageConfiguration + linuxRegistryCredentials + linuxSysctlConfiguration + linuxPrePullImages + `
+--BOUNDARY
+Content-Type: application/node.eks.aws
+
+{{ .NodeConfigYaml }}
+
+--BOUNDARY
+Content-Type: application/node.eks.aws
+
+---
+apiVersion: node.eks.aws/v1alpha1
+kind: NodeConfig
+spec:
+  kubelet:
+{{- if or .ImageGCHighThreshold .ImageGCLowThreshold .SerializeImagePulls .MaxParallelImagePulls .ShutdownGracePeriod .KubeletConfig .AllowedUnsafeSysctls}}
+    config:
+{{- range $key, $value := .KubeletConfig}}
+      {{ $key }}: {{ $value }}
+{{- end}}
+{{- with .ImageGCHighThreshold}}
+      imageGCHighThresholdPercent: {{ . }}
+{{- end}}
+{{- with .ImageGCLowThreshold}}
+      imageGCLowThresholdPercent: {{ . }}
+{{- end}}
+{{- with .SerializeImagePulls}}
+      serializeImagePulls: {{ . }}
+{{- end}}
+{{- with .MaxParallelImagePulls}}
+      maxParallelImagePulls: {{ . }}
+{{- end}}
+{{- with .ShutdownGracePeriod}}
+      shutdownGracePeriod: {{ . }}
+{{- end}}
+{{- with .ShutdownGracePeriodCriticalPods}}
+      shutdownGracePeriodCriticalPods: {{ . }}
+{{- end}}
+{{- with .AllowedUnsafeSysctls}}
+      allowedUnsafeSysctls:
+{{- range .}}
+        - "{{ . }}"
+{{- end}}
+{{- end}}
+{{- end}}
+    flags:
+      - --node-labels={{ $first := true }}{{ range $key, $value := .NodeLabels }}{{if not $first}},{{end}}{{ $key }}={{ $value }}{{ $first = false}}{{- end}}
+      - --register-with-taints={{ $first := true }}{{- range .NodeTaints}}{{if not $first}},{{end}}{{ .Key }}={{ .Value }}:{{ .Effect }}{{ $first = false}}{{- end}}
+{{- if .MaxPods}}
+      - --max-pods={{ .MaxPods }}
+{{- end}}
+
+--BOUNDARY
+Content-Type: text/x-shellscript; charset="us-ascii"
+
+#!/bin/bash` + linuxRetryFunction + linuxProxyEnvironment + `
+set +o xtrace
+{{range $post := .PostBootstrap}}{{$post}}{{end}}
+--BOUNDARY--`
+
+	// linuxProxyConfiguration writes the proxy environment to /etc/environment, which containerd and the kubelet read
+	// through systemd drop-ins, and exports it for the rest of the bootstrap
+	linuxProxyConfiguration = `
+{{- with .Proxy}}
+cat <<'EOF' >> /etc/environment
+{{- range .Variables}}
+{{ .Name }}={{ .Value }}
+{{ .Name | ToLower }}={{ .Value }}
+{{- end}}
+EOF
+for unit in containerd kubelet; do
+	mkdir -p /etc/systemd/system/$unit.service.d
+	cat <<'EOF' > /etc/systemd/system/$unit.service.d/http-proxy.conf
+[Service]
+EnvironmentFile=/etc/environment
+EOF
+done
+systemctl daemon-reload
+{{- end}}` + linuxProxyEnvironment
+
+	// linuxRegistryCredentials retrieves the registry credentials parameter into the kubelet's credentials file
+	linuxRegistryCredentials = `
+{{- if .RegistryCredentialsParameter}}
+REGISTRY_TOKEN=$(curl -s -X PUT "http://169.254.169.254/latest/api/token" -H "X-aws-ec2-metadata-token-ttl-seconds: 300")
+REGISTRY_REGION=$(curl -s -H "X-aws-ec2-metadata-token: $REGISTRY_TOKEN" http://169.254.169.254/latest/meta-data/placement/region)
+mkdir -p /var/lib/kubelet
+(umask 077 && {{ if $.BootstrapRetries }}retry {{ end }}aws ssm get-parameter --region "$REGISTRY_REGION" --name "{{ .RegistryCredentialsParameter }}" --with-decryption --query Parameter.Value --output text > /var/lib/kubelet/config.json)
+{{- end}}`
+
+	// linuxInstanceStorageConfiguration mounts the NVMe instance store volumes at the mount path, multiple volumes are
+	// combined into a RAID 0 array. Data at the mount path is copied to the volumes before they are mounted over it
+	linuxInstanceStorageConfiguration = `
+{{- with .InstanceStorage}}
+INSTANCE_STORE_DEVICES=$(lsblk -d -n -p -o NAME,MODEL | awk '/Amazon EC2 NVMe Instance Storage/ {print $1}')
+INSTANCE_STORE_COUNT=$(echo -n "$INSTANCE_STORE_DEVICES" | grep -c '^')
+if [[ $INSTANCE_STORE_COUNT -eq 0 ]]; then
+	echo "no instance store volumes found, skipping instance storage setup"
+else
+	if [[ $INSTANCE_STORE_COUNT -eq 1 ]]; then
+		INSTANCE_STORE_DEVICE=$INSTANCE_STORE_DEVICES
+	else
+		INSTANCE_STORE_DEVICE=/dev/md/instance-store
+		mdadm --create --force --verbose $INSTANCE_STORE_DEVICE --level=0 --name=instance-store --raid-devices=$INSTANCE_STORE_COUNT $INSTANCE_STORE_DEVICES
+		mdadm --detail --scan >> /etc/mdadm.conf
+	fi
+	mkfs.{{ .FileSystem }} {{ if eq .FileSystem "ext4" }}-F{{ else }}-f{{ end }} $INSTANCE_STORE_DEVICE
+	systemctl stop containerd 2>/dev/null || true
+	mkdir -p {{ .MountPath }} /mnt/instance-store
+	mount $INSTANCE_STORE_DEVICE /mnt/instance-store
+	cp -a {{ .MountPath }}/. /mnt/instance-store/
+	umount /mnt/instance-store
+	mount $INSTANCE_STORE_DEVICE {{ .MountPath }}
+	echo "$INSTANCE_STORE_DEVICE    {{ .MountPath }}    {{ .FileSystem }}    defaults,nofail    0    2" >> /etc/fstab
+fi
+{{- end}}`
+
+	// linuxSysctlConfiguration writes the sysctls to a sysctl.d file, so they are applied again on reboot, and loads them
+	linuxSysctlConfiguration = `
+{{- if .Sysctls}}
+cat <<'EOF' > /etc/sysctl.d/99-instance-manager.conf
+{{- range $key, $value := .Sysctls}}
+{{ $key }} = {{ $value }}
+{{- end}}
+EOF
+sysctl --system
+{{- end}}`
+
+	// linuxPrePullImages pulls the pre-pulled images from a systemd unit which runs once containerd is started and before
+	// the kubelet starts, so that pods are not scheduled on the node before its images are pulled
+	linuxPrePullImages = `
+{{- if .PrePullImages}}
+cat <<'EOF' > /etc/eks/pre-pull-images.sh
+#!/bin/bash` + linuxRetryFunction + `
+{{- range .PrePullImages}}
+{{ if $.BootstrapRetries }}retry {{ end }}crictl pull{{if .ECRRegion}} --creds "AWS:$({{ if $.BootstrapRetries }}retry {{ end }}aws ecr get-login-password --region {{ .ECRRegion }})"{{end}} {{ .Image }} || echo "failed to pre-pull image {{ .Image }}"
+{{- end}}
+EOF
+chmod 0755 /etc/eks/pre-pull-images.sh
+cat <<'EOF' > /etc/systemd/system/pre-pull-images.service
+[Unit]
+Description=Pre-pull container images before the kubelet starts
+Wants=containerd.service
+After=containerd.service
+Before=kubelet.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=true
+EnvironmentFile=-/etc/environment
+ExecStart=/etc/eks/pre-pull-images.sh
+EOF
+mkdir -p /etc/systemd/system/kubelet.service.d
+cat <<'EOF' > /etc/systemd/system/kubelet.service.d/10-pre-pull-images.conf
+[Unit]
+Wants=pre-pull-images.service
+After=pre-pull-images.service
+EOF
+systemctl daemon-reload
+{{- end}}`
+
+	// linuxCABundleConfiguration adds the CA bundle to the system trust store, containerd is restarted to load it
+	linuxCABundleConfiguration = `
+{{- with .CABundle}}
+cat <<'EOF' > /etc/pki/ca-trust/source/anchors/instance-manager-ca-bundle.crt
+{{ .PEM }}
+EOF
+update-ca-trust extract
+systemctl try-restart containerd
+{{- end}}`
+
+	// linuxProxyEnvironment exports the proxy environment written by linuxProxyConfiguration in a shell script
+	linuxProxyEnvironment = `
+{{- if .Proxy}}
+set -a
+. /etc/environment
+set +a
+{{- end}}`
+
+	// linuxRetryFunction defines retry in the shell scripts of Linux userdata when bootstrap retries are configured, it
+	// runs a command until it succeeds or runs out of attempts. Commands are not logged as they may contain credentials.
+	linuxRetryFunction = `
+{{- with .BootstrapRetries}}
+retry() {
+	local attempt=1
+	until "$@"; do
+		if [[ $attempt -ge {{ .Attempts }} ]]; then
+			echo "$1 failed after {{ .Attempts }} attempts" >&2
+			return 1
+		fi
+		sleep $(( {{ .BackoffSeconds }} * attempt ))
+		attempt=$(( attempt + 1 ))
+	done
+}
+{{- end}}`
+)
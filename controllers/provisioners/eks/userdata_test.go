@@ -0,0 +1,264 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
+	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+type fakeUserDataRenderer struct {
+	received []EKSUserData
+	err      error
+}
+
+func (r *fakeUserDataRenderer) Render(data EKSUserData) (string, error) {
+	r.received = append(r.received, data)
+	if r.err != nil {
+		return "", r.err
+	}
+	return "#!/bin/bash\n/opt/acme/bootstrap --cluster " + data.ClusterName, nil
+}
+
+func MockUserDataRenderer(t *testing.T, osFamily string, renderer UserDataRenderer) {
+	previous, registered := GetUserDataRenderer(osFamily)
+	RegisterUserDataRenderer(osFamily, renderer)
+	t.Cleanup(func() {
+		osFamily = strings.ToLower(osFamily)
+		userDataRenderersMu.Lock()
+		defer userDataRenderersMu.Unlock()
+		if registered {
+			userDataRenderers[osFamily] = previous
+		} else {
+			delete(userDataRenderers, osFamily)
+		}
+	})
+}
+
+func TestCustomUserDataRenderer(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+
+	// unregistered families fall back to amazonlinux2
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: "acmeos"})
+	g.Expect(ctx.GetOsFamily()).To(gomega.Equal(OsFamilyAmazonLinux2))
+
+	renderer := &fakeUserDataRenderer{}
+	MockUserDataRenderer(t, "AcmeOS", renderer)
+	g.Expect(IsRegisteredOsFamily("acmeos")).To(gomega.BeTrue())
+	g.Expect(ctx.GetOsFamily()).To(gomega.Equal("acmeos"))
+
+	payload := UserDataPayload{PreBootstrap: []string{"echo pre"}}
+	userData, err := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "--v=2", payload, nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(userData)).To(gomega.Equal("#!/bin/bash\n/opt/acme/bootstrap --cluster my-cluster"))
+
+	g.Expect(renderer.received).To(gomega.HaveLen(1))
+	g.Expect(renderer.received[0].OsFamily).To(gomega.Equal("acmeos"))
+	g.Expect(renderer.received[0].KubeletExtraArgs).To(gomega.Equal("--v=2"))
+	g.Expect(renderer.received[0].PreBootstrap).To(gomega.Equal([]string{"echo pre"}))
+	g.Expect(renderer.received[0].NodeLabels).To(gomega.HaveKey("node.kubernetes.io/role"))
+
+	// built-in families are not affected by the custom renderer
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyBottleRocket})
+	userData, err = base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(userData)).To(gomega.ContainSubstring("[settings.kubernetes]"))
+	g.Expect(renderer.received).To(gomega.HaveLen(1))
+}
+
+func TestOverrideUserDataRenderer(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyBottleRocket})
+
+	MockUserDataRenderer(t, OsFamilyBottleRocket, &TemplateRenderer{Template: `cluster-name = "{{ .ClusterName }}"`})
+	userData, err := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(userData)).To(gomega.Equal(`cluster-name = "my-cluster"`))
+
+	// renderer errors are logged and render empty userdata
+	MockUserDataRenderer(t, OsFamilyBottleRocket, &fakeUserDataRenderer{err: errors.New("bootstrap unavailable")})
+	g.Expect(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil)).To(gomega.BeEmpty())
+}
+
+func TestTemplateRenderer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	out, err := (&TemplateRenderer{Template: `{{ .ClusterName | ToLower }}`}).Render(EKSUserData{ClusterName: "My-Cluster"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(out).To(gomega.Equal("my-cluster"))
+
+	_, err = (&TemplateRenderer{Template: `{{ .ClusterName `}).Render(EKSUserData{})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = (&TemplateRenderer{Template: `{{ .Unknown }}`}).Render(EKSUserData{})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestTemplateRendererVariables(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyBottleRocket})
+	ig.GetEKSConfiguration().UserDataVariables = map[string]string{
+		"ProxyURL": "http://proxy.internal:3128",
+		"Registry": "registry.internal",
+	}
+
+	MockUserDataRenderer(t, OsFamilyBottleRocket, &TemplateRenderer{Template: `cluster-name = "{{ .ClusterName }}"
+https-proxy = "{{ .ProxyURL }}"
+registry = "{{ .Registry }}"
+{{- with .WindowsContainerd }} unexpected{{ end }}`})
+	userData, err := base64.StdEncoding.DecodeString(ctx.GetBasicUserData("my-cluster", "", "", UserDataPayload{}, nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(userData)).To(gomega.Equal(`cluster-name = "my-cluster"
+https-proxy = "http://proxy.internal:3128"
+registry = "registry.internal"`))
+
+	// variables cannot replace userdata fields
+	out, err := (&TemplateRenderer{Template: `{{ .ClusterName }}`}).Render(EKSUserData{
+		ClusterName: "my-cluster",
+		Variables:   map[string]string{"ClusterName": "other-cluster"},
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(out).To(gomega.Equal("my-cluster"))
+
+	// undefined variables fail the render
+	_, err = (&TemplateRenderer{Template: `{{ .NoProxy }}`}).Render(EKSUserData{Variables: map[string]string{"ProxyURL": "http://proxy.internal:3128"}})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestReservedUserDataVariables(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// every userdata field must be reserved so that variables cannot be shadowed by a field
+	fields := reflect.TypeOf(EKSUserData{})
+	for i := 0; i < fields.NumField(); i++ {
+		g.Expect(v1alpha1.ReservedUserDataVariables).To(gomega.ContainElement(fields.Field(i).Name))
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+	var (
+		g       = gomega.NewGomegaWithT(t)
+		k       = MockKubernetesClientSet()
+		ig      = MockInstanceGroup()
+		asgMock = NewAutoScalingMocker()
+		iamMock = NewIamMocker()
+		eksMock = NewEksMocker()
+		ec2Mock = NewEc2Mocker()
+		ssmMock = NewSsmMocker()
+	)
+
+	w := MockAwsWorker(asgMock, iamMock, eksMock, ec2Mock, ssmMock)
+	ctx := MockContext(ig, k, w)
+	ctx.UserDataExporter = provisioners.NewUserDataExporter(true)
+	iamMock.Role = &iam.Role{
+		Arn:      aws.String("some-arn"),
+		RoleName: aws.String("some-role"),
+	}
+	ig.GetEKSSpec().Type = v1alpha1.LaunchTemplate
+	ig.SetAnnotations(map[string]string{OsFamilyAnnotation: OsFamilyBottleRocket})
+	ig.GetEKSConfiguration().UserDataVariables = map[string]string{
+		"ProxyURL":      "http://proxy.internal:3128",
+		"RegistryToken": "c2VjcmV0LXRva2Vu",
+	}
+
+	MockUserDataRenderer(t, OsFamilyBottleRocket, &TemplateRenderer{Template: `cluster-name = "{{ .ClusterName }}"
+https-proxy = "{{ .ProxyURL }}"
+registry-auth = "{{ .RegistryToken }}"
+api_key = "hard-coded-key"`})
+
+	err := ctx.CloudDiscovery()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	err = ctx.Create()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the exported userdata is the userdata of the launch template, with sensitive values redacted
+	g.Expect(ec2Mock.LaunchTemplateData).NotTo(gomega.BeNil())
+	applied, err := base64.StdEncoding.DecodeString(aws.StringValue(ec2Mock.LaunchTemplateData.UserData))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(applied)).To(gomega.Equal(`cluster-name = "my-cluster"
+https-proxy = "http://proxy.internal:3128"
+registry-auth = "c2VjcmV0LXRva2Vu"
+api_key = "hard-coded-key"`))
+
+	exported, ok := ctx.UserDataExporter.Get(ig.GetNamespace(), ig.GetName())
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(exported.Redacted).To(gomega.BeTrue())
+	g.Expect(exported.UserData).To(gomega.Equal(`cluster-name = "my-cluster"
+https-proxy = "http://proxy.internal:3128"
+registry-auth = "<redacted>"
+api_key = <redacted>`))
+	g.Expect(exported.UserDataHash).To(gomega.Equal(provisioners.UserDataHash(string(applied))))
+	g.Expect(ig.GetStatus().GetUserDataHash()).To(gomega.Equal(exported.UserDataHash))
+
+	// the exported userdata is served by namespace and name
+	server := httptest.NewServer(ctx.UserDataExporter)
+	defer server.Close()
+	resp, err := http.Get(server.URL + provisioners.UserDataDebugPath + ig.GetNamespace() + "/" + ig.GetName())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	defer resp.Body.Close()
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+	served := &provisioners.ExportedUserData{}
+	g.Expect(json.NewDecoder(resp.Body).Decode(served)).To(gomega.Succeed())
+	g.Expect(served).To(gomega.Equal(exported))
+}
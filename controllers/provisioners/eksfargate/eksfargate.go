@@ -96,6 +96,13 @@ func (ctx *FargateInstanceGroupContext) processParameters() {
 	params["Selectors"] = CreateFargateSelectors(spec.GetSelectors())
 	params["Subnets"] = spec.GetSubnets()
 	params["Tags"] = CreateFargateTags(spec.GetTags())
+	for _, tags := range spec.GetTags() {
+		for k := range tags {
+			if awsprovider.IsReservedTagKey(k) {
+				ctx.Log.Info("custom tag uses the reserved prefix and is not propagated", "instancegroup", instanceGroup.NamespacedName(), "prefix", awsprovider.ReservedTagPrefix, "tag", k)
+			}
+		}
+	}
 	params["DefaultRoleName"] = fmt.Sprintf("%v-role", ctx.generateUniqueName())
 	ctx.AwsWorker.Parameters = params
 }
@@ -120,6 +127,9 @@ func CreateFargateTags(tagArray []map[string]string) map[string]*string {
 	tags := make(map[string]*string)
 	for _, t := range tagArray {
 		for k, v := range t {
+			if awsprovider.IsReservedTagKey(k) {
+				continue
+			}
 			vv := new(string)
 			*vv = v
 			tags[k] = vv
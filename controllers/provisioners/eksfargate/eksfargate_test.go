@@ -496,6 +496,12 @@ func TestCreateFargateTags(t *testing.T) {
 	if len(output) != 1 || *output["key1"] != "value1" {
 		t.Fatalf("TestCreateFargateTags: output is %v", output)
 	}
+
+	input = []map[string]string{{"key1": "value1"}, {"aws:key2": "value2"}}
+	output = CreateFargateTags(input)
+	if len(output) != 1 || *output["key1"] != "value1" {
+		t.Fatalf("TestCreateFargateTags: reserved tags were not filtered, output is %v", output)
+	}
 }
 func TestCreateFargateSelectors(t *testing.T) {
 	input := []v1alpha1.EKSFargateSelectors{
@@ -255,7 +255,11 @@ func (ctx *EksManagedInstanceGroupContext) processParameters() {
 	params["Ec2SshKey"] = configuration.KeyPairName
 	params["SourceSecurityGroups"] = configuration.NodeSecurityGroups
 	params["Subnets"] = configuration.Subnets
-	params["Tags"] = configuration.Tags
+	tags, reserved := provisioners.FilterReservedTags(configuration.Tags)
+	if len(reserved) > 0 {
+		ctx.Log.Info("custom tags use the reserved prefix and are not propagated", "instancegroup", instanceGroup.NamespacedName(), "prefix", awsprovider.ReservedTagPrefix, "tags", reserved)
+	}
+	params["Tags"] = tags
 	params["MinSize"] = spec.GetMinSize()
 	params["MaxSize"] = spec.GetMaxSize()
 	ctx.AwsWorker.Parameters = params
@@ -0,0 +1,72 @@
+package provisioners
+
+import (
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+)
+
+const (
+	// LogVerbosityAnnotationKey raises the log verbosity of an instance group's reconcile, e.g. "4" emits the V(4) logs of
+	// the annotated instance group regardless of the controller's log level
+	LogVerbosityAnnotationKey = "instancemgr.keikoproj.io/log-verbosity"
+)
+
+// GetInstanceGroupLogger returns the logger an instance group is reconciled with, it emits verbose logs up to the
+// verbosity of the log verbosity annotation. Instance groups without a valid annotation use the logger unchanged
+func GetInstanceGroupLogger(log logr.Logger, instanceGroup *v1alpha1.InstanceGroup) logr.Logger {
+	value, ok := instanceGroup.GetAnnotations()[LogVerbosityAnnotationKey]
+	if !ok {
+		return log
+	}
+
+	verbosity, err := strconv.Atoi(value)
+	if err != nil || verbosity <= 0 {
+		log.Info("ignoring invalid log verbosity annotation, must be a positive integer", "instancegroup", instanceGroup.NamespacedName(), "annotation", LogVerbosityAnnotationKey, "value", value)
+		return log
+	}
+
+	sink := log.GetSink()
+	if sink == nil {
+		return log
+	}
+	return logr.New(&verboseLogSink{sink: sink, verbosity: verbosity})
+}
+
+// verboseLogSink emits the logs up to its verbosity at the lowest level of the underlying sink, so that they are
+// written even when the underlying sink is less verbose
+type verboseLogSink struct {
+	sink      logr.LogSink
+	verbosity int
+}
+
+// Init skips the frame of this sink when reporting callers, the underlying sink is already initialized
+func (s *verboseLogSink) Init(info logr.RuntimeInfo) {
+	if sink, ok := s.sink.(logr.CallDepthLogSink); ok {
+		s.sink = sink.WithCallDepth(1)
+	}
+}
+
+func (s *verboseLogSink) Enabled(level int) bool {
+	return level <= s.verbosity || s.sink.Enabled(level)
+}
+
+func (s *verboseLogSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if level <= s.verbosity {
+		level = 0
+	}
+	s.sink.Info(level, msg, keysAndValues...)
+}
+
+func (s *verboseLogSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.sink.Error(err, msg, keysAndValues...)
+}
+
+func (s *verboseLogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &verboseLogSink{sink: s.sink.WithValues(keysAndValues...), verbosity: s.verbosity}
+}
+
+func (s *verboseLogSink) WithName(name string) logr.LogSink {
+	return &verboseLogSink{sink: s.sink.WithName(name), verbosity: s.verbosity}
+}
@@ -0,0 +1,53 @@
+package provisioners
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetInstanceGroupLogger(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	mockInstanceGroup := func(name string, annotations map[string]string) *v1alpha1.InstanceGroup {
+		return &v1alpha1.InstanceGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "instance-manager", Annotations: annotations},
+		}
+	}
+
+	tests := []struct {
+		annotations map[string]string
+		expected    []string
+	}{
+		// verbose logs are only emitted for annotated instance groups, up to the annotated verbosity
+		{annotations: nil, expected: []string{"annotated/info", "other/info"}},
+		{annotations: map[string]string{LogVerbosityAnnotationKey: "1"}, expected: []string{"annotated/info", "annotated/debug", "other/info"}},
+		{annotations: map[string]string{LogVerbosityAnnotationKey: "4"}, expected: []string{"annotated/info", "annotated/debug", "annotated/trace", "other/info"}},
+		// invalid verbosities are reported and ignored
+		{annotations: map[string]string{LogVerbosityAnnotationKey: "0"}, expected: []string{"annotated", "annotated/info", "other/info"}},
+		{annotations: map[string]string{LogVerbosityAnnotationKey: "verbose"}, expected: []string{"annotated", "annotated/info", "other/info"}},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc.annotations)
+		var (
+			logged  []string
+			base    = funcr.New(func(prefix, args string) { logged = append(logged, prefix) }, funcr.Options{Verbosity: 0})
+			loggers = []logr.Logger{
+				GetInstanceGroupLogger(base.WithName("annotated"), mockInstanceGroup("annotated", tc.annotations)),
+				GetInstanceGroupLogger(base.WithName("other"), mockInstanceGroup("other", nil)),
+			}
+		)
+
+		for _, log := range loggers {
+			log.WithName("info").Info("message")
+			log.WithName("debug").V(1).Info("message")
+			log.WithName("trace").V(4).Info("message")
+		}
+		g.Expect(logged).To(gomega.Equal(tc.expected))
+	}
+}
@@ -0,0 +1,122 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/keikoproj/instance-manager/controllers/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultResourcePrefixTemplate names the resources of an instance group after its cluster, namespace and name
+	DefaultResourcePrefixTemplate = "{{ .ClusterName }}-{{ .Namespace }}-{{ .Name }}"
+	// MaxResourcePrefixLength is the longest resource prefix which still fits the AWS limit of 128 characters of launch
+	// template names once the '-<timestamp>' suffix of scaling configurations is appended
+	MaxResourcePrefixLength = 113
+	// resourceNameHashLength is the length of the hash suffix of truncated resource names
+	resourceNameHashLength = 8
+)
+
+var (
+	// ResourcePrefixRegex matches the characters AWS accepts in the names of all resources derived from a resource prefix
+	ResourcePrefixRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+)
+
+// ResourcePrefixVariables are the variables a resource prefix template is rendered with
+type ResourcePrefixVariables struct {
+	ClusterName string
+	Namespace   string
+	Name        string
+}
+
+// ResourcePrefixTemplate renders the prefix of the names of an instance group's scaling groups, scaling configurations,
+// IAM role and instance profile
+type ResourcePrefixTemplate struct {
+	template *template.Template
+}
+
+// ParseResourcePrefixTemplate parses a resource prefix template, templates may only reference ResourcePrefixVariables
+func ParseResourcePrefixTemplate(value string) (*ResourcePrefixTemplate, error) {
+	if common.StringEmpty(value) {
+		value = DefaultResourcePrefixTemplate
+	}
+	tmpl, err := template.New("resourcePrefix").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse resource prefix template")
+	}
+
+	t := &ResourcePrefixTemplate{template: tmpl}
+	if _, err := t.Render(ResourcePrefixVariables{ClusterName: "cluster", Namespace: "namespace", Name: "name"}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render returns the resource prefix for the variables, prefixes longer than MaxResourcePrefixLength are truncated
+func (t *ResourcePrefixTemplate) Render(variables ResourcePrefixVariables) (string, error) {
+	return t.RenderExisting(variables, "")
+}
+
+// RenderExisting returns the resource prefix for the variables of an instance group whose scaling group is named
+// existingName, see TruncateResourcePrefix
+func (t *ResourcePrefixTemplate) RenderExisting(variables ResourcePrefixVariables, existingName string) (string, error) {
+	if t == nil {
+		t = defaultResourcePrefixTemplate
+	}
+
+	out := &bytes.Buffer{}
+	if err := t.template.Execute(out, variables); err != nil {
+		return "", errors.Wrap(err, "failed to execute resource prefix template")
+	}
+
+	prefix := out.String()
+	if !ResourcePrefixRegex.MatchString(prefix) {
+		return "", errors.Errorf("resource prefix '%v' must only contain alphanumeric characters, '.', '_' and '-'", prefix)
+	}
+	return TruncateResourcePrefix(prefix, existingName), nil
+}
+
+var defaultResourcePrefixTemplate = &ResourcePrefixTemplate{
+	template: template.Must(template.New("resourcePrefix").Parse(DefaultResourcePrefixTemplate)),
+}
+
+// TruncateResourcePrefix truncates prefixes longer than MaxResourcePrefixLength, unless existingName, the name of the
+// scaling group an instance group already has, was derived from the full prefix. Instance groups created before prefixes
+// were truncated keep the names of their scaling groups, IAM role and instance profile
+func TruncateResourcePrefix(prefix, existingName string) string {
+	if existingName == prefix || strings.HasPrefix(existingName, prefix+"-") {
+		return prefix
+	}
+	return TruncateResourceName(prefix, MaxResourcePrefixLength)
+}
+
+// TruncateResourceName shortens names longer than limit, the end of the name is replaced with a hash of the full name so
+// truncated names of different resources remain unique
+func TruncateResourceName(name string, limit int) string {
+	if len(name) <= limit {
+		return name
+	}
+	hash := common.StringMD5(name)[:resourceNameHashLength]
+	if limit <= resourceNameHashLength {
+		return hash[:limit]
+	}
+	return name[:limit-resourceNameHashLength-1] + "-" + hash
+}
@@ -0,0 +1,105 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestParseResourcePrefixTemplate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	variables := ResourcePrefixVariables{ClusterName: "my-cluster", Namespace: "instance-manager", Name: "bdd-test"}
+
+	tests := []struct {
+		template  string
+		expected  string
+		shouldErr bool
+	}{
+		{template: "", expected: "my-cluster-instance-manager-bdd-test"},
+		{template: DefaultResourcePrefixTemplate, expected: "my-cluster-instance-manager-bdd-test"},
+		{template: "{{ .ClusterName }}.{{ .Name }}", expected: "my-cluster.bdd-test"},
+		{template: "k8s-{{ .Namespace }}_{{ .Name }}", expected: "k8s-instance-manager_bdd-test"},
+		{template: "{{ .Cluster }}-{{ .Name }}", shouldErr: true},
+		{template: "{{ .Name ", shouldErr: true},
+		{template: "{{ .ClusterName }}/{{ .Name }}", shouldErr: true},
+		{template: "{{ .ClusterName }} {{ .Name }}", shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v -> %v", i, tc.template)
+		tmpl, err := ParseResourcePrefixTemplate(tc.template)
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		prefix, err := tmpl.Render(variables)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(prefix).To(gomega.Equal(tc.expected))
+	}
+
+	// a nil template renders the default prefix
+	var tmpl *ResourcePrefixTemplate
+	prefix, err := tmpl.Render(variables)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(prefix).To(gomega.Equal("my-cluster-instance-manager-bdd-test"))
+
+	// prefixes which would exceed the AWS name limits are truncated
+	long := ResourcePrefixVariables{ClusterName: "my-cluster", Namespace: "instance-manager", Name: strings.Repeat("a", 120)}
+	prefix, err = tmpl.Render(long)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(prefix).To(gomega.HaveLen(MaxResourcePrefixLength))
+	g.Expect(prefix).To(gomega.HavePrefix("my-cluster-instance-manager-aaaa"))
+
+	// existing scaling groups named with the full prefix keep their names
+	full := "my-cluster-instance-manager-" + strings.Repeat("a", 120)
+	prefix, err = tmpl.RenderExisting(long, full)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(prefix).To(gomega.Equal(full))
+	prefix, err = tmpl.RenderExisting(long, full+"-us-west-2a")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(prefix).To(gomega.Equal(full))
+	prefix, err = tmpl.RenderExisting(long, "my-cluster-instance-manager-other")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(prefix).To(gomega.HaveLen(MaxResourcePrefixLength))
+}
+
+func TestTruncateResourceName(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		name  = strings.Repeat("a", 100)
+		other = strings.Repeat("a", 99) + "b"
+	)
+
+	g.Expect(TruncateResourceName("my-cluster-ns-name", 64)).To(gomega.Equal("my-cluster-ns-name"))
+	g.Expect(TruncateResourceName(name, 100)).To(gomega.Equal(name))
+
+	truncated := TruncateResourceName(name, 64)
+	g.Expect(truncated).To(gomega.HaveLen(64))
+	g.Expect(truncated).To(gomega.HavePrefix(strings.Repeat("a", 55) + "-"))
+	g.Expect(TruncateResourceName(name, 64)).To(gomega.Equal(truncated))
+
+	// names which only differ past the limit remain unique
+	g.Expect(TruncateResourceName(other, 64)).NotTo(gomega.Equal(truncated))
+	g.Expect(TruncateResourceName(other, 64)).To(gomega.HaveLen(64))
+
+	g.Expect(TruncateResourceName(name, 6)).To(gomega.HaveLen(6))
+}
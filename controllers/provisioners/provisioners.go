@@ -1,6 +1,9 @@
 package provisioners
 
 import (
+	"strings"
+	"time"
+
 	"github.com/go-logr/logr"
 	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -8,12 +11,14 @@ import (
 	"github.com/keikoproj/instance-manager/controllers/common"
 	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
+	"github.com/pkg/errors"
 )
 
 const (
 	TagClusterName            = "instancegroups.keikoproj.io/ClusterName"
 	TagInstanceGroupName      = "instancegroups.keikoproj.io/InstanceGroup"
 	TagInstanceGroupNamespace = "instancegroups.keikoproj.io/Namespace"
+	TagAvailabilityZone       = "instancegroups.keikoproj.io/AvailabilityZone"
 	TagClusterOwnershipFmt    = "kubernetes.io/cluster/%s"
 	TagKubernetesCluster      = "KubernetesCluster"
 
@@ -30,10 +35,66 @@ type ProvisionerInput struct {
 	ConfigRetention            int
 	Metrics                    *common.MetricsCollector
 	DisableWinClusterInjection bool
+	RequeueIntervals           RequeueIntervals
+	UserDataValidator          *UserDataValidator
+	// UserDataExporter keeps the rendered userdata of instance groups for the userdata debug endpoint, nil if disabled
+	UserDataExporter *UserDataExporter
+	// StateExporter keeps the discovered state of instance groups for the state debug endpoint, nil if disabled
+	StateExporter *StateExporter
+	// InstanceProfilePropagationTimeout bounds how long launches rejected for an invalid, newly created, instance profile are retried
+	InstanceProfilePropagationTimeout time.Duration
+	// PricingTable are the instance prices cost estimates are computed with
+	PricingTable awsprovider.PricingTable
+	// ResourcePrefixTemplate renders the prefix of the names of the AWS resources of instance groups, nil for the default
+	ResourcePrefixTemplate *ResourcePrefixTemplate
+	// RotationNotifier notifies change management endpoints before and after node rotations, nil if disabled
+	RotationNotifier *RotationNotifier
+	// AwsCallTimeout is the default deadline of AWS API calls, which instance groups may override
+	AwsCallTimeout time.Duration
+	// OsFamilyManagedPolicies replace the default managed policies of the managed roles of an OS family's instance groups
+	OsFamilyManagedPolicies OsFamilyManagedPolicies
+	// Autoscaler scales the desired capacity of the instance group on its metric, nil if no autoscaler targets it
+	Autoscaler *v1alpha1.InstanceGroupAutoscaler
+	// CrossAccountAuthMappings allows authMappings of IAM roles and users of accounts other than the cluster's account
+	CrossAccountAuthMappings bool
+}
+
+// FilterReservedTags splits custom tags into the tags which are propagated to AWS resources and the keys of tags using
+// the reserved aws: prefix, which cannot be set by users
+func FilterReservedTags(tags []map[string]string) ([]map[string]string, []string) {
+	var (
+		filtered = make([]map[string]string, 0)
+		reserved = make([]string, 0)
+	)
+	for _, tag := range tags {
+		if awsprovider.IsReservedTagKey(tag["key"]) {
+			reserved = append(reserved, tag["key"])
+			continue
+		}
+		filtered = append(filtered, tag)
+	}
+	return filtered, reserved
 }
 
+// RequeueIntervals maps a reconcile state to the interval after which an instance group in that state is requeued
+type RequeueIntervals map[v1alpha1.ReconcileState]time.Duration
+
+const (
+	DefaultRequeueInterval = 10 * time.Second
+)
+
 var (
 	NonRetryableStates = []v1alpha1.ReconcileState{v1alpha1.ReconcileErr, v1alpha1.ReconcileReady, v1alpha1.ReconcileDeleted, v1alpha1.ReconcileLocked}
+	RetryableStates    = []v1alpha1.ReconcileState{
+		v1alpha1.ReconcileInit,
+		v1alpha1.ReconcileInitDelete,
+		v1alpha1.ReconcileInitUpdate,
+		v1alpha1.ReconcileInitCreate,
+		v1alpha1.ReconcileInitUpgrade,
+		v1alpha1.ReconcileDeleting,
+		v1alpha1.ReconcileModifying,
+		v1alpha1.ReconcileModified,
+	}
 )
 
 func IsRetryable(instanceGroup *v1alpha1.InstanceGroup) bool {
@@ -44,3 +105,103 @@ func IsRetryable(instanceGroup *v1alpha1.InstanceGroup) bool {
 	}
 	return true
 }
+
+// GetAwsCallTimeout returns the deadline of an instance group's AWS API calls, eks instance groups may override the
+// default with their awsCallTimeout
+func GetAwsCallTimeout(instanceGroup *v1alpha1.InstanceGroup, defaultTimeout time.Duration) time.Duration {
+	if spec := instanceGroup.GetEKSSpec(); spec != nil && spec.EKSConfiguration != nil {
+		if timeout := spec.EKSConfiguration.GetAwsCallTimeout(); timeout > 0 {
+			return timeout
+		}
+	}
+	return defaultTimeout
+}
+
+// GetRequeueInterval returns the interval after which an instance group should be requeued, or zero if its state is not retryable
+func GetRequeueInterval(instanceGroup *v1alpha1.InstanceGroup, intervals RequeueIntervals) time.Duration {
+	if !IsRetryable(instanceGroup) {
+		return 0
+	}
+	if interval, ok := intervals[instanceGroup.GetState()]; ok && interval > 0 {
+		return interval
+	}
+	return DefaultRequeueInterval
+}
+
+// ParseRequeueIntervals parses a comma separated list of state=duration pairs, e.g. "ReconcileModifying=5s,InitUpgrade=30s"
+func ParseRequeueIntervals(value string) (RequeueIntervals, error) {
+	intervals := make(RequeueIntervals)
+	if common.StringEmpty(value) {
+		return intervals, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid requeue interval '%v', must be in the format state=duration", pair)
+		}
+
+		state := v1alpha1.ReconcileState(strings.TrimSpace(parts[0]))
+		if !isRetryableState(state) {
+			return nil, errors.Errorf("invalid requeue interval state '%v', must be one of %v", state, RetryableStates)
+		}
+
+		interval, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || interval <= 0 {
+			return nil, errors.Errorf("invalid requeue interval duration '%v' for state '%v', must be a positive duration", parts[1], state)
+		}
+		intervals[state] = interval
+	}
+	return intervals, nil
+}
+
+// OsFamilyManagedPolicies maps an OS family to the managed policies which are attached to the managed roles of its
+// instance groups in place of the default managed policies
+type OsFamilyManagedPolicies map[string][]string
+
+// Get returns the managed policies of the OS family, or nil if the OS family uses the default managed policies
+func (p OsFamilyManagedPolicies) Get(osFamily string) []string {
+	return p[strings.ToLower(osFamily)]
+}
+
+// ParseOsFamilyManagedPolicies parses a semicolon separated list of family=policies pairs, where policies is a comma
+// separated list of policy names or ARNs, e.g. "windows=AmazonEKSWorkerNodePolicy,AmazonSSMManagedInstanceCore"
+func ParseOsFamilyManagedPolicies(value string) (OsFamilyManagedPolicies, error) {
+	policies := make(OsFamilyManagedPolicies)
+	if common.StringEmpty(value) {
+		return policies, nil
+	}
+
+	for _, pair := range strings.Split(value, ";") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || common.StringEmpty(strings.TrimSpace(parts[0])) {
+			return nil, errors.Errorf("invalid os family managed policies '%v', must be in the format family=policy,policy", pair)
+		}
+
+		osFamily := strings.ToLower(strings.TrimSpace(parts[0]))
+		if _, ok := policies[osFamily]; ok {
+			return nil, errors.Errorf("invalid os family managed policies, os family '%v' is configured more than once", osFamily)
+		}
+
+		names := make([]string, 0)
+		for _, name := range strings.Split(parts[1], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, errors.Errorf("invalid os family managed policies for os family '%v', at least one policy is required", osFamily)
+		}
+		policies[osFamily] = names
+	}
+	return policies, nil
+}
+
+func isRetryableState(state v1alpha1.ReconcileState) bool {
+	for _, s := range RetryableStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
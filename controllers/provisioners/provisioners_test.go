@@ -0,0 +1,162 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+func TestFilterReservedTags(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tags := []map[string]string{
+		{"key": "team", "value": "platform"},
+		{"key": "aws:cloudformation:stack-name", "value": "my-stack"},
+		{"key": "AWS:Owner", "value": "someone"},
+		{"key": "cost-center/aws:id", "value": "1234"},
+	}
+	filtered, reserved := FilterReservedTags(tags)
+	g.Expect(filtered).To(gomega.Equal([]map[string]string{{"key": "team", "value": "platform"}, {"key": "cost-center/aws:id", "value": "1234"}}))
+	g.Expect(reserved).To(gomega.Equal([]string{"aws:cloudformation:stack-name", "AWS:Owner"}))
+
+	filtered, reserved = FilterReservedTags(nil)
+	g.Expect(filtered).To(gomega.BeEmpty())
+	g.Expect(reserved).To(gomega.BeEmpty())
+}
+
+func TestGetRequeueInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	intervals := RequeueIntervals{
+		v1alpha1.ReconcileModifying:   2 * time.Second,
+		v1alpha1.ReconcileInitUpgrade: 30 * time.Second,
+		// non-retryable states are never requeued
+		v1alpha1.ReconcileReady: time.Minute,
+	}
+
+	tests := []struct {
+		state     v1alpha1.ReconcileState
+		intervals RequeueIntervals
+		expected  time.Duration
+	}{
+		{state: v1alpha1.ReconcileInit, intervals: nil, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileInitCreate, intervals: nil, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileInitUpdate, intervals: nil, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileInitDelete, intervals: nil, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileInitUpgrade, intervals: nil, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileDeleting, intervals: nil, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileModifying, intervals: nil, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileModified, intervals: nil, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileReady, intervals: nil, expected: 0},
+		{state: v1alpha1.ReconcileErr, intervals: nil, expected: 0},
+		{state: v1alpha1.ReconcileDeleted, intervals: nil, expected: 0},
+		{state: v1alpha1.ReconcileLocked, intervals: nil, expected: 0},
+		{state: v1alpha1.ReconcileModifying, intervals: intervals, expected: 2 * time.Second},
+		{state: v1alpha1.ReconcileInitUpgrade, intervals: intervals, expected: 30 * time.Second},
+		{state: v1alpha1.ReconcileModified, intervals: intervals, expected: DefaultRequeueInterval},
+		{state: v1alpha1.ReconcileReady, intervals: intervals, expected: 0},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %v", i, tc.state)
+		ig := &v1alpha1.InstanceGroup{}
+		ig.SetState(tc.state)
+		g.Expect(GetRequeueInterval(ig, tc.intervals)).To(gomega.Equal(tc.expected))
+	}
+}
+
+func TestGetAwsCallTimeout(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	mockInstanceGroup := func(timeout string) *v1alpha1.InstanceGroup {
+		return &v1alpha1.InstanceGroup{
+			Spec: v1alpha1.InstanceGroupSpec{
+				EKSSpec: &v1alpha1.EKSSpec{EKSConfiguration: &v1alpha1.EKSConfiguration{AwsCallTimeout: timeout}},
+			},
+		}
+	}
+
+	g.Expect(GetAwsCallTimeout(mockInstanceGroup(""), time.Minute)).To(gomega.Equal(time.Minute))
+	g.Expect(GetAwsCallTimeout(mockInstanceGroup("30s"), time.Minute)).To(gomega.Equal(30 * time.Second))
+	g.Expect(GetAwsCallTimeout(mockInstanceGroup("10m"), 0)).To(gomega.Equal(10 * time.Minute))
+	g.Expect(GetAwsCallTimeout(&v1alpha1.InstanceGroup{}, time.Minute)).To(gomega.Equal(time.Minute))
+}
+
+func TestParseRequeueIntervals(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		value     string
+		expected  RequeueIntervals
+		shouldErr bool
+	}{
+		{value: "", expected: RequeueIntervals{}},
+		{value: "ReconcileModifying=5s", expected: RequeueIntervals{v1alpha1.ReconcileModifying: 5 * time.Second}},
+		{value: "ReconcileModifying=5s, InitUpgrade=1m", expected: RequeueIntervals{v1alpha1.ReconcileModifying: 5 * time.Second, v1alpha1.ReconcileInitUpgrade: time.Minute}},
+		{value: "ReconcileModifying", shouldErr: true},
+		{value: "Ready=5s", shouldErr: true},
+		{value: "SomeState=5s", shouldErr: true},
+		{value: "ReconcileModifying=soon", shouldErr: true},
+		{value: "ReconcileModifying=-5s", shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %v", i, tc.value)
+		intervals, err := ParseRequeueIntervals(tc.value)
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(intervals).To(gomega.Equal(tc.expected))
+	}
+}
+
+func TestParseOsFamilyManagedPolicies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		value     string
+		expected  OsFamilyManagedPolicies
+		shouldErr bool
+	}{
+		{value: "", expected: OsFamilyManagedPolicies{}},
+		{value: "windows=AmazonEKSWorkerNodePolicy", expected: OsFamilyManagedPolicies{"windows": {"AmazonEKSWorkerNodePolicy"}}},
+		{value: "Windows=AmazonEKSWorkerNodePolicy, AmazonSSMManagedInstanceCore; amazonlinux2=arn:aws:iam::123456789012:policy/linux-nodes", expected: OsFamilyManagedPolicies{"windows": {"AmazonEKSWorkerNodePolicy", "AmazonSSMManagedInstanceCore"}, "amazonlinux2": {"arn:aws:iam::123456789012:policy/linux-nodes"}}},
+		{value: "windows", shouldErr: true},
+		{value: "=AmazonEKSWorkerNodePolicy", shouldErr: true},
+		{value: "windows=", shouldErr: true},
+		{value: "windows=AmazonEKSWorkerNodePolicy;windows=AmazonSSMManagedInstanceCore", shouldErr: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - %v", i, tc.value)
+		policies, err := ParseOsFamilyManagedPolicies(tc.value)
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(policies).To(gomega.Equal(tc.expected))
+	}
+
+	g.Expect(OsFamilyManagedPolicies{"windows": {"AmazonEKSWorkerNodePolicy"}}.Get("Windows")).To(gomega.Equal([]string{"AmazonEKSWorkerNodePolicy"}))
+	g.Expect(OsFamilyManagedPolicies(nil).Get("windows")).To(gomega.BeNil())
+}
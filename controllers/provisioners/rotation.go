@@ -0,0 +1,162 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultRotationWebhookTimeout = 10 * time.Second
+
+	RotationPhasePre  = "pre-rotation"
+	RotationPhasePost = "post-rotation"
+)
+
+// RotationNotifier notifies external endpoints before the nodes of an instance group are rotated and after the rotation
+// completes, the pre-rotation endpoint must approve the rotation before it begins
+type RotationNotifier struct {
+	PreRotationURL  string
+	PostRotationURL string
+	Timeout         time.Duration
+	FailOpen        bool
+	Client          *http.Client
+	Log             logr.Logger
+}
+
+// RotationWebhookRequest is the payload posted to the rotation endpoints
+type RotationWebhookRequest struct {
+	Phase         string `json:"phase"`
+	InstanceGroup string `json:"instanceGroup"`
+	Namespace     string `json:"namespace"`
+	// DesiredCapacity is the desired capacity of the instance group's scaling group
+	DesiredCapacity int `json:"desiredCapacity"`
+	// Instances is the number of instances in the instance group's scaling group
+	Instances int `json:"instances"`
+	// PendingInstances is the number of instances which are to be rotated, it is zero once the rotation completed
+	PendingInstances int `json:"pendingInstances"`
+}
+
+// RotationWebhookResponse is the payload expected from the pre-rotation endpoint
+type RotationWebhookResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// RotationDeniedError is returned when the pre-rotation endpoint did not approve the rotation
+type RotationDeniedError struct {
+	Reason string
+}
+
+func (e *RotationDeniedError) Error() string {
+	return "rotation was denied by pre-rotation hook: " + e.Reason
+}
+
+// NewRotationNotifier returns a notifier for the given endpoints, or nil if no endpoint is configured
+func NewRotationNotifier(preRotationURL, postRotationURL string, timeout time.Duration, failOpen bool, log logr.Logger) *RotationNotifier {
+	if preRotationURL == "" && postRotationURL == "" {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultRotationWebhookTimeout
+	}
+	return &RotationNotifier{
+		PreRotationURL:  preRotationURL,
+		PostRotationURL: postRotationURL,
+		Timeout:         timeout,
+		FailOpen:        failOpen,
+		Client:          &http.Client{Timeout: timeout},
+		Log:             log,
+	}
+}
+
+// PreRotation posts the request to the pre-rotation endpoint, a denial always blocks the rotation while errors reaching
+// the endpoint only block it when the notifier is not configured to fail open
+func (n *RotationNotifier) PreRotation(request *RotationWebhookRequest) error {
+	if n.PreRotationURL == "" {
+		return nil
+	}
+
+	request.Phase = RotationPhasePre
+	response, err := n.submit(n.PreRotationURL, request)
+	if err != nil {
+		if n.FailOpen {
+			n.Log.Error(err, "pre-rotation hook failed, proceeding since rotation hooks fail open", "instancegroup", request.Namespace+"/"+request.InstanceGroup)
+			return nil
+		}
+		return errors.Wrap(err, "pre-rotation hook failed")
+	}
+
+	if !response.Approved {
+		return &RotationDeniedError{Reason: response.Reason}
+	}
+	return nil
+}
+
+// PostRotation posts the request to the post-rotation endpoint, the response body is ignored
+func (n *RotationNotifier) PostRotation(request *RotationWebhookRequest) error {
+	if n.PostRotationURL == "" {
+		return nil
+	}
+
+	request.Phase = RotationPhasePost
+	if _, err := n.post(n.PostRotationURL, request); err != nil {
+		return errors.Wrap(err, "post-rotation hook failed")
+	}
+	return nil
+}
+
+func (n *RotationNotifier) submit(url string, request *RotationWebhookRequest) (*RotationWebhookResponse, error) {
+	body, err := n.post(url, request)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RotationWebhookResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal rotation hook response")
+	}
+	return response, nil
+}
+
+func (n *RotationNotifier) post(url string, request *RotationWebhookRequest) ([]byte, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := n.Client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("rotation hook returned status %v: %v", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,126 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onsi/gomega"
+)
+
+func MockRotationWebhookServer(t *testing.T, status int, response *RotationWebhookResponse, delay time.Duration) (*httptest.Server, *RotationWebhookRequest) {
+	received := &RotationWebhookRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Errorf("failed to decode rotation request: %v", err)
+		}
+		time.Sleep(delay)
+		w.WriteHeader(status)
+		if response != nil {
+			json.NewEncoder(w).Encode(response)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestNewRotationNotifier(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(NewRotationNotifier("", "", time.Second, false, logr.Discard())).To(gomega.BeNil())
+
+	notifier := NewRotationNotifier("", "http://localhost", 0, true, logr.Discard())
+	g.Expect(notifier).NotTo(gomega.BeNil())
+	g.Expect(notifier.Timeout).To(gomega.Equal(DefaultRotationWebhookTimeout))
+	g.Expect(notifier.Client.Timeout).To(gomega.Equal(DefaultRotationWebhookTimeout))
+	g.Expect(notifier.FailOpen).To(gomega.BeTrue())
+
+	// rotations are not blocked without a pre-rotation endpoint
+	g.Expect(notifier.PreRotation(&RotationWebhookRequest{})).To(gomega.Succeed())
+}
+
+func TestRotationNotifierPreRotation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		status    int
+		response  *RotationWebhookResponse
+		delay     time.Duration
+		failOpen  bool
+		shouldErr bool
+		denied    bool
+	}{
+		// approved rotations proceed
+		{status: http.StatusOK, response: &RotationWebhookResponse{Approved: true}},
+		// denied rotations are always blocked, even when failing open
+		{status: http.StatusOK, response: &RotationWebhookResponse{Approved: false, Reason: "change freeze"}, shouldErr: true, denied: true},
+		{status: http.StatusOK, response: &RotationWebhookResponse{Approved: false, Reason: "change freeze"}, failOpen: true, shouldErr: true, denied: true},
+		// endpoint errors block unless failing open
+		{status: http.StatusServiceUnavailable, shouldErr: true},
+		{status: http.StatusServiceUnavailable, failOpen: true},
+		{status: http.StatusOK, response: &RotationWebhookResponse{Approved: true}, delay: 200 * time.Millisecond, shouldErr: true},
+		{status: http.StatusOK, response: &RotationWebhookResponse{Approved: true}, delay: 200 * time.Millisecond, failOpen: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - status: %v, failOpen: %v", i, tc.status, tc.failOpen)
+		server, received := MockRotationWebhookServer(t, tc.status, tc.response, tc.delay)
+		notifier := NewRotationNotifier(server.URL, "", 50*time.Millisecond, tc.failOpen, logr.Discard())
+
+		err := notifier.PreRotation(&RotationWebhookRequest{InstanceGroup: "my-group", Namespace: "instance-manager", DesiredCapacity: 3, Instances: 3, PendingInstances: 2})
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+
+		_, isDenied := err.(*RotationDeniedError)
+		g.Expect(isDenied).To(gomega.Equal(tc.denied))
+		if tc.denied {
+			g.Expect(err.Error()).To(gomega.ContainSubstring(tc.response.Reason))
+		}
+
+		if tc.delay == 0 {
+			g.Expect(*received).To(gomega.Equal(RotationWebhookRequest{
+				Phase:            RotationPhasePre,
+				InstanceGroup:    "my-group",
+				Namespace:        "instance-manager",
+				DesiredCapacity:  3,
+				Instances:        3,
+				PendingInstances: 2,
+			}))
+		}
+	}
+}
+
+func TestRotationNotifierPostRotation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server, received := MockRotationWebhookServer(t, http.StatusOK, nil, 0)
+	notifier := NewRotationNotifier("", server.URL, time.Second, false, logr.Discard())
+	g.Expect(notifier.PostRotation(&RotationWebhookRequest{InstanceGroup: "my-group", Namespace: "instance-manager", DesiredCapacity: 3, Instances: 3})).To(gomega.Succeed())
+	g.Expect(received.Phase).To(gomega.Equal(RotationPhasePost))
+	g.Expect(received.InstanceGroup).To(gomega.Equal("my-group"))
+
+	server, _ = MockRotationWebhookServer(t, http.StatusInternalServerError, nil, 0)
+	notifier = NewRotationNotifier("", server.URL, time.Second, true, logr.Discard())
+	g.Expect(notifier.PostRotation(&RotationWebhookRequest{})).NotTo(gomega.Succeed())
+}
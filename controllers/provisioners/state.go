@@ -0,0 +1,112 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StateDebugPath is the path of the metrics server the exported discovered state is served under, as
+// /debug/state/<namespace>/<name>
+const StateDebugPath = "/debug/state/"
+
+// StateExporter keeps the discovered state of each instance group from its last reconcile, so that operators can see
+// what the controller computed for a misbehaving instance group. Provisioners export a view of their state which
+// leaves out sensitive fields.
+type StateExporter struct {
+	sync.RWMutex
+	states map[string]*ExportedState
+}
+
+// ExportedState is the payload served for an instance group by the state debug endpoint
+type ExportedState struct {
+	InstanceGroup string          `json:"instanceGroup"`
+	Namespace     string          `json:"namespace"`
+	RefreshedAt   time.Time       `json:"refreshedAt"`
+	State         json.RawMessage `json:"state"`
+}
+
+// NewStateExporter returns an exporter, or nil if exporting discovered state is not enabled
+func NewStateExporter(enabled bool) *StateExporter {
+	if !enabled {
+		return nil
+	}
+	return &StateExporter{
+		states: make(map[string]*ExportedState),
+	}
+}
+
+// Export stores the serialized view of an instance group's discovered state, replacing the view of its last reconcile
+func (e *StateExporter) Export(namespace, name string, view interface{}) error {
+	state, err := json.Marshal(view)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal discovered state")
+	}
+
+	e.Lock()
+	defer e.Unlock()
+	e.states[namespace+"/"+name] = &ExportedState{
+		InstanceGroup: name,
+		Namespace:     namespace,
+		RefreshedAt:   time.Now().UTC(),
+		State:         state,
+	}
+	return nil
+}
+
+// Get returns the exported discovered state of an instance group
+func (e *StateExporter) Get(namespace, name string) (*ExportedState, bool) {
+	e.RLock()
+	defer e.RUnlock()
+	exported, ok := e.states[namespace+"/"+name]
+	return exported, ok
+}
+
+// Remove drops the exported discovered state of a deleted instance group
+func (e *StateExporter) Remove(namespace, name string) {
+	e.Lock()
+	defer e.Unlock()
+	delete(e.states, namespace+"/"+name)
+}
+
+// ServeHTTP serves the exported discovered state of the instance group at StateDebugPath/<namespace>/<name>
+func (e *StateExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, StateDebugPath), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, "expected path "+StateDebugPath+"<namespace>/<name>", http.StatusBadRequest)
+		return
+	}
+
+	exported, ok := e.Get(parts[0], parts[1])
+	if !ok {
+		http.Error(w, "no state discovered for instance group "+parts[0]+"/"+parts[1], http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exported)
+}
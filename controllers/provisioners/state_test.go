@@ -0,0 +1,82 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestStateExporter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(NewStateExporter(false)).To(gomega.BeNil())
+
+	type view struct {
+		ScalingGroup string `json:"scalingGroup"`
+	}
+	exporter := NewStateExporter(true)
+	g.Expect(exporter.Export("instance-manager", "my-instance-group", view{ScalingGroup: "my-scaling-group"})).To(gomega.Succeed())
+	g.Expect(exporter.Export("instance-manager", "bad-instance-group", func() {})).NotTo(gomega.Succeed())
+	server := httptest.NewServer(exporter)
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		expected *view
+	}{
+		{method: http.MethodGet, path: "instance-manager/my-instance-group", status: http.StatusOK, expected: &view{ScalingGroup: "my-scaling-group"}},
+		{method: http.MethodGet, path: "instance-manager/bad-instance-group", status: http.StatusNotFound},
+		{method: http.MethodGet, path: "my-instance-group", status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "instance-manager/my-instance-group", status: http.StatusMethodNotAllowed},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %v %v", i, tc.method, tc.path)
+		req, err := http.NewRequest(tc.method, server.URL+StateDebugPath+tc.path, nil)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		resp, err := server.Client().Do(req)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(resp.StatusCode).To(gomega.Equal(tc.status))
+		if tc.expected != nil {
+			exported := &ExportedState{}
+			g.Expect(json.NewDecoder(resp.Body).Decode(exported)).To(gomega.Succeed())
+			g.Expect(exported.InstanceGroup).To(gomega.Equal("my-instance-group"))
+			g.Expect(exported.Namespace).To(gomega.Equal("instance-manager"))
+			g.Expect(exported.RefreshedAt.IsZero()).To(gomega.BeFalse())
+			state := &view{}
+			g.Expect(json.Unmarshal(exported.State, state)).To(gomega.Succeed())
+			g.Expect(state).To(gomega.Equal(tc.expected))
+		}
+		resp.Body.Close()
+	}
+
+	// a later reconcile replaces the exported state
+	g.Expect(exporter.Export("instance-manager", "my-instance-group", view{ScalingGroup: "other-scaling-group"})).To(gomega.Succeed())
+	exported, ok := exporter.Get("instance-manager", "my-instance-group")
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(string(exported.State)).To(gomega.Equal(`{"scalingGroup":"other-scaling-group"}`))
+
+	exporter.Remove("instance-manager", "my-instance-group")
+	_, ok = exporter.Get("instance-manager", "my-instance-group")
+	g.Expect(ok).To(gomega.BeFalse())
+}
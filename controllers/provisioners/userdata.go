@@ -0,0 +1,259 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultUserDataValidationTimeout = 10 * time.Second
+	// UserDataDebugPath is the path of the metrics server the exported userdata is served under, as
+	// /debug/userdata/<namespace>/<name>
+	UserDataDebugPath = "/debug/userdata/"
+	RedactedValue     = "<redacted>"
+)
+
+var (
+	// SensitiveNameRegex matches the names of userdata variables and assignments whose values are redacted from exported userdata
+	SensitiveNameRegex = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|access_?key|private_?key|credential)`)
+	// sensitiveAssignmentRegex matches assignments such as TOKEN=value, --password value or "secret": "value" whose name
+	// is sensitive, and captures everything before the value
+	sensitiveAssignmentRegex = regexp.MustCompile(`(?i)((?:[A-Za-z0-9_\-]*(?:password|passwd|secret|token|api_?key|access_?key|private_?key)[A-Za-z0-9_\-]*["']?\s*[=:]\s*)|(?:--[A-Za-z0-9\-]*(?:password|passwd|secret|token|api_?key|access_?key|private_?key)[A-Za-z0-9\-]*\s+))("[^"\n]*"|'[^'\n]*'|[^\s"']+)`)
+)
+
+// UserDataValidator submits the rendered userdata of an instance group to an external endpoint, which must approve it
+// before a scaling configuration using it is created
+type UserDataValidator struct {
+	URL      string
+	Timeout  time.Duration
+	FailOpen bool
+	Client   *http.Client
+	Log      logr.Logger
+}
+
+// UserDataValidationRequest is the payload posted to the validation endpoint
+type UserDataValidationRequest struct {
+	InstanceGroup string `json:"instanceGroup"`
+	Namespace     string `json:"namespace"`
+	UserData      string `json:"userData"`
+}
+
+// UserDataValidationResponse is the payload expected from the validation endpoint
+type UserDataValidationResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// UserDataDeniedError is returned when the validation endpoint did not approve the userdata
+type UserDataDeniedError struct {
+	Reason string
+}
+
+func (e *UserDataDeniedError) Error() string {
+	return "userdata was denied by validation hook: " + e.Reason
+}
+
+// NewUserDataValidator returns a validator for the given endpoint, or nil if no endpoint is configured
+func NewUserDataValidator(url string, timeout time.Duration, failOpen bool, log logr.Logger) *UserDataValidator {
+	if url == "" {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultUserDataValidationTimeout
+	}
+	return &UserDataValidator{
+		URL:      url,
+		Timeout:  timeout,
+		FailOpen: failOpen,
+		Client:   &http.Client{Timeout: timeout},
+		Log:      log,
+	}
+}
+
+// Validate posts the userdata to the validation endpoint, a denial always fails while errors reaching the endpoint
+// only fail when the validator is not configured to fail open
+func (v *UserDataValidator) Validate(namespace, name, userData string) error {
+	response, err := v.submit(&UserDataValidationRequest{
+		InstanceGroup: name,
+		Namespace:     namespace,
+		UserData:      userData,
+	})
+	if err != nil {
+		if v.FailOpen {
+			v.Log.Error(err, "userdata validation failed, proceeding since validation hook fails open", "instancegroup", namespace+"/"+name)
+			return nil
+		}
+		return errors.Wrap(err, "userdata validation failed")
+	}
+
+	if !response.Approved {
+		return &UserDataDeniedError{Reason: response.Reason}
+	}
+	return nil
+}
+
+func (v *UserDataValidator) submit(request *UserDataValidationRequest) (*UserDataValidationResponse, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.Client.Post(v.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("validation hook returned status %v: %v", resp.StatusCode, string(body))
+	}
+
+	response := &UserDataValidationResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal validation hook response")
+	}
+	return response, nil
+}
+
+// UserDataExporter keeps the last rendered userdata of each instance group, redacted of likely secrets, so that operators
+// can inspect and diff it before it is rolled out
+type UserDataExporter struct {
+	sync.RWMutex
+	userData map[string]*ExportedUserData
+}
+
+// ExportedUserData is the payload served for an instance group by the userdata debug endpoint
+type ExportedUserData struct {
+	InstanceGroup string `json:"instanceGroup"`
+	Namespace     string `json:"namespace"`
+	// UserDataHash is the hash of the userdata before redaction, it matches the instance group's status.userDataHash
+	UserDataHash string `json:"userDataHash"`
+	UserData     string `json:"userData"`
+	Redacted     bool   `json:"redacted"`
+}
+
+// NewUserDataExporter returns an exporter, or nil if exporting userdata is not enabled
+func NewUserDataExporter(enabled bool) *UserDataExporter {
+	if !enabled {
+		return nil
+	}
+	return &UserDataExporter{
+		userData: make(map[string]*ExportedUserData),
+	}
+}
+
+// Export stores the rendered, decoded, userdata of an instance group, values of the given variables which have a
+// sensitive name and values of sensitive assignments are redacted
+func (e *UserDataExporter) Export(namespace, name, userData string, variables map[string]string) {
+	redacted, ok := RedactUserData(userData, variables)
+	e.Lock()
+	defer e.Unlock()
+	e.userData[namespace+"/"+name] = &ExportedUserData{
+		InstanceGroup: name,
+		Namespace:     namespace,
+		UserDataHash:  UserDataHash(userData),
+		UserData:      redacted,
+		Redacted:      ok,
+	}
+}
+
+// Get returns the exported userdata of an instance group
+func (e *UserDataExporter) Get(namespace, name string) (*ExportedUserData, bool) {
+	e.RLock()
+	defer e.RUnlock()
+	exported, ok := e.userData[namespace+"/"+name]
+	return exported, ok
+}
+
+// Remove drops the exported userdata of a deleted instance group
+func (e *UserDataExporter) Remove(namespace, name string) {
+	e.Lock()
+	defer e.Unlock()
+	delete(e.userData, namespace+"/"+name)
+}
+
+// ServeHTTP serves the exported userdata of the instance group at UserDataDebugPath/<namespace>/<name>
+func (e *UserDataExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, UserDataDebugPath), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, "expected path "+UserDataDebugPath+"<namespace>/<name>", http.StatusBadRequest)
+		return
+	}
+
+	exported, ok := e.Get(parts[0], parts[1])
+	if !ok {
+		http.Error(w, "no userdata rendered for instance group "+parts[0]+"/"+parts[1], http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exported)
+}
+
+// UserDataHash returns the hex encoded SHA256 hash of the rendered userdata
+func UserDataHash(userData string) string {
+	sum := sha256.Sum256([]byte(userData))
+	return hex.EncodeToString(sum[:])
+}
+
+// RedactUserData replaces the values of variables with a sensitive name, and the values of sensitive assignments, with
+// RedactedValue and returns whether anything was redacted
+func RedactUserData(userData string, variables map[string]string) (string, bool) {
+	sensitive := make([]string, 0)
+	for name, value := range variables {
+		if value != "" && SensitiveNameRegex.MatchString(name) {
+			sensitive = append(sensitive, value)
+		}
+	}
+	// replace longer values first so that values containing other values are fully redacted
+	sort.Slice(sensitive, func(i, j int) bool { return len(sensitive[i]) > len(sensitive[j]) })
+
+	redacted := userData
+	for _, value := range sensitive {
+		redacted = strings.ReplaceAll(redacted, value, RedactedValue)
+	}
+	redacted = sensitiveAssignmentRegex.ReplaceAllStringFunc(redacted, func(match string) string {
+		groups := sensitiveAssignmentRegex.FindStringSubmatch(match)
+		if groups[2] == RedactedValue || strings.HasPrefix(groups[2], "$") {
+			return match
+		}
+		return groups[1] + RedactedValue
+	})
+	return redacted, redacted != userData
+}
@@ -0,0 +1,183 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioners
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onsi/gomega"
+)
+
+func MockUserDataValidationServer(t *testing.T, status int, response *UserDataValidationResponse, delay time.Duration) (*httptest.Server, *UserDataValidationRequest) {
+	received := &UserDataValidationRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Errorf("failed to decode validation request: %v", err)
+		}
+		time.Sleep(delay)
+		w.WriteHeader(status)
+		if response != nil {
+			json.NewEncoder(w).Encode(response)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestNewUserDataValidator(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(NewUserDataValidator("", time.Second, false, logr.Discard())).To(gomega.BeNil())
+
+	validator := NewUserDataValidator("http://localhost", 0, true, logr.Discard())
+	g.Expect(validator).NotTo(gomega.BeNil())
+	g.Expect(validator.Timeout).To(gomega.Equal(DefaultUserDataValidationTimeout))
+	g.Expect(validator.Client.Timeout).To(gomega.Equal(DefaultUserDataValidationTimeout))
+	g.Expect(validator.FailOpen).To(gomega.BeTrue())
+}
+
+func TestUserDataValidatorValidate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		status    int
+		response  *UserDataValidationResponse
+		delay     time.Duration
+		failOpen  bool
+		shouldErr bool
+		denied    bool
+	}{
+		// approved userdata proceeds
+		{status: http.StatusOK, response: &UserDataValidationResponse{Approved: true}},
+		{status: http.StatusOK, response: &UserDataValidationResponse{Approved: true}, failOpen: true},
+		// denied userdata always fails, even when failing open
+		{status: http.StatusOK, response: &UserDataValidationResponse{Approved: false, Reason: "forbidden command"}, shouldErr: true, denied: true},
+		{status: http.StatusOK, response: &UserDataValidationResponse{Approved: false, Reason: "forbidden command"}, failOpen: true, shouldErr: true, denied: true},
+		// endpoint errors fail unless failing open
+		{status: http.StatusInternalServerError, shouldErr: true},
+		{status: http.StatusInternalServerError, failOpen: true},
+		{status: http.StatusOK, shouldErr: true},
+		{status: http.StatusOK, response: &UserDataValidationResponse{Approved: true}, delay: 200 * time.Millisecond, shouldErr: true},
+		{status: http.StatusOK, response: &UserDataValidationResponse{Approved: true}, delay: 200 * time.Millisecond, failOpen: true},
+	}
+
+	for i, tc := range tests {
+		t.Logf("#%v - status: %v, failOpen: %v", i, tc.status, tc.failOpen)
+		server, received := MockUserDataValidationServer(t, tc.status, tc.response, tc.delay)
+		validator := NewUserDataValidator(server.URL, 50*time.Millisecond, tc.failOpen, logr.Discard())
+
+		err := validator.Validate("instance-manager", "my-group", "#!/bin/bash\necho hello")
+		if tc.shouldErr {
+			g.Expect(err).To(gomega.HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+
+		_, isDenied := err.(*UserDataDeniedError)
+		g.Expect(isDenied).To(gomega.Equal(tc.denied))
+		if tc.denied {
+			g.Expect(err.Error()).To(gomega.ContainSubstring(tc.response.Reason))
+		}
+
+		if tc.delay == 0 {
+			g.Expect(received.Namespace).To(gomega.Equal("instance-manager"))
+			g.Expect(received.InstanceGroup).To(gomega.Equal("my-group"))
+			g.Expect(received.UserData).To(gomega.Equal("#!/bin/bash\necho hello"))
+		}
+	}
+}
+
+func TestRedactUserData(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		userData  string
+		variables map[string]string
+		expected  string
+		redacted  bool
+	}{
+		{userData: "#!/bin/bash\n/etc/eks/bootstrap.sh my-cluster", expected: "#!/bin/bash\n/etc/eks/bootstrap.sh my-cluster"},
+		// values of sensitive variables are redacted wherever they are rendered
+		{userData: "curl -H 'Authorization: abc123' https://registry", variables: map[string]string{"RegistryToken": "abc123", "Region": "us-west-2"}, expected: "curl -H 'Authorization: <redacted>' https://registry", redacted: true},
+		{userData: "region=us-west-2", variables: map[string]string{"Region": "us-west-2"}, expected: "region=us-west-2"},
+		// values of sensitive assignments and flags are redacted
+		{userData: "export API_TOKEN=abc123\necho done", expected: "export API_TOKEN=<redacted>\necho done", redacted: true},
+		{userData: `{"clientSecret": "abc123"}`, expected: `{"clientSecret": <redacted>}`, redacted: true},
+		{userData: "login --password 'abc 123' --user admin", expected: "login --password <redacted> --user admin", redacted: true},
+		// shell references are not secrets
+		{userData: "export API_TOKEN=$(cat /run/token)", expected: "export API_TOKEN=$(cat /run/token)"},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %+v", i, tc.userData)
+		redacted, ok := RedactUserData(tc.userData, tc.variables)
+		g.Expect(redacted).To(gomega.Equal(tc.expected))
+		g.Expect(ok).To(gomega.Equal(tc.redacted))
+	}
+}
+
+func TestUserDataExporter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(NewUserDataExporter(false)).To(gomega.BeNil())
+
+	exporter := NewUserDataExporter(true)
+	exporter.Export("instance-manager", "my-instance-group", "TOKEN=abc123", nil)
+	server := httptest.NewServer(exporter)
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		expected *ExportedUserData
+	}{
+		{method: http.MethodGet, path: "instance-manager/my-instance-group", status: http.StatusOK, expected: &ExportedUserData{
+			InstanceGroup: "my-instance-group",
+			Namespace:     "instance-manager",
+			UserDataHash:  UserDataHash("TOKEN=abc123"),
+			UserData:      "TOKEN=<redacted>",
+			Redacted:      true,
+		}},
+		{method: http.MethodGet, path: "instance-manager/other-instance-group", status: http.StatusNotFound},
+		{method: http.MethodGet, path: "my-instance-group", status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "instance-manager/my-instance-group", status: http.StatusMethodNotAllowed},
+	}
+
+	for i, tc := range tests {
+		t.Logf("Test #%v - %v %v", i, tc.method, tc.path)
+		req, err := http.NewRequest(tc.method, server.URL+UserDataDebugPath+tc.path, nil)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		resp, err := server.Client().Do(req)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(resp.StatusCode).To(gomega.Equal(tc.status))
+		if tc.expected != nil {
+			exported := &ExportedUserData{}
+			g.Expect(json.NewDecoder(resp.Body).Decode(exported)).To(gomega.Succeed())
+			g.Expect(exported).To(gomega.Equal(tc.expected))
+		}
+		resp.Body.Close()
+	}
+
+	exporter.Remove("instance-manager", "my-instance-group")
+	_, ok := exporter.Get("instance-manager", "my-instance-group")
+	g.Expect(ok).To(gomega.BeFalse())
+}
@@ -23,7 +23,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/service/autoscaling"
 	v1alpha1 "github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
+	"github.com/keikoproj/instance-manager/controllers/common"
 	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
 	"github.com/keikoproj/instance-manager/controllers/provisioners"
@@ -34,9 +36,12 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 const (
@@ -44,60 +49,58 @@ const (
 )
 
 func (r *InstanceGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	switch r.NodeRelabel {
-	case true:
-		return ctrl.NewControllerManagedBy(mgr).
-			For(&v1alpha1.InstanceGroup{}).
-			Watches(
-				&corev1.Event{},
-				handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
-					return r.spotEventReconciler(obj)
-				}),
-			).
-			Watches(
-				&corev1.Node{},
-				handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
-					return r.nodeReconciler(obj)
-				}),
-			).
-			Watches(
-				&corev1.ConfigMap{},
-				handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
-					return r.configMapReconciler(obj)
-				}),
-			).
-			Watches(
-				&corev1.Namespace{},
-				handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
-					return r.namespaceReconciler(obj)
-				}),
-			).
-			WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxParallel}).
-			Complete(r)
-	default:
-		return ctrl.NewControllerManagedBy(mgr).
-			For(&v1alpha1.InstanceGroup{}).
-			Watches(
-				&corev1.Event{},
-				handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
-					return r.spotEventReconciler(obj)
-				}),
-			).
-			Watches(
-				&corev1.ConfigMap{},
-				handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
-					return r.configMapReconciler(obj)
-				}),
-			).
-			Watches(
-				&corev1.Namespace{},
-				handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
-					return r.namespaceReconciler(obj)
-				}),
-			).
-			WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxParallel}).
-			Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.InstanceGroup{}).
+		Watches(
+			&corev1.Event{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return r.spotEventReconciler(obj)
+			}),
+		)
+
+	if r.NodeRelabel {
+		b = b.Watches(
+			&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return r.nodeReconciler(obj)
+			}),
+		)
+	}
+
+	if r.WatchNodeConditions {
+		b = b.Watches(
+			&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return r.nodeConditionReconciler(obj)
+			}),
+			builder.WithPredicates(NodeReadyConditionChanged()),
+		)
+	}
+
+	if r.AutoscalersEnabled {
+		b = b.Watches(
+			&v1alpha1.InstanceGroupAutoscaler{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return r.autoscalerReconciler(obj)
+			}),
+		)
 	}
+
+	return b.
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return r.configMapReconciler(obj)
+			}),
+		).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return r.namespaceReconciler(obj)
+			}),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxParallel}).
+		Complete(r)
 }
 
 func (r *InstanceGroupReconciler) configMapReconciler(obj client.Object) []ctrl.Request {
@@ -275,6 +278,122 @@ func (r *InstanceGroupReconciler) nodeReconciler(obj client.Object) []ctrl.Reque
 	return nil
 }
 
+// NodeReadyConditionChanged filters node events to updates which change whether the node is ready
+func NodeReadyConditionChanged() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return kubeprovider.IsNodeReady(*oldNode) != kubeprovider.IsNodeReady(*newNode)
+		},
+	}
+}
+
+// scalingGroupWorkers returns the workers which scaling groups of instance groups are discovered with, the controller's
+// worker followed by a worker for each region and assumed role which instance groups are provisioned with
+func (r *InstanceGroupReconciler) scalingGroupWorkers() []awsprovider.AwsWorker {
+	workers := []awsprovider.AwsWorker{r.Auth.Aws}
+
+	instanceGroups := &v1alpha1.InstanceGroupList{}
+	if err := r.List(context.Background(), instanceGroups); err != nil {
+		r.Log.Error(err, "could not list instancegroups")
+		return workers
+	}
+
+	seen := make(map[string]bool)
+	for i := range instanceGroups.Items {
+		instanceGroup := &instanceGroups.Items[i]
+		spec := instanceGroup.GetEKSSpec()
+		if spec == nil || spec.EKSConfiguration == nil {
+			continue
+		}
+		var (
+			region  = strings.ToLower(spec.EKSConfiguration.GetRegion())
+			roleArn = spec.EKSConfiguration.GetAssumeRoleArn()
+		)
+		if common.StringEmpty(roleArn) && (common.StringEmpty(region) || r.Auth.AwsWorkers == nil || strings.EqualFold(region, r.Auth.AwsWorkers.Region)) {
+			continue
+		}
+		key := fmt.Sprintf("%v/%v", region, roleArn)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		worker, err := r.Auth.GetAwsWorker(instanceGroup)
+		if err != nil {
+			r.Log.Error(err, "failed to get aws worker of instancegroup", "instancegroup", instanceGroup.NamespacedName())
+			continue
+		}
+		workers = append(workers, worker)
+	}
+	return workers
+}
+
+// instanceGroupFromTags returns the instance group a scaling group is tagged with, or an empty name if it has no tags
+func instanceGroupFromTags(tags []*autoscaling.TagDescription) types.NamespacedName {
+	instanceGroup := types.NamespacedName{}
+	instanceGroup.Name = awsprovider.GetTagValueByKey(tags, provisioners.TagInstanceGroupName)
+	instanceGroup.Namespace = awsprovider.GetTagValueByKey(tags, provisioners.TagInstanceGroupNamespace)
+	if instanceGroup.Name == "" || instanceGroup.Namespace == "" {
+		return types.NamespacedName{}
+	}
+	return instanceGroup
+}
+
+// nodeConditionReconciler reconciles the instance group of a node whose ready condition changed, the instance group is
+// found from the tags of the scaling group the node's instance belongs to, which is looked up in the regions and accounts
+// instance groups are provisioned in
+func (r *InstanceGroupReconciler) nodeConditionReconciler(obj client.Object) []ctrl.Request {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return nil
+	}
+
+	instanceId := common.GetLastElementBy(node.Spec.ProviderID, "/")
+	if instanceId == "" {
+		return nil
+	}
+
+	var instanceGroup types.NamespacedName
+	for _, worker := range r.scalingGroupWorkers() {
+		scalingGroupName, err := awsprovider.GetScalingGroupNameByInstanceId(instanceId, worker.AsgClient)
+		if err != nil {
+			r.Log.Error(err, "failed to get scaling group of node", "node", node.GetName(), "instance", instanceId)
+			continue
+		}
+		if scalingGroupName == "" {
+			continue
+		}
+
+		tags, err := worker.GetScalingGroupTagsByName(scalingGroupName)
+		if err != nil {
+			return nil
+		}
+		instanceGroup = instanceGroupFromTags(tags)
+		break
+	}
+	if instanceGroup.Name == "" {
+		return nil
+	}
+
+	ctrl.Log.Info("node ready condition changed", "node", node.GetName(), "ready", kubeprovider.IsNodeReady(*node), "instancegroup", instanceGroup)
+	return []ctrl.Request{
+		{
+			NamespacedName: instanceGroup,
+		},
+	}
+}
+
 func (r *InstanceGroupReconciler) spotEventReconciler(obj client.Object) []ctrl.Request {
 	unstructuredObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 	if err != nil {
@@ -306,15 +425,17 @@ func (r *InstanceGroupReconciler) spotEventReconciler(obj client.Object) []ctrl.
 		return nil
 	}
 
-	tags, err := awsprovider.GetScalingGroupTagsByName(involvedObjectName, r.Auth.Aws.AsgClient)
-	if err != nil {
-		return nil
+	var instanceGroup types.NamespacedName
+	for _, worker := range r.scalingGroupWorkers() {
+		tags, err := worker.GetScalingGroupTagsByName(involvedObjectName)
+		if err != nil {
+			continue
+		}
+		if instanceGroup = instanceGroupFromTags(tags); instanceGroup.Name != "" {
+			break
+		}
 	}
-
-	instanceGroup := types.NamespacedName{}
-	instanceGroup.Name = awsprovider.GetTagValueByKey(tags, provisioners.TagInstanceGroupName)
-	instanceGroup.Namespace = awsprovider.GetTagValueByKey(tags, provisioners.TagInstanceGroupNamespace)
-	if instanceGroup.Name == "" || instanceGroup.Namespace == "" {
+	if instanceGroup.Name == "" {
 		return nil
 	}
 
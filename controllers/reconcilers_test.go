@@ -18,13 +18,21 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	v1alpha1 "github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
 	"github.com/keikoproj/instance-manager/controllers/common"
+	awsprovider "github.com/keikoproj/instance-manager/controllers/providers/aws"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
@@ -113,6 +121,96 @@ func TestNodeReconciler(t *testing.T) {
 	}
 }
 
+type nodeConditionAsgClient struct {
+	autoscalingiface.AutoScalingAPI
+	instances []*autoscaling.InstanceDetails
+	groups    []*autoscaling.Group
+}
+
+func (c *nodeConditionAsgClient) DescribeAutoScalingInstances(input *autoscaling.DescribeAutoScalingInstancesInput) (*autoscaling.DescribeAutoScalingInstancesOutput, error) {
+	return &autoscaling.DescribeAutoScalingInstancesOutput{AutoScalingInstances: c.instances}, nil
+}
+
+func (c *nodeConditionAsgClient) DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAutoScalingGroupsInput, callback func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool) error {
+	out := &autoscaling.DescribeAutoScalingGroupsOutput{}
+	for _, group := range c.groups {
+		for _, name := range input.AutoScalingGroupNames {
+			if aws.StringValue(name) == aws.StringValue(group.AutoScalingGroupName) {
+				out.AutoScalingGroups = append(out.AutoScalingGroups, group)
+			}
+		}
+	}
+	callback(out, true)
+	return nil
+}
+
+func mockReadyNode(ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-node",
+		},
+		Spec: corev1.NodeSpec{
+			ProviderID: "aws:///us-west-2a/i-123456789",
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+		},
+	}
+}
+
+func TestNodeReadyConditionChanged(t *testing.T) {
+	p := NodeReadyConditionChanged()
+	ready := mockReadyNode(corev1.ConditionTrue)
+	notReady := mockReadyNode(corev1.ConditionFalse)
+	unknown := mockReadyNode(corev1.ConditionUnknown)
+
+	if !p.Update(event.UpdateEvent{ObjectOld: ready, ObjectNew: notReady}) {
+		t.Errorf("Expected node becoming NotReady to be reconciled")
+	}
+	if !p.Update(event.UpdateEvent{ObjectOld: unknown, ObjectNew: ready}) {
+		t.Errorf("Expected node becoming Ready to be reconciled")
+	}
+	if p.Update(event.UpdateEvent{ObjectOld: notReady, ObjectNew: unknown}) {
+		t.Errorf("Expected node staying NotReady not to be reconciled")
+	}
+	if p.Create(event.CreateEvent{Object: ready}) || p.Delete(event.DeleteEvent{Object: ready}) {
+		t.Errorf("Expected node creates and deletes not to be reconciled")
+	}
+}
+
+func TestNodeConditionReconciler(t *testing.T) {
+	asgClient := &nodeConditionAsgClient{
+		instances: []*autoscaling.InstanceDetails{
+			{InstanceId: aws.String("i-123456789"), AutoScalingGroupName: aws.String("my-asg")},
+		},
+		groups: []*autoscaling.Group{
+			{
+				AutoScalingGroupName: aws.String("my-asg"),
+				Tags: []*autoscaling.TagDescription{
+					{Key: aws.String(provisioners.TagInstanceGroupName), Value: aws.String("test-ig")},
+					{Key: aws.String(provisioners.TagInstanceGroupNamespace), Value: aws.String("default")},
+				},
+			},
+		},
+	}
+
+	reconciler := createTestReconciler()
+	reconciler.Auth = &InstanceGroupAuthenticator{
+		Aws: awsprovider.AwsWorker{AsgClient: asgClient},
+	}
+
+	requests := reconciler.nodeConditionReconciler(mockReadyNode(corev1.ConditionFalse))
+	if len(requests) != 1 || requests[0].NamespacedName != (types.NamespacedName{Namespace: "default", Name: "test-ig"}) {
+		t.Errorf("Expected a request for default/test-ig, got %v", requests)
+	}
+
+	// nodes whose instances do not belong to a scaling group are ignored
+	asgClient.instances = nil
+	if requests = reconciler.nodeConditionReconciler(mockReadyNode(corev1.ConditionFalse)); len(requests) != 0 {
+		t.Errorf("Expected 0 requests, got %d", len(requests))
+	}
+}
+
 func TestConfigMapReconciler(t *testing.T) {
 	// Create a test configmap
 	cm := &corev1.ConfigMap{
@@ -154,3 +252,89 @@ func TestNamespaceReconciler(t *testing.T) {
 		t.Errorf("Expected 0 requests, got %d", len(requests))
 	}
 }
+
+func TestGetAwsWorker(t *testing.T) {
+	roleArn := "arn:aws:iam::123456789012:role/instance-manager"
+	workers := awsprovider.NewAwsWorkers("us-west-2", 3, nil, nil)
+	workers.NewCredentials = func(arn string) *credentials.Credentials {
+		return credentials.NewStaticCredentials(arn, "secret", "")
+	}
+	auth := &InstanceGroupAuthenticator{Aws: awsprovider.AwsWorker{}, AwsWorkers: workers}
+
+	mockInstanceGroup := func(region, assumeRoleArn string) *v1alpha1.InstanceGroup {
+		return &v1alpha1.InstanceGroup{
+			Spec: v1alpha1.InstanceGroupSpec{
+				EKSSpec: &v1alpha1.EKSSpec{
+					EKSConfiguration: &v1alpha1.EKSConfiguration{Region: region, AssumeRoleArn: assumeRoleArn},
+				},
+			},
+		}
+	}
+
+	// instance groups in the controller's region without a role use the controller's worker
+	for _, region := range []string{"", "us-west-2"} {
+		worker, err := auth.GetAwsWorker(mockInstanceGroup(region, ""))
+		if err != nil || worker.AsgClient != nil {
+			t.Errorf("Expected controller worker for region %q, got %v, %v", region, worker, err)
+		}
+	}
+
+	worker, err := auth.GetAwsWorker(mockInstanceGroup("", roleArn))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	value, err := worker.AsgClient.(*autoscaling.AutoScaling).Config.Credentials.Get()
+	if err != nil || value.AccessKeyID != roleArn {
+		t.Errorf("Expected assumed role credentials of %v, got %v, %v", roleArn, value.AccessKeyID, err)
+	}
+
+	worker, err = auth.GetAwsWorker(mockInstanceGroup("eu-west-1", ""))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if region := aws.StringValue(worker.AsgClient.(*autoscaling.AutoScaling).Config.Region); region != "eu-west-1" {
+		t.Errorf("Expected worker in region eu-west-1, got %v", region)
+	}
+
+	auth.AwsWorkers = nil
+	if _, err := auth.GetAwsWorker(mockInstanceGroup("", roleArn)); err == nil {
+		t.Errorf("Expected error when workers are not configured")
+	}
+}
+
+func TestScalingGroupWorkers(t *testing.T) {
+	roleArn := "arn:aws:iam::123456789012:role/instance-manager"
+	workers := awsprovider.NewAwsWorkers("us-west-2", 3, nil, nil)
+	workers.NewCredentials = func(arn string) *credentials.Credentials {
+		return credentials.NewStaticCredentials(arn, "secret", "")
+	}
+
+	mockInstanceGroup := func(name, region, assumeRoleArn string) *v1alpha1.InstanceGroup {
+		return &v1alpha1.InstanceGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1alpha1.InstanceGroupSpec{
+				EKSSpec: &v1alpha1.EKSSpec{
+					EKSConfiguration: &v1alpha1.EKSConfiguration{Region: region, AssumeRoleArn: assumeRoleArn},
+				},
+			},
+		}
+	}
+
+	reconciler := createTestReconciler(
+		mockInstanceGroup("ig-1", "", ""),
+		mockInstanceGroup("ig-2", "us-west-2", ""),
+		mockInstanceGroup("ig-3", "", roleArn),
+		mockInstanceGroup("ig-4", "", roleArn),
+		mockInstanceGroup("ig-5", "eu-west-1", ""),
+	)
+	reconciler.Auth = &InstanceGroupAuthenticator{Aws: awsprovider.AwsWorker{}, AwsWorkers: workers}
+
+	// the controller's worker is followed by one worker per assumed role and region
+	scalingGroupWorkers := reconciler.scalingGroupWorkers()
+	if len(scalingGroupWorkers) != 3 {
+		t.Fatalf("Expected 3 workers, got %d", len(scalingGroupWorkers))
+	}
+	if scalingGroupWorkers[0].AsgClient != nil {
+		t.Errorf("Expected the controller's worker first, got %v", scalingGroupWorkers[0])
+	}
+}
@@ -18,9 +18,13 @@ package main
 import (
 	"context"
 	"flag"
+	"net/http"
 	"os"
 	stdruntime "runtime"
 	"sync"
+	"time"
+	// embed the time zone database, maintenance windows are evaluated in their configured time zone
+	_ "time/tzdata"
 
 	"github.com/keikoproj/aws-sdk-go-cache/cache"
 	instancemgrv1alpha1 "github.com/keikoproj/instance-manager/api/instancemgr/v1alpha1"
@@ -28,6 +32,7 @@ import (
 	"github.com/keikoproj/instance-manager/controllers/common"
 	"github.com/keikoproj/instance-manager/controllers/providers/aws"
 	kubeprovider "github.com/keikoproj/instance-manager/controllers/providers/kubernetes"
+	"github.com/keikoproj/instance-manager/controllers/provisioners"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -74,12 +79,32 @@ func main() {
 		spotRecommendationTime      float64
 		enableLeaderElection        bool
 		nodeRelabel                 bool
+		watchNodeConditions         bool
+		enableAutoscalers           bool
 		disableWinClusterInjection  bool
 		maxParallel                 int
 		maxAPIRetries               int
 		configRetention             int
 		err                         error
 		defaultScalingConfiguration string
+		requeueIntervals            string
+		userDataValidationURL       string
+		userDataValidationTimeout   time.Duration
+		userDataValidationFailOpen  bool
+		userDataDebugEndpoint       bool
+		stateDebugEndpoint          bool
+		instanceProfileTimeout      time.Duration
+		pricingTableFile            string
+		resourcePrefixTemplate      string
+		preRotationWebhookURL       string
+		postRotationWebhookURL      string
+		rotationWebhookTimeout      time.Duration
+		rotationWebhookFailOpen     bool
+		describeBatchWindow         time.Duration
+		describeBatchSize           int
+		awsCallTimeout              time.Duration
+		osFamilyManagedPolicies     string
+		crossAccountAuthMappings    bool
 	)
 
 	flag.IntVar(&maxParallel, "max-workers", 5, "The number of maximum parallel reconciles")
@@ -91,14 +116,70 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&nodeRelabel, "node-relabel", true, "relabel nodes as they join with kubernetes.io/role label via controller")
+	flag.BoolVar(&watchNodeConditions, "watch-node-conditions", false, "Setting this to true will reconcile the instance group of a node when its ready condition changes, so nodes which stay NotReady are replaced without waiting for the next reconcile")
+	flag.BoolVar(&enableAutoscalers, "enable-instance-group-autoscalers", false, "Setting this to true will scale the desired capacity of instance groups targeted by an InstanceGroupAutoscaler on its metric, the InstanceGroupAutoscaler CRD must be installed")
 	flag.BoolVar(&disableWinClusterInjection, "disable-windows-cluster-ca-injection", false, "Setting this to true will cause the ClusterCA and Endpoint to not be injected for Windows nodes")
 	flag.StringVar(&defaultScalingConfiguration, "default-scaling-configuration", "LaunchTemplate", "By default ASGs will have LaunchTemplate. Set this string to either 'LaunchConfiguration' or 'LaunchTemplate' to enforce defaults.")
+	flag.StringVar(&requeueIntervals, "requeue-intervals", "", "Comma separated list of state=duration pairs overriding the default 10s requeue interval of a reconcile state, e.g. 'ReconcileModifying=5s,InitUpgrade=30s'")
+	flag.StringVar(&userDataValidationURL, "userdata-validation-url", "", "The URL of an endpoint that must approve the rendered userdata of an instance group before it is rolled out")
+	flag.DurationVar(&userDataValidationTimeout, "userdata-validation-timeout", provisioners.DefaultUserDataValidationTimeout, "The timeout for requests to the userdata validation endpoint")
+	flag.BoolVar(&userDataValidationFailOpen, "userdata-validation-fail-open", false, "Setting this to true will allow rollouts to proceed when the userdata validation endpoint cannot be reached")
+	flag.BoolVar(&userDataDebugEndpoint, "userdata-debug-endpoint", false, "Setting this to true will serve the last rendered userdata of each instance group, with sensitive values redacted, on the metrics server under "+provisioners.UserDataDebugPath+"<namespace>/<name>")
+	flag.BoolVar(&stateDebugEndpoint, "state-debug-endpoint", false, "Setting this to true will serve the discovered state of each instance group from its last reconcile, without sensitive fields, on the metrics server under "+provisioners.StateDebugPath+"<namespace>/<name>")
+	flag.DurationVar(&instanceProfileTimeout, "instance-profile-propagation-timeout", aws.DefaultInstanceProfilePropagationTimeout, "The time after creating an instance profile during which launches rejected for an invalid instance profile are requeued instead of failing")
+	flag.StringVar(&pricingTableFile, "pricing-table-file", "", "The path of a JSON file mapping instance types to hourly onDemand and spot prices, which override the bundled prices used for cost estimates")
+	flag.StringVar(&resourcePrefixTemplate, "resource-prefix-template", provisioners.DefaultResourcePrefixTemplate, "The template the AWS resources of instance groups are named with, it can reference {{ .ClusterName }}, {{ .Namespace }} and {{ .Name }}, prefixes longer than 113 characters are truncated with a hash")
+	flag.StringVar(&preRotationWebhookURL, "pre-rotation-webhook-url", "", "The URL of an endpoint that must approve node rotations of an instance group before they begin")
+	flag.StringVar(&postRotationWebhookURL, "post-rotation-webhook-url", "", "The URL of an endpoint that is notified after node rotations of an instance group complete")
+	flag.DurationVar(&rotationWebhookTimeout, "rotation-webhook-timeout", provisioners.DefaultRotationWebhookTimeout, "The timeout for requests to the rotation webhook endpoints")
+	flag.BoolVar(&rotationWebhookFailOpen, "rotation-webhook-fail-open", false, "Setting this to true will allow rotations to begin when the pre-rotation webhook endpoint cannot be reached")
+	flag.DurationVar(&describeBatchWindow, "describe-batch-window", aws.DefaultDescribeBatchWindow, "The time during which the scaling group and launch template describes of concurrent reconciles are collected and described together, setting this to 0 disables batching")
+	flag.IntVar(&describeBatchSize, "describe-batch-size", 0, "The maximum number of resources described by name in a single batched call, defaults to and is capped at the AWS limit of the API")
+	flag.DurationVar(&awsCallTimeout, "aws-call-timeout", aws.DefaultCallTimeout, "The deadline of AWS API calls including their retries, reconciles whose calls exceed it are requeued, instance groups can override it with awsCallTimeout and setting this to 0 disables the default deadline")
+	flag.BoolVar(&crossAccountAuthMappings, "allow-cross-account-auth-mappings", false, "Setting this to true will allow the authMappings of instance groups to map IAM roles and users of accounts other than the cluster's account")
+	flag.StringVar(&osFamilyManagedPolicies, "os-family-managed-policies", "", "Semicolon separated list of family=policies pairs replacing the default managed policies of the managed roles of an OS family's instance groups, where policies is a comma separated list of policy names or ARNs, e.g. 'windows=AmazonEKSWorkerNodePolicy,AmazonEC2ContainerRegistryReadOnly,AmazonSSMManagedInstanceCore'")
 	flag.Parse()
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
+	reconcileRequeueIntervals, err := provisioners.ParseRequeueIntervals(requeueIntervals)
+	if err != nil {
+		setupLog.Error(err, "invalid requeue intervals")
+		os.Exit(1)
+	}
+
+	managedPolicies, err := provisioners.ParseOsFamilyManagedPolicies(osFamilyManagedPolicies)
+	if err != nil {
+		setupLog.Error(err, "invalid os family managed policies")
+		os.Exit(1)
+	}
+
+	prefixTemplate, err := provisioners.ParseResourcePrefixTemplate(resourcePrefixTemplate)
+	if err != nil {
+		setupLog.Error(err, "invalid resource prefix template")
+		os.Exit(1)
+	}
+
+	pricingTable := aws.DefaultPricingTable
+	if pricingTableFile != "" {
+		if pricingTable, err = aws.LoadPricingTable(pricingTableFile); err != nil {
+			setupLog.Error(err, "invalid pricing table")
+			os.Exit(1)
+		}
+	}
+
+	userDataExporter := provisioners.NewUserDataExporter(userDataDebugEndpoint)
+	stateExporter := provisioners.NewStateExporter(stateDebugEndpoint)
+	metricsOptions := server.Options{BindAddress: metricsAddr, ExtraHandlers: map[string]http.Handler{}}
+	if userDataExporter != nil {
+		metricsOptions.ExtraHandlers[provisioners.UserDataDebugPath] = userDataExporter
+	}
+	if stateExporter != nil {
+		metricsOptions.ExtraHandlers[provisioners.StateDebugPath] = stateExporter
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:         scheme,
-		Metrics:        server.Options{BindAddress: metricsAddr},
+		Metrics:        metricsOptions,
 		LeaderElection: enableLeaderElection,
 	})
 	if err != nil {
@@ -129,13 +210,19 @@ func main() {
 	cacheCollector := cacheCfg.NewCacheCollector("instance_manager")
 	controllerCollector := common.NewMetricsCollector()
 	awsWorker := aws.AwsWorker{
-		Ec2Client:   aws.GetAwsEc2Client(awsRegion, cacheCfg, maxAPIRetries, controllerCollector),
-		IamClient:   aws.GetAwsIamClient(awsRegion, cacheCfg, maxAPIRetries, controllerCollector),
-		AsgClient:   aws.GetAwsAsgClient(awsRegion, cacheCfg, maxAPIRetries, controllerCollector),
-		EksClient:   aws.GetAwsEksClient(awsRegion, cacheCfg, maxAPIRetries, controllerCollector),
-		SsmClient:   aws.GetAwsSsmClient(awsRegion, cacheCfg, maxAPIRetries, controllerCollector),
+		Ec2Client:   aws.GetAwsEc2Client(awsRegion, nil, cacheCfg, maxAPIRetries, controllerCollector),
+		IamClient:   aws.GetAwsIamClient(awsRegion, nil, cacheCfg, maxAPIRetries, controllerCollector),
+		AsgClient:   aws.GetAwsAsgClient(awsRegion, nil, cacheCfg, maxAPIRetries, controllerCollector),
+		EksClient:   aws.GetAwsEksClient(awsRegion, nil, cacheCfg, maxAPIRetries, controllerCollector),
+		SsmClient:   aws.GetAwsSsmClient(awsRegion, nil, cacheCfg, maxAPIRetries, controllerCollector),
 		Ec2Metadata: metadata,
 	}
+	awsWorker = awsWorker.WithCallTimeout(awsCallTimeout)
+	awsWorker.EnableDescribeBatching(describeBatchWindow, describeBatchSize)
+	awsWorkers := aws.NewAwsWorkers(awsRegion, maxAPIRetries, controllerCollector, metadata)
+	awsWorkers.CallTimeout = awsCallTimeout
+	awsWorkers.DescribeBatchWindow = describeBatchWindow
+	awsWorkers.DescribeBatchSize = describeBatchSize
 
 	prometheus.MustRegister(cacheCollector, controllerCollector)
 	kube := kubeprovider.KubernetesClientSet{
@@ -154,23 +241,39 @@ func main() {
 		setupLog.Info("instance-manager configmap does not exist, will not load defaults/boundaries")
 	}
 
+	userDataValidator := provisioners.NewUserDataValidator(userDataValidationURL, userDataValidationTimeout, userDataValidationFailOpen, ctrl.Log.WithName("controllers").WithName("userdata-validator"))
+	rotationNotifier := provisioners.NewRotationNotifier(preRotationWebhookURL, postRotationWebhookURL, rotationWebhookTimeout, rotationWebhookFailOpen, ctrl.Log.WithName("controllers").WithName("rotation-notifier"))
 	defaultScalingConfigurationType := instancemgrv1alpha1.ScalingConfigurationType(defaultScalingConfiguration)
 	err = (&controllers.InstanceGroupReconciler{
-		Metrics:                     controllerCollector,
-		ConfigMap:                   cm,
-		ConfigRetention:             configRetention,
-		SpotRecommendationTime:      spotRecommendationTime,
-		ConfigNamespace:             configNamespace,
-		Namespaces:                  make(map[string]corev1.Namespace),
-		NamespacesLock:              &sync.RWMutex{},
-		NodeRelabel:                 nodeRelabel,
-		DisableWinClusterInjection:  disableWinClusterInjection,
-		Client:                      mgr.GetClient(),
-		Log:                         ctrl.Log.WithName("controllers").WithName("instancegroup"),
-		MaxParallel:                 maxParallel,
-		DefaultScalingConfiguration: &defaultScalingConfigurationType,
+		Metrics:                           controllerCollector,
+		ConfigMap:                         cm,
+		ConfigRetention:                   configRetention,
+		SpotRecommendationTime:            spotRecommendationTime,
+		ConfigNamespace:                   configNamespace,
+		Namespaces:                        make(map[string]corev1.Namespace),
+		NamespacesLock:                    &sync.RWMutex{},
+		NodeRelabel:                       nodeRelabel,
+		WatchNodeConditions:               watchNodeConditions,
+		AutoscalersEnabled:                enableAutoscalers,
+		DisableWinClusterInjection:        disableWinClusterInjection,
+		Client:                            mgr.GetClient(),
+		Log:                               ctrl.Log.WithName("controllers").WithName("instancegroup"),
+		MaxParallel:                       maxParallel,
+		DefaultScalingConfiguration:       &defaultScalingConfigurationType,
+		RequeueIntervals:                  reconcileRequeueIntervals,
+		UserDataValidator:                 userDataValidator,
+		UserDataExporter:                  userDataExporter,
+		StateExporter:                     stateExporter,
+		InstanceProfilePropagationTimeout: instanceProfileTimeout,
+		PricingTable:                      pricingTable,
+		ResourcePrefixTemplate:            prefixTemplate,
+		RotationNotifier:                  rotationNotifier,
+		AwsCallTimeout:                    awsCallTimeout,
+		OsFamilyManagedPolicies:           managedPolicies,
+		CrossAccountAuthMappings:          crossAccountAuthMappings,
 		Auth: &controllers.InstanceGroupAuthenticator{
 			Aws:        awsWorker,
+			AwsWorkers: awsWorkers,
 			Kubernetes: kube,
 		},
 	}).SetupWithManager(mgr)